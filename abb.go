@@ -0,0 +1,193 @@
+package adb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Packet ids used by the shell protocol, which frames the abb: service.
+const (
+	shellProtocolStdout = 1
+	shellProtocolStderr = 2
+	shellProtocolExit   = 3
+)
+
+/*
+RunAbb runs a binder command (the equivalent of cmd <service> <args>) through the Android
+Binder Bridge and returns its output. Arguments are passed to the service unmodified, without
+going through the device shell. Devices that don't support abb fall back to cmd.
+
+Corresponds to the command:
+
+	adb shell cmd <service> <args>
+*/
+func (c *Device) RunAbb(service string, args ...string) (string, error) {
+	output, err := c.runAbb(service, args...)
+	return output, wrapClientError(err, c, "RunAbb(%s)", service)
+}
+
+func (c *Device) runAbb(service string, args ...string) (string, error) {
+	features, err := c.features()
+	if err != nil {
+		return "", err
+	}
+	cmdArgs := append([]string{service}, args...)
+
+	switch {
+	case containsString(features, FeatureAbbExec):
+		conn, err := c.openService(abbService("abb_exec:", cmdArgs))
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		output, err := ioutil.ReadAll(conn)
+		return string(output), err
+
+	case containsString(features, FeatureAbb):
+		conn, err := c.openService(abbService("abb:", cmdArgs))
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		stdout, stderr, exitCode, err := readShellProtocol(conn)
+		if err != nil {
+			return "", err
+		}
+		if exitCode != 0 {
+			return stdout, errors.Errorf(errors.AdbError, "cmd %s exited with code %d: %s",
+				service, exitCode, strings.TrimSpace(stderr))
+		}
+		return stdout + stderr, nil
+
+	default:
+		return c.runShellCommand(quoteShellArgs(append([]string{"cmd"}, cmdArgs...)...))
+	}
+}
+
+/*
+InstallAppStream installs the APK read from r, which must return exactly size bytes,
+without copying it to the device first. The APK is streamed over abb_exec if supported,
+otherwise over exec: to cmd package. Devices without cmd (before Android 7.0) get the APK
+pushed to /data/local/tmp and installed with pm.
+
+Corresponds to the command:
+
+	adb install [-r] [-g] <apk>
+*/
+func (c *Device) InstallAppStream(r io.Reader, size int64, reinstall bool, grantPermission bool) error {
+	err := c.installAppStream(r, size, reinstall, grantPermission)
+	return wrapClientError(err, c, "InstallAppStream")
+}
+
+func (c *Device) installAppStream(r io.Reader, size int64, reinstall bool, grantPermission bool) error {
+	features, err := c.features()
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"package", "install"}, c.userArgs()...)
+	if reinstall {
+		args = append(args, "-r")
+	}
+	if grantPermission {
+		args = append(args, "-g")
+	}
+
+	var service string
+	switch {
+	case containsString(features, FeatureAbbExec):
+		service = abbService("abb_exec:", append(args, "-S", strconv.FormatInt(size, 10)))
+	case containsString(features, FeatureCmd):
+		service = "exec:cmd " + quoteShellArgs(append(args, "-S", strconv.FormatInt(size, 10))...)
+	default:
+		return c.installAppByPush(r, args[2:])
+	}
+
+	conn, err := c.openService(service)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = io.CopyN(conn, r, size); err != nil {
+		return errors.WrapErrorf(err, errors.NetworkError, "error streaming APK")
+	}
+	output, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return err
+	}
+	return installOutputError(string(output))
+}
+
+// installAppByPush pushes the APK to a temporary file and installs it with pm install.
+func (c *Device) installAppByPush(r io.Reader, options []string) error {
+	remotePath := fmt.Sprintf("/data/local/tmp/goadb-%d.apk", time.Now().UnixNano())
+	writer, err := c.OpenWrite(remotePath, 0644, MtimeOfClose)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(writer, r); err != nil {
+		writer.Close()
+		return errors.WrapErrorf(err, errors.NetworkError, "error pushing APK")
+	}
+	if err = writer.Close(); err != nil {
+		return errors.WrapErrorf(err, errors.NetworkError, "error pushing APK")
+	}
+	defer c.runShellCommand(quoteShellArgs("rm", "-f", remotePath))
+
+	output, err := c.runShellCommand(quoteShellArgs(append(append([]string{"pm", "install"}, options...), remotePath)...))
+	if err != nil {
+		return err
+	}
+	return installOutputError(output)
+}
+
+// installOutputError returns an error unless the output of an install reports Success.
+func installOutputError(output string) error {
+	output = strings.TrimSpace(output)
+	if strings.Contains(output, "Success") {
+		return nil
+	}
+	return errors.Errorf(errors.AdbError, "install failed: %s", output)
+}
+
+// abbService returns the abb or abb_exec service request for args, which are separated by
+// null bytes instead of being parsed by a shell.
+func abbService(prefix string, args []string) string {
+	return prefix + strings.Join(args, "\x00")
+}
+
+// readShellProtocol reads shell protocol packets until the exit packet, and returns the
+// collected stdout and stderr and the exit code.
+func readShellProtocol(r io.Reader) (stdout, stderr string, exitCode int, err error) {
+	var out, errOut strings.Builder
+	header := make([]byte, 5)
+	for {
+		if _, err = io.ReadFull(r, header); err != nil {
+			return "", "", 0, errors.WrapErrorf(err, errors.NetworkError, "error reading shell protocol packet")
+		}
+		data := make([]byte, binary.LittleEndian.Uint32(header[1:]))
+		if _, err = io.ReadFull(r, data); err != nil {
+			return "", "", 0, errors.WrapErrorf(err, errors.NetworkError, "error reading shell protocol packet")
+		}
+
+		switch header[0] {
+		case shellProtocolStdout:
+			out.Write(data)
+		case shellProtocolStderr:
+			errOut.Write(data)
+		case shellProtocolExit:
+			if len(data) > 0 {
+				exitCode = int(data[0])
+			}
+			return out.String(), errOut.String(), exitCode, nil
+		}
+	}
+}
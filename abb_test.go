@@ -0,0 +1,99 @@
+package adb
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseFeatures(t *testing.T) {
+	assert.Equal(t, []string{"shell_v2", "cmd", "abb_exec"}, parseFeatures("shell_v2,cmd,abb_exec\n"))
+	assert.Empty(t, parseFeatures(""))
+}
+
+func TestRunAbbExec(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"shell_v2,cmd,abb,abb_exec", "package:com.example\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	output, err := device.RunAbb("package", "list", "packages", "com.example app")
+	assert.NoError(t, err)
+	assert.Equal(t, "package:com.example\n", output)
+	assert.Equal(t, "host:features", s.Requests[0])
+	assert.Equal(t, "abb_exec:package\x00list\x00packages\x00com.example app", s.Requests[2])
+}
+
+func TestRunAbbShellProtocol(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Messages: []string{"shell_v2,abb",
+			"\x01\x05\x00\x00\x00hello\x02\x04\x00\x00\x00 err\x03\x01\x00\x00\x00\x00"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	output, err := device.RunAbb("package", "path", "com.example")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello err", output)
+	assert.Equal(t, "abb:package\x00path\x00com.example", s.Requests[2])
+}
+
+func TestRunAbbFallsBackToCmd(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"shell_v2", "output"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	output, err := device.RunAbb("package", "list", "packages")
+	assert.NoError(t, err)
+	assert.Equal(t, "output", output)
+	assert.Equal(t, "shell:cmd package list packages", s.Requests[2])
+}
+
+func TestRunAbbOnOldServer(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"output"},
+		Version:  32,
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.Features()
+	assert.True(t, stderrors.Is(err, ErrUnsupportedServer), "%v", err)
+	assert.Empty(t, s.Requests)
+
+	output, err := device.RunAbb("package", "list", "packages")
+	assert.NoError(t, err)
+	assert.Equal(t, "output", output)
+	assert.Equal(t, []string{"host:transport-any", "shell:cmd package list packages"}, s.Requests)
+}
+
+func TestInstallAppStream(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"abb_exec", "Success\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice()).ForUser(10)
+
+	err := device.InstallAppStream(strings.NewReader("apk data"), 8, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "abb_exec:package\x00install\x00--user\x0010\x00-r\x00-S\x008", s.Requests[2])
+	assert.Equal(t, "apk data", s.Requests[3])
+}
+
+func TestInstallAppStreamFailure(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"cmd", "Failure [INSTALL_FAILED_INVALID_APK]\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	err := device.InstallAppStream(strings.NewReader("apk data"), 8, false, false)
+	assert.True(t, HasErrCode(err, AdbError))
+	assert.Equal(t, "exec:cmd package install -S 8", s.Requests[2])
+}
@@ -0,0 +1,123 @@
+package adb
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Activity identifies an activity component running on the device.
+type Activity struct {
+	Package string
+	// Fully-qualified class name of the activity, e.g. com.example.app.MainActivity.
+	Activity string
+}
+
+// Component returns the activity in the package/class form accepted by am start -n.
+func (a Activity) Component() string {
+	return a.Package + "/" + a.Activity
+}
+
+func (a Activity) String() string {
+	return a.Component()
+}
+
+var (
+	// Matches a package/activity component name, e.g. com.example/.MainActivity.
+	componentPattern = regexp.MustCompile(`([A-Za-z0-9_.]+)/([A-Za-z0-9_.$]+)`)
+
+	// Focus lines printed by dumpsys window, in order of preference across Android versions:
+	// mCurrentFocus has been present since at least API 16, mFocusedApp wraps an
+	// AppWindowToken or ActivityRecord.
+	windowFocusPrefixes = []string{"mCurrentFocus=", "mFocusedApp="}
+
+	// Resumed activity lines printed by dumpsys activity activities. API 29+ prints
+	// "ResumedActivity:", older versions "mResumedActivity:" or "mFocusedActivity:".
+	resumedActivityPrefixes = []string{"mResumedActivity:", "ResumedActivity:", "mFocusedActivity:"}
+)
+
+/*
+CurrentActivity returns the activity that currently holds input focus.
+
+The focused window is read from dumpsys window windows first. If the focused window
+doesn't belong to an activity (e.g. the status bar or a system dialog is focused), the
+resumed activity from dumpsys activity, and finally the last activity listed by
+dumpsys activity top, are used instead.
+*/
+func (c *Device) CurrentActivity() (*Activity, error) {
+	output, err := c.RunCommand("dumpsys", "window", "windows")
+	if err != nil {
+		return nil, wrapClientError(err, c, "CurrentActivity")
+	}
+	if activity, ok := parseFocusedActivity(output, windowFocusPrefixes); ok {
+		return activity, nil
+	}
+
+	output, err = c.RunCommand("dumpsys", "activity", "activities")
+	if err != nil {
+		return nil, wrapClientError(err, c, "CurrentActivity")
+	}
+	if activity, ok := parseFocusedActivity(output, resumedActivityPrefixes); ok {
+		return activity, nil
+	}
+
+	output, err = c.RunCommand("dumpsys", "activity", "top")
+	if err != nil {
+		return nil, wrapClientError(err, c, "CurrentActivity")
+	}
+	activity, err := parseTopActivity(output)
+	return activity, wrapClientError(err, c, "CurrentActivity")
+}
+
+// parseFocusedActivity returns the component from the first line starting with one of
+// prefixes (after trimming indentation) that contains a component name.
+func parseFocusedActivity(output string, prefixes []string) (*Activity, bool) {
+	lines := strings.Split(output, "\n")
+	for _, prefix := range prefixes {
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, prefix) {
+				continue
+			}
+			if activity, ok := parseComponent(line[len(prefix):]); ok {
+				return activity, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseTopActivity parses the output of dumpsys activity top, which contains one
+// "ACTIVITY <component> <hash> pid=<pid>" line per visible task. The focused task is listed last.
+func parseTopActivity(output string) (*Activity, error) {
+	var activity *Activity
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "ACTIVITY" {
+			continue
+		}
+		if parsed, ok := parseComponent(fields[1]); ok {
+			activity = parsed
+		}
+	}
+	if activity == nil {
+		return nil, errors.Errorf(errors.ParseError, "no activity found in dumpsys output")
+	}
+	return activity, nil
+}
+
+// parseComponent finds a package/class component in s and expands a class name
+// relative to the package (e.g. ".MainActivity") into its fully-qualified form.
+func parseComponent(s string) (*Activity, bool) {
+	match := componentPattern.FindStringSubmatch(s)
+	if match == nil {
+		return nil, false
+	}
+
+	pkg, class := match[1], match[2]
+	if strings.HasPrefix(class, ".") {
+		class = pkg + class
+	}
+	return &Activity{Package: pkg, Activity: class}, true
+}
@@ -0,0 +1,55 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFocusedActivityWindow(t *testing.T) {
+	for _, test := range []struct {
+		Name   string
+		Output string
+		Want   *Activity
+	}{
+		{"current focus", `
+  mCurrentFocus=Window{4b2d1a8 u0 com.android.settings/com.android.settings.Settings}
+  mFocusedApp=AppWindowToken{8e1f token=Token{3a ActivityRecord{7c u0 com.android.settings/.Settings t12}}}`,
+			&Activity{"com.android.settings", "com.android.settings.Settings"}},
+		{"relative class name", `
+  mFocusedApp=ActivityRecord{a9d55e7 u0 com.example/.ui.MainActivity t31}`,
+			&Activity{"com.example", "com.example.ui.MainActivity"}},
+		{"non-activity window falls back to focused app", `
+  mCurrentFocus=Window{4b2d1a8 u0 StatusBar}
+  mFocusedApp=AppWindowToken{8e1f token=Token{3a ActivityRecord{7c u0 com.example/.Main t12}}}`,
+			&Activity{"com.example", "com.example.Main"}},
+		{"no focus", `
+  mCurrentFocus=null
+  mFocusedApp=null`,
+			nil},
+	} {
+		activity, ok := parseFocusedActivity(test.Output, windowFocusPrefixes)
+		assert.Equal(t, test.Want != nil, ok, test.Name)
+		assert.Equal(t, test.Want, activity, test.Name)
+	}
+}
+
+func TestParseFocusedActivityResumed(t *testing.T) {
+	activity, ok := parseFocusedActivity(`
+    ResumedActivity: ActivityRecord{d2b1c u0 com.example/com.example.Main$Inner t5}`, resumedActivityPrefixes)
+	assert.True(t, ok)
+	assert.Equal(t, &Activity{"com.example", "com.example.Main$Inner"}, activity)
+}
+
+func TestParseTopActivity(t *testing.T) {
+	activity, err := parseTopActivity(`TASK com.android.launcher3 id=1 userId=0
+  ACTIVITY com.android.launcher3/.Launcher 8a1b pid=1090
+TASK com.example id=7 userId=0
+  ACTIVITY com.example/.Main 2c0d pid=4242
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "com.example/com.example.Main", activity.Component())
+
+	_, err = parseTopActivity("")
+	assert.EqualError(t, err, "ParseError: no activity found in dumpsys output")
+}
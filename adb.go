@@ -3,9 +3,7 @@ package adb
 import (
 	"context"
 	"fmt"
-	"strconv"
 
-	"github.com/zach-klippenstein/goadb/internal/errors"
 	"github.com/zach-klippenstein/goadb/wire"
 )
 
@@ -16,6 +14,12 @@ Eg.
 	client := adb.New()
 	client.ListDevices()
 
+Clients don't share state, so one process can talk to several servers at once, e.g. to merge
+the device lists of lab hosts:
+
+	lab1, err := adb.NewWithConfig(adb.ServerConfig{Host: "lab1", Port: 5037, NoServer: true})
+	lab2, err := adb.NewWithConfig(adb.ServerConfig{Host: "lab2", Port: 5037, NoServer: true})
+
 See list of services at https://android.googlesource.com/platform/system/core/+/master/adb/SERVICES.TXT.
 */
 // TODO(z): Finish implementing host services.
@@ -33,6 +37,9 @@ func NewWithConfig(config ServerConfig) (*Adb, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.Logger != nil || config.Instrumentation != nil {
+		server = observeServer(server, config.Logger, config.Instrumentation)
+	}
 	return &Adb{server}, nil
 }
 
@@ -51,6 +58,7 @@ func (c *Adb) Device(descriptor DeviceDescriptor) *Device {
 		server:         c.server,
 		descriptor:     descriptor,
 		deviceListFunc: c.ListDevices,
+		capabilities:   &capabilities{},
 	}
 }
 
@@ -67,17 +75,19 @@ func (c *Adb) NewDeviceWatcherWithCtx(ctx context.Context) *DeviceWatcher {
 	return newDeviceWatcherWitchCtx(c.server, ctx)
 }
 
-// ServerVersion asks the ADB server for its internal version number.
-func (c *Adb) ServerVersion() (int, error) {
-	resp, err := roundTripSingleResponse(c.server, "host:version")
-	if err != nil {
-		return 0, wrapClientError(err, c, "GetServerVersion")
-	}
+/*
+ServerVersion asks the ADB server for its internal version number, e.g. 41 for adb 1.0.41.
 
-	version, err := c.parseServerVersion(resp)
+Requests that need a newer server than the one running fail with ErrUnsupportedServer. The
+version they check is queried once per client, the first time it's needed, and updated by
+ServerVersion, e.g. after the server is upgraded and restarted.
+*/
+func (c *Adb) ServerVersion() (int, error) {
+	version, err := queryServerVersion(c.server)
 	if err != nil {
 		return 0, wrapClientError(err, c, "GetServerVersion")
 	}
+	setCachedServerVersion(c.server, version)
 	return version, nil
 }
 
@@ -108,7 +118,7 @@ Corresponds to the command:
 	adb devices
 */
 func (c *Adb) ListDeviceSerials() ([]string, error) {
-	resp, err := roundTripSingleResponse(c.server, "host:devices")
+	resp, err := retryRoundTrip(c.server, "host:devices")
 	if err != nil {
 		return nil, wrapClientError(err, c, "ListDeviceSerials")
 	}
@@ -126,13 +136,16 @@ func (c *Adb) ListDeviceSerials() ([]string, error) {
 }
 
 /*
-ListDevices returns the list of connected devices.
+ListDevices returns the connected devices with their state and the attributes adb reports in
+the long form: product, model, device, USB path, and transport id. It takes a single request
+to the server, so there's no need to call State or Serial on each device afterwards.
 
 Corresponds to the command:
+
 	adb devices -l
 */
 func (c *Adb) ListDevices() ([]*DeviceInfo, error) {
-	resp, err := roundTripSingleResponse(c.server, "host:devices-l")
+	resp, err := retryRoundTrip(c.server, "host:devices-l")
 	if err != nil {
 		return nil, wrapClientError(err, c, "ListDevices")
 	}
@@ -141,9 +154,28 @@ func (c *Adb) ListDevices() ([]*DeviceInfo, error) {
 	if err != nil {
 		return nil, wrapClientError(err, c, "ListDevices")
 	}
+	for _, device := range devices {
+		c.server.registry().label(device)
+	}
 	return devices, nil
 }
 
+/*
+ListForwards returns the forwards of all devices.
+
+Corresponds to the command:
+
+	adb forward --list
+*/
+func (c *Adb) ListForwards() ([]ForwardEntry, error) {
+	resp, err := retryRoundTrip(c.server, "host:list-forward")
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListForwards")
+	}
+	forwards, err := parseForwardList(string(resp))
+	return forwards, wrapClientError(err, c, "ListForwards")
+}
+
 /*
 Connect connect to a device via TCP/IP
 
@@ -157,13 +189,3 @@ func (c *Adb) Connect(host string, port int) error {
 	}
 	return nil
 }
-
-func (c *Adb) parseServerVersion(versionRaw []byte) (int, error) {
-	versionStr := string(versionRaw)
-	version, err := strconv.ParseInt(versionStr, 16, 32)
-	if err != nil {
-		return 0, errors.WrapErrorf(err, errors.ParseError,
-			"error parsing server version: %s", versionStr)
-	}
-	return int(version), nil
-}
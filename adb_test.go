@@ -30,7 +30,7 @@ func ExecCommand(commandName string, params []string) bool {
 
 	f, err := os.OpenFile("syslog.txt", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
-		log.Fatalf("capture log failed: ", err)
+		log.Fatalf("capture log failed: %v", err)
 	}
 
 	cmd := exec.Command(commandName, params...)
@@ -0,0 +1,80 @@
+package adbgrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// Dial connects to a device service at target, encoding messages with Codec.
+func Dial(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{grpc.WithDefaultCallOptions(grpc.CallContentSubtype(Codec))}, opts...)
+	return grpc.NewClient(target, opts...)
+}
+
+// Client calls a device service. The connection must encode messages with Codec, e.g. by
+// using Dial.
+type Client struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{cc: cc}
+}
+
+func (c *Client) ListDevices(ctx context.Context, req *ListDevicesRequest, opts ...grpc.CallOption) (*ListDevicesResponse, error) {
+	resp := new(ListDevicesResponse)
+	if err := c.cc.Invoke(ctx, fullMethod("ListDevices"), req, resp, opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) TrackDevices(ctx context.Context, req *TrackDevicesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeviceEvent], error) {
+	return openServerStream[TrackDevicesRequest, DeviceEvent](ctx, c.cc, trackDevicesStream, req, opts)
+}
+
+func (c *Client) Shell(ctx context.Context, req *ShellRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	return openServerStream[ShellRequest, Chunk](ctx, c.cc, shellStream, req, opts)
+}
+
+func (c *Client) Logcat(ctx context.Context, req *LogcatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	return openServerStream[LogcatRequest, Chunk](ctx, c.cc, logcatStream, req, opts)
+}
+
+func (c *Client) Pull(ctx context.Context, req *PullRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Chunk], error) {
+	return openServerStream[PullRequest, Chunk](ctx, c.cc, pullStream, req, opts)
+}
+
+func (c *Client) Push(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PushRequest, PushResponse], error) {
+	return openClientStream[PushRequest, PushResponse](ctx, c.cc, pushStream, opts)
+}
+
+func (c *Client) Install(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[InstallRequest, InstallResponse], error) {
+	return openClientStream[InstallRequest, InstallResponse](ctx, c.cc, installStream, opts)
+}
+
+func openServerStream[Req, Res any](ctx context.Context, cc grpc.ClientConnInterface, index int, req *Req, opts []grpc.CallOption) (grpc.ServerStreamingClient[Res], error) {
+	desc := &serviceDesc.Streams[index]
+	stream, err := cc.NewStream(ctx, desc, fullMethod(desc.StreamName), opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := &grpc.GenericClientStream[Req, Res]{ClientStream: stream}
+	if err := s.ClientStream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := s.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func openClientStream[Req, Res any](ctx context.Context, cc grpc.ClientConnInterface, index int, opts []grpc.CallOption) (grpc.ClientStreamingClient[Req, Res], error) {
+	desc := &serviceDesc.Streams[index]
+	stream, err := cc.NewStream(ctx, desc, fullMethod(desc.StreamName), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc.GenericClientStream[Req, Res]{ClientStream: stream}, nil
+}
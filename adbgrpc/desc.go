@@ -0,0 +1,93 @@
+package adbgrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// serviceDesc is written by hand, like the output of protoc-gen-go-grpc, since the messages
+// aren't generated from a .proto file.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*DeviceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ListDevices", Handler: listDevicesHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "TrackDevices", Handler: trackDevicesHandler, ServerStreams: true},
+		{StreamName: "Shell", Handler: shellHandler, ServerStreams: true},
+		{StreamName: "Logcat", Handler: logcatHandler, ServerStreams: true},
+		{StreamName: "Pull", Handler: pullHandler, ServerStreams: true},
+		{StreamName: "Push", Handler: pushHandler, ClientStreams: true},
+		{StreamName: "Install", Handler: installHandler, ClientStreams: true},
+	},
+}
+
+// Indices of the streams in serviceDesc.Streams.
+const (
+	trackDevicesStream = iota
+	shellStream
+	logcatStream
+	pullStream
+	pushStream
+	installStream
+)
+
+func fullMethod(name string) string {
+	return "/" + ServiceName + "/" + name
+}
+
+func listDevicesHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(ListDevicesRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServiceServer).ListDevices(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod("ListDevices")}
+	return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServiceServer).ListDevices(ctx, req.(*ListDevicesRequest))
+	})
+}
+
+func trackDevicesHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(TrackDevicesRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(DeviceServiceServer).TrackDevices(req, &grpc.GenericServerStream[TrackDevicesRequest, DeviceEvent]{ServerStream: stream})
+}
+
+func shellHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(ShellRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(DeviceServiceServer).Shell(req, &grpc.GenericServerStream[ShellRequest, Chunk]{ServerStream: stream})
+}
+
+func logcatHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(LogcatRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(DeviceServiceServer).Logcat(req, &grpc.GenericServerStream[LogcatRequest, Chunk]{ServerStream: stream})
+}
+
+func pullHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(PullRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(DeviceServiceServer).Pull(req, &grpc.GenericServerStream[PullRequest, Chunk]{ServerStream: stream})
+}
+
+func pushHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DeviceServiceServer).Push(&grpc.GenericServerStream[PushRequest, PushResponse]{ServerStream: stream})
+}
+
+func installHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DeviceServiceServer).Install(&grpc.GenericServerStream[InstallRequest, InstallResponse]{ServerStream: stream})
+}
@@ -0,0 +1,24 @@
+module github.com/zach-klippenstein/goadb/adbgrpc
+
+go 1.25.0
+
+require (
+	github.com/stretchr/testify v1.4.0
+	github.com/zach-klippenstein/goadb v0.0.0
+	google.golang.org/grpc v1.84.0
+)
+
+require (
+	github.com/cheggaaa/pb v1.0.29 // indirect
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/mattn/go-runewidth v0.0.4 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.57.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260706201446-f0a921348800 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+replace github.com/zach-klippenstein/goadb => ../
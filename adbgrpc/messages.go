@@ -0,0 +1,104 @@
+package adbgrpc
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Codec is the name of the content subtype messages are encoded with. The messages are plain
+// Go structs encoded as JSON, so no protoc step is needed; Dial sets it on every call.
+const Codec = "json"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return Codec }
+
+// Requests that take a Serial target the device with that serial, or the only attached
+// device if Serial is empty.
+
+type ListDevicesRequest struct{}
+
+// Device mirrors adb.DeviceInfo.
+type Device struct {
+	Serial      string `json:"serial"`
+	State       string `json:"state,omitempty"`
+	Product     string `json:"product,omitempty"`
+	Model       string `json:"model,omitempty"`
+	DeviceInfo  string `json:"device_info,omitempty"`
+	Usb         string `json:"usb,omitempty"`
+	TransportID int    `json:"transport_id,omitempty"`
+
+	Label    string            `json:"label,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type ListDevicesResponse struct {
+	Devices []*Device `json:"devices"`
+}
+
+type TrackDevicesRequest struct{}
+
+// DeviceEvent mirrors adb.DeviceStateChangedEvent. States are formatted like adb.DeviceState,
+// e.g. StateOnline.
+type DeviceEvent struct {
+	Serial   string `json:"serial"`
+	OldState string `json:"old_state"`
+	NewState string `json:"new_state"`
+}
+
+type ShellRequest struct {
+	Serial  string   `json:"serial,omitempty"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+type LogcatRequest struct {
+	Serial string `json:"serial,omitempty"`
+	// Arguments to logcat, e.g. -d to dump the log instead of following it.
+	Args []string `json:"args,omitempty"`
+}
+
+type PullRequest struct {
+	Serial string `json:"serial,omitempty"`
+	Path   string `json:"path"`
+}
+
+// Chunk is a piece of a command's output or a file's contents.
+type Chunk struct {
+	Data []byte `json:"data"`
+}
+
+// PushRequest is sent as a stream. The first message sets the destination, and every message,
+// including the first, may carry data.
+type PushRequest struct {
+	Serial string `json:"serial,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// Permission bits of the file, defaults to 0644.
+	Mode uint32 `json:"mode,omitempty"`
+	// Modification time in seconds since the Unix epoch, defaults to the time the push completes.
+	ModTime int64  `json:"mod_time,omitempty"`
+	Data    []byte `json:"data,omitempty"`
+}
+
+type PushResponse struct {
+	Bytes int64 `json:"bytes"`
+}
+
+// InstallRequest is sent as a stream. The first message sets the options and the size of the
+// APK, and every message, including the first, may carry data.
+type InstallRequest struct {
+	Serial           string `json:"serial,omitempty"`
+	Size             int64  `json:"size,omitempty"`
+	Reinstall        bool   `json:"reinstall,omitempty"`
+	GrantPermissions bool   `json:"grant_permissions,omitempty"`
+	Data             []byte `json:"data,omitempty"`
+}
+
+type InstallResponse struct{}
@@ -0,0 +1,341 @@
+/*
+Package adbgrpc exposes goadb operations over gRPC, so remote workers can drive devices
+attached to another host.
+
+On the host with the devices:
+
+	client, _ := adb.New()
+	server := grpc.NewServer()
+	adbgrpc.Register(server, adbgrpc.NewServer(client))
+	server.Serve(listener)
+
+On the worker:
+
+	conn, _ := adbgrpc.Dial("devicehost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	devices, _ := adbgrpc.NewClient(conn).ListDevices(ctx, &adbgrpc.ListDevicesRequest{})
+
+This package is a separate module, so the goadb library doesn't depend on gRPC.
+*/
+package adbgrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the full name of the gRPC service.
+const ServiceName = "goadb.DeviceService"
+
+// Size of the chunks streamed for command output and file contents.
+const chunkSize = 32 * 1024
+
+// Default permissions of pushed files.
+const defaultPushMode = 0644
+
+// DeviceServiceServer is the server API for the device service.
+type DeviceServiceServer interface {
+	ListDevices(context.Context, *ListDevicesRequest) (*ListDevicesResponse, error)
+	TrackDevices(*TrackDevicesRequest, grpc.ServerStreamingServer[DeviceEvent]) error
+	Shell(*ShellRequest, grpc.ServerStreamingServer[Chunk]) error
+	Logcat(*LogcatRequest, grpc.ServerStreamingServer[Chunk]) error
+	Pull(*PullRequest, grpc.ServerStreamingServer[Chunk]) error
+	Push(grpc.ClientStreamingServer[PushRequest, PushResponse]) error
+	Install(grpc.ClientStreamingServer[InstallRequest, InstallResponse]) error
+}
+
+// Server implements DeviceServiceServer with an adb client.
+type Server struct {
+	client *adb.Adb
+}
+
+var _ DeviceServiceServer = &Server{}
+
+func NewServer(client *adb.Adb) *Server {
+	return &Server{client: client}
+}
+
+// Register registers srv on s.
+func Register(s grpc.ServiceRegistrar, srv DeviceServiceServer) {
+	s.RegisterService(&serviceDesc, srv)
+}
+
+func (s *Server) device(serial string) *adb.Device {
+	if serial == "" {
+		return s.client.Device(adb.AnyDevice())
+	}
+	return s.client.Device(adb.DeviceWithSerial(serial))
+}
+
+func (s *Server) ListDevices(ctx context.Context, req *ListDevicesRequest) (*ListDevicesResponse, error) {
+	devices, err := s.client.ListDevices()
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &ListDevicesResponse{Devices: []*Device{}}
+	for _, device := range devices {
+		resp.Devices = append(resp.Devices, &Device{
+			Serial:      device.Serial,
+			State:       device.State.String(),
+			Product:     device.Product,
+			Model:       device.Model,
+			DeviceInfo:  device.DeviceInfo,
+			Usb:         device.Usb,
+			TransportID: device.TransportID,
+			Label:       device.Label,
+			Metadata:    device.Metadata,
+		})
+	}
+	return resp, nil
+}
+
+// TrackDevices streams device state changes until the client cancels the call.
+func (s *Server) TrackDevices(req *TrackDevicesRequest, stream grpc.ServerStreamingServer[DeviceEvent]) error {
+	watcher := s.client.NewDeviceWatcherWithCtx(stream.Context())
+	for event := range watcher.C() {
+		err := stream.Send(&DeviceEvent{
+			Serial:   event.Serial,
+			OldState: event.OldState.String(),
+			NewState: event.NewState.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := stream.Context().Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return statusError(watcher.Err())
+}
+
+// Shell streams the output of a command as it's produced.
+func (s *Server) Shell(req *ShellRequest, stream grpc.ServerStreamingServer[Chunk]) error {
+	output, err := s.device(req.Serial).OpenCommand(req.Command, req.Args...)
+	if err != nil {
+		return statusError(err)
+	}
+	return sendChunks(stream, output)
+}
+
+// Logcat streams the device log. Unless Args includes -d, it runs until the client cancels
+// the call.
+func (s *Server) Logcat(req *LogcatRequest, stream grpc.ServerStreamingServer[Chunk]) error {
+	output, err := s.device(req.Serial).OpenCommand("logcat", req.Args...)
+	if err != nil {
+		return statusError(err)
+	}
+	return sendChunks(stream, output)
+}
+
+// Pull streams the contents of a file on the device.
+func (s *Server) Pull(req *PullRequest, stream grpc.ServerStreamingServer[Chunk]) error {
+	file, err := s.device(req.Serial).OpenRead(req.Path)
+	if err != nil {
+		return statusError(err)
+	}
+	return sendChunks(stream, file)
+}
+
+// Push writes the streamed data to a file on the device.
+func (s *Server) Push(stream grpc.ClientStreamingServer[PushRequest, PushResponse]) error {
+	header, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if header.Path == "" {
+		return status.Error(codes.InvalidArgument, "first push message must set path")
+	}
+	mode := os.FileMode(header.Mode).Perm()
+	if mode == 0 {
+		mode = defaultPushMode
+	}
+	mtime := adb.MtimeOfClose
+	if header.ModTime != 0 {
+		mtime = time.Unix(header.ModTime, 0)
+	}
+
+	// PushReader aborts the push if the client goes away, instead of committing a truncated file.
+	data := &streamReader{data: header.Data, next: func() ([]byte, error) {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil, recvError(stream.Context(), err)
+		}
+		return req.Data, nil
+	}}
+	stats, err := s.device(header.Serial).PushReader(stream.Context(), data, header.Path, mode, mtime, 0)
+	if err != nil {
+		if data.err != nil {
+			return data.err
+		}
+		if ctxErr := stream.Context().Err(); ctxErr != nil {
+			return status.FromContextError(ctxErr).Err()
+		}
+		return statusError(err)
+	}
+	return stream.SendAndClose(&PushResponse{Bytes: stats.Bytes})
+}
+
+// Install installs the streamed APK.
+func (s *Server) Install(stream grpc.ClientStreamingServer[InstallRequest, InstallResponse]) error {
+	header, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if header.Size <= 0 {
+		return status.Error(codes.InvalidArgument, "first install message must set size")
+	}
+
+	reader, writer := io.Pipe()
+	installed := make(chan error, 1)
+	go func() {
+		err := s.device(header.Serial).InstallAppStream(reader, header.Size, header.Reinstall, header.GrantPermissions)
+		// Unblock the writer if the install failed before reading all the data.
+		reader.CloseWithError(err)
+		installed <- err
+	}()
+
+	// Closing the pipe with the error aborts the install if the client goes away, instead of
+	// installing a truncated APK.
+	_, err = receiveChunks(writer, header.Data, func() ([]byte, error) {
+		req, err := stream.Recv()
+		if err != nil {
+			return nil, recvError(stream.Context(), err)
+		}
+		return req.Data, nil
+	})
+	writer.CloseWithError(err)
+	installErr := <-installed
+	if err != nil {
+		return err
+	}
+	if installErr != nil {
+		if ctxErr := stream.Context().Err(); ctxErr != nil {
+			return status.FromContextError(ctxErr).Err()
+		}
+		return statusError(installErr)
+	}
+	return stream.SendAndClose(&InstallResponse{})
+}
+
+// recvError returns the error to fail with when Recv of a client stream fails with err. Recv
+// also returns io.EOF once the client cancels the stream, which isn't the end of the data.
+func recvError(ctx context.Context, err error) error {
+	if err == io.EOF && ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return err
+}
+
+// sendChunks sends everything read from r, then closes it. r is also closed if the client
+// cancels the call, to stop commands that never finish, like logcat.
+func sendChunks(stream grpc.ServerStreamingServer[Chunk], r io.ReadCloser) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stream.Context().Done():
+		case <-done:
+		}
+		r.Close()
+	}()
+
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&Chunk{Data: buf[:n]}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			if ctxErr := stream.Context().Err(); ctxErr != nil {
+				return status.FromContextError(ctxErr).Err()
+			}
+			return statusError(err)
+		}
+	}
+}
+
+// receiveChunks writes first, then the data returned by next until it returns io.EOF, to w.
+// Returns the number of bytes written.
+func receiveChunks(w io.Writer, first []byte, next func() ([]byte, error)) (int64, error) {
+	var written int64
+	data := first
+	for {
+		if len(data) > 0 {
+			n, err := w.Write(data)
+			written += int64(n)
+			if err != nil {
+				return written, statusError(err)
+			}
+		}
+
+		var err error
+		if data, err = next(); err == io.EOF {
+			return written, nil
+		} else if err != nil {
+			return written, err
+		}
+	}
+}
+
+// streamReader reads the data of the messages returned by next, starting with data, until next
+// returns io.EOF.
+type streamReader struct {
+	data []byte
+	next func() ([]byte, error)
+	// The error next failed with, other than io.EOF.
+	err error
+}
+
+func (r *streamReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		data, err := r.next()
+		if err == io.EOF {
+			return 0, err
+		} else if err != nil {
+			r.err = err
+			return 0, err
+		}
+		r.data = data
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// statusError converts an adb error to a gRPC status error with the closest code.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, adb.ErrDeviceNotFound), errors.Is(err, adb.ErrFileNotExist):
+		code = codes.NotFound
+	case errors.Is(err, adb.ErrPermissionDenied):
+		code = codes.PermissionDenied
+	case errors.Is(err, adb.ErrDeviceOffline), errors.Is(err, adb.ErrUnauthorized),
+		errors.Is(err, adb.ErrReadOnlyFileSystem):
+		code = codes.FailedPrecondition
+	case errors.Is(err, adb.ErrServerNotAvailable), errors.Is(err, adb.ErrConnectionReset),
+		adb.HasErrCode(err, adb.NetworkError):
+		code = codes.Unavailable
+	case errors.Is(err, adb.ErrUnsupportedServer):
+		code = codes.Unimplemented
+	case adb.HasErrCode(err, adb.ParseError), adb.HasErrCode(err, adb.AssertionError):
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,199 @@
+package adbgrpc
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adb "github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/adbtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func newTestClient(t *testing.T, devices ...*adbtest.Device) *Client {
+	adbServer := adbtest.NewServer()
+	t.Cleanup(adbServer.Close)
+	for _, device := range devices {
+		adbServer.AddDevice(device)
+	}
+	return newServerClient(t, adbServer)
+}
+
+// newServerClient returns a client of a gRPC server for the devices of adbServer.
+func newServerClient(t *testing.T, adbServer *adbtest.Server, opts ...grpc.ServerOption) *Client {
+	client, err := adb.NewWithConfig(adbServer.Config())
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(opts...)
+	Register(grpcServer, NewServer(client))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn)
+}
+
+func readChunks(t *testing.T, stream grpc.ServerStreamingClient[Chunk]) (string, error) {
+	var data []byte
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return string(data), nil
+		} else if err != nil {
+			return string(data), err
+		}
+		data = append(data, chunk.Data...)
+	}
+}
+
+func TestListDevices(t *testing.T) {
+	client := newTestClient(t, &adbtest.Device{
+		Serial:     "emulator-5554",
+		Attributes: map[string]string{"product": "sdk_gphone", "model": "Pixel"},
+	})
+
+	resp, err := client.ListDevices(context.Background(), &ListDevicesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Devices, 1)
+	assert.Equal(t, &Device{Serial: "emulator-5554", State: "StateOnline", Product: "sdk_gphone", Model: "Pixel"}, resp.Devices[0])
+}
+
+func TestShell(t *testing.T) {
+	client := newTestClient(t, &adbtest.Device{
+		Serial: "emulator-5554",
+		Shell:  map[string]string{"echo hello": "hello\n"},
+	})
+
+	stream, err := client.Shell(context.Background(), &ShellRequest{Serial: "emulator-5554", Command: "echo", Args: []string{"hello"}})
+	require.NoError(t, err)
+	output, err := readChunks(t, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+}
+
+func TestLogcatDump(t *testing.T) {
+	client := newTestClient(t, &adbtest.Device{
+		Serial: "emulator-5554",
+		Shell:  map[string]string{"logcat -d": "I/tag: message\n"},
+	})
+
+	stream, err := client.Logcat(context.Background(), &LogcatRequest{Args: []string{"-d"}})
+	require.NoError(t, err)
+	output, err := readChunks(t, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, "I/tag: message\n", output)
+}
+
+func TestShellDeviceNotFound(t *testing.T) {
+	client := newTestClient(t)
+
+	stream, err := client.Shell(context.Background(), &ShellRequest{Serial: "missing", Command: "ls"})
+	require.NoError(t, err)
+	_, err = readChunks(t, stream)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestPushCanceled(t *testing.T) {
+	removed := make(chan string, 1)
+	device := &adbtest.Device{
+		Serial: "emulator-5554",
+		Files:  map[string]*adbtest.File{},
+		ShellHandler: func(cmd string) string {
+			removed <- cmd
+			return ""
+		},
+	}
+	adbServer := adbtest.NewServer()
+	t.Cleanup(adbServer.Close)
+	adbServer.AddDevice(device)
+	client := newServerClient(t, adbServer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Push(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&PushRequest{Serial: "emulator-5554", Path: "/sdcard/partial.txt", Data: []byte("part")}))
+	// Cancel once the server has started the push.
+	require.Eventually(t, func() bool {
+		for _, req := range adbServer.Requests() {
+			if req == "sync:" {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, time.Millisecond)
+	cancel()
+
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	select {
+	case cmd := <-removed:
+		assert.Equal(t, "rm -f /sdcard/partial.txt", cmd)
+	case <-time.After(5 * time.Second):
+		t.Fatal("partial file wasn't removed")
+	}
+	assert.Empty(t, device.Files)
+}
+
+func TestInstallCanceled(t *testing.T) {
+	started := make(chan struct{})
+	received := make(chan []byte, 1)
+	device := &adbtest.Device{
+		Serial:   "emulator-5554",
+		Features: []string{"cmd"},
+		ExecHandler: func(cmd string, input io.Reader) string {
+			close(started)
+			data, _ := ioutil.ReadAll(input)
+			received <- data
+			return "Success\n"
+		},
+	}
+	adbServer := adbtest.NewServer()
+	t.Cleanup(adbServer.Close)
+	adbServer.AddDevice(device)
+	// The client sees Canceled whatever the server returns, so check the handler's error.
+	handlerErr := make(chan error, 1)
+	client := newServerClient(t, adbServer, grpc.StreamInterceptor(
+		func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			err := handler(srv, stream)
+			handlerErr <- err
+			return err
+		}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Install(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&InstallRequest{Serial: "emulator-5554", Size: 8, Data: []byte("part")}))
+	<-started
+	cancel()
+
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	select {
+	case data := <-received:
+		// The install was aborted before the rest of the APK was sent.
+		assert.Equal(t, "part", string(data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("install wasn't aborted")
+	}
+	assert.Equal(t, codes.Canceled, status.Code(<-handlerErr))
+}
+
+func TestStatusError(t *testing.T) {
+	assert.NoError(t, statusError(nil))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(statusError(adb.ErrDeviceOffline)))
+	assert.Equal(t, codes.PermissionDenied, status.Code(statusError(adb.ErrPermissionDenied)))
+	assert.Equal(t, codes.Unavailable, status.Code(statusError(adb.ErrServerNotAvailable)))
+	assert.Equal(t, codes.Unimplemented, status.Code(statusError(adb.ErrUnsupportedServer)))
+}
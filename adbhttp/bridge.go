@@ -0,0 +1,166 @@
+/*
+Package adbhttp exposes attached devices over REST and WebSocket, so a web dashboard can view
+and control them remotely.
+
+Routes:
+
+	GET  /devices                     JSON list of attached devices.
+	GET  /devices/{serial}/screenshot PNG of the current screen.
+	POST /devices/{serial}/input      Injects the InputEvent in the JSON body.
+	GET  /devices/{serial}/screen     WebSocket streaming the screen as binary PNG messages,
+	                                  and injecting InputEvents received as text messages.
+	GET  /devices/{serial}/mjpeg      MJPEG stream of the screen, for img tags, see NewMJPEGHandler.
+	*    /devices/{serial}/files/...  WebDAV access to the device filesystem, see package davfs.
+
+E.g.
+
+	client, _ := adb.New()
+	http.ListenAndServe(":8080", adbhttp.NewHandler(client))
+
+This package is a separate module, so the goadb library doesn't depend on the WebSocket
+implementation.
+*/
+package adbhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+	"golang.org/x/net/webdav"
+)
+
+// DefaultFrameInterval is the time between screen frames, unless the stream request sets
+// the interval query parameter, e.g. ?interval=250ms.
+const DefaultFrameInterval = 500 * time.Millisecond
+
+// minFrameInterval bounds the interval a client can request, since each frame runs screencap.
+const minFrameInterval = 100 * time.Millisecond
+
+// Device mirrors adb.DeviceInfo.
+type Device struct {
+	Serial      string `json:"serial"`
+	State       string `json:"state,omitempty"`
+	Product     string `json:"product,omitempty"`
+	Model       string `json:"model,omitempty"`
+	DeviceInfo  string `json:"device_info,omitempty"`
+	Usb         string `json:"usb,omitempty"`
+	TransportID int    `json:"transport_id,omitempty"`
+
+	Label    string            `json:"label,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type handler struct {
+	client *adb.Adb
+	mux    *http.ServeMux
+
+	mu sync.Mutex
+	// WebDAV locks, keyed by serial.
+	locks map[string]webdav.LockSystem
+}
+
+// NewHandler returns a handler serving the devices attached to client's server.
+func NewHandler(client *adb.Adb) http.Handler {
+	h := &handler{client: client, mux: http.NewServeMux(), locks: map[string]webdav.LockSystem{}}
+	h.mux.HandleFunc("GET /devices", h.listDevices)
+	h.mux.HandleFunc("GET /devices/{serial}/screenshot", h.screenshot)
+	h.mux.HandleFunc("POST /devices/{serial}/input", h.input)
+	h.mux.Handle("GET /devices/{serial}/screen", h.screenStream())
+	h.mux.HandleFunc("GET /devices/{serial}/mjpeg", h.mjpegStream)
+	h.mux.HandleFunc("/devices/{serial}/files/", h.files)
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *handler) device(r *http.Request) *adb.Device {
+	return h.client.Device(adb.DeviceWithSerial(r.PathValue("serial")))
+}
+
+func (h *handler) listDevices(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.client.ListDevices()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := []*Device{}
+	for _, device := range devices {
+		resp = append(resp, &Device{
+			Serial:      device.Serial,
+			State:       device.State.String(),
+			Product:     device.Product,
+			Model:       device.Model,
+			DeviceInfo:  device.DeviceInfo,
+			Usb:         device.Usb,
+			TransportID: device.TransportID,
+			Label:       device.Label,
+			Metadata:    device.Metadata,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) screenshot(w http.ResponseWriter, r *http.Request) {
+	png, err := h.device(r).ScreenShot()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(png)
+}
+
+func (h *handler) input(w http.ResponseWriter, r *http.Request) {
+	var event InputEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid input event: " + err.Error()})
+		return
+	}
+	if err := event.inject(h.device(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with the HTTP status closest to err's adb error code.
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, httpStatus(err), errorResponse{Error: err.Error()})
+}
+
+func httpStatus(err error) int {
+	var badRequest badRequestError
+	switch {
+	case errors.As(err, &badRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, adb.ErrDeviceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, adb.ErrDeviceOffline), errors.Is(err, adb.ErrUnauthorized):
+		return http.StatusConflict
+	case errors.Is(err, adb.ErrServerNotAvailable), errors.Is(err, adb.ErrConnectionReset),
+		adb.HasErrCode(err, adb.NetworkError):
+		return http.StatusBadGateway
+	case errors.Is(err, adb.ErrUnsupportedServer):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
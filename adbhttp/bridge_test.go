@@ -0,0 +1,113 @@
+package adbhttp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adb "github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/adbtest"
+	"golang.org/x/net/websocket"
+)
+
+const fakePNG = "\x89PNG\r\n\x1a\nframe"
+
+// newTestServer serves a device whose input commands are sent to the returned channel.
+func newTestServer(t *testing.T) (*httptest.Server, chan string) {
+	adbServer := adbtest.NewServer()
+	t.Cleanup(adbServer.Close)
+	commands := make(chan string, 10)
+	adbServer.AddDevice(&adbtest.Device{
+		Serial:     "emulator-5554",
+		Attributes: map[string]string{"model": "Pixel"},
+		Shell:      map[string]string{"screencap -p": fakePNG},
+		ShellHandler: func(cmd string) string {
+			commands <- cmd
+			return ""
+		},
+	})
+	client, err := adb.NewWithConfig(adbServer.Config())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(NewHandler(client))
+	t.Cleanup(server.Close)
+	return server, commands
+}
+
+func TestListDevices(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	resp, err := http.Get(server.URL + "/devices")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var devices []*Device
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&devices))
+	assert.Equal(t, []*Device{{Serial: "emulator-5554", State: "StateOnline", Model: "Pixel"}}, devices)
+}
+
+func TestScreenshot(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	resp, err := http.Get(server.URL + "/devices/emulator-5554/screenshot")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, fakePNG, string(body))
+
+	resp, err = http.Get(server.URL + "/devices/missing/screenshot")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestInput(t *testing.T) {
+	server, commands := newTestServer(t)
+
+	for _, test := range []struct {
+		Body   string
+		Status int
+		Want   string
+	}{
+		{`{"type": "tap", "x": 10, "y": 20}`, http.StatusNoContent, "input tap 10 20"},
+		{`{"type": "swipe", "x": 1, "y": 2, "x1": 3, "y1": 4, "duration_ms": 300}`, http.StatusNoContent, "input swipe 1 2 3 4 300"},
+		{`{"type": "text", "text": "it's here"}`, http.StatusNoContent, `input text 'it'\''s%shere'`},
+		{`{"type": "key", "key": "KEYCODE_HOME"}`, http.StatusNoContent, "input keyevent KEYCODE_HOME"},
+		{`{"type": "key", "key": "3; reboot"}`, http.StatusBadRequest, ""},
+		{`{"type": "pinch"}`, http.StatusBadRequest, ""},
+		{`not json`, http.StatusBadRequest, ""},
+	} {
+		resp, err := http.Post(server.URL+"/devices/emulator-5554/input", "application/json", strings.NewReader(test.Body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, test.Status, resp.StatusCode, test.Body)
+		if test.Want != "" {
+			assert.Equal(t, test.Want, <-commands)
+		}
+	}
+}
+
+func TestScreenStream(t *testing.T) {
+	server, commands := newTestServer(t)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/devices/emulator-5554/screen?interval=100ms"
+	ws, err := websocket.Dial(url, "", server.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	for i := 0; i < 2; i++ {
+		var frame []byte
+		require.NoError(t, websocket.Message.Receive(ws, &frame))
+		assert.Equal(t, fakePNG, string(frame))
+	}
+
+	require.NoError(t, websocket.JSON.Send(ws, InputEvent{Type: InputTap, X: 5, Y: 6}))
+	assert.Equal(t, "input tap 5 6", <-commands)
+}
@@ -0,0 +1,59 @@
+/*
+goadb-bridge serves the devices attached to an adb server over REST and WebSocket, for web
+dashboards. See package adbhttp for the routes.
+
+E.g.
+
+	goadb-bridge --listen :8080
+	curl localhost:8080/devices
+*/
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/adbhttp"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	listen = kingpin.Flag("listen",
+		"Address to serve HTTP on.").
+		Short('l').
+		Default("localhost:8080").
+		String()
+	host = kingpin.Flag("host",
+		"Host of the adb server.").
+		Short('H').
+		String()
+	port = kingpin.Flag("port",
+		"Port of the adb server.").
+		Short('P').
+		Int()
+	noServer = kingpin.Flag("no-start-server",
+		"Never start the adb server, so the adb executable isn't needed.").
+		Bool()
+)
+
+func main() {
+	kingpin.Parse()
+
+	client, err := adb.NewWithConfig(adb.ServerConfig{
+		Host:     *host,
+		Port:     *port,
+		NoServer: *noServer,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(os.Stderr, "serving on", *listen)
+	if err := http.ListenAndServe(*listen, adbhttp.NewHandler(client)); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
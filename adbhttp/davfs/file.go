@@ -0,0 +1,155 @@
+package davfs
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+// fileInfo adapts an adb.DirEntry to os.FileInfo.
+type fileInfo struct {
+	entry *adb.DirEntry
+}
+
+func (fi fileInfo) Name() string       { return fi.entry.Name }
+func (fi fileInfo) Size() int64        { return int64(fi.entry.Size) }
+func (fi fileInfo) Mode() os.FileMode  { return fi.entry.Mode }
+func (fi fileInfo) ModTime() time.Time { return fi.entry.ModifiedAt }
+func (fi fileInfo) IsDir() bool        { return fi.entry.Mode.IsDir() }
+func (fi fileInfo) Sys() interface{}   { return fi.entry }
+
+// file is either a file opened for reading, a directory, or a file being written.
+//
+// Reads stream the file with sync RECV, which can't seek. Seeking forward discards data, and
+// seeking backward reopens the file, which is enough for http.ServeContent's size check and
+// range requests.
+type file struct {
+	fs   *FileSystem
+	path string
+	info fileInfo
+
+	reader    io.ReadCloser
+	readerPos int64
+	offset    int64
+
+	// Remaining directory entries, read on the first call to Readdir.
+	entries []os.FileInfo
+	listed  bool
+
+	writer io.WriteCloser
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.writer != nil {
+		return 0, &os.PathError{Op: "read", Path: f.path, Err: errors.ErrUnsupported}
+	}
+	if f.info.IsDir() {
+		return 0, &os.PathError{Op: "read", Path: f.path, Err: errors.New("is a directory")}
+	}
+
+	if f.reader != nil && f.readerPos > f.offset {
+		f.reader.Close()
+		f.reader = nil
+	}
+	if f.reader == nil {
+		reader, err := f.fs.device.OpenRead(f.path)
+		if err != nil {
+			return 0, &os.PathError{Op: "read", Path: f.path, Err: err}
+		}
+		f.reader, f.readerPos = reader, 0
+	}
+	if f.readerPos < f.offset {
+		n, err := io.CopyN(ioutil.Discard, f.reader, f.offset-f.readerPos)
+		f.readerPos += n
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.reader.Read(p)
+	f.readerPos += int64(n)
+	f.offset = f.readerPos
+	return n, err
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.writer != nil {
+		return 0, &os.PathError{Op: "seek", Path: f.path, Err: errors.ErrUnsupported}
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.path, Err: errors.New("negative offset")}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.info.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.path, Err: errors.New("not a directory")}
+	}
+	if !f.listed {
+		entries, err := f.fs.device.ListDirEntries(f.path)
+		if err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: f.path, Err: err}
+		}
+		all, err := entries.ReadAll()
+		if err != nil {
+			return nil, &os.PathError{Op: "readdir", Path: f.path, Err: err}
+		}
+		for _, entry := range all {
+			if entry.Name != "." && entry.Name != ".." {
+				f.entries = append(f.entries, fileInfo{entry})
+			}
+		}
+		f.listed = true
+	}
+
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	entries := f.entries[:count]
+	f.entries = f.entries[count:]
+	return entries, nil
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	if f.writer == nil {
+		return 0, &os.PathError{Op: "write", Path: f.path, Err: os.ErrPermission}
+	}
+	n, err := f.writer.Write(p)
+	f.info.entry.Size += int32(n)
+	return n, err
+}
+
+func (f *file) Close() error {
+	if f.writer != nil {
+		return f.writer.Close()
+	}
+	if f.reader != nil {
+		return f.reader.Close()
+	}
+	return nil
+}
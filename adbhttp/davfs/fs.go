@@ -0,0 +1,159 @@
+/*
+Package davfs serves a device's filesystem over WebDAV, so existing file managers and backup
+tools can access device storage without adb.
+
+Files are read, written, and listed with the sync protocol. Directories are created, removed,
+and renamed with shell commands, since the sync protocol can't.
+
+E.g.
+
+	device := client.Device(adb.DeviceWithSerial("emulator-5554"))
+	http.ListenAndServe(":8080", &webdav.Handler{
+		FileSystem: davfs.New(device, "/sdcard"),
+		LockSystem: webdav.NewMemLS(),
+	})
+*/
+package davfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/internal/shellquote"
+	"golang.org/x/net/webdav"
+)
+
+// FileSystem is a webdav.FileSystem rooted at a directory on a device.
+type FileSystem struct {
+	device *adb.Device
+	root   string
+}
+
+var _ webdav.FileSystem = &FileSystem{}
+
+// New returns a FileSystem serving the directory root on device, e.g. /sdcard.
+func New(device *adb.Device, root string) *FileSystem {
+	return &FileSystem{device: device, root: path.Clean("/" + root)}
+}
+
+// resolve returns the device path of name, which can't escape the root.
+func (fs *FileSystem) resolve(name string) string {
+	return path.Join(fs.root, path.Clean("/"+name))
+}
+
+func (fs *FileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	devicePath := fs.resolve(name)
+	if _, err := fs.stat("mkdir", devicePath); err == nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	}
+	if _, err := fs.stat("mkdir", path.Dir(devicePath)); err != nil {
+		return err
+	}
+	return fs.run("mkdir", name, "mkdir", "-m", fmt.Sprintf("%o", perm.Perm()), devicePath)
+}
+
+func (fs *FileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	devicePath := fs.resolve(name)
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		if flag&os.O_APPEND != 0 || flag&(os.O_CREATE|os.O_TRUNC) == 0 {
+			// The sync protocol can only replace whole files.
+			return nil, &os.PathError{Op: "open", Path: name, Err: errors.ErrUnsupported}
+		}
+		if flag&os.O_EXCL != 0 {
+			if _, err := fs.stat("open", devicePath); err == nil {
+				return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrExist}
+			}
+		}
+		// adbd creates missing parent directories, but WebDAV clients expect an error.
+		if parent, err := fs.stat("open", path.Dir(devicePath)); err != nil {
+			return nil, err
+		} else if !parent.Mode.IsDir() {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return fs.create(name, devicePath, perm)
+	}
+
+	entry, err := fs.stat("open", devicePath)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{fs: fs, path: devicePath, info: fileInfo{entry}}, nil
+}
+
+func (fs *FileSystem) create(name, devicePath string, perm os.FileMode) (webdav.File, error) {
+	if perm == 0 {
+		perm = 0644
+	}
+	writer, err := fs.device.OpenWrite(devicePath, perm, adb.MtimeOfClose)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{
+		fs:     fs,
+		path:   devicePath,
+		info:   fileInfo{&adb.DirEntry{Name: path.Base(devicePath), Mode: perm, ModifiedAt: time.Now()}},
+		writer: writer,
+	}, nil
+}
+
+func (fs *FileSystem) RemoveAll(ctx context.Context, name string) error {
+	devicePath := fs.resolve(name)
+	if devicePath == fs.root {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrPermission}
+	}
+	return fs.run("remove", name, "rm", "-rf", devicePath)
+}
+
+func (fs *FileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	oldPath, newPath := fs.resolve(oldName), fs.resolve(newName)
+	if oldPath == fs.root || newPath == fs.root {
+		return &os.PathError{Op: "rename", Path: oldName, Err: os.ErrPermission}
+	}
+	if _, err := fs.stat("rename", oldPath); err != nil {
+		return err
+	}
+	return fs.run("rename", oldName, "mv", "-f", oldPath, newPath)
+}
+
+func (fs *FileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	entry, err := fs.stat("stat", fs.resolve(name))
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{entry}, nil
+}
+
+// stat returns the entry at devicePath. Missing files are reported as an *os.PathError
+// wrapping os.ErrNotExist, since the webdav package checks for them with os.IsNotExist, which
+// doesn't unwrap adb errors.
+func (fs *FileSystem) stat(op, devicePath string) (*adb.DirEntry, error) {
+	entry, err := fs.device.Stat(devicePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &os.PathError{Op: op, Path: devicePath, Err: os.ErrNotExist}
+	} else if err != nil {
+		return nil, err
+	}
+	entry.Name = path.Base(devicePath)
+	return entry, nil
+}
+
+// run runs a shell command that prints nothing on success.
+func (fs *FileSystem) run(op, name, cmd string, args ...string) error {
+	for i, arg := range args {
+		args[i] = shellquote.Quote(arg)
+	}
+	output, err := fs.device.RunCommand(cmd, args...)
+	if err == nil && strings.TrimSpace(output) != "" {
+		err = errors.New(strings.TrimSpace(output))
+	}
+	if err != nil {
+		return &os.PathError{Op: op, Path: name, Err: err}
+	}
+	return nil
+}
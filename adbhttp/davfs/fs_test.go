@@ -0,0 +1,138 @@
+package davfs
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adb "github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/adbtest"
+	"golang.org/x/net/webdav"
+)
+
+// newTestServer serves /sdcard on a fake device, whose mkdir, rm, and mv commands update
+// its files.
+func newTestServer(t *testing.T) (*httptest.Server, *adbtest.Device) {
+	adbServer := adbtest.NewServer()
+	t.Cleanup(adbServer.Close)
+	device := &adbtest.Device{
+		Serial: "emulator-5554",
+		Files: map[string]*adbtest.File{
+			"/sdcard/a.txt":     {Data: []byte("hello world"), ModTime: time.Unix(1500000000, 0)},
+			"/sdcard/sub/b.txt": {Data: []byte("b")},
+		},
+	}
+	device.ShellHandler = func(cmd string) string {
+		args := strings.Fields(strings.ReplaceAll(cmd, "'", ""))
+		switch args[0] {
+		case "mkdir":
+			device.Files[args[len(args)-1]] = &adbtest.File{Mode: os.ModeDir | 0755}
+		case "rm":
+			for name := range device.Files {
+				if name == args[2] || strings.HasPrefix(name, args[2]+"/") {
+					delete(device.Files, name)
+				}
+			}
+		case "mv":
+			for name, file := range device.Files {
+				if name == args[2] || strings.HasPrefix(name, args[2]+"/") {
+					delete(device.Files, name)
+					device.Files[args[3]+strings.TrimPrefix(name, args[2])] = file
+				}
+			}
+		default:
+			return "unexpected command: " + cmd
+		}
+		return ""
+	}
+	adbServer.AddDevice(device)
+	client, err := adb.NewWithConfig(adbServer.Config())
+	require.NoError(t, err)
+
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: New(client.Device(adb.DeviceWithSerial("emulator-5554")), "/sdcard"),
+		LockSystem: webdav.NewMemLS(),
+	})
+	t.Cleanup(server.Close)
+	return server, device
+}
+
+func do(t *testing.T, method, url string, body string, headers ...string) (*http.Response, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestGet(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	resp, body := do(t, "GET", server.URL+"/a.txt", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello world", body)
+
+	resp, body = do(t, "GET", server.URL+"/a.txt", "", "Range", "bytes=6-")
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "world", body)
+
+	resp, _ = do(t, "GET", server.URL+"/missing.txt", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetCannotEscapeRoot(t *testing.T) {
+	server, device := newTestServer(t)
+	device.Files["/secret.txt"] = &adbtest.File{Data: []byte("secret")}
+
+	resp, _ := do(t, "GET", server.URL+"/../secret.txt", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPut(t *testing.T) {
+	server, device := newTestServer(t)
+
+	resp, _ := do(t, "PUT", server.URL+"/sub/new.txt", "new file")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "new file", string(device.Files["/sdcard/sub/new.txt"].Data))
+
+	resp, _ = do(t, "PUT", server.URL+"/nodir/new.txt", "new file")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestPropfind(t *testing.T) {
+	server, _ := newTestServer(t)
+
+	resp, body := do(t, "PROPFIND", server.URL+"/", "", "Depth", "1")
+	assert.Equal(t, http.StatusMultiStatus, resp.StatusCode)
+	assert.Contains(t, body, "<D:href>/a.txt</D:href>")
+	assert.Contains(t, body, "<D:href>/sub/</D:href>")
+	assert.Contains(t, body, "<D:getcontentlength>11</D:getcontentlength>")
+}
+
+func TestMkcolMoveDelete(t *testing.T) {
+	server, device := newTestServer(t)
+
+	resp, _ := do(t, "MKCOL", server.URL+"/dir", "")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.True(t, device.Files["/sdcard/dir"].Mode.IsDir())
+
+	resp, _ = do(t, "MOVE", server.URL+"/sub", "", "Destination", server.URL+"/dir/moved")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "b", string(device.Files["/sdcard/dir/moved/b.txt"].Data))
+
+	resp, _ = do(t, "DELETE", server.URL+"/dir", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Len(t, device.Files, 1)
+}
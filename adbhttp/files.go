@@ -0,0 +1,28 @@
+package adbhttp
+
+import (
+	"net/http"
+
+	"github.com/zach-klippenstein/goadb/adbhttp/davfs"
+	"golang.org/x/net/webdav"
+)
+
+// files serves the device's filesystem, from /, over WebDAV.
+func (h *handler) files(w http.ResponseWriter, r *http.Request) {
+	serial := r.PathValue("serial")
+	dav := &webdav.Handler{
+		Prefix:     "/devices/" + serial + "/files",
+		FileSystem: davfs.New(h.device(r), "/"),
+		LockSystem: h.lockSystem(serial),
+	}
+	dav.ServeHTTP(w, r)
+}
+
+func (h *handler) lockSystem(serial string) webdav.LockSystem {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.locks[serial]; !ok {
+		h.locks[serial] = webdav.NewMemLS()
+	}
+	return h.locks[serial]
+}
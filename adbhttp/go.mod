@@ -0,0 +1,26 @@
+module github.com/zach-klippenstein/goadb/adbhttp
+
+go 1.25.0
+
+require (
+	github.com/stretchr/testify v1.4.0
+	github.com/zach-klippenstein/goadb v0.0.0
+	gopkg.in/alecthomas/kingpin.v2 v2.2.6
+)
+
+require (
+	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
+	github.com/alecthomas/units v0.0.0-20201120081800-1786d5ef83d4 // indirect
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
+)
+
+require (
+	github.com/cheggaaa/pb v1.0.29 // indirect
+	github.com/mattn/go-runewidth v0.0.4 // indirect
+	golang.org/x/net v0.57.0
+	golang.org/x/sys v0.47.0 // indirect
+)
+
+replace github.com/zach-klippenstein/goadb => ../
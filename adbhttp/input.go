@@ -0,0 +1,85 @@
+package adbhttp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	adb "github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/internal/shellquote"
+)
+
+// Input event types.
+const (
+	InputTap   = "tap"
+	InputSwipe = "swipe"
+	InputText  = "text"
+	InputKey   = "key"
+)
+
+// InputEvent is a touch, text, or key event injected with the input command.
+type InputEvent struct {
+	// One of InputTap, InputSwipe, InputText, or InputKey.
+	Type string `json:"type"`
+	// Screen coordinates in pixels. Swipes go from X, Y to X1, Y1.
+	X  int `json:"x,omitempty"`
+	Y  int `json:"y,omitempty"`
+	X1 int `json:"x1,omitempty"`
+	Y1 int `json:"y1,omitempty"`
+	// Duration of a swipe in milliseconds. Defaults to the input command's default.
+	DurationMs int `json:"duration_ms,omitempty"`
+	// Text typed by text events.
+	Text string `json:"text,omitempty"`
+	// Key code of key events, either a number or a name like KEYCODE_HOME or HOME.
+	Key string `json:"key,omitempty"`
+}
+
+// badRequestError is returned for invalid requests, and served as StatusBadRequest.
+type badRequestError string
+
+func (e badRequestError) Error() string {
+	return string(e)
+}
+
+var keyCodePattern = regexp.MustCompile(`^[A-Z0-9_]+$`)
+
+// args returns the arguments to the input command for e.
+func (e InputEvent) args() ([]string, error) {
+	switch e.Type {
+	case InputTap:
+		return []string{"tap", strconv.Itoa(e.X), strconv.Itoa(e.Y)}, nil
+	case InputSwipe:
+		args := []string{"swipe", strconv.Itoa(e.X), strconv.Itoa(e.Y), strconv.Itoa(e.X1), strconv.Itoa(e.Y1)}
+		if e.DurationMs > 0 {
+			args = append(args, strconv.Itoa(e.DurationMs))
+		}
+		return args, nil
+	case InputText:
+		if e.Text == "" {
+			return nil, badRequestError("input text is empty")
+		}
+		// RunCommand rejects arguments containing double quotes.
+		if strings.ContainsRune(e.Text, '"') {
+			return nil, badRequestError("input text can't contain double quotes")
+		}
+		// input text reads %s as a space, since it can't take arguments with spaces.
+		return []string{"text", shellquote.Quote(strings.ReplaceAll(e.Text, " ", "%s"))}, nil
+	case InputKey:
+		if !keyCodePattern.MatchString(e.Key) {
+			return nil, badRequestError(fmt.Sprintf("invalid key code: %q", e.Key))
+		}
+		return []string{"keyevent", e.Key}, nil
+	default:
+		return nil, badRequestError(fmt.Sprintf("unknown input event type: %q", e.Type))
+	}
+}
+
+func (e InputEvent) inject(device *adb.Device) error {
+	args, err := e.args()
+	if err != nil {
+		return err
+	}
+	_, err = device.RunCommand("input", args...)
+	return err
+}
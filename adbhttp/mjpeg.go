@@ -0,0 +1,114 @@
+package adbhttp
+
+import (
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+// DefaultMJPEGFrameRate is the frames per second of MJPEG streams, unless the request sets the
+// fps query parameter, e.g. ?fps=5.
+const DefaultMJPEGFrameRate = 2
+
+// JPEG quality of MJPEG frames, low enough to keep full resolution frames small.
+const mjpegQuality = 75
+
+type mjpegHandler struct {
+	device adb.DeviceClient
+	fps    float64
+}
+
+/*
+NewMJPEGHandler returns a handler that streams device's screen as MJPEG, a
+multipart/x-mixed-replace response of JPEG frames, at up to fps frames per second. Browsers
+render it in a plain img tag, so a screen can be embedded in a dashboard without any
+JavaScript:
+
+	http.Handle("/screen", adbhttp.NewMJPEGHandler(device, 5))
+
+	<img src="/screen">
+
+Requests can lower or raise the rate with the fps query parameter, e.g. /screen?fps=1. The
+rate is capped at 10 frames per second, since each frame runs screencap; the actual rate is
+lower if screencap is slower than that.
+*/
+func NewMJPEGHandler(device adb.DeviceClient, fps float64) http.Handler {
+	return &mjpegHandler{device: device, fps: fps}
+}
+
+func (h *mjpegHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	interval, err := mjpegFrameInterval(r, h.fps)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Capture the first frame before starting the stream, so errors get a proper status.
+	frame, err := captureJPEG(h.device)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	parts := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+parts.Boundary())
+	w.Header().Set("Cache-Control", "no-store")
+	flusher, _ := w.(http.Flusher)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":   {"image/jpeg"},
+			"Content-Length": {strconv.Itoa(len(frame))},
+		})
+		if err != nil {
+			return
+		}
+		if _, err := part.Write(frame); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		if frame, err = captureJPEG(h.device); err != nil {
+			// The response has started, so all that can be done is ending the stream.
+			return
+		}
+	}
+}
+
+func captureJPEG(device adb.DeviceClient) ([]byte, error) {
+	return device.ScreenShotWithOptions(adb.ScreenshotOptions{JPEGQuality: mjpegQuality})
+}
+
+func mjpegFrameInterval(r *http.Request, fps float64) (time.Duration, error) {
+	if value := r.URL.Query().Get("fps"); value != "" {
+		var err error
+		if fps, err = strconv.ParseFloat(value, 64); err != nil || fps <= 0 {
+			return 0, badRequestError("invalid fps: " + value)
+		}
+	}
+	if fps <= 0 {
+		fps = DefaultMJPEGFrameRate
+	}
+	interval := time.Duration(float64(time.Second) / fps)
+	if interval < minFrameInterval {
+		interval = minFrameInterval
+	}
+	return interval, nil
+}
+
+func (h *handler) mjpegStream(w http.ResponseWriter, r *http.Request) {
+	NewMJPEGHandler(h.device(r), DefaultMJPEGFrameRate).ServeHTTP(w, r)
+}
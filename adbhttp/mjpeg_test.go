@@ -0,0 +1,80 @@
+package adbhttp
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adb "github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/adbtest"
+)
+
+func TestMJPEGStream(t *testing.T) {
+	var screen bytes.Buffer
+	require.NoError(t, png.Encode(&screen, image.NewGray(image.Rect(0, 0, 8, 4))))
+	adbServer := adbtest.NewServer()
+	defer adbServer.Close()
+	adbServer.AddDevice(&adbtest.Device{
+		Serial: "emulator-5554",
+		Shell:  map[string]string{"screencap -p": screen.String()},
+	})
+	client, err := adb.NewWithConfig(adbServer.Config())
+	require.NoError(t, err)
+	server := httptest.NewServer(NewHandler(client))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/devices/emulator-5554/mjpeg?fps=10")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/x-mixed-replace", mediaType)
+
+	parts := multipart.NewReader(resp.Body, params["boundary"])
+	for i := 0; i < 2; i++ {
+		part, err := parts.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, "image/jpeg", part.Header.Get("Content-Type"))
+		data, err := ioutil.ReadAll(part)
+		require.NoError(t, err)
+		frame, err := jpeg.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 8, 4), frame.Bounds())
+	}
+
+	// Errors before the first frame get a status.
+	resp, err = http.Get(server.URL + "/devices/missing/mjpeg")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestMJPEGFrameInterval(t *testing.T) {
+	for _, test := range []struct {
+		Query string
+		FPS   float64
+		Want  time.Duration
+	}{
+		{"", 0, time.Second / DefaultMJPEGFrameRate},
+		{"", 4, 250 * time.Millisecond},
+		{"?fps=0.5", 4, 2 * time.Second},
+		{"?fps=60", 4, minFrameInterval},
+	} {
+		interval, err := mjpegFrameInterval(httptest.NewRequest("GET", "/mjpeg"+test.Query, nil), test.FPS)
+		assert.NoError(t, err)
+		assert.Equal(t, test.Want, interval, test.Query)
+	}
+
+	_, err := mjpegFrameInterval(httptest.NewRequest("GET", "/mjpeg?fps=fast", nil), 0)
+	assert.Error(t, err)
+}
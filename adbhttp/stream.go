@@ -0,0 +1,79 @@
+package adbhttp
+
+import (
+	"net/http"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+	"golang.org/x/net/websocket"
+)
+
+// screenStream serves a WebSocket that sends a PNG screenshot as a binary message every frame
+// interval, and injects the InputEvents the client sends as JSON text messages. Errors are
+// sent as an errorResponse text message; screenshot errors end the stream.
+func (h *handler) screenStream() http.Handler {
+	return websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		r := ws.Request()
+		device := h.device(r)
+
+		interval, err := frameInterval(r)
+		if err != nil {
+			websocket.JSON.Send(ws, errorResponse{Error: err.Error()})
+			return
+		}
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.receiveEvents(ws, device)
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			png, err := device.ScreenShot()
+			if err != nil {
+				websocket.JSON.Send(ws, errorResponse{Error: err.Error()})
+				return
+			}
+			if err := websocket.Message.Send(ws, png); err != nil {
+				return
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// receiveEvents injects events from ws until it's closed.
+func (h *handler) receiveEvents(ws *websocket.Conn, device *adb.Device) {
+	for {
+		var event InputEvent
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			return
+		}
+		if err := event.inject(device); err != nil {
+			websocket.JSON.Send(ws, errorResponse{Error: err.Error()})
+		}
+	}
+}
+
+func frameInterval(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("interval")
+	if value == "" {
+		return DefaultFrameInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, badRequestError("invalid interval: " + value)
+	}
+	if interval < minFrameInterval {
+		interval = minFrameInterval
+	}
+	return interval, nil
+}
@@ -0,0 +1,65 @@
+/*
+Package adbmock provides fakes of the adb package's interfaces, for unit-testing code that
+uses goadb without a connected device.
+
+E.g.
+
+	device := &adbmock.Device{
+		SerialFunc: func() (string, error) { return "emulator-5554", nil },
+		RunCommandFunc: func(cmd string, args ...string) (string, error) {
+			return "package:com.example\n", nil
+		},
+	}
+	runMyCode(device)
+	fmt.Println(device.Calls())
+*/
+package adbmock
+
+import (
+	"errors"
+	"sync"
+)
+
+//go:generate go run ./internal/mockgen -src ../device_client.go -out device.go
+
+// ErrNotMocked is returned by fake methods whose function field isn't set.
+var ErrNotMocked = errors.New("adbmock: method not mocked")
+
+// Call is a method call recorded by a fake.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Calls returns the calls made on the fake, in order.
+func (m *Device) Calls() []Call {
+	return m.calls.get()
+}
+
+// CallsTo returns the calls made to method, in order.
+func (m *Device) CallsTo(method string) []Call {
+	var matching []Call
+	for _, call := range m.calls.get() {
+		if call.Method == method {
+			matching = append(matching, call)
+		}
+	}
+	return matching
+}
+
+type calls struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (c *calls) record(method string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Method: method, Args: args})
+}
+
+func (c *calls) get() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call{}, c.calls...)
+}
@@ -0,0 +1,45 @@
+package adbmock
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+func serialAndPackages(device adb.DeviceClient) (string, string, error) {
+	serial, err := device.Serial()
+	if err != nil {
+		return "", "", err
+	}
+	output, err := device.RunCommand("pm", "list", "packages")
+	return serial, output, err
+}
+
+func TestDevice(t *testing.T) {
+	device := &Device{
+		SerialFunc: func() (string, error) { return "emulator-5554", nil },
+		RunCommandFunc: func(cmd string, args ...string) (string, error) {
+			return "package:com.example\n", nil
+		},
+	}
+
+	serial, output, err := serialAndPackages(device)
+	assert.NoError(t, err)
+	assert.Equal(t, "emulator-5554", serial)
+	assert.Equal(t, "package:com.example\n", output)
+	assert.Equal(t, []Call{
+		{Method: "Serial"},
+		{Method: "RunCommand", Args: []interface{}{"pm", []string{"list", "packages"}}},
+	}, device.Calls())
+	assert.Len(t, device.CallsTo("RunCommand"), 1)
+}
+
+func TestDeviceNotMocked(t *testing.T) {
+	device := &Device{}
+
+	sdk, err := device.SDKVersion()
+	assert.Equal(t, 0, sdk)
+	assert.Equal(t, ErrNotMocked, err)
+	assert.Equal(t, "", device.String())
+}
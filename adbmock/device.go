@@ -0,0 +1,1883 @@
+// Code generated by "mockgen"; DO NOT EDIT.
+
+package adbmock
+
+import (
+	"context"
+	"image"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+var _ adb.DeviceClient = &Device{}
+
+// Device is a fake adb.DeviceClient. Each method calls the function in the field with the
+// method's name and a Func suffix, e.g. SerialFunc for Serial, and records the call. If the
+// field is nil, the method returns zero values and ErrNotMocked.
+type Device struct {
+	calls calls
+
+	StringFunc                       func() string
+	SerialFunc                       func() (string, error)
+	DevicePathFunc                   func() (string, error)
+	StateFunc                        func() (adb.DeviceState, error)
+	DeviceInfoFunc                   func() (*adb.DeviceInfo, error)
+	FeaturesFunc                     func() ([]string, error)
+	HasFeatureFunc                   func(feature string) (bool, error)
+	GetPropertyFunc                  func(name string) (string, error)
+	PropertiesFunc                   func() (map[string]string, error)
+	WatchPropsFunc                   func(ctx context.Context) (*adb.PropertyWatcher, error)
+	ListProcessesFunc                func() ([]*adb.Process, error)
+	PidOfFunc                        func(name string) ([]int, error)
+	KillFunc                         func(pid int, signal syscall.Signal) error
+	SampleProcessesFunc              func(ctx context.Context, config adb.ProcessSamplerConfig) (*adb.ProcessSampler, error)
+	SDKVersionFunc                   func() (int, error)
+	IsRootFunc                       func() (bool, error)
+	IsEmulatorFunc                   func() (bool, error)
+	AvdNameFunc                      func() (string, error)
+	RunCommandFunc                   func(cmd string, args ...string) (string, error)
+	OpenCommandFunc                  func(cmd string, args ...string) (io.ReadCloser, error)
+	RunAbbFunc                       func(service string, args ...string) (string, error)
+	RunAdbCmdFunc                    func(cmd string) (string, error)
+	RunAdbCmdCtxFunc                 func(ctx context.Context, cmd string) (string, error)
+	RunAdbCmdCtxWithTimeoutFunc      func(ctx context.Context, cmd string, duration time.Duration) (string, error)
+	RunAdbCmdCtxWithStdoutPipeFunc   func(ctx context.Context, cmd string) (io.ReadCloser, error)
+	RunAdbShellCmdCtxFunc            func(ctx context.Context, cmd string) (string, error)
+	RunAdbShellCmdCtxWithTimeoutFunc func(ctx context.Context, cmd string, duration time.Duration) (string, error)
+	RemountFunc                      func() (string, error)
+	ListDirEntriesFunc               func(path string) (*adb.DirEntries, error)
+	StatFunc                         func(path string) (*adb.DirEntry, error)
+	OpenReadFunc                     func(path string) (io.ReadCloser, error)
+	OpenWriteFunc                    func(path string, perms os.FileMode, mtime time.Time) (io.WriteCloser, error)
+	TailFileFunc                     func(ctx context.Context, path string, fromEnd bool) (io.ReadCloser, error)
+	WatchPathFunc                    func(ctx context.Context, path string) (*adb.PathWatcher, error)
+	MkdirAllFunc                     func(path string, perm os.FileMode) error
+	RemoveFunc                       func(path string) error
+	RemoveAllFunc                    func(path string) error
+	RenameFunc                       func(oldPath, newPath string) error
+	CopyFunc                         func(src, dst string) error
+	ChmodFunc                        func(path string, mode os.FileMode) error
+	ReadLinkFunc                     func(path string) (string, error)
+	CreateSymlinkFunc                func(target, path string) error
+	ChecksumToolsFunc                func() (*adb.ChecksumTools, error)
+	Md5sumFunc                       func(path string) ([]byte, error)
+	Sha256sumFunc                    func(path string) ([]byte, error)
+	PushDirFunc                      func(localDir, remoteDir string, opts adb.TransferOptions) error
+	PullDirFunc                      func(remoteDir, localDir string, opts adb.TransferOptions) error
+	PullDirCompressedFunc            func(remoteDir, localDir string, opts adb.TransferOptions) error
+	PushFunc                         func(localPath, remotePath string) (string, error)
+	PushWithProgressFunc             func(ctx context.Context, showProgress bool, localPath, remotePath string, cb func(event adb.PushEvent)) (*adb.TransferStats, error)
+	PushReaderFunc                   func(ctx context.Context, r io.Reader, remotePath string, perms os.FileMode, mtime time.Time, sizeHint int64) (*adb.TransferStats, error)
+	PullWithProgressFunc             func(ctx context.Context, showProgress bool, remotePath, localPath string, cb func(event adb.PushEvent)) (*adb.TransferStats, error)
+	PullToFunc                       func(ctx context.Context, remotePath string, w io.Writer) (*adb.TransferStats, error)
+	PullToWithProgressFunc           func(ctx context.Context, remotePath string, w io.Writer, cb func(event adb.PushEvent)) (*adb.TransferStats, error)
+	ForwardFunc                      func(local, remote string) (string, error)
+	ForwardToFreePortFunc            func(remote string) (int, error)
+	ListForwardsFunc                 func() ([]adb.ForwardEntry, error)
+	RemoveForwardFunc                func(local string) error
+	RemoveAllForwardsFunc            func() error
+	ClearForwardAllFunc              func() (string, error)
+	ClearForwardBySerialFunc         func(deviceId string, port int, remote string) (string, error)
+	ReverseFunc                      func(remote, local string) (string, error)
+	ListReversesFunc                 func() ([]adb.ReverseEntry, error)
+	RemoveReverseFunc                func(remote string) error
+	RemoveAllReversesFunc            func() error
+	DialLocalAbstractFunc            func(name string) (net.Conn, error)
+	DialTCPOnDeviceFunc              func(port int) (net.Conn, error)
+	InstallAppFunc                   func(ctx context.Context, apk string, reinstall bool, grantPermission bool) (string, error)
+	InstallAppByPmFunc               func(ctx context.Context, apk string, reinstall bool, grantPermission bool) (string, error)
+	InstallAppStreamFunc             func(r io.Reader, size int64, reinstall bool, grantPermission bool) error
+	InstallAppIncrementalFunc        func(ctx context.Context, apk string, reinstall bool, grantPermission bool) (*adb.IncrementalInstall, error)
+	InstallAppFileFunc               func(path string, opts adb.InstallOptions) (bool, error)
+	InstalledApkInfoFunc             func(pkg string) (*adb.ApkInfo, error)
+	UninstallAppFunc                 func(ctx context.Context, pkg string) (string, error)
+	LaunchApkFunc                    func(pkg string) (string, error)
+	ClearDataFunc                    func(pkg string) error
+	DisablePackageFunc               func(pkg string) error
+	EnablePackageFunc                func(pkg string) error
+	PackageEnabledStateFunc          func(pkg string) (adb.PackageEnabledState, error)
+	SetAppOpFunc                     func(pkg, op string, mode adb.AppOpMode) error
+	GetAppOpsFunc                    func(pkg string) ([]adb.AppOp, error)
+	SetDeviceOwnerFunc               func(admin string) error
+	RemoveActiveAdminFunc            func(admin string) error
+	DeviceStateIDFunc                func() (int, error)
+	OverrideDeviceStateFunc          func(id int) error
+	ClearDeviceStateOverrideFunc     func() error
+	EnableTestHarnessFunc            func() error
+	GrantPermissionFunc              func(pkg, permission string) error
+	RevokePermissionFunc             func(pkg, permission string) error
+	CurrentActivityFunc              func() (*adb.Activity, error)
+	ForceStopFunc                    func(pkg string) error
+	MeasureLaunchFunc                func(activity adb.Activity) (*adb.LaunchResult, error)
+	BenchmarkLaunchFunc              func(ctx context.Context, activity adb.Activity, opts adb.LaunchBenchmarkOptions) (*adb.LaunchBenchmark, error)
+	WatchANRsFunc                    func(ctx context.Context) (*adb.ANRWatcher, error)
+	DropboxEntriesFunc               func(since time.Time, tags ...string) ([]adb.DropboxEntry, error)
+	DmesgFunc                        func(ctx context.Context, follow bool) (*adb.KernelLogWatcher, error)
+	ProcessMemoryFunc                func() ([]adb.ProcessMemory, error)
+	MemoryMappingsFunc               func(pid int) ([]adb.MemoryMapping, error)
+	LibraryMemoryFunc                func() ([]adb.LibraryMemory, error)
+	ThermalFunc                      func() (*adb.ThermalState, error)
+	SampleThermalFunc                func(ctx context.Context, interval time.Duration) (*adb.ThermalSampler, error)
+	GPUSourceFunc                    func() (*adb.GPUSource, error)
+	SampleGPUFunc                    func(ctx context.Context, interval time.Duration) (*adb.GPUSampler, error)
+	SurfaceFlingerLayersFunc         func() ([]string, error)
+	FrameLatencyFunc                 func(layer string) (*adb.FrameLatency, error)
+	ClearFrameLatencyFunc            func(layer string) error
+	ResetBatteryStatsFunc            func() error
+	DumpBatteryStatsFunc             func(w io.Writer, checkin bool) error
+	PowerRailsFunc                   func() ([]adb.PowerRail, error)
+	SamplePowerRailsFunc             func(ctx context.Context, interval time.Duration) (*adb.PowerRailSampler, error)
+	ProbeLinkFunc                    func(ctx context.Context, opts adb.LinkProbeOptions) (*adb.LinkQuality, error)
+	ListPackagesFunc                 func() ([]adb.Package, error)
+	ListInstrumentationFunc          func() ([]adb.InstrumentationTarget, error)
+	InstallTestOrchestratorFunc      func(orchestratorAPK, servicesAPK string) error
+	RunOrchestratedTestsFunc         func(ctx context.Context, runner string, opts adb.OrchestratorOptions) ([]adb.TestResult, error)
+	CollectArtifactsFunc             func(opts adb.ArtifactOptions) ([]adb.Artifact, error)
+	TrafficByPackageFunc             func() ([]adb.PackageTraffic, error)
+	WakeLocksFunc                    func() ([]adb.WakeLock, error)
+	WakeLockStatsFunc                func() ([]adb.WakeLockStats, error)
+	SampleWakeLocksFunc              func(ctx context.Context, interval time.Duration) (*adb.WakeLockSampler, error)
+	CPUClustersFunc                  func() ([]adb.CPUCluster, error)
+	PinCPUFrequencyFunc              func(policy, freq int) error
+	SetCPUGovernorFunc               func(policy int, governor string) error
+	RestoreCPUDefaultsFunc           func() error
+	StraceFunc                       func(ctx context.Context, pid int, opts adb.StraceOptions) (*adb.StraceWatcher, error)
+	StracePackageFunc                func(ctx context.Context, pkg string, opts adb.StraceOptions) (*adb.StraceWatcher, error)
+	WatchStateFunc                   func(ctx context.Context) (*adb.DeviceStateWatcher, error)
+	WaitForBootCompleteFunc          func(ctx context.Context) error
+	ListUsersFunc                    func() ([]*adb.UserInfo, error)
+	ClickFunc                        func(x, y int) (string, error)
+	DragFunc                         func(x, y, x1, y1 int) (string, error)
+	HomeFunc                         func() (string, error)
+	InputTextFunc                    func(text string) (string, error)
+	ScreenShotFunc                   func() ([]byte, error)
+	ScreenShotImageFunc              func() (image.Image, error)
+	ScreenShotWithOptionsFunc        func(opts adb.ScreenshotOptions) ([]byte, error)
+	ScreenRecordFunc                 func(ctx context.Context, remotePath string, opts adb.ScreenRecordOptions) error
+	StartRecordingFunc               func(ctx context.Context, opts adb.RecordingOptions) (*adb.RecordingSession, error)
+	SetStatusBarFunc                 func(action adb.StatusBarAction) error
+	IsScreenOnFunc                   func() (bool, error)
+	IsLockedFunc                     func() (bool, error)
+	WakeFunc                         func() error
+	UnlockFunc                       func() error
+	SetStayAwakeFunc                 func(enabled bool) error
+	DisplayInfoFunc                  func() (*adb.DisplayInfo, error)
+	DisplaysFunc                     func() ([]*adb.Display, error)
+	SetDisplaySizeFunc               func(width, height int) error
+	SetDensityFunc                   func(dpi int) error
+	ResetDisplayFunc                 func() error
+	RotationFunc                     func() (adb.Rotation, error)
+	SetRotationFunc                  func(rotation adb.Rotation) error
+	SetAutoRotateFunc                func(enabled bool) error
+	LocaleFunc                       func() (string, error)
+	SetLocaleFunc                    func(locale string, method adb.LocaleMethod) error
+	TimezoneFunc                     func() (string, error)
+	SetTimezoneFunc                  func(tz string) error
+	TimeFunc                         func() (time.Time, error)
+	SetTimeFunc                      func(t time.Time) error
+	SetAirplaneModeFunc              func(enabled bool) error
+	IsAirplaneModeFunc               func() (bool, error)
+	SetWifiEnabledFunc               func(enabled bool) error
+	IsWifiEnabledFunc                func() (bool, error)
+	SetMobileDataEnabledFunc         func(enabled bool) error
+	IsMobileDataEnabledFunc          func() (bool, error)
+	ConnectWifiFunc                  func(ctx context.Context, network adb.WifiNetwork) error
+	WifiSSIDFunc                     func() (string, error)
+	SetGlobalProxyFunc               func(host string, port int, exclusions ...string) error
+	ClearGlobalProxyFunc             func() error
+	GlobalProxyFunc                  func() (*adb.ProxyConfig, error)
+}
+
+// String calls StringFunc.
+func (m *Device) String() (r0 string) {
+	m.calls.record("String")
+	if m.StringFunc != nil {
+		return m.StringFunc()
+	}
+	return
+}
+
+// Serial calls SerialFunc.
+func (m *Device) Serial() (r0 string, r1 error) {
+	m.calls.record("Serial")
+	if m.SerialFunc != nil {
+		return m.SerialFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// DevicePath calls DevicePathFunc.
+func (m *Device) DevicePath() (r0 string, r1 error) {
+	m.calls.record("DevicePath")
+	if m.DevicePathFunc != nil {
+		return m.DevicePathFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// State calls StateFunc.
+func (m *Device) State() (r0 adb.DeviceState, r1 error) {
+	m.calls.record("State")
+	if m.StateFunc != nil {
+		return m.StateFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// DeviceInfo calls DeviceInfoFunc.
+func (m *Device) DeviceInfo() (r0 *adb.DeviceInfo, r1 error) {
+	m.calls.record("DeviceInfo")
+	if m.DeviceInfoFunc != nil {
+		return m.DeviceInfoFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Features calls FeaturesFunc.
+func (m *Device) Features() (r0 []string, r1 error) {
+	m.calls.record("Features")
+	if m.FeaturesFunc != nil {
+		return m.FeaturesFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// HasFeature calls HasFeatureFunc.
+func (m *Device) HasFeature(p0 string) (r0 bool, r1 error) {
+	m.calls.record("HasFeature", p0)
+	if m.HasFeatureFunc != nil {
+		return m.HasFeatureFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// GetProperty calls GetPropertyFunc.
+func (m *Device) GetProperty(p0 string) (r0 string, r1 error) {
+	m.calls.record("GetProperty", p0)
+	if m.GetPropertyFunc != nil {
+		return m.GetPropertyFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Properties calls PropertiesFunc.
+func (m *Device) Properties() (r0 map[string]string, r1 error) {
+	m.calls.record("Properties")
+	if m.PropertiesFunc != nil {
+		return m.PropertiesFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// WatchProps calls WatchPropsFunc.
+func (m *Device) WatchProps(p0 context.Context) (r0 *adb.PropertyWatcher, r1 error) {
+	m.calls.record("WatchProps", p0)
+	if m.WatchPropsFunc != nil {
+		return m.WatchPropsFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ListProcesses calls ListProcessesFunc.
+func (m *Device) ListProcesses() (r0 []*adb.Process, r1 error) {
+	m.calls.record("ListProcesses")
+	if m.ListProcessesFunc != nil {
+		return m.ListProcessesFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PidOf calls PidOfFunc.
+func (m *Device) PidOf(p0 string) (r0 []int, r1 error) {
+	m.calls.record("PidOf", p0)
+	if m.PidOfFunc != nil {
+		return m.PidOfFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Kill calls KillFunc.
+func (m *Device) Kill(p0 int, p1 syscall.Signal) (r0 error) {
+	m.calls.record("Kill", p0, p1)
+	if m.KillFunc != nil {
+		return m.KillFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// SampleProcesses calls SampleProcessesFunc.
+func (m *Device) SampleProcesses(p0 context.Context, p1 adb.ProcessSamplerConfig) (r0 *adb.ProcessSampler, r1 error) {
+	m.calls.record("SampleProcesses", p0, p1)
+	if m.SampleProcessesFunc != nil {
+		return m.SampleProcessesFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SDKVersion calls SDKVersionFunc.
+func (m *Device) SDKVersion() (r0 int, r1 error) {
+	m.calls.record("SDKVersion")
+	if m.SDKVersionFunc != nil {
+		return m.SDKVersionFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// IsRoot calls IsRootFunc.
+func (m *Device) IsRoot() (r0 bool, r1 error) {
+	m.calls.record("IsRoot")
+	if m.IsRootFunc != nil {
+		return m.IsRootFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// IsEmulator calls IsEmulatorFunc.
+func (m *Device) IsEmulator() (r0 bool, r1 error) {
+	m.calls.record("IsEmulator")
+	if m.IsEmulatorFunc != nil {
+		return m.IsEmulatorFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// AvdName calls AvdNameFunc.
+func (m *Device) AvdName() (r0 string, r1 error) {
+	m.calls.record("AvdName")
+	if m.AvdNameFunc != nil {
+		return m.AvdNameFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunCommand calls RunCommandFunc.
+func (m *Device) RunCommand(p0 string, p1 ...string) (r0 string, r1 error) {
+	m.calls.record("RunCommand", p0, p1)
+	if m.RunCommandFunc != nil {
+		return m.RunCommandFunc(p0, p1...)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// OpenCommand calls OpenCommandFunc.
+func (m *Device) OpenCommand(p0 string, p1 ...string) (r0 io.ReadCloser, r1 error) {
+	m.calls.record("OpenCommand", p0, p1)
+	if m.OpenCommandFunc != nil {
+		return m.OpenCommandFunc(p0, p1...)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunAbb calls RunAbbFunc.
+func (m *Device) RunAbb(p0 string, p1 ...string) (r0 string, r1 error) {
+	m.calls.record("RunAbb", p0, p1)
+	if m.RunAbbFunc != nil {
+		return m.RunAbbFunc(p0, p1...)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunAdbCmd calls RunAdbCmdFunc.
+func (m *Device) RunAdbCmd(p0 string) (r0 string, r1 error) {
+	m.calls.record("RunAdbCmd", p0)
+	if m.RunAdbCmdFunc != nil {
+		return m.RunAdbCmdFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunAdbCmdCtx calls RunAdbCmdCtxFunc.
+func (m *Device) RunAdbCmdCtx(p0 context.Context, p1 string) (r0 string, r1 error) {
+	m.calls.record("RunAdbCmdCtx", p0, p1)
+	if m.RunAdbCmdCtxFunc != nil {
+		return m.RunAdbCmdCtxFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunAdbCmdCtxWithTimeout calls RunAdbCmdCtxWithTimeoutFunc.
+func (m *Device) RunAdbCmdCtxWithTimeout(p0 context.Context, p1 string, p2 time.Duration) (r0 string, r1 error) {
+	m.calls.record("RunAdbCmdCtxWithTimeout", p0, p1, p2)
+	if m.RunAdbCmdCtxWithTimeoutFunc != nil {
+		return m.RunAdbCmdCtxWithTimeoutFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunAdbCmdCtxWithStdoutPipe calls RunAdbCmdCtxWithStdoutPipeFunc.
+func (m *Device) RunAdbCmdCtxWithStdoutPipe(p0 context.Context, p1 string) (r0 io.ReadCloser, r1 error) {
+	m.calls.record("RunAdbCmdCtxWithStdoutPipe", p0, p1)
+	if m.RunAdbCmdCtxWithStdoutPipeFunc != nil {
+		return m.RunAdbCmdCtxWithStdoutPipeFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunAdbShellCmdCtx calls RunAdbShellCmdCtxFunc.
+func (m *Device) RunAdbShellCmdCtx(p0 context.Context, p1 string) (r0 string, r1 error) {
+	m.calls.record("RunAdbShellCmdCtx", p0, p1)
+	if m.RunAdbShellCmdCtxFunc != nil {
+		return m.RunAdbShellCmdCtxFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RunAdbShellCmdCtxWithTimeout calls RunAdbShellCmdCtxWithTimeoutFunc.
+func (m *Device) RunAdbShellCmdCtxWithTimeout(p0 context.Context, p1 string, p2 time.Duration) (r0 string, r1 error) {
+	m.calls.record("RunAdbShellCmdCtxWithTimeout", p0, p1, p2)
+	if m.RunAdbShellCmdCtxWithTimeoutFunc != nil {
+		return m.RunAdbShellCmdCtxWithTimeoutFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Remount calls RemountFunc.
+func (m *Device) Remount() (r0 string, r1 error) {
+	m.calls.record("Remount")
+	if m.RemountFunc != nil {
+		return m.RemountFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ListDirEntries calls ListDirEntriesFunc.
+func (m *Device) ListDirEntries(p0 string) (r0 *adb.DirEntries, r1 error) {
+	m.calls.record("ListDirEntries", p0)
+	if m.ListDirEntriesFunc != nil {
+		return m.ListDirEntriesFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Stat calls StatFunc.
+func (m *Device) Stat(p0 string) (r0 *adb.DirEntry, r1 error) {
+	m.calls.record("Stat", p0)
+	if m.StatFunc != nil {
+		return m.StatFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// OpenRead calls OpenReadFunc.
+func (m *Device) OpenRead(p0 string) (r0 io.ReadCloser, r1 error) {
+	m.calls.record("OpenRead", p0)
+	if m.OpenReadFunc != nil {
+		return m.OpenReadFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// OpenWrite calls OpenWriteFunc.
+func (m *Device) OpenWrite(p0 string, p1 os.FileMode, p2 time.Time) (r0 io.WriteCloser, r1 error) {
+	m.calls.record("OpenWrite", p0, p1, p2)
+	if m.OpenWriteFunc != nil {
+		return m.OpenWriteFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// TailFile calls TailFileFunc.
+func (m *Device) TailFile(p0 context.Context, p1 string, p2 bool) (r0 io.ReadCloser, r1 error) {
+	m.calls.record("TailFile", p0, p1, p2)
+	if m.TailFileFunc != nil {
+		return m.TailFileFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// WatchPath calls WatchPathFunc.
+func (m *Device) WatchPath(p0 context.Context, p1 string) (r0 *adb.PathWatcher, r1 error) {
+	m.calls.record("WatchPath", p0, p1)
+	if m.WatchPathFunc != nil {
+		return m.WatchPathFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// MkdirAll calls MkdirAllFunc.
+func (m *Device) MkdirAll(p0 string, p1 os.FileMode) (r0 error) {
+	m.calls.record("MkdirAll", p0, p1)
+	if m.MkdirAllFunc != nil {
+		return m.MkdirAllFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Remove calls RemoveFunc.
+func (m *Device) Remove(p0 string) (r0 error) {
+	m.calls.record("Remove", p0)
+	if m.RemoveFunc != nil {
+		return m.RemoveFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// RemoveAll calls RemoveAllFunc.
+func (m *Device) RemoveAll(p0 string) (r0 error) {
+	m.calls.record("RemoveAll", p0)
+	if m.RemoveAllFunc != nil {
+		return m.RemoveAllFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Rename calls RenameFunc.
+func (m *Device) Rename(p0 string, p1 string) (r0 error) {
+	m.calls.record("Rename", p0, p1)
+	if m.RenameFunc != nil {
+		return m.RenameFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Copy calls CopyFunc.
+func (m *Device) Copy(p0 string, p1 string) (r0 error) {
+	m.calls.record("Copy", p0, p1)
+	if m.CopyFunc != nil {
+		return m.CopyFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Chmod calls ChmodFunc.
+func (m *Device) Chmod(p0 string, p1 os.FileMode) (r0 error) {
+	m.calls.record("Chmod", p0, p1)
+	if m.ChmodFunc != nil {
+		return m.ChmodFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ReadLink calls ReadLinkFunc.
+func (m *Device) ReadLink(p0 string) (r0 string, r1 error) {
+	m.calls.record("ReadLink", p0)
+	if m.ReadLinkFunc != nil {
+		return m.ReadLinkFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// CreateSymlink calls CreateSymlinkFunc.
+func (m *Device) CreateSymlink(p0 string, p1 string) (r0 error) {
+	m.calls.record("CreateSymlink", p0, p1)
+	if m.CreateSymlinkFunc != nil {
+		return m.CreateSymlinkFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ChecksumTools calls ChecksumToolsFunc.
+func (m *Device) ChecksumTools() (r0 *adb.ChecksumTools, r1 error) {
+	m.calls.record("ChecksumTools")
+	if m.ChecksumToolsFunc != nil {
+		return m.ChecksumToolsFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Md5sum calls Md5sumFunc.
+func (m *Device) Md5sum(p0 string) (r0 []byte, r1 error) {
+	m.calls.record("Md5sum", p0)
+	if m.Md5sumFunc != nil {
+		return m.Md5sumFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Sha256sum calls Sha256sumFunc.
+func (m *Device) Sha256sum(p0 string) (r0 []byte, r1 error) {
+	m.calls.record("Sha256sum", p0)
+	if m.Sha256sumFunc != nil {
+		return m.Sha256sumFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PushDir calls PushDirFunc.
+func (m *Device) PushDir(p0 string, p1 string, p2 adb.TransferOptions) (r0 error) {
+	m.calls.record("PushDir", p0, p1, p2)
+	if m.PushDirFunc != nil {
+		return m.PushDirFunc(p0, p1, p2)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// PullDir calls PullDirFunc.
+func (m *Device) PullDir(p0 string, p1 string, p2 adb.TransferOptions) (r0 error) {
+	m.calls.record("PullDir", p0, p1, p2)
+	if m.PullDirFunc != nil {
+		return m.PullDirFunc(p0, p1, p2)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// PullDirCompressed calls PullDirCompressedFunc.
+func (m *Device) PullDirCompressed(p0 string, p1 string, p2 adb.TransferOptions) (r0 error) {
+	m.calls.record("PullDirCompressed", p0, p1, p2)
+	if m.PullDirCompressedFunc != nil {
+		return m.PullDirCompressedFunc(p0, p1, p2)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Push calls PushFunc.
+func (m *Device) Push(p0 string, p1 string) (r0 string, r1 error) {
+	m.calls.record("Push", p0, p1)
+	if m.PushFunc != nil {
+		return m.PushFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PushWithProgress calls PushWithProgressFunc.
+func (m *Device) PushWithProgress(p0 context.Context, p1 bool, p2 string, p3 string, p4 func(event adb.PushEvent)) (r0 *adb.TransferStats, r1 error) {
+	m.calls.record("PushWithProgress", p0, p1, p2, p3, p4)
+	if m.PushWithProgressFunc != nil {
+		return m.PushWithProgressFunc(p0, p1, p2, p3, p4)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PushReader calls PushReaderFunc.
+func (m *Device) PushReader(p0 context.Context, p1 io.Reader, p2 string, p3 os.FileMode, p4 time.Time, p5 int64) (r0 *adb.TransferStats, r1 error) {
+	m.calls.record("PushReader", p0, p1, p2, p3, p4, p5)
+	if m.PushReaderFunc != nil {
+		return m.PushReaderFunc(p0, p1, p2, p3, p4, p5)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PullWithProgress calls PullWithProgressFunc.
+func (m *Device) PullWithProgress(p0 context.Context, p1 bool, p2 string, p3 string, p4 func(event adb.PushEvent)) (r0 *adb.TransferStats, r1 error) {
+	m.calls.record("PullWithProgress", p0, p1, p2, p3, p4)
+	if m.PullWithProgressFunc != nil {
+		return m.PullWithProgressFunc(p0, p1, p2, p3, p4)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PullTo calls PullToFunc.
+func (m *Device) PullTo(p0 context.Context, p1 string, p2 io.Writer) (r0 *adb.TransferStats, r1 error) {
+	m.calls.record("PullTo", p0, p1, p2)
+	if m.PullToFunc != nil {
+		return m.PullToFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PullToWithProgress calls PullToWithProgressFunc.
+func (m *Device) PullToWithProgress(p0 context.Context, p1 string, p2 io.Writer, p3 func(event adb.PushEvent)) (r0 *adb.TransferStats, r1 error) {
+	m.calls.record("PullToWithProgress", p0, p1, p2, p3)
+	if m.PullToWithProgressFunc != nil {
+		return m.PullToWithProgressFunc(p0, p1, p2, p3)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Forward calls ForwardFunc.
+func (m *Device) Forward(p0 string, p1 string) (r0 string, r1 error) {
+	m.calls.record("Forward", p0, p1)
+	if m.ForwardFunc != nil {
+		return m.ForwardFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ForwardToFreePort calls ForwardToFreePortFunc.
+func (m *Device) ForwardToFreePort(p0 string) (r0 int, r1 error) {
+	m.calls.record("ForwardToFreePort", p0)
+	if m.ForwardToFreePortFunc != nil {
+		return m.ForwardToFreePortFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ListForwards calls ListForwardsFunc.
+func (m *Device) ListForwards() (r0 []adb.ForwardEntry, r1 error) {
+	m.calls.record("ListForwards")
+	if m.ListForwardsFunc != nil {
+		return m.ListForwardsFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RemoveForward calls RemoveForwardFunc.
+func (m *Device) RemoveForward(p0 string) (r0 error) {
+	m.calls.record("RemoveForward", p0)
+	if m.RemoveForwardFunc != nil {
+		return m.RemoveForwardFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// RemoveAllForwards calls RemoveAllForwardsFunc.
+func (m *Device) RemoveAllForwards() (r0 error) {
+	m.calls.record("RemoveAllForwards")
+	if m.RemoveAllForwardsFunc != nil {
+		return m.RemoveAllForwardsFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ClearForwardAll calls ClearForwardAllFunc.
+func (m *Device) ClearForwardAll() (r0 string, r1 error) {
+	m.calls.record("ClearForwardAll")
+	if m.ClearForwardAllFunc != nil {
+		return m.ClearForwardAllFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ClearForwardBySerial calls ClearForwardBySerialFunc.
+func (m *Device) ClearForwardBySerial(p0 string, p1 int, p2 string) (r0 string, r1 error) {
+	m.calls.record("ClearForwardBySerial", p0, p1, p2)
+	if m.ClearForwardBySerialFunc != nil {
+		return m.ClearForwardBySerialFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Reverse calls ReverseFunc.
+func (m *Device) Reverse(p0 string, p1 string) (r0 string, r1 error) {
+	m.calls.record("Reverse", p0, p1)
+	if m.ReverseFunc != nil {
+		return m.ReverseFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ListReverses calls ListReversesFunc.
+func (m *Device) ListReverses() (r0 []adb.ReverseEntry, r1 error) {
+	m.calls.record("ListReverses")
+	if m.ListReversesFunc != nil {
+		return m.ListReversesFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// RemoveReverse calls RemoveReverseFunc.
+func (m *Device) RemoveReverse(p0 string) (r0 error) {
+	m.calls.record("RemoveReverse", p0)
+	if m.RemoveReverseFunc != nil {
+		return m.RemoveReverseFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// RemoveAllReverses calls RemoveAllReversesFunc.
+func (m *Device) RemoveAllReverses() (r0 error) {
+	m.calls.record("RemoveAllReverses")
+	if m.RemoveAllReversesFunc != nil {
+		return m.RemoveAllReversesFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// DialLocalAbstract calls DialLocalAbstractFunc.
+func (m *Device) DialLocalAbstract(p0 string) (r0 net.Conn, r1 error) {
+	m.calls.record("DialLocalAbstract", p0)
+	if m.DialLocalAbstractFunc != nil {
+		return m.DialLocalAbstractFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// DialTCPOnDevice calls DialTCPOnDeviceFunc.
+func (m *Device) DialTCPOnDevice(p0 int) (r0 net.Conn, r1 error) {
+	m.calls.record("DialTCPOnDevice", p0)
+	if m.DialTCPOnDeviceFunc != nil {
+		return m.DialTCPOnDeviceFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// InstallApp calls InstallAppFunc.
+func (m *Device) InstallApp(p0 context.Context, p1 string, p2 bool, p3 bool) (r0 string, r1 error) {
+	m.calls.record("InstallApp", p0, p1, p2, p3)
+	if m.InstallAppFunc != nil {
+		return m.InstallAppFunc(p0, p1, p2, p3)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// InstallAppByPm calls InstallAppByPmFunc.
+func (m *Device) InstallAppByPm(p0 context.Context, p1 string, p2 bool, p3 bool) (r0 string, r1 error) {
+	m.calls.record("InstallAppByPm", p0, p1, p2, p3)
+	if m.InstallAppByPmFunc != nil {
+		return m.InstallAppByPmFunc(p0, p1, p2, p3)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// InstallAppStream calls InstallAppStreamFunc.
+func (m *Device) InstallAppStream(p0 io.Reader, p1 int64, p2 bool, p3 bool) (r0 error) {
+	m.calls.record("InstallAppStream", p0, p1, p2, p3)
+	if m.InstallAppStreamFunc != nil {
+		return m.InstallAppStreamFunc(p0, p1, p2, p3)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// InstallAppIncremental calls InstallAppIncrementalFunc.
+func (m *Device) InstallAppIncremental(p0 context.Context, p1 string, p2 bool, p3 bool) (r0 *adb.IncrementalInstall, r1 error) {
+	m.calls.record("InstallAppIncremental", p0, p1, p2, p3)
+	if m.InstallAppIncrementalFunc != nil {
+		return m.InstallAppIncrementalFunc(p0, p1, p2, p3)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// InstallAppFile calls InstallAppFileFunc.
+func (m *Device) InstallAppFile(p0 string, p1 adb.InstallOptions) (r0 bool, r1 error) {
+	m.calls.record("InstallAppFile", p0, p1)
+	if m.InstallAppFileFunc != nil {
+		return m.InstallAppFileFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// InstalledApkInfo calls InstalledApkInfoFunc.
+func (m *Device) InstalledApkInfo(p0 string) (r0 *adb.ApkInfo, r1 error) {
+	m.calls.record("InstalledApkInfo", p0)
+	if m.InstalledApkInfoFunc != nil {
+		return m.InstalledApkInfoFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// UninstallApp calls UninstallAppFunc.
+func (m *Device) UninstallApp(p0 context.Context, p1 string) (r0 string, r1 error) {
+	m.calls.record("UninstallApp", p0, p1)
+	if m.UninstallAppFunc != nil {
+		return m.UninstallAppFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// LaunchApk calls LaunchApkFunc.
+func (m *Device) LaunchApk(p0 string) (r0 string, r1 error) {
+	m.calls.record("LaunchApk", p0)
+	if m.LaunchApkFunc != nil {
+		return m.LaunchApkFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ClearData calls ClearDataFunc.
+func (m *Device) ClearData(p0 string) (r0 error) {
+	m.calls.record("ClearData", p0)
+	if m.ClearDataFunc != nil {
+		return m.ClearDataFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// DisablePackage calls DisablePackageFunc.
+func (m *Device) DisablePackage(p0 string) (r0 error) {
+	m.calls.record("DisablePackage", p0)
+	if m.DisablePackageFunc != nil {
+		return m.DisablePackageFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// EnablePackage calls EnablePackageFunc.
+func (m *Device) EnablePackage(p0 string) (r0 error) {
+	m.calls.record("EnablePackage", p0)
+	if m.EnablePackageFunc != nil {
+		return m.EnablePackageFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// PackageEnabledState calls PackageEnabledStateFunc.
+func (m *Device) PackageEnabledState(p0 string) (r0 adb.PackageEnabledState, r1 error) {
+	m.calls.record("PackageEnabledState", p0)
+	if m.PackageEnabledStateFunc != nil {
+		return m.PackageEnabledStateFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetAppOp calls SetAppOpFunc.
+func (m *Device) SetAppOp(p0 string, p1 string, p2 adb.AppOpMode) (r0 error) {
+	m.calls.record("SetAppOp", p0, p1, p2)
+	if m.SetAppOpFunc != nil {
+		return m.SetAppOpFunc(p0, p1, p2)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// GetAppOps calls GetAppOpsFunc.
+func (m *Device) GetAppOps(p0 string) (r0 []adb.AppOp, r1 error) {
+	m.calls.record("GetAppOps", p0)
+	if m.GetAppOpsFunc != nil {
+		return m.GetAppOpsFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetDeviceOwner calls SetDeviceOwnerFunc.
+func (m *Device) SetDeviceOwner(p0 string) (r0 error) {
+	m.calls.record("SetDeviceOwner", p0)
+	if m.SetDeviceOwnerFunc != nil {
+		return m.SetDeviceOwnerFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// RemoveActiveAdmin calls RemoveActiveAdminFunc.
+func (m *Device) RemoveActiveAdmin(p0 string) (r0 error) {
+	m.calls.record("RemoveActiveAdmin", p0)
+	if m.RemoveActiveAdminFunc != nil {
+		return m.RemoveActiveAdminFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// DeviceStateID calls DeviceStateIDFunc.
+func (m *Device) DeviceStateID() (r0 int, r1 error) {
+	m.calls.record("DeviceStateID")
+	if m.DeviceStateIDFunc != nil {
+		return m.DeviceStateIDFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// OverrideDeviceState calls OverrideDeviceStateFunc.
+func (m *Device) OverrideDeviceState(p0 int) (r0 error) {
+	m.calls.record("OverrideDeviceState", p0)
+	if m.OverrideDeviceStateFunc != nil {
+		return m.OverrideDeviceStateFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ClearDeviceStateOverride calls ClearDeviceStateOverrideFunc.
+func (m *Device) ClearDeviceStateOverride() (r0 error) {
+	m.calls.record("ClearDeviceStateOverride")
+	if m.ClearDeviceStateOverrideFunc != nil {
+		return m.ClearDeviceStateOverrideFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// EnableTestHarness calls EnableTestHarnessFunc.
+func (m *Device) EnableTestHarness() (r0 error) {
+	m.calls.record("EnableTestHarness")
+	if m.EnableTestHarnessFunc != nil {
+		return m.EnableTestHarnessFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// GrantPermission calls GrantPermissionFunc.
+func (m *Device) GrantPermission(p0 string, p1 string) (r0 error) {
+	m.calls.record("GrantPermission", p0, p1)
+	if m.GrantPermissionFunc != nil {
+		return m.GrantPermissionFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// RevokePermission calls RevokePermissionFunc.
+func (m *Device) RevokePermission(p0 string, p1 string) (r0 error) {
+	m.calls.record("RevokePermission", p0, p1)
+	if m.RevokePermissionFunc != nil {
+		return m.RevokePermissionFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// CurrentActivity calls CurrentActivityFunc.
+func (m *Device) CurrentActivity() (r0 *adb.Activity, r1 error) {
+	m.calls.record("CurrentActivity")
+	if m.CurrentActivityFunc != nil {
+		return m.CurrentActivityFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ForceStop calls ForceStopFunc.
+func (m *Device) ForceStop(p0 string) (r0 error) {
+	m.calls.record("ForceStop", p0)
+	if m.ForceStopFunc != nil {
+		return m.ForceStopFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// MeasureLaunch calls MeasureLaunchFunc.
+func (m *Device) MeasureLaunch(p0 adb.Activity) (r0 *adb.LaunchResult, r1 error) {
+	m.calls.record("MeasureLaunch", p0)
+	if m.MeasureLaunchFunc != nil {
+		return m.MeasureLaunchFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// BenchmarkLaunch calls BenchmarkLaunchFunc.
+func (m *Device) BenchmarkLaunch(p0 context.Context, p1 adb.Activity, p2 adb.LaunchBenchmarkOptions) (r0 *adb.LaunchBenchmark, r1 error) {
+	m.calls.record("BenchmarkLaunch", p0, p1, p2)
+	if m.BenchmarkLaunchFunc != nil {
+		return m.BenchmarkLaunchFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// WatchANRs calls WatchANRsFunc.
+func (m *Device) WatchANRs(p0 context.Context) (r0 *adb.ANRWatcher, r1 error) {
+	m.calls.record("WatchANRs", p0)
+	if m.WatchANRsFunc != nil {
+		return m.WatchANRsFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// DropboxEntries calls DropboxEntriesFunc.
+func (m *Device) DropboxEntries(p0 time.Time, p1 ...string) (r0 []adb.DropboxEntry, r1 error) {
+	m.calls.record("DropboxEntries", p0, p1)
+	if m.DropboxEntriesFunc != nil {
+		return m.DropboxEntriesFunc(p0, p1...)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Dmesg calls DmesgFunc.
+func (m *Device) Dmesg(p0 context.Context, p1 bool) (r0 *adb.KernelLogWatcher, r1 error) {
+	m.calls.record("Dmesg", p0, p1)
+	if m.DmesgFunc != nil {
+		return m.DmesgFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ProcessMemory calls ProcessMemoryFunc.
+func (m *Device) ProcessMemory() (r0 []adb.ProcessMemory, r1 error) {
+	m.calls.record("ProcessMemory")
+	if m.ProcessMemoryFunc != nil {
+		return m.ProcessMemoryFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// MemoryMappings calls MemoryMappingsFunc.
+func (m *Device) MemoryMappings(p0 int) (r0 []adb.MemoryMapping, r1 error) {
+	m.calls.record("MemoryMappings", p0)
+	if m.MemoryMappingsFunc != nil {
+		return m.MemoryMappingsFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// LibraryMemory calls LibraryMemoryFunc.
+func (m *Device) LibraryMemory() (r0 []adb.LibraryMemory, r1 error) {
+	m.calls.record("LibraryMemory")
+	if m.LibraryMemoryFunc != nil {
+		return m.LibraryMemoryFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Thermal calls ThermalFunc.
+func (m *Device) Thermal() (r0 *adb.ThermalState, r1 error) {
+	m.calls.record("Thermal")
+	if m.ThermalFunc != nil {
+		return m.ThermalFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SampleThermal calls SampleThermalFunc.
+func (m *Device) SampleThermal(p0 context.Context, p1 time.Duration) (r0 *adb.ThermalSampler, r1 error) {
+	m.calls.record("SampleThermal", p0, p1)
+	if m.SampleThermalFunc != nil {
+		return m.SampleThermalFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// GPUSource calls GPUSourceFunc.
+func (m *Device) GPUSource() (r0 *adb.GPUSource, r1 error) {
+	m.calls.record("GPUSource")
+	if m.GPUSourceFunc != nil {
+		return m.GPUSourceFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SampleGPU calls SampleGPUFunc.
+func (m *Device) SampleGPU(p0 context.Context, p1 time.Duration) (r0 *adb.GPUSampler, r1 error) {
+	m.calls.record("SampleGPU", p0, p1)
+	if m.SampleGPUFunc != nil {
+		return m.SampleGPUFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SurfaceFlingerLayers calls SurfaceFlingerLayersFunc.
+func (m *Device) SurfaceFlingerLayers() (r0 []string, r1 error) {
+	m.calls.record("SurfaceFlingerLayers")
+	if m.SurfaceFlingerLayersFunc != nil {
+		return m.SurfaceFlingerLayersFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// FrameLatency calls FrameLatencyFunc.
+func (m *Device) FrameLatency(p0 string) (r0 *adb.FrameLatency, r1 error) {
+	m.calls.record("FrameLatency", p0)
+	if m.FrameLatencyFunc != nil {
+		return m.FrameLatencyFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ClearFrameLatency calls ClearFrameLatencyFunc.
+func (m *Device) ClearFrameLatency(p0 string) (r0 error) {
+	m.calls.record("ClearFrameLatency", p0)
+	if m.ClearFrameLatencyFunc != nil {
+		return m.ClearFrameLatencyFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ResetBatteryStats calls ResetBatteryStatsFunc.
+func (m *Device) ResetBatteryStats() (r0 error) {
+	m.calls.record("ResetBatteryStats")
+	if m.ResetBatteryStatsFunc != nil {
+		return m.ResetBatteryStatsFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// DumpBatteryStats calls DumpBatteryStatsFunc.
+func (m *Device) DumpBatteryStats(p0 io.Writer, p1 bool) (r0 error) {
+	m.calls.record("DumpBatteryStats", p0, p1)
+	if m.DumpBatteryStatsFunc != nil {
+		return m.DumpBatteryStatsFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// PowerRails calls PowerRailsFunc.
+func (m *Device) PowerRails() (r0 []adb.PowerRail, r1 error) {
+	m.calls.record("PowerRails")
+	if m.PowerRailsFunc != nil {
+		return m.PowerRailsFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SamplePowerRails calls SamplePowerRailsFunc.
+func (m *Device) SamplePowerRails(p0 context.Context, p1 time.Duration) (r0 *adb.PowerRailSampler, r1 error) {
+	m.calls.record("SamplePowerRails", p0, p1)
+	if m.SamplePowerRailsFunc != nil {
+		return m.SamplePowerRailsFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ProbeLink calls ProbeLinkFunc.
+func (m *Device) ProbeLink(p0 context.Context, p1 adb.LinkProbeOptions) (r0 *adb.LinkQuality, r1 error) {
+	m.calls.record("ProbeLink", p0, p1)
+	if m.ProbeLinkFunc != nil {
+		return m.ProbeLinkFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ListPackages calls ListPackagesFunc.
+func (m *Device) ListPackages() (r0 []adb.Package, r1 error) {
+	m.calls.record("ListPackages")
+	if m.ListPackagesFunc != nil {
+		return m.ListPackagesFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ListInstrumentation calls ListInstrumentationFunc.
+func (m *Device) ListInstrumentation() (r0 []adb.InstrumentationTarget, r1 error) {
+	m.calls.record("ListInstrumentation")
+	if m.ListInstrumentationFunc != nil {
+		return m.ListInstrumentationFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// InstallTestOrchestrator calls InstallTestOrchestratorFunc.
+func (m *Device) InstallTestOrchestrator(p0 string, p1 string) (r0 error) {
+	m.calls.record("InstallTestOrchestrator", p0, p1)
+	if m.InstallTestOrchestratorFunc != nil {
+		return m.InstallTestOrchestratorFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// RunOrchestratedTests calls RunOrchestratedTestsFunc.
+func (m *Device) RunOrchestratedTests(p0 context.Context, p1 string, p2 adb.OrchestratorOptions) (r0 []adb.TestResult, r1 error) {
+	m.calls.record("RunOrchestratedTests", p0, p1, p2)
+	if m.RunOrchestratedTestsFunc != nil {
+		return m.RunOrchestratedTestsFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// CollectArtifacts calls CollectArtifactsFunc.
+func (m *Device) CollectArtifacts(p0 adb.ArtifactOptions) (r0 []adb.Artifact, r1 error) {
+	m.calls.record("CollectArtifacts", p0)
+	if m.CollectArtifactsFunc != nil {
+		return m.CollectArtifactsFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// TrafficByPackage calls TrafficByPackageFunc.
+func (m *Device) TrafficByPackage() (r0 []adb.PackageTraffic, r1 error) {
+	m.calls.record("TrafficByPackage")
+	if m.TrafficByPackageFunc != nil {
+		return m.TrafficByPackageFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// WakeLocks calls WakeLocksFunc.
+func (m *Device) WakeLocks() (r0 []adb.WakeLock, r1 error) {
+	m.calls.record("WakeLocks")
+	if m.WakeLocksFunc != nil {
+		return m.WakeLocksFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// WakeLockStats calls WakeLockStatsFunc.
+func (m *Device) WakeLockStats() (r0 []adb.WakeLockStats, r1 error) {
+	m.calls.record("WakeLockStats")
+	if m.WakeLockStatsFunc != nil {
+		return m.WakeLockStatsFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SampleWakeLocks calls SampleWakeLocksFunc.
+func (m *Device) SampleWakeLocks(p0 context.Context, p1 time.Duration) (r0 *adb.WakeLockSampler, r1 error) {
+	m.calls.record("SampleWakeLocks", p0, p1)
+	if m.SampleWakeLocksFunc != nil {
+		return m.SampleWakeLocksFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// CPUClusters calls CPUClustersFunc.
+func (m *Device) CPUClusters() (r0 []adb.CPUCluster, r1 error) {
+	m.calls.record("CPUClusters")
+	if m.CPUClustersFunc != nil {
+		return m.CPUClustersFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// PinCPUFrequency calls PinCPUFrequencyFunc.
+func (m *Device) PinCPUFrequency(p0 int, p1 int) (r0 error) {
+	m.calls.record("PinCPUFrequency", p0, p1)
+	if m.PinCPUFrequencyFunc != nil {
+		return m.PinCPUFrequencyFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// SetCPUGovernor calls SetCPUGovernorFunc.
+func (m *Device) SetCPUGovernor(p0 int, p1 string) (r0 error) {
+	m.calls.record("SetCPUGovernor", p0, p1)
+	if m.SetCPUGovernorFunc != nil {
+		return m.SetCPUGovernorFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// RestoreCPUDefaults calls RestoreCPUDefaultsFunc.
+func (m *Device) RestoreCPUDefaults() (r0 error) {
+	m.calls.record("RestoreCPUDefaults")
+	if m.RestoreCPUDefaultsFunc != nil {
+		return m.RestoreCPUDefaultsFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Strace calls StraceFunc.
+func (m *Device) Strace(p0 context.Context, p1 int, p2 adb.StraceOptions) (r0 *adb.StraceWatcher, r1 error) {
+	m.calls.record("Strace", p0, p1, p2)
+	if m.StraceFunc != nil {
+		return m.StraceFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// StracePackage calls StracePackageFunc.
+func (m *Device) StracePackage(p0 context.Context, p1 string, p2 adb.StraceOptions) (r0 *adb.StraceWatcher, r1 error) {
+	m.calls.record("StracePackage", p0, p1, p2)
+	if m.StracePackageFunc != nil {
+		return m.StracePackageFunc(p0, p1, p2)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// WatchState calls WatchStateFunc.
+func (m *Device) WatchState(p0 context.Context) (r0 *adb.DeviceStateWatcher, r1 error) {
+	m.calls.record("WatchState", p0)
+	if m.WatchStateFunc != nil {
+		return m.WatchStateFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// WaitForBootComplete calls WaitForBootCompleteFunc.
+func (m *Device) WaitForBootComplete(p0 context.Context) (r0 error) {
+	m.calls.record("WaitForBootComplete", p0)
+	if m.WaitForBootCompleteFunc != nil {
+		return m.WaitForBootCompleteFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ListUsers calls ListUsersFunc.
+func (m *Device) ListUsers() (r0 []*adb.UserInfo, r1 error) {
+	m.calls.record("ListUsers")
+	if m.ListUsersFunc != nil {
+		return m.ListUsersFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Click calls ClickFunc.
+func (m *Device) Click(p0 int, p1 int) (r0 string, r1 error) {
+	m.calls.record("Click", p0, p1)
+	if m.ClickFunc != nil {
+		return m.ClickFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Drag calls DragFunc.
+func (m *Device) Drag(p0 int, p1 int, p2 int, p3 int) (r0 string, r1 error) {
+	m.calls.record("Drag", p0, p1, p2, p3)
+	if m.DragFunc != nil {
+		return m.DragFunc(p0, p1, p2, p3)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Home calls HomeFunc.
+func (m *Device) Home() (r0 string, r1 error) {
+	m.calls.record("Home")
+	if m.HomeFunc != nil {
+		return m.HomeFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// InputText calls InputTextFunc.
+func (m *Device) InputText(p0 string) (r0 string, r1 error) {
+	m.calls.record("InputText", p0)
+	if m.InputTextFunc != nil {
+		return m.InputTextFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ScreenShot calls ScreenShotFunc.
+func (m *Device) ScreenShot() (r0 []byte, r1 error) {
+	m.calls.record("ScreenShot")
+	if m.ScreenShotFunc != nil {
+		return m.ScreenShotFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ScreenShotImage calls ScreenShotImageFunc.
+func (m *Device) ScreenShotImage() (r0 image.Image, r1 error) {
+	m.calls.record("ScreenShotImage")
+	if m.ScreenShotImageFunc != nil {
+		return m.ScreenShotImageFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ScreenShotWithOptions calls ScreenShotWithOptionsFunc.
+func (m *Device) ScreenShotWithOptions(p0 adb.ScreenshotOptions) (r0 []byte, r1 error) {
+	m.calls.record("ScreenShotWithOptions", p0)
+	if m.ScreenShotWithOptionsFunc != nil {
+		return m.ScreenShotWithOptionsFunc(p0)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ScreenRecord calls ScreenRecordFunc.
+func (m *Device) ScreenRecord(p0 context.Context, p1 string, p2 adb.ScreenRecordOptions) (r0 error) {
+	m.calls.record("ScreenRecord", p0, p1, p2)
+	if m.ScreenRecordFunc != nil {
+		return m.ScreenRecordFunc(p0, p1, p2)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// StartRecording calls StartRecordingFunc.
+func (m *Device) StartRecording(p0 context.Context, p1 adb.RecordingOptions) (r0 *adb.RecordingSession, r1 error) {
+	m.calls.record("StartRecording", p0, p1)
+	if m.StartRecordingFunc != nil {
+		return m.StartRecordingFunc(p0, p1)
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetStatusBar calls SetStatusBarFunc.
+func (m *Device) SetStatusBar(p0 adb.StatusBarAction) (r0 error) {
+	m.calls.record("SetStatusBar", p0)
+	if m.SetStatusBarFunc != nil {
+		return m.SetStatusBarFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// IsScreenOn calls IsScreenOnFunc.
+func (m *Device) IsScreenOn() (r0 bool, r1 error) {
+	m.calls.record("IsScreenOn")
+	if m.IsScreenOnFunc != nil {
+		return m.IsScreenOnFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// IsLocked calls IsLockedFunc.
+func (m *Device) IsLocked() (r0 bool, r1 error) {
+	m.calls.record("IsLocked")
+	if m.IsLockedFunc != nil {
+		return m.IsLockedFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Wake calls WakeFunc.
+func (m *Device) Wake() (r0 error) {
+	m.calls.record("Wake")
+	if m.WakeFunc != nil {
+		return m.WakeFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Unlock calls UnlockFunc.
+func (m *Device) Unlock() (r0 error) {
+	m.calls.record("Unlock")
+	if m.UnlockFunc != nil {
+		return m.UnlockFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// SetStayAwake calls SetStayAwakeFunc.
+func (m *Device) SetStayAwake(p0 bool) (r0 error) {
+	m.calls.record("SetStayAwake", p0)
+	if m.SetStayAwakeFunc != nil {
+		return m.SetStayAwakeFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// DisplayInfo calls DisplayInfoFunc.
+func (m *Device) DisplayInfo() (r0 *adb.DisplayInfo, r1 error) {
+	m.calls.record("DisplayInfo")
+	if m.DisplayInfoFunc != nil {
+		return m.DisplayInfoFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// Displays calls DisplaysFunc.
+func (m *Device) Displays() (r0 []*adb.Display, r1 error) {
+	m.calls.record("Displays")
+	if m.DisplaysFunc != nil {
+		return m.DisplaysFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetDisplaySize calls SetDisplaySizeFunc.
+func (m *Device) SetDisplaySize(p0 int, p1 int) (r0 error) {
+	m.calls.record("SetDisplaySize", p0, p1)
+	if m.SetDisplaySizeFunc != nil {
+		return m.SetDisplaySizeFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// SetDensity calls SetDensityFunc.
+func (m *Device) SetDensity(p0 int) (r0 error) {
+	m.calls.record("SetDensity", p0)
+	if m.SetDensityFunc != nil {
+		return m.SetDensityFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ResetDisplay calls ResetDisplayFunc.
+func (m *Device) ResetDisplay() (r0 error) {
+	m.calls.record("ResetDisplay")
+	if m.ResetDisplayFunc != nil {
+		return m.ResetDisplayFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Rotation calls RotationFunc.
+func (m *Device) Rotation() (r0 adb.Rotation, r1 error) {
+	m.calls.record("Rotation")
+	if m.RotationFunc != nil {
+		return m.RotationFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetRotation calls SetRotationFunc.
+func (m *Device) SetRotation(p0 adb.Rotation) (r0 error) {
+	m.calls.record("SetRotation", p0)
+	if m.SetRotationFunc != nil {
+		return m.SetRotationFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// SetAutoRotate calls SetAutoRotateFunc.
+func (m *Device) SetAutoRotate(p0 bool) (r0 error) {
+	m.calls.record("SetAutoRotate", p0)
+	if m.SetAutoRotateFunc != nil {
+		return m.SetAutoRotateFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Locale calls LocaleFunc.
+func (m *Device) Locale() (r0 string, r1 error) {
+	m.calls.record("Locale")
+	if m.LocaleFunc != nil {
+		return m.LocaleFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetLocale calls SetLocaleFunc.
+func (m *Device) SetLocale(p0 string, p1 adb.LocaleMethod) (r0 error) {
+	m.calls.record("SetLocale", p0, p1)
+	if m.SetLocaleFunc != nil {
+		return m.SetLocaleFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Timezone calls TimezoneFunc.
+func (m *Device) Timezone() (r0 string, r1 error) {
+	m.calls.record("Timezone")
+	if m.TimezoneFunc != nil {
+		return m.TimezoneFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetTimezone calls SetTimezoneFunc.
+func (m *Device) SetTimezone(p0 string) (r0 error) {
+	m.calls.record("SetTimezone", p0)
+	if m.SetTimezoneFunc != nil {
+		return m.SetTimezoneFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// Time calls TimeFunc.
+func (m *Device) Time() (r0 time.Time, r1 error) {
+	m.calls.record("Time")
+	if m.TimeFunc != nil {
+		return m.TimeFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetTime calls SetTimeFunc.
+func (m *Device) SetTime(p0 time.Time) (r0 error) {
+	m.calls.record("SetTime", p0)
+	if m.SetTimeFunc != nil {
+		return m.SetTimeFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// SetAirplaneMode calls SetAirplaneModeFunc.
+func (m *Device) SetAirplaneMode(p0 bool) (r0 error) {
+	m.calls.record("SetAirplaneMode", p0)
+	if m.SetAirplaneModeFunc != nil {
+		return m.SetAirplaneModeFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// IsAirplaneMode calls IsAirplaneModeFunc.
+func (m *Device) IsAirplaneMode() (r0 bool, r1 error) {
+	m.calls.record("IsAirplaneMode")
+	if m.IsAirplaneModeFunc != nil {
+		return m.IsAirplaneModeFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetWifiEnabled calls SetWifiEnabledFunc.
+func (m *Device) SetWifiEnabled(p0 bool) (r0 error) {
+	m.calls.record("SetWifiEnabled", p0)
+	if m.SetWifiEnabledFunc != nil {
+		return m.SetWifiEnabledFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// IsWifiEnabled calls IsWifiEnabledFunc.
+func (m *Device) IsWifiEnabled() (r0 bool, r1 error) {
+	m.calls.record("IsWifiEnabled")
+	if m.IsWifiEnabledFunc != nil {
+		return m.IsWifiEnabledFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetMobileDataEnabled calls SetMobileDataEnabledFunc.
+func (m *Device) SetMobileDataEnabled(p0 bool) (r0 error) {
+	m.calls.record("SetMobileDataEnabled", p0)
+	if m.SetMobileDataEnabledFunc != nil {
+		return m.SetMobileDataEnabledFunc(p0)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// IsMobileDataEnabled calls IsMobileDataEnabledFunc.
+func (m *Device) IsMobileDataEnabled() (r0 bool, r1 error) {
+	m.calls.record("IsMobileDataEnabled")
+	if m.IsMobileDataEnabledFunc != nil {
+		return m.IsMobileDataEnabledFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// ConnectWifi calls ConnectWifiFunc.
+func (m *Device) ConnectWifi(p0 context.Context, p1 adb.WifiNetwork) (r0 error) {
+	m.calls.record("ConnectWifi", p0, p1)
+	if m.ConnectWifiFunc != nil {
+		return m.ConnectWifiFunc(p0, p1)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// WifiSSID calls WifiSSIDFunc.
+func (m *Device) WifiSSID() (r0 string, r1 error) {
+	m.calls.record("WifiSSID")
+	if m.WifiSSIDFunc != nil {
+		return m.WifiSSIDFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
+
+// SetGlobalProxy calls SetGlobalProxyFunc.
+func (m *Device) SetGlobalProxy(p0 string, p1 int, p2 ...string) (r0 error) {
+	m.calls.record("SetGlobalProxy", p0, p1, p2)
+	if m.SetGlobalProxyFunc != nil {
+		return m.SetGlobalProxyFunc(p0, p1, p2...)
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// ClearGlobalProxy calls ClearGlobalProxyFunc.
+func (m *Device) ClearGlobalProxy() (r0 error) {
+	m.calls.record("ClearGlobalProxy")
+	if m.ClearGlobalProxyFunc != nil {
+		return m.ClearGlobalProxyFunc()
+	}
+	r0 = ErrNotMocked
+	return
+}
+
+// GlobalProxy calls GlobalProxyFunc.
+func (m *Device) GlobalProxy() (r0 *adb.ProxyConfig, r1 error) {
+	m.calls.record("GlobalProxy")
+	if m.GlobalProxyFunc != nil {
+		return m.GlobalProxyFunc()
+	}
+	r1 = ErrNotMocked
+	return
+}
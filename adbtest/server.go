@@ -0,0 +1,452 @@
+/*
+Package adbtest provides an in-process fake adb server for hermetic integration tests.
+
+The server speaks the adb smart socket protocol on a local TCP port, so tests exercise the
+same code paths as a real server, from the Adb client down to the wire package.
+
+E.g.
+
+	server := adbtest.NewServer()
+	defer server.Close()
+	server.AddDevice(&adbtest.Device{
+		Serial: "emulator-5554",
+		Shell:  map[string]string{"getprop ro.build.version.sdk": "30\n"},
+	})
+
+	client, _ := adb.NewWithConfig(server.Config())
+	sdk, _ := client.Device(adb.DeviceWithSerial("emulator-5554")).SDKVersion()
+*/
+package adbtest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+
+	adb "github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// DefaultVersion is the version the server reports for host:version, unless Server.Version is set.
+const DefaultVersion = 41
+
+// Device is a device attached to a fake Server.
+type Device struct {
+	Serial string
+	// State as printed by adb devices, e.g. device, offline, or unauthorized.
+	// Defaults to device.
+	State string
+	// Attributes printed by adb devices -l, e.g. product, model, device, and usb.
+	Attributes map[string]string
+	// Features advertised by adbd, e.g. shell_v2 and cmd.
+	Features []string
+	// Device path returned for get-devpath.
+	DevPath string
+
+	// Output of shell: and exec: commands, keyed by command line.
+	Shell map[string]string
+	// If set, called for commands that aren't in Shell.
+	ShellHandler func(cmd string) string
+	// If set, called instead of ShellHandler for exec: commands that aren't in Shell, with
+	// the data the client sends, e.g. an APK streamed to cmd package install. Unlike
+	// ShellHandler, calls for different connections aren't serialized.
+	ExecHandler func(cmd string, input io.Reader) string
+
+	// Files served by sync: requests, keyed by absolute path. Files pushed by clients are
+	// added to it.
+	Files map[string]*File
+}
+
+func (d *Device) state() string {
+	if d.State == "" {
+		return "device"
+	}
+	return d.State
+}
+
+func (d *Device) runCommand(cmd string) string {
+	if output, ok := d.Shell[cmd]; ok {
+		return output
+	}
+	if d.ShellHandler != nil {
+		return d.ShellHandler(cmd)
+	}
+	return fmt.Sprintf("/system/bin/sh: %s: not found\n", strings.Fields(cmd + " ")[0])
+}
+
+// Server is a fake adb server listening on a loopback TCP port.
+type Server struct {
+	// Version reported for host:version. Defaults to DefaultVersion.
+	Version int
+	// If set, called when a client sends host:kill, e.g. to detach devices and attach them
+	// again as a restarted server would. The server keeps running either way.
+	OnKill func()
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	// Held while handling shell commands and sync requests, so ShellHandler can modify Files.
+	serviceMu sync.Mutex
+
+	mu       sync.Mutex
+	devices  []*Device
+	requests []string
+	// Channels of host:track-devices connections, notified when devices change.
+	trackers map[chan struct{}]bool
+	closed   bool
+}
+
+// NewServer starts a fake server. Call Close to stop it.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("adbtest: failed to listen on a port: %v", err))
+	}
+	s := &Server{
+		Version:  DefaultVersion,
+		listener: listener,
+		trackers: map[chan struct{}]bool{},
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr returns the host:port the server is listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Config returns a ServerConfig that connects to this server, and never tries to start a
+// real one.
+func (s *Server) Config() adb.ServerConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return adb.ServerConfig{Host: addr.IP.String(), Port: addr.Port, NoServer: true}
+}
+
+// AddDevice attaches device, replacing any device with the same serial.
+func (s *Server) AddDevice(device *Device) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, d := range s.devices {
+		if d.Serial == device.Serial {
+			s.devices[i] = device
+			s.notifyTrackersLocked()
+			return
+		}
+	}
+	s.devices = append(s.devices, device)
+	s.notifyTrackersLocked()
+}
+
+// RemoveDevice detaches the device with serial.
+func (s *Server) RemoveDevice(serial string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, d := range s.devices {
+		if d.Serial == serial {
+			s.devices = append(s.devices[:i], s.devices[i+1:]...)
+			break
+		}
+	}
+	s.notifyTrackersLocked()
+}
+
+// SetDeviceState changes the state of the device with serial, e.g. to offline.
+func (s *Server) SetDeviceState(serial, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.devices {
+		if d.Serial == serial {
+			d.State = state
+		}
+	}
+	s.notifyTrackersLocked()
+}
+
+// Requests returns every request received so far, in order, e.g. host:version,
+// host:transport:emulator-5554, and shell:ls.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+// Close stops the server and waits for open connections to finish.
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.notifyTrackersLocked()
+	s.mu.Unlock()
+
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+// handleConn serves host requests on conn until one of them ends the connection.
+// After a transport request, the next request is a service on the selected device.
+func (s *Server) handleConn(conn net.Conn) {
+	scanner := wire.NewScanner(conn)
+	sender := wire.NewSender(conn)
+
+	var device *Device
+	for {
+		msg, err := scanner.ReadMessage()
+		if err != nil {
+			return
+		}
+		req := string(msg)
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+
+		if device != nil {
+			s.handleDeviceService(conn, sender, device, req)
+			return
+		}
+
+		if strings.HasPrefix(req, "host:transport") {
+			if device, err = s.findTransport(strings.TrimPrefix(req, "host:")); err != nil {
+				sendFail(conn, sender, err.Error())
+				return
+			}
+			conn.Write([]byte(wire.StatusSuccess))
+			continue
+		}
+
+		s.handleHostRequest(conn, sender, req)
+		return
+	}
+}
+
+func (s *Server) handleHostRequest(conn net.Conn, sender wire.Sender, req string) {
+	switch req {
+	case "host:version":
+		sendOkay(conn, sender, fmt.Sprintf("%04x", s.Version))
+		return
+	case "host:devices":
+		sendOkay(conn, sender, s.deviceList(false))
+		return
+	case "host:devices-l":
+		sendOkay(conn, sender, s.deviceList(true))
+		return
+	case "host:track-devices":
+		s.trackDevices(conn, sender)
+		return
+	case "host:kill":
+		conn.Write([]byte(wire.StatusSuccess))
+		if s.OnKill != nil {
+			s.OnKill()
+		}
+		return
+	}
+
+	// host[-serial:<serial>|-usb|-local]:<attribute>
+	prefix, attr, ok := splitHostRequest(req)
+	if !ok {
+		sendFail(conn, sender, "unknown host service")
+		return
+	}
+	device, err := s.findDevice(prefix)
+	if err != nil {
+		sendFail(conn, sender, err.Error())
+		return
+	}
+	switch attr {
+	case "get-state":
+		sendOkay(conn, sender, device.state())
+	case "get-serialno":
+		sendOkay(conn, sender, device.Serial)
+	case "get-devpath":
+		sendOkay(conn, sender, device.DevPath)
+	case "features":
+		sendOkay(conn, sender, strings.Join(device.Features, ","))
+	default:
+		sendFail(conn, sender, "unknown host service")
+	}
+}
+
+func (s *Server) handleDeviceService(conn net.Conn, sender wire.Sender, device *Device, req string) {
+	if req == "sync:" {
+		conn.Write([]byte(wire.StatusSuccess))
+		(&syncSession{conn: conn, device: device, mu: &s.serviceMu}).serve()
+		return
+	}
+	if cmd := strings.TrimPrefix(req, "exec:"); cmd != req && device.ExecHandler != nil {
+		s.serviceMu.Lock()
+		_, ok := device.Shell[cmd]
+		s.serviceMu.Unlock()
+		if !ok {
+			conn.Write([]byte(wire.StatusSuccess))
+			io.WriteString(conn, device.ExecHandler(cmd, conn))
+			return
+		}
+	}
+	for _, prefix := range []string{"shell:", "exec:"} {
+		if strings.HasPrefix(req, prefix) {
+			s.serviceMu.Lock()
+			output := device.runCommand(strings.TrimPrefix(req, prefix))
+			s.serviceMu.Unlock()
+			conn.Write([]byte(wire.StatusSuccess))
+			io.WriteString(conn, output)
+			return
+		}
+	}
+	sendFail(conn, sender, "unknown device service")
+}
+
+// trackDevices sends the device list each time it changes, until the server is closed or
+// the client disconnects.
+func (s *Server) trackDevices(conn net.Conn, sender wire.Sender) {
+	changed := make(chan struct{}, 1)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.trackers[changed] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.trackers, changed)
+		s.mu.Unlock()
+	}()
+
+	// Detect the client disconnecting, since it never sends anything else.
+	disconnected := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		close(disconnected)
+	}()
+
+	conn.Write([]byte(wire.StatusSuccess))
+	for {
+		if err := sender.SendMessage([]byte(s.deviceList(false))); err != nil {
+			return
+		}
+		select {
+		case <-changed:
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+		case <-disconnected:
+			return
+		}
+	}
+}
+
+func (s *Server) notifyTrackersLocked() {
+	for tracker := range s.trackers {
+		select {
+		case tracker <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// findTransport returns the device selected by a transport descriptor, e.g.
+// transport:<serial> or transport-any.
+func (s *Server) findTransport(descriptor string) (*Device, error) {
+	if strings.HasPrefix(descriptor, "transport:") {
+		return s.findDevice("host-serial:" + strings.TrimPrefix(descriptor, "transport:"))
+	}
+	return s.findDevice("host")
+}
+
+// findDevice returns the device selected by a host request prefix: host-serial:<serial>, or
+// host, host-usb, and host-local, which all select the only device.
+func (s *Server) findDevice(prefix string) (*Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasPrefix(prefix, "host-serial:") {
+		serial := strings.TrimPrefix(prefix, "host-serial:")
+		for _, d := range s.devices {
+			if d.Serial == serial {
+				return d, nil
+			}
+		}
+		return nil, fmt.Errorf("device '%s' not found", serial)
+	}
+
+	switch len(s.devices) {
+	case 0:
+		return nil, fmt.Errorf("no devices/emulators found")
+	case 1:
+		return s.devices[0], nil
+	default:
+		return nil, fmt.Errorf("more than one device/emulator")
+	}
+}
+
+func (s *Server) deviceList(long bool) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var list strings.Builder
+	for _, d := range s.devices {
+		list.WriteString(d.Serial)
+		list.WriteString("\t")
+		list.WriteString(d.state())
+		if long {
+			for _, key := range []string{"usb", "product", "model", "device", "adbd_port", "transport_id"} {
+				if value, ok := d.Attributes[key]; ok {
+					fmt.Fprintf(&list, " %s:%s", key, value)
+				}
+			}
+		}
+		list.WriteString("\n")
+	}
+	return list.String()
+}
+
+// splitHostRequest splits host-serial:<serial>:<attr> and host[-usb|-local]:<attr> requests.
+// Serials may contain colons, e.g. 127.0.0.1:5555.
+func splitHostRequest(req string) (prefix, attr string, ok bool) {
+	i := strings.LastIndexByte(req, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	prefix, attr = req[:i], req[i+1:]
+	switch {
+	case prefix == "host", prefix == "host-usb", prefix == "host-local", strings.HasPrefix(prefix, "host-serial:"):
+		return prefix, attr, true
+	}
+	return "", "", false
+}
+
+func sendOkay(conn net.Conn, sender wire.Sender, msg string) {
+	if _, err := conn.Write([]byte(wire.StatusSuccess)); err != nil {
+		return
+	}
+	sender.SendMessage([]byte(msg))
+}
+
+func sendFail(conn net.Conn, sender wire.Sender, msg string) {
+	if _, err := conn.Write([]byte(wire.StatusFailure)); err != nil {
+		return
+	}
+	sender.SendMessage([]byte(msg))
+}
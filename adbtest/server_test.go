@@ -0,0 +1,673 @@
+package adbtest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+func newClient(t *testing.T, server *Server) *adb.Adb {
+	client, err := adb.NewWithConfig(server.Config())
+	require.NoError(t, err)
+	return client
+}
+
+func TestServerVersion(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Version = 39
+
+	version, err := newClient(t, server).ServerVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 39, version)
+}
+
+func TestUnsupportedServer(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Version = 32
+	server.AddDevice(&Device{Serial: "emulator-5554", Features: []string{"shell_v2"}})
+	client := newClient(t, server)
+	device := client.Device(adb.AnyDevice())
+
+	_, err := device.Features()
+	assert.True(t, stderrors.Is(err, adb.ErrUnsupportedServer), "%v", err)
+
+	// The version is cached until ServerVersion queries it again, e.g. after an upgrade.
+	server.Version = DefaultVersion
+	_, err = device.Features()
+	assert.True(t, stderrors.Is(err, adb.ErrUnsupportedServer), "%v", err)
+	_, err = client.ServerVersion()
+	assert.NoError(t, err)
+	features, err := device.Features()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shell_v2"}, features)
+}
+
+func TestListDevices(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{
+		Serial:     "emulator-5554",
+		Attributes: map[string]string{"product": "sdk_phone", "model": "Pixel", "device": "generic"},
+	})
+	server.AddDevice(&Device{Serial: "0123abcd", State: "offline"})
+	client := newClient(t, server)
+
+	devices, err := client.ListDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, []*adb.DeviceInfo{
+		{Serial: "emulator-5554", State: adb.StateOnline, Product: "sdk_phone", Model: "Pixel", DeviceInfo: "generic"},
+		{Serial: "0123abcd", State: adb.StateOffline},
+	}, devices)
+
+	serials, err := client.ListDeviceSerials()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"emulator-5554", "0123abcd"}, serials)
+}
+
+func TestListDevicesLong(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{Serial: "0123abcd", Attributes: map[string]string{"usb": "1-1", "transport_id": "4"}})
+	server.AddDevice(&Device{Serial: "emulator-5554", State: "recovery"})
+	client := newClient(t, server)
+
+	devices, err := client.ListDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, []*adb.DeviceInfo{
+		{Serial: "0123abcd", State: adb.StateOnline, Usb: "1-1", TransportID: 4},
+		{Serial: "emulator-5554", State: adb.StateRecovery},
+	}, devices)
+}
+
+func TestMultipleServers(t *testing.T) {
+	// Lab hosts often have devices with the same serial, e.g. their first emulator.
+	var clients []*adb.Adb
+	for _, host := range []string{"lab1", "lab2"} {
+		server := NewServer()
+		defer server.Close()
+		server.AddDevice(&Device{Serial: "emulator-5554", Shell: map[string]string{"hostname": host + "\n"}})
+		server.AddDevice(&Device{Serial: host + "-phone"})
+		clients = append(clients, newClient(t, server))
+	}
+
+	outputs := make([]string, len(clients))
+	serials := make([][]string, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func(i int, client *adb.Adb) {
+			defer wg.Done()
+			var err error
+			serials[i], err = client.ListDeviceSerials()
+			assert.NoError(t, err)
+			outputs[i], err = client.Device(adb.DeviceWithSerial("emulator-5554")).RunCommand("hostname")
+			assert.NoError(t, err)
+		}(i, client)
+	}
+	wg.Wait()
+
+	assert.Equal(t, [][]string{{"emulator-5554", "lab1-phone"}, {"emulator-5554", "lab2-phone"}}, serials)
+	assert.Equal(t, []string{"lab1\n", "lab2\n"}, outputs)
+}
+
+func TestDeviceAttributes(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{Serial: "127.0.0.1:5555", DevPath: "usb:1-1", Features: []string{"shell_v2", "cmd"}})
+	device := newClient(t, server).Device(adb.DeviceWithSerial("127.0.0.1:5555"))
+
+	serial, err := device.Serial()
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:5555", serial)
+
+	devPath, err := device.DevicePath()
+	assert.NoError(t, err)
+	assert.Equal(t, "usb:1-1", devPath)
+
+	state, err := device.State()
+	assert.NoError(t, err)
+	assert.Equal(t, adb.StateOnline, state)
+
+	features, err := device.Features()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"shell_v2", "cmd"}, features)
+}
+
+func TestRunCommand(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{
+		Serial: "emulator-5554",
+		Shell:  map[string]string{"echo hello": "hello\n"},
+	})
+	device := newClient(t, server).Device(adb.AnyDevice())
+
+	output, err := device.RunCommand("echo", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+
+	output, err = device.RunCommand("frobnicate")
+	assert.NoError(t, err)
+	assert.Equal(t, "/system/bin/sh: frobnicate: not found\n", output)
+
+	assert.Equal(t, []string{
+		"host:transport-any", "shell:echo hello",
+		"host:transport-any", "shell:frobnicate",
+	}, server.Requests())
+}
+
+func TestShellHandler(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{
+		Serial:       "emulator-5554",
+		ShellHandler: func(cmd string) string { return "ran " + cmd + "\n" },
+	})
+
+	output, err := newClient(t, server).Device(adb.AnyDevice()).RunCommand("ls", "/sdcard")
+	assert.NoError(t, err)
+	assert.Equal(t, "ran ls /sdcard\n", output)
+}
+
+func TestDeviceNotFound(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	device := newClient(t, server).Device(adb.DeviceWithSerial("missing"))
+
+	_, err := device.RunCommand("ls")
+	assert.True(t, adb.HasErrCode(err, adb.DeviceNotFound), "%v", err)
+
+	_, err = device.Serial()
+	assert.Error(t, err)
+}
+
+func TestDeviceWatcher(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	watcher := newClient(t, server).NewDeviceWatcher()
+	defer watcher.Shutdown()
+
+	server.AddDevice(&Device{Serial: "emulator-5554"})
+	assert.Equal(t, adb.DeviceStateChangedEvent{
+		Serial: "emulator-5554", OldState: adb.StateDisconnected, NewState: adb.StateOnline,
+	}, nextEvent(t, watcher))
+
+	server.RemoveDevice("emulator-5554")
+	assert.Equal(t, adb.DeviceStateChangedEvent{
+		Serial: "emulator-5554", OldState: adb.StateOnline, NewState: adb.StateDisconnected,
+	}, nextEvent(t, watcher))
+}
+
+func TestWatchState(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{Serial: "emulator-5554"})
+	server.AddDevice(&Device{Serial: "0123abcd"})
+	client := newClient(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := client.Device(adb.DeviceWithSerial("emulator-5554")).WatchState(ctx)
+	require.NoError(t, err)
+
+	transition := nextTransition(t, watcher)
+	assert.Equal(t, adb.StateDisconnected, transition.OldState)
+	assert.Equal(t, adb.StateOnline, transition.NewState)
+	assert.False(t, transition.Time.IsZero())
+
+	server.SetDeviceState("0123abcd", "offline")
+	server.SetDeviceState("emulator-5554", "offline")
+	transition = nextTransition(t, watcher)
+	assert.Equal(t, adb.StateOnline, transition.OldState)
+	assert.Equal(t, adb.StateOffline, transition.NewState)
+
+	cancel()
+	for range watcher.C() {
+	}
+	assert.NoError(t, watcher.Err())
+}
+
+func TestAuthorizationMonitor(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{Serial: "0123abcd", State: "unauthorized"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan adb.AuthorizationEvent, 2)
+	monitor := newClient(t, server).NewAuthorizationMonitor(func(e adb.AuthorizationEvent) {
+		events <- e
+	})
+	go monitor.Run(ctx)
+
+	nextAuthorizationEvent := func() adb.AuthorizationEvent {
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for authorization event")
+			return adb.AuthorizationEvent{}
+		}
+	}
+	e := nextAuthorizationEvent()
+	assert.True(t, e.Pending())
+	assert.Equal(t, "0123abcd", e.Device.Serial)
+
+	server.SetDeviceState("0123abcd", "device")
+	assert.True(t, nextAuthorizationEvent().Authorized())
+	assert.Empty(t, monitor.Pending())
+}
+
+func TestWaitForBootComplete(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{
+		Serial: "emulator-5554",
+		Shell: map[string]string{
+			"getprop sys.boot_completed; getprop dev.bootcomplete; pm path android 2>&1; service check activity 2>&1": "1\n1\n" +
+				"package:/system/framework/framework-res.apk\nService activity: found\n",
+		},
+	})
+	server.AddDevice(&Device{Serial: "0123abcd", State: "offline"})
+	client := newClient(t, server)
+
+	assert.NoError(t, client.Device(adb.DeviceWithSerial("emulator-5554")).WaitForBootComplete(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := client.Device(adb.DeviceWithSerial("0123abcd")).WaitForBootComplete(ctx)
+	assert.True(t, stderrors.Is(err, context.DeadlineExceeded), "%v", err)
+	assert.Contains(t, adb.ErrorWithCauseChain(err), "StateOffline")
+}
+
+func TestRestartServer(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	phone := &Device{Serial: "0123abcd", Attributes: map[string]string{"transport_id": "1"}}
+	emulator := &Device{Serial: "emulator-5554"}
+	server.AddDevice(phone)
+	server.AddDevice(emulator)
+	server.OnKill = func() {
+		server.RemoveDevice(phone.Serial)
+		server.RemoveDevice(emulator.Serial)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			server.AddDevice(&Device{Serial: "0123abcd", Attributes: map[string]string{"transport_id": "2"}})
+		}()
+	}
+	config := server.Config()
+	config.Timeouts.Reattach = time.Second
+	client, err := adb.NewWithConfig(config)
+	require.NoError(t, err)
+
+	restart, err := client.RestartServer(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*adb.DeviceInfo{{Serial: "0123abcd", State: adb.StateOnline, TransportID: 2}}, restart.Reattached)
+	assert.Equal(t, []*adb.DeviceInfo{{Serial: "emulator-5554", State: adb.StateOnline}}, restart.Missing)
+	assert.Contains(t, server.Requests(), "host:kill")
+
+	// Without Reattach, missing devices are waited for until ctx is done.
+	server.OnKill = func() { server.RemoveDevice(phone.Serial) }
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	restart, err = newClient(t, server).RestartServer(ctx)
+	assert.True(t, stderrors.Is(err, context.DeadlineExceeded), "%v", err)
+	assert.Empty(t, restart.Reattached)
+	assert.Len(t, restart.Missing, 1)
+}
+
+func nextTransition(t *testing.T, watcher *adb.DeviceStateWatcher) adb.DeviceStateTransition {
+	select {
+	case transition := <-watcher.C():
+		return transition
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for state transition")
+		return adb.DeviceStateTransition{}
+	}
+}
+
+func nextEvent(t *testing.T, watcher *adb.DeviceWatcher) adb.DeviceStateChangedEvent {
+	select {
+	case event := <-watcher.C():
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for device event")
+		return adb.DeviceStateChangedEvent{}
+	}
+}
+
+func TestSync(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	mtime := time.Unix(1500000000, 0).UTC()
+	device := &Device{
+		Serial: "emulator-5554",
+		Files:  map[string]*File{"/sdcard/a.txt": {Data: []byte("a"), ModTime: mtime}},
+	}
+	server.AddDevice(device)
+	client := newClient(t, server).Device(adb.DeviceWithSerial("emulator-5554"))
+
+	writer, err := client.OpenWrite("/sdcard/sub/b.txt", 0600, mtime)
+	require.NoError(t, err)
+	writer.Write([]byte("hello"))
+	require.NoError(t, writer.Close())
+	assert.Equal(t, &File{Data: []byte("hello"), Mode: 0600, ModTime: mtime}, device.Files["/sdcard/sub/b.txt"])
+
+	reader, err := client.OpenRead("/sdcard/sub/b.txt")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	assert.Equal(t, "hello", string(data))
+
+	entry, err := client.Stat("/sdcard/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, &adb.DirEntry{Mode: 0644, Size: 1, ModifiedAt: mtime}, entry)
+	_, err = client.Stat("/sdcard/missing")
+	assert.True(t, adb.HasErrCode(err, adb.FileNoExistError))
+
+	entries, err := client.ListDirEntries("/sdcard")
+	require.NoError(t, err)
+	all, err := entries.ReadAll()
+	assert.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "a.txt", all[0].Name)
+	assert.Equal(t, "sub", all[1].Name)
+	assert.True(t, all[1].Mode.IsDir())
+}
+
+func TestPushDirParallel(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	device := &Device{
+		Serial:       "emulator-5554",
+		Files:        map[string]*File{},
+		ShellHandler: func(cmd string) string { return "" },
+	}
+	server.AddDevice(device)
+	client := newClient(t, server).Device(adb.DeviceWithSerial("emulator-5554"))
+
+	local, err := ioutil.TempDir("", "adbtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	for i := 0; i < 50; i++ {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(local, fmt.Sprintf("%02d.txt", i)), []byte("data"), 0644))
+	}
+
+	var last adb.TransferProgress
+	err = client.PushDir(local, "/sdcard/assets", adb.TransferOptions{
+		Workers:  8,
+		Progress: func(p adb.TransferProgress) { last = p },
+	})
+	require.NoError(t, err)
+	assert.Len(t, device.Files, 50)
+	assert.Equal(t, []byte("data"), device.Files["/sdcard/assets/49.txt"].Data)
+	assert.Equal(t, adb.TransferProgress{Files: 50, TotalFiles: 50, Bytes: 200, TotalBytes: 200}, last)
+}
+
+func TestPullDirCompressed(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for i := 0; i < 20; i++ {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: fmt.Sprintf("./shard-%d/result.xml", i), Mode: 0644, Size: 4}))
+		tw.Write([]byte("pass"))
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	device := &Device{Serial: "emulator-5554", Files: map[string]*File{"/sdcard/out/shard-0/result.xml": {Data: []byte("pass")}}}
+	device.ShellHandler = func(cmd string) string {
+		args := strings.Fields(cmd)
+		switch args[0] {
+		case "command":
+			return "/system/bin/" + args[2] + "\n"
+		case "tar":
+			if args[1] != "-chzf" || args[4] != "/sdcard/out" {
+				return "tar: unexpected arguments\n"
+			}
+			device.Files[args[2]] = &File{Data: archive.Bytes()}
+		case "rm":
+			delete(device.Files, args[len(args)-1])
+		}
+		return ""
+	}
+	server.AddDevice(device)
+	client := newClient(t, server).Device(adb.DeviceWithSerial("emulator-5554"))
+
+	local, err := ioutil.TempDir("", "adbtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	var last adb.TransferProgress
+	err = client.PullDirCompressed("/sdcard/out", local, adb.TransferOptions{
+		Progress: func(p adb.TransferProgress) { last = p },
+	})
+	require.NoError(t, err, adb.ErrorWithCauseChain(err))
+	data, err := ioutil.ReadFile(filepath.Join(local, "shard-19", "result.xml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "pass", string(data))
+	assert.Equal(t, 20, last.Files)
+	assert.Equal(t, int64(archive.Len()), last.TotalBytes)
+	assert.Equal(t, last.TotalBytes, last.Bytes)
+	// Only the tree is left on the device.
+	assert.Len(t, device.Files, 1)
+}
+
+func TestPullDirCompressedRemovesArchiveOnError(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	device := &Device{Serial: "emulator-5554", Files: map[string]*File{"/sdcard/out/result.xml": {Data: []byte("pass")}}}
+	device.ShellHandler = func(cmd string) string {
+		args := strings.Fields(cmd)
+		switch args[0] {
+		case "command":
+			return "/system/bin/" + args[2] + "\n"
+		case "tar":
+			// Ran out of space partway through the archive.
+			device.Files[args[2]] = &File{Data: []byte("\x1f\x8b")}
+			return "tar: write error: No space left on device\n"
+		case "rm":
+			delete(device.Files, args[len(args)-1])
+		}
+		return ""
+	}
+	server.AddDevice(device)
+	client := newClient(t, server).Device(adb.DeviceWithSerial("emulator-5554"))
+
+	local, err := ioutil.TempDir("", "adbtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	assert.Error(t, client.PullDirCompressed("/sdcard/out", local, adb.TransferOptions{}))
+	assert.Len(t, device.Files, 1)
+}
+
+func TestRecordingSession(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var recorded int
+	device := &Device{Serial: "emulator-5554", Files: map[string]*File{}}
+	device.ShellHandler = func(cmd string) string {
+		args := strings.Fields(strings.TrimPrefix(cmd, "echo $$; exec "))
+		switch args[0] {
+		case "screenrecord":
+			device.Files[args[len(args)-1]] = &File{Data: []byte(fmt.Sprintf("segment %d", recorded))}
+			if recorded++; recorded == 3 {
+				cancel()
+			}
+			return "1234\n"
+		case "rm":
+			delete(device.Files, args[len(args)-1])
+		}
+		return ""
+	}
+	server.AddDevice(device)
+	client := newClient(t, server).Device(adb.DeviceWithSerial("emulator-5554"))
+
+	local, err := ioutil.TempDir("", "adbtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+
+	session, err := client.StartRecording(ctx, adb.RecordingOptions{LocalDir: local})
+	require.NoError(t, err)
+	files, err := session.Wait()
+	require.NoError(t, err)
+
+	require.Len(t, files, 3)
+	for i, file := range files {
+		assert.Equal(t, filepath.Join(local, fmt.Sprintf("segment-%03d.mp4", i)), file)
+		data, err := ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("segment %d", i), string(data))
+	}
+	// Segments are deleted from the device once they're pulled.
+	assert.Empty(t, device.Files)
+	for _, req := range server.Requests() {
+		if strings.Contains(req, "screenrecord") {
+			assert.True(t, strings.HasPrefix(req, "shell:echo $$; exec screenrecord --time-limit 180 /data/local/tmp/goadb-recording-"), req)
+		}
+	}
+}
+
+func TestRunOrchestratedTestsCanceled(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stopped []string
+	server.AddDevice(&Device{
+		Serial: "emulator-5554",
+		ExecHandler: func(cmd string, input io.Reader) string {
+			cancel()
+			return ""
+		},
+		ShellHandler: func(cmd string) string {
+			stopped = append(stopped, cmd)
+			return ""
+		},
+	})
+	client := newClient(t, server).Device(adb.DeviceWithSerial("emulator-5554"))
+
+	_, err := client.RunOrchestratedTests(ctx, "com.example.test/androidx.test.runner.AndroidJUnitRunner", adb.OrchestratorOptions{})
+	assert.True(t, stderrors.Is(err, context.Canceled), "%v", err)
+	// am instrument exiting doesn't stop the tests it started.
+	assert.Equal(t, []string{"am force-stop androidx.test.orchestrator", "am force-stop com.example.test"}, stopped)
+}
+
+func TestProbeLink(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	device := &Device{
+		Serial: "emulator-5554",
+		Shell:  map[string]string{"echo": "\n"},
+		Files:  map[string]*File{},
+	}
+	server.AddDevice(device)
+	client := newClient(t, server).Device(adb.DeviceWithSerial("emulator-5554"))
+
+	quality, err := client.ProbeLink(context.Background(), adb.LinkProbeOptions{Pings: 3, PushBytes: 1 << 20})
+	require.NoError(t, err)
+	assert.Len(t, quality.Latencies, 3)
+	assert.Equal(t, 0, quality.Failures)
+	require.NotNil(t, quality.Push)
+	assert.Equal(t, int64(1<<20), quality.Push.Bytes)
+	assert.Len(t, device.Files["/dev/null"].Data, 1<<20)
+}
+
+func TestInstallOnAll(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	apk := bytes.Repeat([]byte("apk!"), 300*1024)
+	dir, err := ioutil.TempDir("", "adbtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.apk")
+	require.NoError(t, ioutil.WriteFile(path, apk, 0644))
+
+	var mu sync.Mutex
+	received := map[string][]byte{}
+	for _, serial := range []string{"emulator-5554", "emulator-5556", "emulator-5558"} {
+		serial := serial
+		server.AddDevice(&Device{
+			Serial:   serial,
+			Features: []string{"cmd"},
+			ExecHandler: func(cmd string, input io.Reader) string {
+				if cmd != fmt.Sprintf("cmd package install -S %d", len(apk)) {
+					return "Unknown command: " + cmd
+				}
+				data, _ := ioutil.ReadAll(io.LimitReader(input, int64(len(apk))))
+				mu.Lock()
+				received[serial] = data
+				mu.Unlock()
+				if serial == "emulator-5558" {
+					return "Failure [INSTALL_FAILED_INSUFFICIENT_STORAGE]\n"
+				}
+				return "Success\n"
+			},
+		})
+	}
+
+	results, err := newClient(t, server).InstallOnAll(context.Background(), nil, path, adb.InstallOptions{})
+	require.Len(t, results, 3)
+	for i, serial := range []string{"emulator-5554", "emulator-5556", "emulator-5558"} {
+		assert.Equal(t, serial, results[i].Serial)
+		assert.Equal(t, apk, received[serial], serial)
+		assert.True(t, results[i].Duration > 0)
+	}
+	assert.True(t, results[0].Installed)
+	assert.NoError(t, results[1].Err)
+	assert.False(t, results[2].Installed)
+	assert.Contains(t, adb.ErrorWithCauseChain(results[2].Err), "INSTALL_FAILED_INSUFFICIENT_STORAGE")
+	assert.EqualError(t, err, "AdbError: emulator-5558")
+}
+
+func TestUninstallFromAll(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	for _, serial := range []string{"emulator-5554", "emulator-5556"} {
+		server.AddDevice(&Device{
+			Serial: serial,
+			Shell: map[string]string{
+				"getprop ro.build.version.sdk":          "34\n",
+				"cmd package uninstall com.example.app": "Success\n",
+			},
+		})
+	}
+
+	results, err := newClient(t, server).UninstallFromAll(context.Background(), nil, "com.example.app")
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	server.AddDevice(&Device{
+		Serial: "emulator-5558",
+		Shell: map[string]string{
+			"getprop ro.build.version.sdk":          "34\n",
+			"cmd package uninstall com.example.app": "Failure [DELETE_FAILED_INTERNAL_ERROR]\n",
+		},
+	})
+	results, err = newClient(t, server).UninstallFromAll(context.Background(), nil, "com.example.app")
+	assert.True(t, adb.HasErrCode(results[2].Err, adb.AdbError))
+	assert.EqualError(t, err, "AdbError: emulator-5558")
+}
@@ -0,0 +1,110 @@
+package adbtest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	adb "github.com/zach-klippenstein/goadb"
+)
+
+// TestSSHHelperProcess isn't a real test, it's run by fakeSSH as ssh -W would be: it connects to
+// the address after -W, and forwards its stdin and stdout to it.
+func TestSSHHelperProcess(t *testing.T) {
+	if os.Getenv("GOADB_SSH_HELPER") != "1" {
+		return
+	}
+	if len(os.Args) < 2 || os.Args[len(os.Args)-2] != "--" {
+		fmt.Fprintf(os.Stderr, "destination isn't after --: %q\n", os.Args)
+		os.Exit(255)
+	}
+	var address string
+	for i, arg := range os.Args {
+		if arg == "-W" && i+1 < len(os.Args) {
+			address = os.Args[i+1]
+		}
+	}
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "channel 0: open failed: connect failed: %v\n", err)
+		os.Exit(255)
+	}
+	go func() {
+		io.Copy(conn, os.Stdin)
+		conn.(*net.TCPConn).CloseWrite()
+	}()
+	io.Copy(os.Stdout, conn)
+	os.Exit(0)
+}
+
+// fakeSSH returns an SSHDialer that runs TestSSHHelperProcess instead of ssh.
+func fakeSSH(t *testing.T) (dialer *adb.SSHDialer, cleanup func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake ssh is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "ssh")
+	require.NoError(t, err)
+	script := filepath.Join(dir, "ssh")
+	require.NoError(t, ioutil.WriteFile(script,
+		[]byte(fmt.Sprintf("#!/bin/sh\nexec %q -test.run=TestSSHHelperProcess -- \"$@\"\n", os.Args[0])), 0755))
+	os.Setenv("GOADB_SSH_HELPER", "1")
+	return &adb.SSHDialer{Destination: "build-7", SSHPath: script}, func() {
+		os.Unsetenv("GOADB_SSH_HELPER")
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSSHDialer(t *testing.T) {
+	dialer, cleanup := fakeSSH(t)
+	defer cleanup()
+	server := NewServer()
+	defer server.Close()
+	server.AddDevice(&Device{Serial: "emulator-5554", Shell: map[string]string{"echo hello": "hello\n"}})
+
+	config := server.Config()
+	config.Dialer = dialer
+	client, err := adb.NewWithConfig(config)
+	require.NoError(t, err)
+
+	serials, err := client.ListDeviceSerials()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"emulator-5554"}, serials)
+	output, err := client.Device(adb.AnyDevice()).RunCommand("echo", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+
+	// Nothing listens on the address on the remote machine.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+	client, err = adb.NewWithConfig(adb.ServerConfig{Host: addr.IP.String(), Port: addr.Port, Dialer: dialer, NoServer: true})
+	require.NoError(t, err)
+	_, err = client.ServerVersion()
+	assert.Contains(t, adb.ErrorWithCauseChain(err), "connect failed")
+}
+
+func TestForwardSSH(t *testing.T) {
+	dialer, cleanup := fakeSSH(t)
+	defer cleanup()
+	server := NewServer()
+	defer server.Close()
+
+	listener, err := adb.ForwardSSH(dialer, server.Addr())
+	require.NoError(t, err)
+	defer listener.Close()
+	addr := listener.Addr().(*net.TCPAddr)
+	client, err := adb.NewWithConfig(adb.ServerConfig{Host: addr.IP.String(), Port: addr.Port, NoServer: true})
+	require.NoError(t, err)
+
+	version, err := client.ServerVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultVersion, version)
+}
@@ -0,0 +1,220 @@
+package adbtest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// File is a file or directory on a fake Device, served over the sync protocol.
+type File struct {
+	Data []byte
+	// Defaults to a regular file with permissions 0644. Set os.ModeDir for an empty directory;
+	// directories containing files are implied.
+	Mode    os.FileMode
+	ModTime time.Time
+}
+
+// lookupFile returns the file at name, a directory if name is the parent of any file, or nil.
+func (d *Device) lookupFile(name string) *File {
+	name = path.Clean(name)
+	if file, ok := d.Files[name]; ok {
+		return file
+	}
+	for other := range d.Files {
+		if strings.HasPrefix(other, name+"/") || name == "/" {
+			return &File{Mode: os.ModeDir | 0755}
+		}
+	}
+	return nil
+}
+
+// syncSession serves sync requests on conn until the client quits or disconnects.
+type syncSession struct {
+	conn   net.Conn
+	device *Device
+	// Held while handling each request.
+	mu *sync.Mutex
+}
+
+func (s *syncSession) serve() {
+	for {
+		id, arg, err := s.readRequest()
+		if err != nil {
+			return
+		}
+		if !s.handle(id, arg) {
+			return
+		}
+	}
+}
+
+// handle handles a request, and returns false if the session is over.
+func (s *syncSession) handle(id, arg string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch id {
+	case "STAT":
+		s.conn.Write([]byte("STAT"))
+		s.writeFileInfo(s.device.lookupFile(arg))
+	case "LIST":
+		s.writeList(arg)
+	case "RECV":
+		s.writeFile(arg)
+	case "SEND":
+		return s.receiveFile(arg) == nil
+	case "QUIT":
+		return false
+	default:
+		s.writeFail(fmt.Sprintf("unknown sync request %q", id))
+		return false
+	}
+	return true
+}
+
+func (s *syncSession) readHeader() (string, uint32, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(s.conn, header[:]); err != nil {
+		return "", 0, err
+	}
+	return string(header[:4]), binary.LittleEndian.Uint32(header[4:]), nil
+}
+
+func (s *syncSession) readRequest() (string, string, error) {
+	id, length, err := s.readHeader()
+	if err != nil {
+		return "", "", err
+	}
+	arg := make([]byte, length)
+	if _, err := io.ReadFull(s.conn, arg); err != nil {
+		return "", "", err
+	}
+	return id, string(arg), nil
+}
+
+func (s *syncSession) writeList(dir string) {
+	dir = path.Clean(dir)
+	entries := map[string]*File{}
+	for name := range s.device.Files {
+		rel := strings.TrimPrefix(name, strings.TrimSuffix(dir, "/")+"/")
+		if rel == name || rel == "" {
+			continue
+		}
+		// Files in subdirectories imply the subdirectory.
+		child := strings.SplitN(rel, "/", 2)[0]
+		entries[child] = s.device.lookupFile(path.Join(dir, child))
+	}
+
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s.conn.Write([]byte("DENT"))
+		s.writeFileInfo(entries[name])
+		s.writeInt32(uint32(len(name)))
+		io.WriteString(s.conn, name)
+	}
+	io.WriteString(s.conn, wire.StatusSyncDone)
+	s.conn.Write(make([]byte, 16))
+}
+
+func (s *syncSession) writeFile(name string) {
+	file := s.device.lookupFile(name)
+	if file == nil || file.Mode.IsDir() {
+		s.writeFail("No such file or directory")
+		return
+	}
+	for data := file.Data; len(data) > 0; {
+		chunk := data
+		if len(chunk) > wire.SyncMaxChunkSize {
+			chunk = chunk[:wire.SyncMaxChunkSize]
+		}
+		io.WriteString(s.conn, wire.StatusSyncData)
+		s.writeInt32(uint32(len(chunk)))
+		s.conn.Write(chunk)
+		data = data[len(chunk):]
+	}
+	io.WriteString(s.conn, wire.StatusSyncDone)
+	s.writeInt32(0)
+}
+
+// receiveFile reads the DATA packets following a SEND request for "<path>,<mode>", up to DONE,
+// which carries the mtime.
+func (s *syncSession) receiveFile(pathAndMode string) error {
+	i := strings.LastIndexByte(pathAndMode, ',')
+	if i < 0 {
+		s.writeFail("invalid SEND request")
+		return fmt.Errorf("invalid SEND request")
+	}
+	var perm uint32
+	fmt.Sscanf(pathAndMode[i+1:], "%d", &perm)
+	file := &File{Mode: os.FileMode(perm).Perm()}
+
+	for {
+		id, arg, err := s.readHeader()
+		if err != nil {
+			return err
+		}
+		switch id {
+		case wire.StatusSyncData:
+			data := make([]byte, arg)
+			if _, err := io.ReadFull(s.conn, data); err != nil {
+				return err
+			}
+			file.Data = append(file.Data, data...)
+		case wire.StatusSyncDone:
+			file.ModTime = time.Unix(int64(arg), 0).UTC()
+			if s.device.Files == nil {
+				s.device.Files = map[string]*File{}
+			}
+			s.device.Files[path.Clean(pathAndMode[:i])] = file
+			io.WriteString(s.conn, wire.StatusSuccess)
+			s.writeInt32(0)
+			return nil
+		default:
+			s.writeFail(fmt.Sprintf("unexpected %q during SEND", id))
+			return fmt.Errorf("unexpected %q during SEND", id)
+		}
+	}
+}
+
+func (s *syncSession) writeFail(msg string) {
+	io.WriteString(s.conn, wire.StatusFailure)
+	s.writeInt32(uint32(len(msg)))
+	io.WriteString(s.conn, msg)
+}
+
+// writeFileInfo writes the mode, size and mtime of file, or zeros if it's nil, which is how
+// adbd reports missing files.
+func (s *syncSession) writeFileInfo(file *File) {
+	if file == nil {
+		s.conn.Write(make([]byte, 12))
+		return
+	}
+
+	mode := wire.ModeRegular | uint32(file.Mode.Perm())
+	if file.Mode.IsDir() {
+		mode = wire.ModeDir | uint32(file.Mode.Perm())
+	} else if file.Mode == 0 {
+		mode = wire.ModeRegular | 0644
+	}
+	s.writeInt32(mode)
+	s.writeInt32(uint32(len(file.Data)))
+	s.writeInt32(uint32(file.ModTime.Unix()))
+}
+
+func (s *syncSession) writeInt32(v uint32) {
+	binary.Write(s.conn, binary.LittleEndian, v)
+}
@@ -0,0 +1,325 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Directory the system writes ANR traces to. Before Android 10 it's a single traces.txt,
+// since then it's an anr_<date> file per ANR.
+const anrTraceDir = "/data/anr"
+
+var (
+	// anrTraceTimeout is how long to wait for the traces of an ANR to be written after the ANR
+	// is logged, which happens before the system dumps the stacks.
+	anrTraceTimeout = 10 * time.Second
+
+	// anrTracePollInterval is the time between checks for the traces of an ANR.
+	anrTracePollInterval = time.Second
+)
+
+var (
+	// Matches an am_anr line of logcat -v epoch -b events, e.g.
+	// "1500000000.123  1000  1234 I am_anr  : [0,4321,com.example,952745541,Input dispatching timed out]".
+	amANRLinePattern = regexp.MustCompile(`^\s*(\d+)\.(\d+)\s+\d+\s+\d+\s+\w\s+am_anr\s*:\s*\[(.*)\]\s*$`)
+
+	// Matches the "----- pid 4321 at 2024-01-02 03:04:05 -----" line that starts a process in a
+	// trace file.
+	tracePidLinePattern = regexp.MustCompile(`^----- pid (\d+) at .* -----$`)
+
+	// Matches the `"main" prio=5 tid=1 Blocked` line that starts a thread, or the
+	// `"binder:4321_1" prio=5 (not attached)` line of a thread the runtime doesn't know about.
+	traceThreadLinePattern = regexp.MustCompile(`^"(.*)"(?: daemon)?(?: prio=\d+)?(?: tid=(\d+) (\S+))?`)
+)
+
+// ANR is an Application Not Responding error reported by an ANRWatcher.
+type ANR struct {
+	// When the system detected the ANR.
+	Time    time.Time
+	PID     int
+	Package string
+	// Why the system decided the app isn't responding, e.g. "Input dispatching timed out (...)".
+	Reason string
+
+	// Path on the device of the traces file the stacks were read from.
+	TracePath string
+	// Stacks of the app's threads when the ANR happened, in the order they were dumped.
+	Threads []ThreadStack
+	// Error reading the traces, if they couldn't be read, e.g. because the device isn't rooted.
+	TraceErr error
+}
+
+// MainThread returns the stack of the app's main thread, which is usually what's blocked, or
+// nil if the traces weren't read.
+func (a *ANR) MainThread() *ThreadStack {
+	for i := range a.Threads {
+		if a.Threads[i].TID == 1 || a.Threads[i].Name == "main" {
+			return &a.Threads[i]
+		}
+	}
+	return nil
+}
+
+// ThreadStack is the stack of a thread in an ANR trace.
+type ThreadStack struct {
+	Name string
+	// Thread id in the runtime, not the kernel; the main thread is 1. 0 for native threads the
+	// runtime isn't attached to.
+	TID int
+	// State in the runtime, e.g. Runnable, Blocked, Waiting, or Native.
+	State string
+	// Java and native frames, innermost first, e.g.
+	// "com.example.MainActivity.onClick(MainActivity.java:42)" or
+	// "#00 pc 000000000004e0f8  /apex/com.android.runtime/lib64/bionic/libc.so (syscall+24)".
+	Frames []string
+	// Locks the thread holds or waits for, e.g.
+	// "waiting to lock <0x0a1b2c3d> (a java.lang.Object) held by thread 12".
+	Locks []string
+}
+
+// ANRWatcher publishes the ANRs of a device.
+type ANRWatcher struct {
+	anrChan chan ANR
+	// If an error occurs, it is stored here and anrChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get ANRs. It's closed when the context
+// passed to WatchANRs is done, or if an error occurs.
+func (w *ANRWatcher) C() <-chan ANR {
+	return w.anrChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (w *ANRWatcher) Err() error {
+	if err, ok := w.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+WatchANRs reports ANRs that happen after it's called, until ctx is done. For each ANR it waits
+for the system to dump the app's stacks, reads them from /data/anr, and parses the stacks of
+the app's threads.
+
+/data/anr is only readable by the system, so traces are read as root if possible, else as the
+app with run-as, which only works on old builds where the traces are readable by apps. If
+neither works, the ANR is still reported, with TraceErr set.
+
+E.g. to print what the main thread was doing:
+
+	watcher, err := device.WatchANRs(ctx)
+	for anr := range watcher.C() {
+		fmt.Println(anr.Package, anr.Reason)
+		if main := anr.MainThread(); main != nil {
+			fmt.Println(strings.Join(main.Frames, "\n"))
+		}
+	}
+
+Corresponds to the commands:
+
+	adb shell logcat -v epoch -b events -T <start time> -s am_anr
+	adb shell ls -t /data/anr
+	adb shell cat /data/anr/<file>
+*/
+func (c *Device) WatchANRs(ctx context.Context) (*ANRWatcher, error) {
+	// Read the device clock, so ANRs already in the log aren't reported.
+	startTime, err := c.runShellCommand("date +%s")
+	if err != nil {
+		return nil, wrapClientError(err, c, "WatchANRs")
+	}
+	startTime = strings.TrimSpace(startTime)
+	if _, err := strconv.Atoi(startTime); err != nil {
+		return nil, wrapClientError(errors.WrapErrorf(err, errors.ParseError, "invalid device time: %q", startTime),
+			c, "WatchANRs")
+	}
+
+	conn, err := c.openService("shell:" + quoteShellArgs("logcat", "-v", "epoch", "-b", "events",
+		"-T", startTime+".000", "-s", "am_anr"))
+	if err != nil {
+		return nil, wrapClientError(err, c, "WatchANRs")
+	}
+
+	watcher := &ANRWatcher{anrChan: make(chan ANR)}
+	go func() {
+		defer close(watcher.anrChan)
+		output := newContextReader(ctx, conn)
+		defer output.Close()
+
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			anr, ok := parseAMANRLine(strings.TrimRight(scanner.Text(), "\r"))
+			if !ok {
+				continue
+			}
+			anr.TracePath, anr.Threads, anr.TraceErr = c.waitForANRTrace(ctx, anr.PID, anr.Package)
+			if anr.TraceErr != nil && ctx.Err() != nil {
+				return
+			}
+			select {
+			case watcher.anrChan <- *anr:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			watcher.err.Store(wrapClientError(err, c, "WatchANRs"))
+		}
+	}()
+	return watcher, nil
+}
+
+// waitForANRTrace reads the stacks of pid from the newest trace file, waiting up to
+// anrTraceTimeout for them to be written.
+func (c *Device) waitForANRTrace(ctx context.Context, pid int, pkg string) (string, []ThreadStack, error) {
+	deadline := time.Now().Add(anrTraceTimeout)
+	for {
+		path, threads, err := c.readANRTrace(pid, pkg)
+		if err == nil || !HasErrCode(err, FileNoExistError) || time.Now().After(deadline) {
+			return path, threads, wrapClientError(err, c, "WatchANRs")
+		}
+		select {
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		case <-time.After(anrTracePollInterval):
+		}
+	}
+}
+
+// readANRTrace reads the stacks of pid from the newest trace file. It returns a
+// FileNoExistError if they haven't been written yet.
+func (c *Device) readANRTrace(pid int, pkg string) (string, []ThreadStack, error) {
+	listing, err := c.runANRTraceCommand(pkg, quoteShellArgs("ls", "-t", anrTraceDir))
+	if err != nil {
+		return "", nil, err
+	}
+	var name string
+	for _, line := range strings.Split(listing, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "anr_") || line == "traces.txt" {
+			name = line
+			break
+		}
+	}
+	if name == "" {
+		return "", nil, errors.Errorf(errors.FileNoExistError, "no traces in %s", anrTraceDir)
+	}
+
+	path := anrTraceDir + "/" + name
+	trace, err := c.runANRTraceCommand(pkg, quoteShellArgs("cat", path))
+	if err != nil {
+		return "", nil, err
+	}
+	threads, ok := parseANRTrace(trace, pid)
+	if !ok {
+		return "", nil, errors.Errorf(errors.FileNoExistError, "no traces for pid %d in %s", pid, path)
+	}
+	return path, threads, nil
+}
+
+// runANRTraceCommand runs cmdLine, an ls or cat of the trace directory, as root, or as pkg if
+// root isn't available.
+func (c *Device) runANRTraceCommand(pkg string, cmdLine string) (string, error) {
+	output, err := c.runRootShellCommand(cmdLine)
+	if err != nil || anrTraceCommandFailed(output) {
+		if output, err = c.RunAs(pkg).RunCommand("sh", "-c", cmdLine); err != nil {
+			return "", err
+		}
+		if anrTraceCommandFailed(output) {
+			return "", errors.Errorf(errors.PermissionDenied, "can't read %s: %s", anrTraceDir,
+				strings.TrimSpace(output))
+		}
+	}
+	return output, nil
+}
+
+func anrTraceCommandFailed(output string) bool {
+	return strings.HasPrefix(output, "ls:") || strings.HasPrefix(output, "cat:")
+}
+
+// parseAMANRLine parses an am_anr line of the events log. Before Android 4.2 the event has no
+// user field.
+func parseAMANRLine(line string) (*ANR, bool) {
+	match := amANRLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+	seconds, _ := strconv.ParseInt(match[1], 10, 64)
+	millis, _ := strconv.ParseInt(match[2], 10, 64)
+
+	fields := strings.SplitN(match[3], ",", 5)
+	if len(fields) == 5 {
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			fields = strings.SplitN(match[3], ",", 4)
+		} else {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) < 4 {
+		return nil, false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, false
+	}
+	return &ANR{
+		Time:    time.Unix(seconds, millis*int64(time.Millisecond)),
+		PID:     pid,
+		Package: fields[1],
+		Reason:  strings.TrimSpace(fields[3]),
+	}, true
+}
+
+// parseANRTrace returns the stacks of the threads of pid in trace, which may contain the
+// traces of several processes.
+func parseANRTrace(trace string, pid int) ([]ThreadStack, bool) {
+	var threads []ThreadStack
+	var thread *ThreadStack
+	found, inProcess := false, false
+	for _, line := range strings.Split(trace, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if match := tracePidLinePattern.FindStringSubmatch(line); match != nil {
+			inProcess = match[1] == strconv.Itoa(pid)
+			if inProcess {
+				// A process can be dumped more than once, e.g. by repeated ANRs; use the last.
+				threads, thread, found = nil, nil, true
+			}
+			continue
+		}
+		if !inProcess {
+			continue
+		}
+		if strings.HasPrefix(line, "----- end ") {
+			inProcess = false
+			continue
+		}
+
+		if match := traceThreadLinePattern.FindStringSubmatch(line); match != nil {
+			tid, _ := strconv.Atoi(match[2])
+			threads = append(threads, ThreadStack{Name: match[1], TID: tid, State: match[3]})
+			thread = &threads[len(threads)-1]
+			continue
+		}
+		if thread == nil {
+			continue
+		}
+		switch trimmed := strings.TrimSpace(line); {
+		case strings.HasPrefix(trimmed, "at "):
+			thread.Frames = append(thread.Frames, strings.TrimPrefix(trimmed, "at "))
+		case strings.HasPrefix(trimmed, "native: "):
+			thread.Frames = append(thread.Frames, strings.TrimPrefix(trimmed, "native: "))
+		case strings.HasPrefix(trimmed, "- "):
+			thread.Locks = append(thread.Locks, strings.TrimPrefix(trimmed, "- "))
+		}
+	}
+	return threads, found
+}
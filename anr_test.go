@@ -0,0 +1,123 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testANRTrace = `
+----- pid 1111 at 2024-01-02 03:04:00.000 -----
+Cmd line: com.other
+
+"main" prio=5 tid=1 Native
+  at android.os.MessageQueue.nativePollOnce(Native method)
+
+----- end 1111 -----
+
+----- pid 4321 at 2024-01-02 03:04:05.678 -----
+Cmd line: com.example
+Build fingerprint: 'google/sdk_gphone64_x86_64/emu64xa:14/UE1A.230829.036/10764500:userdebug/dev-keys'
+
+DALVIK THREADS (2):
+"main" prio=5 tid=1 Blocked
+  | group="main" sCount=1 ucsCount=0 flags=1 obj=0x72313478 self=0xb400007b0c4f67b0
+  | sysTid=4321 nice=-10 cgrp=top-app sched=0/0 handle=0x7c3a6d04f8
+  at com.example.MainActivity.onClick(MainActivity.java:42)
+  - waiting to lock <0x0a1b2c3d> (a java.lang.Object) held by thread 12
+  at android.view.View.performClick(View.java:7448)
+
+"Signal Catcher" daemon prio=10 tid=2 Runnable
+  native: #00 pc 000000000004e0f8  /apex/com.android.runtime/lib64/bionic/libc.so (syscall+24)
+  (no managed stack frames)
+
+"binder:4321_1" prio=5 (not attached)
+  native: #00 pc 00000000000a25a8  /apex/com.android.runtime/lib64/bionic/libc.so (__ioctl+8)
+
+----- end 4321 -----
+`
+
+func TestParseAMANRLine(t *testing.T) {
+	anr, ok := parseAMANRLine("1500000000.123  1000  612 I am_anr  : [0,4321,com.example,952745541,Input dispatching timed out (a, b)]")
+	require.True(t, ok)
+	assert.Equal(t, &ANR{
+		Time:    time.Unix(1500000000, 123*int64(time.Millisecond)),
+		PID:     4321,
+		Package: "com.example",
+		Reason:  "Input dispatching timed out (a, b)",
+	}, anr)
+
+	anr, ok = parseAMANRLine("1500000000.000  1000  612 I am_anr  : [4321,com.example,48694,executing service com.example/.Sync]")
+	require.True(t, ok)
+	assert.Equal(t, 4321, anr.PID)
+	assert.Equal(t, "executing service com.example/.Sync", anr.Reason)
+
+	_, ok = parseAMANRLine("--------- beginning of events")
+	assert.False(t, ok)
+}
+
+func TestParseANRTrace(t *testing.T) {
+	threads, ok := parseANRTrace(testANRTrace, 4321)
+	require.True(t, ok)
+	assert.Equal(t, []ThreadStack{
+		{
+			Name:  "main",
+			TID:   1,
+			State: "Blocked",
+			Frames: []string{
+				"com.example.MainActivity.onClick(MainActivity.java:42)",
+				"android.view.View.performClick(View.java:7448)",
+			},
+			Locks: []string{"waiting to lock <0x0a1b2c3d> (a java.lang.Object) held by thread 12"},
+		},
+		{
+			Name:   "Signal Catcher",
+			TID:    2,
+			State:  "Runnable",
+			Frames: []string{"#00 pc 000000000004e0f8  /apex/com.android.runtime/lib64/bionic/libc.so (syscall+24)"},
+		},
+		{
+			Name:   "binder:4321_1",
+			Frames: []string{"#00 pc 00000000000a25a8  /apex/com.android.runtime/lib64/bionic/libc.so (__ioctl+8)"},
+		},
+	}, threads)
+
+	anr := &ANR{Threads: threads}
+	assert.Equal(t, &threads[0], anr.MainThread())
+
+	_, ok = parseANRTrace(testANRTrace, 9999)
+	assert.False(t, ok)
+}
+
+func TestWatchANRs(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"date +%s": "1500000000\n",
+			"logcat -v epoch -b events -T 1500000000.000 -s am_anr": "--------- beginning of events\n" +
+				"1500000005.678  1000  612 I am_anr  : [0,4321,com.example,952745541,Input dispatching timed out]\n",
+			"id -u":           "0\n",
+			"ls -t /data/anr": "anr_2024-01-02-03-04-05-678\nanr_2024-01-01-00-00-00-000\n",
+			"cat /data/anr/anr_2024-01-02-03-04-05-678": testANRTrace,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	watcher, err := device.WatchANRs(context.Background())
+	require.NoError(t, err)
+	var anrs []ANR
+	for anr := range watcher.C() {
+		anrs = append(anrs, anr)
+	}
+	assert.NoError(t, watcher.Err())
+	require.Len(t, anrs, 1)
+	assert.Equal(t, "com.example", anrs[0].Package)
+	assert.Equal(t, "/data/anr/anr_2024-01-02-03-04-05-678", anrs[0].TracePath)
+	assert.NoError(t, anrs[0].TraceErr)
+	require.NotNil(t, anrs[0].MainThread())
+	assert.Equal(t, "Blocked", anrs[0].MainThread().State)
+}
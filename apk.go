@@ -0,0 +1,355 @@
+package adb
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// ApkInfo identifies a build of an app, from its manifest or from the package manager.
+type ApkInfo struct {
+	Package string
+	// Includes versionCodeMajor in the high 32 bits.
+	VersionCode int64
+	// Empty if the manifest refers to a resource for it.
+	VersionName string
+}
+
+// Chunk types of compiled XML, from ResourceTypes.h.
+const (
+	axmlStringPoolType   = 0x0001
+	axmlXMLType          = 0x0003
+	axmlStartElementType = 0x0102
+	axmlResourceMapType  = 0x0180
+
+	axmlUTF8Flag     = 1 << 8
+	axmlNoIndex      = 0xffffffff
+	axmlTypeString   = 0x03
+	axmlTypeIntDec   = 0x10
+	axmlTypeIntHex   = 0x11
+	axmlChunkHeader  = 8
+	axmlAttrSize     = 20
+	axmlElementStart = 16
+
+	// Resource ids of the manifest attributes, which shrunk APKs may only be identified by.
+	attrVersionCode      = 0x0101021b
+	attrVersionName      = 0x0101021c
+	attrVersionCodeMajor = 0x01010576
+)
+
+/*
+ReadApkInfo reads the package name and version from the compiled AndroidManifest.xml of the
+APK at path, without needing aapt.
+*/
+func ReadApkInfo(path string) (*ApkInfo, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "error opening %s", path)
+	}
+	defer archive.Close()
+
+	for _, file := range archive.File {
+		if file.Name != "AndroidManifest.xml" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, errors.WrapErrorf(err, errors.ParseError, "error reading manifest of %s", path)
+		}
+		manifest, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, errors.WrapErrorf(err, errors.ParseError, "error reading manifest of %s", path)
+		}
+		info, err := parseManifest(manifest)
+		if err != nil {
+			return nil, errors.WrapErrf(err, "invalid manifest in %s", path)
+		}
+		return info, nil
+	}
+	return nil, errors.Errorf(errors.ParseError, "no AndroidManifest.xml in %s", path)
+}
+
+// parseManifest reads the attributes of the manifest element of a compiled manifest.
+func parseManifest(data []byte) (*ApkInfo, error) {
+	if len(data) < axmlChunkHeader || binary.LittleEndian.Uint16(data) != axmlXMLType {
+		return nil, errors.Errorf(errors.ParseError, "not compiled XML")
+	}
+
+	var strs []string
+	var resourceIDs []uint32
+	offset := int(binary.LittleEndian.Uint16(data[2:]))
+	for offset+axmlChunkHeader <= len(data) {
+		chunk := data[offset:]
+		chunkType := binary.LittleEndian.Uint16(chunk)
+		size := int(binary.LittleEndian.Uint32(chunk[4:]))
+		if size < axmlChunkHeader || size > len(chunk) {
+			return nil, errors.Errorf(errors.ParseError, "invalid chunk size %d at %d", size, offset)
+		}
+		chunk = chunk[:size]
+
+		switch chunkType {
+		case axmlStringPoolType:
+			var err error
+			if strs, err = parseStringPool(chunk); err != nil {
+				return nil, err
+			}
+		case axmlResourceMapType:
+			headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+			for i := headerSize; i+4 <= size; i += 4 {
+				resourceIDs = append(resourceIDs, binary.LittleEndian.Uint32(chunk[i:]))
+			}
+		case axmlStartElementType:
+			if size < axmlElementStart+20 || axmlString(strs, binary.LittleEndian.Uint32(chunk[20:])) != "manifest" {
+				break
+			}
+			return parseManifestElement(chunk, strs, resourceIDs)
+		}
+		offset += size
+	}
+	return nil, errors.Errorf(errors.ParseError, "no manifest element")
+}
+
+func parseManifestElement(chunk []byte, strs []string, resourceIDs []uint32) (*ApkInfo, error) {
+	ext := chunk[axmlElementStart:]
+	start := int(binary.LittleEndian.Uint16(ext[8:]))
+	attrSize := int(binary.LittleEndian.Uint16(ext[10:]))
+	count := int(binary.LittleEndian.Uint16(ext[12:]))
+	if attrSize < axmlAttrSize || start+count*attrSize > len(ext) {
+		return nil, errors.Errorf(errors.ParseError, "invalid manifest attributes")
+	}
+
+	var info ApkInfo
+	var major int64
+	for i := 0; i < count; i++ {
+		attr := ext[start+i*attrSize:]
+		nameIndex := binary.LittleEndian.Uint32(attr[4:])
+		rawValue := binary.LittleEndian.Uint32(attr[8:])
+		dataType := attr[15]
+		value := binary.LittleEndian.Uint32(attr[16:])
+
+		var resourceID uint32
+		if int(nameIndex) < len(resourceIDs) {
+			resourceID = resourceIDs[nameIndex]
+		}
+		name := axmlString(strs, nameIndex)
+		switch {
+		case name == "package":
+			info.Package = axmlString(strs, rawValue)
+		case name == "versionCode" || resourceID == attrVersionCode:
+			if dataType == axmlTypeIntDec || dataType == axmlTypeIntHex {
+				info.VersionCode |= int64(value)
+			} else if code, err := strconv.ParseInt(axmlString(strs, rawValue), 10, 64); err == nil {
+				info.VersionCode |= code
+			}
+		case name == "versionCodeMajor" || resourceID == attrVersionCodeMajor:
+			major = int64(value)
+		case name == "versionName" || resourceID == attrVersionName:
+			if dataType == axmlTypeString {
+				info.VersionName = axmlString(strs, rawValue)
+			}
+		}
+	}
+	if info.Package == "" {
+		return nil, errors.Errorf(errors.ParseError, "manifest has no package")
+	}
+	info.VersionCode |= major << 32
+	return &info, nil
+}
+
+// parseStringPool returns the strings of a string pool chunk, in UTF-8 or UTF-16.
+func parseStringPool(chunk []byte) ([]string, error) {
+	if len(chunk) < 28 {
+		return nil, errors.Errorf(errors.ParseError, "invalid string pool")
+	}
+	headerSize := int(binary.LittleEndian.Uint16(chunk[2:]))
+	count := int(binary.LittleEndian.Uint32(chunk[8:]))
+	utf8 := binary.LittleEndian.Uint32(chunk[16:])&axmlUTF8Flag != 0
+	stringsStart := int(binary.LittleEndian.Uint32(chunk[20:]))
+	if headerSize+count*4 > len(chunk) || stringsStart > len(chunk) {
+		return nil, errors.Errorf(errors.ParseError, "invalid string pool")
+	}
+
+	strs := make([]string, count)
+	for i := range strs {
+		offset := stringsStart + int(binary.LittleEndian.Uint32(chunk[headerSize+i*4:]))
+		var ok bool
+		if utf8 {
+			strs[i], ok = decodePoolUTF8(chunk, offset)
+		} else {
+			strs[i], ok = decodePoolUTF16(chunk, offset)
+		}
+		if !ok {
+			return nil, errors.Errorf(errors.ParseError, "invalid string %d in string pool", i)
+		}
+	}
+	return strs, nil
+}
+
+// decodePoolUTF8 decodes a string prefixed with its lengths in UTF-16 and UTF-8, which take
+// two bytes each if the high bit of the first is set.
+func decodePoolUTF8(chunk []byte, offset int) (string, bool) {
+	for i := 0; i < 2; i++ {
+		if offset >= len(chunk) {
+			return "", false
+		}
+		length := int(chunk[offset])
+		offset++
+		if length&0x80 != 0 {
+			if offset >= len(chunk) {
+				return "", false
+			}
+			length = (length&0x7f)<<8 | int(chunk[offset])
+			offset++
+		}
+		if i == 1 {
+			if offset+length > len(chunk) {
+				return "", false
+			}
+			return string(chunk[offset : offset+length]), true
+		}
+	}
+	return "", false
+}
+
+// decodePoolUTF16 decodes a string prefixed with its length in code units, which takes two
+// units if the high bit of the first is set.
+func decodePoolUTF16(chunk []byte, offset int) (string, bool) {
+	if offset+2 > len(chunk) {
+		return "", false
+	}
+	length := int(binary.LittleEndian.Uint16(chunk[offset:]))
+	offset += 2
+	if length&0x8000 != 0 {
+		if offset+2 > len(chunk) {
+			return "", false
+		}
+		length = (length&0x7fff)<<16 | int(binary.LittleEndian.Uint16(chunk[offset:]))
+		offset += 2
+	}
+	if offset+length*2 > len(chunk) {
+		return "", false
+	}
+	units := make([]uint16, length)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(chunk[offset+i*2:])
+	}
+	return string(utf16.Decode(units)), true
+}
+
+func axmlString(strs []string, index uint32) string {
+	if index == axmlNoIndex || int(index) >= len(strs) {
+		return ""
+	}
+	return strs[index]
+}
+
+/*
+InstalledApkInfo returns the version of pkg installed on the device, or nil if it isn't
+installed.
+
+Corresponds to the command:
+
+	adb shell dumpsys package <package>
+*/
+func (c *Device) InstalledApkInfo(pkg string) (*ApkInfo, error) {
+	output, err := c.runShellCommand(quoteShellArgs("dumpsys", "package", pkg))
+	if err != nil {
+		return nil, wrapClientError(err, c, "InstalledApkInfo(%s)", pkg)
+	}
+	return parsePackageDump(output, pkg), nil
+}
+
+// parsePackageDump returns the version of the first "Package [pkg]" section of dumpsys
+// package, which is the installed one; an updated system app lists its original after it.
+func parsePackageDump(output, pkg string) *ApkInfo {
+	var info *ApkInfo
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Package [") {
+			if info != nil {
+				break
+			}
+			if strings.HasPrefix(line, "Package ["+pkg+"]") {
+				info = &ApkInfo{Package: pkg}
+			}
+			continue
+		}
+		if info == nil {
+			continue
+		}
+		// The name has a line of its own, and may contain spaces.
+		if strings.HasPrefix(line, "versionName=") {
+			info.VersionName = strings.TrimPrefix(line, "versionName=")
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if strings.HasPrefix(field, "versionCode=") {
+				info.VersionCode, _ = strconv.ParseInt(strings.TrimPrefix(field, "versionCode="), 10, 64)
+			}
+		}
+	}
+	return info
+}
+
+// InstallOptions control InstallAppFile.
+type InstallOptions struct {
+	// Replace an existing app, keeping its data.
+	Reinstall bool
+	// Grant all runtime permissions.
+	GrantPermission bool
+	// Don't install if the device already has the APK's package with the same versionCode.
+	// Builds that don't bump it, e.g. local debug builds, are installed only once.
+	SkipSameVersion bool
+}
+
+/*
+InstallAppFile installs the APK at path like InstallAppStream. Returns false, without
+installing anything, if SkipSameVersion is set and the same version is already installed,
+which saves most of the time of installing large apps in repeated test runs.
+*/
+func (c *Device) InstallAppFile(path string, opts InstallOptions) (bool, error) {
+	var apk *ApkInfo
+	if opts.SkipSameVersion {
+		var err error
+		if apk, err = ReadApkInfo(path); err != nil {
+			return false, wrapClientError(err, c, "InstallAppFile(%s)", path)
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false, wrapClientError(err, c, "InstallAppFile(%s)", path)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return false, wrapClientError(err, c, "InstallAppFile(%s)", path)
+	}
+	installed, err := c.installIfChanged(file, info.Size(), apk, opts)
+	return installed, wrapClientError(err, c, "InstallAppFile(%s)", path)
+}
+
+// installIfChanged installs the APK read from r, whose manifest has apk if SkipSameVersion
+// is set, unless the same version is installed.
+func (c *Device) installIfChanged(r io.Reader, size int64, apk *ApkInfo, opts InstallOptions) (bool, error) {
+	if opts.SkipSameVersion {
+		installed, err := c.InstalledApkInfo(apk.Package)
+		if err != nil {
+			return false, err
+		}
+		if installed != nil && installed.VersionCode == apk.VersionCode {
+			return false, nil
+		}
+	}
+	if err := c.installAppStream(r, size, opts.Reinstall, opts.GrantPermission); err != nil {
+		return false, err
+	}
+	return true, nil
+}
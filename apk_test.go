@@ -0,0 +1,218 @@
+package adb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// axmlAttr is an attribute of the manifest element written by compileManifest.
+type axmlAttr struct {
+	name     string
+	str      string
+	dataType byte
+	data     uint32
+}
+
+// compileManifest returns a compiled manifest element with attrs, in the format aapt2 writes.
+// Names in resourceNames are listed in the resource map with the real attribute ids, and left
+// empty in the string pool, like shrunk APKs.
+func compileManifest(attrs []axmlAttr, resourceNames map[string]uint32) []byte {
+	var strs []string
+	var resourceIDs []uint32
+	index := func(s string) uint32 {
+		for i, str := range strs {
+			if str == s {
+				return uint32(i)
+			}
+		}
+		strs = append(strs, s)
+		return uint32(len(strs) - 1)
+	}
+	// Attribute names in the resource map come first in the pool.
+	for _, attr := range attrs {
+		if id, ok := resourceNames[attr.name]; ok {
+			strs = append(strs, "")
+			resourceIDs = append(resourceIDs, id)
+		}
+	}
+	manifest := index("manifest")
+
+	var element bytes.Buffer
+	le := func(w *bytes.Buffer, values ...interface{}) {
+		for _, value := range values {
+			binary.Write(w, binary.LittleEndian, value)
+		}
+	}
+	le(&element, uint32(axmlNoIndex), manifest, uint16(20), uint16(axmlAttrSize), uint16(len(attrs)),
+		uint16(0), uint16(0), uint16(0))
+	mapped := 0
+	for _, attr := range attrs {
+		var name uint32
+		if _, ok := resourceNames[attr.name]; ok {
+			name = uint32(mapped)
+			mapped++
+		} else {
+			name = index(attr.name)
+		}
+		raw := uint32(axmlNoIndex)
+		data := attr.data
+		if attr.dataType == axmlTypeString {
+			raw = index(attr.str)
+			data = raw
+		}
+		le(&element, uint32(axmlNoIndex), name, raw, uint16(8), uint8(0), attr.dataType, data)
+	}
+
+	var pool bytes.Buffer
+	var offsets, chars bytes.Buffer
+	for _, str := range strs {
+		le(&offsets, uint32(chars.Len()))
+		units := utf16.Encode([]rune(str))
+		le(&chars, uint16(len(units)), units, uint16(0))
+	}
+	le(&pool, uint16(axmlStringPoolType), uint16(28), uint32(28+offsets.Len()+chars.Len()),
+		uint32(len(strs)), uint32(0), uint32(0), uint32(28+offsets.Len()), uint32(0))
+	pool.Write(offsets.Bytes())
+	pool.Write(chars.Bytes())
+
+	var resourceMap bytes.Buffer
+	le(&resourceMap, uint16(axmlResourceMapType), uint16(8), uint32(8+4*len(resourceIDs)), resourceIDs)
+
+	var body bytes.Buffer
+	body.Write(pool.Bytes())
+	body.Write(resourceMap.Bytes())
+	le(&body, uint16(axmlStartElementType), uint16(16), uint32(16+element.Len()), uint32(1), uint32(axmlNoIndex))
+	body.Write(element.Bytes())
+
+	var out bytes.Buffer
+	le(&out, uint16(axmlXMLType), uint16(8), uint32(8+body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes()
+}
+
+func writeApk(t *testing.T, dir string, manifest []byte) string {
+	path := filepath.Join(dir, "app.apk")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	archive := zip.NewWriter(file)
+	w, err := archive.Create("classes.dex")
+	require.NoError(t, err)
+	w.Write([]byte("dex\n035"))
+	w, err = archive.Create("AndroidManifest.xml")
+	require.NoError(t, err)
+	w.Write(manifest)
+	require.NoError(t, archive.Close())
+	require.NoError(t, file.Close())
+	return path
+}
+
+func TestReadApkInfo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apk")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	apk := writeApk(t, dir, compileManifest([]axmlAttr{
+		{name: "versionCode", dataType: axmlTypeIntDec, data: 4021},
+		{name: "versionName", str: "4.2.1 beta", dataType: axmlTypeString},
+		{name: "package", str: "com.example.app", dataType: axmlTypeString},
+	}, nil))
+	info, err := ReadApkInfo(apk)
+	assert.NoError(t, err)
+	assert.Equal(t, &ApkInfo{Package: "com.example.app", VersionCode: 4021, VersionName: "4.2.1 beta"}, info)
+
+	// Shrunk APKs only identify attributes by resource id.
+	apk = writeApk(t, dir, compileManifest([]axmlAttr{
+		{name: "versionCode", dataType: axmlTypeIntHex, data: 7},
+		{name: "versionCodeMajor", dataType: axmlTypeIntDec, data: 1},
+		{name: "versionName", dataType: 0x01, data: 0x7f0e0001},
+		{name: "package", str: "com.example.app", dataType: axmlTypeString},
+	}, map[string]uint32{
+		"versionCode":      attrVersionCode,
+		"versionCodeMajor": attrVersionCodeMajor,
+		"versionName":      attrVersionName,
+	}))
+	info, err = ReadApkInfo(apk)
+	assert.NoError(t, err)
+	assert.Equal(t, &ApkInfo{Package: "com.example.app", VersionCode: 1<<32 | 7}, info)
+
+	apk = writeApk(t, dir, []byte("<manifest/>"))
+	_, err = ReadApkInfo(apk)
+	assert.True(t, HasErrCode(err, ParseError))
+	_, err = ReadApkInfo(filepath.Join(dir, "missing.apk"))
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+const packageDump = `Packages:
+  Package [com.example.app] (6f1e2a1):
+    userId=10187
+    pkg=Package{c0a8e1 com.example.app}
+    versionCode=4021 minSdk=24 targetSdk=34
+    versionName=4.2.1 beta
+    splits=[base]
+
+Hidden system packages:
+  Package [com.example.app] (2b9c4d0):
+    versionCode=1 minSdk=24 targetSdk=34
+    versionName=1.0
+`
+
+func TestInstalledApkInfo(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dumpsys package com.example.app":  packageDump,
+			"dumpsys package com.example.gone": "Dexopt state:\n  Unable to find package: com.example.gone\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	info, err := device.InstalledApkInfo("com.example.app")
+	assert.NoError(t, err)
+	assert.Equal(t, &ApkInfo{Package: "com.example.app", VersionCode: 4021, VersionName: "4.2.1 beta"}, info)
+
+	info, err = device.InstalledApkInfo("com.example.gone")
+	assert.NoError(t, err)
+	assert.Nil(t, info)
+}
+
+func TestInstallAppFileSkipSameVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apk")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	apk := writeApk(t, dir, compileManifest([]axmlAttr{
+		{name: "package", str: "com.example.app", dataType: axmlTypeString},
+		{name: "versionCode", dataType: axmlTypeIntDec, data: 4021},
+	}, nil))
+
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{"dumpsys package com.example.app": packageDump},
+	}
+	installed, err := (&Adb{s}).Device(AnyDevice()).InstallAppFile(apk, InstallOptions{Reinstall: true, SkipSameVersion: true})
+	assert.NoError(t, err)
+	assert.False(t, installed)
+	assert.Equal(t, "shell:dumpsys package com.example.app", s.Requests[len(s.Requests)-1])
+
+	// A different build is installed.
+	s = &MockServer{
+		Status:       wire.StatusSuccess,
+		Messages:     []string{"abb_exec", "Success\n"},
+		ShellOutputs: map[string]string{"dumpsys package com.example.app": "Packages:\n  Package [com.example.app] (1):\n    versionCode=4020\n"},
+	}
+	installed, err = (&Adb{s}).Device(AnyDevice()).InstallAppFile(apk, InstallOptions{Reinstall: true, SkipSameVersion: true})
+	assert.NoError(t, err)
+	assert.True(t, installed)
+	info, _ := os.Stat(apk)
+	assert.Contains(t, s.Requests, abbService("abb_exec:", []string{"package", "install", "-r", "-S", strconv.FormatInt(info.Size(), 10)}))
+}
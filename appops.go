@@ -0,0 +1,118 @@
+package adb
+
+import (
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// AppOpMode is whether an app op is allowed, as accepted and printed by appops.
+type AppOpMode string
+
+const (
+	AppOpAllow AppOpMode = "allow"
+	// The op silently fails, e.g. returns empty data.
+	AppOpIgnore AppOpMode = "ignore"
+	// The op fails with a SecurityException.
+	AppOpDeny AppOpMode = "deny"
+	// Decided by the op's permission.
+	AppOpDefault AppOpMode = "default"
+	// Allowed only while the app is in the foreground, for location, camera, and microphone.
+	AppOpForeground AppOpMode = "foreground"
+)
+
+// Names of app ops that tests commonly change. appops accepts any name from AppOpsManager.
+const (
+	AppOpRunInBackground        = "RUN_IN_BACKGROUND"
+	AppOpRunAnyInBackground     = "RUN_ANY_IN_BACKGROUND"
+	AppOpSystemAlertWindow      = "SYSTEM_ALERT_WINDOW"
+	AppOpWriteSettings          = "WRITE_SETTINGS"
+	AppOpRequestInstallPackages = "REQUEST_INSTALL_PACKAGES"
+	AppOpGetUsageStats          = "GET_USAGE_STATS"
+	AppOpManageExternalStorage  = "MANAGE_EXTERNAL_STORAGE"
+)
+
+// AppOp is the mode of an op for an app.
+type AppOp struct {
+	Name string
+	Mode AppOpMode
+	// True if the mode is set for the app's uid, which overrides the mode for the package.
+	UID bool
+}
+
+/*
+SetAppOp sets op, e.g. AppOpSystemAlertWindow, to mode for pkg and the device's user.
+
+Corresponds to the command:
+
+	adb shell cmd appops set <package> <op> <mode>
+*/
+func (c *Device) SetAppOp(pkg, op string, mode AppOpMode) error {
+	output, err := c.runAppOps("set", pkg, op, string(mode))
+	if err == nil {
+		err = commandOutputError("appops set", output)
+	}
+	return wrapClientError(err, c, "SetAppOp(%s, %s, %s)", pkg, op, mode)
+}
+
+/*
+GetAppOps returns the ops that have been set or used by pkg for the device's user, in the
+order appops prints them. Ops that are neither have their default mode.
+
+Corresponds to the command:
+
+	adb shell cmd appops get <package>
+*/
+func (c *Device) GetAppOps(pkg string) ([]AppOp, error) {
+	output, err := c.runAppOps("get", pkg)
+	if err == nil {
+		err = commandOutputError("appops get", output)
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "GetAppOps(%s)", pkg)
+	}
+	ops, err := parseAppOps(output)
+	return ops, wrapClientError(err, c, "GetAppOps(%s)", pkg)
+}
+
+// runAppOps runs appops <verb>, or cmd appops <verb> where supported, with the device's
+// --user option followed by args.
+func (c *Device) runAppOps(verb string, args ...string) (string, error) {
+	cmdArgs := []string{"appops"}
+	ok, err := c.supportsSDK(sdkCmdAppOps)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		cmdArgs = []string{"cmd", "appops"}
+	}
+	cmdArgs = append(cmdArgs, verb)
+	cmdArgs = append(cmdArgs, c.userArgs()...)
+	cmdArgs = append(cmdArgs, args...)
+	return c.runShellCommand(quoteShellArgs(cmdArgs...))
+}
+
+// parseAppOps parses lines like "SYSTEM_ALERT_WINDOW: allow; time=+1h2m ago" and
+// "Uid mode: COARSE_LOCATION: foreground" of appops get.
+func parseAppOps(output string) ([]AppOp, error) {
+	var ops []AppOp
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == "No operations." {
+			continue
+		}
+		var op AppOp
+		if strings.HasPrefix(line, "Uid mode: ") {
+			op.UID = true
+			line = strings.TrimPrefix(line, "Uid mode: ")
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf(errors.ParseError, "invalid appops line: %q", line)
+		}
+		op.Name = parts[0]
+		op.Mode = AppOpMode(strings.TrimSpace(strings.SplitN(parts[1], ";", 2)[0]))
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
@@ -0,0 +1,60 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestSetAppOp(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"cmd appops set --user 10 com.example SYSTEM_ALERT_WINDOW allow": "",
+			"cmd appops set --user 10 com.example NOT_AN_OP allow":           "Error: Unknown operation string: NOT_AN_OP\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 30
+	device = device.ForUser(10)
+
+	assert.NoError(t, device.SetAppOp("com.example", AppOpSystemAlertWindow, AppOpAllow))
+	err := device.SetAppOp("com.example", "NOT_AN_OP", AppOpAllow)
+	assert.True(t, HasErrCode(err, AdbError))
+
+	// Devices before Android 7.0 have the appops tool.
+	s = &MockServer{Status: wire.StatusSuccess}
+	device = (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 23
+	assert.NoError(t, device.SetAppOp("com.example", AppOpRunInBackground, AppOpIgnore))
+	assert.Equal(t, "shell:appops set com.example RUN_IN_BACKGROUND ignore", s.Requests[1])
+}
+
+func TestGetAppOps(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"cmd appops get com.example": "Uid mode: COARSE_LOCATION: foreground\n" +
+				"RUN_IN_BACKGROUND: ignore; rejectTime=+2m3s ago\n" +
+				"SYSTEM_ALERT_WINDOW: allow\n" +
+				"WAKE_LOCK: allow; time=+5m ago; duration=+3s\n",
+			"cmd appops get com.example.fresh": "No operations.\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 30
+
+	ops, err := device.GetAppOps("com.example")
+	assert.NoError(t, err)
+	assert.Equal(t, []AppOp{
+		{Name: "COARSE_LOCATION", Mode: AppOpForeground, UID: true},
+		{Name: "RUN_IN_BACKGROUND", Mode: AppOpIgnore},
+		{Name: "SYSTEM_ALERT_WINDOW", Mode: AppOpAllow},
+		{Name: "WAKE_LOCK", Mode: AppOpAllow},
+	}, ops)
+
+	ops, err = device.GetAppOps("com.example.fresh")
+	assert.NoError(t, err)
+	assert.Empty(t, ops)
+}
@@ -0,0 +1,227 @@
+package adb
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// PendingAuthorization is a device waiting for the "Allow USB debugging?" dialog to be accepted.
+type PendingAuthorization struct {
+	Serial string
+	// From ServerConfig.Registry, empty if the device isn't in it.
+	Label string
+	// StateUnauthorized, or StateAuthorizing while adbd checks the host's key.
+	State DeviceState
+	// When an AuthorizationMonitor first saw the device waiting, zero if it's from
+	// PendingAuthorizations.
+	Since time.Time
+	// Fingerprint of the host's public key, which the dialog shows, e.g. "AB:CD:...". Empty
+	// if the key couldn't be read, see HostKeyFingerprint.
+	HostKeyFingerprint string
+}
+
+// AuthorizationEvent is reported by an AuthorizationMonitor when a device starts or stops
+// waiting for authorization.
+type AuthorizationEvent struct {
+	Device PendingAuthorization
+	// The device's state after the change: StateUnauthorized or StateAuthorizing when it starts
+	// waiting, StateOnline when it's authorized, and e.g. StateDisconnected when it's unplugged
+	// before that.
+	NewState DeviceState
+}
+
+// Pending returns true if the device started waiting for authorization.
+func (e AuthorizationEvent) Pending() bool {
+	return isAwaitingAuthorization(e.NewState)
+}
+
+// Authorized returns true if the dialog was accepted and the device came online.
+func (e AuthorizationEvent) Authorized() bool {
+	return e.NewState == StateOnline
+}
+
+func isAwaitingAuthorization(state DeviceState) bool {
+	return state == StateUnauthorized || state == StateAuthorizing
+}
+
+/*
+PendingAuthorizations returns the devices waiting for the "Allow USB debugging?" dialog to be
+accepted. Unauthorized devices refuse every service, including pushing a key to
+/data/misc/adb/adb_keys, so someone has to accept the dialog, checking that it shows
+HostKeyFingerprint.
+*/
+func (c *Adb) PendingAuthorizations() ([]PendingAuthorization, error) {
+	devices, err := c.ListDevices()
+	if err != nil {
+		return nil, wrapClientError(err, c, "PendingAuthorizations")
+	}
+	fingerprint, _ := HostKeyFingerprint()
+
+	var pending []PendingAuthorization
+	for _, device := range devices {
+		if isAwaitingAuthorization(device.State) {
+			pending = append(pending, PendingAuthorization{
+				Serial:             device.Serial,
+				Label:              device.Label,
+				State:              device.State,
+				HostKeyFingerprint: fingerprint,
+			})
+		}
+	}
+	return pending, nil
+}
+
+/*
+AuthorizationMonitor tracks the devices waiting for authorization, and reports when they start
+and stop waiting, so lab operators can be alerted to accept the dialog.
+
+E.g.
+
+	monitor := client.NewAuthorizationMonitor(func(e adb.AuthorizationEvent) {
+		if e.Pending() {
+			alert("accept the debugging dialog on %s, key %s", e.Device.Serial, e.Device.HostKeyFingerprint)
+		}
+	})
+	go monitor.Run(ctx)
+*/
+type AuthorizationMonitor struct {
+	client      *Adb
+	onEvent     func(AuthorizationEvent)
+	fingerprint string
+
+	mu      sync.Mutex
+	pending map[string]*PendingAuthorization
+}
+
+// NewAuthorizationMonitor returns an AuthorizationMonitor that calls onEvent, if not nil, each
+// time a device starts or stops waiting. onEvent is called synchronously and must not block.
+func (c *Adb) NewAuthorizationMonitor(onEvent func(AuthorizationEvent)) *AuthorizationMonitor {
+	fingerprint, _ := HostKeyFingerprint()
+	return &AuthorizationMonitor{
+		client:      c,
+		onEvent:     onEvent,
+		fingerprint: fingerprint,
+		pending:     map[string]*PendingAuthorization{},
+	}
+}
+
+// Run tracks devices until ctx is done or the device watcher fails. Devices that are already
+// waiting when it starts are reported too.
+func (m *AuthorizationMonitor) Run(ctx context.Context) error {
+	watcher := m.client.NewDeviceWatcherWithCtx(ctx)
+	for event := range watcher.C() {
+		m.handleEvent(event)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return watcher.Err()
+}
+
+// Pending returns the devices waiting for authorization, sorted by serial.
+func (m *AuthorizationMonitor) Pending() []PendingAuthorization {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pending := make([]PendingAuthorization, 0, len(m.pending))
+	for _, device := range m.pending {
+		pending = append(pending, *device)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Serial < pending[j].Serial })
+	return pending
+}
+
+func (m *AuthorizationMonitor) handleEvent(event DeviceStateChangedEvent) {
+	m.mu.Lock()
+	device, wasPending := m.pending[event.Serial]
+	report := false
+	switch {
+	case isAwaitingAuthorization(event.NewState):
+		if !wasPending {
+			device = &PendingAuthorization{
+				Serial:             event.Serial,
+				Label:              m.client.server.registry().name(event.Serial),
+				Since:              time.Now(),
+				HostKeyFingerprint: m.fingerprint,
+			}
+			m.pending[event.Serial] = device
+		}
+		// Going from unauthorized to authorizing isn't worth reporting.
+		device.State = event.NewState
+		report = !wasPending
+	case wasPending:
+		delete(m.pending, event.Serial)
+		report = true
+	}
+	var authEvent AuthorizationEvent
+	if report {
+		authEvent = AuthorizationEvent{Device: *device, NewState: event.NewState}
+	}
+	m.mu.Unlock()
+
+	if report && m.onEvent != nil {
+		m.onEvent(authEvent)
+	}
+}
+
+/*
+HostKeyFingerprint returns the fingerprint of this host's adb public key, as shown by the
+"Allow USB debugging?" dialog, e.g. "AB:CD:...". The key is read from ANDROID_USER_HOME, or the
+.android directory in ANDROID_SDK_HOME or the home directory, like the adb server does. It's
+only the key the dialog shows if the server runs on this host.
+*/
+func HostKeyFingerprint() (string, error) {
+	path, err := hostKeyPath()
+	if err != nil {
+		return "", err
+	}
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.WrapErrorf(err, errors.FileNoExistError, "error reading adb key %s", path)
+	}
+	return publicKeyFingerprint(string(key))
+}
+
+func hostKeyPath() (string, error) {
+	if dir := os.Getenv("ANDROID_USER_HOME"); dir != "" {
+		return filepath.Join(dir, "adbkey.pub"), nil
+	}
+	home := os.Getenv("ANDROID_SDK_HOME")
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return "", errors.WrapErrorf(err, errors.FileNoExistError, "can't find the adb key")
+		}
+	}
+	return filepath.Join(home, ".android", "adbkey.pub"), nil
+}
+
+// publicKeyFingerprint returns the fingerprint of an adbkey.pub key, "<base64 key> user@host":
+// the MD5 of the key, in colon-separated hex.
+func publicKeyFingerprint(key string) (string, error) {
+	fields := strings.Fields(key)
+	if len(fields) == 0 {
+		return "", errors.Errorf(errors.ParseError, "empty adb key")
+	}
+	der, err := base64.StdEncoding.DecodeString(fields[0])
+	if err != nil {
+		return "", errors.WrapErrorf(err, errors.ParseError, "invalid adb key")
+	}
+	sum := md5.Sum(der)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hex, ":"), nil
+}
@@ -0,0 +1,76 @@
+package adb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testAdbKey = "bm90IHJlYWxseSBhbiByc2Ega2V5 user@host\n"
+
+func TestHostKeyFingerprint(t *testing.T) {
+	fingerprint, err := publicKeyFingerprint(testAdbKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "1A:43:85:0B:3B:11:04:EA:AD:30:73:40:6C:F3:FA:23", fingerprint)
+	_, err = publicKeyFingerprint("not base64!")
+	assert.True(t, HasErrCode(err, ParseError))
+
+	dir, err := ioutil.TempDir("", "android")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "adbkey.pub"), []byte(testAdbKey), 0644))
+	defer os.Setenv("ANDROID_USER_HOME", os.Getenv("ANDROID_USER_HOME"))
+	os.Setenv("ANDROID_USER_HOME", dir)
+
+	fingerprint, err = HostKeyFingerprint()
+	assert.NoError(t, err)
+	assert.Equal(t, "1A:43:85:0B:3B:11:04:EA:AD:30:73:40:6C:F3:FA:23", fingerprint)
+}
+
+func TestPendingAuthorizations(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"a device\nb unauthorized\nc authorizing\n"},
+	}
+
+	pending, err := (&Adb{s}).PendingAuthorizations()
+	assert.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "b", pending[0].Serial)
+	assert.Equal(t, StateUnauthorized, pending[0].State)
+	assert.Equal(t, "c", pending[1].Serial)
+	assert.Equal(t, StateAuthorizing, pending[1].State)
+}
+
+func TestAuthorizationMonitor(t *testing.T) {
+	var events []AuthorizationEvent
+	monitor := (&Adb{&MockServer{}}).NewAuthorizationMonitor(func(e AuthorizationEvent) {
+		events = append(events, e)
+	})
+
+	monitor.handleEvent(DeviceStateChangedEvent{"a", StateDisconnected, StateOnline})
+	monitor.handleEvent(DeviceStateChangedEvent{"b", StateDisconnected, StateUnauthorized})
+	monitor.handleEvent(DeviceStateChangedEvent{"b", StateUnauthorized, StateAuthorizing})
+	monitor.handleEvent(DeviceStateChangedEvent{"c", StateDisconnected, StateUnauthorized})
+	require.Len(t, events, 2)
+	assert.True(t, events[0].Pending())
+	assert.Equal(t, "b", events[0].Device.Serial)
+	assert.False(t, events[0].Device.Since.IsZero())
+
+	pending := monitor.Pending()
+	require.Len(t, pending, 2)
+	assert.Equal(t, StateAuthorizing, pending[0].State)
+
+	monitor.handleEvent(DeviceStateChangedEvent{"b", StateAuthorizing, StateOnline})
+	monitor.handleEvent(DeviceStateChangedEvent{"c", StateUnauthorized, StateDisconnected})
+	require.Len(t, events, 4)
+	assert.True(t, events[2].Authorized())
+	assert.False(t, events[3].Authorized())
+	assert.False(t, events[3].Pending())
+	assert.Empty(t, monitor.Pending())
+}
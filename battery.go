@@ -0,0 +1,165 @@
+package adb
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// BatteryStatus values reported by dumpsys battery, from android.os.BatteryManager.
+const (
+	BatteryStatusUnknown     = 1
+	BatteryStatusCharging    = 2
+	BatteryStatusDischarging = 3
+	BatteryStatusNotCharging = 4
+	BatteryStatusFull        = 5
+)
+
+// BatteryState is the battery state reported by dumpsys battery.
+// Values reflect any simulated state set through Battery.
+type BatteryState struct {
+	ACPowered       bool
+	USBPowered      bool
+	WirelessPowered bool
+
+	// One of the BatteryStatus constants.
+	Status  int
+	Health  int
+	Present bool
+
+	// Level is out of Scale, which is usually 100.
+	Level int
+	Scale int
+
+	// Millivolts.
+	Voltage int
+	// Tenths of a degree Celsius.
+	Temperature int
+
+	Technology string
+}
+
+// IsPowered returns true if the device is connected to any power source.
+func (s *BatteryState) IsPowered() bool {
+	return s.ACPowered || s.USBPowered || s.WirelessPowered
+}
+
+/*
+Battery simulates battery and charger states, e.g. for low-battery scenarios.
+Simulated values stay in effect until Reset is called or the device reboots.
+To get an instance, call Battery() on a Device.
+
+Corresponds to the command:
+
+	adb shell dumpsys battery
+*/
+type Battery struct {
+	device *Device
+}
+
+// Battery returns a client for the device's battery service.
+func (c *Device) Battery() *Battery {
+	return &Battery{device: c}
+}
+
+// State returns the current (possibly simulated) battery state.
+func (b *Battery) State() (*BatteryState, error) {
+	output, err := b.device.RunCommand("dumpsys", "battery")
+	if err != nil {
+		return nil, wrapClientError(err, b.device, "Battery.State")
+	}
+	state, err := parseBatteryState(output)
+	return state, wrapClientError(err, b.device, "Battery.State")
+}
+
+// SetLevel simulates the battery being at level percent.
+func (b *Battery) SetLevel(level int) error {
+	if level < 0 || level > 100 {
+		return wrapClientError(errors.AssertionErrorf("invalid battery level: %d", level), b.device, "Battery.SetLevel")
+	}
+	return b.set("SetLevel", "level", level)
+}
+
+// SetCharging simulates the battery charging or discharging.
+// To simulate unplugging the charger as well, use Unplug.
+func (b *Battery) SetCharging(charging bool) error {
+	status := BatteryStatusDischarging
+	if charging {
+		status = BatteryStatusCharging
+	}
+	return b.set("SetCharging", "status", status)
+}
+
+// SetTemperature simulates the battery being at tenthsCelsius tenths of a degree Celsius,
+// e.g. 450 for 45°C.
+func (b *Battery) SetTemperature(tenthsCelsius int) error {
+	return b.set("SetTemperature", "temp", tenthsCelsius)
+}
+
+// Unplug simulates disconnecting all power sources.
+func (b *Battery) Unplug() error {
+	return b.run("Unplug", "unplug")
+}
+
+// Reset stops simulating and returns to reporting the real battery state.
+func (b *Battery) Reset() error {
+	return b.run("Reset", "reset")
+}
+
+func (b *Battery) set(operation, key string, value int) error {
+	return b.run(operation, "set", key, strconv.Itoa(value))
+}
+
+func (b *Battery) run(operation string, args ...string) error {
+	output, err := b.device.RunCommand("dumpsys", append([]string{"battery"}, args...)...)
+	if err == nil {
+		err = commandOutputError("dumpsys battery", output)
+	}
+	return wrapClientError(err, b.device, "Battery.%s", operation)
+}
+
+func parseBatteryState(output string) (*BatteryState, error) {
+	var state BatteryState
+	found := false
+
+	for _, line := range strings.Split(output, "\n") {
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		n, _ := strconv.Atoi(value)
+
+		switch key {
+		case "AC powered":
+			state.ACPowered = value == "true"
+		case "USB powered":
+			state.USBPowered = value == "true"
+		case "Wireless powered":
+			state.WirelessPowered = value == "true"
+		case "status":
+			state.Status = n
+		case "health":
+			state.Health = n
+		case "present":
+			state.Present = value == "true"
+		case "level":
+			state.Level = n
+			found = true
+		case "scale":
+			state.Scale = n
+		case "voltage":
+			state.Voltage = n
+		case "temperature":
+			state.Temperature = n
+		case "technology":
+			state.Technology = value
+		}
+	}
+
+	if !found {
+		return nil, errors.Errorf(errors.ParseError, "no battery level found in dumpsys battery output")
+	}
+	return &state, nil
+}
@@ -0,0 +1,52 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseBatteryState(t *testing.T) {
+	state, err := parseBatteryState(`Current Battery Service state:
+  (UPDATES STOPPED -- use 'reset' to restart)
+  AC powered: false
+  USB powered: true
+  Wireless powered: false
+  Max charging current: 500000
+  status: 2
+  health: 2
+  present: true
+  level: 15
+  scale: 100
+  voltage: 4251
+  temperature: 280
+  technology: Li-ion
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, &BatteryState{
+		USBPowered:  true,
+		Status:      BatteryStatusCharging,
+		Health:      2,
+		Present:     true,
+		Level:       15,
+		Scale:       100,
+		Voltage:     4251,
+		Temperature: 280,
+		Technology:  "Li-ion",
+	}, state)
+	assert.True(t, state.IsPowered())
+
+	_, err = parseBatteryState("Can't find service: battery\n")
+	assert.Error(t, err)
+}
+
+func TestBatterySetLevel(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	battery := (&Adb{s}).Device(AnyDevice()).Battery()
+
+	assert.NoError(t, battery.SetLevel(5))
+	assert.Equal(t, "shell:dumpsys battery set level 5", s.Requests[1])
+
+	assert.True(t, HasErrCode(battery.SetLevel(101), AssertionError))
+}
@@ -0,0 +1,64 @@
+package adb
+
+import (
+	"io"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+/*
+ResetBatteryStats discards the power usage the system has collected, so the next
+DumpBatteryStats only covers what happened after this call, e.g. one test. It also turns on
+the full wake lock history, which Battery Historian needs to attribute wake locks, and which
+is off by default to save memory. The history is kept until the device reboots.
+
+Stats are only collected while the device is on battery, so unplug it after resetting, e.g.
+with Battery().Unplug().
+
+Corresponds to the commands:
+
+	adb shell dumpsys batterystats --reset
+	adb shell dumpsys batterystats --enable full-wake-history
+*/
+func (c *Device) ResetBatteryStats() error {
+	for _, args := range [][]string{{"--reset"}, {"--enable", "full-wake-history"}} {
+		output, err := c.RunCommand("dumpsys", append([]string{"batterystats"}, args...)...)
+		if err == nil {
+			err = commandOutputError("dumpsys batterystats", output)
+		}
+		if err != nil {
+			return wrapClientError(err, c, "ResetBatteryStats")
+		}
+	}
+	return nil
+}
+
+/*
+DumpBatteryStats writes the power usage the system has collected since the last reset or full
+charge to w. The default format is the human-readable one, which Battery Historian accepts
+along with bugreports. If checkin is true, it's the comma-separated format meant for parsing,
+instead.
+
+The dump can be several megabytes, so it's streamed rather than buffered.
+
+Corresponds to the command:
+
+	adb shell dumpsys batterystats [-c]
+*/
+func (c *Device) DumpBatteryStats(w io.Writer, checkin bool) error {
+	cmdLine := "dumpsys batterystats"
+	if checkin {
+		cmdLine += " -c"
+	}
+	conn, err := c.openService("shell:" + cmdLine)
+	if err != nil {
+		return wrapClientError(err, c, "DumpBatteryStats")
+	}
+	defer conn.Close()
+
+	if _, err := io.Copy(w, conn); err != nil {
+		return wrapClientError(errors.WrapErrorf(err, errors.NetworkError, "error reading batterystats"),
+			c, "DumpBatteryStats")
+	}
+	return nil
+}
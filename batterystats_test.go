@@ -0,0 +1,46 @@
+package adb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestResetBatteryStats(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dumpsys batterystats --reset":                    "Battery stats reset.\n",
+			"dumpsys batterystats --enable full-wake-history": "Enabled: full-wake-history\n",
+		},
+	}
+	require.NoError(t, (&Adb{s}).Device(AnyDevice()).ResetBatteryStats())
+	assert.Contains(t, s.Requests, "shell:dumpsys batterystats --reset")
+	assert.Contains(t, s.Requests, "shell:dumpsys batterystats --enable full-wake-history")
+
+	s.ShellOutputs["dumpsys batterystats --enable full-wake-history"] = "Unknown option: full-wake-history\n"
+	err := (&Adb{s}).Device(AnyDevice()).ResetBatteryStats()
+	assert.True(t, HasErrCode(err, AdbError))
+}
+
+func TestDumpBatteryStats(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dumpsys batterystats":    "Battery History (1% used, 4KB used of 4096KB, 12 strings using 1KB):\n",
+			"dumpsys batterystats -c": "9,0,i,vers,36,214,UP1A,UP1A\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	var buf bytes.Buffer
+	require.NoError(t, device.DumpBatteryStats(&buf, false))
+	assert.Equal(t, "Battery History (1% used, 4KB used of 4096KB, 12 strings using 1KB):\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, device.DumpBatteryStats(&buf, true))
+	assert.Equal(t, "9,0,i,vers,36,214,UP1A,UP1A\n", buf.String())
+}
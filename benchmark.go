@@ -0,0 +1,177 @@
+package adb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// LaunchBenchmarkOptions configures BenchmarkLaunch.
+type LaunchBenchmarkOptions struct {
+	// Number of cold launches, each after force-stopping the app.
+	ColdRuns int
+	// Number of warm launches, each after pressing back, so the activity is destroyed but the
+	// process keeps running.
+	WarmRuns int
+
+	// Drop the kernel's page cache before each cold launch, so the app's code and resources are
+	// read from storage, like after a reboot. Needs root.
+	DropCaches bool
+	// Bring the device out of doze and wake the screen before each launch, so power saving
+	// doesn't slow launches down.
+	ExitDoze bool
+	// Time to wait before each launch, for work started by the previous one to finish.
+	SettleTime time.Duration
+}
+
+// LaunchStats summarizes the TotalTime of repeated launches.
+type LaunchStats struct {
+	// TotalTime of each launch, in order.
+	Times []time.Duration
+
+	Min, Median, P90, Max, Mean time.Duration
+	// Indexes in Times of launches more than 1.5 interquartile ranges outside the quartiles,
+	// e.g. ones slowed by a background job.
+	Outliers []int
+}
+
+// LaunchBenchmark is the result of BenchmarkLaunch. Cold or Warm is nil if it had no runs.
+type LaunchBenchmark struct {
+	Cold, Warm *LaunchStats
+}
+
+/*
+BenchmarkLaunch measures repeated cold and warm launches of activity, cold ones first, and
+summarizes their TotalTime, the time until the activity drew its first frame.
+
+E.g.
+
+	result, err := device.BenchmarkLaunch(ctx, adb.Activity{"com.example", ".MainActivity"},
+		adb.LaunchBenchmarkOptions{ColdRuns: 10, WarmRuns: 10, ExitDoze: true, SettleTime: 2 * time.Second})
+	fmt.Printf("cold: median %s, p90 %s, outliers %v\n", result.Cold.Median, result.Cold.P90, result.Cold.Outliers)
+
+It stops, and returns ctx.Err(), if ctx is done between launches.
+*/
+func (c *Device) BenchmarkLaunch(ctx context.Context, activity Activity, opts LaunchBenchmarkOptions) (*LaunchBenchmark, error) {
+	if opts.ColdRuns < 0 || opts.WarmRuns < 0 || opts.ColdRuns+opts.WarmRuns == 0 {
+		return nil, wrapClientError(errors.AssertionErrorf("invalid number of runs: %d cold, %d warm",
+			opts.ColdRuns, opts.WarmRuns), c, "BenchmarkLaunch(%s)", activity)
+	}
+
+	var benchmark LaunchBenchmark
+	var err error
+	if opts.ColdRuns > 0 {
+		benchmark.Cold, err = c.benchmarkLaunches(ctx, activity, opts, opts.ColdRuns, c.settleCold)
+		if err != nil {
+			return nil, wrapClientError(err, c, "BenchmarkLaunch(%s)", activity)
+		}
+	}
+	if opts.WarmRuns > 0 {
+		if opts.ColdRuns == 0 {
+			// Start the process, so the first run is warm.
+			if _, err := c.launch(activity); err != nil {
+				return nil, wrapClientError(err, c, "BenchmarkLaunch(%s)", activity)
+			}
+		}
+		benchmark.Warm, err = c.benchmarkLaunches(ctx, activity, opts, opts.WarmRuns, c.settleWarm)
+		if err != nil {
+			return nil, wrapClientError(err, c, "BenchmarkLaunch(%s)", activity)
+		}
+	}
+	return &benchmark, nil
+}
+
+// benchmarkLaunches launches activity runs times, calling settle before each launch.
+func (c *Device) benchmarkLaunches(ctx context.Context, activity Activity, opts LaunchBenchmarkOptions, runs int,
+	settle func(Activity, LaunchBenchmarkOptions) error) (*LaunchStats, error) {
+	times := make([]time.Duration, 0, runs)
+	for i := 0; i < runs; i++ {
+		if err := settle(activity, opts); err != nil {
+			return nil, err
+		}
+		if opts.ExitDoze {
+			if err := c.exitDoze(); err != nil {
+				return nil, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(opts.SettleTime):
+		}
+
+		result, err := c.launch(activity)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, result.TotalTime)
+	}
+	return newLaunchStats(times), nil
+}
+
+func (c *Device) settleCold(activity Activity, opts LaunchBenchmarkOptions) error {
+	if err := c.ForceStop(activity.Package); err != nil {
+		return err
+	}
+	if opts.DropCaches {
+		if _, err := c.runRootShellCommand("echo 3 > /proc/sys/vm/drop_caches"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Device) settleWarm(activity Activity, opts LaunchBenchmarkOptions) error {
+	_, err := c.runInputCommand("keyevent 4")
+	return err
+}
+
+// exitDoze forces the device out of idle mode, and wakes the screen.
+func (c *Device) exitDoze() error {
+	if _, err := c.runShellCommand("dumpsys deviceidle unforce"); err != nil {
+		return err
+	}
+	return c.Wake()
+}
+
+// newLaunchStats summarizes times, which must not be empty.
+func newLaunchStats(times []time.Duration) *LaunchStats {
+	sorted := append([]time.Duration(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	stats := &LaunchStats{
+		Times:  times,
+		Min:    sorted[0],
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		Max:    sorted[len(sorted)-1],
+		Mean:   total / time.Duration(len(times)),
+	}
+
+	q1, q3 := percentile(sorted, 25), percentile(sorted, 75)
+	iqr := q3 - q1
+	for i, t := range times {
+		if t < q1-iqr*3/2 || t > q3+iqr*3/2 {
+			stats.Outliers = append(stats.Outliers, i)
+		}
+	}
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted, interpolating between the closest ranks.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	fraction := rank - float64(lower)
+	return sorted[lower] + time.Duration(fraction*float64(sorted[lower+1]-sorted[lower]))
+}
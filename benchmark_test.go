@@ -0,0 +1,75 @@
+package adb
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestNewLaunchStats(t *testing.T) {
+	stats := newLaunchStats([]time.Duration{ms(500), ms(520), ms(480), ms(2000), ms(510), ms(490)})
+	assert.Equal(t, ms(480), stats.Min)
+	assert.Equal(t, ms(505), stats.Median)
+	assert.Equal(t, ms(2000), stats.Max)
+	assert.Equal(t, ms(750), stats.Mean)
+	assert.Equal(t, []int{3}, stats.Outliers)
+
+	stats = newLaunchStats([]time.Duration{ms(100)})
+	assert.Equal(t, ms(100), stats.Median)
+	assert.Equal(t, ms(100), stats.P90)
+	assert.Empty(t, stats.Outliers)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{ms(10), ms(20), ms(30), ms(40), ms(50), ms(60), ms(70), ms(80), ms(90), ms(100), ms(110)}
+	assert.Equal(t, ms(60), percentile(sorted, 50))
+	assert.Equal(t, ms(100), percentile(sorted, 90))
+	assert.Equal(t, ms(110), percentile(sorted, 100))
+	assert.Equal(t, ms(10), percentile(sorted, 0))
+}
+
+func TestBenchmarkLaunch(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"am start -W -n com.example/.MainActivity": "Status: ok\nTotalTime: 700\nWaitTime: 720\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	result, err := device.BenchmarkLaunch(context.Background(), Activity{"com.example", ".MainActivity"},
+		LaunchBenchmarkOptions{ColdRuns: 2, WarmRuns: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{ms(700), ms(700)}, result.Cold.Times)
+	assert.Equal(t, []time.Duration{ms(700)}, result.Warm.Times)
+
+	var forceStops, backs int
+	for _, req := range s.Requests {
+		switch req {
+		case "shell:am force-stop com.example":
+			forceStops++
+		case "shell:input keyevent 4":
+			backs++
+		}
+	}
+	assert.Equal(t, 2, forceStops)
+	assert.Equal(t, 1, backs)
+
+	_, err = device.BenchmarkLaunch(context.Background(), Activity{"com.example", ".MainActivity"}, LaunchBenchmarkOptions{})
+	assert.True(t, HasErrCode(err, AssertionError))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = device.BenchmarkLaunch(ctx, Activity{"com.example", ".MainActivity"},
+		LaunchBenchmarkOptions{ColdRuns: 1, SettleTime: time.Minute})
+	assert.True(t, stderrors.Is(err, context.Canceled), "%v", err)
+}
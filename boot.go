@@ -0,0 +1,83 @@
+package adb
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// bootPollInterval is the time between checks of WaitForBootComplete.
+const bootPollInterval = time.Second
+
+// Prints the boot properties, then whether the package manager and activity manager answer,
+// in one round trip.
+const bootCompleteCommand = `getprop sys.boot_completed; getprop dev.bootcomplete; ` +
+	`pm path android 2>&1; service check activity 2>&1`
+
+/*
+WaitForBootComplete waits until the device is online and has finished booting, so it can run
+am and pm commands, or ctx is done. It's meant for after a reboot, a flash, or starting an
+emulator: sys.boot_completed is set before the package manager has always finished scanning
+packages, so that's checked as well.
+
+Errors while the device is disconnected or offline, e.g. because it's rebooting, are retried.
+If ctx is done first, the error wraps ctx's, and says what the device was still waiting for.
+*/
+func (c *Device) WaitForBootComplete(ctx context.Context) error {
+	ticker := time.NewTicker(bootPollInterval)
+	defer ticker.Stop()
+
+	for {
+		waitingFor, err := c.bootWaitingFor()
+		if err == nil && waitingFor == "" {
+			return nil
+		}
+		if err != nil {
+			waitingFor = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			return wrapClientError(errors.WrapErrf(ctx.Err(), "device didn't finish booting, waiting for: %s",
+				waitingFor), c, "WaitForBootComplete")
+		case <-ticker.C:
+		}
+	}
+}
+
+// bootWaitingFor returns what the device hasn't finished yet, or "" if it's booted.
+func (c *Device) bootWaitingFor() (string, error) {
+	state, err := c.State()
+	if err != nil {
+		return "", err
+	}
+	if state != StateOnline {
+		return "device to come online, it's " + state.String(), nil
+	}
+	output, err := c.runShellCommand(bootCompleteCommand)
+	if err != nil {
+		return "", err
+	}
+	return parseBootComplete(output), nil
+}
+
+// parseBootComplete parses the output of bootCompleteCommand, returning what the device hasn't
+// finished yet, or "" if it's booted. Old releases only set dev.bootcomplete. pm prints a stack
+// trace when the package manager isn't up, so its output can be any number of lines.
+func parseBootComplete(output string) string {
+	lines := strings.SplitN(strings.Replace(output, "\r", "", -1), "\n", 3)
+	for len(lines) < 3 {
+		lines = append(lines, "")
+	}
+	switch {
+	case strings.TrimSpace(lines[0]) != "1" && strings.TrimSpace(lines[1]) != "1":
+		return "sys.boot_completed"
+	case !strings.HasPrefix(lines[2], "package:") && !strings.Contains(lines[2], "\npackage:"):
+		return "package manager"
+	case !strings.Contains(lines[2], "Service activity: found"):
+		return "activity manager"
+	}
+	return ""
+}
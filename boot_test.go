@@ -0,0 +1,20 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBootComplete(t *testing.T) {
+	for output, want := range map[string]string{
+		"1\n1\npackage:/system/framework/framework-res.apk\nService activity: found\n": "",
+		"\n1\npackage:/system/framework/framework-res.apk\nService activity: found\n":  "",
+		"\n\n": "sys.boot_completed",
+		"1\n1\nError: Could not access the Package Manager.  Is the system running?\nService activity: found\n": "package manager",
+		"1\n1\nException in thread \"main\"\n\tat PackageManager\npackage:/system/framework/framework-res.apk\n" +
+			"Service activity: not found\n": "activity manager",
+	} {
+		assert.Equal(t, want, parseBootComplete(output), "%q", output)
+	}
+}
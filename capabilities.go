@@ -0,0 +1,100 @@
+package adb
+
+import "sync"
+
+// API levels at which device commands used by the high-level helpers changed.
+const (
+	// settings delete was added in Android 5.0.
+	sdkSettingsDelete = 21
+	// settings list was added in Android 6.0.
+	sdkSettingsList = 23
+	// toybox, which has tail -f, replaced toolbox in Android 6.0.
+	sdkTailFollow = 23
+	// The cmd binary, and cmd package, were added in Android 7.0.
+	sdkCmdPackage = 24
+	// cmd appops was added in Android 7.0, replacing the appops tool.
+	sdkCmdAppOps = 24
+	// cmd statusbar was added in Android 8.0.
+	sdkCmdStatusBar = 26
+	// toybox ps, which takes -A and -o, replaced toolbox ps in Android 8.0.
+	sdkToyboxPs = 26
+	// pm list packages -U, which lists the uid of each package, was added in Android 8.0.
+	sdkListPackagesUID = 26
+)
+
+// capabilities caches what a device supports, so helpers can pick between modern and legacy
+// commands without querying the device every time. It's shared by the copies of a Device
+// returned from ForUser.
+type capabilities struct {
+	mu            sync.Mutex
+	sdk           int
+	features      []string
+	checksumTools *ChecksumTools
+}
+
+// sdkVersion returns the device's API level, querying it only the first time.
+func (c *Device) sdkVersion() (int, error) {
+	caps := c.capabilities
+	caps.mu.Lock()
+	defer caps.mu.Unlock()
+
+	if caps.sdk == 0 {
+		sdk, err := c.SDKVersion()
+		if err != nil {
+			return 0, err
+		}
+		caps.sdk = sdk
+	}
+	return caps.sdk, nil
+}
+
+// features returns the device's adb features, querying them only the first time.
+func (c *Device) features() ([]string, error) {
+	caps := c.capabilities
+	caps.mu.Lock()
+	defer caps.mu.Unlock()
+
+	if caps.features == nil {
+		features, err := c.Features()
+		if HasErrCode(err, UnsupportedServer) {
+			// Servers that old can't route requests for any feature anyway.
+			features, err = []string{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		caps.features = append([]string{}, features...)
+	}
+	return caps.features, nil
+}
+
+// hasFeature is like HasFeature, but uses the cached feature list.
+func (c *Device) hasFeature(feature string) (bool, error) {
+	features, err := c.features()
+	if err != nil {
+		return false, err
+	}
+	return containsString(features, feature), nil
+}
+
+// supportsSDK returns true if the device's API level is at least sdk.
+func (c *Device) supportsSDK(sdk int) (bool, error) {
+	version, err := c.sdkVersion()
+	if err != nil {
+		return false, err
+	}
+	return version >= sdk, nil
+}
+
+// packageManagerCommand returns cmd package on devices that have it, since it talks to the
+// package service directly instead of starting a VM like pm does, and pm otherwise.
+func (c *Device) packageManagerCommand() ([]string, error) {
+	ok, err := c.supportsSDK(sdkCmdPackage)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return []string{"cmd", "package"}, nil
+	}
+	return []string{"pm"}, nil
+}
@@ -0,0 +1,70 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestPackageManagerCommandBySDK(t *testing.T) {
+	for _, test := range []struct {
+		SDK  string
+		Want string
+	}{
+		{"19\n", "shell:pm grant com.example android.permission.CAMERA"},
+		{"24\n", "shell:cmd package grant com.example android.permission.CAMERA"},
+	} {
+		s := &MockServer{
+			Status:   wire.StatusSuccess,
+			Messages: []string{test.SDK},
+		}
+		device := (&Adb{s}).Device(AnyDevice())
+
+		assert.NoError(t, device.GrantPermission("com.example", "android.permission.CAMERA"))
+		assert.NoError(t, device.GrantPermission("com.example", "android.permission.CAMERA"))
+		// The SDK version is only queried once.
+		assert.Equal(t, []string{"host:transport-any", "shell:getprop ro.build.version.sdk",
+			"host:transport-any", test.Want, "host:transport-any", test.Want}, s.Requests, test.SDK)
+	}
+}
+
+func TestStatusBarCommandBySDK(t *testing.T) {
+	for _, test := range []struct {
+		SDK    int
+		Action StatusBarAction
+		Want   string
+	}{
+		{25, StatusBarExpandNotifications, "shell:service call statusbar 1"},
+		{25, StatusBarCollapse, "shell:service call statusbar 2"},
+		{26, StatusBarExpandSettings, "shell:cmd statusbar expand-settings"},
+	} {
+		s := &MockServer{
+			Status:   wire.StatusSuccess,
+			Messages: []string{"Result: Parcel(00000000    '....')\n"},
+		}
+		device := (&Adb{s}).Device(AnyDevice())
+		device.capabilities.sdk = test.SDK
+
+		assert.NoError(t, device.SetStatusBar(test.Action))
+		assert.Equal(t, test.Want, s.Requests[1])
+	}
+}
+
+func TestSettingsLegacyFallbacks(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"Row: 0 name=adb_enabled, value=1\nRow: 1 name=device_name, value=My phone\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 19
+	settings := device.Settings()
+
+	values, err := settings.List(SettingsGlobal)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"adb_enabled": "1", "device_name": "My phone"}, values)
+	assert.Equal(t, "shell:content query --uri content://settings/global --projection name:value", s.Requests[1])
+
+	assert.NoError(t, settings.Delete(SettingsGlobal, "device_name"))
+	assert.Equal(t, `shell:content delete --uri content://settings/global --where 'name = '\''device_name'\'''`, s.Requests[3])
+}
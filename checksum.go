@@ -0,0 +1,116 @@
+package adb
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// ChecksumTools are the commands a device can compute checksums with. Commands are nil if the
+// device has no tool for that checksum.
+type ChecksumTools struct {
+	Md5sum    []string
+	Sha256sum []string
+}
+
+// ChecksumTools probes which checksum commands the device has, preferring standalone md5sum
+// and sha256sum, then the toybox and busybox applets. Applets are only used if they run, since
+// older toybox builds lack sha256sum. The result is cached.
+func (c *Device) ChecksumTools() (*ChecksumTools, error) {
+	caps := c.capabilities
+	caps.mu.Lock()
+	defer caps.mu.Unlock()
+
+	if caps.checksumTools == nil {
+		output, err := c.runShellCommand(
+			"for c in md5sum sha256sum; do command -v $c >/dev/null && echo $c; done; " +
+				"for t in toybox busybox; do for c in md5sum sha256sum; do $t $c </dev/null >/dev/null 2>&1 && echo $t $c; done; done")
+		if err != nil {
+			return nil, wrapClientError(err, c, "ChecksumTools")
+		}
+		caps.checksumTools = parseChecksumTools(output)
+	}
+	tools := *caps.checksumTools
+	return &tools, nil
+}
+
+// parseChecksumTools picks the checksum commands from the commands that ran on the device, one
+// per line, e.g. "md5sum" or "toybox sha256sum".
+func parseChecksumTools(output string) *ChecksumTools {
+	found := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		found[strings.TrimSpace(line)] = true
+	}
+	pick := func(tool string) []string {
+		switch {
+		case found[tool]:
+			return []string{tool}
+		case found["toybox "+tool]:
+			return []string{"toybox", tool}
+		case found["busybox "+tool]:
+			return []string{"busybox", tool}
+		}
+		return nil
+	}
+	return &ChecksumTools{Md5sum: pick("md5sum"), Sha256sum: pick("sha256sum")}
+}
+
+/*
+Md5sum returns the MD5 checksum of the file at path, as computed on the device.
+
+Corresponds to the command:
+
+	adb shell md5sum <path>
+*/
+func (c *Device) Md5sum(path string) ([]byte, error) {
+	tools, err := c.ChecksumTools()
+	if err != nil {
+		return nil, err
+	}
+	sum, err := c.checksum(tools.Md5sum, "md5sum", path, 16)
+	return sum, wrapClientError(err, c, "Md5sum(%s)", path)
+}
+
+/*
+Sha256sum returns the SHA-256 checksum of the file at path, as computed on the device.
+
+Corresponds to the command:
+
+	adb shell sha256sum <path>
+*/
+func (c *Device) Sha256sum(path string) ([]byte, error) {
+	tools, err := c.ChecksumTools()
+	if err != nil {
+		return nil, err
+	}
+	sum, err := c.checksum(tools.Sha256sum, "sha256sum", path, 32)
+	return sum, wrapClientError(err, c, "Sha256sum(%s)", path)
+}
+
+func (c *Device) checksum(cmd []string, name, path string, size int) ([]byte, error) {
+	if cmd == nil {
+		return nil, errors.Errorf(errors.AdbError, "device has no %s command", name)
+	}
+	output, err := c.runShellCommand(quoteShellArgs(append(cmd, path)...))
+	if err != nil {
+		return nil, err
+	}
+	return parseChecksumOutput(output, size)
+}
+
+// parseChecksumOutput parses the "<hex>  <path>" line printed by md5sum and sha256sum.
+func parseChecksumOutput(output string, size int) ([]byte, error) {
+	if strings.Contains(output, "No such file") {
+		return nil, errors.Errorf(errors.FileNoExistError, "%s", strings.TrimSpace(output))
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil, errors.Errorf(errors.ParseError, "empty checksum output")
+	}
+	sum, err := hex.DecodeString(fields[0])
+	if err != nil || len(sum) != size {
+		return nil, errors.Errorf(errors.ParseError, "invalid checksum output: %q", output)
+	}
+	return sum, nil
+}
@@ -0,0 +1,77 @@
+package adb
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const checksumToolsProbe = "for c in md5sum sha256sum; do command -v $c >/dev/null && echo $c; done; " +
+	"for t in toybox busybox; do for c in md5sum sha256sum; do $t $c </dev/null >/dev/null 2>&1 && echo $t $c; done; done"
+
+func TestParseChecksumTools(t *testing.T) {
+	for _, test := range []struct {
+		Output string
+		Want   ChecksumTools
+	}{
+		{"md5sum\nsha256sum\ntoybox md5sum\n", ChecksumTools{[]string{"md5sum"}, []string{"sha256sum"}}},
+		{"md5sum\ntoybox md5sum\ntoybox sha256sum\n", ChecksumTools{[]string{"md5sum"}, []string{"toybox", "sha256sum"}}},
+		{"busybox md5sum\nbusybox sha256sum\n", ChecksumTools{[]string{"busybox", "md5sum"}, []string{"busybox", "sha256sum"}}},
+		// Older toybox builds have md5sum but not sha256sum.
+		{"toybox md5sum\nbusybox md5sum\nbusybox sha256sum\n", ChecksumTools{[]string{"toybox", "md5sum"}, []string{"busybox", "sha256sum"}}},
+		{"toybox md5sum\n", ChecksumTools{Md5sum: []string{"toybox", "md5sum"}}},
+		{"", ChecksumTools{}},
+	} {
+		assert.Equal(t, test.Want, *parseChecksumTools(test.Output), "%q", test.Output)
+	}
+}
+
+func TestParseChecksumOutput(t *testing.T) {
+	sum, err := parseChecksumOutput("d41d8cd98f00b204e9800998ecf8427e  /sdcard/a\n", 16)
+	assert.NoError(t, err)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", hex.EncodeToString(sum))
+
+	_, err = parseChecksumOutput("md5sum: /sdcard/b: No such file or directory\n", 16)
+	assert.True(t, HasErrCode(err, FileNoExistError))
+
+	_, err = parseChecksumOutput("d41d8cd98f00b204  /sdcard/a\n", 16)
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestMd5sum(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			checksumToolsProbe:        "toybox md5sum\n",
+			"toybox md5sum /sdcard/a": "d41d8cd98f00b204e9800998ecf8427e  /sdcard/a\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	sum, err := device.Md5sum("/sdcard/a")
+	assert.NoError(t, err)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", hex.EncodeToString(sum))
+
+	// The probe is cached.
+	_, err = device.Md5sum("/sdcard/a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"host:transport-any", "shell:" + checksumToolsProbe,
+		"host:transport-any", "shell:toybox md5sum /sdcard/a",
+		"host:transport-any", "shell:toybox md5sum /sdcard/a",
+	}, s.Requests)
+}
+
+func TestSha256sumWithoutTool(t *testing.T) {
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{checksumToolsProbe: "md5sum\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.Sha256sum("/sdcard/a")
+	assert.Equal(t, errors.AdbError, errors.CodeOf(err))
+}
@@ -2,19 +2,13 @@ package main
 
 import (
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
-	"syscall"
-	"time"
 
-	"github.com/cheggaaa/pb"
 	"github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/internal/cli"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
-const StdIoFilename = "-"
-
 var (
 	serial = kingpin.Flag("serial",
 		"Connect to device by serial number.").
@@ -82,9 +76,11 @@ func main() {
 	case "shell":
 		exitCode = runShellCommand(*shellCommandArg, parseDevice())
 	case "pull":
-		exitCode = pull(*pullProgressFlag, *pullRemoteArg, *pullLocalArg, parseDevice())
+		exitCode = cli.Pull(client.Device(parseDevice()), *pullRemoteArg, *pullLocalArg,
+			cli.TransferOptions{ShowProgress: *pullProgressFlag})
 	case "push":
-		exitCode = push(*pushProgressFlag, *pushLocalArg, *pushRemoteArg, parseDevice())
+		exitCode = cli.Push(client.Device(parseDevice()), *pushLocalArg, *pushRemoteArg,
+			cli.TransferOptions{ShowProgress: *pushProgressFlag})
 	}
 
 	os.Exit(exitCode)
@@ -146,144 +142,3 @@ func runShellCommand(commandAndArgs []string, device adb.DeviceDescriptor) int {
 	fmt.Print(output)
 	return 0
 }
-
-func pull(showProgress bool, remotePath, localPath string, device adb.DeviceDescriptor) int {
-	if remotePath == "" {
-		fmt.Fprintln(os.Stderr, "error: must specify remote file")
-		kingpin.Usage()
-		return 1
-	}
-
-	if localPath == "" {
-		localPath = filepath.Base(remotePath)
-	}
-
-	client := client.Device(device)
-
-	info, err := client.Stat(remotePath)
-	if adb.HasErrCode(err, adb.ErrCode(adb.FileNoExistError)) {
-		fmt.Fprintln(os.Stderr, "remote file does not exist:", remotePath)
-		return 1
-	} else if err != nil {
-		fmt.Fprintf(os.Stderr, "error reading remote file %s: %s\n", remotePath, err)
-		return 1
-	}
-
-	remoteFile, err := client.OpenRead(remotePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error opening remote file %s: %s\n", remotePath, adb.ErrorWithCauseChain(err))
-		return 1
-	}
-	defer remoteFile.Close()
-
-	var localFile io.WriteCloser
-	if localPath == StdIoFilename {
-		localFile = os.Stdout
-	} else {
-		localFile, err = os.Create(localPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error opening local file %s: %s\n", localPath, err)
-			return 1
-		}
-	}
-	defer localFile.Close()
-
-	if err := copyWithProgressAndStats(localFile, remoteFile, int(info.Size), showProgress); err != nil {
-		fmt.Fprintln(os.Stderr, "error pulling file:", err)
-		return 1
-	}
-	return 0
-}
-
-func push(showProgress bool, localPath, remotePath string, device adb.DeviceDescriptor) int {
-	if remotePath == "" {
-		fmt.Fprintln(os.Stderr, "error: must specify remote file")
-		kingpin.Usage()
-		return 1
-	}
-
-	var (
-		localFile io.ReadCloser
-		size      int
-		perms     os.FileMode
-		mtime     time.Time
-	)
-	if localPath == "" || localPath == StdIoFilename {
-		localFile = os.Stdin
-		// 0 size will hide the progress bar.
-		perms = os.FileMode(0660)
-		mtime = adb.MtimeOfClose
-	} else {
-		var err error
-		localFile, err = os.Open(localPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error opening local file %s: %s\n", localPath, err)
-			return 1
-		}
-		info, err := os.Stat(localPath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error reading local file %s: %s\n", localPath, err)
-			return 1
-		}
-		size = int(info.Size())
-		perms = info.Mode().Perm()
-		mtime = info.ModTime()
-	}
-	defer localFile.Close()
-
-	client := client.Device(device)
-	writer, err := client.OpenWrite(remotePath, perms, mtime)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error opening remote file %s: %s\n", remotePath, err)
-		return 1
-	}
-	defer writer.Close()
-
-	if err := copyWithProgressAndStats(writer, localFile, size, showProgress); err != nil {
-		fmt.Fprintln(os.Stderr, "error pushing file:", err)
-		return 1
-	}
-	return 0
-}
-
-// copyWithProgressAndStats copies src to dst.
-// If showProgress is true and size is positive, a progress bar is shown.
-// After copying, final stats about the transfer speed and size are shown.
-// Progress and stats are printed to stderr.
-func copyWithProgressAndStats(dst io.Writer, src io.Reader, size int, showProgress bool) error {
-	var progress *pb.ProgressBar
-	if showProgress && size > 0 {
-		progress = pb.New(size)
-		// Write to stderr in case dst is stdout.
-		progress.Output = os.Stderr
-		progress.ShowSpeed = true
-		progress.ShowPercent = true
-		progress.ShowTimeLeft = true
-		progress.SetUnits(pb.U_BYTES)
-		progress.Start()
-		dst = io.MultiWriter(dst, progress)
-	}
-
-	startTime := time.Now()
-	copied, err := io.Copy(dst, src)
-
-	if progress != nil {
-		progress.Finish()
-	}
-
-	if pathErr, ok := err.(*os.PathError); ok {
-		if errno, ok := pathErr.Err.(syscall.Errno); ok && errno == syscall.EPIPE {
-			// Pipe closed. Handle this like an EOF.
-			err = nil
-		}
-	}
-	if err != nil {
-		return err
-	}
-
-	duration := time.Now().Sub(startTime)
-	rate := int64(float64(copied) / duration.Seconds())
-	fmt.Fprintf(os.Stderr, "%d B/s (%d bytes in %s)\n", rate, copied, duration)
-
-	return nil
-}
@@ -0,0 +1,369 @@
+/*
+goadb is a lightweight adb client built on the goadb library. It implements a subset of the
+adb commands, and serves as living documentation of the library's API.
+
+E.g.
+
+	goadb devices -l
+	goadb -s emulator-5554 shell getprop ro.build.version.sdk
+	goadb push -p --verify app.apk /data/local/tmp/app.apk
+	goadb install -r app.apk
+	goadb logcat -d
+	goadb forward tcp:8080 tcp:8080
+	goadb screenshot screen.png
+*/
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zach-klippenstein/goadb"
+	"github.com/zach-klippenstein/goadb/internal/cli"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	serial = kingpin.Flag("serial",
+		"Connect to device by serial number.").
+		Short('s').
+		String()
+	host = kingpin.Flag("host",
+		"Host of the adb server.").
+		Short('H').
+		String()
+	port = kingpin.Flag("port",
+		"Port of the adb server.").
+		Short('P').
+		Int()
+	noServer = kingpin.Flag("no-start-server",
+		"Never start the adb server, so the adb executable isn't needed.").
+		Bool()
+
+	devicesCommand = kingpin.Command("devices",
+		"List devices.")
+	devicesLongFlag = devicesCommand.Flag("long",
+		"Include extra detail about devices.").
+		Short('l').
+		Bool()
+
+	shellCommand = kingpin.Command("shell",
+		"Run a shell command on the device.")
+	shellCommandArg = shellCommand.Arg("command",
+		"Command to run on device.").
+		Strings()
+
+	pullCommand = kingpin.Command("pull",
+		"Pull a file from the device.")
+	pullProgressFlag = pullCommand.Flag("progress",
+		"Show progress.").
+		Short('p').
+		Bool()
+	pullVerifyFlag = pullCommand.Flag("verify",
+		"Compare checksums of the local and remote files after pulling.").
+		Bool()
+	pullRemoteArg = pullCommand.Arg("remote",
+		"Path of source file on device.").
+		Required().
+		String()
+	pullLocalArg = pullCommand.Arg("local",
+		"Path of destination file. If -, will write to stdout.").
+		String()
+
+	pushCommand = kingpin.Command("push",
+		"Push a file to the device.")
+	pushProgressFlag = pushCommand.Flag("progress",
+		"Show progress.").
+		Short('p').
+		Bool()
+	pushVerifyFlag = pushCommand.Flag("verify",
+		"Compare checksums of the local and remote files after pushing.").
+		Bool()
+	pushWorkersFlag = pushCommand.Flag("workers",
+		"Number of files to push at once when pushing a directory.").
+		Short('j').
+		Default("4").
+		Int()
+	pushTarFlag = pushCommand.Flag("tar",
+		"Stream a directory as one tar archive, which is faster for many small files.").
+		Bool()
+	pushLocalArg = pushCommand.Arg("local",
+		"Path of source file or directory. If -, will read from stdin.").
+		Required().
+		String()
+	pushRemoteArg = pushCommand.Arg("remote",
+		"Path of destination file on device.").
+		Required().
+		String()
+
+	installCommand = kingpin.Command("install",
+		"Install an app.")
+	installReinstallFlag = installCommand.Flag("reinstall",
+		"Replace the existing app, keeping its data.").
+		Short('r').
+		Bool()
+	installGrantFlag = installCommand.Flag("grant",
+		"Grant all runtime permissions.").
+		Short('g').
+		Bool()
+	installSkipSameFlag = installCommand.Flag("skip-same-version",
+		"Don't install if the device already has the same versionCode of the app.").
+		Bool()
+	installApkArg = installCommand.Arg("apk",
+		"Path of the APK to install.").
+		Required().
+		ExistingFile()
+
+	logcatCommand = kingpin.Command("logcat",
+		"Print the device log.")
+	logcatDumpFlag = logcatCommand.Flag("dump",
+		"Print the log and exit, instead of following it.").
+		Short('d').
+		Bool()
+	logcatArgs = logcatCommand.Arg("args",
+		"Extra arguments to logcat, e.g. a filter spec like ActivityManager:I *:S.").
+		Strings()
+
+	forwardCommand = kingpin.Command("forward",
+		"Forward a local socket to the device.")
+	forwardListFlag = forwardCommand.Flag("list",
+		"List this device's forwards.").
+		Bool()
+	forwardRemoveFlag = forwardCommand.Flag("remove",
+		"Remove the forward of the local socket.").
+		String()
+	forwardRemoveAllFlag = forwardCommand.Flag("remove-all",
+		"Remove all of this device's forwards.").
+		Bool()
+	forwardLocalArg = forwardCommand.Arg("local",
+		"Local socket, e.g. tcp:8080. tcp:0 picks a free port.").
+		String()
+	forwardRemoteArg = forwardCommand.Arg("remote",
+		"Device socket, e.g. tcp:8080 or localabstract:chrome_devtools_remote.").
+		String()
+
+	screenshotCommand = kingpin.Command("screenshot",
+		"Save a screenshot as PNG, or JPEG if --jpeg-quality is set.")
+	screenshotMaxWidthFlag = screenshotCommand.Flag("max-width",
+		"Scale the screenshot down to at most this many pixels wide.").
+		Int()
+	screenshotMaxHeightFlag = screenshotCommand.Flag("max-height",
+		"Scale the screenshot down to at most this many pixels tall.").
+		Int()
+	screenshotJPEGQualityFlag = screenshotCommand.Flag("jpeg-quality",
+		"Save the screenshot as a JPEG with this quality, from 1 to 100.").
+		Int()
+	screenshotFileArg = screenshotCommand.Arg("file",
+		"Path of the image file. If - or omitted, will write to stdout.").
+		Default(cli.StdIoFilename).
+		String()
+)
+
+var client *adb.Adb
+
+func main() {
+	var exitCode int
+
+	command := kingpin.Parse()
+
+	var err error
+	client, err = adb.NewWithConfig(adb.ServerConfig{Host: *host, Port: *port, NoServer: *noServer})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+
+	switch command {
+	case "devices":
+		exitCode = listDevices(*devicesLongFlag)
+	case "shell":
+		exitCode = runShellCommand(*shellCommandArg, parseDevice())
+	case "pull":
+		exitCode = cli.Pull(client.Device(parseDevice()), *pullRemoteArg, *pullLocalArg,
+			cli.TransferOptions{ShowProgress: *pullProgressFlag, Verify: *pullVerifyFlag})
+	case "push":
+		exitCode = cli.Push(client.Device(parseDevice()), *pushLocalArg, *pushRemoteArg, cli.TransferOptions{
+			ShowProgress: *pushProgressFlag, Verify: *pushVerifyFlag, Workers: *pushWorkersFlag, Tar: *pushTarFlag,
+		})
+	case "install":
+		exitCode = install(*installApkArg, adb.InstallOptions{
+			Reinstall:       *installReinstallFlag,
+			GrantPermission: *installGrantFlag,
+			SkipSameVersion: *installSkipSameFlag,
+		}, parseDevice())
+	case "logcat":
+		exitCode = logcat(*logcatDumpFlag, *logcatArgs, parseDevice())
+	case "forward":
+		exitCode = forward(parseDevice())
+	case "screenshot":
+		exitCode = screenshot(*screenshotFileArg, adb.ScreenshotOptions{
+			MaxWidth:    *screenshotMaxWidthFlag,
+			MaxHeight:   *screenshotMaxHeightFlag,
+			JPEGQuality: *screenshotJPEGQualityFlag,
+		}, parseDevice())
+	}
+
+	os.Exit(exitCode)
+}
+
+func parseDevice() adb.DeviceDescriptor {
+	if *serial != "" {
+		return adb.DeviceWithSerial(*serial)
+	}
+
+	return adb.AnyDevice()
+}
+
+func listDevices(long bool) int {
+	devices, err := client.ListDevices()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	for _, device := range devices {
+		if long {
+			if device.Usb == "" {
+				fmt.Printf("%s\tproduct:%s model:%s device:%s\n",
+					device.Serial, device.Product, device.Model, device.DeviceInfo)
+			} else {
+				fmt.Printf("%s\tusb:%s product:%s model:%s device:%s\n",
+					device.Serial, device.Usb, device.Product, device.Model, device.DeviceInfo)
+			}
+		} else {
+			fmt.Println(device.Serial)
+		}
+	}
+
+	return 0
+}
+
+func runShellCommand(commandAndArgs []string, device adb.DeviceDescriptor) int {
+	if len(commandAndArgs) == 0 {
+		fmt.Fprintln(os.Stderr, "error: no command")
+		kingpin.Usage()
+		return 1
+	}
+
+	output, err := client.Device(device).RunCommand(commandAndArgs[0], commandAndArgs[1:]...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	fmt.Print(output)
+	return 0
+}
+
+func install(apk string, opts adb.InstallOptions, device adb.DeviceDescriptor) int {
+	installed, err := client.Device(device).InstallAppFile(apk, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error installing:", adb.ErrorWithCauseChain(err))
+		return 1
+	}
+	if !installed {
+		fmt.Println("Skipped: same version already installed")
+		return 0
+	}
+	fmt.Println("Success")
+	return 0
+}
+
+func logcat(dump bool, args []string, device adb.DeviceDescriptor) int {
+	if dump {
+		args = append([]string{"-d"}, args...)
+	}
+
+	// Stream the output, since logcat runs until interrupted unless dumping.
+	output, err := client.Device(device).OpenCommand("logcat", args...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	defer output.Close()
+
+	if _, err := io.Copy(os.Stdout, output); err != nil {
+		fmt.Fprintln(os.Stderr, "error reading log:", err)
+		return 1
+	}
+	return 0
+}
+
+func forward(descriptor adb.DeviceDescriptor) int {
+	device := client.Device(descriptor)
+
+	switch {
+	case *forwardListFlag:
+		forwards, err := device.ListForwards()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		for _, forward := range forwards {
+			fmt.Printf("%s %s %s\n", forward.Serial, forward.Local, forward.Remote)
+		}
+		return 0
+
+	case *forwardRemoveAllFlag:
+		if err := device.RemoveAllForwards(); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		return 0
+
+	case *forwardRemoveFlag != "":
+		if err := device.RemoveForward(*forwardRemoveFlag); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		return 0
+	}
+
+	if *forwardLocalArg == "" || *forwardRemoteArg == "" {
+		fmt.Fprintln(os.Stderr, "error: must specify local and remote sockets")
+		kingpin.Usage()
+		return 1
+	}
+	port, err := device.Forward(*forwardLocalArg, *forwardRemoteArg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if port != "" {
+		// The server picked the port, print it like adb.
+		fmt.Println(port)
+	}
+	return 0
+}
+
+func screenshot(path string, opts adb.ScreenshotOptions, device adb.DeviceDescriptor) int {
+	image, err := client.Device(device).ScreenShotWithOptions(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	if path == cli.StdIoFilename {
+		_, err = os.Stdout.Write(image)
+	} else {
+		err = writeFile(path, image)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %s\n", path, err)
+		return 1
+	}
+	return 0
+}
+
+func writeFile(path string, data []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
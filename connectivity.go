@@ -0,0 +1,103 @@
+package adb
+
+import "strconv"
+
+// API levels that introduced the cmd interfaces used for connectivity toggles.
+const (
+	// cmd connectivity airplane-mode.
+	sdkConnectivityAirplaneMode = 28
+	// cmd wifi set-wifi-enabled.
+	sdkCmdWifi = 30
+)
+
+/*
+SetAirplaneMode enables or disables airplane mode.
+
+On API 28+ this uses cmd connectivity airplane-mode. Older versions write the
+airplane_mode_on setting and broadcast the change; on API 24-27 the broadcast is
+protected, so the radios only follow the setting if adbd runs as root.
+*/
+func (c *Device) SetAirplaneMode(enabled bool) error {
+	sdk, err := c.sdkVersion()
+	if err != nil {
+		return err
+	}
+
+	var output string
+	if sdk >= sdkConnectivityAirplaneMode {
+		output, err = c.RunCommand("cmd", "connectivity", "airplane-mode", enableDisable(enabled))
+	} else {
+		if err := c.Settings().PutBool(SettingsGlobal, "airplane_mode_on", enabled); err != nil {
+			return err
+		}
+		output, err = c.RunCommand("am", "broadcast", "-a", "android.intent.action.AIRPLANE_MODE",
+			"--ez", "state", strconv.FormatBool(enabled))
+	}
+	if err == nil {
+		err = commandOutputError("airplane-mode", output)
+	}
+	return wrapClientError(err, c, "SetAirplaneMode")
+}
+
+// IsAirplaneMode returns true if airplane mode is enabled.
+func (c *Device) IsAirplaneMode() (bool, error) {
+	return c.Settings().GetBool(SettingsGlobal, "airplane_mode_on")
+}
+
+/*
+SetWifiEnabled turns Wi-Fi on or off.
+
+On API 30+ this uses cmd wifi set-wifi-enabled, older versions use svc wifi.
+*/
+func (c *Device) SetWifiEnabled(enabled bool) error {
+	sdk, err := c.sdkVersion()
+	if err != nil {
+		return err
+	}
+
+	var output string
+	if sdk >= sdkCmdWifi {
+		output, err = c.RunCommand("cmd", "wifi", "set-wifi-enabled", enableDisable(enabled)+"d")
+	} else {
+		output, err = c.RunCommand("svc", "wifi", enableDisable(enabled))
+	}
+	if err == nil {
+		err = commandOutputError("wifi", output)
+	}
+	return wrapClientError(err, c, "SetWifiEnabled")
+}
+
+// IsWifiEnabled returns true if Wi-Fi is turned on.
+func (c *Device) IsWifiEnabled() (bool, error) {
+	// wifi_on is 2 or 3 when Wi-Fi was kept on while entering airplane mode.
+	enabled, err := c.Settings().Get(SettingsGlobal, "wifi_on")
+	return enabled != "" && enabled != "0", err
+}
+
+/*
+SetMobileDataEnabled turns mobile data on or off.
+
+Corresponds to the command:
+
+	adb shell svc data enable|disable
+*/
+func (c *Device) SetMobileDataEnabled(enabled bool) error {
+	output, err := c.RunCommand("svc", "data", enableDisable(enabled))
+	if err == nil {
+		err = commandOutputError("svc data", output)
+	}
+	return wrapClientError(err, c, "SetMobileDataEnabled")
+}
+
+// IsMobileDataEnabled returns true if mobile data is turned on.
+func (c *Device) IsMobileDataEnabled() (bool, error) {
+	enabled, err := c.Settings().Get(SettingsGlobal, "mobile_data")
+	return enabled == "1", err
+}
+
+func enableDisable(enabled bool) string {
+	if enabled {
+		return "enable"
+	}
+	return "disable"
+}
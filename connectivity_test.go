@@ -0,0 +1,30 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestSetWifiEnabledLegacy(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"29\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	assert.NoError(t, device.SetWifiEnabled(false))
+	assert.Equal(t, []string{
+		"host:transport-any", "shell:getprop ro.build.version.sdk",
+		"host:transport-any", "shell:svc wifi disable",
+	}, s.Requests)
+}
+
+func TestSetMobileDataEnabled(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	assert.NoError(t, device.SetMobileDataEnabled(true))
+	assert.Equal(t, "shell:svc data enable", s.Requests[1])
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+// +build !windows
+
+package adb
+
+func isPlatformConnectionRefused(err error) bool {
+	return false
+}
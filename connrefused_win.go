@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package adb
+
+import (
+	stderrors "errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isPlatformConnectionRefused returns true if err is Winsock's connection refused error, which
+// isn't syscall.ECONNREFUSED on Windows.
+func isPlatformConnectionRefused(err error) bool {
+	return stderrors.Is(err, windows.WSAECONNREFUSED)
+}
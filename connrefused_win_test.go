@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package adb
+
+import (
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"golang.org/x/sys/windows"
+)
+
+func TestIsConnectionRefusedWindows(t *testing.T) {
+	err := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connectex", windows.WSAECONNREFUSED)}
+	assert.True(t, isConnectionRefused(errors.WrapErrorf(err, errors.ServerNotAvailable, "error dialing")))
+	assert.False(t, isConnectionRefused(windows.WSAETIMEDOUT))
+}
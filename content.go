@@ -0,0 +1,251 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// ContentQuery holds the optional parts of a content provider query.
+type ContentQuery struct {
+	// Columns to return. If empty, all columns are returned.
+	Projection []string
+	// SQL selection, see ContentWhere for binding arguments safely.
+	Where string
+	// SQL sort order, e.g. "name ASC".
+	Sort string
+}
+
+/*
+ContentProvider queries and modifies data exposed by content providers, e.g.
+content://settings/secure or content://com.example.provider/items.
+To get an instance, call Content() on a Device.
+
+Values passed to Insert, Update, and Call are bound with a type derived from their Go type:
+string, bool, int, int32, int64, float32, float64, or nil.
+
+Corresponds to the command:
+
+	adb shell content
+*/
+type ContentProvider struct {
+	device *Device
+}
+
+// Matches the start of each column in a row printed by content query:
+//
+//	Row: 0 _id=1, name=foo, value=bar
+var contentColumnPattern = regexp.MustCompile(`(?:^|, )([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// Content returns a client for the device's content providers.
+func (c *Device) Content() *ContentProvider {
+	return &ContentProvider{device: c}
+}
+
+// Query returns the rows matching query from uri. Values are returned as printed by the
+// device, NULL columns are returned as "NULL".
+func (p *ContentProvider) Query(uri string, query ContentQuery) ([]map[string]string, error) {
+	args := []string{}
+	if len(query.Projection) > 0 {
+		args = append(args, "--projection", strings.Join(query.Projection, ":"))
+	}
+	if query.Where != "" {
+		args = append(args, "--where", query.Where)
+	}
+	if query.Sort != "" {
+		args = append(args, "--sort", query.Sort)
+	}
+
+	output, err := p.run("query", uri, args...)
+	if err != nil {
+		return nil, wrapClientError(err, p.device, "Content.Query(%s)", uri)
+	}
+	return parseContentRows(output), nil
+}
+
+// Insert inserts a row with values into uri.
+func (p *ContentProvider) Insert(uri string, values map[string]interface{}) error {
+	args, err := contentBindArgs("--bind", values)
+	if err == nil {
+		_, err = p.run("insert", uri, args...)
+	}
+	return wrapClientError(err, p.device, "Content.Insert(%s)", uri)
+}
+
+// Update sets values on the rows of uri matching where. If where is empty, all rows are updated.
+func (p *ContentProvider) Update(uri, where string, values map[string]interface{}) error {
+	args, err := contentBindArgs("--bind", values)
+	if err == nil {
+		if where != "" {
+			args = append(args, "--where", where)
+		}
+		_, err = p.run("update", uri, args...)
+	}
+	return wrapClientError(err, p.device, "Content.Update(%s)", uri)
+}
+
+// Delete deletes the rows of uri matching where. If where is empty, all rows are deleted.
+func (p *ContentProvider) Delete(uri, where string) error {
+	var args []string
+	if where != "" {
+		args = append(args, "--where", where)
+	}
+	_, err := p.run("delete", uri, args...)
+	return wrapClientError(err, p.device, "Content.Delete(%s)", uri)
+}
+
+// Call invokes method on the provider at uri with an optional string arg and extras,
+// and returns the printed result bundle.
+func (p *ContentProvider) Call(uri, method, arg string, extras map[string]interface{}) (string, error) {
+	args, err := contentBindArgs("--extra", extras)
+	if err != nil {
+		return "", wrapClientError(err, p.device, "Content.Call(%s, %s)", uri, method)
+	}
+	args = append([]string{"--method", method}, args...)
+	if arg != "" {
+		args = append(args, "--arg", arg)
+	}
+
+	output, err := p.run("call", uri, args...)
+	return strings.TrimSpace(output), wrapClientError(err, p.device, "Content.Call(%s, %s)", uri, method)
+}
+
+func (p *ContentProvider) run(verb, uri string, args ...string) (string, error) {
+	cmdArgs := append([]string{"content", verb, "--uri", uri}, p.device.userArgs()...)
+	cmdArgs = append(cmdArgs, args...)
+	output, err := p.device.runShellCommand(quoteShellArgs(cmdArgs...))
+	if err != nil {
+		return "", err
+	}
+	return output, contentOutputError(output)
+}
+
+/*
+ContentWhere builds a selection clause for ContentProvider by replacing each ? in clause
+with the corresponding arg, escaped as an SQL literal. Strings are single-quoted, booleans
+become 1 or 0, and nil becomes NULL.
+
+E.g.
+
+	adb.ContentWhere("name = ? AND value > ?", "it's", 3) // name = 'it''s' AND value > 3
+*/
+func ContentWhere(clause string, args ...interface{}) (string, error) {
+	var result strings.Builder
+	argIndex := 0
+	for _, r := range clause {
+		if r != '?' {
+			result.WriteRune(r)
+			continue
+		}
+		if argIndex >= len(args) {
+			return "", errors.AssertionErrorf("not enough arguments for where clause: %s", clause)
+		}
+		literal, err := sqlLiteral(args[argIndex])
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(literal)
+		argIndex++
+	}
+	if argIndex != len(args) {
+		return "", errors.AssertionErrorf("too many arguments for where clause: %s", clause)
+	}
+	return result.String(), nil
+}
+
+func sqlLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'", nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case int, int32, int64, float32, float64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", errors.AssertionErrorf("unsupported where argument type: %T", value)
+	}
+}
+
+// contentBindArgs returns a flag <column>:<type>:<value> pair for each value, in column order.
+func contentBindArgs(flag string, values map[string]interface{}) ([]string, error) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var args []string
+	for _, column := range columns {
+		var binding string
+		switch v := values[column].(type) {
+		case nil:
+			binding = "n:"
+		case string:
+			binding = "s:" + v
+		case bool:
+			binding = "b:" + strconv.FormatBool(v)
+		case int:
+			binding = "i:" + strconv.Itoa(v)
+		case int32:
+			binding = "i:" + strconv.FormatInt(int64(v), 10)
+		case int64:
+			binding = "l:" + strconv.FormatInt(v, 10)
+		case float32:
+			binding = "f:" + strconv.FormatFloat(float64(v), 'g', -1, 32)
+		case float64:
+			binding = "d:" + strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			return nil, errors.AssertionErrorf("unsupported value type for column %s: %T", column, v)
+		}
+		args = append(args, flag, column+":"+binding)
+	}
+	return args, nil
+}
+
+// contentOutputError returns an error if the content command printed an exception or usage.
+func contentOutputError(output string) error {
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "Error while accessing provider") || strings.Contains(trimmed, "Exception") ||
+		strings.HasPrefix(trimmed, "usage:") || strings.HasPrefix(trimmed, "Error:") {
+		return errors.Errorf(errors.AdbError, "content command failed: %s", strings.SplitN(trimmed, "\n", 2)[0])
+	}
+	return nil
+}
+
+// parseContentRows parses rows printed by content query. Values may contain ", ", so columns
+// are split at ", <name>=" boundaries.
+func parseContentRows(output string) []map[string]string {
+	rows := []map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasPrefix(line, "Row: ") {
+			continue
+		}
+
+		// Skip "Row: <index> ".
+		fields := strings.SplitN(line, " ", 3)
+		row := map[string]string{}
+		if len(fields) == 3 {
+			columns := fields[2]
+			matches := contentColumnPattern.FindAllStringSubmatchIndex(columns, -1)
+			for i, match := range matches {
+				end := len(columns)
+				if i+1 < len(matches) {
+					end = matches[i+1][0]
+				}
+				row[columns[match[2]:match[3]]] = columns[match[1]:end]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,48 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseContentRows(t *testing.T) {
+	rows := parseContentRows(`Row: 0 _id=1, name=adb_enabled, value=1
+Row: 1 _id=2, name=device_name, value=Pixel, the phone
+Row: 2 _id=3, name=empty, value=NULL
+`)
+	assert.Equal(t, []map[string]string{
+		{"_id": "1", "name": "adb_enabled", "value": "1"},
+		{"_id": "2", "name": "device_name", "value": "Pixel, the phone"},
+		{"_id": "3", "name": "empty", "value": "NULL"},
+	}, rows)
+
+	assert.Empty(t, parseContentRows("No result found.\n"))
+}
+
+func TestContentWhere(t *testing.T) {
+	where, err := ContentWhere("name = ? AND value > ? AND flag = ? AND x IS ?", "it's", 3, true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "name = 'it''s' AND value > 3 AND flag = 1 AND x IS NULL", where)
+
+	_, err = ContentWhere("a = ?")
+	assert.True(t, HasErrCode(err, AssertionError))
+	_, err = ContentWhere("a = 1", 2)
+	assert.True(t, HasErrCode(err, AssertionError))
+}
+
+func TestContentInsertBindings(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	content := (&Adb{s}).Device(AnyDevice()).Content()
+
+	err := content.Insert("content://settings/secure", map[string]interface{}{
+		"name":  "my setting",
+		"value": 5,
+		"ratio": 0.5,
+		"on":    true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "shell:content insert --uri content://settings/secure "+
+		"--bind 'name:s:my setting' --bind on:b:true --bind ratio:d:0.5 --bind value:i:5", s.Requests[1])
+}
@@ -0,0 +1,255 @@
+package adb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Prints the cpufreq files of each policy, or of each CPU on kernels without policies, after a
+// "==> <dir>" line.
+const cpuFreqCommand = `cd /sys/devices/system/cpu && if [ -e cpufreq/policy0 ]; then set -- cpufreq/policy*; ` +
+	`else set -- cpu[0-9]*/cpufreq; fi; for p; do echo "==> /sys/devices/system/cpu/$p"; ` +
+	`for f in related_cpus scaling_governor scaling_available_governors scaling_cur_freq scaling_min_freq ` +
+	`scaling_max_freq cpuinfo_min_freq cpuinfo_max_freq scaling_available_frequencies; do ` +
+	`echo "$f: $(cat $p/$f 2>/dev/null)"; done; done`
+
+// The governor and limits of each cluster are saved here before they're first changed, for
+// RestoreCPUDefaults. It's on the device so a crashed or different host can still restore them.
+const cpuFreqDefaultsPath = "/data/local/tmp/.goadb-cpufreq-defaults"
+
+// CPUCluster is a group of CPUs that run at the same frequency, a cpufreq policy. Frequencies
+// are in kHz.
+type CPUCluster struct {
+	// Number of the policy, which is the first of its CPUs.
+	Policy int
+	CPUs   []int
+
+	// Governor that picks the frequency, e.g. schedutil.
+	Governor           string
+	AvailableGovernors []string
+
+	CurFreq int
+	// Limits the governor picks CurFreq between.
+	MinFreq, MaxFreq int
+	// Limits of the hardware.
+	HardwareMinFreq, HardwareMaxFreq int
+	// Frequencies the cluster can run at, ascending. Empty if the driver doesn't list them.
+	AvailableFrequencies []int
+
+	// Sysfs directory of the policy.
+	path string
+}
+
+/*
+CPUClusters returns the frequencies and governors of the device's CPU clusters, e.g. the little,
+big, and prime cores.
+
+Corresponds to the command:
+
+	adb shell cat /sys/devices/system/cpu/cpufreq/policy<N>/scaling_cur_freq ...
+*/
+func (c *Device) CPUClusters() ([]CPUCluster, error) {
+	clusters, err := c.cpuClusters()
+	return clusters, wrapClientError(err, c, "CPUClusters")
+}
+
+func (c *Device) cpuClusters() ([]CPUCluster, error) {
+	output, err := c.runShellCommand(cpuFreqCommand)
+	if err != nil {
+		return nil, err
+	}
+	return parseCPUFreq(output)
+}
+
+func (c *Device) cpuCluster(policy int) (*CPUCluster, error) {
+	clusters, err := c.cpuClusters()
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusters {
+		if clusters[i].Policy == policy {
+			return &clusters[i], nil
+		}
+	}
+	return nil, errors.AssertionErrorf("no CPU cluster with policy %d", policy)
+}
+
+/*
+PinCPUFrequency locks the cluster of policy to freq, in kHz, by setting both of its limits to it,
+so benchmarks aren't skewed by the governor or by thermal headroom. Pick a frequency the device
+can sustain without throttling, usually well below the maximum. freq must be one of the
+cluster's AvailableFrequencies, if it lists them. Requires root.
+
+The original limits and governors are saved on the device the first time they're changed, and
+put back by RestoreCPUDefaults, or a reboot.
+
+Corresponds to the commands:
+
+	adb shell su 0 sh -c 'echo <freq> > /sys/devices/system/cpu/cpufreq/policy<policy>/scaling_max_freq'
+	adb shell su 0 sh -c 'echo <freq> > /sys/devices/system/cpu/cpufreq/policy<policy>/scaling_min_freq'
+*/
+func (c *Device) PinCPUFrequency(policy, freq int) error {
+	cluster, err := c.cpuCluster(policy)
+	if err != nil {
+		return wrapClientError(err, c, "PinCPUFrequency(%d, %d)", policy, freq)
+	}
+	if !cluster.supportsFrequency(freq) {
+		return wrapClientError(errors.AssertionErrorf("cluster %d can't run at %d kHz, available: %v",
+			policy, freq, cluster.AvailableFrequencies), c, "PinCPUFrequency(%d, %d)", policy, freq)
+	}
+	// The minimum is lowered first since the max can't be set below it, and vice versa.
+	err = c.writeCPUFreq(cluster.path,
+		"scaling_min_freq", strconv.Itoa(cluster.HardwareMinFreq),
+		"scaling_max_freq", strconv.Itoa(freq),
+		"scaling_min_freq", strconv.Itoa(freq))
+	return wrapClientError(err, c, "PinCPUFrequency(%d, %d)", policy, freq)
+}
+
+/*
+SetCPUGovernor sets the governor of the cluster of policy, one of its AvailableGovernors, e.g.
+performance to run at MaxFreq. Requires root.
+
+Like PinCPUFrequency, the original governors are saved for RestoreCPUDefaults.
+
+Corresponds to the command:
+
+	adb shell su 0 sh -c 'echo <governor> > /sys/devices/system/cpu/cpufreq/policy<policy>/scaling_governor'
+*/
+func (c *Device) SetCPUGovernor(policy int, governor string) error {
+	cluster, err := c.cpuCluster(policy)
+	if err != nil {
+		return wrapClientError(err, c, "SetCPUGovernor(%d, %s)", policy, governor)
+	}
+	if len(cluster.AvailableGovernors) > 0 && !containsString(cluster.AvailableGovernors, governor) {
+		return wrapClientError(errors.AssertionErrorf("cluster %d has no governor %q, available: %v",
+			policy, governor, cluster.AvailableGovernors), c, "SetCPUGovernor(%d, %s)", policy, governor)
+	}
+	err = c.writeCPUFreq(cluster.path, "scaling_governor", governor)
+	return wrapClientError(err, c, "SetCPUGovernor(%d, %s)", policy, governor)
+}
+
+/*
+RestoreCPUDefaults puts back the governors and frequency limits PinCPUFrequency and
+SetCPUGovernor changed. It does nothing if they weren't changed since the defaults were last
+restored. Requires root.
+*/
+func (c *Device) RestoreCPUDefaults() error {
+	// Each saved line is "<dir> <governor> <min> <max>". The min is written again after the max,
+	// in case the pinned max was below it.
+	script := fmt.Sprintf(`f=%s; [ -e $f ] || exit 0; `+
+		`while read p g min max; do echo $g > $p/scaling_governor; echo $min > $p/scaling_min_freq; `+
+		`echo $max > $p/scaling_max_freq; echo $min > $p/scaling_min_freq; done < $f && rm $f`,
+		cpuFreqDefaultsPath)
+	output, err := c.runRootShellCommand(script)
+	if err == nil {
+		err = fileCommandError("restore cpufreq defaults", output)
+	}
+	return wrapClientError(err, c, "RestoreCPUDefaults")
+}
+
+// writeCPUFreq saves the defaults if they haven't been, then writes the name, value pairs to the
+// files in dir, as root.
+func (c *Device) writeCPUFreq(dir string, namesAndValues ...string) error {
+	script := fmt.Sprintf(`f=%s; [ -e $f ] || for p in $(cd /sys/devices/system/cpu && `+
+		`if [ -e cpufreq/policy0 ]; then echo cpufreq/policy*; else echo cpu[0-9]*/cpufreq; fi); do `+
+		`p=/sys/devices/system/cpu/$p; echo "$p $(cat $p/scaling_governor) $(cat $p/scaling_min_freq) `+
+		`$(cat $p/scaling_max_freq)"; done > $f`, cpuFreqDefaultsPath)
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		script += fmt.Sprintf(" && echo %s > %s", quoteShellArg(namesAndValues[i+1]),
+			quoteShellArg(dir+"/"+namesAndValues[i]))
+	}
+	output, err := c.runRootShellCommand(script)
+	if err != nil {
+		return err
+	}
+	return fileCommandError("write "+dir, output)
+}
+
+func (cluster *CPUCluster) supportsFrequency(freq int) bool {
+	if len(cluster.AvailableFrequencies) == 0 {
+		return freq >= cluster.HardwareMinFreq && freq <= cluster.HardwareMaxFreq
+	}
+	for _, available := range cluster.AvailableFrequencies {
+		if available == freq {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCPUFreq parses the output of cpuFreqCommand. On kernels without policies, the CPUs of a
+// cluster share their cpufreq directory, so only the first is kept.
+func parseCPUFreq(output string) ([]CPUCluster, error) {
+	var clusters []CPUCluster
+	seen := make(map[int]bool)
+	var current *CPUCluster
+	flush := func() {
+		if current != nil && len(current.CPUs) > 0 && !seen[current.CPUs[0]] {
+			seen[current.CPUs[0]] = true
+			current.Policy = current.CPUs[0]
+			clusters = append(clusters, *current)
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "==> ") {
+			flush()
+			current = &CPUCluster{path: strings.TrimPrefix(line, "==> ")}
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if current == nil || len(parts) != 2 {
+			continue
+		}
+		fields := strings.Fields(parts[1])
+		switch parts[0] {
+		case "related_cpus":
+			current.CPUs = parseInts(fields)
+		case "scaling_governor":
+			current.Governor = strings.Join(fields, " ")
+		case "scaling_available_governors":
+			current.AvailableGovernors = fields
+		case "scaling_available_frequencies":
+			current.AvailableFrequencies = parseInts(fields)
+			sort.Ints(current.AvailableFrequencies)
+		case "scaling_cur_freq":
+			current.CurFreq = firstInt(fields)
+		case "scaling_min_freq":
+			current.MinFreq = firstInt(fields)
+		case "scaling_max_freq":
+			current.MaxFreq = firstInt(fields)
+		case "cpuinfo_min_freq":
+			current.HardwareMinFreq = firstInt(fields)
+		case "cpuinfo_max_freq":
+			current.HardwareMaxFreq = firstInt(fields)
+		}
+	}
+	flush()
+	if len(clusters) == 0 {
+		return nil, errors.Errorf(errors.AdbError, "cpufreq isn't available: %s", strings.TrimSpace(output))
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Policy < clusters[j].Policy })
+	return clusters, nil
+}
+
+func parseInts(fields []string) []int {
+	var values []int
+	for _, field := range fields {
+		if value, err := strconv.Atoi(field); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func firstInt(fields []string) int {
+	if values := parseInts(fields); len(values) > 0 {
+		return values[0]
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package adb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testCPUFreq = `==> /sys/devices/system/cpu/cpufreq/policy4
+related_cpus: 4 5 6
+scaling_governor: schedutil
+scaling_available_governors: performance schedutil
+scaling_cur_freq: 1800000
+scaling_min_freq: 500000
+scaling_max_freq: 2400000
+cpuinfo_min_freq: 500000
+cpuinfo_max_freq: 2400000
+scaling_available_frequencies: 2400000 500000 1200000 1800000
+==> /sys/devices/system/cpu/cpufreq/policy0
+related_cpus: 0 1 2 3
+scaling_governor: schedutil
+scaling_available_governors: performance schedutil
+scaling_cur_freq: 300000
+scaling_min_freq: 300000
+scaling_max_freq: 1800000
+cpuinfo_min_freq: 300000
+cpuinfo_max_freq: 1800000
+scaling_available_frequencies:
+`
+
+func TestParseCPUFreq(t *testing.T) {
+	clusters, err := parseCPUFreq(testCPUFreq)
+	require.NoError(t, err)
+	assert.Equal(t, []CPUCluster{
+		{
+			Policy: 0, CPUs: []int{0, 1, 2, 3},
+			Governor: "schedutil", AvailableGovernors: []string{"performance", "schedutil"},
+			CurFreq: 300000, MinFreq: 300000, MaxFreq: 1800000,
+			HardwareMinFreq: 300000, HardwareMaxFreq: 1800000,
+			path: "/sys/devices/system/cpu/cpufreq/policy0",
+		},
+		{
+			Policy: 4, CPUs: []int{4, 5, 6},
+			Governor: "schedutil", AvailableGovernors: []string{"performance", "schedutil"},
+			CurFreq: 1800000, MinFreq: 500000, MaxFreq: 2400000,
+			HardwareMinFreq: 500000, HardwareMaxFreq: 2400000,
+			AvailableFrequencies: []int{500000, 1200000, 1800000, 2400000},
+			path:                 "/sys/devices/system/cpu/cpufreq/policy4",
+		},
+	}, clusters)
+
+	_, err = parseCPUFreq("")
+	assert.True(t, HasErrCode(err, AdbError))
+}
+
+func TestParseCPUFreqSharedDirectories(t *testing.T) {
+	output := "==> /sys/devices/system/cpu/cpu0/cpufreq\nrelated_cpus: 0 1\n" +
+		"==> /sys/devices/system/cpu/cpu1/cpufreq\nrelated_cpus: 0 1\n"
+	clusters, err := parseCPUFreq(output)
+	require.NoError(t, err)
+	require.Len(t, clusters, 1)
+	assert.Equal(t, "/sys/devices/system/cpu/cpu0/cpufreq", clusters[0].path)
+}
+
+func TestPinCPUFrequency(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			cpuFreqCommand: testCPUFreq,
+			"id -u":        "0\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	require.NoError(t, device.PinCPUFrequency(4, 1200000))
+	script := s.Requests[len(s.Requests)-1]
+	assert.True(t, strings.HasSuffix(script,
+		" && echo 500000 > /sys/devices/system/cpu/cpufreq/policy4/scaling_min_freq"+
+			" && echo 1200000 > /sys/devices/system/cpu/cpufreq/policy4/scaling_max_freq"+
+			" && echo 1200000 > /sys/devices/system/cpu/cpufreq/policy4/scaling_min_freq"), script)
+
+	assert.True(t, HasErrCode(device.PinCPUFrequency(4, 1000000), AssertionError))
+	assert.True(t, HasErrCode(device.PinCPUFrequency(2, 1200000), AssertionError))
+	// Without a list of frequencies, anything within the hardware limits is accepted.
+	assert.NoError(t, device.PinCPUFrequency(0, 1000000))
+	assert.True(t, HasErrCode(device.SetCPUGovernor(0, "ondemand"), AssertionError))
+}
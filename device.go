@@ -7,10 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"strings"
-	"syscall"
 	"time"
 
-	"github.com/cheggaaa/pb"
 	"github.com/zach-klippenstein/goadb/internal/errors"
 	"github.com/zach-klippenstein/goadb/wire"
 )
@@ -27,6 +25,18 @@ type Device struct {
 
 	// Used to get device info.
 	deviceListFunc func() ([]*DeviceInfo, error)
+
+	// If set, package, activity, and settings operations target this user. See ForUser.
+	user *UserID
+
+	// Cached SDK version and features, used to pick commands the device supports.
+	capabilities *capabilities
+
+	// If set, OpenRead and OpenWrite transfers are limited by it. See WithRateLimit.
+	rateLimit *RateLimiter
+
+	// If set, input and capture operations target this display. See OnDisplay.
+	display *Display
 }
 
 func (c *Device) String() string {
@@ -108,26 +118,51 @@ func (c *Device) RunCommand(cmd string, args ...string) (string, error) {
 		return "", wrapClientError(err, c, "RunCommand")
 	}
 
+	resp, err := c.runShellCommand(cmd)
+	return resp, wrapClientError(err, c, "RunCommand")
+}
+
+/*
+OpenCommand runs the specified command like RunCommand, but returns a reader for its output
+as it's produced, e.g. for logcat or top. Close the reader to stop reading; the command may
+keep running on the device until it next writes output.
+*/
+func (c *Device) OpenCommand(cmd string, args ...string) (io.ReadCloser, error) {
+	cmd, err := prepareCommandLine(cmd, args...)
+	if err != nil {
+		return nil, wrapClientError(err, c, "OpenCommand")
+	}
+
+	conn, err := c.openService("shell:" + cmd)
+	if err != nil {
+		return nil, wrapClientError(err, c, "OpenCommand")
+	}
+	return conn, nil
+}
+
+// runShellCommand runs cmdLine verbatim in a shell on the device and returns its output.
+// Callers are responsible for quoting, see quoteShellArgs.
+func (c *Device) runShellCommand(cmdLine string) (string, error) {
 	conn, err := c.dialDevice()
 	if err != nil {
-		return "", wrapClientError(err, c, "RunCommand")
+		return "", err
 	}
 	defer conn.Close()
 
-	req := fmt.Sprintf("shell:%s", cmd)
+	req := fmt.Sprintf("shell:%s", cmdLine)
 
 	// Shell responses are special, they don't include a length header.
 	// We read until the stream is closed.
 	// So, we can't use conn.RoundTripSingleResponse.
 	if err = conn.SendMessage([]byte(req)); err != nil {
-		return "", wrapClientError(err, c, "RunCommand")
+		return "", err
 	}
 	if _, err = conn.ReadStatus(req); err != nil {
-		return "", wrapClientError(err, c, "RunCommand")
+		return "", err
 	}
 
 	resp, err := conn.ReadUntilEof()
-	return string(resp), wrapClientError(err, c, "RunCommand")
+	return string(resp), err
 }
 
 /*
@@ -160,14 +195,17 @@ func (c *Device) ListDirEntries(path string) (*DirEntries, error) {
 	return entries, wrapClientError(err, c, "ListDirEntries(%s)", path)
 }
 
-func (c *Device) Stat(path string) (*DirEntry, error) {
-	conn, err := c.getSyncConn()
-	if err != nil {
-		return nil, wrapClientError(err, c, "Stat(%s)", path)
-	}
-	defer conn.Close()
+func (c *Device) Stat(path string) (entry *DirEntry, err error) {
+	err = c.server.retryPolicy().do(func() error {
+		conn, err := c.getSyncConn()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
 
-	entry, err := stat(conn, path)
+		entry, err = stat(conn, path)
+		return err
+	})
 	return entry, wrapClientError(err, c, "Stat(%s)", path)
 }
 
@@ -178,6 +216,9 @@ func (c *Device) OpenRead(path string) (io.ReadCloser, error) {
 	}
 
 	reader, err := receiveFile(conn, path)
+	if err == nil && c.rateLimit != nil {
+		reader = &rateLimitedReader{ReadCloser: reader, limiter: c.rateLimit}
+	}
 	return reader, wrapClientError(err, c, "OpenRead(%s)", path)
 }
 
@@ -192,13 +233,16 @@ func (c *Device) OpenWrite(path string, perms os.FileMode, mtime time.Time) (io.
 	}
 
 	writer, err := sendFile(conn, path, perms, mtime)
+	if err == nil && c.rateLimit != nil {
+		writer = &rateLimitedWriter{WriteCloser: writer, limiter: c.rateLimit}
+	}
 	return writer, wrapClientError(err, c, "OpenWrite(%s)", path)
 }
 
 // getAttribute returns the first message returned by the server by running
 // <host-prefix>:<attr>, where host-prefix is determined from the DeviceDescriptor.
 func (c *Device) getAttribute(attr string) (string, error) {
-	resp, err := roundTripSingleResponse(c.server,
+	resp, err := retryRoundTrip(c.server,
 		fmt.Sprintf("%s:%s", c.descriptor.getHostPrefix(), attr))
 	if err != nil {
 		return "", err
@@ -211,6 +255,10 @@ func (c *Device) getSyncConn() (*wire.SyncConn, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = conn.SetDeadline(deadlineFor(c.server.timeouts().Transfer)); err != nil {
+		conn.Close()
+		return nil, err
+	}
 
 	// Switch the connection to sync mode.
 	if err := wire.SendMessageString(conn, "sync:"); err != nil {
@@ -223,6 +271,25 @@ func (c *Device) getSyncConn() (*wire.SyncConn, error) {
 	return conn.NewSyncConn(), nil
 }
 
+// openService dials the device and requests service (e.g. "exec:<cmd>"), returning the
+// connection positioned at the start of the service's raw stream.
+func (c *Device) openService(service string) (*wire.Conn, error) {
+	conn, err := c.dialDevice()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.SendMessage([]byte(service)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err = conn.ReadStatus(service); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
 // dialDevice switches the connection to communicate directly with the device
 // by requesting the transport defined by the DeviceDescriptor.
 func (c *Device) dialDevice() (*wire.Conn, error) {
@@ -298,7 +365,8 @@ func (c *Device) RunAdbCmdCtx(ctx context.Context, cmd string) (string, error) {
 // run adb cmd string with timeout
 func (c *Device) RunAdbCmdCtxWithTimeout(ctx context.Context, cmd string, duration time.Duration) (string, error) {
 	// cmdArgs := strings.Split(cmd, " ")
-	ctx1,_ := context.WithTimeout(ctx, duration)
+	ctx1, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
 	cmdArgs := splitCmdAgrs(cmd)
 	adbPath, _ := exec.LookPath(AdbExecutableName)
 	runCmd := exec.CommandContext(ctx1, adbPath, cmdArgs...)
@@ -334,7 +402,8 @@ func (c *Device) RunAdbShellCmdCtx(ctx context.Context, cmd string) (string, err
 
 // run adb shell cmd string with timeout
 func (c *Device) RunAdbShellCmdCtxWithTimeout(ctx context.Context, cmd string, duration time.Duration) (string, error) {
-	ctx1,_ := context.WithTimeout(ctx, duration)
+	ctx1, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
 	cmdArgs := splitCmdAgrs("-s " + c.descriptor.serial + " shell " + cmd)
 	adbPath, _ := exec.LookPath(AdbExecutableName)
 	runCmd := exec.CommandContext(ctx1, adbPath, cmdArgs...)
@@ -353,56 +422,34 @@ func (c *Device) Push(localPath, remotePath string) (string, error) {
 	return result, isError
 }
 
-// Forward
-func (c *Device) Forward(localPort, remotePort string) (string, error) {
-	var args string
-	args += " " + safeArg(strings.TrimSpace(localPort)) + " " + safeArg(strings.TrimSpace(remotePort))
-	result, isError := c.RunAdbCmd("-s " + c.descriptor.serial + " forward" + args)
-	return result, isError
-}
-
-// ClearForward
+// ClearForwardAll removes all the forwards to the device.
+//
+// Deprecated: use RemoveAllForwards.
 func (c *Device) ClearForwardAll() (string, error) {
-	var args string
-	args += " " + "--remove-all"
-	result, isError := c.RunAdbCmd("-s " + c.descriptor.serial + " forward" + args)
-	return result, isError
-}
-
-// GetForwardList
-func (c *Device) GetForwardList(localPort, remotePort string) (string, error) {
-	var args string
-	args += " " + " --list "
-	result, isError := c.RunAdbCmd("-s " + c.descriptor.serial + " forward" + args)
-	return result, isError
+	return "", c.RemoveAllForwards()
 }
 
-// ClearForward by Serial
+// ClearForwardBySerial removes the forwards to the device with serial deviceId from local
+// tcp:port, if port is valid, to remote, if not empty, which is a TCP port or abstract socket name.
+//
+// Deprecated: use ListForwards and RemoveForward.
 func (c *Device) ClearForwardBySerial(deviceId string, port int, remote string) (string, error) {
-	forwardStr, err := c.GetForwardList(deviceId, remote)
+	forwards, err := c.ListForwards()
 	if err != nil {
 		return "", err
 	}
-	forwardStrList := strings.Split(forwardStr, "\n")
-	for _, forwardLine := range forwardStrList {
-		if strings.TrimSpace(forwardLine) == "" || !strings.HasPrefix(forwardLine, deviceId) {
-			continue
-		}
-		forwardParams := strings.Fields(forwardLine)
-		if len(forwardParams) < 2 {
+	for _, forward := range forwards {
+		if forward.Serial != deviceId {
 			continue
 		}
-		if port > 0 && port < 65535 && !strings.Contains(forwardParams[1], fmt.Sprintf("tcp:%d", port)) {
+		if port > 0 && port < 65535 && forward.Local != fmt.Sprintf("tcp:%d", port) {
 			continue
 		}
-		if remote != "" && !strings.Contains(forwardParams[2], "localabstract:"+remote) &&
-			!strings.Contains(forwardParams[2], "tcp:"+remote) {
+		if remote != "" && forward.Remote != "localabstract:"+remote && forward.Remote != "tcp:"+remote {
 			continue
 		}
-		args := " " + " --remove " + forwardParams[1]
-		result, err := c.RunAdbCmd("-s " + c.descriptor.serial + " forward " + args)
-		if err != nil {
-			return result, err
+		if err := c.RemoveForward(forward.Local); err != nil {
+			return "", err
 		}
 	}
 	return "ok", nil
@@ -420,6 +467,10 @@ func (c *Device) InstallApp(ctx context.Context, apk string, reinstall bool, gra
 		args += " -g "
 	}
 
+	if c.user != nil {
+		args += " --user " + c.user.String()
+	}
+
 	result, isError := c.RunAdbCmdCtx(ctx, "-s " + c.descriptor.serial + " install" + args)
 	return result, isError
 }
@@ -435,6 +486,10 @@ func (c *Device) InstallAppByPm(ctx context.Context, apk string, reinstall bool,
 		args += " -g "
 	}
 
+	if c.user != nil {
+		args += " --user " + c.user.String()
+	}
+
 	args += " " + safeArg(strings.TrimSpace(apk))
 
 	result, isError := c.RunAdbCmdCtx(ctx, "-s " + c.descriptor.serial + " shell pm install " + args)
@@ -444,6 +499,9 @@ func (c *Device) InstallAppByPm(ctx context.Context, apk string, reinstall bool,
 // UninstallApp TODO:connect to adb server
 func (c *Device) UninstallApp(ctx context.Context, pkg string) (string, error) {
 	var args string
+	if c.user != nil {
+		args += " --user " + c.user.String()
+	}
 	args += " " + safeArg(strings.TrimSpace(pkg))
 	result, isError := c.RunAdbCmdCtx(ctx, "-s " + c.descriptor.serial + " uninstall " + args)
 	return result, isError
@@ -452,6 +510,9 @@ func (c *Device) UninstallApp(ctx context.Context, pkg string) (string, error) {
 // LaunchApk
 func (c *Device) LaunchApk(pkg string) (string, error) {
 	temps := fmt.Sprintf("start -n %s", pkg)
+	if c.user != nil {
+		temps = fmt.Sprintf("start --user %s -n %s", c.user, pkg)
+	}
 	result, isError := c.RunCommand("am", temps)
 	return result, isError
 }
@@ -459,27 +520,27 @@ func (c *Device) LaunchApk(pkg string) (string, error) {
 // Click 436,1291
 func (c *Device) Click(x, y int) (string, error) {
 	temps := fmt.Sprintf("tap %d %d", x, y)
-	result, isError := c.RunCommand("input", temps)
+	result, isError := c.runInputCommand(temps)
 	return result, isError
 }
 
 // Drag 436,1291 -> 636,1291
 func (c *Device) Drag(x, y, x1, y1 int) (string, error) {
 	temps := fmt.Sprintf("swipe %d %d %d %d", x, y, x1, y1)
-	result, isError := c.RunCommand("input", temps)
+	result, isError := c.runInputCommand(temps)
 	return result, isError
 }
 
 // Home back to home
 func (c *Device) Home() (string, error) {
-	result, isError := c.RunCommand("input", "keyevent 3")
+	result, isError := c.runInputCommand("keyevent 3")
 	return result, isError
 }
 
 // Home back to home
 func (c *Device) InputText(text string) (string, error) {
 	temps := fmt.Sprintf("text %s", text)
-	result, isError := c.RunCommand("input", temps)
+	result, isError := c.runInputCommand(temps)
 	return result, isError
 }
 
@@ -487,7 +548,11 @@ func (c *Device) InputText(text string) (string, error) {
 func (c *Device) ScreenShot() ([]byte, error) {
 	temps := fmt.Sprintf("-p")
 	//result, isError := c.RunCommand("screencap", temps)
-	cmd, err := prepareCommandLine("screencap", temps)
+	displayArgs, err := c.captureDisplayArgs("-d")
+	if err != nil {
+		return nil, wrapClientError(err, c, "ScreenShot")
+	}
+	cmd, err := prepareCommandLine("screencap", append([]string{temps}, displayArgs...)...)
 	if err != nil {
 		return nil, wrapClientError(err, c, "RunCommand")
 	}
@@ -516,120 +581,172 @@ func (c *Device) ScreenShot() ([]byte, error) {
 
 const StdIoFilename = "-"
 
-type PushEvent struct {
-	Current int64
-	Total   int64
-	Speed   string
-	Raw     string
-}
+/*
+PushWithProgress pushes the file at localPath, or stdin if localPath is StdIoFilename, to
+remotePath on the device. If showProgress is true, cb is called with the progress of the
+transfer. Closing ctx aborts the push. To push anything other than a local file, use
+PushReader.
 
-func (c *Device) PushWithProgress(ctx context.Context, showProgress bool, localPath, remotePath string, cb func(event PushEvent)) error {
+Returns stats about the transfer; nothing is printed.
+*/
+func (c *Device) PushWithProgress(ctx context.Context, showProgress bool, localPath, remotePath string, cb func(event PushEvent)) (*TransferStats, error) {
 	if remotePath == "" {
-		return wrapClientError(errors.WrapErrf(nil,"error: must specify remote file"),
+		return nil, wrapClientError(errors.AssertionErrorf("must specify remote file"),
 			c, "PushWithProgress")
 	}
+	if !showProgress {
+		cb = nil
+	}
 
-	var (
-		localFile io.ReadCloser
-		size      int
-		perms     os.FileMode
-		mtime     time.Time
-	)
 	if localPath == "" || localPath == StdIoFilename {
-		localFile = os.Stdin
-		// 0 size will hide the progress bar.
-		perms = os.FileMode(0660)
-		mtime = MtimeOfClose
-	} else {
-		var err error
-		localFile, err = os.Open(localPath)
-		if err != nil {
-			return wrapClientError(err, c, "PushWithProgress")
-		}
-		info, err := os.Stat(localPath)
-		if err != nil {
-			return wrapClientError(err, c, "PushWithProgress")
-		}
-		size = int(info.Size())
-		perms = info.Mode().Perm()
-		mtime = info.ModTime()
+		// The size of stdin is unknown.
+		stats, err := c.pushReader(ctx, os.Stdin, remotePath, os.FileMode(0660), MtimeOfClose, 0, cb)
+		return stats, wrapClientError(err, c, "PushWithProgress")
+	}
+
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return nil, wrapClientError(err, c, "PushWithProgress")
 	}
 	defer localFile.Close()
+	info, err := localFile.Stat()
+	if err != nil {
+		return nil, wrapClientError(err, c, "PushWithProgress")
+	}
+
+	stats, err := c.pushReader(ctx, localFile, remotePath, info.Mode().Perm(), info.ModTime(), info.Size(), cb)
+	return stats, wrapClientError(err, c, "PushWithProgress")
+}
 
+/*
+PushReader streams everything read from r to remotePath on the device, creating it with perms
+and setting its modification time to mtime, e.g. to push generated content like tar output or
+an HTTP body without staging it in a temporary file.
+
+sizeHint is the number of bytes r is expected to produce, or 0 if it's unknown. If it's
+positive and r ends early, the push fails instead of leaving a truncated file that looks
+complete. If r fails or ends early, or ctx is done before r ends, the push is aborted without
+committing the file. If the push fails, a partial file is removed unless remotePath already
+existed.
+*/
+func (c *Device) PushReader(ctx context.Context, r io.Reader, remotePath string, perms os.FileMode, mtime time.Time, sizeHint int64) (*TransferStats, error) {
+	stats, err := c.pushReader(ctx, r, remotePath, perms, mtime, sizeHint, nil)
+	return stats, wrapClientError(err, c, "PushReader(%s)", remotePath)
+}
+
+func (c *Device) pushReader(ctx context.Context, r io.Reader, remotePath string, perms os.FileMode, mtime time.Time, size int64, cb func(PushEvent)) (*TransferStats, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Only a file this push creates may be removed if it fails, since some sync errors only show
+	// up once it's closed, e.g. a push onto a directory.
+	_, err := c.Stat(remotePath)
+	created := HasErrCode(err, FileNoExistError)
+	if err != nil && !created {
+		return nil, err
+	}
 	writer, err := c.OpenWrite(remotePath, perms, mtime)
 	if err != nil {
-		return wrapClientError(err, c, "PushWithProgress")
-	}
-	defer writer.Close()
-
-	if ctx != nil {
-		go func() {
-			select {
-			case <-ctx.Done():
-				writer.Close()
-			}
-		}()
-	}
-
-	if err := c.copyWithProgressAndStats(writer, localFile, size, showProgress, cb); err != nil {
-		fmt.Fprintln(os.Stderr, "error pushing file:", err)
-		return wrapClientError(err, c, "PushWithProgress")
-	}
-	return nil
-}
-
-// copyWithProgressAndStats copies src to dst.
-// If showProgress is true and size is positive, a progress bar is shown.
-// After copying, final stats about the transfer speed and size are shown.
-// Progress and stats are printed to stderr.
-func (c *Device) copyWithProgressAndStats(dst io.Writer, src io.Reader, size int, showProgress bool, cb func(event PushEvent)) error {
-	var progress *pb.ProgressBar
-	if showProgress && size > 0 && cb != nil {
-		progress = pb.New(size)
-		progress.Callback = func(out string) {
-			fmt.Println("current", progress.Add64(0))
-
-			cb(PushEvent{
-				Current: progress.Add64(0),
-				Total:   progress.Total,
-				Speed:   strings.TrimSpace(out),
-			})
-		}
-		progress.ShowSpeed = true
+		return nil, err
+	}
 
-		progress.ShowCounters = false
-		progress.ShowPercent = false
-		progress.ShowTimeLeft = false
-		progress.ShowElapsedTime = false
-		progress.ShowFinalTime = false
-		progress.ShowBar = false
+	stats, err := copyWithStats(writer, &ctxErrReader{ctx: ctx, Reader: r}, size, cb)
+	if err == nil && size > 0 && stats.Bytes < size {
+		err = errors.WrapErrorf(io.ErrUnexpectedEOF, errors.ConnectionResetError,
+			"stream ended after %d of %d bytes", stats.Bytes, size)
+	}
+	if err != nil {
+		abortWrite(writer)
+	} else {
+		err = writer.Close()
+	}
+	if err != nil && created {
+		// Best effort, the connection may be what failed. Without -r, so that if something
+		// else created a directory there meanwhile it's left alone.
+		c.runShellCommand(quoteShellArgs("rm", "-f", remotePath))
+	}
+	return stats, err
+}
 
-		progress.SetUnits(pb.U_BYTES_DEC)
-		progress.Start()
+// ctxErrReader fails reads with the error of ctx once it's done.
+type ctxErrReader struct {
+	ctx context.Context
+	io.Reader
+}
 
-		dst = io.MultiWriter(dst, progress)
+func (r *ctxErrReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
 	}
+	return r.Reader.Read(p)
+}
 
-	startTime := time.Now()
-	copied, err := io.Copy(dst, src)
+/*
+PullWithProgress pulls the file at remotePath on the device to localPath, or stdout if
+localPath is StdIoFilename. If showProgress is true, cb is called with the progress of the
+transfer. Closing ctx aborts the pull.
 
-	if progress != nil {
-		progress.Finish()
+Returns stats about the transfer; nothing is printed.
+*/
+func (c *Device) PullWithProgress(ctx context.Context, showProgress bool, remotePath, localPath string, cb func(event PushEvent)) (*TransferStats, error) {
+	if !showProgress {
+		cb = nil
+	}
+	if localPath == StdIoFilename {
+		stats, err := c.pullTo(ctx, remotePath, os.Stdout, cb)
+		return stats, wrapClientError(err, c, "PullWithProgress")
 	}
 
-	if pathErr, ok := err.(*os.PathError); ok {
-		if errno, ok := pathErr.Err.(syscall.Errno); ok && errno == syscall.EPIPE {
-			// Pipe closed. Handle this like an EOF.
-			err = nil
-		}
+	localFile, err := os.Create(localPath)
+	if err != nil {
+		return nil, wrapClientError(err, c, "PullWithProgress")
+	}
+	stats, err := c.pullTo(ctx, remotePath, localFile, cb)
+	if closeErr := localFile.Close(); err == nil {
+		err = closeErr
 	}
 	if err != nil {
-		return err
+		// Don't leave a partial file behind.
+		os.Remove(localPath)
 	}
+	return stats, wrapClientError(err, c, "PullWithProgress")
+}
+
+/*
+PullTo streams the file at remotePath on the device into w, e.g. a hasher, an HTTP response, or
+an archive, without touching the local disk. Closing ctx aborts the pull. To report progress,
+use PullToWithProgress.
+*/
+func (c *Device) PullTo(ctx context.Context, remotePath string, w io.Writer) (*TransferStats, error) {
+	stats, err := c.pullTo(ctx, remotePath, w, nil)
+	return stats, wrapClientError(err, c, "PullTo(%s)", remotePath)
+}
 
-	duration := time.Now().Sub(startTime)
-	rate := int64(float64(copied) / duration.Seconds())
-	fmt.Fprintf(os.Stderr, "%d B/s (%d bytes in %s)\n", rate, copied, duration)
+// PullToWithProgress pulls remotePath into w like PullTo, calling cb with the progress of the
+// transfer.
+func (c *Device) PullToWithProgress(ctx context.Context, remotePath string, w io.Writer, cb func(event PushEvent)) (*TransferStats, error) {
+	stats, err := c.pullTo(ctx, remotePath, w, cb)
+	return stats, wrapClientError(err, c, "PullToWithProgress(%s)", remotePath)
+}
 
-	return nil
+func (c *Device) pullTo(ctx context.Context, remotePath string, w io.Writer, cb func(PushEvent)) (*TransferStats, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var size int64
+	if cb != nil {
+		// Only needed for the progress total.
+		info, err := c.Stat(remotePath)
+		if err != nil {
+			return nil, err
+		}
+		size = int64(info.Size)
+	}
+
+	reader, err := c.OpenRead(remotePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return copyWithStats(w, &ctxErrReader{ctx: ctx, Reader: reader}, size, cb)
 }
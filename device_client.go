@@ -0,0 +1,202 @@
+package adb
+
+import (
+	"context"
+	"image"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+/*
+DeviceClient is the method set of Device, so code that talks to devices can be unit-tested
+against a fake such as adbmock.Device instead of a connected phone.
+
+Methods that return sub-clients or copies bound to a real Device (ForUser, WithLogger,
+WithInstrumentation, WithRateLimit, OnDisplay, Settings, Battery, Content, and RunAs) aren't included.
+
+When adding exported methods to Device, add them here and run go generate ./adbmock.
+*/
+type DeviceClient interface {
+	String() string
+	Serial() (string, error)
+	DevicePath() (string, error)
+	State() (DeviceState, error)
+	DeviceInfo() (*DeviceInfo, error)
+	Features() ([]string, error)
+	HasFeature(feature string) (bool, error)
+	GetProperty(name string) (string, error)
+	Properties() (map[string]string, error)
+	WatchProps(ctx context.Context) (*PropertyWatcher, error)
+	ListProcesses() ([]*Process, error)
+	PidOf(name string) ([]int, error)
+	Kill(pid int, signal syscall.Signal) error
+	SampleProcesses(ctx context.Context, config ProcessSamplerConfig) (*ProcessSampler, error)
+	SDKVersion() (int, error)
+	IsRoot() (bool, error)
+	IsEmulator() (bool, error)
+	AvdName() (string, error)
+
+	RunCommand(cmd string, args ...string) (string, error)
+	OpenCommand(cmd string, args ...string) (io.ReadCloser, error)
+	RunAbb(service string, args ...string) (string, error)
+	RunAdbCmd(cmd string) (string, error)
+	RunAdbCmdCtx(ctx context.Context, cmd string) (string, error)
+	RunAdbCmdCtxWithTimeout(ctx context.Context, cmd string, duration time.Duration) (string, error)
+	RunAdbCmdCtxWithStdoutPipe(ctx context.Context, cmd string) (io.ReadCloser, error)
+	RunAdbShellCmdCtx(ctx context.Context, cmd string) (string, error)
+	RunAdbShellCmdCtxWithTimeout(ctx context.Context, cmd string, duration time.Duration) (string, error)
+	Remount() (string, error)
+
+	ListDirEntries(path string) (*DirEntries, error)
+	Stat(path string) (*DirEntry, error)
+	OpenRead(path string) (io.ReadCloser, error)
+	OpenWrite(path string, perms os.FileMode, mtime time.Time) (io.WriteCloser, error)
+	TailFile(ctx context.Context, path string, fromEnd bool) (io.ReadCloser, error)
+	WatchPath(ctx context.Context, path string) (*PathWatcher, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(path string) error
+	RemoveAll(path string) error
+	Rename(oldPath, newPath string) error
+	Copy(src, dst string) error
+	Chmod(path string, mode os.FileMode) error
+	ReadLink(path string) (string, error)
+	CreateSymlink(target, path string) error
+	ChecksumTools() (*ChecksumTools, error)
+	Md5sum(path string) ([]byte, error)
+	Sha256sum(path string) ([]byte, error)
+	PushDir(localDir, remoteDir string, opts TransferOptions) error
+	PullDir(remoteDir, localDir string, opts TransferOptions) error
+	PullDirCompressed(remoteDir, localDir string, opts TransferOptions) error
+	Push(localPath, remotePath string) (string, error)
+	PushWithProgress(ctx context.Context, showProgress bool, localPath, remotePath string, cb func(event PushEvent)) (*TransferStats, error)
+	PushReader(ctx context.Context, r io.Reader, remotePath string, perms os.FileMode, mtime time.Time, sizeHint int64) (*TransferStats, error)
+	PullWithProgress(ctx context.Context, showProgress bool, remotePath, localPath string, cb func(event PushEvent)) (*TransferStats, error)
+	PullTo(ctx context.Context, remotePath string, w io.Writer) (*TransferStats, error)
+	PullToWithProgress(ctx context.Context, remotePath string, w io.Writer, cb func(event PushEvent)) (*TransferStats, error)
+
+	Forward(local, remote string) (string, error)
+	ForwardToFreePort(remote string) (int, error)
+	ListForwards() ([]ForwardEntry, error)
+	RemoveForward(local string) error
+	RemoveAllForwards() error
+	ClearForwardAll() (string, error)
+	ClearForwardBySerial(deviceId string, port int, remote string) (string, error)
+	Reverse(remote, local string) (string, error)
+	ListReverses() ([]ReverseEntry, error)
+	RemoveReverse(remote string) error
+	RemoveAllReverses() error
+	DialLocalAbstract(name string) (net.Conn, error)
+	DialTCPOnDevice(port int) (net.Conn, error)
+
+	InstallApp(ctx context.Context, apk string, reinstall bool, grantPermission bool) (string, error)
+	InstallAppByPm(ctx context.Context, apk string, reinstall bool, grantPermission bool) (string, error)
+	InstallAppStream(r io.Reader, size int64, reinstall bool, grantPermission bool) error
+	InstallAppIncremental(ctx context.Context, apk string, reinstall bool, grantPermission bool) (*IncrementalInstall, error)
+	InstallAppFile(path string, opts InstallOptions) (bool, error)
+	InstalledApkInfo(pkg string) (*ApkInfo, error)
+	UninstallApp(ctx context.Context, pkg string) (string, error)
+	LaunchApk(pkg string) (string, error)
+	ClearData(pkg string) error
+	DisablePackage(pkg string) error
+	EnablePackage(pkg string) error
+	PackageEnabledState(pkg string) (PackageEnabledState, error)
+	SetAppOp(pkg, op string, mode AppOpMode) error
+	GetAppOps(pkg string) ([]AppOp, error)
+	SetDeviceOwner(admin string) error
+	RemoveActiveAdmin(admin string) error
+	DeviceStateID() (int, error)
+	OverrideDeviceState(id int) error
+	ClearDeviceStateOverride() error
+	EnableTestHarness() error
+	GrantPermission(pkg, permission string) error
+	RevokePermission(pkg, permission string) error
+	CurrentActivity() (*Activity, error)
+	ForceStop(pkg string) error
+	MeasureLaunch(activity Activity) (*LaunchResult, error)
+	BenchmarkLaunch(ctx context.Context, activity Activity, opts LaunchBenchmarkOptions) (*LaunchBenchmark, error)
+	WatchANRs(ctx context.Context) (*ANRWatcher, error)
+	DropboxEntries(since time.Time, tags ...string) ([]DropboxEntry, error)
+	Dmesg(ctx context.Context, follow bool) (*KernelLogWatcher, error)
+	ProcessMemory() ([]ProcessMemory, error)
+	MemoryMappings(pid int) ([]MemoryMapping, error)
+	LibraryMemory() ([]LibraryMemory, error)
+	Thermal() (*ThermalState, error)
+	SampleThermal(ctx context.Context, interval time.Duration) (*ThermalSampler, error)
+	GPUSource() (*GPUSource, error)
+	SampleGPU(ctx context.Context, interval time.Duration) (*GPUSampler, error)
+	SurfaceFlingerLayers() ([]string, error)
+	FrameLatency(layer string) (*FrameLatency, error)
+	ClearFrameLatency(layer string) error
+	ResetBatteryStats() error
+	DumpBatteryStats(w io.Writer, checkin bool) error
+	PowerRails() ([]PowerRail, error)
+	SamplePowerRails(ctx context.Context, interval time.Duration) (*PowerRailSampler, error)
+	ProbeLink(ctx context.Context, opts LinkProbeOptions) (*LinkQuality, error)
+	ListPackages() ([]Package, error)
+	ListInstrumentation() ([]InstrumentationTarget, error)
+	InstallTestOrchestrator(orchestratorAPK, servicesAPK string) error
+	RunOrchestratedTests(ctx context.Context, runner string, opts OrchestratorOptions) ([]TestResult, error)
+	CollectArtifacts(opts ArtifactOptions) ([]Artifact, error)
+	TrafficByPackage() ([]PackageTraffic, error)
+	WakeLocks() ([]WakeLock, error)
+	WakeLockStats() ([]WakeLockStats, error)
+	SampleWakeLocks(ctx context.Context, interval time.Duration) (*WakeLockSampler, error)
+	CPUClusters() ([]CPUCluster, error)
+	PinCPUFrequency(policy, freq int) error
+	SetCPUGovernor(policy int, governor string) error
+	RestoreCPUDefaults() error
+	Strace(ctx context.Context, pid int, opts StraceOptions) (*StraceWatcher, error)
+	StracePackage(ctx context.Context, pkg string, opts StraceOptions) (*StraceWatcher, error)
+	WatchState(ctx context.Context) (*DeviceStateWatcher, error)
+	WaitForBootComplete(ctx context.Context) error
+	ListUsers() ([]*UserInfo, error)
+
+	Click(x, y int) (string, error)
+	Drag(x, y, x1, y1 int) (string, error)
+	Home() (string, error)
+	InputText(text string) (string, error)
+	ScreenShot() ([]byte, error)
+	ScreenShotImage() (image.Image, error)
+	ScreenShotWithOptions(opts ScreenshotOptions) ([]byte, error)
+	ScreenRecord(ctx context.Context, remotePath string, opts ScreenRecordOptions) error
+	StartRecording(ctx context.Context, opts RecordingOptions) (*RecordingSession, error)
+	SetStatusBar(action StatusBarAction) error
+
+	IsScreenOn() (bool, error)
+	IsLocked() (bool, error)
+	Wake() error
+	Unlock() error
+	SetStayAwake(enabled bool) error
+	DisplayInfo() (*DisplayInfo, error)
+	Displays() ([]*Display, error)
+	SetDisplaySize(width, height int) error
+	SetDensity(dpi int) error
+	ResetDisplay() error
+	Rotation() (Rotation, error)
+	SetRotation(rotation Rotation) error
+	SetAutoRotate(enabled bool) error
+
+	Locale() (string, error)
+	SetLocale(locale string, method LocaleMethod) error
+	Timezone() (string, error)
+	SetTimezone(tz string) error
+	Time() (time.Time, error)
+	SetTime(t time.Time) error
+
+	SetAirplaneMode(enabled bool) error
+	IsAirplaneMode() (bool, error)
+	SetWifiEnabled(enabled bool) error
+	IsWifiEnabled() (bool, error)
+	SetMobileDataEnabled(enabled bool) error
+	IsMobileDataEnabled() (bool, error)
+	ConnectWifi(ctx context.Context, network WifiNetwork) error
+	WifiSSID() (string, error)
+	SetGlobalProxy(host string, port int, exclusions ...string) error
+	ClearGlobalProxy() error
+	GlobalProxy() (*ProxyConfig, error)
+}
+
+var _ DeviceClient = &Device{}
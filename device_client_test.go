@@ -0,0 +1,34 @@
+package adb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceClientCoversDeviceMethods(t *testing.T) {
+	// Methods returning sub-clients bound to a real Device.
+	excluded := map[string]bool{
+		"ForUser":             true,
+		"Settings":            true,
+		"Battery":             true,
+		"Content":             true,
+		"RunAs":               true,
+		"WithLogger":          true,
+		"WithInstrumentation": true,
+		"WithRateLimit":       true,
+		"OnDisplay":           true,
+	}
+
+	iface := reflect.TypeOf((*DeviceClient)(nil)).Elem()
+	device := reflect.TypeOf(&Device{})
+	for i := 0; i < device.NumMethod(); i++ {
+		name := device.Method(i).Name
+		if excluded[name] {
+			continue
+		}
+		_, ok := iface.MethodByName(name)
+		assert.True(t, ok, "DeviceClient is missing %s", name)
+	}
+}
@@ -2,6 +2,7 @@ package adb
 
 import (
 	"bufio"
+	"strconv"
 	"strings"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
@@ -10,6 +11,8 @@ import (
 type DeviceInfo struct {
 	// Always set.
 	Serial string
+	// StateInvalid if adb reported a state this package doesn't know.
+	State DeviceState
 
 	// Product, device, and model are not set in the short form.
 	Product    string
@@ -19,8 +22,24 @@ type DeviceInfo struct {
 	// Only set for devices connected via USB.
 	Usb string
 
+	// Id of the connection to the device, 0 if the server is too old to report it. Unlike the
+	// serial, it's unique, and changes when the device reconnects.
+	TransportID int
+
 	// Only set for remote connect to adbd.
 	AdbdPort  string
+
+	// Label and metadata from ServerConfig.Registry, empty if the device isn't in it.
+	Label    string
+	Metadata map[string]string
+}
+
+// Name returns the label of the device if it has one, else its serial.
+func (d *DeviceInfo) Name() string {
+	if d.Label != "" {
+		return d.Label
+	}
+	return d.Serial
 }
 
 // IsUsb returns true if the device is connected via USB.
@@ -28,18 +47,23 @@ func (d *DeviceInfo) IsUsb() bool {
 	return d.Usb != ""
 }
 
-func newDevice(serial string, attrs map[string]string) (*DeviceInfo, error) {
+func newDevice(serial, state string, attrs map[string]string) (*DeviceInfo, error) {
 	if serial == "" {
 		return nil, errors.AssertionErrorf("device serial cannot be blank")
 	}
 
+	// Newer servers may report states this package doesn't know, which shouldn't hide the device.
+	deviceState, _ := parseDeviceState(state)
+	transportID, _ := strconv.Atoi(attrs["transport_id"])
 	return &DeviceInfo{
-		Serial:     serial,
-		Product:    attrs["product"],
-		Model:      attrs["model"],
-		DeviceInfo: attrs["device"],
-		Usb:        attrs["usb"],
-		AdbdPort:   attrs["adbd_port"],
+		Serial:      serial,
+		State:       deviceState,
+		Product:     attrs["product"],
+		Model:       attrs["model"],
+		DeviceInfo:  attrs["device"],
+		Usb:         attrs["usb"],
+		TransportID: transportID,
+		AdbdPort:    attrs["adbd_port"],
 	}, nil
 }
 
@@ -65,27 +89,36 @@ func parseDeviceShort(line string) (*DeviceInfo, error) {
 			"malformed device line, expected 2 fields but found %d", len(fields))
 	}
 
-	return newDevice(fields[0], map[string]string{})
+	return newDevice(fields[0], fields[1], map[string]string{})
 }
 
 func parseDeviceLong(line string) (*DeviceInfo, error) {
 	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil, errors.Errorf(errors.ParseError,
+			"malformed device line, expected at least 2 fields but found %d", len(fields))
+	}
 
 	attrs := parseDeviceAttributes(fields[2:])
-	return newDevice(fields[0], attrs)
+	return newDevice(fields[0], fields[1], attrs)
 }
 
 func parseDeviceAttributes(fields []string) map[string]string {
 	attrs := map[string]string{}
 	for _, field := range fields {
-		key, val := parseKeyVal(field)
-		attrs[key] = val
+		// Skips the words of states with spaces, like "no permissions (...)".
+		if key, val, ok := parseKeyVal(field); ok {
+			attrs[key] = val
+		}
 	}
 	return attrs
 }
 
 // Parses a key:val pair and returns key, val.
-func parseKeyVal(pair string) (string, string) {
-	split := strings.Split(pair, ":")
-	return split[0], split[1]
+func parseKeyVal(pair string) (string, string, bool) {
+	split := strings.SplitN(pair, ":", 2)
+	if len(split) != 2 {
+		return "", "", false
+	}
+	return split[0], split[1], true
 }
@@ -20,7 +20,8 @@ func TestParseDeviceShort(t *testing.T) {
 	dev, err := parseDeviceShort("192.168.56.101:5555	device\n")
 	assert.NoError(t, err)
 	assert.Equal(t, &DeviceInfo{
-		Serial: "192.168.56.101:5555"}, dev)
+		Serial: "192.168.56.101:5555",
+		State:  StateOnline}, dev)
 }
 
 func TestParseDeviceLong(t *testing.T) {
@@ -28,6 +29,7 @@ func TestParseDeviceLong(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, &DeviceInfo{
 		Serial:     "SERIAL",
+		State:      StateOnline,
 		Product:    "PRODUCT",
 		Model:      "MODEL",
 		DeviceInfo: "DEVICE"}, dev)
@@ -38,7 +40,9 @@ func TestParseDeviceLongUnauthorized(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, &DeviceInfo{
 		Serial: "SERIAL",
-		Usb: "1234"}, dev)
+		State: StateUnauthorized,
+		Usb: "1234",
+		TransportID: 8}, dev)
 }
 
 func TestParseDeviceLongUsb(t *testing.T) {
@@ -46,8 +50,35 @@ func TestParseDeviceLongUsb(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, &DeviceInfo{
 		Serial:     "SERIAL",
+		State:      StateOnline,
 		Product:    "PRODUCT",
 		Model:      "MODEL",
 		DeviceInfo: "DEVICE",
 		Usb:        "1234"}, dev)
 }
+
+func TestParseDeviceLongStates(t *testing.T) {
+	for line, want := range map[string]DeviceState{
+		"SERIAL    recovery usb:1-1 transport_id:2":   StateRecovery,
+		"SERIAL    sideload usb:1-1 transport_id:2":   StateSideload,
+		"SERIAL    rescue usb:1-1 transport_id:2":     StateRescue,
+		"SERIAL    bootloader usb:1-1 transport_id:2": StateBootloader,
+		"SERIAL    future usb:1-1 transport_id:2":     StateInvalid,
+	} {
+		dev, err := parseDeviceLong(line)
+		assert.NoError(t, err)
+		assert.Equal(t, want, dev.State, line)
+	}
+}
+
+func TestParseDeviceLongNoPermissions(t *testing.T) {
+	dev, err := parseDeviceLong("SERIAL    no permissions (missing udev rules? user is in the plugdev group); " +
+		"see [http://developer.android.com/tools/device.html] usb:1-1 transport_id:3")
+	assert.NoError(t, err)
+	assert.Equal(t, StateInvalid, dev.State)
+	assert.Equal(t, "1-1", dev.Usb)
+	assert.Equal(t, 3, dev.TransportID)
+
+	_, err = parseDeviceLong("SERIAL")
+	assert.Error(t, err)
+}
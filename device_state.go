@@ -2,7 +2,7 @@ package adb
 
 import "github.com/zach-klippenstein/goadb/internal/errors"
 
-// DeviceState represents one of the 3 possible states adb will report devices.
+// DeviceState represents one of the states adb will report devices in.
 // A device can be communicated with when it's in StateOnline.
 // A USB device will make the following state transitions:
 // 	Plugged in: StateDisconnected->StateOffline->StateOnline
@@ -18,6 +18,14 @@ const (
 	StateOffline
 	StateOnline
 	StateHost
+	// The device is in the bootloader, where fastboot talks to it instead of adb.
+	StateBootloader
+	// The device is in recovery, which runs a limited adbd.
+	StateRecovery
+	// The device is in recovery, waiting for adb sideload.
+	StateSideload
+	// The device is in rescue mode, recovery's limited mode for adb rescue.
+	StateRescue
 )
 
 var deviceStateStrings = map[string]DeviceState{
@@ -27,6 +35,10 @@ var deviceStateStrings = map[string]DeviceState{
 	"unauthorized": StateUnauthorized,
 	"authorizing":  StateAuthorizing,
 	"host":         StateHost,
+	"bootloader":   StateBootloader,
+	"recovery":     StateRecovery,
+	"sideload":     StateSideload,
+	"rescue":       StateRescue,
 }
 
 func parseDeviceState(str string) (DeviceState, error) {
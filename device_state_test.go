@@ -18,6 +18,11 @@ func TestParseDeviceState(t *testing.T) {
 		{"offline", StateOffline, "StateOffline", nil},
 		{"device", StateOnline, "StateOnline", nil},
 		{"unauthorized", StateUnauthorized, "StateUnauthorized", nil},
+		{"recovery", StateRecovery, "StateRecovery", nil},
+		{"sideload", StateSideload, "StateSideload", nil},
+		{"rescue", StateRescue, "StateRescue", nil},
+		{"bootloader", StateBootloader, "StateBootloader", nil},
+		{"host", StateHost, "StateHost", nil},
 		{"bad", StateInvalid, "StateInvalid", errors.New(`ParseError: invalid device state: "StateInvalid"`)},
 	} {
 		state, err := parseDeviceState(test.String)
@@ -1,7 +1,11 @@
 package adb
 
 import (
+	stderrors "errors"
+	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/zach-klippenstein/goadb/internal/errors"
@@ -51,6 +55,12 @@ func TestGetDeviceInfo(t *testing.T) {
 	assert.EqualError(t, err.(*errors.Err).Cause,
 		"DeviceNotFound: device list doesn't contain serial serial")
 	assert.Nil(t, device)
+	assert.True(t, stderrors.Is(err, ErrDeviceNotFound))
+	assert.False(t, stderrors.Is(err, ErrDeviceOffline))
+
+	var adbErr *Error
+	assert.True(t, stderrors.As(err, &adbErr))
+	assert.Equal(t, DeviceNotFound, ErrCode(adbErr.Code))
 }
 
 func newDeviceClientWithDeviceLister(serial string, deviceLister func() ([]*DeviceInfo, error)) *Device {
@@ -76,6 +86,22 @@ func TestRunCommandNoArgs(t *testing.T) {
 	assert.Equal(t, "output", v)
 }
 
+func TestOpenCommand(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"line 1\n", "line 2\n"},
+	}
+	client := (&Adb{s}).Device(AnyDevice())
+
+	reader, err := client.OpenCommand("logcat", "-v", "brief")
+	assert.NoError(t, err)
+	output, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	assert.Equal(t, "line 1\nline 2\n", string(output))
+	assert.Equal(t, []string{"host:transport-any", "shell:logcat -v brief"}, s.Requests)
+}
+
 func TestPrepareCommandLineNoArgs(t *testing.T) {
 	result, err := prepareCommandLine("cmd")
 	assert.NoError(t, err)
@@ -100,10 +126,11 @@ func TestPrepareCommandLineCleanArgs(t *testing.T) {
 	assert.Equal(t, "cmd arg1 arg2", result)
 }
 
-func TestPrepareCommandLineArgWithWhitespaceQuotes(t *testing.T) {
+// Callers pass pre-joined argument strings (e.g. "tap 1 2"), so whitespace is not quoted.
+func TestPrepareCommandLineArgWithWhitespaceNotQuoted(t *testing.T) {
 	result, err := prepareCommandLine("cmd", "arg with spaces")
 	assert.NoError(t, err)
-	assert.Equal(t, "cmd \"arg with spaces\"", result)
+	assert.Equal(t, "cmd arg with spaces", result)
 }
 
 func TestPrepareCommandLineArgWithDoubleQuoteFails(t *testing.T) {
@@ -119,3 +146,86 @@ func code(err error) errors.ErrCode {
 func message(err error) string {
 	return err.(*errors.Err).Message
 }
+
+func TestDeviceSyncRoundTrip(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+	mtime := time.Unix(1500000000, 0).UTC()
+
+	writer, err := device.OpenWrite("/sdcard/dir/file.txt", 0600, mtime)
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("hello world"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, &MockFile{Data: []byte("hello world"), Mode: 0600, ModTime: mtime}, s.Files["/sdcard/dir/file.txt"])
+
+	reader, err := device.OpenRead("/sdcard/dir/file.txt")
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	assert.Equal(t, []string{"SEND /sdcard/dir/file.txt,384", "RECV /sdcard/dir/file.txt"}, s.SyncRequests)
+}
+
+func TestDeviceSyncStatAndList(t *testing.T) {
+	mtime := time.Unix(1500000000, 0).UTC()
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files: map[string]*MockFile{
+			"/sdcard/a.txt":       {Data: []byte("a"), Mode: 0644, ModTime: mtime},
+			"/sdcard/sub/b.txt":   {Data: []byte("bb"), Mode: 0600, ModTime: mtime},
+			"/sdcard/sub/c/d.txt": {},
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	entry, err := device.Stat("/sdcard/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, &DirEntry{Mode: 0644, Size: 1, ModifiedAt: mtime}, entry)
+
+	entry, err = device.Stat("/sdcard/sub")
+	assert.NoError(t, err)
+	assert.True(t, entry.Mode.IsDir())
+
+	_, err = device.Stat("/sdcard/missing")
+	assert.True(t, HasErrCode(err, FileNoExistError))
+
+	entries, err := device.ListDirEntries("/sdcard/sub")
+	assert.NoError(t, err)
+	all, err := entries.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, "b.txt", all[0].Name)
+	assert.Equal(t, os.FileMode(0600), all[0].Mode)
+	assert.Equal(t, "c", all[1].Name)
+	assert.True(t, all[1].Mode.IsDir())
+}
+
+func TestDeviceSyncOpenReadMissing(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	reader, err := device.OpenRead("/sdcard/missing")
+	if err == nil {
+		_, err = ioutil.ReadAll(reader)
+	}
+	assert.True(t, HasErrCode(err, FileNoExistError))
+	assert.True(t, stderrors.Is(err, ErrFileNotExist))
+	assert.True(t, stderrors.Is(err, os.ErrNotExist))
+}
+
+func TestMockServerShellOutputs(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop ro.kernel.qemu": "\n",
+			"getprop ro.boot.qemu":   "1\n",
+		},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("127.0.0.1:5555"))
+
+	isEmulator, err := device.IsEmulator()
+	assert.NoError(t, err)
+	assert.True(t, isEmulator)
+}
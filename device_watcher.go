@@ -122,43 +122,32 @@ func publishDevices(watcher *deviceWatcherImpl, ctx context.Context) {
 
 	for {
 		scanner, err := connectToTrackDevices(watcher.server)
-
-		go func() {
-			if ctx != nil {
-				<-ctx.Done()
-				log.Println("[DeviceWatcher] give up by ctx done")
-				nostartServer = false
-				scanner.Close()
-			}
-		}()
 		if err != nil {
-			if nostartServer {
+			if nostartServer && !isDone(ctx) {
 				time.Sleep(delay)
 				continue
 			}
 			watcher.reportErr(err)
-			// Delay by a random [0ms, 500ms) in case multiple DeviceWatchers are trying to
-			// start the same server.
-			log.Printf("[DeviceWatcher] server died, restarting in %s…", delay)
-			time.Sleep(delay)
 			return
 		}
 
-		finished, err = publishDevicesUntilError(scanner, watcher.eventChan, &lastKnownStates)
-		if err != nil {
-			log.Printf("[DeviceWatcher] publish devices failed %s…", err)
-			watcher.reportErr(err)
-			scanner.Close()
-			return
+		stop := make(chan struct{})
+		if ctx != nil {
+			go func() {
+				select {
+				case <-ctx.Done():
+					log.Println("[DeviceWatcher] give up by ctx done")
+					scanner.Close()
+				case <-stop:
+				}
+			}()
 		}
 
-		if finished {
-			scanner.Close()
-			return
-		}
+		finished, err = publishDevicesUntilError(scanner, watcher.eventChan, &lastKnownStates)
+		close(stop)
 
-		select {
-		case <-ctx.Done():
+		if finished || isDone(ctx) {
+			scanner.Close()
 			return
 		}
 
@@ -192,6 +181,11 @@ func publishDevices(watcher *deviceWatcherImpl, ctx context.Context) {
 	}
 }
 
+// isDone returns true if ctx is non-nil and has been cancelled.
+func isDone(ctx context.Context) bool {
+	return ctx != nil && ctx.Err() != nil
+}
+
 func connectToTrackDevices(server server) (wire.Scanner, error) {
 	conn, err := server.Dial()
 	if err != nil {
@@ -277,3 +271,87 @@ func calculateStateDiffs(oldStates, newStates map[string]DeviceState) (events []
 
 	return events
 }
+
+// DeviceStateTransition is a change of the state of a device watched with Device.WatchState.
+type DeviceStateTransition struct {
+	// When the change was received from the server.
+	Time     time.Time
+	OldState DeviceState
+	NewState DeviceState
+}
+
+// DeviceStateWatcher publishes the state transitions of a single device.
+type DeviceStateWatcher struct {
+	transitionChan chan DeviceStateTransition
+	// If an error occurs, it is stored here and transitionChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get transitions. It's closed when the context
+// passed to WatchState is done, or if an error occurs.
+func (w *DeviceStateWatcher) C() <-chan DeviceStateTransition {
+	return w.transitionChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (w *DeviceStateWatcher) Err() error {
+	if err, ok := w.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+WatchState sends the state transitions of the device until ctx is done, from the server's device
+tracker. The first is from StateDisconnected to the device's current state. Devices that aren't
+identified by serial, e.g. AnyDevice, must be connected when WatchState is called, to look the
+serial up; the device is then followed by that serial.
+
+E.g. to wait for a device to come back after a reboot:
+
+	watcher, err := device.WatchState(ctx)
+	device.RunCommand("reboot")
+	for transition := range watcher.C() {
+		if transition.OldState != adb.StateOnline && transition.NewState == adb.StateOnline {
+			break
+		}
+	}
+
+Corresponds to the command:
+
+	adb track-devices
+*/
+func (c *Device) WatchState(ctx context.Context) (*DeviceStateWatcher, error) {
+	serial, err := c.serial()
+	if err != nil {
+		return nil, wrapClientError(err, c, "WatchState")
+	}
+
+	devices := newDeviceWatcherWitchCtx(c.server, ctx)
+	watcher := &DeviceStateWatcher{transitionChan: make(chan DeviceStateTransition)}
+	go func() {
+		defer close(watcher.transitionChan)
+		// The device watcher blocks until its events are received, until it sees ctx is done.
+		defer func() {
+			for range devices.C() {
+			}
+		}()
+
+		for event := range devices.C() {
+			if event.Serial != serial {
+				continue
+			}
+			transition := DeviceStateTransition{Time: time.Now(), OldState: event.OldState, NewState: event.NewState}
+			select {
+			case watcher.transitionChan <- transition:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := devices.Err(); err != nil && ctx.Err() == nil {
+			watcher.err.Store(wrapClientError(err, c, "WatchState"))
+		}
+	}()
+	return watcher, nil
+}
@@ -1,6 +1,7 @@
 package adb
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -219,7 +220,7 @@ func TestPublishDevicesRestartsServer(t *testing.T) {
 		eventChan: make(chan DeviceStateChangedEvent),
 	}
 
-	publishDevices(&watcher)
+	publishDevices(&watcher, context.Background())
 
 	assert.Empty(t, server.Errs)
 	assert.Equal(t, []string{"host:track-devices"}, server.Requests)
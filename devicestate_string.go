@@ -15,11 +15,15 @@ func _() {
 	_ = x[StateOffline-4]
 	_ = x[StateOnline-5]
 	_ = x[StateHost-6]
+	_ = x[StateBootloader-7]
+	_ = x[StateRecovery-8]
+	_ = x[StateSideload-9]
+	_ = x[StateRescue-10]
 }
 
-const _DeviceState_name = "StateInvalidStateUnauthorizedStateAuthorizingStateDisconnectedStateOfflineStateOnlineStateHost"
+const _DeviceState_name = "StateInvalidStateUnauthorizedStateAuthorizingStateDisconnectedStateOfflineStateOnlineStateHostStateBootloaderStateRecoveryStateSideloadStateRescue"
 
-var _DeviceState_index = [...]uint8{0, 12, 29, 45, 62, 74, 85, 94}
+var _DeviceState_index = [...]uint8{0, 12, 29, 45, 62, 74, 85, 94, 109, 122, 135, 146}
 
 func (i DeviceState) String() string {
 	if i < 0 || i >= DeviceState(len(_DeviceState_index)-1) {
@@ -3,6 +3,7 @@ package adb
 import (
 	"io"
 	"net"
+	"net/url"
 	"runtime"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
@@ -14,19 +15,29 @@ type Dialer interface {
 	Dial(address string) (*wire.Conn, error)
 }
 
-type tcpDialer struct{}
+type tcpDialer struct {
+	timeouts Timeouts
+	// See ServerConfig.Proxy.
+	proxy func(address string) (*url.URL, error)
+}
 
 // Dial connects to the adb server on the host and port set on the netDialer.
 // The zero-value will connect to the default, localhost:5037.
-func (tcpDialer) Dial(address string) (*wire.Conn, error) {
-	netConn, err := net.Dial("tcp", address)
+func (d tcpDialer) Dial(address string) (*wire.Conn, error) {
+	netConn, err := d.dialNet(address)
 	if err != nil {
-		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error dialing %s", address)
+		return nil, err
 	}
 
+	return newStreamConn(&timeoutConn{Conn: netConn, timeouts: d.timeouts}), nil
+}
+
+// newStreamConn returns a wire.Conn that reads and writes stream, which is closed when the
+// wire.Conn is closed or garbage collected.
+func newStreamConn(stream io.ReadWriteCloser) *wire.Conn {
 	// net.Conn can't be closed more than once, but wire.Conn will try to close both sender and scanner
 	// so we need to wrap it to make it safe.
-	safeConn := wire.MultiCloseable(netConn)
+	safeConn := wire.MultiCloseable(stream)
 
 	// Prevent leaking the network connection, not sure if TCPConn does this itself.
 	// Note that the network connection may still be in use after the conn isn't (scanners/senders
@@ -39,5 +50,65 @@ func (tcpDialer) Dial(address string) (*wire.Conn, error) {
 	return &wire.Conn{
 		Scanner: wire.NewScanner(safeConn),
 		Sender:  wire.NewSender(safeConn),
-	}, nil
+	}
+}
+
+// dialNet connects to address, through a proxy if d.proxy returns one for it.
+func (d tcpDialer) dialNet(address string) (net.Conn, error) {
+	var proxyURL *url.URL
+	if d.proxy != nil {
+		var err error
+		if proxyURL, err = d.proxy(address); err != nil {
+			return nil, err
+		}
+	}
+	if proxyURL != nil {
+		return dialProxy(proxyURL, address, d.timeouts.Dial)
+	}
+
+	netConn, err := net.DialTimeout("tcp", address, d.timeouts.Dial)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error dialing %s", address)
+	}
+	return netConn, nil
+}
+
+// listenAndForward listens on a local port, and forwards each connection to it to a stream
+// returned by dial, until the returned listener is closed.
+func listenAndForward(dial func() (io.ReadWriteCloser, error)) (net.Listener, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.NetworkError, "error listening for forward")
+	}
+	go func() {
+		for {
+			local, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go forwardConn(local, dial)
+		}
+	}()
+	return listener, nil
+}
+
+func forwardConn(local net.Conn, dial func() (io.ReadWriteCloser, error)) {
+	defer local.Close()
+	remote, err := dial()
+	if err != nil {
+		return
+	}
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	// Either side closing ends the forward.
+	<-done
 }
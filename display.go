@@ -0,0 +1,366 @@
+package adb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Rotation is the orientation of the display, in 90° steps counter-clockwise from
+// the device's natural orientation. Values match Android's Surface.ROTATION_* constants.
+type Rotation int
+
+const (
+	Rotation0 Rotation = iota
+	Rotation90
+	Rotation180
+	Rotation270
+)
+
+// DisplayInfo describes the size and orientation of the default display.
+type DisplayInfo struct {
+	// Current size in pixels, including any override set by SetDisplaySize.
+	// Not adjusted for rotation.
+	Width, Height int
+	// Size of the panel in pixels.
+	PhysicalWidth, PhysicalHeight int
+
+	// Current density in dpi, including any override set by SetDensity.
+	Density int
+	// Density of the panel in dpi.
+	PhysicalDensity int
+
+	Rotation Rotation
+}
+
+// Display describes one of the logical displays of the device, e.g. an external monitor, a
+// foldable's cover screen, or a virtual display created by an app.
+type Display struct {
+	// Logical id of the display, as taken by input -d. The default display is 0.
+	ID int
+	// Id SurfaceFlinger gives the physical display, as taken by screencap -d and
+	// screenrecord --display-id. It's 0 for virtual displays, and on devices before Android 10.
+	PhysicalID uint64
+	Name       string
+
+	// Current size in pixels, adjusted for rotation.
+	Width, Height int
+	// Density in dpi.
+	Density  int
+	Rotation Rotation
+
+	// Kind of display, e.g. "INTERNAL", "EXTERNAL", "VIRTUAL", or "OVERLAY".
+	Type string
+	// Power state of the display, e.g. "ON", "OFF", or "DOZE".
+	State string
+}
+
+var (
+	// Matches the "mDisplayId=1" line that starts each logical display of dumpsys display.
+	logicalDisplayIDPattern = regexp.MustCompile(`^\s*mDisplayId=(\d+)`)
+
+	// Matches the name at the start of a DisplayInfo{...} from dumpsys display.
+	displayInfoNamePattern = regexp.MustCompile(`DisplayInfo\{"([^"]*)"`)
+
+	// Match fields of a DisplayInfo{...} from dumpsys display.
+	displayInfoIDPattern       = regexp.MustCompile(`\bdisplayId (\d+)`)
+	displayInfoUniqueIDPattern = regexp.MustCompile(`\buniqueId "local:(\d+)"`)
+	displayInfoSizePattern     = regexp.MustCompile(`\breal (\d+) x (\d+)`)
+	displayInfoRotationPattern = regexp.MustCompile(`\brotation (\d)`)
+	displayInfoDensityPattern  = regexp.MustCompile(`\bdensity (\d+)`)
+	displayInfoTypePattern     = regexp.MustCompile(`\btype ([A-Z_]+)`)
+	displayInfoStatePattern    = regexp.MustCompile(`\bstate ([A-Z_]+)`)
+)
+
+var (
+	// Matches "Physical size: 1080x2400" and "Override size: 720x1600" from wm size.
+	wmSizePattern = regexp.MustCompile(`(Physical|Override) size: (\d+)x(\d+)`)
+
+	// Matches "Physical density: 420" and "Override density: 320" from wm density.
+	wmDensityPattern = regexp.MustCompile(`(Physical|Override) density: (\d+)`)
+
+	// Matches the SurfaceOrientation line of dumpsys input.
+	surfaceOrientationPattern = regexp.MustCompile(`SurfaceOrientation:\s*(\d)`)
+)
+
+/*
+DisplayInfo returns the size, density, and rotation of the default display.
+
+Corresponds to the commands:
+
+	adb shell wm size
+	adb shell wm density
+	adb shell dumpsys input
+*/
+func (c *Device) DisplayInfo() (*DisplayInfo, error) {
+	var info DisplayInfo
+
+	output, err := c.RunCommand("wm", "size")
+	if err != nil {
+		return nil, wrapClientError(err, c, "DisplayInfo")
+	}
+	if err := parseWmSize(output, &info); err != nil {
+		return nil, wrapClientError(err, c, "DisplayInfo")
+	}
+
+	output, err = c.RunCommand("wm", "density")
+	if err != nil {
+		return nil, wrapClientError(err, c, "DisplayInfo")
+	}
+	if err := parseWmDensity(output, &info); err != nil {
+		return nil, wrapClientError(err, c, "DisplayInfo")
+	}
+
+	info.Rotation, err = c.Rotation()
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+/*
+Displays returns the logical displays of the device, ordered by id. The default display is
+always first.
+
+Corresponds to the command:
+
+	adb shell dumpsys display
+*/
+func (c *Device) Displays() ([]*Display, error) {
+	output, err := c.RunCommand("dumpsys", "display")
+	if err != nil {
+		return nil, wrapClientError(err, c, "Displays")
+	}
+	displays, err := parseDisplays(output)
+	if err != nil {
+		return nil, wrapClientError(err, c, "Displays")
+	}
+	return displays, nil
+}
+
+/*
+OnDisplay returns a Device whose input and capture operations, Click, Drag, Home, InputText,
+ScreenShot, and ScreenRecord, target display instead of the default display. Other operations
+aren't affected.
+
+E.g. to tap the cover screen of a foldable:
+
+	displays, _ := device.Displays()
+	device.OnDisplay(displays[1]).Click(100, 200)
+
+Input on secondary displays needs Android 10 or later, and so does capturing them, which also
+needs a PhysicalID, so virtual displays can't be captured.
+*/
+func (c *Device) OnDisplay(display *Display) *Device {
+	scoped := *c
+	scoped.display = display
+	return &scoped
+}
+
+// runInputCommand runs the input command with args, on the display the Device targets.
+// args are passed through unquoted, like RunCommand.
+func (c *Device) runInputCommand(args string) (string, error) {
+	if c.display != nil && c.display.ID != 0 {
+		args = fmt.Sprintf("-d %d %s", c.display.ID, args)
+	}
+	return c.RunCommand("input", args)
+}
+
+// captureDisplayArgs returns the flag, followed by the physical id, that selects the display
+// the Device targets for screencap or screenrecord. It returns no arguments for the default
+// display, so capture still works on devices before Android 10.
+func (c *Device) captureDisplayArgs(flag string) ([]string, error) {
+	if c.display == nil || c.display.ID == 0 {
+		return nil, nil
+	}
+	if c.display.PhysicalID == 0 {
+		return nil, errors.AssertionErrorf("display %d has no physical id, it can't be captured", c.display.ID)
+	}
+	return []string{flag, strconv.FormatUint(c.display.PhysicalID, 10)}, nil
+}
+
+/*
+SetDisplaySize overrides the resolution of the default display.
+
+Corresponds to the command:
+
+	adb shell wm size <width>x<height>
+*/
+func (c *Device) SetDisplaySize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return wrapClientError(errors.AssertionErrorf("invalid display size: %dx%d", width, height),
+			c, "SetDisplaySize")
+	}
+	output, err := c.RunCommand("wm", "size", strconv.Itoa(width)+"x"+strconv.Itoa(height))
+	if err == nil {
+		err = commandOutputError("wm", output)
+	}
+	return wrapClientError(err, c, "SetDisplaySize")
+}
+
+/*
+SetDensity overrides the density of the default display.
+
+Corresponds to the command:
+
+	adb shell wm density <dpi>
+*/
+func (c *Device) SetDensity(dpi int) error {
+	if dpi <= 0 {
+		return wrapClientError(errors.AssertionErrorf("invalid density: %d", dpi), c, "SetDensity")
+	}
+	output, err := c.RunCommand("wm", "density", strconv.Itoa(dpi))
+	if err == nil {
+		err = commandOutputError("wm", output)
+	}
+	return wrapClientError(err, c, "SetDensity")
+}
+
+// ResetDisplay removes any size and density overrides from the default display.
+func (c *Device) ResetDisplay() error {
+	for _, setting := range []string{"size", "density"} {
+		output, err := c.RunCommand("wm", setting, "reset")
+		if err == nil {
+			err = commandOutputError("wm", output)
+		}
+		if err != nil {
+			return wrapClientError(err, c, "ResetDisplay")
+		}
+	}
+	return nil
+}
+
+// Rotation returns the current rotation of the default display.
+func (c *Device) Rotation() (Rotation, error) {
+	output, err := c.RunCommand("dumpsys", "input")
+	if err != nil {
+		return Rotation0, wrapClientError(err, c, "Rotation")
+	}
+	if match := surfaceOrientationPattern.FindStringSubmatch(output); match != nil {
+		rotation, _ := strconv.Atoi(match[1])
+		return Rotation(rotation), nil
+	}
+
+	// Some builds don't report the orientation of the input surface, fall back to the
+	// rotation the user locked the screen to.
+	rotation, err := c.Settings().GetInt(SettingsSystem, "user_rotation")
+	return Rotation(rotation), err
+}
+
+// SetRotation disables auto-rotation and locks the display to rotation.
+func (c *Device) SetRotation(rotation Rotation) error {
+	if rotation < Rotation0 || rotation > Rotation270 {
+		return wrapClientError(errors.AssertionErrorf("invalid rotation: %d", rotation), c, "SetRotation")
+	}
+	if err := c.SetAutoRotate(false); err != nil {
+		return err
+	}
+	return c.Settings().PutInt(SettingsSystem, "user_rotation", int(rotation))
+}
+
+// SetAutoRotate enables or disables rotating the display based on the accelerometer.
+func (c *Device) SetAutoRotate(enabled bool) error {
+	return c.Settings().PutBool(SettingsSystem, "accelerometer_rotation", enabled)
+}
+
+// parseWmSize sets the size fields of info from the output of wm size.
+func parseWmSize(output string, info *DisplayInfo) error {
+	for _, match := range wmSizePattern.FindAllStringSubmatch(output, -1) {
+		width, _ := strconv.Atoi(match[2])
+		height, _ := strconv.Atoi(match[3])
+		if match[1] == "Physical" {
+			info.PhysicalWidth, info.PhysicalHeight = width, height
+		}
+		if match[1] == "Override" || info.Width == 0 {
+			info.Width, info.Height = width, height
+		}
+	}
+	if info.Width == 0 || info.Height == 0 {
+		return errors.Errorf(errors.ParseError, "could not parse display size from: %q", output)
+	}
+	return nil
+}
+
+// parseWmDensity sets the density fields of info from the output of wm density.
+func parseWmDensity(output string, info *DisplayInfo) error {
+	for _, match := range wmDensityPattern.FindAllStringSubmatch(output, -1) {
+		density, _ := strconv.Atoi(match[2])
+		if match[1] == "Physical" {
+			info.PhysicalDensity = density
+		}
+		if match[1] == "Override" || info.Density == 0 {
+			info.Density = density
+		}
+	}
+	if info.Density == 0 {
+		return errors.Errorf(errors.ParseError, "could not parse display density from: %q", output)
+	}
+	return nil
+}
+
+// parseDisplays parses the logical displays from the output of dumpsys display. Each logical
+// display has an mOverrideDisplayInfo line with its current state. Older versions don't include
+// the id in it, so the mDisplayId line before it is used instead.
+func parseDisplays(output string) ([]*Display, error) {
+	var displays []*Display
+	seen := make(map[int]bool)
+	currentID := -1
+
+	for _, line := range strings.Split(output, "\n") {
+		if match := logicalDisplayIDPattern.FindStringSubmatch(line); match != nil {
+			currentID, _ = strconv.Atoi(match[1])
+			continue
+		}
+		if !strings.Contains(line, "mOverrideDisplayInfo=DisplayInfo{") {
+			continue
+		}
+
+		display := &Display{ID: currentID}
+		if match := displayInfoIDPattern.FindStringSubmatch(line); match != nil {
+			display.ID, _ = strconv.Atoi(match[1])
+		}
+		if display.ID < 0 || seen[display.ID] {
+			continue
+		}
+		if match := displayInfoSizePattern.FindStringSubmatch(line); match != nil {
+			display.Width, _ = strconv.Atoi(match[1])
+			display.Height, _ = strconv.Atoi(match[2])
+		}
+		if display.Width == 0 || display.Height == 0 {
+			return nil, errors.Errorf(errors.ParseError, "could not parse display size from: %q", line)
+		}
+
+		if match := displayInfoNamePattern.FindStringSubmatch(line); match != nil {
+			display.Name = match[1]
+		}
+		if match := displayInfoUniqueIDPattern.FindStringSubmatch(line); match != nil {
+			display.PhysicalID, _ = strconv.ParseUint(match[1], 10, 64)
+		}
+		if match := displayInfoRotationPattern.FindStringSubmatch(line); match != nil {
+			rotation, _ := strconv.Atoi(match[1])
+			display.Rotation = Rotation(rotation)
+		}
+		if match := displayInfoDensityPattern.FindStringSubmatch(line); match != nil {
+			display.Density, _ = strconv.Atoi(match[1])
+		}
+		if match := displayInfoTypePattern.FindStringSubmatch(line); match != nil {
+			display.Type = match[1]
+		}
+		if match := displayInfoStatePattern.FindStringSubmatch(line); match != nil {
+			display.State = match[1]
+		}
+
+		seen[display.ID] = true
+		displays = append(displays, display)
+	}
+	if len(displays) == 0 {
+		return nil, errors.Errorf(errors.ParseError, "no logical displays in dumpsys display output")
+	}
+
+	sort.Slice(displays, func(i, j int) bool { return displays[i].ID < displays[j].ID })
+	return displays, nil
+}
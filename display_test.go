@@ -0,0 +1,154 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseWmSize(t *testing.T) {
+	var info DisplayInfo
+	assert.NoError(t, parseWmSize("Physical size: 1080x2400\n", &info))
+	assert.Equal(t, DisplayInfo{Width: 1080, Height: 2400, PhysicalWidth: 1080, PhysicalHeight: 2400}, info)
+
+	info = DisplayInfo{}
+	assert.NoError(t, parseWmSize("Physical size: 1080x2400\nOverride size: 720x1600\n", &info))
+	assert.Equal(t, DisplayInfo{Width: 720, Height: 1600, PhysicalWidth: 1080, PhysicalHeight: 2400}, info)
+
+	assert.Error(t, parseWmSize("", &DisplayInfo{}))
+}
+
+func TestParseWmDensity(t *testing.T) {
+	var info DisplayInfo
+	assert.NoError(t, parseWmDensity("Physical density: 420\nOverride density: 320\n", &info))
+	assert.Equal(t, 320, info.Density)
+	assert.Equal(t, 420, info.PhysicalDensity)
+
+	assert.Error(t, parseWmDensity("", &DisplayInfo{}))
+}
+
+func TestSetDisplaySizeValidates(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	err := device.SetDisplaySize(0, 100)
+	assert.True(t, HasErrCode(err, AssertionError))
+	assert.Empty(t, s.Requests)
+
+	assert.NoError(t, device.SetDisplaySize(720, 1280))
+	assert.Equal(t, "shell:wm size 720x1280", s.Requests[1])
+}
+
+func TestSetRotationValidates(t *testing.T) {
+	device := (&Adb{&MockServer{Status: wire.StatusSuccess}}).Device(AnyDevice())
+	assert.True(t, HasErrCode(device.SetRotation(Rotation(4)), AssertionError))
+}
+
+func TestParseDisplays(t *testing.T) {
+	output := `DISPLAY MANAGER (dumpsys display)
+Logical Displays: size=2
+  Display 2:
+    mDisplayId=2
+    mLayerStack=2
+    mBaseDisplayInfo=DisplayInfo{"HDMI Screen", displayId 2, uniqueId "local:4619827551948147201", app 1920 x 1080, real 1920 x 1080, rotation 0, density 160 (160.0 x 160.0) dpi, type EXTERNAL, state OFF}
+    mOverrideDisplayInfo=DisplayInfo{"HDMI Screen", displayId 2, uniqueId "local:4619827551948147201", app 1920 x 1080, real 1920 x 1080, rotation 0, density 160 (160.0 x 160.0) dpi, type EXTERNAL, state ON}
+  Display 0:
+    mDisplayId=0
+    mLayerStack=0
+    mOverrideDisplayInfo=DisplayInfo{"Built-in Screen", displayId 0, uniqueId "local:4619827259835644672", app 2400 x 1080, real 2400 x 1080, largest app 2400 x 2337, rotation 1, density 420 (409.432 x 411.891) dpi, type INTERNAL, address {port=0, model=0x401cec6a7a2b7b}, state ON}
+`
+	displays, err := parseDisplays(output)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Display{
+		{
+			ID:         0,
+			PhysicalID: 4619827259835644672,
+			Name:       "Built-in Screen",
+			Width:      2400,
+			Height:     1080,
+			Density:    420,
+			Rotation:   Rotation90,
+			Type:       "INTERNAL",
+			State:      "ON",
+		},
+		{
+			ID:         2,
+			PhysicalID: 4619827551948147201,
+			Name:       "HDMI Screen",
+			Width:      1920,
+			Height:     1080,
+			Density:    160,
+			Rotation:   Rotation0,
+			Type:       "EXTERNAL",
+			State:      "ON",
+		},
+	}, displays)
+}
+
+func TestParseDisplaysWithoutDisplayID(t *testing.T) {
+	output := `Logical Displays: size=1
+  Display 0:
+    mDisplayId=0
+    mOverrideDisplayInfo=DisplayInfo{"Built-in Screen", uniqueId "local:0", app 1080 x 1920, real 1080 x 1920, rotation 0, density 480 (480.0 x 480.0) dpi, type BUILT_IN, state ON}
+`
+	displays, err := parseDisplays(output)
+	assert.NoError(t, err)
+	assert.Len(t, displays, 1)
+	assert.Equal(t, 0, displays[0].ID)
+	assert.Equal(t, uint64(0), displays[0].PhysicalID)
+	assert.Equal(t, "BUILT_IN", displays[0].Type)
+
+	_, err = parseDisplays("Can't find service: display\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestDisplays(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dumpsys display": `    mDisplayId=0
+    mOverrideDisplayInfo=DisplayInfo{"Built-in Screen", displayId 0, real 1080 x 2400, rotation 0, density 420 (409.432 x 411.891) dpi, type INTERNAL, state ON}
+`,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	displays, err := device.Displays()
+	assert.NoError(t, err)
+	assert.Len(t, displays, 1)
+	assert.Equal(t, 1080, displays[0].Width)
+	assert.Equal(t, 2400, displays[0].Height)
+}
+
+func TestOnDisplay(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+	cover := device.OnDisplay(&Display{ID: 2, PhysicalID: 4619827551948147201})
+
+	_, err := cover.Click(10, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "shell:input -d 2 tap 10 20", s.Requests[len(s.Requests)-1])
+
+	_, err = cover.ScreenShot()
+	assert.NoError(t, err)
+	assert.Equal(t, "shell:screencap -p -d 4619827551948147201", s.Requests[len(s.Requests)-1])
+
+	// The original Device still targets the default display.
+	_, err = device.Click(10, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "shell:input tap 10 20", s.Requests[len(s.Requests)-1])
+
+	_, err = device.OnDisplay(&Display{ID: 0}).ScreenShot()
+	assert.NoError(t, err)
+	assert.Equal(t, "shell:screencap -p", s.Requests[len(s.Requests)-1])
+}
+
+func TestOnVirtualDisplayCantCapture(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice()).OnDisplay(&Display{ID: 5})
+
+	_, err := device.ScreenShot()
+	assert.True(t, HasErrCode(err, AssertionError))
+	assert.Empty(t, s.Requests)
+}
@@ -0,0 +1,188 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// KernelLogLevel is the severity of a kernel log message, the printk level.
+//
+//go:generate stringer -type=KernelLogLevel
+type KernelLogLevel int8
+
+// Kernel log levels, most severe first.
+const (
+	KernelEmerg KernelLogLevel = iota
+	KernelAlert
+	KernelCrit
+	KernelErr
+	KernelWarning
+	KernelNotice
+	KernelInfo
+	KernelDebug
+)
+
+// Matches the "<6>[   12.345678] message" lines of dmesg -r. The level is absent from lines
+// that continue the previous message, and the timestamp is absent on kernels built without
+// CONFIG_PRINTK_TIME.
+var dmesgLinePattern = regexp.MustCompile(`^(?:<(\d+)>)?(?:\[\s*(\d+)\.(\d+)\] ?)?(.*)$`)
+
+// KernelLogLine is a message from the kernel log.
+type KernelLogLine struct {
+	// Time since the device booted.
+	Timestamp time.Duration
+	Level     KernelLogLevel
+	Message   string
+}
+
+// KernelLogWatcher publishes the lines of the kernel log of a device.
+type KernelLogWatcher struct {
+	lineChan chan KernelLogLine
+	// If an error occurs, it is stored here and lineChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get lines. It's closed when the context
+// passed to Dmesg is done, when the end of the log is reached if not following it, or if an
+// error occurs.
+func (w *KernelLogWatcher) C() <-chan KernelLogLine {
+	return w.lineChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (w *KernelLogWatcher) Err() error {
+	if err, ok := w.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+Dmesg sends the lines of the kernel log, until ctx is done. If follow is true, lines logged
+after the call are sent as well, like dmesg -w, else the channel is closed after the lines
+already in the log.
+
+Most builds restrict the kernel log to root, so if shell can't read it, dmesg is run through
+su. It returns a PermissionDenied error if neither works.
+
+E.g. to watch for thermal throttling during a benchmark:
+
+	watcher, err := device.Dmesg(ctx, true)
+	for line := range watcher.C() {
+		if line.Level <= adb.KernelWarning && strings.Contains(line.Message, "thermal") {
+			fmt.Println(line.Timestamp, line.Message)
+		}
+	}
+
+Corresponds to the command:
+
+	adb shell dmesg -r [-w]
+*/
+func (c *Device) Dmesg(ctx context.Context, follow bool) (*KernelLogWatcher, error) {
+	cmdLine := "dmesg -r"
+	if follow {
+		cmdLine += " -w"
+	}
+
+	root, err := c.IsRoot()
+	if err != nil {
+		return nil, wrapClientError(err, c, "Dmesg")
+	}
+	conn, output, err := c.openDmesg(cmdLine)
+	if err != nil && HasErrCode(err, PermissionDenied) && !root {
+		conn, output, err = c.openDmesg(quoteShellArgs("su", "0", "sh", "-c", cmdLine))
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "Dmesg")
+	}
+
+	watcher := &KernelLogWatcher{lineChan: make(chan KernelLogLine)}
+	go func() {
+		defer close(watcher.lineChan)
+		stream := newContextReader(ctx, conn)
+		defer stream.Close()
+
+		previous := KernelLogLine{Level: KernelInfo}
+		scanner := bufio.NewScanner(io.MultiReader(output, stream))
+		for scanner.Scan() {
+			line := parseDmesgLine(strings.TrimRight(scanner.Text(), "\r"), previous)
+			previous = line
+			select {
+			case watcher.lineChan <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			watcher.err.Store(wrapClientError(err, c, "Dmesg"))
+		}
+	}()
+	return watcher, nil
+}
+
+// openDmesg runs cmdLine and checks the start of its output for errors. It returns the
+// connection, and a reader for the output read while checking.
+func (c *Device) openDmesg(cmdLine string) (io.ReadCloser, io.Reader, error) {
+	conn, err := c.openService("shell:" + cmdLine)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Errors are printed in one write, before any log lines.
+	reader := bufio.NewReader(conn)
+	reader.Peek(1)
+	head, _ := reader.Peek(reader.Buffered())
+	if err := dmesgOutputError(string(head)); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, strings.NewReader(string(head)), nil
+}
+
+// dmesgOutputError returns an error if output starts with an error message from dmesg or su.
+func dmesgOutputError(output string) error {
+	if !strings.HasPrefix(output, "dmesg:") && !strings.HasPrefix(output, "su:") &&
+		!strings.HasPrefix(output, "/system/bin/sh:") {
+		return nil
+	}
+	msg := strings.TrimSpace(strings.SplitN(output, "\n", 2)[0])
+	if strings.Contains(msg, "not permitted") || strings.Contains(msg, "Permission denied") ||
+		strings.Contains(msg, "not found") {
+		return errors.Errorf(errors.PermissionDenied, "root is required: %s", msg)
+	}
+	return errors.Errorf(errors.AdbError, "%s", msg)
+}
+
+// parseDmesgLine parses a line of dmesg -r. Lines without a level or timestamp continue
+// previous, and take them from it.
+func parseDmesgLine(text string, previous KernelLogLine) KernelLogLine {
+	line := KernelLogLine{Timestamp: previous.Timestamp, Level: previous.Level, Message: text}
+	match := dmesgLinePattern.FindStringSubmatch(text)
+	if match == nil {
+		return line
+	}
+	if match[1] != "" {
+		// The facility is in the higher bits.
+		priority, _ := strconv.Atoi(match[1])
+		line.Level = KernelLogLevel(priority & 7)
+	}
+	if match[2] != "" {
+		seconds, _ := strconv.ParseInt(match[2], 10, 64)
+		fraction, _ := strconv.ParseInt(match[3], 10, 64)
+		for i := len(match[3]); i < 9; i++ {
+			fraction *= 10
+		}
+		line.Timestamp = time.Duration(seconds)*time.Second + time.Duration(fraction)
+	}
+	line.Message = match[4]
+	return line
+}
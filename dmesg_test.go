@@ -0,0 +1,58 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseDmesgLine(t *testing.T) {
+	line := parseDmesgLine("<6>[    0.000000] Booting Linux on physical CPU 0x0", KernelLogLine{})
+	assert.Equal(t, KernelLogLine{Level: KernelInfo, Message: "Booting Linux on physical CPU 0x0"}, line)
+
+	line = parseDmesgLine("<12>[ 1234.567890] thermal thermal_zone3: critical temperature reached", line)
+	assert.Equal(t, KernelLogLine{
+		Timestamp: 1234*time.Second + 567890*time.Microsecond,
+		Level:     KernelWarning,
+		Message:   "thermal thermal_zone3: critical temperature reached",
+	}, line)
+
+	line = parseDmesgLine("  continued", line)
+	assert.Equal(t, KernelWarning, line.Level)
+	assert.Equal(t, 1234*time.Second+567890*time.Microsecond, line.Timestamp)
+	assert.Equal(t, "  continued", line.Message)
+
+	assert.Equal(t, "KernelErr", KernelErr.String())
+}
+
+func TestDmesg(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"id -u":                 "2000\n",
+			"dmesg -r":              "dmesg: klogctl: Operation not permitted\n",
+			"su 0 sh -c 'dmesg -r'": "<6>[    0.000000] Booting Linux\n<3>[    1.500000] init: failed\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	watcher, err := device.Dmesg(context.Background(), false)
+	require.NoError(t, err)
+	var lines []KernelLogLine
+	for line := range watcher.C() {
+		lines = append(lines, line)
+	}
+	assert.NoError(t, watcher.Err())
+	assert.Equal(t, []KernelLogLine{
+		{Level: KernelInfo, Message: "Booting Linux"},
+		{Timestamp: 1500 * time.Millisecond, Level: KernelErr, Message: "init: failed"},
+	}, lines)
+
+	s.ShellOutputs["su 0 sh -c 'dmesg -r'"] = "/system/bin/sh: su: not found\n"
+	_, err = device.Dmesg(context.Background(), false)
+	assert.True(t, HasErrCode(err, PermissionDenied))
+}
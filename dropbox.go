@@ -0,0 +1,146 @@
+package adb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Line dumpsys dropbox prints before each entry.
+const dropboxSeparator = "========================================"
+
+// Time format of the headers of dumpsys dropbox entries, in the device's time zone.
+const dropboxTimeFormat = "2006-01-02 15:04:05"
+
+// Matches the "2024-01-02 03:04:05 data_app_crash (text, 1234 bytes)" header of an entry.
+var dropboxHeaderPattern = regexp.MustCompile(
+	`^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d)(?:\.\d+)? (\S+) \(([^,)]*)(?:, (\d+) bytes)?\)`)
+
+// DropboxEntry is a problem recorded by the system's DropBoxManager, e.g. an app crash, ANR, or
+// StrictMode violation.
+type DropboxEntry struct {
+	Time time.Time
+	// Kind of problem and who had it, e.g. data_app_crash, system_app_anr, system_server_wtf, or
+	// data_app_strictmode.
+	Tag string
+	// How the entry is stored, e.g. "text", "compressed text", or "data". The payload of "data"
+	// entries isn't printed.
+	Type string
+	// Size of the stored payload, in bytes, before compression.
+	Size int
+	// Content of the entry, e.g. the process, its flags, and the stack trace of a crash.
+	Payload string
+}
+
+/*
+DropboxEntries returns the entries DropBoxManager recorded since since, oldest first. If tags
+are given, only entries with one of those tags are returned. The system only keeps a limited
+number of entries, so old entries may be gone.
+
+E.g. to check for crashes during a test run:
+
+	entries, err := device.DropboxEntries(start, "data_app_crash", "data_app_anr", "system_app_crash")
+	for _, entry := range entries {
+		fmt.Println(entry.Time, entry.Tag, entry.Payload)
+	}
+
+Corresponds to the command:
+
+	adb shell dumpsys dropbox --print <date> <time> [tag]
+*/
+func (c *Device) DropboxEntries(since time.Time, tags ...string) ([]DropboxEntry, error) {
+	// Entry times are printed in the device's time zone.
+	offset, err := c.runShellCommand("date +%z")
+	if err != nil {
+		return nil, wrapClientError(err, c, "DropboxEntries")
+	}
+	zone, err := parseZoneOffset(offset)
+	if err != nil {
+		return nil, wrapClientError(err, c, "DropboxEntries")
+	}
+
+	args := []string{"dumpsys", "dropbox", "--print"}
+	if !since.IsZero() {
+		since := since.In(zone)
+		args = append(args, since.Format("2006-01-02"), since.Format("15:04:05"))
+	}
+	if len(tags) == 1 {
+		// dumpsys only matches entries with all of the tags given, so more than one are
+		// filtered here.
+		args = append(args, tags[0])
+	}
+	output, err := c.runShellCommand(quoteShellArgs(args...))
+	if err == nil && strings.HasPrefix(output, "Can't find service") {
+		// Payloads are stack traces, which commandOutputError would mistake for errors.
+		err = errors.Errorf(errors.AdbError, "dumpsys dropbox failed: %s", strings.TrimSpace(output))
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "DropboxEntries")
+	}
+
+	entries, err := parseDropbox(output, zone)
+	if err != nil {
+		return nil, wrapClientError(err, c, "DropboxEntries")
+	}
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if entry.Time.Before(since.Truncate(time.Second)) || (len(tags) > 0 && !containsString(tags, entry.Tag)) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered, nil
+}
+
+// parseZoneOffset parses the "+0100" style offset printed by date +%z.
+func parseZoneOffset(output string) (*time.Location, error) {
+	offset := strings.TrimSpace(output)
+	t, err := time.Parse("-0700", offset)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "invalid time zone offset: %q", offset)
+	}
+	_, seconds := t.Zone()
+	return time.FixedZone(offset, seconds), nil
+}
+
+// parseDropbox parses the entries printed by dumpsys dropbox --print, whose times are in zone.
+func parseDropbox(output string, zone *time.Location) ([]DropboxEntry, error) {
+	var entries []DropboxEntry
+	var payload []string
+	inEntry := false
+	endEntry := func() {
+		if inEntry {
+			entries[len(entries)-1].Payload = strings.TrimRight(strings.Join(payload, "\n"), "\n")
+		}
+		payload, inEntry = nil, false
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == dropboxSeparator {
+			endEntry()
+			continue
+		}
+		if !inEntry {
+			match := dropboxHeaderPattern.FindStringSubmatch(line)
+			if match == nil {
+				// The summary before the first entry.
+				continue
+			}
+			entryTime, err := time.ParseInLocation(dropboxTimeFormat, match[1], zone)
+			if err != nil {
+				return nil, errors.WrapErrorf(err, errors.ParseError, "invalid dropbox entry header: %q", line)
+			}
+			size, _ := strconv.Atoi(match[4])
+			entries = append(entries, DropboxEntry{Time: entryTime, Tag: match[2], Type: match[3], Size: size})
+			inEntry = true
+			continue
+		}
+		payload = append(payload, line)
+	}
+	endEntry()
+	return entries, nil
+}
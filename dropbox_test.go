@@ -0,0 +1,96 @@
+package adb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testDropboxOutput = `Drop box contents: 3 entries
+Max entries: 1000
+
+========================================
+2024-01-02 03:04:05 data_app_crash (text, 120 bytes)
+Process: com.example
+Flags: 0x38c8bf46
+Package: com.example v1 (1.0)
+
+java.lang.IllegalStateException: boom
+	at com.example.MainActivity.onClick(MainActivity.java:42)
+
+========================================
+2024-01-02 03:05:00 SYSTEM_BOOT (data, 0 bytes)
+
+========================================
+2024-01-02 03:06:07 data_app_strictmode (compressed text, 512 bytes)
+Process: com.example
+android.os.strictmode.DiskReadViolation
+`
+
+func TestParseDropbox(t *testing.T) {
+	zone := time.FixedZone("+0100", 3600)
+	entries, err := parseDropbox(testDropboxOutput, zone)
+	require.NoError(t, err)
+	assert.Equal(t, []DropboxEntry{
+		{
+			Time: time.Date(2024, 1, 2, 3, 4, 5, 0, zone),
+			Tag:  "data_app_crash",
+			Type: "text",
+			Size: 120,
+			Payload: "Process: com.example\nFlags: 0x38c8bf46\nPackage: com.example v1 (1.0)\n\n" +
+				"java.lang.IllegalStateException: boom\n\tat com.example.MainActivity.onClick(MainActivity.java:42)",
+		},
+		{Time: time.Date(2024, 1, 2, 3, 5, 0, 0, zone), Tag: "SYSTEM_BOOT", Type: "data"},
+		{
+			Time:    time.Date(2024, 1, 2, 3, 6, 7, 0, zone),
+			Tag:     "data_app_strictmode",
+			Type:    "compressed text",
+			Size:    512,
+			Payload: "Process: com.example\nandroid.os.strictmode.DiskReadViolation",
+		},
+	}, entries)
+
+	entries, err = parseDropbox("Drop box contents: 0 entries\nMax entries: 1000\n", zone)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestParseZoneOffset(t *testing.T) {
+	zone, err := parseZoneOffset("-0530\n")
+	require.NoError(t, err)
+	_, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, zone).Zone()
+	assert.Equal(t, -(5*3600 + 30*60), offset)
+
+	_, err = parseZoneOffset("UTC")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestDropboxEntries(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"date +%z": "+0100\n",
+			"dumpsys dropbox --print 2024-01-02 03:05:00":                     testDropboxOutput,
+			"dumpsys dropbox --print 2024-01-02 03:05:00 data_app_strictmode": testDropboxOutput,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	since := time.Date(2024, 1, 2, 2, 5, 0, 0, time.UTC)
+
+	entries, err := device.DropboxEntries(since)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "SYSTEM_BOOT", entries[0].Tag)
+
+	entries, err = device.DropboxEntries(since, "data_app_crash", "data_app_strictmode")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "data_app_strictmode", entries[0].Tag)
+
+	entries, err = device.DropboxEntries(since, "data_app_strictmode")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,167 @@
+package adb
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+const (
+	emulatorSerialPrefix = "emulator-"
+
+	// The emulator console only listens on the loopback interface.
+	emulatorConsoleHost    = "127.0.0.1"
+	emulatorConsoleTimeout = 5 * time.Second
+
+	// File in the home directory holding the token required by the console since emulator 25.
+	emulatorConsoleAuthTokenFile = ".emulator_console_auth_token"
+)
+
+/*
+EmulatorPorts returns the console and adb ports of the emulator with serial emulator-<port>.
+The console listens on the port in the serial, and adbd on the next one.
+Returns false if serial isn't an emulator serial.
+*/
+func EmulatorPorts(serial string) (consolePort, adbPort int, ok bool) {
+	if !strings.HasPrefix(serial, emulatorSerialPrefix) {
+		return 0, 0, false
+	}
+	port, err := strconv.Atoi(strings.TrimPrefix(serial, emulatorSerialPrefix))
+	if err != nil || port <= 0 || port >= 65535 {
+		return 0, 0, false
+	}
+	return port, port + 1, true
+}
+
+// IsEmulator returns true if the device is an Android emulator, including emulators
+// connected over TCP whose serial isn't emulator-<port>.
+func (c *Device) IsEmulator() (bool, error) {
+	serial, err := c.serial()
+	if err != nil {
+		return false, wrapClientError(err, c, "IsEmulator")
+	}
+	if _, _, ok := EmulatorPorts(serial); ok {
+		return true, nil
+	}
+
+	for _, prop := range []string{"ro.kernel.qemu", "ro.boot.qemu"} {
+		value, err := c.GetProperty(prop)
+		if err != nil {
+			return false, err
+		}
+		if value == "1" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+/*
+AvdName returns the name of the Android Virtual Device the emulator is running.
+The name is read from system properties where the emulator sets them, otherwise from the
+emulator console.
+
+Corresponds to the console command:
+
+	avd name
+*/
+func (c *Device) AvdName() (string, error) {
+	for _, prop := range []string{"ro.boot.qemu.avd_name", "ro.kernel.qemu.avd_name"} {
+		name, err := c.GetProperty(prop)
+		if err != nil {
+			return "", err
+		}
+		if name != "" {
+			return name, nil
+		}
+	}
+
+	serial, err := c.serial()
+	if err != nil {
+		return "", wrapClientError(err, c, "AvdName")
+	}
+	consolePort, _, ok := EmulatorPorts(serial)
+	if !ok {
+		return "", wrapClientError(errors.Errorf(errors.AdbError, "%s is not an emulator", serial), c, "AvdName")
+	}
+
+	output, err := runEmulatorConsoleCommand(net.JoinHostPort(emulatorConsoleHost, strconv.Itoa(consolePort)),
+		readEmulatorConsoleAuthToken(), "avd name")
+	if err != nil {
+		return "", wrapClientError(err, c, "AvdName")
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// readEmulatorConsoleAuthToken returns the console auth token of the current user, or "" if
+// there is none.
+func readEmulatorConsoleAuthToken() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	token, err := ioutil.ReadFile(filepath.Join(home, emulatorConsoleAuthTokenFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(token))
+}
+
+// runEmulatorConsoleCommand connects to the emulator console at address, authenticates with
+// token if it's not empty, and returns the output of command.
+func runEmulatorConsoleCommand(address, token, command string) (string, error) {
+	conn, err := net.DialTimeout("tcp", address, emulatorConsoleTimeout)
+	if err != nil {
+		return "", errors.WrapErrorf(err, errors.ServerNotAvailable, "error dialing emulator console %s", address)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(emulatorConsoleTimeout))
+
+	reader := bufio.NewReader(conn)
+	// Skip the banner.
+	if _, err = readEmulatorConsoleReply(reader); err != nil {
+		return "", err
+	}
+	if token != "" {
+		if _, err = fmt.Fprintf(conn, "auth %s\n", token); err != nil {
+			return "", errors.WrapErrorf(err, errors.NetworkError, "error writing to emulator console")
+		}
+		if _, err = readEmulatorConsoleReply(reader); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err = fmt.Fprintf(conn, "%s\n", command); err != nil {
+		return "", errors.WrapErrorf(err, errors.NetworkError, "error writing to emulator console")
+	}
+	return readEmulatorConsoleReply(reader)
+}
+
+// readEmulatorConsoleReply reads lines up to the OK or KO line that ends each console reply.
+func readEmulatorConsoleReply(reader *bufio.Reader) (string, error) {
+	var reply strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", errors.WrapErrorf(err, errors.NetworkError, "error reading from emulator console")
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "OK":
+			return reply.String(), nil
+		case strings.HasPrefix(line, "KO"):
+			return "", errors.Errorf(errors.AdbError, "emulator console error: %s",
+				strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "KO"), ":")))
+		}
+		reply.WriteString(line)
+		reply.WriteString("\n")
+	}
+}
@@ -0,0 +1,75 @@
+package adb
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmulatorPorts(t *testing.T) {
+	for _, test := range []struct {
+		Serial      string
+		ConsolePort int
+		AdbPort     int
+		OK          bool
+	}{
+		{"emulator-5554", 5554, 5555, true},
+		{"emulator-5580", 5580, 5581, true},
+		{"emulator-", 0, 0, false},
+		{"127.0.0.1:5555", 0, 0, false},
+		{"R3CN30XXXX", 0, 0, false},
+	} {
+		consolePort, adbPort, ok := EmulatorPorts(test.Serial)
+		assert.Equal(t, test.ConsolePort, consolePort, test.Serial)
+		assert.Equal(t, test.AdbPort, adbPort, test.Serial)
+		assert.Equal(t, test.OK, ok, test.Serial)
+	}
+}
+
+func TestIsEmulatorFromSerial(t *testing.T) {
+	s := &MockServer{}
+	isEmulator, err := (&Adb{s}).Device(DeviceWithSerial("emulator-5554")).IsEmulator()
+	assert.NoError(t, err)
+	assert.True(t, isEmulator)
+	assert.Empty(t, s.Requests)
+}
+
+func TestRunEmulatorConsoleCommand(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var commands []string
+		reader := bufio.NewReader(conn)
+		fmt.Fprint(conn, "Android Console: Authentication required\r\nOK\r\n")
+		for _, reply := range []string{"Android Console: type 'help' for a list of commands\r\nOK\r\n", "Pixel_6_API_33\r\nOK\r\n"} {
+			line, _ := reader.ReadString('\n')
+			commands = append(commands, strings.TrimSpace(line))
+			fmt.Fprint(conn, reply)
+		}
+		received <- commands
+	}()
+
+	output, err := runEmulatorConsoleCommand(listener.Addr().String(), "secret", "avd name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Pixel_6_API_33\n", output)
+	assert.Equal(t, []string{"auth secret", "avd name"}, <-received)
+}
+
+func TestReadEmulatorConsoleReplyError(t *testing.T) {
+	_, err := readEmulatorConsoleReply(bufio.NewReader(strings.NewReader("KO: unknown command\r\n")))
+	assert.True(t, HasErrCode(err, AdbError))
+	assert.Contains(t, err.Error(), "unknown command")
+}
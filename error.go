@@ -2,6 +2,16 @@ package adb
 
 import "github.com/zach-klippenstein/goadb/internal/errors"
 
+/*
+Error is the type of all errors returned by this package. Use errors.As to get its code:
+
+	var adbErr *adb.Error
+	if errors.As(err, &adbErr) && adb.ErrCode(adbErr.Code) == adb.AdbError {
+		...
+	}
+*/
+type Error = errors.Err
+
 type ErrCode errors.ErrCode
 
 const (
@@ -19,6 +29,36 @@ const (
 	DeviceNotFound = ErrCode(errors.DeviceNotFound)
 	// Tried to perform an operation on a path that doesn't exist on the device.
 	FileNoExistError = ErrCode(errors.FileNoExistError)
+	// The server returned a "device offline" error.
+	DeviceOffline = ErrCode(errors.DeviceOffline)
+	// The server returned a "device unauthorized" error.
+	DeviceUnauthorized = ErrCode(errors.DeviceUnauthorized)
+	// A command on the device was denied access to a path.
+	PermissionDenied = ErrCode(errors.PermissionDenied)
+	// A command on the device tried to modify a path on a read-only file system.
+	ReadOnlyFileSystem = ErrCode(errors.ReadOnlyFileSystem)
+	// The server is too old to support a request.
+	UnsupportedServer = ErrCode(errors.UnsupportedServer)
+)
+
+// Sentinel errors matching every error with the corresponding ErrCode, for use with errors.Is.
+// E.g.
+//
+//	if errors.Is(err, adb.ErrDeviceNotFound) {
+//		...
+//	}
+//
+// ErrFileNotExist is also matched by os.ErrNotExist, and ErrPermissionDenied by os.ErrPermission.
+var (
+	ErrDeviceNotFound     error = &errors.Sentinel{Code: errors.DeviceNotFound, Message: "device not found"}
+	ErrDeviceOffline      error = &errors.Sentinel{Code: errors.DeviceOffline, Message: "device offline"}
+	ErrUnauthorized       error = &errors.Sentinel{Code: errors.DeviceUnauthorized, Message: "device unauthorized"}
+	ErrFileNotExist       error = &errors.Sentinel{Code: errors.FileNoExistError, Message: "file does not exist"}
+	ErrConnectionReset    error = &errors.Sentinel{Code: errors.ConnectionResetError, Message: "connection reset"}
+	ErrServerNotAvailable error = &errors.Sentinel{Code: errors.ServerNotAvailable, Message: "server not available"}
+	ErrPermissionDenied   error = &errors.Sentinel{Code: errors.PermissionDenied, Message: "permission denied"}
+	ErrReadOnlyFileSystem error = &errors.Sentinel{Code: errors.ReadOnlyFileSystem, Message: "read-only file system"}
+	ErrUnsupportedServer  error = &errors.Sentinel{Code: errors.UnsupportedServer, Message: "unsupported server"}
 )
 
 // HasErrCode returns true if err is an *errors.Err and err.Code == code.
@@ -0,0 +1,259 @@
+package adb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DefaultParallelism is the number of devices ForEachDevice operates on at once.
+const DefaultParallelism = 8
+
+// DeviceResult is the outcome of an operation run by ForEachDevice on one device.
+type DeviceResult struct {
+	Serial string
+	// From ServerConfig.Registry, empty if the device isn't in it.
+	Label string
+	// Returned by the operation, or ctx's error if ctx was done before it started.
+	Err error
+}
+
+// name returns the label of the device if it has one, else its serial.
+func (r DeviceResult) name() string {
+	if r.Label != "" {
+		return r.Label + " (" + r.Serial + ")"
+	}
+	return r.Serial
+}
+
+/*
+ForEachDevice runs fn concurrently on every attached device for which filter returns true, or
+every device if filter is nil, up to DefaultParallelism devices at a time.
+
+It returns the result for each device, in the order ListDevices returns them, and an error
+combining the errors of all the devices that failed. fn must be safe to call concurrently.
+
+E.g.
+
+	results, err := client.ForEachDevice(ctx, nil, func(ctx context.Context, device *adb.Device) error {
+		return device.InstallApp(ctx, "app.apk", true, true)
+	})
+*/
+func (c *Adb) ForEachDevice(ctx context.Context, filter func(*DeviceInfo) bool, fn func(context.Context, *Device) error) ([]DeviceResult, error) {
+	return c.ForEachDeviceParallel(ctx, DefaultParallelism, filter, fn)
+}
+
+// ForEachDeviceParallel is like ForEachDevice, but runs fn on up to parallelism devices at
+// a time.
+func (c *Adb) ForEachDeviceParallel(ctx context.Context, parallelism int, filter func(*DeviceInfo) bool, fn func(context.Context, *Device) error) ([]DeviceResult, error) {
+	if parallelism < 1 {
+		return nil, errors.AssertionErrorf("parallelism must be at least 1, got %d", parallelism)
+	}
+	results, err := c.matchingDevices(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallelism)
+	for i := range results {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			<-slots
+			results[i].Err = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *DeviceResult) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result.Err = fn(ctx, c.Device(DeviceWithSerial(result.Serial)))
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results, combineDeviceErrors(results)
+}
+
+// matchingDevices returns an empty result for each device for which filter returns true, or
+// every device if filter is nil.
+func (c *Adb) matchingDevices(filter func(*DeviceInfo) bool) ([]DeviceResult, error) {
+	devices, err := c.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+	var results []DeviceResult
+	for _, device := range devices {
+		if filter == nil || filter(device) {
+			results = append(results, DeviceResult{Serial: device.Serial, Label: device.Label})
+		}
+	}
+	return results, nil
+}
+
+// combineDeviceErrors returns an error combining the errors of results, or nil if none failed.
+func combineDeviceErrors(results []DeviceResult) error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, errors.WrapErrorf(result.Err, errors.CodeOf(result.Err), "%s", result.name()))
+		}
+	}
+	return errors.CombineErrs(fmt.Sprintf("%d of %d devices failed", len(errs), len(results)),
+		errors.AdbError, errs...)
+}
+
+// Size of the reads of an APK shared by InstallOnAll.
+const fanOutChunkSize = 256 * 1024
+
+// InstallResult is the outcome of installing an APK on one device with InstallOnAll.
+type InstallResult struct {
+	DeviceResult
+	// False if the install failed, or was skipped by InstallOptions.SkipSameVersion.
+	Installed bool
+	// How long the device took, including checking its installed version.
+	Duration time.Duration
+}
+
+/*
+InstallOnAll installs the APK at apk like InstallAppFile on every attached device for which
+filter returns true, or every device if filter is nil, all at once. The APK is only read once:
+each chunk is streamed to every device before the next is read, so the slowest device sets the
+pace, but a large APK isn't read from disk once per device.
+
+It returns the result for each device, in the order ListDevices returns them, and an error
+combining the errors of all the devices that failed.
+*/
+func (c *Adb) InstallOnAll(ctx context.Context, filter func(*DeviceInfo) bool, apk string, opts InstallOptions) ([]InstallResult, error) {
+	var info *ApkInfo
+	if opts.SkipSameVersion {
+		var err error
+		if info, err = ReadApkInfo(apk); err != nil {
+			return nil, wrapClientError(err, c, "InstallOnAll(%s)", apk)
+		}
+	}
+	file, err := os.Open(apk)
+	if err != nil {
+		return nil, wrapClientError(err, c, "InstallOnAll(%s)", apk)
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, wrapClientError(err, c, "InstallOnAll(%s)", apk)
+	}
+	devices, err := c.matchingDevices(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]InstallResult, len(devices))
+	readers := fanOut(ctx, file, len(devices))
+	var wg sync.WaitGroup
+	for i := range results {
+		results[i].DeviceResult = devices[i]
+		wg.Add(1)
+		go func(result *InstallResult, reader *io.PipeReader) {
+			defer wg.Done()
+			// Stop sending to the device once it's done, even if it didn't read the APK.
+			defer reader.Close()
+			start := time.Now()
+			device := c.Device(DeviceWithSerial(result.Serial))
+			result.Installed, result.Err = device.installIfChanged(reader, stat.Size(), info, opts)
+			result.Err = wrapClientError(result.Err, device, "InstallOnAll(%s)", apk)
+			if result.Err != nil && ctx.Err() != nil {
+				result.Err = ctx.Err()
+			}
+			result.Duration = time.Since(start)
+		}(&results[i], readers[i])
+	}
+	wg.Wait()
+
+	for i := range results {
+		devices[i].Err = results[i].Err
+	}
+	return results, combineDeviceErrors(devices)
+}
+
+// fanOut returns n readers that each read all of src, which is only read once. Each chunk
+// is written to every reader before the next is read; closing a reader drops it. The readers
+// fail with ctx's error if it's done first.
+func fanOut(ctx context.Context, src io.Reader, n int) []*io.PipeReader {
+	readers := make([]*io.PipeReader, n)
+	writers := make([]*io.PipeWriter, n)
+	for i := range readers {
+		readers[i], writers[i] = io.Pipe()
+	}
+
+	done := make(chan struct{})
+	all := append([]*io.PipeWriter(nil), writers...)
+	go func() {
+		select {
+		case <-ctx.Done():
+			for _, writer := range all {
+				writer.CloseWithError(ctx.Err())
+			}
+		case <-done:
+		}
+	}()
+
+	go func() {
+		defer close(done)
+		buf := make([]byte, fanOutChunkSize)
+		for {
+			n, err := src.Read(buf)
+			if n > 0 {
+				var wg sync.WaitGroup
+				for i, writer := range writers {
+					if writer == nil {
+						continue
+					}
+					wg.Add(1)
+					go func(i int, writer *io.PipeWriter) {
+						defer wg.Done()
+						if _, err := writer.Write(buf[:n]); err != nil {
+							writers[i] = nil
+						}
+					}(i, writer)
+				}
+				wg.Wait()
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				for _, writer := range writers {
+					if writer != nil {
+						writer.CloseWithError(err)
+					}
+				}
+				return
+			}
+		}
+	}()
+	return readers
+}
+
+/*
+UninstallFromAll uninstalls pkg, for the device's user, from every attached device for which
+filter returns true, or every device if filter is nil, like ForEachDevice.
+
+Corresponds to the command:
+
+	adb shell pm uninstall <package>
+*/
+func (c *Adb) UninstallFromAll(ctx context.Context, filter func(*DeviceInfo) bool, pkg string) ([]DeviceResult, error) {
+	return c.ForEachDevice(ctx, filter, func(ctx context.Context, device *Device) error {
+		return device.uninstallPackage(pkg)
+	})
+}
@@ -0,0 +1,115 @@
+package adb
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func newFanOutClient() *Adb {
+	return &Adb{&MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"a device model:A\nb device model:B\nc device model:A\nd device model:A\n"},
+	}}
+}
+
+func TestForEachDeviceBoundsParallelism(t *testing.T) {
+	var mu sync.Mutex
+	var running, maxRunning int
+	var serials []string
+
+	results, err := newFanOutClient().ForEachDeviceParallel(context.Background(), 2,
+		func(info *DeviceInfo) bool { return info.Model == "A" },
+		func(ctx context.Context, device *Device) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			serials = append(serials, device.descriptor.serial)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []DeviceResult{{Serial: "a"}, {Serial: "c"}, {Serial: "d"}}, results)
+	assert.ElementsMatch(t, []string{"a", "c", "d"}, serials)
+	assert.Equal(t, 2, maxRunning)
+}
+
+func TestForEachDeviceAggregatesErrors(t *testing.T) {
+	results, err := newFanOutClient().ForEachDevice(context.Background(), nil,
+		func(ctx context.Context, device *Device) error {
+			switch device.descriptor.serial {
+			case "b":
+				return errors.Errorf(errors.DeviceOffline, "offline")
+			case "d":
+				return errors.Errorf(errors.AdbError, "failed")
+			}
+			return nil
+		})
+	assert.Len(t, results, 4)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, HasErrCode(results[1].Err, DeviceOffline))
+	assert.Error(t, results[3].Err)
+
+	assert.EqualError(t, err, "AdbError: 2 of 4 devices failed")
+	assert.True(t, stderrors.Is(err, ErrDeviceOffline))
+	assert.Contains(t, ErrorWithCauseChain(err), "b")
+}
+
+func TestForEachDeviceContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := newFanOutClient().ForEachDeviceParallel(ctx, 1, nil,
+		func(ctx context.Context, device *Device) error {
+			cancel()
+			return nil
+		})
+	assert.NoError(t, results[0].Err)
+	for _, result := range results[1:] {
+		assert.Equal(t, context.Canceled, result.Err)
+	}
+	assert.True(t, stderrors.Is(err, context.Canceled))
+}
+
+func TestInstallOnAllMissingApk(t *testing.T) {
+	_, err := newFanOutClient().InstallOnAll(context.Background(), nil, "/nonexistent/app.apk", InstallOptions{})
+	assert.Contains(t, err.Error(), "InstallOnAll(/nonexistent/app.apk)")
+}
+
+func TestFanOut(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), fanOutChunkSize/4)
+	readers := fanOut(context.Background(), bytes.NewReader(data), 3)
+	// A dropped reader doesn't hold up the others.
+	readers[1].Close()
+
+	var wg sync.WaitGroup
+	for _, i := range []int{0, 2} {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			read, err := ioutil.ReadAll(readers[i])
+			assert.NoError(t, err)
+			assert.Equal(t, data, read)
+		}(i)
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	readers = fanOut(ctx, bytes.NewReader(data), 2)
+	cancel()
+	_, err := ioutil.ReadAll(readers[0])
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,50 @@
+package adb
+
+import "strings"
+
+// Features advertised by adbd, as returned by Features.
+const (
+	FeatureShellV2 = "shell_v2"
+	FeatureCmd     = "cmd"
+	FeatureAbb     = "abb"
+	FeatureAbbExec = "abb_exec"
+)
+
+/*
+Features returns the features advertised by the device's adbd and supported by the server,
+e.g. shell_v2, cmd, abb, abb_exec. Servers older than adb 1.0.33 don't know the features
+service, and fail with ErrUnsupportedServer.
+
+Corresponds to the command:
+
+	adb features
+*/
+func (c *Device) Features() ([]string, error) {
+	if err := requireServerVersion(c.server, serverVersionFeatures, "features"); err != nil {
+		return nil, wrapClientError(err, c, "Features")
+	}
+	attr, err := c.getAttribute("features")
+	if err != nil {
+		return nil, wrapClientError(err, c, "Features")
+	}
+	return parseFeatures(attr), nil
+}
+
+// HasFeature returns true if the device advertises feature.
+func (c *Device) HasFeature(feature string) (bool, error) {
+	features, err := c.Features()
+	if err != nil {
+		return false, err
+	}
+	return containsString(features, feature), nil
+}
+
+func parseFeatures(attr string) []string {
+	var features []string
+	for _, feature := range strings.Split(strings.TrimSpace(attr), ",") {
+		if feature != "" {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
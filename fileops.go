@@ -0,0 +1,105 @@
+package adb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+/*
+MkdirAll creates the directory path on the device, along with any missing parents, with
+permissions perm. It does nothing if path is already a directory.
+
+Corresponds to the command:
+
+	adb shell mkdir -p -m <perm> <path>
+*/
+func (c *Device) MkdirAll(path string, perm os.FileMode) error {
+	err := c.runFileCommand("mkdir", "-p", "-m", fmt.Sprintf("%o", perm.Perm()), path)
+	return wrapClientError(err, c, "MkdirAll(%s)", path)
+}
+
+// Remove removes the file or empty directory at path on the device.
+func (c *Device) Remove(path string) error {
+	quoted := quoteShellArg(path)
+	output, err := c.runShellCommand(fmt.Sprintf("if [ -d %s ]; then rmdir %s; else rm %s; fi", quoted, quoted, quoted))
+	if err == nil {
+		err = fileCommandError("rm", output)
+	}
+	return wrapClientError(err, c, "Remove(%s)", path)
+}
+
+/*
+RemoveAll removes path on the device and everything it contains. Like os.RemoveAll, it
+returns nil if path doesn't exist.
+
+Corresponds to the command:
+
+	adb shell rm -rf <path>
+*/
+func (c *Device) RemoveAll(path string) error {
+	return wrapClientError(c.runFileCommand("rm", "-rf", path), c, "RemoveAll(%s)", path)
+}
+
+/*
+Rename moves oldPath to newPath on the device, replacing newPath if it's a file.
+
+Corresponds to the command:
+
+	adb shell mv -f <oldPath> <newPath>
+*/
+func (c *Device) Rename(oldPath, newPath string) error {
+	return wrapClientError(c.runFileCommand("mv", "-f", oldPath, newPath), c, "Rename(%s, %s)", oldPath, newPath)
+}
+
+/*
+Copy copies the file or directory tree at src to dst on the device, without transferring
+it through the host.
+
+Corresponds to the command:
+
+	adb shell cp -R <src> <dst>
+*/
+func (c *Device) Copy(src, dst string) error {
+	return wrapClientError(c.runFileCommand("cp", "-R", src, dst), c, "Copy(%s, %s)", src, dst)
+}
+
+/*
+Chmod sets the permissions of path on the device to mode.
+
+Corresponds to the command:
+
+	adb shell chmod <mode> <path>
+*/
+func (c *Device) Chmod(path string, mode os.FileMode) error {
+	err := c.runFileCommand("chmod", fmt.Sprintf("%o", mode.Perm()), path)
+	return wrapClientError(err, c, "Chmod(%s)", path)
+}
+
+// runFileCommand runs a toybox file command, which prints nothing on success.
+func (c *Device) runFileCommand(cmd string, args ...string) error {
+	output, err := c.runShellCommand(quoteShellArgs(append([]string{cmd}, args...)...))
+	if err != nil {
+		return err
+	}
+	return fileCommandError(cmd, output)
+}
+
+// fileCommandError returns an error if cmd printed anything, with a code for the common failures,
+// e.g. "mkdir: '/system/foo': Read-only file system".
+func fileCommandError(cmd, output string) error {
+	output = strings.TrimSpace(output)
+	switch {
+	case output == "":
+		return nil
+	case strings.Contains(output, "No such file or directory"):
+		return errors.Errorf(errors.FileNoExistError, "%s", output)
+	case strings.Contains(output, "Permission denied"):
+		return errors.Errorf(errors.PermissionDenied, "%s", output)
+	case strings.Contains(output, "Read-only file system"):
+		return errors.Errorf(errors.ReadOnlyFileSystem, "%s", output)
+	}
+	return errors.Errorf(errors.AdbError, "%s failed: %s", cmd, output)
+}
@@ -0,0 +1,57 @@
+package adb
+
+import (
+	stderrors "errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestFileCommandError(t *testing.T) {
+	for _, test := range []struct {
+		Output string
+		Want   error
+	}{
+		{"mkdir: '/sdcard/a/b': No such file or directory\n", ErrFileNotExist},
+		{"rm: /data/foo: Permission denied\n", ErrPermissionDenied},
+		{"mkdir: '/system/foo': Read-only file system\n", ErrReadOnlyFileSystem},
+	} {
+		assert.True(t, stderrors.Is(fileCommandError("rm", test.Output), test.Want), "%q", test.Output)
+	}
+	assert.NoError(t, fileCommandError("rm", "\n"))
+	assert.True(t, HasErrCode(fileCommandError("cp", "cp: bad option -R"), AdbError))
+}
+
+func TestFileOperations(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	assert.NoError(t, device.MkdirAll("/sdcard/my files/a", 0755))
+	assert.NoError(t, device.RemoveAll("/sdcard/tmp"))
+	assert.NoError(t, device.Rename("/sdcard/a", "/sdcard/it's b"))
+	assert.NoError(t, device.Copy("/sdcard/a", "/sdcard/b"))
+	assert.NoError(t, device.Chmod("/sdcard/a", 0644))
+	assert.NoError(t, device.Remove("/sdcard/a b"))
+	assert.Equal(t, []string{
+		"host:transport-any", "shell:mkdir -p -m 755 '/sdcard/my files/a'",
+		"host:transport-any", "shell:rm -rf /sdcard/tmp",
+		"host:transport-any", `shell:mv -f /sdcard/a '/sdcard/it'\''s b'`,
+		"host:transport-any", "shell:cp -R /sdcard/a /sdcard/b",
+		"host:transport-any", "shell:chmod 644 /sdcard/a",
+		"host:transport-any", "shell:if [ -d '/sdcard/a b' ]; then rmdir '/sdcard/a b'; else rm '/sdcard/a b'; fi",
+	}, s.Requests)
+}
+
+func TestFileOperationErrors(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"chmod: /system/bin/sh: Read-only file system\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	err := device.Chmod("/system/bin/sh", 0777)
+	assert.True(t, stderrors.Is(err, ErrReadOnlyFileSystem))
+	assert.False(t, stderrors.Is(err, os.ErrPermission))
+}
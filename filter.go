@@ -0,0 +1,184 @@
+package adb
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+DeviceFilter selects devices by their properties and state. Zero fields match any device.
+
+Properties are read with getprop, only if the filter needs them, and only for devices whose
+serial and state match.
+
+E.g.
+
+	devices, err := client.ListDevicesMatching(adb.DeviceFilter{MinSDK: 30, ABI: "arm64-v8a"})
+*/
+type DeviceFilter struct {
+	// Matched case-insensitively against ro.product.model and ro.product.manufacturer.
+	Model        string
+	Manufacturer string
+	// Range of API levels, from ro.build.version.sdk. Both ends are inclusive.
+	MinSDK int
+	MaxSDK int
+	// ABI the device must support, e.g. arm64-v8a, from ro.product.cpu.abilist.
+	ABI string
+	// Matched against the serial, e.g. regexp.MustCompile(`^emulator-`).
+	Serial *regexp.Regexp
+	// Defaults to any state.
+	State DeviceState
+}
+
+// deviceProperties are the properties of a device DeviceFilter matches on.
+type deviceProperties struct {
+	SDK          int
+	ABIs         []string
+	Model        string
+	Manufacturer string
+}
+
+func (f *DeviceFilter) needsProperties() bool {
+	return f.Model != "" || f.Manufacturer != "" || f.MinSDK != 0 || f.MaxSDK != 0 || f.ABI != ""
+}
+
+// matches returns true if a device with serial, state, and props matches f. props is only
+// used, and may be nil, if f.needsProperties.
+func (f *DeviceFilter) matches(serial string, state DeviceState, props *deviceProperties) bool {
+	if !f.matchesListing(serial, state) {
+		return false
+	}
+	return !f.needsProperties() || f.matchesProperties(props)
+}
+
+// matchesListing returns true if a device with serial and state may match f, before reading
+// its properties.
+func (f *DeviceFilter) matchesListing(serial string, state DeviceState) bool {
+	if f.Serial != nil && !f.Serial.MatchString(serial) {
+		return false
+	}
+	return f.State == StateInvalid || f.State == state
+}
+
+func (f *DeviceFilter) matchesProperties(props *deviceProperties) bool {
+	if f.Model != "" && !strings.EqualFold(f.Model, props.Model) {
+		return false
+	}
+	if f.Manufacturer != "" && !strings.EqualFold(f.Manufacturer, props.Manufacturer) {
+		return false
+	}
+	if f.MinSDK != 0 && props.SDK < f.MinSDK {
+		return false
+	}
+	if f.MaxSDK != 0 && props.SDK > f.MaxSDK {
+		return false
+	}
+	if f.ABI != "" {
+		for _, abi := range props.ABIs {
+			if abi == f.ABI {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Match returns true if device matches f.
+func (f DeviceFilter) Match(device *Device) (bool, error) {
+	serial, err := device.serial()
+	if err != nil {
+		return false, err
+	}
+	state := StateInvalid
+	if f.State != StateInvalid {
+		if state, err = device.State(); err != nil {
+			return false, err
+		}
+	}
+	return f.matchDevice(device, serial, state)
+}
+
+// matchDevice is Match for a device whose serial and state are already known, e.g. listed.
+func (f DeviceFilter) matchDevice(device *Device, serial string, state DeviceState) (bool, error) {
+	if !f.matchesListing(serial, state) {
+		return false, nil
+	}
+	if !f.needsProperties() {
+		return true, nil
+	}
+	props, err := readDeviceProperties(device)
+	if err != nil {
+		return false, err
+	}
+	return f.matchesProperties(props), nil
+}
+
+/*
+Func returns a filter for ForEachDevice that matches devices attached to client against f.
+Devices whose properties can't be read don't match.
+
+E.g.
+
+	client.ForEachDevice(ctx, adb.DeviceFilter{Manufacturer: "Google"}.Func(client), install)
+*/
+func (f DeviceFilter) Func(client *Adb) func(*DeviceInfo) bool {
+	return func(info *DeviceInfo) bool {
+		match, err := f.Match(client.Device(DeviceWithSerial(info.Serial)))
+		return err == nil && match
+	}
+}
+
+// ListDevicesMatching returns the connected devices that match filter.
+func (c *Adb) ListDevicesMatching(filter DeviceFilter) ([]*DeviceInfo, error) {
+	devices, err := c.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []*DeviceInfo
+	for _, info := range devices {
+		// The properties of offline and unauthorized devices can't be read, so they can't match.
+		if filter.needsProperties() && info.State != StateOnline {
+			continue
+		}
+		match, err := filter.matchDevice(c.Device(DeviceWithSerial(info.Serial)), info.Serial, info.State)
+		if err != nil {
+			return nil, wrapClientError(err, c, "ListDevicesMatching")
+		}
+		if match {
+			matching = append(matching, info)
+		}
+	}
+	return matching, nil
+}
+
+func readDeviceProperties(device *Device) (*deviceProperties, error) {
+	sdk, err := device.SDKVersion()
+	if err != nil {
+		return nil, err
+	}
+	abis, err := device.GetProperty("ro.product.cpu.abilist")
+	if err != nil {
+		return nil, err
+	}
+	if abis == "" {
+		// Devices before API 21 only report their primary ABI.
+		if abis, err = device.GetProperty("ro.product.cpu.abi"); err != nil {
+			return nil, err
+		}
+	}
+	props := &deviceProperties{SDK: sdk}
+	if props.Model, err = device.GetProperty("ro.product.model"); err != nil {
+		return nil, err
+	}
+	if props.Manufacturer, err = device.GetProperty("ro.product.manufacturer"); err != nil {
+		return nil, err
+	}
+	for _, abi := range strings.Split(abis, ",") {
+		if abi = strings.TrimSpace(abi); abi != "" {
+			props.ABIs = append(props.ABIs, abi)
+		}
+	}
+	return props, nil
+}
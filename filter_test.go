@@ -0,0 +1,80 @@
+package adb
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestDeviceFilterMatches(t *testing.T) {
+	props := &deviceProperties{SDK: 30, ABIs: []string{"arm64-v8a", "armeabi-v7a"}, Model: "Pixel 6", Manufacturer: "Google"}
+	for _, test := range []struct {
+		Filter DeviceFilter
+		Want   bool
+	}{
+		{DeviceFilter{}, true},
+		{DeviceFilter{Model: "pixel 6", Manufacturer: "GOOGLE"}, true},
+		{DeviceFilter{Model: "Pixel 7"}, false},
+		{DeviceFilter{MinSDK: 30, MaxSDK: 30}, true},
+		{DeviceFilter{MinSDK: 31}, false},
+		{DeviceFilter{MaxSDK: 29}, false},
+		{DeviceFilter{ABI: "armeabi-v7a"}, true},
+		{DeviceFilter{ABI: "x86_64"}, false},
+		{DeviceFilter{Serial: regexp.MustCompile(`^emulator-`)}, true},
+		{DeviceFilter{Serial: regexp.MustCompile(`^usb`)}, false},
+		{DeviceFilter{State: StateOnline}, true},
+		{DeviceFilter{State: StateOffline}, false},
+	} {
+		assert.Equal(t, test.Want, test.Filter.matches("emulator-5554", StateOnline, props), "%+v", test.Filter)
+	}
+}
+
+func TestListDevicesMatching(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"0123abcd device\nemulator-5554 device\n"},
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk":    "30\n",
+			"getprop ro.product.cpu.abilist":  "x86_64\n",
+			"getprop ro.product.model":        "sdk_gphone64\n",
+			"getprop ro.product.manufacturer": "Google\n",
+		},
+	}
+	client := &Adb{s}
+
+	devices, err := client.ListDevicesMatching(DeviceFilter{Serial: regexp.MustCompile(`^emulator-`)})
+	assert.NoError(t, err)
+	assert.Equal(t, []*DeviceInfo{{Serial: "emulator-5554", State: StateOnline}}, devices)
+	// Properties aren't read unless the filter needs them.
+	assert.Equal(t, []string{"host:devices-l"}, s.Requests)
+
+	s.nextMsgIndex = 0
+	devices, err = client.ListDevicesMatching(DeviceFilter{ABI: "x86_64", MinSDK: 29})
+	assert.NoError(t, err)
+	assert.Len(t, devices, 2)
+}
+
+func TestListDevicesMatchingSkipsUnreadableDevices(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"0123abcd offline\n4567efgh unauthorized\nemulator-5554 device\nemulator-5556 device\n"},
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk":    "30\n",
+			"getprop ro.product.cpu.abilist":  "x86_64\n",
+			"getprop ro.product.model":        "sdk_gphone64\n",
+			"getprop ro.product.manufacturer": "Google\n",
+		},
+	}
+	client := &Adb{s}
+
+	devices, err := client.ListDevicesMatching(DeviceFilter{Serial: regexp.MustCompile(`-5554$`), MinSDK: 29})
+	assert.NoError(t, err)
+	assert.Equal(t, []*DeviceInfo{{Serial: "emulator-5554", State: StateOnline}}, devices)
+	for _, req := range s.Requests {
+		assert.NotContains(t, req, "0123abcd")
+		assert.NotContains(t, req, "4567efgh")
+		assert.NotContains(t, req, "emulator-5556")
+	}
+}
@@ -0,0 +1,234 @@
+package adb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// ForwardEntry is a forward from a socket spec on the host to one on a device.
+type ForwardEntry struct {
+	// Serial of the device.
+	Serial string
+	// Socket spec on the host, e.g. tcp:8080.
+	Local string
+	// Socket spec on the device, e.g. localabstract:chrome_devtools_remote.
+	Remote string
+}
+
+// LocalTCPPort returns the port of a tcp: local spec, or false if Local isn't a TCP port.
+func (f ForwardEntry) LocalTCPPort() (int, bool) {
+	return parseTCPSpec(f.Local)
+}
+
+// RemoteTCPPort returns the port of a tcp: remote spec, or false if Remote isn't a TCP port.
+func (f ForwardEntry) RemoteTCPPort() (int, bool) {
+	return parseTCPSpec(f.Remote)
+}
+
+/*
+Forward forwards connections to local on the host to remote on the device. Both are socket
+specs, e.g. tcp:8080, localabstract:chrome_devtools_remote, or jdwp:<pid> for remote.
+
+If local is tcp:0, the server picks a free port, which is returned. Otherwise the returned
+string is empty.
+
+Corresponds to the command:
+
+	adb forward <local> <remote>
+*/
+func (c *Device) Forward(local, remote string) (string, error) {
+	port, err := c.forward(local, remote)
+	return port, wrapClientError(err, c, "Forward(%s, %s)", local, remote)
+}
+
+/*
+ForwardToFreePort forwards a local TCP port picked by the server to remote on the device,
+and returns the port. Unlike hard-coded ports, this never collides with other forwards.
+
+Corresponds to the command:
+
+	adb forward tcp:0 <remote>
+*/
+func (c *Device) ForwardToFreePort(remote string) (int, error) {
+	resp, err := c.forward("tcp:0", remote)
+	if err != nil {
+		return 0, wrapClientError(err, c, "ForwardToFreePort(%s)", remote)
+	}
+	port, err := strconv.Atoi(resp)
+	if err != nil {
+		return 0, wrapClientError(errors.WrapErrorf(err, errors.ParseError, "invalid allocated port: %q", resp),
+			c, "ForwardToFreePort(%s)", remote)
+	}
+	return port, nil
+}
+
+func (c *Device) forward(local, remote string) (string, error) {
+	if isBlank(local) || isBlank(remote) || strings.Contains(local, ";") {
+		return "", errors.AssertionErrorf("invalid forward spec: %q -> %q", local, remote)
+	}
+
+	conn, err := c.forwardRequest(fmt.Sprintf("forward:%s;%s", local, remote))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if local != "tcp:0" {
+		return "", nil
+	}
+
+	// The allocated port follows as a length-prefixed string.
+	port, err := conn.ReadMessage()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(port)), nil
+}
+
+/*
+RemoveForward removes the forward listening on local.
+
+Corresponds to the command:
+
+	adb forward --remove <local>
+*/
+func (c *Device) RemoveForward(local string) error {
+	conn, err := c.forwardRequest("killforward:" + local)
+	if err != nil {
+		return wrapClientError(err, c, "RemoveForward(%s)", local)
+	}
+	return wrapClientError(conn.Close(), c, "RemoveForward(%s)", local)
+}
+
+/*
+RemoveAllForwards removes all the forwards to this device. Forwards to other devices are kept,
+unlike adb forward --remove-all, so each forward is removed individually.
+
+Corresponds to the command:
+
+	adb forward --remove <local>
+*/
+func (c *Device) RemoveAllForwards() error {
+	forwards, err := c.ListForwards()
+	if err != nil {
+		return err
+	}
+	for _, forward := range forwards {
+		if err := c.RemoveForward(forward.Local); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+ListForwards returns the forwards to this device.
+
+Corresponds to the command:
+
+	adb forward --list
+*/
+func (c *Device) ListForwards() ([]ForwardEntry, error) {
+	serial, err := c.serial()
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListForwards")
+	}
+
+	resp, err := roundTripSingleResponse(c.server, c.descriptor.getHostPrefix()+":list-forward")
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListForwards")
+	}
+	forwards, err := parseForwardList(string(resp))
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListForwards")
+	}
+	// The server lists the forwards of all devices.
+	return FilterForwards(forwards, func(f ForwardEntry) bool { return f.Serial == serial }), nil
+}
+
+// FilterForwards returns the entries of forwards for which keep returns true.
+func FilterForwards(forwards []ForwardEntry, keep func(ForwardEntry) bool) []ForwardEntry {
+	var filtered []ForwardEntry
+	for _, forward := range forwards {
+		if keep(forward) {
+			filtered = append(filtered, forward)
+		}
+	}
+	return filtered
+}
+
+// FindForwardByLocal returns the entry of forwards listening on local, if any.
+func FindForwardByLocal(forwards []ForwardEntry, local string) (ForwardEntry, bool) {
+	for _, forward := range forwards {
+		if forward.Local == local {
+			return forward, true
+		}
+	}
+	return ForwardEntry{}, false
+}
+
+// FindForwardsByRemote returns the entries of forwards that connect to remote.
+func FindForwardsByRemote(forwards []ForwardEntry, remote string) []ForwardEntry {
+	return FilterForwards(forwards, func(f ForwardEntry) bool { return f.Remote == remote })
+}
+
+// parseForwardList parses "<serial> <local> <remote>" lines.
+func parseForwardList(list string) ([]ForwardEntry, error) {
+	var forwards []ForwardEntry
+	for _, line := range strings.Split(list, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, errors.Errorf(errors.ParseError, "invalid forward: %q", line)
+		}
+		forwards = append(forwards, ForwardEntry{Serial: fields[0], Local: fields[1], Remote: fields[2]})
+	}
+	return forwards, nil
+}
+
+func parseTCPSpec(spec string) (int, bool) {
+	if !strings.HasPrefix(spec, "tcp:") {
+		return 0, false
+	}
+	port, err := strconv.Atoi(strings.TrimPrefix(spec, "tcp:"))
+	return port, err == nil
+}
+
+// serial returns the serial of the device, only asking the server if the descriptor doesn't
+// include it.
+func (c *Device) serial() (string, error) {
+	if c.descriptor.descriptorType == DeviceSerial {
+		return c.descriptor.serial, nil
+	}
+	return c.Serial()
+}
+
+// forwardRequest sends the forward service request (e.g. forward:<local>;<remote> or
+// killforward:<local>) for the device, and returns the connection once the server confirms it.
+func (c *Device) forwardRequest(service string) (*wire.Conn, error) {
+	conn, err := c.server.Dial()
+	if err != nil {
+		return nil, err
+	}
+
+	req := fmt.Sprintf("%s:%s", c.descriptor.getHostPrefix(), service)
+	if err = conn.SendMessage([]byte(req)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The server replies with one status for the request, and another once the listener is
+	// updated.
+	for i := 0; i < 2; i++ {
+		if _, err = conn.ReadStatus(req); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
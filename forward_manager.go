@@ -0,0 +1,246 @@
+package adb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// ForwardRule is a forward or reverse forward that a ForwardManager keeps established.
+type ForwardRule struct {
+	// Serial of the device.
+	Serial string
+	// If true, connections to Remote on the device are forwarded to Local on the host.
+	// Otherwise connections to Local on the host are forwarded to Remote on the device.
+	Reverse bool
+	// Socket specs on the host and device, see Device.Forward and Device.Reverse.
+	Local  string
+	Remote string
+}
+
+// ForwardStatus is reported by a ForwardManager each time it establishes, or loses, a rule.
+type ForwardStatus struct {
+	Rule ForwardRule
+	// True if the forward is currently established.
+	Active bool
+	// Port allocated for a tcp:0 spec, empty otherwise. Ports are reallocated each time
+	// the rule is re-established.
+	Port string
+	// Set if establishing the rule failed.
+	Err error
+}
+
+/*
+ForwardManager owns a set of forwards and reverse forwards, and re-establishes them when
+their device reconnects. adb drops all of a device's forwards when it disconnects, so
+long-running hosts would otherwise have to track this themselves.
+
+E.g.
+
+	manager := client.NewForwardManager(func(status adb.ForwardStatus) {
+		log.Printf("%+v", status)
+	})
+	manager.AddForward("emulator-5554", "tcp:0", "localabstract:chrome_devtools_remote")
+	go manager.Run(ctx)
+
+Reverse forwards can also be served in-process with AddReverseHandler, letting apps on the
+device call into Go services on the host.
+*/
+type ForwardManager struct {
+	client   *Adb
+	onStatus func(ForwardStatus)
+
+	mu    sync.Mutex
+	rules []ForwardRule
+	// Host listeners of the rules added by AddReverseHandler.
+	listeners map[ForwardRule]net.Listener
+	// Ports allocated for the tcp:0 specs of established rules, which removing them must use.
+	ports map[ForwardRule]string
+}
+
+// NewForwardManager returns a ForwardManager that calls onStatus, if not nil, each time the
+// status of a rule changes. onStatus is called synchronously and must not block.
+func (c *Adb) NewForwardManager(onStatus func(ForwardStatus)) *ForwardManager {
+	return &ForwardManager{client: c, onStatus: onStatus}
+}
+
+// AddForward adds a rule forwarding local on the host to remote on the device with serial,
+// and tries to establish it. The rule is kept, and retried on reconnect, even if that fails.
+func (m *ForwardManager) AddForward(serial, local, remote string) error {
+	return m.add(ForwardRule{Serial: serial, Local: local, Remote: remote})
+}
+
+// AddReverse adds a rule forwarding remote on the device with serial to local on the host,
+// and tries to establish it. The rule is kept, and retried on reconnect, even if that fails.
+func (m *ForwardManager) AddReverse(serial, remote, local string) error {
+	return m.add(ForwardRule{Serial: serial, Reverse: true, Local: local, Remote: remote})
+}
+
+/*
+AddReverseHandler adds a rule forwarding remote on the device with serial to handler, and tries
+to establish it. The rule is kept, and retried on reconnect, even if that fails.
+
+Each connection made to remote on the device is passed to handler in a new goroutine, and closed
+when handler returns. The connections come from the adb server, to a port the manager listens on
+on 127.0.0.1, so the server must run on this host. The port stays open until the returned rule
+is removed, or the manager is closed.
+
+E.g. to serve net/rpc to an agent on the device connecting to localabstract:agent:
+
+	rule, err := manager.AddReverseHandler("emulator-5554", "localabstract:agent", func(conn net.Conn) {
+		rpcServer.ServeConn(conn)
+	})
+*/
+func (m *ForwardManager) AddReverseHandler(serial, remote string, handler func(net.Conn)) (ForwardRule, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return ForwardRule{}, errors.WrapErrorf(err, errors.NetworkError, "error listening for reverse forward of %s", remote)
+	}
+	rule := ForwardRule{
+		Serial:  serial,
+		Reverse: true,
+		Local:   fmt.Sprintf("tcp:%d", listener.Addr().(*net.TCPAddr).Port),
+		Remote:  remote,
+	}
+
+	m.mu.Lock()
+	if m.listeners == nil {
+		m.listeners = map[ForwardRule]net.Listener{}
+	}
+	m.listeners[rule] = listener
+	m.mu.Unlock()
+	go serveReverse(listener, handler)
+
+	return rule, m.add(rule)
+}
+
+func serveReverse(listener net.Listener, handler func(net.Conn)) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			handler(conn)
+		}()
+	}
+}
+
+// Remove removes rule and tears down its forward, if the device is connected. If the rule was
+// added by AddReverseHandler, its host listener is closed too.
+func (m *ForwardManager) Remove(rule ForwardRule) error {
+	m.mu.Lock()
+	for i, r := range m.rules {
+		if r == rule {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			break
+		}
+	}
+	if listener, ok := m.listeners[rule]; ok {
+		listener.Close()
+		delete(m.listeners, rule)
+	}
+	port := m.ports[rule]
+	delete(m.ports, rule)
+	m.mu.Unlock()
+
+	device := m.client.Device(DeviceWithSerial(rule.Serial))
+	if rule.Reverse {
+		return device.RemoveReverse(allocatedSpec(rule.Remote, port))
+	}
+	return device.RemoveForward(allocatedSpec(rule.Local, port))
+}
+
+// allocatedSpec returns the spec of the port allocated for spec, if any.
+func allocatedSpec(spec, port string) string {
+	if port == "" {
+		return spec
+	}
+	return "tcp:" + port
+}
+
+// Close removes every rule, closing the host listeners of AddReverseHandler rules, and returns
+// the first error tearing down a forward.
+func (m *ForwardManager) Close() error {
+	var firstErr error
+	for _, rule := range m.Rules() {
+		if err := m.Remove(rule); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Rules returns the rules currently owned by the manager.
+func (m *ForwardManager) Rules() []ForwardRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ForwardRule{}, m.rules...)
+}
+
+// Run watches for devices reconnecting and re-establishes their rules, until ctx is done
+// or the device watcher fails.
+func (m *ForwardManager) Run(ctx context.Context) error {
+	watcher := m.client.NewDeviceWatcherWithCtx(ctx)
+	for event := range watcher.C() {
+		m.handleEvent(event)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return watcher.Err()
+}
+
+func (m *ForwardManager) add(rule ForwardRule) error {
+	m.mu.Lock()
+	m.rules = append(m.rules, rule)
+	m.mu.Unlock()
+	return m.establish(rule)
+}
+
+func (m *ForwardManager) handleEvent(event DeviceStateChangedEvent) {
+	if !event.CameOnline() && !event.WentOffline() {
+		return
+	}
+	for _, rule := range m.Rules() {
+		if rule.Serial != event.Serial {
+			continue
+		}
+		if event.CameOnline() {
+			m.establish(rule)
+		} else {
+			m.report(ForwardStatus{Rule: rule})
+		}
+	}
+}
+
+func (m *ForwardManager) establish(rule ForwardRule) error {
+	device := m.client.Device(DeviceWithSerial(rule.Serial))
+	var port string
+	var err error
+	if rule.Reverse {
+		port, err = device.Reverse(rule.Remote, rule.Local)
+	} else {
+		port, err = device.Forward(rule.Local, rule.Remote)
+	}
+	m.mu.Lock()
+	if port != "" && err == nil {
+		if m.ports == nil {
+			m.ports = map[ForwardRule]string{}
+		}
+		m.ports[rule] = port
+	}
+	m.mu.Unlock()
+	m.report(ForwardStatus{Rule: rule, Active: err == nil, Port: port, Err: err})
+	return err
+}
+
+func (m *ForwardManager) report(status ForwardStatus) {
+	if m.onStatus != nil {
+		m.onStatus(status)
+	}
+}
@@ -0,0 +1,88 @@
+package adb
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestForwardManagerReestablishesOnReconnect(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	var statuses []ForwardStatus
+	manager := (&Adb{s}).NewForwardManager(func(status ForwardStatus) {
+		statuses = append(statuses, status)
+	})
+
+	assert.NoError(t, manager.AddForward("serial", "tcp:8080", "tcp:80"))
+	assert.NoError(t, manager.AddReverse("serial", "tcp:8081", "tcp:9000"))
+	assert.NoError(t, manager.AddForward("other", "tcp:8082", "tcp:80"))
+
+	forward := ForwardRule{Serial: "serial", Local: "tcp:8080", Remote: "tcp:80"}
+	reverse := ForwardRule{Serial: "serial", Reverse: true, Local: "tcp:9000", Remote: "tcp:8081"}
+	s.Requests = nil
+	statuses = nil
+
+	manager.handleEvent(DeviceStateChangedEvent{"serial", StateOnline, StateDisconnected})
+	assert.Empty(t, s.Requests)
+	assert.Equal(t, []ForwardStatus{{Rule: forward}, {Rule: reverse}}, statuses)
+
+	statuses = nil
+	manager.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	assert.Equal(t, []string{
+		"host-serial:serial:forward:tcp:8080;tcp:80",
+		"host:transport:serial", "reverse:forward:tcp:8081;tcp:9000",
+	}, s.Requests)
+	assert.Equal(t, []ForwardStatus{{Rule: forward, Active: true}, {Rule: reverse, Active: true}}, statuses)
+}
+
+func TestForwardManagerRemove(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	manager := (&Adb{s}).NewForwardManager(nil)
+
+	assert.NoError(t, manager.AddForward("serial", "tcp:8080", "tcp:80"))
+	assert.NoError(t, manager.Remove(ForwardRule{Serial: "serial", Local: "tcp:8080", Remote: "tcp:80"}))
+	assert.Empty(t, manager.Rules())
+	assert.Equal(t, "host-serial:serial:killforward:tcp:8080", s.Requests[1])
+}
+
+func TestForwardManagerRemoveAllocatedPort(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess, Messages: []string{"41237"}}
+	manager := (&Adb{s}).NewForwardManager(nil)
+
+	assert.NoError(t, manager.AddForward("serial", "tcp:0", "localabstract:chrome_devtools_remote"))
+	assert.NoError(t, manager.Close())
+	assert.Equal(t, "host-serial:serial:killforward:tcp:41237", s.Requests[len(s.Requests)-1])
+}
+
+func TestForwardManagerReverseHandler(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	manager := (&Adb{s}).NewForwardManager(nil)
+
+	rule, err := manager.AddReverseHandler("serial", "localabstract:agent", func(conn net.Conn) {
+		io.WriteString(conn, "hello from the host")
+	})
+	require.NoError(t, err)
+	assert.True(t, rule.Reverse)
+	assert.Equal(t, "reverse:forward:localabstract:agent;"+rule.Local, s.Requests[1])
+
+	// The adb server connects to the local port when the device connects to remote.
+	address := "127.0.0.1:" + strings.TrimPrefix(rule.Local, "tcp:")
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(conn)
+	conn.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello from the host", string(data))
+
+	assert.NoError(t, manager.Close())
+	assert.Empty(t, manager.Rules())
+	assert.Equal(t, "reverse:killforward:localabstract:agent", s.Requests[len(s.Requests)-1])
+	_, err = net.Dial("tcp", address)
+	assert.Error(t, err)
+}
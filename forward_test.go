@@ -0,0 +1,110 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestForward(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	port, err := device.Forward("tcp:8080", "localabstract:chrome_devtools_remote")
+	assert.NoError(t, err)
+	assert.Equal(t, "", port)
+	assert.Equal(t, []string{"host-serial:serial:forward:tcp:8080;localabstract:chrome_devtools_remote"}, s.Requests)
+}
+
+func TestForwardToFreePort(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"41237"},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	port, err := device.ForwardToFreePort("tcp:8080")
+	assert.NoError(t, err)
+	assert.Equal(t, 41237, port)
+	assert.Equal(t, []string{"host-serial:serial:forward:tcp:0;tcp:8080"}, s.Requests)
+}
+
+func TestForwardInvalidSpec(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	_, err := device.Forward("tcp:1;tcp:2", "tcp:8080")
+	assert.True(t, HasErrCode(err, AssertionError))
+	assert.Empty(t, s.Requests)
+}
+
+func TestParseForwardList(t *testing.T) {
+	forwards, err := parseForwardList("emulator-5554 tcp:8080 tcp:80\nR3CN tcp:9222 localabstract:chrome_devtools_remote\n")
+	assert.NoError(t, err)
+	assert.Equal(t, []ForwardEntry{
+		{Serial: "emulator-5554", Local: "tcp:8080", Remote: "tcp:80"},
+		{Serial: "R3CN", Local: "tcp:9222", Remote: "localabstract:chrome_devtools_remote"},
+	}, forwards)
+
+	port, ok := forwards[0].LocalTCPPort()
+	assert.True(t, ok)
+	assert.Equal(t, 8080, port)
+	_, ok = forwards[1].RemoteTCPPort()
+	assert.False(t, ok)
+
+	_, err = parseForwardList("emulator-5554 tcp:8080\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestFindForwards(t *testing.T) {
+	forwards := []ForwardEntry{
+		{Serial: "a", Local: "tcp:1", Remote: "tcp:80"},
+		{Serial: "a", Local: "tcp:2", Remote: "tcp:80"},
+		{Serial: "a", Local: "tcp:3", Remote: "tcp:443"},
+	}
+
+	forward, ok := FindForwardByLocal(forwards, "tcp:3")
+	assert.True(t, ok)
+	assert.Equal(t, "tcp:443", forward.Remote)
+	_, ok = FindForwardByLocal(forwards, "tcp:4")
+	assert.False(t, ok)
+
+	assert.Equal(t, forwards[:2], FindForwardsByRemote(forwards, "tcp:80"))
+}
+
+func TestDeviceListForwards(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"serial tcp:8080 tcp:80\nother tcp:8081 tcp:80\n"},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	forwards, err := device.ListForwards()
+	assert.NoError(t, err)
+	assert.Equal(t, []ForwardEntry{{Serial: "serial", Local: "tcp:8080", Remote: "tcp:80"}}, forwards)
+	assert.Equal(t, []string{"host-serial:serial:list-forward"}, s.Requests)
+}
+
+func TestRemoveForward(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	assert.NoError(t, device.RemoveForward("tcp:8080"))
+	assert.Equal(t, []string{"host-serial:serial:killforward:tcp:8080"}, s.Requests)
+}
+
+func TestRemoveAllForwardsKeepsOtherDevices(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"serial tcp:8080 tcp:80\nother tcp:8081 tcp:80\nserial tcp:9222 localabstract:devtools\n"},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	assert.NoError(t, device.RemoveAllForwards())
+	assert.Equal(t, []string{
+		"host-serial:serial:list-forward",
+		"host-serial:serial:killforward:tcp:8080",
+		"host-serial:serial:killforward:tcp:9222",
+	}, s.Requests)
+}
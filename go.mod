@@ -6,5 +6,7 @@ require (
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
 	github.com/alecthomas/units v0.0.0-20201120081800-1786d5ef83d4 // indirect
 	github.com/cheggaaa/pb v1.0.29
+	github.com/stretchr/testify v1.4.0
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 )
@@ -0,0 +1,204 @@
+package adb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DefaultGPUSampleInterval is the time between GPUSampler samples, unless another interval is
+// passed to SampleGPU.
+const DefaultGPUSampleInterval = time.Second
+
+// GPUSource is a file a device's GPU driver reports its utilization in.
+type GPUSource struct {
+	// GPU family, adreno, mali, or mediatek.
+	Vendor string
+	Path   string
+
+	parse func(string) (float64, error)
+}
+
+// gpuSources are the utilization files of the drivers, the preferred ones for each vendor first.
+var gpuSources = []GPUSource{
+	{Vendor: "adreno", Path: "/sys/class/kgsl/kgsl-3d0/gpu_busy_percentage", parse: parseGPUPercentage},
+	{Vendor: "adreno", Path: "/sys/class/kgsl/kgsl-3d0/gpubusy", parse: parseKGSLGPUBusy},
+	{Vendor: "mali", Path: "/sys/kernel/gpu/gpu_busy", parse: parseGPUPercentage},
+	{Vendor: "mali", Path: "/sys/class/misc/mali0/device/utilization", parse: parseGPUPercentage},
+	{Vendor: "mali", Path: "/sys/devices/platform/mali.0/utilization", parse: parseGPUPercentage},
+	{Vendor: "mediatek", Path: "/sys/kernel/ged/hal/gpu_utilization", parse: parseGPUPercentage},
+}
+
+// GPUSample is the utilization of a device's GPU at a point in time.
+type GPUSample struct {
+	Time time.Time
+	// Percentage of time the GPU was busy, over the driver's last sampling window.
+	Busy float64
+}
+
+/*
+GPUSource returns the first GPU utilization file the shell user can read, e.g.
+/sys/class/kgsl/kgsl-3d0/gpu_busy_percentage on Adreno GPUs, or an AdbError if the device has
+none. Drivers differ by vendor, and many builds only let root read them, so not every device
+supports GPU sampling.
+*/
+func (c *Device) GPUSource() (*GPUSource, error) {
+	source, err := c.gpuSource()
+	return source, wrapClientError(err, c, "GPUSource")
+}
+
+func (c *Device) gpuSource() (*GPUSource, error) {
+	output, err := c.runShellCommand(gpuProbeCommand())
+	if err != nil {
+		return nil, err
+	}
+	readable := strings.Fields(output)
+	for i := range gpuSources {
+		if containsString(readable, gpuSources[i].Path) {
+			source := gpuSources[i]
+			return &source, nil
+		}
+	}
+	return nil, errors.Errorf(errors.AdbError, "no readable GPU utilization file")
+}
+
+// gpuProbeCommand returns a command that prints the paths of the gpuSources the shell user can
+// read. Some drivers' files exist and have read permission, but fail to read.
+func gpuProbeCommand() string {
+	cmdLine := "for f in"
+	for _, source := range gpuSources {
+		cmdLine += " " + source.Path
+	}
+	return cmdLine + `; do [ -r "$f" ] && cat "$f" >/dev/null 2>&1 && echo "$f"; done`
+}
+
+func (c *Device) sampleGPU(source *GPUSource) (*GPUSample, error) {
+	output, err := c.runShellCommand(quoteShellArgs("cat", source.Path))
+	if err != nil {
+		return nil, err
+	}
+	busy, err := source.parse(output)
+	if err != nil {
+		return nil, err
+	}
+	return &GPUSample{Time: time.Now(), Busy: busy}, nil
+}
+
+// GPUSampler publishes the utilization of a device's GPU over time.
+type GPUSampler struct {
+	source     GPUSource
+	sampleChan chan GPUSample
+	// If an error occurs, it is stored here and sampleChan is closed immediately after.
+	err atomic.Value
+}
+
+// Source returns the file the sampler reads.
+func (s *GPUSampler) Source() GPUSource {
+	return s.source
+}
+
+// C returns a channel that can be received on to get samples. It's closed when the context
+// passed to SampleGPU is done, or if an error occurs.
+func (s *GPUSampler) C() <-chan GPUSample {
+	return s.sampleChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (s *GPUSampler) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+SampleGPU sends the GPU utilization of the device once per interval, the first immediately,
+until ctx is done. If interval is 0, DefaultGPUSampleInterval is used. It returns an AdbError
+if the device has no GPU utilization file the shell user can read, see GPUSource.
+
+E.g.
+
+	sampler, err := device.SampleGPU(ctx, 0)
+	for sample := range sampler.C() {
+		fmt.Printf("%s GPU %.0f%%\n", sampler.Source().Vendor, sample.Busy)
+	}
+
+Corresponds to the command:
+
+	adb shell cat <GPUSource().Path>
+*/
+func (c *Device) SampleGPU(ctx context.Context, interval time.Duration) (*GPUSampler, error) {
+	if interval <= 0 {
+		interval = DefaultGPUSampleInterval
+	}
+	source, err := c.gpuSource()
+	if err != nil {
+		return nil, wrapClientError(err, c, "SampleGPU")
+	}
+	sample, err := c.sampleGPU(source)
+	if err != nil {
+		return nil, wrapClientError(err, c, "SampleGPU")
+	}
+
+	sampler := &GPUSampler{source: *source, sampleChan: make(chan GPUSample)}
+	go func() {
+		defer close(sampler.sampleChan)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case sampler.sampleChan <- *sample:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if sample, err = c.sampleGPU(source); err != nil {
+				sampler.err.Store(wrapClientError(err, c, "SampleGPU"))
+				return
+			}
+		}
+	}()
+	return sampler, nil
+}
+
+// parseGPUPercentage parses a percentage like "45 %" or "45", the first field of the file.
+func parseGPUPercentage(output string) (float64, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, errors.Errorf(errors.ParseError, "invalid GPU utilization: %q", output)
+	}
+	busy, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "%"), 64)
+	if err != nil {
+		return 0, errors.WrapErrorf(err, errors.ParseError, "invalid GPU utilization: %q", output)
+	}
+	return busy, nil
+}
+
+// parseKGSLGPUBusy parses the "busy total" cycle counts of kgsl's gpubusy.
+func parseKGSLGPUBusy(output string) (float64, error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, errors.Errorf(errors.ParseError, "invalid gpubusy: %q", output)
+	}
+	busy, err1 := strconv.ParseInt(fields[0], 10, 64)
+	total, err2 := strconv.ParseInt(fields[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, errors.Errorf(errors.ParseError, "invalid gpubusy: %q", output)
+	}
+	if total == 0 {
+		// No cycles in the window, the GPU was idle.
+		return 0, nil
+	}
+	return float64(busy) / float64(total) * 100, nil
+}
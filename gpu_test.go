@@ -0,0 +1,59 @@
+package adb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseGPUPercentage(t *testing.T) {
+	busy, err := parseGPUPercentage("45 %\n")
+	require.NoError(t, err)
+	assert.Equal(t, 45.0, busy)
+
+	busy, err = parseGPUPercentage("12 0 0\n")
+	require.NoError(t, err)
+	assert.Equal(t, 12.0, busy)
+
+	_, err = parseGPUPercentage("")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestParseKGSLGPUBusy(t *testing.T) {
+	busy, err := parseKGSLGPUBusy("  250000   1000000\n")
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, busy)
+
+	busy, err = parseKGSLGPUBusy("0 0\n")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, busy)
+
+	_, err = parseKGSLGPUBusy("busy\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestSampleGPU(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"cat /sys/class/kgsl/kgsl-3d0/gpubusy": "500 1000\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.SampleGPU(context.Background(), 0)
+	assert.True(t, HasErrCode(err, AdbError))
+
+	s.ShellOutputs[gpuProbeCommand()] = "/sys/class/kgsl/kgsl-3d0/gpubusy\n"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sampler, err := device.SampleGPU(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "adreno", sampler.Source().Vendor)
+	sample := <-sampler.C()
+	assert.Equal(t, 50.0, sample.Busy)
+}
@@ -0,0 +1,360 @@
+package adb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// HealthState summarizes the checks of a device by a HealthMonitor.
+//
+//go:generate stringer -type=HealthState
+type HealthState int8
+
+const (
+	// Not checked yet.
+	HealthUnknown HealthState = iota
+	// All checks passed.
+	HealthHealthy
+	// The device responds, but a threshold was crossed, e.g. the battery is too hot.
+	HealthDegraded
+	// The device is offline or doesn't respond.
+	HealthUnhealthy
+)
+
+// Defaults used for zero HealthThresholds fields.
+const (
+	DefaultMaxLatency            = 2 * time.Second
+	DefaultMaxBatteryTemperature = 450
+	DefaultMinBatteryLevel       = 15
+	DefaultMinFreeStorage        = 500 << 20
+	DefaultStoragePath           = "/data"
+)
+
+// HealthThresholds are the limits a device is HealthDegraded outside of.
+type HealthThresholds struct {
+	// Longest round trip of the ping.
+	MaxLatency time.Duration
+	// Tenths of a degree Celsius, like BatteryState.Temperature.
+	MaxBatteryTemperature int
+	// Percent. Only checked while the device isn't powered.
+	MinBatteryLevel int
+	// Bytes free in StoragePath.
+	MinFreeStorage int64
+	StoragePath    string
+}
+
+func (t HealthThresholds) withDefaults() HealthThresholds {
+	if t.MaxLatency == 0 {
+		t.MaxLatency = DefaultMaxLatency
+	}
+	if t.MaxBatteryTemperature == 0 {
+		t.MaxBatteryTemperature = DefaultMaxBatteryTemperature
+	}
+	if t.MinBatteryLevel == 0 {
+		t.MinBatteryLevel = DefaultMinBatteryLevel
+	}
+	if t.MinFreeStorage == 0 {
+		t.MinFreeStorage = DefaultMinFreeStorage
+	}
+	if t.StoragePath == "" {
+		t.StoragePath = DefaultStoragePath
+	}
+	return t
+}
+
+// HealthReport is the result of checking a device.
+type HealthReport struct {
+	Serial    string
+	State     HealthState
+	CheckedAt time.Time
+
+	// Round trip of a trivial shell command.
+	Latency time.Duration
+	// Nil if the battery state couldn't be read, e.g. on devices without a battery.
+	Battery *BatteryState
+	// Bytes free in HealthThresholds.StoragePath, or -1 if it couldn't be read.
+	FreeStorage int64
+
+	// Thresholds the device is outside of, e.g. "battery temperature 47.5°C > 45.0°C".
+	Problems []string
+	// Why the device is HealthUnhealthy.
+	Err error
+}
+
+// HealthTransition is reported by a HealthMonitor when the state of a device changes.
+type HealthTransition struct {
+	OldState HealthState
+	Report   HealthReport
+}
+
+// HealthMonitorConfig configures a HealthMonitor. The zero value uses the defaults.
+type HealthMonitorConfig struct {
+	// Time between checks. Defaults to DefaultHealthCheckInterval.
+	Interval time.Duration
+	// Longest a check may take before the device is HealthUnhealthy. Defaults to
+	// DefaultHealthCheckTimeout.
+	Timeout    time.Duration
+	Thresholds HealthThresholds
+}
+
+/*
+HealthMonitor periodically checks every online device: it pings it with a trivial shell
+command, measures the round trip, and checks the battery temperature and level and the free
+storage. It reports each change of a device's HealthState, e.g. for alerting.
+
+E.g.
+
+	monitor := client.NewHealthMonitor(adb.HealthMonitorConfig{}, func(t adb.HealthTransition) {
+		log.Printf("%s: %s -> %s %v", t.Report.Serial, t.OldState, t.Report.State, t.Report.Problems)
+	})
+	go monitor.Run(ctx)
+*/
+type HealthMonitor struct {
+	client       *Adb
+	config       HealthMonitorConfig
+	onTransition func(HealthTransition)
+
+	mu      sync.Mutex
+	reports map[string]*HealthReport
+	// Serials of the devices being checked, including ones whose check timed out but hasn't
+	// returned yet, so a hung device isn't checked again until it does.
+	checking map[string]bool
+	// Waited on by tests for the checks started by handleEvent and checkAll.
+	checks sync.WaitGroup
+}
+
+// NewHealthMonitor returns a HealthMonitor that calls onTransition, if not nil, each time the
+// state of a device changes. onTransition may be called concurrently for different devices, and
+// must not block.
+func (c *Adb) NewHealthMonitor(config HealthMonitorConfig, onTransition func(HealthTransition)) *HealthMonitor {
+	if config.Interval == 0 {
+		config.Interval = DefaultHealthCheckInterval
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultHealthCheckTimeout
+	}
+	config.Thresholds = config.Thresholds.withDefaults()
+	return &HealthMonitor{
+		client:       c,
+		config:       config,
+		onTransition: onTransition,
+		reports:      map[string]*HealthReport{},
+		checking:     map[string]bool{},
+	}
+}
+
+// Run tracks devices and checks them every interval, until ctx is done or the device watcher
+// fails.
+func (m *HealthMonitor) Run(ctx context.Context) error {
+	watcher := m.client.NewDeviceWatcherWithCtx(ctx)
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.C():
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return watcher.Err()
+			}
+			m.handleEvent(event)
+		case <-ticker.C:
+			m.checkAll()
+		}
+	}
+}
+
+// Reports returns the latest report of each tracked device, sorted by serial.
+func (m *HealthMonitor) Reports() []HealthReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reports := make([]HealthReport, 0, len(m.reports))
+	for _, report := range m.reports {
+		reports = append(reports, *report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Serial < reports[j].Serial })
+	return reports
+}
+
+func (m *HealthMonitor) handleEvent(event DeviceStateChangedEvent) {
+	switch {
+	case event.CameOnline():
+		m.mu.Lock()
+		if _, ok := m.reports[event.Serial]; !ok {
+			m.reports[event.Serial] = &HealthReport{Serial: event.Serial}
+		}
+		m.mu.Unlock()
+		m.startCheck(event.Serial)
+	case event.WentOffline():
+		m.update(HealthReport{
+			Serial:      event.Serial,
+			State:       HealthUnhealthy,
+			CheckedAt:   time.Now(),
+			FreeStorage: -1,
+			Err:         errors.Errorf(errors.DeviceOffline, "device is %s", event.NewState),
+		})
+	}
+}
+
+// checkAll starts checking every device that's online, concurrently.
+func (m *HealthMonitor) checkAll() {
+	m.mu.Lock()
+	var serials []string
+	for serial, report := range m.reports {
+		if !errors.HasErrCode(report.Err, errors.DeviceOffline) {
+			serials = append(serials, serial)
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Strings(serials)
+	for _, serial := range serials {
+		m.startCheck(serial)
+	}
+}
+
+// startCheck checks the device with serial in a new goroutine, unless it's already being
+// checked.
+func (m *HealthMonitor) startCheck(serial string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checking[serial] {
+		return
+	}
+	m.checking[serial] = true
+	m.checks.Add(1)
+	go func() {
+		defer m.checks.Done()
+		m.check(serial)
+	}()
+}
+
+// check checks the device with serial, and reports it HealthUnhealthy if that takes longer than
+// the timeout. Device calls can't be interrupted, so a hung check is left running until it
+// returns.
+func (m *HealthMonitor) check(serial string) {
+	start := time.Now()
+	reports := make(chan HealthReport, 1)
+	go func() {
+		reports <- checkDeviceHealth(m.client.Device(DeviceWithSerial(serial)), serial, m.config.Thresholds)
+		m.mu.Lock()
+		delete(m.checking, serial)
+		m.mu.Unlock()
+	}()
+
+	timer := time.NewTimer(m.config.Timeout)
+	defer timer.Stop()
+	select {
+	case report := <-reports:
+		m.update(report)
+	case <-timer.C:
+		m.update(HealthReport{
+			Serial:      serial,
+			State:       HealthUnhealthy,
+			CheckedAt:   start,
+			FreeStorage: -1,
+			Err:         errors.Errorf(errors.NetworkError, "health check timed out after %s", m.config.Timeout),
+		})
+	}
+}
+
+// update stores report, and reports a transition if the state changed.
+func (m *HealthMonitor) update(report HealthReport) {
+	m.mu.Lock()
+	old, ok := m.reports[report.Serial]
+	if !ok {
+		// The device went offline before it was checked.
+		m.mu.Unlock()
+		return
+	}
+	if old.CheckedAt.After(report.CheckedAt) {
+		// The check started before the device went offline.
+		m.mu.Unlock()
+		return
+	}
+	oldState := old.State
+	m.reports[report.Serial] = &report
+	m.mu.Unlock()
+
+	if oldState != report.State && m.onTransition != nil {
+		m.onTransition(HealthTransition{OldState: oldState, Report: report})
+	}
+}
+
+func checkDeviceHealth(device *Device, serial string, thresholds HealthThresholds) HealthReport {
+	report := HealthReport{Serial: serial, CheckedAt: time.Now(), FreeStorage: -1}
+
+	start := time.Now()
+	if err := defaultHealthCheck(device); err != nil {
+		report.State, report.Err = HealthUnhealthy, err
+		return report
+	}
+	report.Latency = time.Since(start)
+	if report.Latency > thresholds.MaxLatency {
+		report.Problems = append(report.Problems, fmt.Sprintf("latency %s > %s", report.Latency, thresholds.MaxLatency))
+	}
+
+	if battery, err := device.Battery().State(); err == nil {
+		report.Battery = battery
+		if battery.Temperature > thresholds.MaxBatteryTemperature {
+			report.Problems = append(report.Problems, fmt.Sprintf("battery temperature %.1f°C > %.1f°C",
+				float64(battery.Temperature)/10, float64(thresholds.MaxBatteryTemperature)/10))
+		}
+		if battery.Scale > 0 && !battery.IsPowered() {
+			if level := battery.Level * 100 / battery.Scale; level < thresholds.MinBatteryLevel {
+				report.Problems = append(report.Problems, fmt.Sprintf("battery level %d%% < %d%%", level, thresholds.MinBatteryLevel))
+			}
+		}
+	}
+
+	if free, err := device.freeStorage(thresholds.StoragePath); err == nil {
+		report.FreeStorage = free
+		if free < thresholds.MinFreeStorage {
+			report.Problems = append(report.Problems, fmt.Sprintf("free storage in %s %d MiB < %d MiB",
+				thresholds.StoragePath, free>>20, thresholds.MinFreeStorage>>20))
+		}
+	}
+
+	report.State = HealthHealthy
+	if len(report.Problems) > 0 {
+		report.State = HealthDegraded
+	}
+	return report
+}
+
+// freeStorage returns the bytes available in the filesystem containing path.
+func (c *Device) freeStorage(path string) (int64, error) {
+	output, err := c.runShellCommand(quoteShellArgs("df", "-k", path))
+	if err != nil {
+		return 0, err
+	}
+	return parseDfAvailable(output)
+}
+
+// parseDfAvailable parses the available column of df -k output. Long filesystem names may
+// wrap the row onto a second line.
+func parseDfAvailable(output string) (int64, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "Filesystem") {
+		return 0, errors.Errorf(errors.ParseError, "invalid df output: %q", output)
+	}
+	fields := strings.Fields(strings.Join(lines[1:], " "))
+	if len(fields) < 6 {
+		return 0, errors.Errorf(errors.ParseError, "invalid df output: %q", output)
+	}
+	available, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return 0, errors.WrapErrorf(err, errors.ParseError, "invalid df output: %q", output)
+	}
+	return available * 1024, nil
+}
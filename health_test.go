@@ -0,0 +1,145 @@
+package adb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testDfOutput = `Filesystem     1K-blocks    Used Available Use% Mounted on
+/dev/block/dm-5  5000000 4800000    200000  96% /data
+`
+
+const testBatteryOutput = `Current Battery Service state:
+  AC powered: false
+  USB powered: false
+  Wireless powered: false
+  status: 3
+  health: 2
+  present: true
+  level: 10
+  scale: 100
+  voltage: 3800
+  temperature: 475
+  technology: Li-ion
+`
+
+func TestParseDfAvailable(t *testing.T) {
+	available, err := parseDfAvailable(testDfOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(200000*1024), available)
+
+	available, err = parseDfAvailable(`Filesystem           1K-blocks      Used Available Use% Mounted on
+/dev/block/bootdevice/by-name/userdata
+                      25000000  5000000  20000000  20% /data
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20000000*1024), available)
+
+	_, err = parseDfAvailable("df: /missing: No such file or directory\n")
+	assert.Error(t, err)
+}
+
+func newHealthTestServer(battery, df string) *MockServer {
+	return &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"echo ok":         "ok\n",
+			"dumpsys battery": battery,
+			"df -k /data":     df,
+		},
+	}
+}
+
+func TestCheckDeviceHealthDegraded(t *testing.T) {
+	s := newHealthTestServer(testBatteryOutput, testDfOutput)
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	report := checkDeviceHealth(device, "serial", HealthThresholds{}.withDefaults())
+	assert.Equal(t, HealthDegraded, report.State)
+	assert.NoError(t, report.Err)
+	assert.Equal(t, 475, report.Battery.Temperature)
+	assert.Equal(t, int64(200000*1024), report.FreeStorage)
+	assert.Equal(t, []string{
+		"battery temperature 47.5°C > 45.0°C",
+		"battery level 10% < 15%",
+		"free storage in /data 195 MiB < 500 MiB",
+	}, report.Problems)
+}
+
+func TestCheckDeviceHealthUnhealthy(t *testing.T) {
+	s := newHealthTestServer("", "")
+	s.ShellOutputs["echo ok"] = "/system/bin/sh: echo: inaccessible\n"
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	report := checkDeviceHealth(device, "serial", HealthThresholds{}.withDefaults())
+	assert.Equal(t, HealthUnhealthy, report.State)
+	assert.Error(t, report.Err)
+}
+
+func TestHealthMonitorTransitions(t *testing.T) {
+	s := newHealthTestServer(testBatteryOutput, testDfOutput)
+	var transitions []HealthTransition
+	monitor := (&Adb{s}).NewHealthMonitor(HealthMonitorConfig{
+		Thresholds: HealthThresholds{MaxBatteryTemperature: 500, MinBatteryLevel: 5, MinFreeStorage: 1 << 20},
+	}, func(transition HealthTransition) {
+		transitions = append(transitions, transition)
+	})
+
+	monitor.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	monitor.checks.Wait()
+	monitor.checkAll()
+	monitor.checks.Wait()
+	s.ShellOutputs["dumpsys battery"] = strings.Replace(testBatteryOutput, "temperature: 475", "temperature: 510", 1)
+	monitor.checkAll()
+	monitor.checks.Wait()
+	monitor.handleEvent(DeviceStateChangedEvent{"serial", StateOnline, StateOffline})
+	// Offline devices aren't checked until they come back online.
+	monitor.checkAll()
+	monitor.checks.Wait()
+
+	var states []HealthState
+	for _, transition := range transitions {
+		states = append(states, transition.Report.State)
+	}
+	assert.Equal(t, []HealthState{HealthHealthy, HealthDegraded, HealthUnhealthy}, states)
+	assert.Equal(t, HealthUnknown, transitions[0].OldState)
+	assert.True(t, HasErrCode(transitions[2].Report.Err, DeviceOffline))
+	assert.Equal(t, HealthUnhealthy, monitor.Reports()[0].State)
+	assert.Equal(t, "HealthDegraded", HealthDegraded.String())
+}
+
+// hungServer blocks dials until unblock is closed.
+type hungServer struct {
+	*MockServer
+	unblock chan struct{}
+}
+
+func (s hungServer) Dial() (*wire.Conn, error) {
+	<-s.unblock
+	return s.MockServer.Dial()
+}
+
+func TestHealthMonitorTimesOutHungDevice(t *testing.T) {
+	s := hungServer{newHealthTestServer(testBatteryOutput, testDfOutput), make(chan struct{})}
+	defer close(s.unblock)
+	var transitions []HealthTransition
+	monitor := (&Adb{s}).NewHealthMonitor(HealthMonitorConfig{Timeout: 10 * time.Millisecond}, func(transition HealthTransition) {
+		transitions = append(transitions, transition)
+	})
+
+	monitor.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	monitor.checks.Wait()
+	// The hung check is still running, so the device isn't checked again.
+	monitor.checkAll()
+	monitor.checks.Wait()
+
+	require.Len(t, transitions, 1)
+	assert.Equal(t, HealthUnhealthy, transitions[0].Report.State)
+	assert.True(t, HasErrCode(transitions[0].Report.Err, NetworkError))
+	assert.Equal(t, HealthUnhealthy, monitor.Reports()[0].State)
+}
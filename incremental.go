@@ -0,0 +1,521 @@
+package adb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Constants of APK Signature Scheme v4, and of the protocol adb's incremental server uses to
+// send blocks of the APK as the device needs them.
+const (
+	v4SignatureVersion = 2
+	// Upper bound of a field of a .idsig file, which is about 1/128th of the APK for the tree.
+	maxV4FieldSize = 1 << 30
+
+	incrementalBlockSize = 4096
+	// Blocks are sent in chunks of up to this many bytes, as adb does.
+	incrementalChunkSize = 31 * incrementalBlockSize
+
+	incrementalServingComplete = 0
+	incrementalBlockMissing    = 1
+	incrementalPrefetch        = 2
+	incrementalDestroy         = 3
+
+	incrementalTypeData        = 0
+	incrementalTypeHash        = 1
+	incrementalCompressionNone = 0
+)
+
+// Starts each request from the device, which is mixed with the output of the install.
+var incrementalMagic = []byte("INCR")
+
+/*
+V4Signature is an APK Signature Scheme v4 signature, as stored in the .idsig file apksigner
+writes next to the APK. The device checks each block it's sent against the Merkle tree, whose
+root hash is signed.
+*/
+type V4Signature struct {
+	// Serialized hashing and signing info, passed to the device as is.
+	HashingInfo []byte
+	SigningInfo []byte
+	// The fs-verity Merkle tree of the APK, nil if the file didn't have it, e.g. when signed with
+	// apksigner --v4-no-merkle-tree. See BuildMerkleTree.
+	MerkleTree []byte
+}
+
+// ReadV4Signature reads a .idsig file.
+func ReadV4Signature(r io.Reader) (*V4Signature, error) {
+	var version int32
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "error reading v4 signature")
+	}
+	if version != v4SignatureVersion {
+		return nil, errors.Errorf(errors.ParseError, "unsupported v4 signature version %d", version)
+	}
+
+	var sig V4Signature
+	var err error
+	if sig.HashingInfo, err = readV4Field(r); err != nil {
+		return nil, v4FieldError(err)
+	}
+	if sig.SigningInfo, err = readV4Field(r); err != nil {
+		return nil, v4FieldError(err)
+	}
+	// A signature without a tree ends before its size, or has an empty one.
+	if sig.MerkleTree, err = readV4Field(r); err != nil && err != io.EOF {
+		return nil, v4FieldError(err)
+	}
+	if len(sig.MerkleTree) == 0 {
+		sig.MerkleTree = nil
+	}
+	return &sig, nil
+}
+
+// readV4Field reads a field prefixed with its size, returning io.EOF if there are no more.
+func readV4Field(r io.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	if size < 0 || size > maxV4FieldSize {
+		return nil, errors.Errorf(errors.ParseError, "invalid v4 signature field size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func v4FieldError(err error) error {
+	if errors.HasErrCode(err, errors.ParseError) {
+		return err
+	}
+	return errors.WrapErrorf(err, errors.ParseError, "error reading v4 signature")
+}
+
+// WriteTo writes the signature as a .idsig file, e.g. to save a tree built by BuildMerkleTree.
+func (s *V4Signature) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	s.writeHeader(&buf)
+	writeV4Field(&buf, s.MerkleTree)
+	n, err := w.Write(buf.Bytes())
+	if err != nil {
+		return int64(n), errors.WrapErrorf(err, errors.AssertionError, "error writing v4 signature")
+	}
+	return int64(n), nil
+}
+
+// writeHeader writes the signature without the tree, which is what's sent to the device.
+func (s *V4Signature) writeHeader(buf *bytes.Buffer) {
+	binary.Write(buf, binary.LittleEndian, int32(v4SignatureVersion))
+	writeV4Field(buf, s.HashingInfo)
+	writeV4Field(buf, s.SigningInfo)
+}
+
+func writeV4Field(buf *bytes.Buffer, data []byte) {
+	binary.Write(buf, binary.LittleEndian, int32(len(data)))
+	buf.Write(data)
+}
+
+/*
+BuildMerkleTree returns the fs-verity Merkle tree of the size bytes read from r, with SHA-256,
+4096-byte blocks, and no salt, as v4 signatures and IncFS use, and its root hash. The levels are
+stored from the root down. Files of one block have no tree, and their root hash is the hash of
+the block.
+*/
+func BuildMerkleTree(r io.ReaderAt, size int64) (tree, rootHash []byte, err error) {
+	if size <= 0 {
+		return nil, nil, errors.AssertionErrorf("can't build a Merkle tree of %d bytes", size)
+	}
+
+	// The hashes of the data blocks, then of each level's blocks, until one hash is left.
+	var hashes bytes.Buffer
+	block := make([]byte, incrementalBlockSize)
+	for offset := int64(0); offset < size; offset += incrementalBlockSize {
+		n, err := r.ReadAt(block, offset)
+		if err != nil && !(err == io.EOF && offset+int64(n) == size) {
+			return nil, nil, errors.WrapErrorf(err, errors.FileNoExistError, "error reading data to hash")
+		}
+		// The last block is padded with zeros.
+		for i := n; i < len(block); i++ {
+			block[i] = 0
+		}
+		sum := sha256.Sum256(block)
+		hashes.Write(sum[:])
+	}
+
+	var levels [][]byte
+	level := hashes.Bytes()
+	for len(level) > sha256.Size {
+		padded := make([]byte, (len(level)+incrementalBlockSize-1)/incrementalBlockSize*incrementalBlockSize)
+		copy(padded, level)
+		levels = append(levels, padded)
+
+		var next bytes.Buffer
+		for i := 0; i < len(padded); i += incrementalBlockSize {
+			sum := sha256.Sum256(padded[i : i+incrementalBlockSize])
+			next.Write(sum[:])
+		}
+		level = next.Bytes()
+	}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		tree = append(tree, levels[i]...)
+	}
+	return tree, level, nil
+}
+
+// merkleTreeSize returns the size of the tree BuildMerkleTree returns for a file of size bytes.
+func merkleTreeSize(size int64) int64 {
+	const hashesPerBlock = incrementalBlockSize / sha256.Size
+	var blocks int64
+	for n := (size + incrementalBlockSize - 1) / incrementalBlockSize; n > 1; {
+		n = (n + hashesPerBlock - 1) / hashesPerBlock
+		blocks += n
+	}
+	return blocks * incrementalBlockSize
+}
+
+/*
+InstallAppIncremental installs the APK at apk with ADB Incremental: the install finishes once
+the device has the blocks it needs to start, and the rest of the APK is streamed in the
+background, so multi-GB APKs install in seconds. Needs Android 11 or later, and the APK's v4
+signature in apk + ".idsig", as written by apksigner. If the .idsig doesn't have the Merkle tree,
+it's built from the APK.
+
+Returns once the install succeeds or fails, or ctx is done. The app isn't fully on the device
+until the returned IncrementalInstall's Wait returns, and must not be used after closing it
+before then.
+
+Corresponds to the command:
+
+	adb install --incremental [-r] [-g] <apk>
+*/
+func (c *Device) InstallAppIncremental(ctx context.Context, apk string, reinstall bool, grantPermission bool) (*IncrementalInstall, error) {
+	install, err := c.installAppIncremental(ctx, apk, reinstall, grantPermission)
+	return install, wrapClientError(err, c, "InstallAppIncremental(%s)", apk)
+}
+
+func (c *Device) installAppIncremental(ctx context.Context, apk string, reinstall bool, grantPermission bool) (*IncrementalInstall, error) {
+	features, err := c.features()
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(features, FeatureAbbExec) {
+		return nil, errors.Errorf(errors.AdbError, "incremental installs need abb_exec, in Android 11 and later")
+	}
+
+	file, err := os.Open(apk)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.FileNoExistError, "error opening %s", apk)
+	}
+	install, err := newIncrementalInstall(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	args := append([]string{"package", "install-incremental"}, c.userArgs()...)
+	if reinstall {
+		args = append(args, "-r")
+	}
+	if grantPermission {
+		args = append(args, "-g")
+	}
+	// <name>:<size>:<file id>:<signature>:<mode>, where mode 1 is streaming.
+	args = append(args, fmt.Sprintf("%s:%d:0:%s:1", filepath.Base(apk), install.size, install.signature))
+
+	conn, err := c.openService(abbService("abb_exec:", args))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	install.conn = conn
+	go install.serve()
+
+	select {
+	case err := <-install.result:
+		if err != nil {
+			install.Close()
+			return nil, err
+		}
+		return install, nil
+	case <-ctx.Done():
+		install.Close()
+		return nil, errors.WrapErrf(ctx.Err(), "incremental install interrupted")
+	}
+}
+
+// IncrementalInstall serves the blocks of an APK installed by InstallAppIncremental to the
+// device.
+type IncrementalInstall struct {
+	apk       *os.File
+	size      int64
+	tree      []byte
+	signature string
+	conn      io.ReadWriteCloser
+
+	// Receives the result of the install, once.
+	result   chan error
+	reported bool
+	output   bytes.Buffer
+
+	sentTree bool
+	sent     []bool
+	chunk    bytes.Buffer
+
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+// newIncrementalInstall reads the signature of apk, building its tree if needed.
+func newIncrementalInstall(apk *os.File) (*IncrementalInstall, error) {
+	info, err := apk.Stat()
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.FileNoExistError, "error reading %s", apk.Name())
+	}
+	sigFile, err := os.Open(apk.Name() + ".idsig")
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.FileNoExistError, "incremental installs need the APK's v4 signature")
+	}
+	defer sigFile.Close()
+	sig, err := ReadV4Signature(bufio.NewReader(sigFile))
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	if sig.MerkleTree == nil {
+		if sig.MerkleTree, _, err = BuildMerkleTree(apk, size); err != nil {
+			return nil, err
+		}
+	}
+	if int64(len(sig.MerkleTree)) != merkleTreeSize(size) {
+		return nil, errors.Errorf(errors.ParseError, "v4 signature's Merkle tree is %d bytes, expected %d for the APK",
+			len(sig.MerkleTree), merkleTreeSize(size))
+	}
+
+	var header bytes.Buffer
+	sig.writeHeader(&header)
+	return &IncrementalInstall{
+		apk:       apk,
+		size:      size,
+		tree:      sig.MerkleTree,
+		signature: base64.StdEncoding.EncodeToString(header.Bytes()),
+		result:    make(chan error, 1),
+		sent:      make([]bool, (size+incrementalBlockSize-1)/incrementalBlockSize),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Wait blocks until the device has every block of the APK, or serving them fails.
+func (i *IncrementalInstall) Wait() error {
+	<-i.done
+	return i.err
+}
+
+// Close stops serving blocks. Blocks the device doesn't have yet can't be loaded, so the app may
+// fail until it's reinstalled.
+func (i *IncrementalInstall) Close() error {
+	i.closeOnce.Do(func() {
+		i.conn.Close()
+	})
+	<-i.done
+	return nil
+}
+
+// serve answers the device's requests until it has every block, and reports the install's
+// output, which is mixed with the requests, to result.
+func (i *IncrementalInstall) serve() {
+	defer close(i.done)
+	defer i.apk.Close()
+	defer i.closeOnce.Do(func() { i.conn.Close() })
+
+	i.err = i.serveRequests()
+	// A no-op if pm already printed its result.
+	i.reportResult(i.err)
+}
+
+func (i *IncrementalInstall) serveRequests() error {
+	if _, err := i.conn.Write([]byte("OKAY")); err != nil {
+		return errors.WrapErrorf(err, errors.NetworkError, "error starting incremental serving")
+	}
+
+	reader := bufio.NewReader(i.conn)
+	matched := 0
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			if !i.reported {
+				return installOutputError(i.output.String())
+			}
+			return errors.WrapErrorf(err, errors.ConnectionResetError, "device stopped requesting blocks")
+		}
+		if b == incrementalMagic[matched] {
+			if matched++; matched < len(incrementalMagic) {
+				continue
+			}
+			matched = 0
+			request := make([]byte, 8)
+			if _, err := io.ReadFull(reader, request); err != nil {
+				return errors.WrapErrorf(err, errors.NetworkError, "error reading block request")
+			}
+			if complete, err := i.handleRequest(request); err != nil {
+				return err
+			} else if complete {
+				return i.readResult(reader)
+			}
+			continue
+		}
+
+		i.output.Write(incrementalMagic[:matched])
+		matched = 0
+		if b == incrementalMagic[0] {
+			matched = 1
+			continue
+		}
+		i.output.WriteByte(b)
+		if b == '\n' {
+			i.checkOutput()
+		}
+	}
+}
+
+// readResult reads the rest of the install's output once the device has every block, since pm
+// may not have printed its result yet.
+func (i *IncrementalInstall) readResult(reader *bufio.Reader) error {
+	for !i.reported {
+		line, err := reader.ReadString('\n')
+		i.output.WriteString(line)
+		if err != nil {
+			return installOutputError(i.output.String())
+		}
+		i.checkOutput()
+	}
+	return nil
+}
+
+// checkOutput reports the install's result once pm prints it.
+func (i *IncrementalInstall) checkOutput() {
+	output := i.output.String()
+	if strings.Contains(output, "Success") || strings.Contains(output, "Failure") {
+		i.reportResult(installOutputError(output))
+	}
+}
+
+func (i *IncrementalInstall) reportResult(err error) {
+	if !i.reported {
+		i.reported = true
+		i.result <- err
+	}
+}
+
+// handleRequest answers a request: its type, file id, and block index or count, big-endian.
+// Returns true once the device has every block.
+func (i *IncrementalInstall) handleRequest(request []byte) (bool, error) {
+	requestType := binary.BigEndian.Uint16(request[0:])
+	fileID := int16(binary.BigEndian.Uint16(request[2:]))
+	blockIdx := int32(binary.BigEndian.Uint32(request[4:]))
+	if fileID != 0 && requestType != incrementalServingComplete && requestType != incrementalDestroy {
+		return false, errors.Errorf(errors.ParseError, "device requested unknown file %d", fileID)
+	}
+
+	var err error
+	switch requestType {
+	case incrementalServingComplete, incrementalDestroy:
+		return true, nil
+	case incrementalBlockMissing:
+		if blockIdx < 0 || int(blockIdx) >= len(i.sent) {
+			return false, errors.Errorf(errors.ParseError, "device requested invalid block %d", blockIdx)
+		}
+		if err = i.sendTree(); err == nil {
+			err = i.sendBlock(blockIdx)
+		}
+	case incrementalPrefetch:
+		if err = i.sendTree(); err != nil {
+			break
+		}
+		for idx := range i.sent {
+			if !i.sent[idx] {
+				if err = i.sendBlock(int32(idx)); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err == nil {
+		err = i.flush()
+	}
+	return false, err
+}
+
+// sendTree sends the whole Merkle tree, which the device needs to check the data blocks, the
+// first time it's called.
+func (i *IncrementalInstall) sendTree() error {
+	if i.sentTree {
+		return nil
+	}
+	i.sentTree = true
+	for offset := 0; offset < len(i.tree); offset += incrementalBlockSize {
+		if err := i.queue(incrementalTypeHash, int32(offset/incrementalBlockSize), i.tree[offset:offset+incrementalBlockSize]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *IncrementalInstall) sendBlock(idx int32) error {
+	data := make([]byte, incrementalBlockSize)
+	n, err := i.apk.ReadAt(data, int64(idx)*incrementalBlockSize)
+	if err != nil && err != io.EOF {
+		return errors.WrapErrorf(err, errors.FileNoExistError, "error reading block %d of the APK", idx)
+	}
+	i.sent[idx] = true
+	return i.queue(incrementalTypeData, idx, data[:n])
+}
+
+// queue adds a block to the current chunk, sending the chunk first if the block doesn't fit.
+// Each block starts with its file id, type, compression, index, and size, big-endian.
+func (i *IncrementalInstall) queue(blockType int8, idx int32, data []byte) error {
+	if i.chunk.Len()+10+len(data) > incrementalChunkSize {
+		if err := i.flush(); err != nil {
+			return err
+		}
+	}
+	binary.Write(&i.chunk, binary.BigEndian, int16(0))
+	binary.Write(&i.chunk, binary.BigEndian, blockType)
+	binary.Write(&i.chunk, binary.BigEndian, int8(incrementalCompressionNone))
+	binary.Write(&i.chunk, binary.BigEndian, idx)
+	binary.Write(&i.chunk, binary.BigEndian, int16(len(data)))
+	i.chunk.Write(data)
+	return nil
+}
+
+// flush sends the current chunk, prefixed with its size.
+func (i *IncrementalInstall) flush() error {
+	if i.chunk.Len() == 0 {
+		return nil
+	}
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(i.chunk.Len()))
+	_, err := i.conn.Write(append(header, i.chunk.Bytes()...))
+	i.chunk.Reset()
+	if err != nil {
+		return errors.WrapErrorf(err, errors.NetworkError, "error sending blocks")
+	}
+	return nil
+}
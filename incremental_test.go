@@ -0,0 +1,179 @@
+package adb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestBuildMerkleTree(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 100)
+	tree, root, err := BuildMerkleTree(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assert.Empty(t, tree)
+	block := make([]byte, incrementalBlockSize)
+	copy(block, data)
+	sum := sha256.Sum256(block)
+	assert.Equal(t, sum[:], root)
+
+	for _, size := range []int64{incrementalBlockSize + 1, 128 * incrementalBlockSize, 130 * incrementalBlockSize} {
+		data := bytes.Repeat([]byte("x"), int(size))
+		tree, root, err := BuildMerkleTree(bytes.NewReader(data), size)
+		assert.NoError(t, err)
+		assert.Equal(t, merkleTreeSize(size), int64(len(tree)), "%d", size)
+		// The root level comes first.
+		sum := sha256.Sum256(tree[:incrementalBlockSize])
+		assert.Equal(t, sum[:], root, "%d", size)
+	}
+	assert.Equal(t, int64(3*incrementalBlockSize), merkleTreeSize(130*incrementalBlockSize))
+}
+
+func TestReadV4Signature(t *testing.T) {
+	sig := &V4Signature{HashingInfo: []byte("hashing"), SigningInfo: []byte("signing"), MerkleTree: []byte("tree")}
+	var buf bytes.Buffer
+	_, err := sig.WriteTo(&buf)
+	require.NoError(t, err)
+	read, err := ReadV4Signature(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, sig, read)
+
+	// Without the tree.
+	var header bytes.Buffer
+	sig.writeHeader(&header)
+	read, err = ReadV4Signature(&header)
+	assert.NoError(t, err)
+	assert.Nil(t, read.MerkleTree)
+
+	_, err = ReadV4Signature(strings.NewReader("\x03\x00\x00\x00"))
+	assert.True(t, HasErrCode(err, ParseError))
+	_, err = ReadV4Signature(strings.NewReader("\x02\x00\x00\x00\x10\x00\x00\x00short"))
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+// incrementalRequest returns a request from the device for the block at idx.
+func incrementalRequest(requestType uint16, idx int32) string {
+	request := make([]byte, 12)
+	copy(request, incrementalMagic)
+	binary.BigEndian.PutUint16(request[4:], requestType)
+	binary.BigEndian.PutUint32(request[8:], uint32(idx))
+	return string(request)
+}
+
+func TestInstallAppIncremental(t *testing.T) {
+	dir, err := ioutil.TempDir("", "incremental")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	apk := filepath.Join(dir, "game.apk")
+	data := bytes.Repeat([]byte("apk!"), 2000)
+	require.NoError(t, ioutil.WriteFile(apk, data, 0644))
+	sigFile, err := os.Create(apk + ".idsig")
+	require.NoError(t, err)
+	_, err = (&V4Signature{HashingInfo: []byte("hashing"), SigningInfo: []byte("signing")}).WriteTo(sigFile)
+	require.NoError(t, err)
+	sigFile.Close()
+
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Messages: []string{
+			"abb_exec",
+			"Performing incremental install",
+			incrementalRequest(incrementalBlockMissing, 1),
+			"\nSuccess\n",
+			incrementalRequest(incrementalServingComplete, 0),
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	install, err := device.InstallAppIncremental(context.Background(), apk, true, false)
+	require.NoError(t, err)
+	require.NoError(t, install.Wait())
+
+	var header bytes.Buffer
+	(&V4Signature{HashingInfo: []byte("hashing"), SigningInfo: []byte("signing")}).writeHeader(&header)
+	assert.Equal(t, "abb_exec:package\x00install-incremental\x00-r\x00game.apk:8000:0:"+
+		base64.StdEncoding.EncodeToString(header.Bytes())+":1", s.Requests[2])
+	assert.Equal(t, "OKAY", s.Requests[3])
+
+	// The tree block, then the requested data block, in one chunk.
+	chunk := []byte(s.Requests[4])
+	assert.Equal(t, uint32(len(chunk)-4), binary.BigEndian.Uint32(chunk))
+	tree, _, err := BuildMerkleTree(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, incrementalTypeHash, incrementalCompressionNone, 0, 0, 0, 0, 0x10, 0}, chunk[4:14])
+	assert.Equal(t, tree, chunk[14:14+incrementalBlockSize])
+	blockHeader := chunk[14+incrementalBlockSize:]
+	assert.Equal(t, []byte{0, 0, incrementalTypeData, incrementalCompressionNone, 0, 0, 0, 1}, blockHeader[:8])
+	assert.Equal(t, uint16(8000-incrementalBlockSize), binary.BigEndian.Uint16(blockHeader[8:]))
+	assert.Equal(t, data[incrementalBlockSize:], blockHeader[10:])
+}
+
+func TestInstallAppIncrementalResultAfterServing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "incremental")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	apk := filepath.Join(dir, "game.apk")
+	require.NoError(t, ioutil.WriteFile(apk, bytes.Repeat([]byte("apk!"), 2000), 0644))
+	var buf bytes.Buffer
+	(&V4Signature{}).WriteTo(&buf)
+	require.NoError(t, ioutil.WriteFile(apk+".idsig", buf.Bytes(), 0644))
+
+	// The device can have every block before pm prints its result.
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Messages: []string{
+			"abb_exec",
+			incrementalRequest(incrementalPrefetch, 0),
+			incrementalRequest(incrementalServingComplete, 0),
+			"Success\n",
+		},
+	}
+	install, err := (&Adb{s}).Device(AnyDevice()).InstallAppIncremental(context.Background(), apk, false, false)
+	require.NoError(t, err)
+	assert.NoError(t, install.Wait())
+
+	s = &MockServer{
+		Status: wire.StatusSuccess,
+		Messages: []string{
+			"abb_exec",
+			incrementalRequest(incrementalServingComplete, 0),
+			"Failure [INSTALL_FAILED_INSUFFICIENT_STORAGE]\n",
+		},
+	}
+	_, err = (&Adb{s}).Device(AnyDevice()).InstallAppIncremental(context.Background(), apk, false, false)
+	assert.True(t, HasErrCode(err, AdbError), "%v", err)
+	assert.Contains(t, ErrorWithCauseChain(err), "INSTALL_FAILED_INSUFFICIENT_STORAGE")
+}
+
+func TestInstallAppIncrementalFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "incremental")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	apk := filepath.Join(dir, "game.apk")
+	require.NoError(t, ioutil.WriteFile(apk, []byte("apk"), 0644))
+	device := (&Adb{&MockServer{Status: wire.StatusSuccess, Messages: []string{"abb_exec"}}}).Device(AnyDevice())
+
+	_, err = device.InstallAppIncremental(context.Background(), apk, false, false)
+	assert.True(t, HasErrCode(err, FileNoExistError), "%v", err)
+
+	var buf bytes.Buffer
+	(&V4Signature{}).WriteTo(&buf)
+	require.NoError(t, ioutil.WriteFile(apk+".idsig", buf.Bytes(), 0644))
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"abb_exec", "Failure [INSTALL_FAILED_VERIFICATION_FAILURE]\n"},
+	}
+	_, err = (&Adb{s}).Device(AnyDevice()).InstallAppIncremental(context.Background(), apk, false, false)
+	assert.True(t, HasErrCode(err, AdbError), "%v", err)
+	assert.Contains(t, ErrorWithCauseChain(err), "INSTALL_FAILED_VERIFICATION_FAILURE")
+}
@@ -0,0 +1,114 @@
+package adb
+
+import (
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+/*
+Instrumentation receives timings and traffic stats for every connection to the server, to
+export as traces and metrics, e.g. with OpenTelemetry. Methods may be called concurrently.
+
+Stats are reported once the connection is closed, along with its start time, so spans can be
+recorded after the fact. E.g. with go.opentelemetry.io/otel:
+
+	func (o *otelInstrumentation) RequestDone(s adb.RequestStats) {
+		ctx := context.Background()
+		_, span := o.tracer.Start(ctx, "adb "+s.Operation, trace.WithTimestamp(s.Start))
+		span.SetAttributes(attribute.StringSlice("adb.services", s.Services))
+		if s.Err != nil {
+			span.RecordError(s.Err)
+			span.SetStatus(codes.Error, s.Err.Error())
+		}
+		span.End(trace.WithTimestamp(s.Start.Add(s.Duration)))
+		o.latency.Record(ctx, s.Duration.Seconds(), metric.WithAttributes(attribute.String("adb.operation", s.Operation)))
+		o.pushed.Add(ctx, s.BytesPushed)
+		o.pulled.Add(ctx, s.BytesPulled)
+	}
+*/
+type Instrumentation interface {
+	// DialDone is called after each attempt to connect to the server.
+	DialDone(DialStats)
+	// RequestDone is called when each connection to the server is closed.
+	RequestDone(RequestStats)
+}
+
+// DialStats describes a single attempt to connect to the server.
+type DialStats struct {
+	Start    time.Time
+	Duration time.Duration
+	// Set if the dial failed.
+	Err error
+}
+
+// RequestStats describes the traffic on a single connection to the server.
+type RequestStats struct {
+	// Low-cardinality name of the final service requested, e.g. shell, sync, or host:version,
+	// suitable for span names and metric attributes.
+	Operation string
+	// Full messages sent to request services, e.g. host:transport-any and shell:ls -l.
+	Services []string
+
+	Start    time.Time
+	Duration time.Duration
+
+	// Total bytes of messages and raw data written to and read from the server, excluding
+	// length headers.
+	BytesSent     int64
+	BytesReceived int64
+	// File data sent and received in sync mode, i.e. by pushes and pulls.
+	BytesPushed int64
+	BytesPulled int64
+
+	// First error returned by the connection, if any.
+	Err error
+}
+
+// ErrCode returns the code of Err, see errors.CodeOf. Only valid if Err is not nil.
+func (s RequestStats) ErrCode() ErrCode {
+	return ErrCode(errors.CodeOf(s.Err))
+}
+
+/*
+WithInstrumentation returns a Device that reports its requests to instrumentation. Other
+Devices, even from the same Adb, aren't affected. To instrument all requests, set
+ServerConfig.Instrumentation.
+*/
+func (c *Device) WithInstrumentation(instrumentation Instrumentation) *Device {
+	instrumented := *c
+	instrumented.server = observeServer(c.server, nil, instrumentation)
+	return &instrumented
+}
+
+// Host attributes whose requests are of the form host-serial:<serial>:<attribute>.
+var hostSerialAttributes = []string{
+	"get-state", "get-serialno", "get-devpath", "get-product", "features",
+	"forward", "killforward", "list-forward",
+}
+
+// operationName returns the name of the last service in services, without its arguments or
+// device serial. E.g. "shell:ls -l" is shell, host-serial:emulator-5554:get-state is
+// host-serial:get-state, and host:forward:tcp:1;tcp:2 is host:forward.
+func operationName(services []string) string {
+	if len(services) == 0 {
+		return ""
+	}
+	service := services[len(services)-1]
+
+	if strings.HasPrefix(service, "host-serial:") {
+		rest := strings.TrimPrefix(service, "host-serial:")
+		for _, attr := range hostSerialAttributes {
+			if strings.HasSuffix(rest, ":"+attr) || strings.Contains(rest, ":"+attr+":") {
+				return "host-serial:" + attr
+			}
+		}
+		return "host-serial"
+	}
+	parts := strings.SplitN(service, ":", 3)
+	if strings.HasPrefix(parts[0], "host") && len(parts) > 1 {
+		return parts[0] + ":" + parts[1]
+	}
+	return parts[0]
+}
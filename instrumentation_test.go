@@ -0,0 +1,104 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+type testInstrumentation struct {
+	dials    []DialStats
+	requests []RequestStats
+}
+
+func (i *testInstrumentation) DialDone(stats DialStats)       { i.dials = append(i.dials, stats) }
+func (i *testInstrumentation) RequestDone(stats RequestStats) { i.requests = append(i.requests, stats) }
+
+func TestDeviceWithInstrumentation(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"output"},
+	}
+	instrumentation := &testInstrumentation{}
+	device := (&Adb{s}).Device(AnyDevice()).WithInstrumentation(instrumentation)
+
+	_, err := device.RunCommand("ls", "-l")
+	assert.NoError(t, err)
+
+	require.Len(t, instrumentation.dials, 1)
+	assert.NoError(t, instrumentation.dials[0].Err)
+	require.Len(t, instrumentation.requests, 1)
+	stats := instrumentation.requests[0]
+	assert.Equal(t, "shell", stats.Operation)
+	assert.Equal(t, []string{"host:transport-any", "shell:ls -l"}, stats.Services)
+	assert.Equal(t, int64(len("host:transport-anyshell:ls -l")), stats.BytesSent)
+	assert.Equal(t, int64(len("OKAYOKAYoutput")), stats.BytesReceived)
+	assert.NoError(t, stats.Err)
+}
+
+func TestInstrumentationSyncBytes(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/foo": {Data: []byte("hello")}},
+	}
+	instrumentation := &testInstrumentation{}
+	device := (&Adb{observeServer(s, nil, instrumentation)}).Device(AnyDevice())
+
+	reader, err := device.OpenRead("/sdcard/foo")
+	require.NoError(t, err)
+	buf := make([]byte, 16)
+	n, _ := reader.Read(buf)
+	assert.Equal(t, "hello", string(buf[:n]))
+	reader.Close()
+
+	require.Len(t, instrumentation.requests, 1)
+	assert.Equal(t, "sync", instrumentation.requests[0].Operation)
+	assert.Equal(t, int64(5), instrumentation.requests[0].BytesPulled)
+}
+
+func TestInstrumentationErrCode(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Errs:   []error{nil, nil, errors.Errorf(errors.DeviceNotFound, "device not found")},
+	}
+	instrumentation := &testInstrumentation{}
+	_, err := (&Adb{observeServer(s, nil, instrumentation)}).ServerVersion()
+	assert.Error(t, err)
+
+	require.Len(t, instrumentation.requests, 1)
+	assert.Equal(t, "host:version", instrumentation.requests[0].Operation)
+	assert.Equal(t, DeviceNotFound, instrumentation.requests[0].ErrCode())
+}
+
+func TestObserveServerKeepsLoggerAndInstrumentation(t *testing.T) {
+	logger := &testLogger{}
+	instrumentation := &testInstrumentation{}
+	s := observeServer(observeServer(&MockServer{}, logger, nil), nil, instrumentation).(*observedServer)
+	assert.Equal(t, logger, s.logger)
+	assert.Equal(t, instrumentation, s.instrumentation)
+	_, nested := s.server.(*observedServer)
+	assert.False(t, nested)
+}
+
+func TestOperationName(t *testing.T) {
+	for _, test := range []struct {
+		Service string
+		Want    string
+	}{
+		{"shell:ls -l", "shell"},
+		{"exec:cat /sdcard/foo", "exec"},
+		{"sync:", "sync"},
+		{"host:version", "host:version"},
+		{"host:transport:emulator-5554", "host:transport"},
+		{"host:forward:tcp:1;tcp:2", "host:forward"},
+		{"host-serial:127.0.0.1:5555:get-state", "host-serial:get-state"},
+		{"host-serial:emulator-5554:forward:tcp:1;tcp:2", "host-serial:forward"},
+		{"host-usb:get-serialno", "host-usb:get-serialno"},
+	} {
+		assert.Equal(t, test.Want, operationName([]string{test.Service}), test.Service)
+	}
+	assert.Equal(t, "", operationName(nil))
+}
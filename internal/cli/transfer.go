@@ -0,0 +1,267 @@
+// Package cli implements the commands shared by the adb and goadb command line tools.
+package cli
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/cheggaaa/pb"
+	"github.com/zach-klippenstein/goadb"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// StdIoFilename is the local path that means stdin or stdout.
+const StdIoFilename = "-"
+
+// TransferOptions configure Pull and Push.
+type TransferOptions struct {
+	// Show a progress bar on stderr.
+	ShowProgress bool
+	// Compare the checksums of the file on the host and the device after the transfer.
+	Verify bool
+	// Used to push directories, see adb.TransferOptions.
+	Workers int
+	Tar     bool
+}
+
+// Pull copies remotePath on device to localPath, and returns the exit code. If localPath is
+// empty, the file is copied to the working directory.
+func Pull(device *adb.Device, remotePath, localPath string, opts TransferOptions) int {
+	if remotePath == "" {
+		fmt.Fprintln(os.Stderr, "error: must specify remote file")
+		kingpin.Usage()
+		return 1
+	}
+
+	if localPath == "" {
+		localPath = filepath.Base(remotePath)
+	}
+
+	info, err := device.Stat(remotePath)
+	if adb.HasErrCode(err, adb.ErrCode(adb.FileNoExistError)) {
+		fmt.Fprintln(os.Stderr, "remote file does not exist:", remotePath)
+		return 1
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading remote file %s: %s\n", remotePath, err)
+		return 1
+	}
+
+	remoteFile, err := device.OpenRead(remotePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening remote file %s: %s\n", remotePath, adb.ErrorWithCauseChain(err))
+		return 1
+	}
+	defer remoteFile.Close()
+
+	var localFile io.WriteCloser
+	if localPath == StdIoFilename {
+		localFile = os.Stdout
+	} else {
+		localFile, err = os.Create(localPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening local file %s: %s\n", localPath, err)
+			return 1
+		}
+	}
+	defer localFile.Close()
+
+	sums := newChecksums()
+	if err := copyWithProgressAndStats(io.MultiWriter(localFile, sums), remoteFile, int(info.Size), opts.ShowProgress); err != nil {
+		fmt.Fprintln(os.Stderr, "error pulling file:", err)
+		return 1
+	}
+	if opts.Verify {
+		return sums.verify(device, remotePath)
+	}
+	return 0
+}
+
+// Push copies localPath, a file or a directory, to remotePath on device, and returns the exit
+// code. If localPath is empty or StdIoFilename, stdin is copied.
+func Push(device *adb.Device, localPath, remotePath string, opts TransferOptions) int {
+	if remotePath == "" {
+		fmt.Fprintln(os.Stderr, "error: must specify remote file")
+		kingpin.Usage()
+		return 1
+	}
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		return pushDir(device, localPath, remotePath, opts)
+	}
+
+	var (
+		localFile io.ReadCloser
+		size      int
+		perms     os.FileMode
+		mtime     time.Time
+	)
+	if localPath == "" || localPath == StdIoFilename {
+		localFile = os.Stdin
+		// 0 size will hide the progress bar.
+		perms = os.FileMode(0660)
+		mtime = adb.MtimeOfClose
+	} else {
+		var err error
+		localFile, err = os.Open(localPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening local file %s: %s\n", localPath, err)
+			return 1
+		}
+		info, err := os.Stat(localPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading local file %s: %s\n", localPath, err)
+			return 1
+		}
+		size = int(info.Size())
+		perms = info.Mode().Perm()
+		mtime = info.ModTime()
+	}
+	defer localFile.Close()
+
+	writer, err := device.OpenWrite(remotePath, perms, mtime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening remote file %s: %s\n", remotePath, err)
+		return 1
+	}
+
+	sums := newChecksums()
+	if err := copyWithProgressAndStats(writer, io.TeeReader(localFile, sums), size, opts.ShowProgress); err != nil {
+		writer.Close()
+		fmt.Fprintln(os.Stderr, "error pushing file:", err)
+		return 1
+	}
+	// The file isn't complete on the device until the writer is closed.
+	if err := writer.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, "error pushing file:", err)
+		return 1
+	}
+	if opts.Verify {
+		return sums.verify(device, remotePath)
+	}
+	return 0
+}
+
+func pushDir(device *adb.Device, localPath, remotePath string, opts TransferOptions) int {
+	dirOpts := adb.TransferOptions{Workers: opts.Workers, Tar: opts.Tar}
+	var progress *pb.ProgressBar
+	if opts.ShowProgress {
+		dirOpts.Progress = func(p adb.TransferProgress) {
+			if progress == nil {
+				progress = pb.New64(p.TotalBytes)
+				progress.Output = os.Stderr
+				progress.ShowSpeed = true
+				progress.SetUnits(pb.U_BYTES)
+				progress.Start()
+			}
+			progress.Set64(p.Bytes)
+			progress.Prefix(fmt.Sprintf("%d/%d files", p.Files, p.TotalFiles))
+		}
+	}
+
+	err := device.PushDir(localPath, remotePath, dirOpts)
+	if progress != nil {
+		progress.Finish()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error pushing directory:", adb.ErrorWithCauseChain(err))
+		return 1
+	}
+	return 0
+}
+
+// checksums computes the checksums of the data written to it, to compare with the checksum
+// of a transferred file computed on the device.
+type checksums struct {
+	io.Writer
+	md5    hash.Hash
+	sha256 hash.Hash
+}
+
+func newChecksums() *checksums {
+	sums := &checksums{md5: md5.New(), sha256: sha256.New()}
+	sums.Writer = io.MultiWriter(sums.md5, sums.sha256)
+	return sums
+}
+
+// verify compares the checksums with remotePath's, using SHA-256 if the device supports it,
+// and returns the exit code.
+func (sums *checksums) verify(device *adb.Device, remotePath string) int {
+	tools, err := device.ChecksumTools()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error verifying file:", err)
+		return 1
+	}
+
+	var remote, local []byte
+	switch {
+	case tools.Sha256sum != nil:
+		remote, err = device.Sha256sum(remotePath)
+		local = sums.sha256.Sum(nil)
+	case tools.Md5sum != nil:
+		remote, err = device.Md5sum(remotePath)
+		local = sums.md5.Sum(nil)
+	default:
+		fmt.Fprintln(os.Stderr, "error verifying file: device has no checksum command")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error verifying file:", adb.ErrorWithCauseChain(err))
+		return 1
+	}
+	if !bytes.Equal(remote, local) {
+		fmt.Fprintf(os.Stderr, "checksum mismatch: local %x, remote %x\n", local, remote)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "verified checksum %x\n", local)
+	return 0
+}
+
+// copyWithProgressAndStats copies src to dst.
+// If showProgress is true and size is positive, a progress bar is shown.
+// After copying, final stats about the transfer speed and size are shown.
+// Progress and stats are printed to stderr.
+func copyWithProgressAndStats(dst io.Writer, src io.Reader, size int, showProgress bool) error {
+	var progress *pb.ProgressBar
+	if showProgress && size > 0 {
+		progress = pb.New(size)
+		// Write to stderr in case dst is stdout.
+		progress.Output = os.Stderr
+		progress.ShowSpeed = true
+		progress.ShowPercent = true
+		progress.ShowTimeLeft = true
+		progress.SetUnits(pb.U_BYTES)
+		progress.Start()
+		// Tee the source, so dst can still tell io.Copy to read into its own buffers.
+		src = io.TeeReader(src, progress)
+	}
+
+	startTime := time.Now()
+	copied, err := io.Copy(dst, src)
+
+	if progress != nil {
+		progress.Finish()
+	}
+
+	if pathErr, ok := err.(*os.PathError); ok {
+		if errno, ok := pathErr.Err.(syscall.Errno); ok && errno == syscall.EPIPE {
+			// Pipe closed. Handle this like an EOF.
+			err = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	duration := time.Now().Sub(startTime)
+	rate := int64(float64(copied) / duration.Seconds())
+	fmt.Fprintf(os.Stderr, "%d B/s (%d bytes in %s)\n", rate, copied, duration)
+
+	return nil
+}
@@ -4,9 +4,9 @@ package errors
 
 import "fmt"
 
-const _ErrCode_name = "AssertionErrorParseErrorServerNotAvailableNetworkErrorConnectionResetErrorAdbErrorDeviceNotFoundFileNoExistError"
+const _ErrCode_name = "AssertionErrorParseErrorServerNotAvailableNetworkErrorConnectionResetErrorAdbErrorDeviceNotFoundFileNoExistErrorDeviceOfflineDeviceUnauthorizedPermissionDeniedReadOnlyFileSystemUnsupportedServer"
 
-var _ErrCode_index = [...]uint8{0, 14, 24, 42, 54, 74, 82, 96, 112}
+var _ErrCode_index = [...]uint8{0, 14, 24, 42, 54, 74, 82, 96, 112, 125, 143, 159, 177, 194}
 
 func (i ErrCode) String() string {
 	if i >= ErrCode(len(_ErrCode_index)-1) {
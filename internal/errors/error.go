@@ -2,13 +2,19 @@ package errors
 
 import (
 	"bytes"
+	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
 )
 
 /*
 Err is the implementation of error that all goadb functions return.
 
-Best Practice
+# Best Practice
 
 External errors should be wrapped using WrapErrorf, as soon as they are known about.
 
@@ -32,6 +38,7 @@ type Err struct {
 var _ error = &Err{}
 
 // Keep this in sync with ../error.go.
+//
 //go:generate stringer -type=ErrCode
 type ErrCode byte
 
@@ -50,8 +57,35 @@ const (
 	DeviceNotFound
 	// Tried to perform an operation on a path that doesn't exist on the device.
 	FileNoExistError
+	// The server returned a "device offline" error.
+	DeviceOffline
+	// The server returned a "device unauthorized" error, the device hasn't accepted this host's key.
+	DeviceUnauthorized
+	// A command on the device was denied access to a path.
+	PermissionDenied
+	// A command on the device tried to modify a path on a read-only file system.
+	ReadOnlyFileSystem
+	// The server is too old to support a request.
+	UnsupportedServer
 )
 
+/*
+Sentinel is an error value that matches, via errors.Is, every *Err with the same Code.
+
+E.g.
+
+	var ErrDeviceNotFound = &Sentinel{DeviceNotFound, "device not found"}
+	errors.Is(Errorf(DeviceNotFound, "device 'foo' not found"), ErrDeviceNotFound) // true
+*/
+type Sentinel struct {
+	Code    ErrCode
+	Message string
+}
+
+func (s *Sentinel) Error() string {
+	return s.Message
+}
+
 func Errorf(code ErrCode, format string, args ...interface{}) error {
 	return &Err{
 		Code:    code,
@@ -60,21 +94,47 @@ func Errorf(code ErrCode, format string, args ...interface{}) error {
 }
 
 /*
-WrapErrf returns an *Err that wraps another *Err and has the same ErrCode.
-Panics if cause is not an *Err.
+WrapErrf returns an *Err that wraps another error and has the same ErrCode.
+If cause is not an *Err, its code is chosen by CodeOf.
 
-To wrap generic errors, use WrapErrorf.
+To wrap generic errors with a specific code, use WrapErrorf.
 */
 func WrapErrf(cause error, format string, args ...interface{}) error {
 	if cause == nil {
 		return nil
 	}
 
-	err := cause.(*Err)
 	return &Err{
-		Code:    err.Code,
+		Code:    CodeOf(cause),
 		Message: fmt.Sprintf(format, args...),
-		Cause:   err,
+		Cause:   cause,
+	}
+}
+
+/*
+CodeOf returns the ErrCode that best describes err.
+
+If err is, or wraps, an *Err, returns its Code. Otherwise, errors from the standard library are
+classified by kind, e.g. os.ErrNotExist is a FileNoExistError, io.EOF and connection resets are
+ConnectionResetErrors, and net errors are NetworkErrors. Anything else is an AssertionError.
+*/
+func CodeOf(err error) ErrCode {
+	var adbErr *Err
+	var netErr net.Error
+	switch {
+	case stderrors.As(err, &adbErr):
+		return adbErr.Code
+	case stderrors.Is(err, os.ErrNotExist):
+		return FileNoExistError
+	case stderrors.Is(err, io.EOF), stderrors.Is(err, io.ErrUnexpectedEOF), stderrors.Is(err, io.ErrClosedPipe),
+		stderrors.Is(err, syscall.ECONNRESET), stderrors.Is(err, syscall.EPIPE):
+		return ConnectionResetError
+	case stderrors.Is(err, syscall.ECONNREFUSED):
+		return ServerNotAvailable
+	case stderrors.As(err, &netErr), stderrors.Is(err, context.DeadlineExceeded), stderrors.Is(err, context.Canceled):
+		return NetworkError
+	default:
+		return AssertionError
 	}
 }
 
@@ -103,6 +163,22 @@ func CombineErrs(msg string, code ErrCode, errs ...error) error {
 
 type multiError []error
 
+// Unwrap returns all the errors, for errors.Is and errors.As.
+func (errs multiError) Unwrap() []error {
+	return errs
+}
+
+// Is reports whether any of the errors matches target. Go versions before 1.20 don't use
+// Unwrap() []error.
+func (errs multiError) Is(target error) bool {
+	for _, err := range errs {
+		if stderrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 func (errs multiError) Error() string {
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "%d errors: [", len(errs))
@@ -120,6 +196,7 @@ func (errs multiError) Error() string {
 WrapErrorf returns an *Err that wraps another arbitrary error with an ErrCode and a message.
 
 If cause is nil, returns nil, so you can use it like
+
 	return util.WrapErrorf(DoSomethingDangerous(), util.NetworkError, "well that didn't work")
 
 If cause is known to be of type *Err, use WrapErrf.
@@ -151,6 +228,22 @@ func (err *Err) Error() string {
 	return msg
 }
 
+// Unwrap returns the cause of err, for errors.Is and errors.As.
+func (err *Err) Unwrap() error {
+	return err.Cause
+}
+
+// Is reports whether target is a *Sentinel with err's Code. FileNoExistError also matches
+// os.ErrNotExist, and PermissionDenied os.ErrPermission, so device paths can be checked like
+// local ones.
+func (err *Err) Is(target error) bool {
+	if sentinel, ok := target.(*Sentinel); ok {
+		return sentinel.Code == err.Code
+	}
+	return (target == os.ErrNotExist && err.Code == FileNoExistError) ||
+		(target == os.ErrPermission && err.Code == PermissionDenied)
+}
+
 // HasErrCode returns true if err is an *Err and err.Code == code.
 func HasErrCode(err error, code ErrCode) bool {
 	switch err := err.(type) {
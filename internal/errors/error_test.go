@@ -1,7 +1,13 @@
 package errors
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,3 +48,66 @@ func TestCombineErrors(t *testing.T) {
 	assert.Equal(t, `AdbError: hello
 caused by 2 errors: [lulz ∪ fail]`, ErrorWithCauseChain(err))
 }
+
+func TestErrIsSentinel(t *testing.T) {
+	sentinel := &Sentinel{DeviceNotFound, "device not found"}
+	err := WrapErrf(Errorf(DeviceNotFound, "device 'foo' not found"), "error getting serial")
+
+	assert.True(t, errors.Is(err, sentinel))
+	assert.False(t, errors.Is(err, &Sentinel{AdbError, "adb error"}))
+	assert.False(t, errors.Is(Errorf(AdbError, "fail"), sentinel))
+
+	// Sentinels match codes anywhere in the cause chain.
+	assert.True(t, errors.Is(WrapErrorf(err, NetworkError, "wrapped"), sentinel))
+}
+
+func TestErrIsNotExist(t *testing.T) {
+	assert.True(t, errors.Is(Errorf(FileNoExistError, "no such file"), os.ErrNotExist))
+	assert.False(t, errors.Is(Errorf(AdbError, "fail"), os.ErrNotExist))
+}
+
+func TestErrIsPermission(t *testing.T) {
+	assert.True(t, errors.Is(Errorf(PermissionDenied, "permission denied"), os.ErrPermission))
+	assert.False(t, errors.Is(Errorf(ReadOnlyFileSystem, "read-only file system"), os.ErrPermission))
+}
+
+func TestErrAs(t *testing.T) {
+	cause := &os.PathError{Op: "open", Path: "foo", Err: os.ErrPermission}
+	err := WrapErrorf(cause, AssertionError, "error opening")
+
+	var pathErr *os.PathError
+	assert.True(t, errors.As(err, &pathErr))
+	assert.Equal(t, cause, pathErr)
+	assert.True(t, errors.Is(err, os.ErrPermission))
+}
+
+func TestCombineErrsIs(t *testing.T) {
+	err := CombineErrs("hello", AdbError, Errorf(NetworkError, "fail"), Errorf(ConnectionResetError, "reset"))
+	assert.True(t, errors.Is(err, &Sentinel{ConnectionResetError, "connection reset"}))
+	assert.False(t, errors.Is(err, &Sentinel{DeviceNotFound, "device not found"}))
+}
+
+func TestCodeOf(t *testing.T) {
+	for _, test := range []struct {
+		Err  error
+		Want ErrCode
+	}{
+		{Errorf(DeviceNotFound, "device not found"), DeviceNotFound},
+		{fmt.Errorf("wrapped: %w", Errorf(ParseError, "bad")), ParseError},
+		{&os.PathError{Op: "open", Path: "foo", Err: os.ErrNotExist}, FileNoExistError},
+		{io.EOF, ConnectionResetError},
+		{&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, ConnectionResetError},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, ServerNotAvailable},
+		{&net.DNSError{Err: "no such host", Name: "foo"}, NetworkError},
+		{context.DeadlineExceeded, NetworkError},
+		{errors.New("something else"), AssertionError},
+	} {
+		assert.Equal(t, test.Want, CodeOf(test.Err), "%v", test.Err)
+	}
+}
+
+func TestWrapErrfForeignError(t *testing.T) {
+	cause := errors.New("fail")
+	err := WrapErrf(cause, "wrapped")
+	assert.Equal(t, &Err{Code: AssertionError, Message: "wrapped", Cause: cause}, err)
+}
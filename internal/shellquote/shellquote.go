@@ -0,0 +1,29 @@
+// Package shellquote quotes arguments for the device shell, for the goadb packages and modules
+// that build command lines.
+package shellquote
+
+import (
+	"regexp"
+	"strings"
+)
+
+var safeArgRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Join joins args into a single command line, quoting each argument so the device shell passes
+// it through unchanged.
+func Join(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = Quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Quote wraps arg in single quotes if it contains anything other than characters that are
+// always safe in a POSIX shell.
+func Quote(arg string) string {
+	if arg != "" && safeArgRegex.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
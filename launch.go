@@ -0,0 +1,151 @@
+package adb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// LaunchResult is the timing of an activity launch, as reported by am start -W.
+type LaunchResult struct {
+	// How the activity started, e.g. COLD, WARM, or HOT. Only reported since Android 10.
+	LaunchState string
+	// Time to launch the activity itself, without any trampoline activities before it. Not
+	// reported since Android 10.
+	ThisTime time.Duration
+	// Time from the intent being sent until the activity drew its first frame, the standard
+	// startup metric.
+	TotalTime time.Duration
+	// Time am waited for the launch, including the time the system took to handle it.
+	WaitTime time.Duration
+	// Time in the "Displayed" line the system logs when the first frame is drawn, or 0 if it
+	// wasn't found in the log.
+	Displayed time.Duration
+}
+
+var (
+	// Matches the "TotalTime: 1234" style lines of am start -W.
+	amStartTimePattern = regexp.MustCompile(`(?m)^(ThisTime|TotalTime|WaitTime): (\d+)`)
+
+	// Matches the "LaunchState: COLD" line of am start -W.
+	amStartLaunchStatePattern = regexp.MustCompile(`(?m)^LaunchState: (\w+)`)
+
+	// Matches the time of a "Displayed com.example/.MainActivity: +1s234ms" log line.
+	displayedTimePattern = regexp.MustCompile(`Displayed (\S+): \+(?:(\d+)s)?(\d+)ms`)
+)
+
+/*
+ForceStop stops everything associated with pkg, its processes, services, and alarms.
+
+Corresponds to the command:
+
+	adb shell am force-stop <package>
+*/
+func (c *Device) ForceStop(pkg string) error {
+	args := append(append([]string{"am", "force-stop"}, c.userArgs()...), pkg)
+	output, err := c.runShellCommand(quoteShellArgs(args...))
+	if err == nil {
+		err = commandOutputError("am force-stop", output)
+	}
+	return wrapClientError(err, c, "ForceStop(%s)", pkg)
+}
+
+/*
+MeasureLaunch force-stops the package of activity, cold-starts activity, and waits for it to
+draw its first frame. It returns the launch times reported by am and logged by the system.
+
+Corresponds to the commands:
+
+	adb shell am force-stop <package>
+	adb shell am start -W -n <package>/<activity>
+	adb shell logcat -d -t <start time> -s ActivityTaskManager:I ActivityManager:I
+*/
+func (c *Device) MeasureLaunch(activity Activity) (*LaunchResult, error) {
+	if err := c.ForceStop(activity.Package); err != nil {
+		return nil, err
+	}
+	result, err := c.launch(activity)
+	return result, wrapClientError(err, c, "MeasureLaunch(%s)", activity)
+}
+
+// launch starts activity, waits for it, and returns its launch times.
+func (c *Device) launch(activity Activity) (*LaunchResult, error) {
+	// Read the device clock, so only log lines from this launch are searched.
+	startTime, err := c.runShellCommand("date +%s")
+	if err != nil {
+		return nil, err
+	}
+	startTime = strings.TrimSpace(startTime)
+
+	args := append(append([]string{"am", "start", "-W"}, c.userArgs()...), "-n", activity.Component())
+	output, err := c.runShellCommand(quoteShellArgs(args...))
+	if err != nil {
+		return nil, err
+	}
+	result, err := parseAmStartW(output)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := strconv.Atoi(startTime); err == nil {
+		output, err := c.runShellCommand(quoteShellArgs("logcat", "-d", "-t", startTime+".000",
+			"-s", "ActivityTaskManager:I", "ActivityManager:I"))
+		if err != nil {
+			return nil, err
+		}
+		result.Displayed = parseDisplayedTime(output, activity)
+	}
+	return result, nil
+}
+
+// parseAmStartW parses the output of am start -W.
+func parseAmStartW(output string) (*LaunchResult, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Error") || strings.HasPrefix(line, "Warning: Activity not started") {
+			return nil, errors.Errorf(errors.AdbError, "am start failed: %s", strings.TrimSpace(output))
+		}
+	}
+
+	result := &LaunchResult{}
+	if match := amStartLaunchStatePattern.FindStringSubmatch(output); match != nil {
+		result.LaunchState = match[1]
+	}
+	for _, match := range amStartTimePattern.FindAllStringSubmatch(output, -1) {
+		ms, _ := strconv.Atoi(match[2])
+		d := time.Duration(ms) * time.Millisecond
+		switch match[1] {
+		case "ThisTime":
+			result.ThisTime = d
+		case "TotalTime":
+			result.TotalTime = d
+		case "WaitTime":
+			result.WaitTime = d
+		}
+	}
+	if result.TotalTime == 0 && result.WaitTime == 0 {
+		return nil, errors.Errorf(errors.ParseError, "no launch times in am start output: %q", output)
+	}
+	return result, nil
+}
+
+// parseDisplayedTime returns the time of the last Displayed log line for activity, or 0.
+func parseDisplayedTime(output string, activity Activity) time.Duration {
+	if strings.HasPrefix(activity.Activity, ".") {
+		activity.Activity = activity.Package + activity.Activity
+	}
+	var displayed time.Duration
+	for _, match := range displayedTimePattern.FindAllStringSubmatch(output, -1) {
+		logged, ok := parseComponent(match[1])
+		if !ok || *logged != activity {
+			continue
+		}
+		seconds, _ := strconv.Atoi(match[2])
+		ms, _ := strconv.Atoi(match[3])
+		displayed = time.Duration(seconds)*time.Second + time.Duration(ms)*time.Millisecond
+	}
+	return displayed
+}
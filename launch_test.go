@@ -0,0 +1,64 @@
+package adb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseAmStartW(t *testing.T) {
+	result, err := parseAmStartW(`Starting: Intent { cmp=com.example/.MainActivity }
+Status: ok
+LaunchState: COLD
+Activity: com.example/.MainActivity
+TotalTime: 1234
+WaitTime: 1250
+Complete
+`)
+	require.NoError(t, err)
+	assert.Equal(t, &LaunchResult{LaunchState: "COLD", TotalTime: 1234 * time.Millisecond, WaitTime: 1250 * time.Millisecond}, result)
+
+	result, err = parseAmStartW("Status: ok\r\nThisTime: 400\r\nTotalTime: 600\r\nWaitTime: 650\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, 400*time.Millisecond, result.ThisTime)
+
+	_, err = parseAmStartW("Starting: Intent { cmp=com.example/.Missing }\nError type 3\nError: Activity class {com.example/com.example.Missing} does not exist.\n")
+	assert.True(t, HasErrCode(err, AdbError))
+	_, err = parseAmStartW("Status: timeout\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestParseDisplayedTime(t *testing.T) {
+	output := `I/ActivityTaskManager( 612): Displayed com.other/.Main: +90ms
+I/ActivityTaskManager( 612): Displayed com.example/.MainActivity: +1s234ms
+I/ActivityTaskManager( 612): Displayed com.example/com.example.Settings: +345ms (total +2s3ms)
+`
+	assert.Equal(t, 1234*time.Millisecond, parseDisplayedTime(output, Activity{"com.example", ".MainActivity"}))
+	assert.Equal(t, 345*time.Millisecond, parseDisplayedTime(output, Activity{"com.example", "com.example.Settings"}))
+	assert.Equal(t, time.Duration(0), parseDisplayedTime(output, Activity{"com.example", ".Missing"}))
+}
+
+func TestMeasureLaunch(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"date +%s": "1500000000\n",
+			"am start -W -n com.example/.MainActivity":                               "Status: ok\nLaunchState: COLD\nTotalTime: 800\nWaitTime: 820\nComplete\n",
+			"logcat -d -t 1500000000.000 -s ActivityTaskManager:I ActivityManager:I": "I/ActivityTaskManager( 612): Displayed com.example/.MainActivity: +812ms\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	result, err := device.MeasureLaunch(Activity{"com.example", ".MainActivity"})
+	require.NoError(t, err)
+	assert.Equal(t, &LaunchResult{
+		LaunchState: "COLD",
+		TotalTime:   800 * time.Millisecond,
+		WaitTime:    820 * time.Millisecond,
+		Displayed:   812 * time.Millisecond,
+	}, result)
+	assert.Contains(t, s.Requests, "shell:am force-stop com.example")
+}
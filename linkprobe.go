@@ -0,0 +1,141 @@
+package adb
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Defaults used for zero LinkProbeOptions fields.
+const (
+	DefaultLinkProbePings     = 10
+	DefaultLinkProbePushBytes = 8 << 20
+)
+
+// LinkProbeOptions configures ProbeLink. The zero value uses the defaults.
+type LinkProbeOptions struct {
+	// Number of echo round trips. Defaults to DefaultLinkProbePings.
+	Pings int
+	// Number of bytes pushed to measure throughput. Defaults to DefaultLinkProbePushBytes. -1
+	// skips the push.
+	PushBytes int64
+}
+
+// LinkQuality is the latency and throughput of the connection between the host and a device,
+// measured by ProbeLink.
+type LinkQuality struct {
+	// Time each successful echo round trip took, in order.
+	Latencies        []time.Duration
+	Min, Median, Max time.Duration
+	// Mean difference between consecutive round trips. High jitter with a low median is typical
+	// of a flaky USB hub or a congested Wi-Fi network.
+	Jitter time.Duration
+	// Number of round trips that failed.
+	Failures int
+
+	// Stats of the push, nil if it was skipped. The duration includes waiting for the device to
+	// acknowledge the data, so it's the rate the device actually received it at.
+	Push *TransferStats
+}
+
+/*
+ProbeLink measures the connection between the host and the device, through the adb server:
+the latency of shell round trips, each over a new connection like most commands, and the
+throughput of a push to /dev/null. Farms can use it to find devices on bad cables, hubs, or
+networks before scheduling work on them.
+
+It returns an error if every round trip or the push fails, or ctx is done. Individual round trip
+failures are counted in Failures.
+
+Corresponds to the commands:
+
+	adb shell echo
+	adb push <data> /dev/null
+*/
+func (c *Device) ProbeLink(ctx context.Context, opts LinkProbeOptions) (*LinkQuality, error) {
+	if opts.Pings <= 0 {
+		opts.Pings = DefaultLinkProbePings
+	}
+	if opts.PushBytes == 0 {
+		opts.PushBytes = DefaultLinkProbePushBytes
+	}
+
+	quality := &LinkQuality{}
+	var lastErr error
+	for i := 0; i < opts.Pings; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, wrapClientError(err, c, "ProbeLink")
+		}
+		start := time.Now()
+		if _, err := c.runShellCommand("echo"); err != nil {
+			quality.Failures++
+			lastErr = err
+			continue
+		}
+		quality.Latencies = append(quality.Latencies, time.Since(start))
+	}
+	if len(quality.Latencies) == 0 {
+		return nil, wrapClientError(lastErr, c, "ProbeLink")
+	}
+	quality.summarizeLatencies()
+
+	if opts.PushBytes > 0 {
+		stats, err := c.pushToDevNull(ctx, opts.PushBytes)
+		if err != nil {
+			return nil, wrapClientError(err, c, "ProbeLink")
+		}
+		quality.Push = stats
+	}
+	return quality, nil
+}
+
+func (q *LinkQuality) summarizeLatencies() {
+	sorted := append([]time.Duration(nil), q.Latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	q.Min, q.Median, q.Max = sorted[0], percentile(sorted, 50), sorted[len(sorted)-1]
+
+	if len(q.Latencies) < 2 {
+		return
+	}
+	var total time.Duration
+	for i := 1; i < len(q.Latencies); i++ {
+		diff := q.Latencies[i] - q.Latencies[i-1]
+		if diff < 0 {
+			diff = -diff
+		}
+		total += diff
+	}
+	q.Jitter = total / time.Duration(len(q.Latencies)-1)
+}
+
+// pushToDevNull pushes size zero bytes to /dev/null. It doesn't use pushReader, which removes
+// the destination if the push fails.
+func (c *Device) pushToDevNull(ctx context.Context, size int64) (*TransferStats, error) {
+	start := time.Now()
+	writer, err := c.OpenWrite("/dev/null", 0666, MtimeOfClose)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := copyWithStats(writer, &ctxErrReader{ctx: ctx, Reader: io.LimitReader(zeroReader{}, size)}, size, nil)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.NetworkError, "error pushing to /dev/null")
+	}
+	stats.Duration = time.Since(start)
+	return stats, nil
+}
+
+// zeroReader reads an endless stream of zeroes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,45 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestSummarizeLatencies(t *testing.T) {
+	quality := &LinkQuality{Latencies: []time.Duration{10 * time.Millisecond, 30 * time.Millisecond, 20 * time.Millisecond}}
+	quality.summarizeLatencies()
+	assert.Equal(t, 10*time.Millisecond, quality.Min)
+	assert.Equal(t, 20*time.Millisecond, quality.Median)
+	assert.Equal(t, 30*time.Millisecond, quality.Max)
+	assert.Equal(t, 15*time.Millisecond, quality.Jitter)
+
+	quality = &LinkQuality{Latencies: []time.Duration{time.Millisecond}}
+	quality.summarizeLatencies()
+	assert.Equal(t, time.Millisecond, quality.Median)
+	assert.Equal(t, time.Duration(0), quality.Jitter)
+}
+
+func TestProbeLink(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Errs:   []error{nil, nil, errors.Errorf(errors.NetworkError, "reset")},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	quality, err := device.ProbeLink(context.Background(), LinkProbeOptions{Pings: 3, PushBytes: -1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, quality.Failures)
+	assert.Len(t, quality.Latencies, 2)
+	assert.Nil(t, quality.Push)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = device.ProbeLink(ctx, LinkProbeOptions{})
+	assert.Error(t, err)
+}
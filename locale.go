@@ -0,0 +1,168 @@
+package adb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// LocaleMethod selects how SetLocale changes the device locale, since no single method
+// works on every build.
+type LocaleMethod int
+
+const (
+	// LocaleViaSettings writes system_locales in the system settings table. Works without root
+	// on API 24+, but only takes effect for processes started after the change.
+	LocaleViaSettings LocaleMethod = iota
+	// LocaleViaProperty sets persist.sys.locale and restarts the framework. Requires root.
+	LocaleViaProperty
+	// LocaleViaBroadcast sends a broadcast to a locale-changer helper app installed on the
+	// device (e.g. io.appium.settings), which applies the locale through the platform API.
+	LocaleViaBroadcast
+)
+
+const (
+	// Broadcast action and receiver used by LocaleViaBroadcast.
+	localeChangerAction   = "io.appium.settings.locale"
+	localeChangerReceiver = "io.appium.settings/.receivers.LocaleSettingReceiver"
+
+	// Transaction code of IAlarmManager.setTimeZone.
+	alarmSetTimeZoneTransaction = 3
+)
+
+var (
+	// Matches BCP 47 language tags like "en", "en-US", and "zh-Hans-CN".
+	localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+	// Matches IANA time zone names like UTC, America/Argentina/Buenos_Aires and Etc/GMT+5.
+	timezonePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+\-]*(/[A-Za-z0-9_+\-]+)*$`)
+)
+
+// Locale returns the device's current locale as a BCP 47 language tag, e.g. en-US.
+func (c *Device) Locale() (string, error) {
+	for _, prop := range []string{"persist.sys.locale", "ro.product.locale"} {
+		locale, err := c.GetProperty(prop)
+		if err != nil {
+			return "", err
+		}
+		if locale != "" {
+			return locale, nil
+		}
+	}
+
+	// API 19 and older split the locale into language and country.
+	language, err := c.GetProperty("persist.sys.language")
+	if err != nil {
+		return "", err
+	}
+	country, err := c.GetProperty("persist.sys.country")
+	if err != nil {
+		return "", err
+	}
+	if country == "" {
+		return language, nil
+	}
+	return language + "-" + country, nil
+}
+
+// SetLocale changes the device locale to locale, a BCP 47 language tag like fr-FR,
+// using method.
+func (c *Device) SetLocale(locale string, method LocaleMethod) error {
+	if !localePattern.MatchString(locale) {
+		return wrapClientError(errors.AssertionErrorf("invalid locale: %q", locale), c, "SetLocale")
+	}
+
+	var (
+		output string
+		err    error
+	)
+	switch method {
+	case LocaleViaSettings:
+		return c.Settings().Put(SettingsSystem, "system_locales", locale)
+	case LocaleViaProperty:
+		output, err = c.runRootShellCommand(quoteShellArgs("setprop", "persist.sys.locale", locale) +
+			" && setprop ctl.restart zygote")
+	case LocaleViaBroadcast:
+		parts := strings.SplitN(locale, "-", 2)
+		args := []string{"am", "broadcast", "-a", localeChangerAction, "-n", localeChangerReceiver,
+			"--es", "lang", parts[0]}
+		if len(parts) > 1 {
+			args = append(args, "--es", "country", parts[1])
+		}
+		output, err = c.runShellCommand(quoteShellArgs(args...))
+		if err == nil && !strings.Contains(output, "result=-1") {
+			err = errors.Errorf(errors.AdbError, "locale changer did not accept broadcast: %s", strings.TrimSpace(output))
+		}
+	default:
+		err = errors.AssertionErrorf("invalid locale method: %d", method)
+	}
+	if err == nil && method != LocaleViaBroadcast {
+		err = commandOutputError("setprop", output)
+	}
+	return wrapClientError(err, c, "SetLocale(%s)", locale)
+}
+
+// Timezone returns the device's time zone, as an IANA name like Europe/Paris.
+func (c *Device) Timezone() (string, error) {
+	return c.GetProperty("persist.sys.timezone")
+}
+
+// SetTimezone changes the device's time zone to tz, an IANA name like America/New_York.
+// Automatic time zone detection is disabled first, so the change isn't reverted by the network.
+func (c *Device) SetTimezone(tz string) error {
+	if !timezonePattern.MatchString(tz) {
+		return wrapClientError(errors.AssertionErrorf("invalid time zone: %q", tz), c, "SetTimezone")
+	}
+
+	if err := c.Settings().PutBool(SettingsGlobal, "auto_time_zone", false); err != nil {
+		return err
+	}
+	output, err := c.runShellCommand(quoteShellArgs("service", "call", "alarm",
+		strconv.Itoa(alarmSetTimeZoneTransaction), "s16", tz))
+	if err == nil && !strings.Contains(output, "Parcel") {
+		err = errors.Errorf(errors.AdbError, "unexpected response from alarm service: %s", strings.TrimSpace(output))
+	}
+	return wrapClientError(err, c, "SetTimezone(%s)", tz)
+}
+
+// Time returns the current time on the device clock, with second precision.
+func (c *Device) Time() (time.Time, error) {
+	output, err := c.RunCommand("date", "+%s")
+	if err != nil {
+		return time.Time{}, wrapClientError(err, c, "Time")
+	}
+	seconds, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, wrapClientError(errors.WrapErrorf(err, errors.ParseError, "invalid date output: %q", output),
+			c, "Time")
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// SetTime sets the device clock to t. Requires root.
+// Devices whose date command doesn't accept Unix timestamps are set using the host's
+// local time, so the host and device time zones should match.
+// Automatic time is disabled first, so the change isn't reverted by the network.
+func (c *Device) SetTime(t time.Time) error {
+	if err := c.Settings().PutBool(SettingsGlobal, "auto_time", false); err != nil {
+		return err
+	}
+
+	// Toybox date accepts @<unix seconds>, older toolbox only -s in local time.
+	cmdLine := "date -u @" + strconv.FormatInt(t.Unix(), 10) + " || date -s " +
+		t.Local().Format("20060102.150405")
+	output, err := c.runRootShellCommand(cmdLine)
+	if err != nil {
+		return wrapClientError(err, c, "SetTime")
+	}
+	if strings.Contains(output, "Operation not permitted") || strings.Contains(output, "bad date") {
+		return wrapClientError(errors.Errorf(errors.AdbError, "date failed: %s", strings.TrimSpace(output)), c, "SetTime")
+	}
+
+	// Let apps know the clock changed.
+	_, err = c.RunCommand("am", "broadcast", "-a", "android.intent.action.TIME_SET")
+	return wrapClientError(err, c, "SetTime")
+}
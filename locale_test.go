@@ -0,0 +1,35 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestSetLocaleValidates(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	for _, locale := range []string{"", "en_US", "en US", "e"} {
+		assert.True(t, HasErrCode(device.SetLocale(locale, LocaleViaSettings), AssertionError), locale)
+	}
+	assert.Empty(t, s.Requests)
+}
+
+func TestSetLocaleViaSettings(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	assert.NoError(t, device.SetLocale("fr-FR", LocaleViaSettings))
+	assert.Equal(t, "shell:settings put system system_locales fr-FR", s.Requests[1])
+}
+
+func TestTimezonePattern(t *testing.T) {
+	for _, tz := range []string{"UTC", "Europe/Paris", "America/Argentina/Buenos_Aires", "Etc/GMT+5"} {
+		assert.True(t, timezonePattern.MatchString(tz), tz)
+	}
+	for _, tz := range []string{"", "Europe/", "a b", "$(reboot)"} {
+		assert.False(t, timezonePattern.MatchString(tz), tz)
+	}
+}
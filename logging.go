@@ -0,0 +1,25 @@
+package adb
+
+/*
+Logger receives logs from the client. It's satisfied by *slog.Logger, and easy to adapt to
+other structured loggers. Args are alternating keys and values, as for slog.
+
+Each request to the server, e.g. host:version or host:transport-any followed by shell:ls, is
+logged at info level with its duration and error, if any. At debug level, every message read
+from or written to the server is logged with a hex dump.
+*/
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+/*
+WithLogger returns a Device that logs its requests to logger. Other Devices, even from the
+same Adb, aren't affected. To log the requests of all devices, set ServerConfig.Logger.
+*/
+func (c *Device) WithLogger(logger Logger) *Device {
+	logged := *c
+	logged.server = observeServer(c.server, logger, nil)
+	return &logged
+}
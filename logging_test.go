@@ -0,0 +1,100 @@
+package adb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+type logEntry struct {
+	Level string
+	Msg   string
+	Attrs map[string]interface{}
+}
+
+type testLogger struct {
+	entries []logEntry
+}
+
+func (l *testLogger) log(level, msg string, args []interface{}) {
+	attrs := map[string]interface{}{}
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[fmt.Sprint(args[i])] = args[i+1]
+	}
+	l.entries = append(l.entries, logEntry{level, msg, attrs})
+}
+
+func (l *testLogger) Debug(msg string, args ...interface{}) { l.log("debug", msg, args) }
+func (l *testLogger) Info(msg string, args ...interface{})  { l.log("info", msg, args) }
+func (l *testLogger) Warn(msg string, args ...interface{})  { l.log("warn", msg, args) }
+
+func (l *testLogger) at(level string) []logEntry {
+	var entries []logEntry
+	for _, entry := range l.entries {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestDeviceWithLogger(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"output"},
+	}
+	logger := &testLogger{}
+	device := (&Adb{s}).Device(AnyDevice()).WithLogger(logger)
+
+	output, err := device.RunCommand("ls")
+	assert.NoError(t, err)
+	assert.Equal(t, "output", output)
+
+	requests := logger.at("info")
+	require.Len(t, requests, 1)
+	assert.Equal(t, "adb request", requests[0].Msg)
+	assert.Equal(t, "host:transport-any shell:ls", requests[0].Attrs["request"])
+	// Two OKAY statuses and the output.
+	assert.Equal(t, int64(len("OKAYOKAYoutput")), requests[0].Attrs["bytes_in"])
+	assert.NotContains(t, requests[0].Attrs, "error")
+
+	messages := logger.at("debug")
+	require.NotEmpty(t, messages)
+	assert.Equal(t, "send message", messages[0].Attrs["op"])
+	assert.Contains(t, messages[0].Attrs["dump"], "host:transpor")
+}
+
+func TestLoggingServerLogsErrors(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Errs:   []error{nil, nil, errors.Errorf(errors.AdbError, "fail")},
+	}
+	logger := &testLogger{}
+	_, err := (&Adb{observeServer(s, logger, nil)}).ServerVersion()
+	assert.Error(t, err)
+
+	requests := logger.at("info")
+	require.Len(t, requests, 1)
+	assert.Equal(t, "host:version", requests[0].Attrs["request"])
+	assert.Contains(t, requests[0].Attrs, "error")
+}
+
+func TestDeviceWithLoggerDoesNotAffectOtherDevices(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess, Messages: []string{"a", "b"}}
+	logger := &testLogger{}
+	client := &Adb{s}
+	client.Device(AnyDevice()).WithLogger(logger).WithLogger(logger)
+	_, err := client.Device(AnyDevice()).RunCommand("ls")
+	assert.NoError(t, err)
+	assert.Empty(t, logger.entries)
+}
+
+func TestHexDumpTruncates(t *testing.T) {
+	dump := hexDump(make([]byte, wireDumpLimit+1))
+	assert.Contains(t, dump, "...\n")
+	assert.Equal(t, "00000000  68 69                                             |hi|\n", hexDump([]byte("hi")))
+}
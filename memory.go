@@ -0,0 +1,453 @@
+package adb
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Prints the smaps_rollup of every process, each after a "pid <pid> <cmdline>" line.
+const smapsRollupsCommand = `cd /proc && for p in [0-9]*; do ` +
+	`echo "pid $p $(tr '\0' ' ' < $p/cmdline 2>/dev/null)"; cat $p/smaps_rollup 2>/dev/null; done`
+
+var (
+	// Matches the "7f1234000-7f1235000 r-xp 00000000 fd:00 1234   /system/lib64/libc.so" line
+	// that starts a mapping in smaps.
+	smapsMappingLinePattern = regexp.MustCompile(`^[0-9a-f]+-[0-9a-f]+ \S{4} [0-9a-f]+ \S+ \d+\s*(.*)$`)
+
+	// Matches the "Pss:  1234 kB" lines of smaps.
+	smapsFieldLinePattern = regexp.MustCompile(`^(\w+):\s+(\d+) kB$`)
+)
+
+// ProcessMemory is the memory used by a process. Sizes are in KiB.
+type ProcessMemory struct {
+	PID int
+	// Command line of the process, the package name for app processes.
+	Name string
+	// Virtual set size, 0 if it's not known.
+	VSS int64
+	// Resident set size, the memory mapped into the process, including shared pages.
+	RSS int64
+	// Proportional set size, RSS with shared pages split evenly between the processes sharing
+	// them. The PSS of all processes adds up to the memory in use.
+	PSS int64
+	// Unique set size, the private memory that would be freed if the process exited.
+	USS int64
+	// Memory swapped out, e.g. to zram.
+	Swap int64
+}
+
+// MemoryMapping is the memory used by the mappings of a file, or of an anonymous region like
+// [anon:dalvik-main space], in a process. Sizes are in KiB.
+type MemoryMapping struct {
+	Name string
+	// Number of mappings with the name.
+	Count int
+
+	VSS, RSS, PSS              int64
+	SharedClean, SharedDirty   int64
+	PrivateClean, PrivateDirty int64
+	Swap                       int64
+}
+
+// LibraryMemory is the memory used by a file or anonymous region mapped by several processes.
+type LibraryMemory struct {
+	Name string
+	// Total RSS of the mappings, in KiB.
+	RSS int64
+	// The memory each process uses for the mappings. Name is the process name, and VSS, RSS,
+	// PSS, USS, and Swap are those of its mappings of the library.
+	Processes []ProcessMemory
+}
+
+/*
+ProcessMemory returns the memory used by every process, most PSS first.
+
+Builds that have procrank, like eng and userdebug builds, run it. Other rooted devices read the
+smaps_rollup of every process, which needs Linux 4.14 or later. Both need root.
+
+Corresponds to the commands:
+
+	adb shell procrank
+	adb shell cat /proc/<pid>/smaps_rollup
+*/
+func (c *Device) ProcessMemory() ([]ProcessMemory, error) {
+	var processes []ProcessMemory
+	output, err := c.runMemoryTool("procrank")
+	if err == nil && output != "" {
+		processes, err = parseProcrank(output)
+	} else if err == nil {
+		if output, err = c.runRootShellCommand(smapsRollupsCommand); err == nil {
+			processes = parseSmapsRollups(output)
+		}
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "ProcessMemory")
+	}
+
+	sort.SliceStable(processes, func(i, j int) bool { return processes[i].PSS > processes[j].PSS })
+	return processes, nil
+}
+
+/*
+MemoryMappings returns the memory used by the mappings of the process pid, grouped by name and
+sorted by PSS, most first. Hunting a leak starts with the mapping that grows, e.g. the Java
+heap, [anon:dalvik-main space], or native allocations, [anon:libc_malloc] or [anon:scudo:primary].
+
+Builds that have showmap run it, other rooted devices read the process's smaps. Without root,
+only the totals in smaps_rollup can be read, for processes run by the shell user; they're
+returned as a single mapping named [rollup].
+
+Corresponds to the commands:
+
+	adb shell showmap <pid>
+	adb shell cat /proc/<pid>/smaps
+	adb shell cat /proc/<pid>/smaps_rollup
+*/
+func (c *Device) MemoryMappings(pid int) ([]MemoryMapping, error) {
+	mappings, err := c.memoryMappings(pid)
+	if err != nil {
+		return nil, wrapClientError(err, c, "MemoryMappings(%d)", pid)
+	}
+	sort.SliceStable(mappings, func(i, j int) bool { return mappings[i].PSS > mappings[j].PSS })
+	return mappings, nil
+}
+
+func (c *Device) memoryMappings(pid int) ([]MemoryMapping, error) {
+	output, err := c.runMemoryTool("showmap", strconv.Itoa(pid))
+	if err == nil && output != "" {
+		return parseShowmap(output)
+	}
+
+	path := "/proc/" + strconv.Itoa(pid)
+	if output, err := c.runRootShellCommand(quoteShellArgs("cat", path+"/smaps")); err == nil {
+		if err := procFileError(output); err != nil {
+			return nil, err
+		}
+		return parseSmaps(output), nil
+	}
+
+	output, err = c.runShellCommand(quoteShellArgs("cat", path+"/smaps_rollup"))
+	if err != nil {
+		return nil, err
+	}
+	if err := procFileError(output); err != nil {
+		return nil, err
+	}
+	return parseSmaps(output), nil
+}
+
+/*
+LibraryMemory returns the memory used by each file or anonymous region mapped by processes,
+and by which processes, most RSS first. It needs librank, which is only on eng and userdebug
+builds, and root.
+
+Corresponds to the command:
+
+	adb shell librank
+*/
+func (c *Device) LibraryMemory() ([]LibraryMemory, error) {
+	output, err := c.runMemoryTool("librank")
+	if err == nil && output == "" {
+		err = errors.Errorf(errors.AdbError, "librank isn't available on this build")
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "LibraryMemory")
+	}
+	return parseLibrank(output), nil
+}
+
+// runMemoryTool runs the tool with args as root, or returns "" if the device doesn't have it.
+func (c *Device) runMemoryTool(tool string, args ...string) (string, error) {
+	output, err := c.runShellCommand("command -v " + quoteShellArg(tool))
+	if err != nil || strings.TrimSpace(output) == "" {
+		return "", err
+	}
+	return c.runRootShellCommand(quoteShellArgs(append([]string{tool}, args...)...))
+}
+
+// procFileError returns an error if output is an error message from cat.
+func procFileError(output string) error {
+	if !strings.HasPrefix(output, "cat:") {
+		return nil
+	}
+	msg := strings.TrimSpace(strings.SplitN(output, "\n", 2)[0])
+	switch {
+	case strings.Contains(msg, "No such file or directory"):
+		return errors.Errorf(errors.FileNoExistError, "%s", msg)
+	case strings.Contains(msg, "Permission denied"):
+		return errors.Errorf(errors.PermissionDenied, "%s", msg)
+	}
+	return errors.Errorf(errors.AdbError, "%s", msg)
+}
+
+// parseKiB parses a size in KiB, with or without a K suffix.
+func parseKiB(field string) (int64, bool) {
+	value, err := strconv.ParseInt(strings.TrimSuffix(field, "K"), 10, 64)
+	return value, err == nil
+}
+
+// parseProcrank parses the table of procrank. Columns vary by version, e.g. the swap columns
+// are only there on devices with swap.
+func parseProcrank(output string) ([]ProcessMemory, error) {
+	var columns []string
+	var processes []ProcessMemory
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "PID" {
+			columns = fields
+			continue
+		}
+		if columns == nil || len(fields) < len(columns) {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			// The totals and RAM summary after the table.
+			continue
+		}
+
+		process := ProcessMemory{PID: pid, Name: strings.Join(fields[len(columns)-1:], " ")}
+		for i, column := range columns[1 : len(columns)-1] {
+			value, ok := parseKiB(fields[i+1])
+			if !ok {
+				return nil, errors.Errorf(errors.ParseError, "invalid procrank line: %q", line)
+			}
+			switch column {
+			case "Vss":
+				process.VSS = value
+			case "Rss":
+				process.RSS = value
+			case "Pss":
+				process.PSS = value
+			case "Uss":
+				process.USS = value
+			case "Swap":
+				process.Swap = value
+			}
+		}
+		processes = append(processes, process)
+	}
+	if columns == nil {
+		return nil, errors.Errorf(errors.ParseError, "no table in procrank output: %q", output)
+	}
+	return processes, nil
+}
+
+// parseShowmap parses the table of showmap, skipping its TOTAL line.
+func parseShowmap(output string) ([]MemoryMapping, error) {
+	var columns []string
+	var mappings []MemoryMapping
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[len(fields)-1] == "object" {
+			// The second header line, which names the columns; "clean" and "dirty" are shared
+			// the first time and private the second.
+			columns = fields
+			continue
+		}
+		if columns == nil || len(fields) < len(columns) {
+			continue
+		}
+		if _, ok := parseKiB(fields[0]); !ok {
+			continue
+		}
+
+		mapping := MemoryMapping{Name: strings.Join(fields[len(columns)-1:], " ")}
+		if mapping.Name == "TOTAL" {
+			continue
+		}
+		private := false
+		for i, column := range columns[:len(columns)-1] {
+			value, ok := parseKiB(fields[i])
+			if !ok {
+				return nil, errors.Errorf(errors.ParseError, "invalid showmap line: %q", line)
+			}
+			switch column {
+			case "size":
+				mapping.VSS = value
+			case "RSS":
+				mapping.RSS = value
+			case "PSS":
+				mapping.PSS = value
+			case "clean":
+				if private {
+					mapping.PrivateClean = value
+				} else {
+					mapping.SharedClean = value
+				}
+			case "dirty":
+				if private {
+					mapping.PrivateDirty = value
+				} else {
+					mapping.SharedDirty = value
+				}
+				private = true
+			case "swap":
+				mapping.Swap = value
+			case "#":
+				mapping.Count = int(value)
+			}
+		}
+		mappings = append(mappings, mapping)
+	}
+	if columns == nil {
+		return nil, errors.Errorf(errors.ParseError, "no table in showmap output: %q", output)
+	}
+	return mappings, nil
+}
+
+// parseSmaps parses the mappings of smaps or smaps_rollup, summing mappings with the same
+// name. Anonymous mappings without a name are named [anon].
+func parseSmaps(output string) []MemoryMapping {
+	var mappings []MemoryMapping
+	indexes := make(map[string]int)
+	var mapping *MemoryMapping
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if match := smapsMappingLinePattern.FindStringSubmatch(line); match != nil {
+			name := match[1]
+			if name == "" {
+				name = "[anon]"
+			}
+			i, ok := indexes[name]
+			if !ok {
+				i = len(mappings)
+				indexes[name] = i
+				mappings = append(mappings, MemoryMapping{Name: name})
+			}
+			mapping = &mappings[i]
+			mapping.Count++
+			continue
+		}
+		if mapping == nil {
+			continue
+		}
+		if match := smapsFieldLinePattern.FindStringSubmatch(line); match != nil {
+			value, _ := strconv.ParseInt(match[2], 10, 64)
+			addSmapsField(mapping, match[1], value)
+		}
+	}
+	return mappings
+}
+
+func addSmapsField(mapping *MemoryMapping, name string, value int64) {
+	switch name {
+	case "Size":
+		mapping.VSS += value
+	case "Rss":
+		mapping.RSS += value
+	case "Pss":
+		mapping.PSS += value
+	case "Shared_Clean":
+		mapping.SharedClean += value
+	case "Shared_Dirty":
+		mapping.SharedDirty += value
+	case "Private_Clean":
+		mapping.PrivateClean += value
+	case "Private_Dirty":
+		mapping.PrivateDirty += value
+	case "Swap":
+		mapping.Swap += value
+	}
+}
+
+// parseSmapsRollups parses the output of smapsRollupsCommand. Processes without smaps_rollup,
+// like kernel threads, are skipped.
+func parseSmapsRollups(output string) []ProcessMemory {
+	var processes []ProcessMemory
+	var pid int
+	var name string
+	var lines []string
+	endProcess := func() {
+		if pid == 0 {
+			return
+		}
+		for _, rollup := range parseSmaps(strings.Join(lines, "\n")) {
+			processes = append(processes, ProcessMemory{
+				PID:  pid,
+				Name: name,
+				RSS:  rollup.RSS,
+				PSS:  rollup.PSS,
+				USS:  rollup.PrivateClean + rollup.PrivateDirty,
+				Swap: rollup.Swap,
+			})
+		}
+		pid, lines = 0, nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "pid ") {
+			endProcess()
+			fields := strings.SplitN(line, " ", 3)
+			pid, _ = strconv.Atoi(fields[1])
+			name = ""
+			if len(fields) == 3 {
+				name = strings.TrimSpace(fields[2])
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	endProcess()
+	return processes
+}
+
+// parseLibrank parses the table of librank, in which each library's line is followed by the
+// lines of the processes that map it.
+func parseLibrank(output string) []LibraryMemory {
+	var columns []string
+	var libraries []LibraryMemory
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "RSStot" {
+			columns = fields
+			continue
+		}
+		if columns == nil {
+			continue
+		}
+
+		numbers := 0
+		for numbers < len(fields) {
+			if _, ok := parseKiB(fields[numbers]); !ok {
+				break
+			}
+			numbers++
+		}
+		switch {
+		case numbers == 1 && len(fields) > 1:
+			rss, _ := parseKiB(fields[0])
+			libraries = append(libraries, LibraryMemory{Name: strings.Join(fields[1:], " "), RSS: rss})
+		case numbers == len(columns)-2 && len(fields) > numbers+1 && len(libraries) > 0:
+			pidField := fields[len(fields)-1]
+			pid, err := strconv.Atoi(strings.Trim(pidField, "[]"))
+			if err != nil {
+				continue
+			}
+			process := ProcessMemory{PID: pid, Name: strings.Join(fields[numbers:len(fields)-1], " ")}
+			for i, column := range columns[1 : len(columns)-1] {
+				value, _ := parseKiB(fields[i])
+				switch column {
+				case "VSS":
+					process.VSS = value
+				case "RSS":
+					process.RSS = value
+				case "PSS":
+					process.PSS = value
+				case "USS":
+					process.USS = value
+				case "Swap":
+					process.Swap = value
+				}
+			}
+			library := &libraries[len(libraries)-1]
+			library.Processes = append(library.Processes, process)
+		}
+	}
+	return libraries
+}
@@ -0,0 +1,162 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseProcrank(t *testing.T) {
+	processes, err := parseProcrank(`  PID       Vss      Rss      Pss      Uss     Swap    PSwap    USwap    ZSwap  cmdline
+ 4321  15208780K  187008K   98765K   87654K    1024K     512K     512K     128K  com.example
+  612  15765312K  256000K  120000K  100000K       0K       0K       0K       0K  system_server
+                           ------   ------   ------   ------   ------   ------   ------
+                           218765K  187654K    1024K     512K     512K     128K  TOTAL
+
+ZRAM: 1234K physical used for 5678K in swap (2097148K total swap)
+ RAM: 3884220K total, 170320K free, 4544K buffers, 1330660K cached, 12420K shmem, 174380K slab
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []ProcessMemory{
+		{PID: 4321, Name: "com.example", VSS: 15208780, RSS: 187008, PSS: 98765, USS: 87654, Swap: 1024},
+		{PID: 612, Name: "system_server", VSS: 15765312, RSS: 256000, PSS: 120000, USS: 100000},
+	}, processes)
+
+	processes, err = parseProcrank("  PID      Vss      Rss      Pss      Uss  cmdline\n  123   1000K    900K    800K    700K  /system/bin/surfaceflinger --verbose\n")
+	require.NoError(t, err)
+	assert.Equal(t, []ProcessMemory{
+		{PID: 123, Name: "/system/bin/surfaceflinger --verbose", VSS: 1000, RSS: 900, PSS: 800, USS: 700},
+	}, processes)
+
+	_, err = parseProcrank("procrank: not found\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestParseShowmap(t *testing.T) {
+	mappings, err := parseShowmap(` virtual                     shared   shared  private  private
+    size      RSS      PSS    clean    dirty    clean    dirty     swap  swapPSS   # object
+-------- -------- -------- -------- -------- -------- -------- -------- -------- ---- ------------------------------
+  262144    51200    51200        0        0        0    51200      128      128    1 [anon:dalvik-main space]
+    1200      900      300      600        0        0      300        0        0    4 /apex/com.android.runtime/lib64/bionic/libc.so
+-------- -------- -------- -------- -------- -------- -------- -------- -------- ---- ------------------------------
+ virtual                     shared   shared  private  private
+    size      RSS      PSS    clean    dirty    clean    dirty     swap  swapPSS   # object
+  263344    52100    51500      600        0        0    51500      128      128    5 TOTAL
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []MemoryMapping{
+		{Name: "[anon:dalvik-main space]", Count: 1, VSS: 262144, RSS: 51200, PSS: 51200, PrivateDirty: 51200, Swap: 128},
+		{Name: "/apex/com.android.runtime/lib64/bionic/libc.so", Count: 4, VSS: 1200, RSS: 900, PSS: 300, SharedClean: 600, PrivateDirty: 300},
+	}, mappings)
+}
+
+const testSmaps = `12c00000-32c00000 rw-p 00000000 00:00 0                                  [anon:dalvik-main space]
+Size:             524288 kB
+Rss:               51200 kB
+Pss:               51200 kB
+Shared_Clean:          0 kB
+Shared_Dirty:          0 kB
+Private_Clean:         0 kB
+Private_Dirty:     51200 kB
+Swap:                128 kB
+VmFlags: rd wr mr mw me ac
+7f0000000-7f0001000 r-xp 00000000 fd:00 1234                             /system/lib64/libc.so
+Size:                  4 kB
+Rss:                   4 kB
+Pss:                   2 kB
+Shared_Clean:          4 kB
+7f0002000-7f0003000 r--p 00001000 fd:00 1234                             /system/lib64/libc.so
+Size:                  4 kB
+Rss:                   4 kB
+Pss:                   4 kB
+Private_Clean:         4 kB
+7f0004000-7f0005000 rw-p 00000000 00:00 0 
+Size:                  4 kB
+Rss:                   4 kB
+Pss:                   4 kB
+Private_Dirty:         4 kB
+`
+
+func TestParseSmaps(t *testing.T) {
+	assert.Equal(t, []MemoryMapping{
+		{Name: "[anon:dalvik-main space]", Count: 1, VSS: 524288, RSS: 51200, PSS: 51200, PrivateDirty: 51200, Swap: 128},
+		{Name: "/system/lib64/libc.so", Count: 2, VSS: 8, RSS: 8, PSS: 6, SharedClean: 4, PrivateClean: 4},
+		{Name: "[anon]", Count: 1, VSS: 4, RSS: 4, PSS: 4, PrivateDirty: 4},
+	}, parseSmaps(testSmaps))
+}
+
+func TestParseSmapsRollups(t *testing.T) {
+	assert.Equal(t, []ProcessMemory{
+		{PID: 4321, Name: "com.example", RSS: 187008, PSS: 98765, USS: 87654, Swap: 1024},
+	}, parseSmapsRollups(`pid 2 
+pid 4321 com.example 
+00400000-7fffffff000 ---p 00000000 00:00 0                              [rollup]
+Rss:              187008 kB
+Pss:               98765 kB
+Private_Clean:     50000 kB
+Private_Dirty:     37654 kB
+Swap:               1024 kB
+`))
+}
+
+func TestParseLibrank(t *testing.T) {
+	assert.Equal(t, []LibraryMemory{
+		{
+			Name: "[anon:dalvik-main space]",
+			RSS:  51200,
+			Processes: []ProcessMemory{
+				{PID: 4321, Name: "com.example", VSS: 262144, RSS: 40000, PSS: 40000, USS: 40000},
+				{PID: 612, Name: "system_server", VSS: 262144, RSS: 11200, PSS: 11200, USS: 11200},
+			},
+		},
+		{
+			Name:      "/system/lib64/libc.so",
+			RSS:       900,
+			Processes: []ProcessMemory{{PID: 1, Name: "/system/bin/init second_stage", VSS: 1200, RSS: 900, PSS: 300}},
+		},
+	}, parseLibrank(` RSStot      VSS      RSS      PSS      USS  Name/PID
+  51200K                                      [anon:dalvik-main space]
+           262144K   40000K   40000K   40000K    com.example [4321]
+           262144K   11200K   11200K   11200K    system_server [612]
+    900K                                      /system/lib64/libc.so
+             1200K     900K     300K       0K    /system/bin/init second_stage [1]
+`))
+}
+
+func TestMemoryMappings(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"id -u":                             "2000\n",
+			"su 0 sh -c 'cat /proc/4321/smaps'": "/system/bin/sh: su: not found\n",
+			"cat /proc/4321/smaps_rollup":       "cat: /proc/4321/smaps_rollup: Permission denied\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.MemoryMappings(4321)
+	assert.True(t, HasErrCode(err, PermissionDenied), "%v", err)
+
+	s.ShellOutputs["cat /proc/4321/smaps_rollup"] = "00400000-7fffffff000 ---p 00000000 00:00 0 [rollup]\nRss: 100 kB\nPss: 80 kB\n"
+	mappings, err := device.MemoryMappings(4321)
+	require.NoError(t, err)
+	assert.Equal(t, []MemoryMapping{{Name: "[rollup]", Count: 1, RSS: 100, PSS: 80}}, mappings)
+
+	s.ShellOutputs["id -u"] = "0\n"
+	s.ShellOutputs["command -v showmap"] = "/system/bin/showmap\n"
+	s.ShellOutputs["showmap 4321"] = "    size      RSS      PSS    clean    dirty    clean    dirty    # object\n" +
+		"       8        8        6        4        0        0        2    2 /system/lib64/libc.so\n" +
+		"    1024      512      512        0        0        0      512    1 [anon:libc_malloc]\n"
+	mappings, err = device.MemoryMappings(4321)
+	require.NoError(t, err)
+	require.Len(t, mappings, 2)
+	assert.Equal(t, "[anon:libc_malloc]", mappings[0].Name)
+}
+
+func TestLibraryMemoryUnavailable(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	_, err := (&Adb{s}).Device(AnyDevice()).LibraryMemory()
+	assert.True(t, HasErrCode(err, AdbError))
+}
@@ -0,0 +1,287 @@
+package adb
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// Maximum number of bytes of each wire message included in debug hex dumps.
+const wireDumpLimit = 512
+
+// observedServer reports the requests made on every connection it dials to a Logger and an
+// Instrumentation. Either may be nil.
+type observedServer struct {
+	server
+	logger          Logger
+	instrumentation Instrumentation
+}
+
+// observeServer wraps s to report to logger and instrumentation. If s is already observed,
+// nil arguments keep its current Logger or Instrumentation, so requests are never reported twice.
+func observeServer(s server, logger Logger, instrumentation Instrumentation) server {
+	if observed, ok := s.(*observedServer); ok {
+		s = observed.server
+		if logger == nil {
+			logger = observed.logger
+		}
+		if instrumentation == nil {
+			instrumentation = observed.instrumentation
+		}
+	}
+	return &observedServer{server: s, logger: logger, instrumentation: instrumentation}
+}
+
+func (s *observedServer) Dial() (*wire.Conn, error) {
+	start := time.Now()
+	conn, err := s.server.Dial()
+	if s.instrumentation != nil {
+		s.instrumentation.DialDone(DialStats{Start: start, Duration: time.Since(start), Err: err})
+	}
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Warn("adb dial failed", "error", err)
+		}
+		return nil, err
+	}
+
+	observed := &observedConn{
+		server:  s,
+		scanner: conn.Scanner,
+		sender:  conn.Sender,
+		stats:   RequestStats{Start: time.Now()},
+	}
+	return wire.NewConn(observed, observed), nil
+}
+
+// observedConn counts the traffic passed through it, and logs each message at debug level.
+// When closed, it logs a summary of the request at info level, and reports it to the
+// Instrumentation.
+type observedConn struct {
+	server  *observedServer
+	scanner wire.Scanner
+	sender  wire.Sender
+
+	lock   sync.Mutex
+	stats  RequestStats
+	closed bool
+}
+
+var _ wire.Scanner = &observedConn{}
+var _ wire.Sender = &observedConn{}
+
+func (c *observedConn) SendMessage(msg []byte) error {
+	err := c.sender.SendMessage(msg)
+	c.lock.Lock()
+	c.stats.Services = append(c.stats.Services, string(msg))
+	c.lock.Unlock()
+	c.record("send message", msg, err)
+	return err
+}
+
+func (c *observedConn) Write(p []byte) (int, error) {
+	n, err := c.sender.Write(p)
+	c.record("write", p[:n], err)
+	return n, err
+}
+
+func (c *observedConn) ReadStatus(req string) (string, error) {
+	status, err := c.scanner.ReadStatus(req)
+	c.record("read status", []byte(status), err)
+	return status, err
+}
+
+func (c *observedConn) ReadMessage() ([]byte, error) {
+	msg, err := c.scanner.ReadMessage()
+	c.record("read message", msg, err)
+	return msg, err
+}
+
+func (c *observedConn) ReadUntilEof() ([]byte, error) {
+	data, err := c.scanner.ReadUntilEof()
+	c.record("read until EOF", data, err)
+	return data, err
+}
+
+func (c *observedConn) Read(p []byte) (int, error) {
+	n, err := c.scanner.Read(p)
+	if n > 0 {
+		// EOF is the normal end of a raw stream, not worth reporting on its own.
+		c.record("read", p[:n], nil)
+	}
+	return n, err
+}
+
+func (c *observedConn) SetDeadline(t time.Time) error {
+	return wire.NewConn(c.scanner, c.sender).SetDeadline(t)
+}
+
+func (c *observedConn) SetReadDeadline(t time.Time) error {
+	return wire.NewConn(c.scanner, c.sender).SetReadDeadline(t)
+}
+
+func (c *observedConn) SetWriteDeadline(t time.Time) error {
+	return wire.NewConn(c.scanner, c.sender).SetWriteDeadline(t)
+}
+
+func (c *observedConn) NewSyncScanner() wire.SyncScanner {
+	return &observedSyncScanner{SyncScanner: c.scanner.NewSyncScanner(), conn: c}
+}
+
+func (c *observedConn) NewSyncSender() wire.SyncSender {
+	return &observedSyncSender{SyncSender: c.sender.NewSyncSender(), conn: c}
+}
+
+// Close closes the connection and reports the request. wire.Conn closes both its scanner and
+// sender, so only the first call is reported.
+func (c *observedConn) Close() error {
+	c.lock.Lock()
+	closed := c.closed
+	c.closed = true
+	c.lock.Unlock()
+	if closed {
+		return nil
+	}
+
+	err := wire.NewConn(c.scanner, c.sender).Close()
+
+	c.lock.Lock()
+	stats := c.stats
+	c.lock.Unlock()
+	stats.Duration = time.Since(stats.Start)
+	stats.Operation = operationName(stats.Services)
+
+	if logger := c.server.logger; logger != nil {
+		args := []interface{}{
+			"request", strings.Join(stats.Services, " "),
+			"duration", stats.Duration,
+			"bytes_out", stats.BytesSent,
+			"bytes_in", stats.BytesReceived,
+		}
+		if stats.Err != nil {
+			args = append(args, "error", stats.Err)
+		}
+		logger.Info("adb request", args...)
+	}
+	if instrumentation := c.server.instrumentation; instrumentation != nil {
+		instrumentation.RequestDone(stats)
+	}
+	return err
+}
+
+// record counts data and logs a hex dump of it at debug level. The first error is kept for
+// the request summary.
+func (c *observedConn) record(op string, data []byte, err error) {
+	c.lock.Lock()
+	switch {
+	case op == "send sync data":
+		c.stats.BytesPushed += int64(len(data))
+		c.stats.BytesSent += int64(len(data))
+	case op == "read sync data":
+		c.stats.BytesPulled += int64(len(data))
+		c.stats.BytesReceived += int64(len(data))
+	case strings.HasPrefix(op, "read"):
+		c.stats.BytesReceived += int64(len(data))
+	default:
+		c.stats.BytesSent += int64(len(data))
+	}
+	if err != nil && c.stats.Err == nil {
+		c.stats.Err = err
+	}
+	c.lock.Unlock()
+
+	logger := c.server.logger
+	if logger == nil {
+		return
+	}
+	args := []interface{}{"op", op, "bytes", len(data)}
+	if len(data) > 0 {
+		args = append(args, "dump", hexDump(data))
+	}
+	if err != nil {
+		args = append(args, "error", err)
+	}
+	logger.Debug("adb wire", args...)
+}
+
+// hexDump formats data like hexdump -C, truncated to wireDumpLimit bytes.
+func hexDump(data []byte) string {
+	if len(data) <= wireDumpLimit {
+		return hex.Dump(data)
+	}
+	return hex.Dump(data[:wireDumpLimit]) + "...\n"
+}
+
+type observedSyncScanner struct {
+	wire.SyncScanner
+	conn *observedConn
+}
+
+// Close closes the connection, which is reported when its sync scanner or sender is closed.
+func (s *observedSyncScanner) Close() error {
+	err := s.SyncScanner.Close()
+	s.conn.Close()
+	return err
+}
+
+func (s *observedSyncScanner) ReadStatus(req string) (string, error) {
+	status, err := s.SyncScanner.ReadStatus(req)
+	s.conn.record("read sync status", []byte(status), err)
+	return status, err
+}
+
+func (s *observedSyncScanner) ReadString() (string, error) {
+	str, err := s.SyncScanner.ReadString()
+	s.conn.record("read sync string", []byte(str), err)
+	return str, err
+}
+
+func (s *observedSyncScanner) ReadBytes() (io.Reader, error) {
+	r, err := s.SyncScanner.ReadBytes()
+	if err != nil {
+		s.conn.record("read sync data", nil, err)
+		return nil, err
+	}
+	return &observedReader{Reader: r, conn: s.conn}, nil
+}
+
+// observedReader records the file data read in sync mode.
+type observedReader struct {
+	io.Reader
+	conn *observedConn
+}
+
+func (r *observedReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.conn.record("read sync data", p[:n], nil)
+	}
+	return n, err
+}
+
+type observedSyncSender struct {
+	wire.SyncSender
+	conn *observedConn
+}
+
+func (s *observedSyncSender) Close() error {
+	err := s.SyncSender.Close()
+	s.conn.Close()
+	return err
+}
+
+func (s *observedSyncSender) SendOctetString(str string) error {
+	err := s.SyncSender.SendOctetString(str)
+	s.conn.record("send sync id", []byte(str), err)
+	return err
+}
+
+func (s *observedSyncSender) SendBytes(data []byte) error {
+	err := s.SyncSender.SendBytes(data)
+	s.conn.record("send sync data", data, err)
+	return err
+}
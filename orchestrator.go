@@ -0,0 +1,339 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Components of AndroidX Test Orchestrator, which runs each test in its own instrumentation.
+const (
+	OrchestratorPackage = "androidx.test.orchestrator"
+	OrchestratorRunner  = OrchestratorPackage + "/androidx.test.orchestrator.AndroidTestOrchestrator"
+	// The test services APK runs the orchestrator's shell commands, e.g. pm clear.
+	TestServicesPackage = "androidx.test.services"
+	testServicesShell   = "androidx.test.services.shellexecutor.ShellMain"
+)
+
+// Status codes of am instrument -r, from InstrumentationResultPrinter. Tests start with 1.
+const (
+	instrumentationStatusOK                = 0
+	instrumentationStatusError             = -1
+	instrumentationStatusFailure           = -2
+	instrumentationStatusIgnored           = -3
+	instrumentationStatusAssumptionFailure = -4
+)
+
+// Prefixes of the lines of am instrument -r.
+const (
+	instrumentationStatusPrefix     = "INSTRUMENTATION_STATUS: "
+	instrumentationStatusCodePrefix = "INSTRUMENTATION_STATUS_CODE: "
+	instrumentationResultPrefix     = "INSTRUMENTATION_RESULT: "
+	instrumentationFailedPrefix     = "INSTRUMENTATION_FAILED: "
+	instrumentationCodePrefix       = "INSTRUMENTATION_CODE: "
+)
+
+// TestStatus is the outcome of a single test.
+type TestStatus string
+
+const (
+	TestPassed TestStatus = "passed"
+	TestFailed TestStatus = "failed"
+	// The test was @Ignore'd.
+	TestIgnored TestStatus = "ignored"
+	// An assumption of the test didn't hold, so it was skipped.
+	TestAssumptionFailed TestStatus = "assumption_failed"
+)
+
+// TestResult is the result of a single test run by RunOrchestratedTests.
+type TestResult struct {
+	Class  string
+	Method string
+	Status TestStatus
+	// Stack trace of a failure, including the process crashing.
+	Stack string
+}
+
+// OrchestratorOptions control RunOrchestratedTests.
+type OrchestratorOptions struct {
+	// Clear the target app's data before each test, so each starts from a clean state.
+	ClearPackageData bool
+	// Arguments passed to the runner with -e, e.g. class=com.example.LoginTest or
+	// notAnnotation=androidx.test.filters.FlakyTest.
+	Args map[string]string
+	// If set, the artifacts to pull once the tests have run, even if some failed.
+	Artifacts *ArtifactOptions
+}
+
+// Name of the manifest CollectArtifacts writes to ArtifactOptions.LocalDir.
+const ArtifactManifestName = "artifacts.json"
+
+// ArtifactOptions control CollectArtifacts.
+type ArtifactOptions struct {
+	// Files and directories on the device to pull, e.g. screenshots or the
+	// additionalTestOutputDir passed in Args. Paths that don't exist are skipped, since tests
+	// may only write some artifacts when they fail.
+	RemotePaths []string
+	// If set, RunOrchestratedTests has the runner write a coverage .ec file per test to this
+	// directory on the device, which the app must be able to write, e.g.
+	// /sdcard/Android/media/com.example/coverage. It's pulled along with RemotePaths.
+	CoverageDir string
+	// Directory on the host that each path is pulled to, by its base name.
+	LocalDir string
+	// If set, called with the remote path being pulled and its progress, like
+	// TransferOptions.Progress.
+	Progress func(remote string, progress TransferProgress)
+}
+
+// Artifact is a file pulled by CollectArtifacts, as listed in its manifest.
+type Artifact struct {
+	Remote string `json:"remote"`
+	Local  string `json:"local"`
+	Size   int64  `json:"size"`
+}
+
+/*
+InstallTestOrchestrator installs the orchestrator and test services APKs at orchestratorAPK and
+servicesAPK, which RunOrchestratedTests needs, skipping either if it's already installed.
+*/
+func (c *Device) InstallTestOrchestrator(orchestratorAPK, servicesAPK string) error {
+	opts := InstallOptions{Reinstall: true, GrantPermission: true, SkipSameVersion: true}
+	for _, apk := range []string{orchestratorAPK, servicesAPK} {
+		if _, err := c.InstallAppFile(apk, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+RunOrchestratedTests runs the tests of runner, e.g.
+com.example.test/androidx.test.runner.AndroidJUnitRunner, through AndroidX Test Orchestrator, so
+each test runs in its own process, and a test that crashes or leaves state behind doesn't
+affect the others. Install the orchestrator first with InstallTestOrchestrator.
+
+Returns the result of each test, in the order they ran, once all have run or ctx is done. If
+ctx is done first, the orchestrator and the package of runner are force-stopped. An error is
+only returned if the run itself failed, e.g. the runner isn't installed; check the
+results for failed tests.
+
+Corresponds to the command:
+
+	adb shell 'CLASSPATH=$(pm path androidx.test.services) app_process / \
+		androidx.test.services.shellexecutor.ShellMain am instrument -r -w \
+		-e targetInstrumentation <runner> androidx.test.orchestrator/androidx.test.orchestrator.AndroidTestOrchestrator'
+*/
+func (c *Device) RunOrchestratedTests(ctx context.Context, runner string, opts OrchestratorOptions) ([]TestResult, error) {
+	results, err := c.runOrchestratedTests(ctx, runner, opts)
+	return results, wrapClientError(err, c, "RunOrchestratedTests(%s)", runner)
+}
+
+func (c *Device) runOrchestratedTests(ctx context.Context, runner string, opts OrchestratorOptions) ([]TestResult, error) {
+	conn, err := c.openService("exec:" + quoteShellArgs("sh", "-c", orchestratorCommand(runner, opts)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	results, err := parseInstrumentationOutput(bufio.NewScanner(conn))
+	if ctx.Err() != nil {
+		// Closing the connection only stops am instrument, so stop the orchestrator and the
+		// tests it's running too.
+		c.ForceStop(OrchestratorPackage)
+		c.ForceStop(strings.SplitN(runner, "/", 2)[0])
+		return results, ctx.Err()
+	}
+	if opts.Artifacts != nil {
+		// Artifacts are just as useful when the run crashed.
+		if _, collectErr := c.collectArtifacts(*opts.Artifacts); err == nil {
+			err = collectErr
+		}
+	}
+	return results, err
+}
+
+/*
+CollectArtifacts pulls the files and directories in opts from the device to opts.LocalDir, e.g.
+coverage files and screenshots after a test run, and writes a manifest of the files it pulled,
+ArtifactManifestName, to opts.LocalDir. Paths with the same base name overwrite each other.
+
+Returns the files pulled, as listed in the manifest.
+*/
+func (c *Device) CollectArtifacts(opts ArtifactOptions) ([]Artifact, error) {
+	artifacts, err := c.collectArtifacts(opts)
+	return artifacts, wrapClientError(err, c, "CollectArtifacts")
+}
+
+func (c *Device) collectArtifacts(opts ArtifactOptions) ([]Artifact, error) {
+	remotePaths := append([]string(nil), opts.RemotePaths...)
+	if opts.CoverageDir != "" {
+		remotePaths = append(remotePaths, opts.CoverageDir)
+	}
+	if err := os.MkdirAll(opts.LocalDir, 0755); err != nil {
+		return nil, err
+	}
+
+	artifacts := []Artifact{}
+	for _, remote := range remotePaths {
+		remote = path.Clean(remote)
+		if _, err := c.Stat(remote); HasErrCode(err, FileNoExistError) {
+			continue
+		} else if err != nil {
+			return artifacts, err
+		}
+
+		local := filepath.Join(opts.LocalDir, path.Base(remote))
+		var transfer TransferOptions
+		if opts.Progress != nil {
+			transfer.Progress = func(progress TransferProgress) { opts.Progress(remote, progress) }
+		}
+		if err := c.PullDir(remote, local, transfer); err != nil {
+			return artifacts, err
+		}
+
+		err := filepath.Walk(local, func(file string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(local, file)
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, Artifact{
+				Remote: path.Join(remote, filepath.ToSlash(rel)),
+				Local:  file,
+				Size:   info.Size(),
+			})
+			return nil
+		})
+		if err != nil {
+			return artifacts, err
+		}
+	}
+
+	manifest, err := json.MarshalIndent(artifacts, "", "  ")
+	if err != nil {
+		return artifacts, errors.WrapErrorf(err, errors.AssertionError, "error encoding artifact manifest")
+	}
+	return artifacts, ioutil.WriteFile(filepath.Join(opts.LocalDir, ArtifactManifestName), manifest, 0644)
+}
+
+// orchestratorCommand returns the shell command line that runs runner through the
+// orchestrator, as documented for AndroidX Test.
+func orchestratorCommand(runner string, opts OrchestratorOptions) string {
+	args := []string{"am", "instrument", "-r", "-w", "-e", "targetInstrumentation", runner}
+	if opts.ClearPackageData {
+		args = append(args, "-e", "clearPackageData", "true")
+	}
+	if opts.Artifacts != nil && opts.Artifacts.CoverageDir != "" {
+		// The orchestrator writes a file per test to coverageFilePath if it ends with a slash.
+		args = append(args, "-e", "coverage", "true",
+			"-e", "coverageFilePath", strings.TrimSuffix(opts.Artifacts.CoverageDir, "/")+"/")
+	}
+	keys := make([]string, 0, len(opts.Args))
+	for key := range opts.Args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key, opts.Args[key])
+	}
+	args = append(args, OrchestratorRunner)
+	return "CLASSPATH=$(pm path " + TestServicesPackage + ") app_process / " + testServicesShell + " " +
+		quoteShellArgs(args...)
+}
+
+// parseInstrumentationOutput parses the status blocks of am instrument -r, each a set of
+// "INSTRUMENTATION_STATUS: key=value" lines, whose values may continue over several lines,
+// ended by an "INSTRUMENTATION_STATUS_CODE: code" line.
+func parseInstrumentationOutput(scanner *bufio.Scanner) ([]TestResult, error) {
+	var results []TestResult
+	status := map[string]string{}
+	var key, failure string
+	finished := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, instrumentationStatusPrefix):
+			parts := strings.SplitN(strings.TrimPrefix(line, instrumentationStatusPrefix), "=", 2)
+			key = parts[0]
+			if len(parts) == 2 {
+				status[key] = parts[1]
+			}
+		case strings.HasPrefix(line, instrumentationStatusCodePrefix):
+			code, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, instrumentationStatusCodePrefix)))
+			if err != nil {
+				return results, errors.WrapErrorf(err, errors.ParseError, "invalid status code: %q", line)
+			}
+			if result, ok := testResult(status, code); ok {
+				results = append(results, result)
+			}
+			status, key = map[string]string{}, ""
+		case strings.HasPrefix(line, instrumentationResultPrefix):
+			// The run's own result, whose shortMsg is set if it crashed.
+			if msg := strings.TrimPrefix(line, instrumentationResultPrefix+"shortMsg="); msg != line {
+				failure = msg
+			}
+			key = ""
+		case strings.HasPrefix(line, instrumentationFailedPrefix):
+			failure = strings.TrimPrefix(line, instrumentationFailedPrefix)
+			key = ""
+		case strings.HasPrefix(line, instrumentationCodePrefix):
+			finished = true
+			key = ""
+		case strings.HasPrefix(line, "Error:") && len(results) == 0 && key == "":
+			// am prints errors like "Error: Unable to find instrumentation info for: ..." by
+			// themselves.
+			failure = line
+		case key != "":
+			status[key] += "\n" + line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return results, errors.WrapErrorf(err, errors.NetworkError, "error reading test output")
+	}
+	if failure != "" {
+		return results, errors.Errorf(errors.AdbError, "instrumentation failed: %s", failure)
+	}
+	if !finished {
+		return results, errors.Errorf(errors.AdbError, "instrumentation didn't finish")
+	}
+	return results, nil
+}
+
+// testResult returns the result for a status block with code, or false if it's the start of
+// a test.
+func testResult(status map[string]string, code int) (TestResult, bool) {
+	result := TestResult{Class: status["class"], Method: status["test"], Stack: status["stack"]}
+	switch code {
+	case instrumentationStatusOK:
+		result.Status = TestPassed
+	case instrumentationStatusError, instrumentationStatusFailure:
+		result.Status = TestFailed
+	case instrumentationStatusIgnored:
+		result.Status = TestIgnored
+	case instrumentationStatusAssumptionFailure:
+		result.Status = TestAssumptionFailed
+	default:
+		return result, false
+	}
+	return result, true
+}
@@ -0,0 +1,157 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const orchestratedOutput = `INSTRUMENTATION_STATUS: class=com.example.LoginTest
+INSTRUMENTATION_STATUS: current=1
+INSTRUMENTATION_STATUS: id=AndroidJUnitRunner
+INSTRUMENTATION_STATUS: numtests=1
+INSTRUMENTATION_STATUS: stream=
+com.example.LoginTest:
+INSTRUMENTATION_STATUS: test=logsIn
+INSTRUMENTATION_STATUS_CODE: 1
+INSTRUMENTATION_STATUS: class=com.example.LoginTest
+INSTRUMENTATION_STATUS: current=1
+INSTRUMENTATION_STATUS: id=AndroidJUnitRunner
+INSTRUMENTATION_STATUS: numtests=1
+INSTRUMENTATION_STATUS: stream=.
+INSTRUMENTATION_STATUS: test=logsIn
+INSTRUMENTATION_STATUS_CODE: 0
+INSTRUMENTATION_STATUS: class=com.example.LoginTest
+INSTRUMENTATION_STATUS: test=rejectsBadPassword
+INSTRUMENTATION_STATUS_CODE: 1
+INSTRUMENTATION_STATUS: class=com.example.LoginTest
+INSTRUMENTATION_STATUS: stack=java.lang.AssertionError: expected:<error> but was:<home>
+	at org.junit.Assert.fail(Assert.java:89)
+	at com.example.LoginTest.rejectsBadPassword(LoginTest.java:42)
+INSTRUMENTATION_STATUS: test=rejectsBadPassword
+INSTRUMENTATION_STATUS_CODE: -2
+INSTRUMENTATION_STATUS: class=com.example.LoginTest
+INSTRUMENTATION_STATUS: test=offline
+INSTRUMENTATION_STATUS_CODE: 1
+INSTRUMENTATION_STATUS: class=com.example.LoginTest
+INSTRUMENTATION_STATUS: test=offline
+INSTRUMENTATION_STATUS_CODE: -3
+INSTRUMENTATION_RESULT: stream=
+
+Time: 4.12
+
+FAILURES!!!
+Tests run: 3,  Failures: 1
+
+INSTRUMENTATION_CODE: -1
+`
+
+func TestParseInstrumentationOutput(t *testing.T) {
+	results, err := parseInstrumentationOutput(bufio.NewScanner(strings.NewReader(orchestratedOutput)))
+	assert.NoError(t, err)
+	assert.Equal(t, []TestResult{
+		{Class: "com.example.LoginTest", Method: "logsIn", Status: TestPassed},
+		{Class: "com.example.LoginTest", Method: "rejectsBadPassword", Status: TestFailed,
+			Stack: "java.lang.AssertionError: expected:<error> but was:<home>\n" +
+				"\tat org.junit.Assert.fail(Assert.java:89)\n" +
+				"\tat com.example.LoginTest.rejectsBadPassword(LoginTest.java:42)"},
+		{Class: "com.example.LoginTest", Method: "offline", Status: TestIgnored},
+	}, results)
+
+	for _, output := range []string{
+		"INSTRUMENTATION_FAILED: com.example.test/androidx.test.runner.AndroidJUnitRunner\nINSTRUMENTATION_CODE: 0\n",
+		"INSTRUMENTATION_RESULT: shortMsg=Process crashed.\nINSTRUMENTATION_CODE: 0\n",
+		"Error: Unable to find instrumentation info for: ComponentInfo{com.example.test/Runner}\n",
+		// Cut off, e.g. by the device disconnecting.
+		"INSTRUMENTATION_STATUS: class=com.example.LoginTest\nINSTRUMENTATION_STATUS: test=logsIn\nINSTRUMENTATION_STATUS_CODE: 1\n",
+	} {
+		_, err := parseInstrumentationOutput(bufio.NewScanner(strings.NewReader(output)))
+		assert.True(t, HasErrCode(err, AdbError), output)
+	}
+}
+
+func TestRunOrchestratedTests(t *testing.T) {
+	command := orchestratorCommand("com.example.test/androidx.test.runner.AndroidJUnitRunner", OrchestratorOptions{
+		ClearPackageData: true,
+		Args:             map[string]string{"package": "com.example", "notAnnotation": "androidx.test.filters.FlakyTest"},
+	})
+	assert.Equal(t, "CLASSPATH=$(pm path androidx.test.services) app_process / androidx.test.services.shellexecutor.ShellMain "+
+		"am instrument -r -w -e targetInstrumentation com.example.test/androidx.test.runner.AndroidJUnitRunner "+
+		"-e clearPackageData true -e notAnnotation androidx.test.filters.FlakyTest -e package com.example "+
+		"androidx.test.orchestrator/androidx.test.orchestrator.AndroidTestOrchestrator", command)
+
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{quoteShellArgs("sh", "-c", command): orchestratedOutput},
+	}
+	results, err := (&Adb{s}).Device(AnyDevice()).RunOrchestratedTests(context.Background(),
+		"com.example.test/androidx.test.runner.AndroidJUnitRunner", OrchestratorOptions{
+			ClearPackageData: true,
+			Args:             map[string]string{"package": "com.example", "notAnnotation": "androidx.test.filters.FlakyTest"},
+		})
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "exec:"+quoteShellArgs("sh", "-c", command), s.Requests[1])
+}
+
+func TestRunOrchestratedTestsCollectsArtifacts(t *testing.T) {
+	local, err := ioutil.TempDir("", "artifacts")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+
+	const media = "/sdcard/Android/media/com.example"
+	opts := OrchestratorOptions{Artifacts: &ArtifactOptions{
+		RemotePaths: []string{media + "/additional_test_output", media + "/screenshots"},
+		CoverageDir: media + "/coverage",
+		LocalDir:    local,
+	}}
+	var pulled []string
+	opts.Artifacts.Progress = func(remote string, progress TransferProgress) {
+		if len(pulled) == 0 || pulled[len(pulled)-1] != remote {
+			pulled = append(pulled, remote)
+		}
+	}
+	command := orchestratorCommand("com.example.test/androidx.test.runner.AndroidJUnitRunner", opts)
+	assert.Contains(t, command, "-e coverage true -e coverageFilePath "+media+"/coverage/ ")
+
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{quoteShellArgs("sh", "-c", command): orchestratedOutput},
+		Files: map[string]*MockFile{
+			media + "/additional_test_output/LoginTest/trace.perfetto": {Data: []byte("trace")},
+			media + "/coverage/com.example.LoginTest#logsIn.ec":        {Data: []byte("coverage1")},
+			media + "/coverage/com.example.LoginTest#offline.ec":       {Data: []byte("coverage2")},
+		},
+	}
+	results, err := (&Adb{s}).Device(AnyDevice()).RunOrchestratedTests(context.Background(),
+		"com.example.test/androidx.test.runner.AndroidJUnitRunner", opts)
+	require.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, []string{media + "/additional_test_output", media + "/coverage"}, pulled)
+
+	data, err := ioutil.ReadFile(filepath.Join(local, "coverage", "com.example.LoginTest#offline.ec"))
+	assert.NoError(t, err)
+	assert.Equal(t, "coverage2", string(data))
+
+	manifest, err := ioutil.ReadFile(filepath.Join(local, ArtifactManifestName))
+	require.NoError(t, err)
+	var artifacts []Artifact
+	require.NoError(t, json.Unmarshal(manifest, &artifacts))
+	assert.Equal(t, []Artifact{
+		{Remote: media + "/additional_test_output/LoginTest/trace.perfetto",
+			Local: filepath.Join(local, "additional_test_output", "LoginTest", "trace.perfetto"), Size: 5},
+		{Remote: media + "/coverage/com.example.LoginTest#logsIn.ec",
+			Local: filepath.Join(local, "coverage", "com.example.LoginTest#logsIn.ec"), Size: 9},
+		{Remote: media + "/coverage/com.example.LoginTest#offline.ec",
+			Local: filepath.Join(local, "coverage", "com.example.LoginTest#offline.ec"), Size: 9},
+	}, artifacts)
+}
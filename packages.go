@@ -0,0 +1,329 @@
+package adb
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Package is an app installed on the device.
+type Package struct {
+	Name string
+	// Linux user id the app runs as, shared by apps with the same sharedUserId. -1 on devices
+	// before Android 8.0, whose pm doesn't report it.
+	UID int
+}
+
+/*
+ListPackages returns the packages installed for the device's user, sorted by name. If pm
+rejects -U, as it does before Android 8.0, the packages are listed without their UIDs.
+
+Corresponds to the command:
+
+	adb shell pm list packages -U
+*/
+func (c *Device) ListPackages() ([]Package, error) {
+	packages, err := c.listPackages("-U")
+	if isUnknownOptionError(err, "-U") {
+		packages, err = c.listPackages()
+	}
+	return packages, wrapClientError(err, c, "ListPackages")
+}
+
+func (c *Device) listPackages(args ...string) ([]Package, error) {
+	cmdArgs, err := c.packageManagerCommand()
+	if err != nil {
+		return nil, err
+	}
+	cmdArgs = append(append(append(cmdArgs, "list", "packages"), args...), c.userArgs()...)
+	output, err := c.runShellCommand(quoteShellArgs(cmdArgs...))
+	if err == nil {
+		err = commandOutputError("pm list packages", output)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parsePackageList(output), nil
+}
+
+// isUnknownOptionError returns true if err is from a command that printed that it doesn't
+// know option, e.g. "Error: Unknown option: -U".
+func isUnknownOptionError(err error, option string) bool {
+	return errors.HasErrCode(err, errors.AdbError) && strings.Contains(err.Error(), "Unknown option: "+option)
+}
+
+// packagesByUID returns the names of the installed packages by uid.
+func (c *Device) packagesByUID() (map[int][]string, error) {
+	packages, err := c.ListPackages()
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[int][]string)
+	for _, pkg := range packages {
+		byUID[pkg.UID] = append(byUID[pkg.UID], pkg.Name)
+	}
+	return byUID, nil
+}
+
+// parsePackageList parses the "package:com.example uid:10123" lines of pm list packages -U.
+func parsePackageList(output string) []Package {
+	var packages []Package
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "package:") {
+			continue
+		}
+		pkg := Package{Name: strings.TrimPrefix(fields[0], "package:"), UID: -1}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "uid:") {
+				// Apps installed for several users list a uid per user.
+				uid := strings.SplitN(strings.TrimPrefix(field, "uid:"), ",", 2)[0]
+				if value, err := strconv.Atoi(uid); err == nil {
+					pkg.UID = value
+				}
+			}
+		}
+		packages = append(packages, pkg)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	return packages
+}
+
+// InstrumentationTarget is a test runner installed on the device, as listed by pm.
+type InstrumentationTarget struct {
+	// Component to pass to am instrument, e.g.
+	// com.example.test/androidx.test.runner.AndroidJUnitRunner.
+	Runner string
+	// Package the runner instruments, e.g. com.example.
+	TargetPackage string
+}
+
+/*
+ListInstrumentation returns the test runners installed on the device, sorted by runner, so
+test frameworks can find the runner for a package instead of assuming AndroidJUnitRunner.
+
+Corresponds to the command:
+
+	adb shell pm list instrumentation
+*/
+func (c *Device) ListInstrumentation() ([]InstrumentationTarget, error) {
+	cmdArgs, err := c.packageManagerCommand()
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListInstrumentation")
+	}
+	output, err := c.runShellCommand(quoteShellArgs(append(cmdArgs, "list", "instrumentation")...))
+	if err == nil {
+		err = commandOutputError("pm list instrumentation", output)
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListInstrumentation")
+	}
+	return parseInstrumentationList(output), nil
+}
+
+// parseInstrumentationList parses the "instrumentation:com.example.test/.Runner
+// (target=com.example)" lines of pm list instrumentation.
+func parseInstrumentationList(output string) []InstrumentationTarget {
+	var targets []InstrumentationTarget
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "instrumentation:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "instrumentation:"))
+		if len(fields) == 0 {
+			continue
+		}
+		target := InstrumentationTarget{Runner: fields[0]}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "(target=") {
+				target.TargetPackage = strings.TrimSuffix(strings.TrimPrefix(field, "(target="), ")")
+			}
+		}
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Runner < targets[j].Runner })
+	return targets
+}
+
+/*
+ClearData deletes all data associated with pkg, as if it had just been installed.
+
+Corresponds to the command:
+
+	adb shell pm clear <package>
+*/
+func (c *Device) ClearData(pkg string) error {
+	output, err := c.runPackageManager("clear", pkg)
+	if err == nil && !strings.HasPrefix(strings.TrimSpace(output), "Success") {
+		err = errors.Errorf(errors.AdbError, "pm clear failed: %s", strings.TrimSpace(output))
+	}
+	return wrapClientError(err, c, "ClearData(%s)", pkg)
+}
+
+/*
+GrantPermission grants a runtime permission, e.g. android.permission.CAMERA, to pkg.
+
+Corresponds to the command:
+
+	adb shell pm grant <package> <permission>
+*/
+func (c *Device) GrantPermission(pkg, permission string) error {
+	output, err := c.runPackageManager("grant", pkg, permission)
+	if err == nil {
+		err = commandOutputError("pm grant", output)
+	}
+	return wrapClientError(err, c, "GrantPermission(%s, %s)", pkg, permission)
+}
+
+/*
+RevokePermission revokes a runtime permission from pkg.
+
+Corresponds to the command:
+
+	adb shell pm revoke <package> <permission>
+*/
+func (c *Device) RevokePermission(pkg, permission string) error {
+	output, err := c.runPackageManager("revoke", pkg, permission)
+	if err == nil {
+		err = commandOutputError("pm revoke", output)
+	}
+	return wrapClientError(err, c, "RevokePermission(%s, %s)", pkg, permission)
+}
+
+// PackageEnabledState is whether a package is enabled for a user, from the package manager's
+// COMPONENT_ENABLED_STATE values.
+//
+//go:generate stringer -type=PackageEnabledState
+type PackageEnabledState int8
+
+const (
+	// Enabled or disabled as declared in the manifest, which is enabled for most apps.
+	PackageEnabledDefault PackageEnabledState = iota
+	PackageEnabled
+	// Disabled by the system or an app, e.g. by pm disable as root.
+	PackageDisabled
+	// Disabled by the user or DisablePackage. Users can enable it again in settings.
+	PackageDisabledUser
+	// Disabled until the user tries to use it, e.g. some preinstalled apps.
+	PackageDisabledUntilUsed
+)
+
+/*
+DisablePackage disables pkg for the device's user, e.g. preinstalled apps that would interfere
+with tests. Disabled apps are hidden from the launcher and can't run, but stay installed, even
+system apps that can't be uninstalled.
+
+Corresponds to the command:
+
+	adb shell pm disable-user <package>
+*/
+func (c *Device) DisablePackage(pkg string) error {
+	err := c.setPackageEnabled("disable-user", pkg)
+	return wrapClientError(err, c, "DisablePackage(%s)", pkg)
+}
+
+/*
+EnablePackage enables pkg for the device's user, after DisablePackage.
+
+Corresponds to the command:
+
+	adb shell pm enable <package>
+*/
+func (c *Device) EnablePackage(pkg string) error {
+	err := c.setPackageEnabled("enable", pkg)
+	return wrapClientError(err, c, "EnablePackage(%s)", pkg)
+}
+
+// setPackageEnabled runs pm verb, which prints the new state, e.g. "Package com.example new
+// state: disabled-user".
+func (c *Device) setPackageEnabled(verb, pkg string) error {
+	output, err := c.runPackageManager(verb, pkg)
+	if err != nil {
+		return err
+	}
+	if err := commandOutputError("pm "+verb, output); err != nil {
+		return err
+	}
+	if !strings.Contains(output, "new state:") {
+		return errors.Errorf(errors.AdbError, "pm %s failed: %s", verb, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+/*
+PackageEnabledState returns whether pkg is enabled for the device's user, or the first user
+with the package if no user, UserAll, or UserCurrent is set.
+
+Corresponds to the command:
+
+	adb shell dumpsys package <package>
+*/
+func (c *Device) PackageEnabledState(pkg string) (PackageEnabledState, error) {
+	output, err := c.runShellCommand(quoteShellArgs("dumpsys", "package", pkg))
+	if err != nil {
+		return PackageEnabledDefault, wrapClientError(err, c, "PackageEnabledState(%s)", pkg)
+	}
+	state, err := parsePackageEnabledState(output, pkg, c.user)
+	return state, wrapClientError(err, c, "PackageEnabledState(%s)", pkg)
+}
+
+// parsePackageEnabledState returns the enabled= value of the "User <id>:" line for user in the
+// first "Package [pkg]" section of dumpsys package, like parsePackageDump.
+func parsePackageEnabledState(output, pkg string, user *UserID) (PackageEnabledState, error) {
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "Package [") {
+			if found {
+				break
+			}
+			found = strings.HasPrefix(line, "Package ["+pkg+"]")
+			continue
+		}
+		if !found || !strings.HasPrefix(line, "User ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if user != nil && *user >= 0 && fields[1] != user.String()+":" {
+			continue
+		}
+		for _, field := range fields[2:] {
+			if strings.HasPrefix(field, "enabled=") {
+				state, err := strconv.Atoi(strings.TrimPrefix(field, "enabled="))
+				if err != nil {
+					return PackageEnabledDefault, errors.WrapErrorf(err, errors.ParseError, "invalid enabled state: %q", field)
+				}
+				return PackageEnabledState(state), nil
+			}
+		}
+	}
+	if !found {
+		return PackageEnabledDefault, errors.Errorf(errors.AdbError, "%s is not installed", pkg)
+	}
+	return PackageEnabledDefault, errors.Errorf(errors.ParseError, "no enabled state for %s", pkg)
+}
+
+// uninstallPackage removes pkg for the device's user with pm uninstall.
+func (c *Device) uninstallPackage(pkg string) error {
+	output, err := c.runPackageManager("uninstall", pkg)
+	if err == nil && !strings.HasPrefix(strings.TrimSpace(output), "Success") {
+		err = errors.Errorf(errors.AdbError, "pm uninstall failed: %s", strings.TrimSpace(output))
+	}
+	return wrapClientError(err, c, "Uninstall(%s)", pkg)
+}
+
+// runPackageManager runs pm <verb>, or cmd package <verb> where supported, with the device's
+// --user option followed by args.
+func (c *Device) runPackageManager(verb string, args ...string) (string, error) {
+	cmdArgs, err := c.packageManagerCommand()
+	if err != nil {
+		return "", err
+	}
+	cmdArgs = append(cmdArgs, verb)
+	cmdArgs = append(cmdArgs, c.userArgs()...)
+	cmdArgs = append(cmdArgs, args...)
+	return c.runShellCommand(quoteShellArgs(cmdArgs...))
+}
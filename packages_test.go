@@ -0,0 +1,83 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestDisablePackage(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"cmd package disable-user --user 10 com.vendor.assistant": "Package com.vendor.assistant new state: disabled-user\n",
+			"cmd package enable --user 10 com.vendor.assistant":       "Package com.vendor.assistant new state: enabled\n",
+			"cmd package disable-user --user 10 com.android.phone": "Exception occurred while executing 'disable-user':\n" +
+				"java.lang.IllegalArgumentException: Cannot disable a protected package: com.android.phone\n",
+			"cmd package disable-user --user 10 com.example.gone": "Unknown package: com.example.gone\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 34
+	device = device.ForUser(10)
+
+	assert.NoError(t, device.DisablePackage("com.vendor.assistant"))
+	assert.NoError(t, device.EnablePackage("com.vendor.assistant"))
+	assert.True(t, HasErrCode(device.DisablePackage("com.android.phone"), AdbError))
+	err := device.DisablePackage("com.example.gone")
+	assert.True(t, HasErrCode(err, AdbError))
+	assert.Contains(t, ErrorWithCauseChain(err), "Unknown package")
+}
+
+func TestParsePackageEnabledState(t *testing.T) {
+	const dump = `Packages:
+  Package [com.vendor.assistant] (4a1b2c3):
+    versionCode=7 minSdk=29 targetSdk=33
+    User 0: ceDataInode=1234 installed=true hidden=false suspended=false stopped=false notLaunched=false enabled=3 instant=false virtual=false
+    User 10: ceDataInode=5678 installed=true hidden=false suspended=false stopped=true notLaunched=true enabled=0 instant=false virtual=false
+
+Hidden system packages:
+  Package [com.vendor.assistant] (9d8e7f6):
+    User 0: ceDataInode=0 installed=true hidden=false suspended=false stopped=false notLaunched=false enabled=1 instant=false virtual=false
+`
+	state, err := parsePackageEnabledState(dump, "com.vendor.assistant", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, PackageDisabledUser, state)
+
+	user := UserID(10)
+	state, err = parsePackageEnabledState(dump, "com.vendor.assistant", &user)
+	assert.NoError(t, err)
+	assert.Equal(t, PackageEnabledDefault, state)
+
+	user = UserCurrent
+	state, err = parsePackageEnabledState(dump, "com.vendor.assistant", &user)
+	assert.NoError(t, err)
+	assert.Equal(t, PackageDisabledUser, state)
+
+	_, err = parsePackageEnabledState("Unable to find package: com.example.gone\n", "com.example.gone", nil)
+	assert.True(t, HasErrCode(err, AdbError))
+	assert.Equal(t, "PackageDisabledUntilUsed", PackageDisabledUntilUsed.String())
+}
+
+func TestListInstrumentation(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"cmd package list instrumentation": "instrumentation:com.example.test/androidx.test.runner.AndroidJUnitRunner (target=com.example)\n" +
+				"instrumentation:com.android.shell.tests/androidx.test.runner.AndroidJUnitRunner (target=com.android.shell)\n" +
+				"instrumentation:\n" +
+				"instrumentation:com.example.bench/androidx.benchmark.junit4.AndroidBenchmarkRunner (target=com.example)\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 34
+
+	targets, err := device.ListInstrumentation()
+	assert.NoError(t, err)
+	assert.Equal(t, []InstrumentationTarget{
+		{Runner: "com.android.shell.tests/androidx.test.runner.AndroidJUnitRunner", TargetPackage: "com.android.shell"},
+		{Runner: "com.example.bench/androidx.benchmark.junit4.AndroidBenchmarkRunner", TargetPackage: "com.example"},
+		{Runner: "com.example.test/androidx.test.runner.AndroidJUnitRunner", TargetPackage: "com.example"},
+	}, targets)
+}
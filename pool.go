@@ -0,0 +1,351 @@
+package adb
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DefaultHealthCheckInterval is the time between health checks of idle pooled devices, unless
+// DevicePoolConfig.HealthCheckInterval is set.
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// DefaultHealthCheckTimeout is the longest a health check may take before the device is
+// considered unhealthy, unless a timeout is configured.
+const DefaultHealthCheckTimeout = 10 * time.Second
+
+// PooledDevice describes a device tracked by a DevicePool.
+type PooledDevice struct {
+	Serial string
+	// From ServerConfig.Registry, empty if the device isn't in it.
+	Label        string
+	SDK          int
+	ABIs         []string
+	Model        string
+	Manufacturer string
+
+	// True if the device is connected and online.
+	Online bool
+	// True if the device is leased, or being health checked.
+	Busy bool
+	// True if the last health check passed.
+	Healthy bool
+}
+
+// DevicePoolConfig configures a DevicePool. The zero value uses the defaults.
+type DevicePoolConfig struct {
+	// Returns an error if an idle device shouldn't be leased. Defaults to checking that the
+	// device runs shell commands.
+	HealthCheck func(*Device) error
+	// Time between health checks of idle devices. Defaults to DefaultHealthCheckInterval.
+	HealthCheckInterval time.Duration
+	// Longest a health check may take before the device is unhealthy. Defaults to
+	// DefaultHealthCheckTimeout.
+	HealthCheckTimeout time.Duration
+}
+
+/*
+DevicePool tracks the online devices, and leases them out one user at a time to callers whose
+filters they match. Idle devices are health checked periodically, and unhealthy devices
+aren't leased until they pass again.
+
+E.g.
+
+	pool := client.NewDevicePool(adb.DevicePoolConfig{})
+	go pool.Run(ctx)
+
+	lease, err := pool.Acquire(ctx, adb.DeviceFilter{MinSDK: 30, ABI: "arm64-v8a"})
+	if err != nil {
+		return err
+	}
+	defer lease.Release()
+	lease.Device.InstallApp(...)
+*/
+type DevicePool struct {
+	client *Adb
+	config DevicePoolConfig
+
+	mu      sync.Mutex
+	devices map[string]*PooledDevice
+	// Closed and replaced each time a device may have become available.
+	changed chan struct{}
+	// Waited on by tests for the checks started by handleEvent and checkHealth.
+	checks sync.WaitGroup
+}
+
+func (c *Adb) NewDevicePool(config DevicePoolConfig) *DevicePool {
+	if config.HealthCheck == nil {
+		config.HealthCheck = defaultHealthCheck
+	}
+	if config.HealthCheckInterval == 0 {
+		config.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+	if config.HealthCheckTimeout == 0 {
+		config.HealthCheckTimeout = DefaultHealthCheckTimeout
+	}
+	return &DevicePool{
+		client:  c,
+		config:  config,
+		devices: map[string]*PooledDevice{},
+		changed: make(chan struct{}),
+	}
+}
+
+func defaultHealthCheck(device *Device) error {
+	output, err := device.RunCommand("echo", "ok")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(output) != "ok" {
+		return errors.Errorf(errors.AdbError, "unexpected health check output: %q", output)
+	}
+	return nil
+}
+
+// Run tracks devices and health checks idle ones, until ctx is done or the device watcher
+// fails.
+func (p *DevicePool) Run(ctx context.Context) error {
+	watcher := p.client.NewDeviceWatcherWithCtx(ctx)
+	ticker := time.NewTicker(p.config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.C():
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return watcher.Err()
+			}
+			p.handleEvent(event)
+		case <-ticker.C:
+			p.checkHealth()
+		}
+	}
+}
+
+// Devices returns the devices tracked by the pool, sorted by serial.
+func (p *DevicePool) Devices() []PooledDevice {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	devices := make([]PooledDevice, 0, len(p.devices))
+	for _, device := range p.devices {
+		devices = append(devices, *device)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Serial < devices[j].Serial })
+	return devices
+}
+
+/*
+Acquire blocks until a device matching filter is available, and leases it. The lease is released
+when Release is called or ctx is done, whichever happens first.
+
+Returns ctx's error if it's done before a device is available.
+*/
+func (p *DevicePool) Acquire(ctx context.Context, filter DeviceFilter) (*Lease, error) {
+	for {
+		p.mu.Lock()
+		device := p.findAvailableLocked(filter)
+		if device != nil {
+			device.Busy = true
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		if device != nil {
+			lease := &Lease{
+				Device: p.client.Device(DeviceWithSerial(device.Serial)),
+				pool:   p,
+				serial: device.Serial,
+				done:   make(chan struct{}),
+			}
+			go lease.releaseWhenDone(ctx)
+			return lease, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *DevicePool) findAvailableLocked(filter DeviceFilter) *PooledDevice {
+	var serials []string
+	for serial, device := range p.devices {
+		props := &deviceProperties{SDK: device.SDK, ABIs: device.ABIs, Model: device.Model, Manufacturer: device.Manufacturer}
+		if device.Online && device.Healthy && !device.Busy && filter.matches(serial, StateOnline, props) {
+			serials = append(serials, serial)
+		}
+	}
+	if len(serials) == 0 {
+		return nil
+	}
+	// Lease devices in a stable order, so results are reproducible.
+	sort.Strings(serials)
+	return p.devices[serials[0]]
+}
+
+// notifyLocked wakes up callers waiting in Acquire.
+func (p *DevicePool) notifyLocked() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *DevicePool) handleEvent(event DeviceStateChangedEvent) {
+	if event.WentOffline() {
+		p.mu.Lock()
+		if device, ok := p.devices[event.Serial]; ok {
+			device.Online, device.Healthy = false, false
+		}
+		p.mu.Unlock()
+		return
+	}
+	if !event.CameOnline() {
+		return
+	}
+
+	p.mu.Lock()
+	device, ok := p.devices[event.Serial]
+	if !ok {
+		device = &PooledDevice{Serial: event.Serial}
+		p.devices[event.Serial] = device
+	}
+	device.Online = true
+	// A leased device that reconnected is checked by the next health check after it's
+	// released.
+	if device.Busy {
+		p.mu.Unlock()
+		return
+	}
+	device.Busy = true
+	p.mu.Unlock()
+
+	p.startCheck(event.Serial)
+}
+
+// checkHealth starts health checking every idle online device, concurrently.
+func (p *DevicePool) checkHealth() {
+	p.mu.Lock()
+	var serials []string
+	for serial, device := range p.devices {
+		if device.Online && !device.Busy {
+			device.Busy = true
+			serials = append(serials, serial)
+		}
+	}
+	p.mu.Unlock()
+
+	sort.Strings(serials)
+	for _, serial := range serials {
+		p.startCheck(serial)
+	}
+}
+
+// startCheck checks the device with serial in a new goroutine, off the Run loop. The device
+// must have been marked busy, so it isn't leased meanwhile.
+func (p *DevicePool) startCheck(serial string) {
+	p.checks.Add(1)
+	go func() {
+		defer p.checks.Done()
+		p.check(serial)
+	}()
+}
+
+// check reads the properties of the device with serial, if they haven't been read, and runs
+// the health check. If that takes longer than the timeout the device is unhealthy, but stays
+// busy until the hung check returns, since device calls can't be interrupted.
+func (p *DevicePool) check(serial string) {
+	p.mu.Lock()
+	info := *p.devices[serial]
+	p.mu.Unlock()
+
+	type result struct {
+		info PooledDevice
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		info, err := p.readAndCheck(info)
+		results <- result{info, err}
+	}()
+
+	timer := time.NewTimer(p.config.HealthCheckTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-results:
+		p.finishCheck(serial, r.info, r.err)
+	case <-timer.C:
+		p.mu.Lock()
+		p.devices[serial].Healthy = false
+		p.mu.Unlock()
+		err := errors.Errorf(errors.NetworkError, "health check timed out after %s", p.config.HealthCheckTimeout)
+		go func() {
+			r := <-results
+			p.finishCheck(serial, r.info, err)
+		}()
+	}
+}
+
+func (p *DevicePool) readAndCheck(info PooledDevice) (PooledDevice, error) {
+	device := p.client.Device(DeviceWithSerial(info.Serial))
+	if info.SDK == 0 {
+		props, err := readDeviceProperties(device)
+		if err != nil {
+			return info, err
+		}
+		info.SDK, info.ABIs, info.Model, info.Manufacturer = props.SDK, props.ABIs, props.Model, props.Manufacturer
+	}
+	return info, p.config.HealthCheck(device)
+}
+
+// finishCheck stores the result of a health check, and makes the device available again.
+func (p *DevicePool) finishCheck(serial string, info PooledDevice, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	current := p.devices[serial]
+	// Picks up changes to the registry.
+	current.Label = p.client.server.registry().name(serial)
+	current.SDK, current.ABIs, current.Model, current.Manufacturer = info.SDK, info.ABIs, info.Model, info.Manufacturer
+	current.Healthy = err == nil
+	current.Busy = false
+	p.notifyLocked()
+}
+
+// Lease is exclusive use of a device in a DevicePool.
+type Lease struct {
+	Device *Device
+
+	pool   *DevicePool
+	serial string
+	once   sync.Once
+	done   chan struct{}
+}
+
+// Release returns the device to the pool. It's safe to call more than once.
+func (l *Lease) Release() {
+	l.once.Do(func() {
+		close(l.done)
+		l.pool.mu.Lock()
+		defer l.pool.mu.Unlock()
+		if device, ok := l.pool.devices[l.serial]; ok {
+			device.Busy = false
+		}
+		l.pool.notifyLocked()
+	})
+}
+
+func (l *Lease) releaseWhenDone(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		l.Release()
+	case <-l.done:
+	}
+}
@@ -0,0 +1,137 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func newTestPool(healthCheck func(*Device) error) *DevicePool {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk":    "30\n",
+			"getprop ro.product.cpu.abilist":  "arm64-v8a,armeabi-v7a\n",
+			"getprop ro.product.model":        "Pixel 6\n",
+			"getprop ro.product.manufacturer": "Google\n",
+			"echo ok":                         "ok\n",
+		},
+	}
+	return (&Adb{s}).NewDevicePool(DevicePoolConfig{HealthCheck: healthCheck})
+}
+
+// acquireWithTimeout returns quickly if no device is available, and any lease is released
+// when it returns.
+func acquireWithTimeout(pool *DevicePool, filter DeviceFilter) (*Lease, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	return pool.Acquire(ctx, filter)
+}
+
+func TestDevicePoolLeasesMatchingDevice(t *testing.T) {
+	pool := newTestPool(nil)
+	pool.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	pool.checks.Wait()
+	assert.Equal(t, []PooledDevice{{
+		Serial:       "serial",
+		SDK:          30,
+		ABIs:         []string{"arm64-v8a", "armeabi-v7a"},
+		Model:        "Pixel 6",
+		Manufacturer: "Google",
+		Online:       true,
+		Healthy:      true,
+	}}, pool.Devices())
+
+	_, err := acquireWithTimeout(pool, DeviceFilter{MinSDK: 31})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	_, err = acquireWithTimeout(pool, DeviceFilter{ABI: "x86_64"})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	lease, err := pool.Acquire(context.Background(), DeviceFilter{MinSDK: 29, MaxSDK: 30, ABI: "armeabi-v7a", Model: "Pixel 6"})
+	require.NoError(t, err)
+	assert.Equal(t, "serial", lease.Device.descriptor.serial)
+	assert.True(t, pool.Devices()[0].Busy)
+
+	_, err = acquireWithTimeout(pool, DeviceFilter{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	lease.Release()
+	lease.Release()
+	_, err = acquireWithTimeout(pool, DeviceFilter{})
+	assert.NoError(t, err)
+}
+
+func TestDevicePoolReleasesOnContextDone(t *testing.T) {
+	pool := newTestPool(nil)
+	pool.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	pool.checks.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := pool.Acquire(ctx, DeviceFilter{})
+	require.NoError(t, err)
+
+	// The waiting Acquire is woken up when the lease is released.
+	go cancel()
+	ctx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+	defer cancelWait()
+	_, err = pool.Acquire(ctx, DeviceFilter{})
+	assert.NoError(t, err)
+}
+
+func TestDevicePoolSkipsUnhealthyAndOfflineDevices(t *testing.T) {
+	healthy := false
+	pool := newTestPool(func(*Device) error {
+		if !healthy {
+			return errors.Errorf(errors.AdbError, "unhealthy")
+		}
+		return nil
+	})
+	pool.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	pool.checks.Wait()
+	assert.False(t, pool.Devices()[0].Healthy)
+	_, err := acquireWithTimeout(pool, DeviceFilter{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	healthy = true
+	pool.checkHealth()
+	pool.checks.Wait()
+	assert.True(t, pool.Devices()[0].Healthy)
+
+	pool.handleEvent(DeviceStateChangedEvent{"serial", StateOnline, StateOffline})
+	assert.False(t, pool.Devices()[0].Online)
+	_, err = acquireWithTimeout(pool, DeviceFilter{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestDevicePoolTimesOutHungHealthCheck(t *testing.T) {
+	unblock := make(chan struct{})
+	pool := newTestPool(func(*Device) error {
+		<-unblock
+		return nil
+	})
+	pool.config.HealthCheckTimeout = 10 * time.Millisecond
+
+	pool.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	pool.checks.Wait()
+	device := pool.Devices()[0]
+	assert.False(t, device.Healthy)
+	// The device isn't leased or checked again until the hung check returns.
+	assert.True(t, device.Busy)
+	pool.checkHealth()
+	pool.checks.Wait()
+
+	close(unblock)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for pool.Devices()[0].Busy && ctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	device = pool.Devices()[0]
+	assert.False(t, device.Busy)
+	assert.False(t, device.Healthy)
+}
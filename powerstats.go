@@ -0,0 +1,208 @@
+package adb
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DefaultPowerRailSampleInterval is the time between PowerRailSampler snapshots, unless
+// another interval is passed to SamplePowerRails.
+const DefaultPowerRailSampleInterval = time.Second
+
+// Service of the power stats HAL, which reads the on-device power monitors (ODPM).
+const powerStatsService = "android.hardware.power.stats.IPowerStats/default"
+
+// Matches the "  S4M_VDD_CPUCL0   123456.78  mWs" lines of the energy meter section of the power
+// stats HAL dump, which are followed by the change since the last dump on some builds.
+var powerRailLinePattern = regexp.MustCompile(`^\s*(\S.*?)\s+([\d.]+)\s+mWs\b`)
+
+// PowerRail is the energy a power rail has supplied since the device booted.
+type PowerRail struct {
+	// Name of the rail, e.g. S4M_VDD_CPUCL0 for the little CPU cores; names are device specific.
+	Name string
+	// Energy supplied since boot, in millijoules (mWs).
+	Energy float64
+}
+
+// RailPower is the energy a power rail supplied during an interval.
+type RailPower struct {
+	Name string
+	// Energy supplied during the interval, in millijoules.
+	Energy float64
+	// Average power during the interval, in milliwatts.
+	Power float64
+}
+
+// PowerRailSnapshot is one sample of a PowerRailSampler.
+type PowerRailSnapshot struct {
+	Time time.Time
+	// Time since the previous snapshot.
+	Interval time.Duration
+	// Sorted by name.
+	Rails []RailPower
+}
+
+/*
+PowerRails returns the energy counters of the device's power rails, from the on-device power
+monitors (ODPM) that Pixel and some other devices have. It returns an AdbError if the device
+doesn't have them.
+
+Corresponds to the command:
+
+	adb shell dumpsys android.hardware.power.stats.IPowerStats/default
+*/
+func (c *Device) PowerRails() ([]PowerRail, error) {
+	rails, err := c.powerRails()
+	return rails, wrapClientError(err, c, "PowerRails")
+}
+
+func (c *Device) powerRails() ([]PowerRail, error) {
+	output, err := c.runShellCommand(quoteShellArgs("dumpsys", powerStatsService))
+	if err != nil {
+		return nil, err
+	}
+	return parsePowerStats(output)
+}
+
+// PowerRailSampler publishes the power drawn from a device's power rails over time.
+type PowerRailSampler struct {
+	snapshotChan chan PowerRailSnapshot
+	// If an error occurs, it is stored here and snapshotChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get snapshots. It's closed when the context
+// passed to SamplePowerRails is done, or if an error occurs.
+func (s *PowerRailSampler) C() <-chan PowerRailSnapshot {
+	return s.snapshotChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (s *PowerRailSampler) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+SamplePowerRails reads the energy counters of the device's power rails once per interval, until
+ctx is done, and sends the energy and average power of each rail during the interval. The first
+snapshot is sent after the first interval. If interval is 0, DefaultPowerRailSampleInterval is
+used. It returns an AdbError if the device doesn't have power rails, see PowerRails.
+
+The monitors are much finer than the battery level, so short scenarios can be compared, e.g.
+the CPU power of a scroll:
+
+	sampler, err := device.SamplePowerRails(ctx, time.Second)
+	for snapshot := range sampler.C() {
+		for _, rail := range snapshot.Rails {
+			fmt.Printf("%s %.1f mW\n", rail.Name, rail.Power)
+		}
+	}
+*/
+func (c *Device) SamplePowerRails(ctx context.Context, interval time.Duration) (*PowerRailSampler, error) {
+	if interval <= 0 {
+		interval = DefaultPowerRailSampleInterval
+	}
+	previous, err := c.powerRails()
+	if err != nil {
+		return nil, wrapClientError(err, c, "SamplePowerRails")
+	}
+	previousTime := time.Now()
+
+	sampler := &PowerRailSampler{snapshotChan: make(chan PowerRailSnapshot)}
+	go func() {
+		defer close(sampler.snapshotChan)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, err := c.powerRails()
+			if err != nil {
+				sampler.err.Store(wrapClientError(err, c, "SamplePowerRails"))
+				return
+			}
+			now := time.Now()
+			snapshot := PowerRailSnapshot{
+				Time:     now,
+				Interval: now.Sub(previousTime),
+				Rails:    railPower(previous, current, now.Sub(previousTime)),
+			}
+			previous, previousTime = current, now
+
+			select {
+			case sampler.snapshotChan <- snapshot:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sampler, nil
+}
+
+// railPower returns the energy and power of the rails in current since previous.
+func railPower(previous, current []PowerRail, elapsed time.Duration) []RailPower {
+	energies := make(map[string]float64, len(previous))
+	for _, rail := range previous {
+		energies[rail.Name] = rail.Energy
+	}
+	rails := make([]RailPower, 0, len(current))
+	for _, rail := range current {
+		power := RailPower{Name: rail.Name, Energy: rail.Energy - energies[rail.Name]}
+		if power.Energy < 0 {
+			// The counter was reset.
+			power.Energy = rail.Energy
+		}
+		if elapsed > 0 {
+			power.Power = power.Energy / elapsed.Seconds()
+		}
+		rails = append(rails, power)
+	}
+	return rails
+}
+
+// parsePowerStats parses the energy meter section of the power stats HAL dump.
+func parsePowerStats(output string) ([]PowerRail, error) {
+	var rails []PowerRail
+	inMeter := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "=====") {
+			inMeter = strings.Contains(line, "energy meter")
+			continue
+		}
+		if !inMeter {
+			continue
+		}
+		match := powerRailLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		energy, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return nil, errors.WrapErrorf(err, errors.ParseError, "invalid energy meter line: %q", line)
+		}
+		rails = append(rails, PowerRail{Name: match[1], Energy: energy})
+	}
+	if len(rails) == 0 {
+		return nil, errors.Errorf(errors.AdbError, "power rails aren't available: %s",
+			strings.TrimSpace(strings.SplitN(output, "\n", 2)[0]))
+	}
+	sort.Slice(rails, func(i, j int) bool { return rails[i].Name < rails[j].Name })
+	return rails, nil
+}
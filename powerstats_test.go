@@ -0,0 +1,65 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testPowerStats = `
+============= PowerStats HAL 2.0 state residencies ==============
+                   Entity                 State        Total time     Total entries
+                      CPU                   OFF         123456 ms             7890
+
+============= PowerStats HAL 2.0 energy meter ==============
+                           Channel    Cumulative Energy
+     [VSYS_PWR_DISPLAY]:Display          2500.50  mWs
+  [S4M_VDD_CPUCL0]:CPU(LITTLE)          12000.25  mWs (         10.00)
+`
+
+func TestParsePowerStats(t *testing.T) {
+	rails, err := parsePowerStats(testPowerStats)
+	require.NoError(t, err)
+	assert.Equal(t, []PowerRail{
+		{Name: "[S4M_VDD_CPUCL0]:CPU(LITTLE)", Energy: 12000.25},
+		{Name: "[VSYS_PWR_DISPLAY]:Display", Energy: 2500.5},
+	}, rails)
+
+	_, err = parsePowerStats("Can't find service: android.hardware.power.stats.IPowerStats/default\n")
+	assert.True(t, HasErrCode(err, AdbError))
+}
+
+func TestRailPower(t *testing.T) {
+	rails := railPower(
+		[]PowerRail{{Name: "cpu", Energy: 1000}, {Name: "gpu", Energy: 500}},
+		[]PowerRail{{Name: "cpu", Energy: 1500}, {Name: "gpu", Energy: 100}, {Name: "new", Energy: 20}},
+		2*time.Second)
+	assert.Equal(t, []RailPower{
+		{Name: "cpu", Energy: 500, Power: 250},
+		{Name: "gpu", Energy: 100, Power: 50},
+		{Name: "new", Energy: 20, Power: 10},
+	}, rails)
+}
+
+func TestSamplePowerRails(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dumpsys android.hardware.power.stats.IPowerStats/default": testPowerStats,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sampler, err := device.SamplePowerRails(ctx, time.Millisecond)
+	require.NoError(t, err)
+	snapshot := <-sampler.C()
+	require.Len(t, snapshot.Rails, 2)
+	assert.Equal(t, 0.0, snapshot.Rails[0].Energy)
+	assert.True(t, snapshot.Interval > 0)
+}
@@ -0,0 +1,206 @@
+package adb
+
+import (
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Process is a process running on the device, as listed by ps.
+type Process struct {
+	PID, PPID int
+	// -1 on devices before Android 8.0, whose ps doesn't report it.
+	UID  int
+	User string
+	// Resident set size and virtual memory size, in KiB.
+	RSS, VSZ int64
+	// Single letter state, e.g. R for running or S for sleeping. Empty if ps doesn't
+	// report it.
+	State string
+	// Name of the process, the package name for app processes, with a suffix like
+	// ":remote" for their secondary processes.
+	Name string
+}
+
+// Columns ListProcesses asks toybox ps for. NAME is last, since it may contain spaces.
+const psColumns = "PID,PPID,UID,USER,RSS,VSZ,S,NAME"
+
+/*
+ListProcesses returns the processes running on the device, as far as the shell user can see.
+
+Corresponds to the command:
+
+	adb shell ps -A -o PID,PPID,UID,USER,RSS,VSZ,S,NAME
+
+or on devices before Android 8.0, whose toolbox ps has fixed columns:
+
+	adb shell ps
+*/
+func (c *Device) ListProcesses() ([]*Process, error) {
+	toybox, err := c.supportsSDK(sdkToyboxPs)
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListProcesses")
+	}
+
+	var output string
+	if toybox {
+		output, err = c.runShellCommand("ps -A -o " + psColumns)
+	} else {
+		output, err = c.runShellCommand("ps")
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListProcesses")
+	}
+
+	var processes []*Process
+	if toybox {
+		processes, err = parseToyboxPs(output)
+	} else {
+		processes, err = parseToolboxPs(output)
+	}
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListProcesses")
+	}
+	return processes, nil
+}
+
+/*
+PidOf returns the ids of the processes named name, e.g. an app's package name, or nothing if
+none are running. Secondary processes of apps, like "com.example:remote", have their own names.
+*/
+func (c *Device) PidOf(name string) ([]int, error) {
+	processes, err := c.ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, process := range processes {
+		if process.Name == name {
+			pids = append(pids, process.PID)
+		}
+	}
+	return pids, nil
+}
+
+/*
+Kill sends signal to the process pid. The shell user can only signal its own processes, and
+debuggable apps' through RunAs; it gets a PermissionDenied error for others.
+
+Corresponds to the command:
+
+	adb shell kill -<signal> <pid>
+*/
+func (c *Device) Kill(pid int, signal syscall.Signal) error {
+	output, err := c.runShellCommand("kill -" + strconv.Itoa(int(signal)) + " " + strconv.Itoa(pid))
+	if err == nil {
+		err = killError(output)
+	}
+	return wrapClientError(err, c, "Kill(%d, %d)", pid, int(signal))
+}
+
+// killError returns an error if kill printed anything, e.g.
+// "/system/bin/sh: kill: 1: Operation not permitted".
+func killError(output string) error {
+	output = strings.TrimSpace(output)
+	switch {
+	case output == "":
+		return nil
+	case strings.Contains(output, "Operation not permitted"):
+		return errors.Errorf(errors.PermissionDenied, "%s", output)
+	}
+	return errors.Errorf(errors.AdbError, "kill failed: %s", output)
+}
+
+// parseToyboxPs parses the output of ps -A -o psColumns.
+func parseToyboxPs(output string) ([]*Process, error) {
+	var processes []*Process
+	for i, line := range psLines(output) {
+		if i == 0 {
+			// Header.
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, errors.Errorf(errors.ParseError, "invalid ps line: %q", line)
+		}
+		var ints psIntParser
+		process := &Process{
+			PID:   int(ints.parse(fields[0])),
+			PPID:  int(ints.parse(fields[1])),
+			UID:   int(ints.parse(fields[2])),
+			User:  fields[3],
+			RSS:   ints.parse(fields[4]),
+			VSZ:   ints.parse(fields[5]),
+			State: fields[6],
+			Name:  strings.Join(fields[7:], " "),
+		}
+		if err := ints.err; err != nil {
+			return nil, errors.WrapErrorf(err, errors.ParseError, "invalid ps line: %q", line)
+		}
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
+
+// parseToolboxPs parses the output of toolbox ps, whose columns are
+//
+//	USER PID PPID VSIZE RSS WCHAN PC NAME
+//
+// with an unlabeled state column before NAME on most builds.
+func parseToolboxPs(output string) ([]*Process, error) {
+	var processes []*Process
+	for i, line := range psLines(output) {
+		if i == 0 {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			return nil, errors.Errorf(errors.ParseError, "invalid ps line: %q", line)
+		}
+		var ints psIntParser
+		process := &Process{
+			PID:  int(ints.parse(fields[1])),
+			PPID: int(ints.parse(fields[2])),
+			UID:  -1,
+			User: fields[0],
+			VSZ:  ints.parse(fields[3]),
+			RSS:  ints.parse(fields[4]),
+		}
+		if err := ints.err; err != nil {
+			return nil, errors.WrapErrorf(err, errors.ParseError, "invalid ps line: %q", line)
+		}
+		rest := fields[7:]
+		if len(rest) > 1 && len(rest[0]) == 1 {
+			process.State, rest = rest[0], rest[1:]
+		}
+		process.Name = strings.Join(rest, " ")
+		processes = append(processes, process)
+	}
+	return processes, nil
+}
+
+// psIntParser parses numeric ps columns, and keeps the first error.
+type psIntParser struct {
+	err error
+}
+
+func (p *psIntParser) parse(field string) int64 {
+	value, err := strconv.ParseInt(field, 10, 64)
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	return value
+}
+
+// psLines returns the non-empty lines of output.
+func psLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
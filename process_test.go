@@ -0,0 +1,75 @@
+package adb
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseToyboxPs(t *testing.T) {
+	processes, err := parseToyboxPs(`  PID  PPID   UID USER           RSS    VSZ S NAME
+    1     0     0 root          9876  12345 S init
+ 4321   612 10123 u0_a123     102400 4800000 S com.example.app:remote
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []*Process{
+		{PID: 1, PPID: 0, UID: 0, User: "root", RSS: 9876, VSZ: 12345, State: "S", Name: "init"},
+		{PID: 4321, PPID: 612, UID: 10123, User: "u0_a123", RSS: 102400, VSZ: 4800000, State: "S", Name: "com.example.app:remote"},
+	}, processes)
+
+	_, err = parseToyboxPs("PID PPID UID USER RSS VSZ S NAME\nx 0 0 root 1 1 S init\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestParseToolboxPs(t *testing.T) {
+	processes, err := parseToolboxPs(`USER      PID   PPID  VSIZE  RSS   WCHAN              PC  NAME
+root      1     0     8904   788   SyS_epoll_ 0000000000 S /init
+u0_a12    2345  190   1012345 65432 SyS_epoll_ 0000000000 S com.example.app
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []*Process{
+		{PID: 1, PPID: 0, UID: -1, User: "root", RSS: 788, VSZ: 8904, State: "S", Name: "/init"},
+		{PID: 2345, PPID: 190, UID: -1, User: "u0_a12", RSS: 65432, VSZ: 1012345, State: "S", Name: "com.example.app"},
+	}, processes)
+}
+
+func TestPidOf(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk": "30\n",
+			"ps -A -o " + psColumns: `PID PPID UID USER RSS VSZ S NAME
+100 1 10123 u0_a123 1 1 S com.example.app
+101 1 10123 u0_a123 1 1 S com.example.app:remote
+`,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	pids, err := device.PidOf("com.example.app")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{100}, pids)
+
+	pids, err = device.PidOf("com.example.other")
+	assert.NoError(t, err)
+	assert.Empty(t, pids)
+}
+
+func TestKill(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"kill -9 1": "/system/bin/sh: kill: 1: Operation not permitted\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	assert.NoError(t, device.Kill(1234, syscall.SIGTERM))
+	assert.Equal(t, "shell:kill -15 1234", s.Requests[len(s.Requests)-1])
+
+	err := device.Kill(1, syscall.SIGKILL)
+	assert.True(t, HasErrCode(err, PermissionDenied))
+}
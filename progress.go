@@ -0,0 +1,127 @@
+package adb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// PushEvent reports the progress of PushWithProgress or PullWithProgress.
+type PushEvent struct {
+	Current int64
+	// Size of the file, or 0 if it's unknown, e.g. when pushing stdin.
+	Total int64
+	// Rate over the last progressInterval, formatted, e.g. "1.25 MB/s".
+	Speed string
+	// Never set, only kept for compatibility.
+	Raw string
+	// Bytes per second over the last progressInterval.
+	Rate float64
+	// Time since the transfer started.
+	Elapsed time.Duration
+}
+
+// TransferStats describes a completed, or failed, transfer.
+type TransferStats struct {
+	Bytes    int64
+	Duration time.Duration
+}
+
+// Rate returns the average bytes per second of the transfer.
+func (s *TransferStats) Rate() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+func (s *TransferStats) String() string {
+	return fmt.Sprintf("%s (%d bytes in %s)", formatRate(s.Rate()), s.Bytes, s.Duration)
+}
+
+// Minimum time between progress events, except the last one.
+const progressInterval = 200 * time.Millisecond
+
+// progressCounter calls cb with the bytes written to it, at most once per progressInterval.
+type progressCounter struct {
+	total int64
+	cb    func(PushEvent)
+
+	start       time.Time
+	current     int64
+	lastTime    time.Time
+	lastCurrent int64
+}
+
+func newProgressCounter(total int64, cb func(PushEvent)) *progressCounter {
+	now := time.Now()
+	return &progressCounter{total: total, cb: cb, start: now, lastTime: now}
+}
+
+func (p *progressCounter) Write(b []byte) (int, error) {
+	p.current += int64(len(b))
+	if now := time.Now(); now.Sub(p.lastTime) >= progressInterval {
+		p.report(now)
+	}
+	return len(b), nil
+}
+
+func (p *progressCounter) report(now time.Time) {
+	var rate float64
+	if elapsed := now.Sub(p.lastTime).Seconds(); elapsed > 0 {
+		rate = float64(p.current-p.lastCurrent) / elapsed
+	}
+	p.cb(PushEvent{
+		Current: p.current,
+		Total:   p.total,
+		Speed:   formatRate(rate),
+		Rate:    rate,
+		Elapsed: now.Sub(p.start),
+	})
+	p.lastTime, p.lastCurrent = now, p.current
+}
+
+// copyWithStats copies src to dst, calls cb with the progress if it's not nil, and returns the
+// stats of the copy.
+func copyWithStats(dst io.Writer, src io.Reader, size int64, cb func(PushEvent)) (*TransferStats, error) {
+	var progress *progressCounter
+	if cb != nil {
+		progress = newProgressCounter(size, cb)
+		// Tee the source, so dst can still tell io.Copy to read into its own buffers.
+		src = io.TeeReader(src, progress)
+	}
+
+	startTime := time.Now()
+	copied, err := io.Copy(dst, src)
+	stats := &TransferStats{Bytes: copied, Duration: time.Since(startTime)}
+
+	if pathErr, ok := err.(*os.PathError); ok {
+		if errno, ok := pathErr.Err.(syscall.Errno); ok && errno == syscall.EPIPE {
+			// Pipe closed. Handle this like an EOF.
+			err = nil
+		}
+	}
+	if err != nil {
+		return stats, err
+	}
+	if progress != nil {
+		progress.report(time.Now())
+	}
+	return stats, nil
+}
+
+// formatRate formats bytes per second with decimal units, e.g. "1.25 MB/s".
+func formatRate(bytesPerSecond float64) string {
+	units := []string{"B/s", "kB/s", "MB/s", "GB/s"}
+	unit := 0
+	for bytesPerSecond >= 1000 && unit < len(units)-1 {
+		bytesPerSecond /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f %s", bytesPerSecond, units[unit])
+	}
+	return fmt.Sprintf("%.2f %s", bytesPerSecond, units[unit])
+}
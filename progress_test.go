@@ -0,0 +1,165 @@
+package adb
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestFormatRate(t *testing.T) {
+	assert.Equal(t, "512 B/s", formatRate(512))
+	assert.Equal(t, "1.25 MB/s", formatRate(1250000))
+	assert.Equal(t, "3.00 GB/s", formatRate(3e9))
+}
+
+func TestTransferStatsRate(t *testing.T) {
+	stats := &TransferStats{Bytes: 2000, Duration: 2 * time.Second}
+	assert.Equal(t, 1000.0, stats.Rate())
+	assert.Equal(t, "1.00 kB/s (2000 bytes in 2s)", stats.String())
+	assert.Equal(t, 0.0, (&TransferStats{Bytes: 1}).Rate())
+}
+
+func TestCopyWithStats(t *testing.T) {
+	var events []PushEvent
+	var dst bytes.Buffer
+	stats, err := copyWithStats(&dst, strings.NewReader("hello"), 5, func(event PushEvent) {
+		events = append(events, event)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", dst.String())
+	assert.Equal(t, int64(5), stats.Bytes)
+	// The last event is always reported.
+	require.NotEmpty(t, events)
+	last := events[len(events)-1]
+	assert.Equal(t, int64(5), last.Current)
+	assert.Equal(t, int64(5), last.Total)
+}
+
+func TestPushWithProgress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	local := filepath.Join(dir, "a.txt")
+	require.NoError(t, ioutil.WriteFile(local, []byte("hello"), 0644))
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	var last PushEvent
+	stats, err := device.PushWithProgress(context.Background(), true, local, "/sdcard/a.txt", func(event PushEvent) {
+		last = event
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), stats.Bytes)
+	assert.Equal(t, int64(5), last.Current)
+	assert.Equal(t, []byte("hello"), s.Files["/sdcard/a.txt"].Data)
+}
+
+func TestPullWithProgress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/a.txt": {Data: []byte("hello")}},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	local := filepath.Join(dir, "a.txt")
+	stats, err := device.PullWithProgress(context.Background(), false, "/sdcard/a.txt", local, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), stats.Bytes)
+	data, err := ioutil.ReadFile(local)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	_, err = device.PullWithProgress(context.Background(), false, "/sdcard/missing", local, nil)
+	assert.True(t, HasErrCode(err, FileNoExistError))
+}
+
+func TestPushReader(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+	mtime := time.Unix(1500000000, 0).UTC()
+
+	stats, err := device.PushReader(context.Background(), strings.NewReader("generated"), "/sdcard/out", 0600, mtime, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), stats.Bytes)
+	assert.Equal(t, &MockFile{Data: []byte("generated"), Mode: 0600, ModTime: mtime}, s.Files["/sdcard/out"])
+}
+
+func TestPushReaderTruncated(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.PushReader(context.Background(), strings.NewReader("short"), "/sdcard/out", 0644, MtimeOfClose, 100)
+	assert.True(t, stderrors.Is(err, io.ErrUnexpectedEOF), "%v", err)
+	assert.NotContains(t, s.Files, "/sdcard/out")
+	assert.Contains(t, s.Requests, "shell:rm -f /sdcard/out")
+}
+
+func TestPushReaderCanceled(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := device.PushReader(ctx, strings.NewReader("data"), "/sdcard/out", 0644, MtimeOfClose, 0)
+	assert.True(t, stderrors.Is(err, context.Canceled), "%v", err)
+	assert.Contains(t, s.Requests, "shell:rm -f /sdcard/out")
+}
+
+func TestPushReaderFailedKeepsExisting(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/out/a.txt": {Data: []byte("hello")}},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.PushReader(context.Background(), strings.NewReader("short"), "/sdcard/out", 0644, MtimeOfClose, 100)
+	assert.Error(t, err)
+	for _, req := range s.Requests {
+		assert.NotContains(t, req, "rm ")
+	}
+}
+
+func TestPullTo(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/a.txt": {Data: []byte("hello")}},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	hash := sha256.New()
+	var last PushEvent
+	stats, err := device.PullToWithProgress(context.Background(), "/sdcard/a.txt", hash, func(event PushEvent) {
+		last = event
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), stats.Bytes)
+	assert.Equal(t, PushEvent{Current: 5, Total: 5, Speed: last.Speed, Rate: last.Rate, Elapsed: last.Elapsed}, last)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", hex.EncodeToString(hash.Sum(nil)))
+
+	var buf bytes.Buffer
+	stats, err = device.PullTo(context.Background(), "/sdcard/a.txt", &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), stats.Bytes)
+	assert.Equal(t, "hello", buf.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = device.PullTo(ctx, "/sdcard/a.txt", ioutil.Discard)
+	assert.True(t, stderrors.Is(err, context.Canceled), "%v", err)
+}
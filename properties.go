@@ -0,0 +1,221 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// propertyPollInterval is the time between listings of the properties, on devices without
+// watchprops.
+const propertyPollInterval = time.Second
+
+var (
+	// Matches a "[name]: [value]" line of getprop.
+	getpropLinePattern = regexp.MustCompile(`^\[([^\]]+)\]: \[(.*)\]$`)
+
+	// Matches a "1500000000 name = 'value'" line of watchprops.
+	watchpropsLinePattern = regexp.MustCompile(`^\d+ (\S+) = '(.*)'$`)
+)
+
+/*
+GetProperty returns the value of the system property name, or "" if it isn't set.
+
+Corresponds to the command:
+
+	adb shell getprop <name>
+*/
+func (c *Device) GetProperty(name string) (string, error) {
+	output, err := c.runShellCommand(quoteShellArgs("getprop", name))
+	if err != nil {
+		return "", wrapClientError(err, c, "GetProperty(%s)", name)
+	}
+	return strings.TrimRight(output, "\r\n"), nil
+}
+
+// SDKVersion returns the API level of the Android build running on the device,
+// from ro.build.version.sdk.
+func (c *Device) SDKVersion() (int, error) {
+	value, err := c.GetProperty("ro.build.version.sdk")
+	if err != nil {
+		return 0, err
+	}
+	sdk, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, wrapClientError(errors.WrapErrorf(err, errors.ParseError, "invalid SDK version: %q", value),
+			c, "SDKVersion")
+	}
+	return sdk, nil
+}
+
+/*
+Properties returns all the system properties set on the device.
+
+Corresponds to the command:
+
+	adb shell getprop
+*/
+func (c *Device) Properties() (map[string]string, error) {
+	output, err := c.runShellCommand("getprop")
+	if err != nil {
+		return nil, wrapClientError(err, c, "Properties")
+	}
+	return parseGetprop(output), nil
+}
+
+// PropertyEvent is a change to a system property reported by a PropertyWatcher.
+type PropertyEvent struct {
+	Name  string
+	Value string
+	// Value before the change, or "" if the property wasn't set.
+	OldValue string
+}
+
+// PropertyWatcher publishes changes to the system properties of a device.
+type PropertyWatcher struct {
+	eventChan chan PropertyEvent
+	// If an error occurs, it is stored here and eventChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get events. It's closed when the context
+// passed to WatchProps is done, or if an error occurs.
+func (w *PropertyWatcher) C() <-chan PropertyEvent {
+	return w.eventChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (w *PropertyWatcher) Err() error {
+	if err, ok := w.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+WatchProps reports changes to the system properties made after it's called, until ctx is done.
+Devices that have watchprops, before Android 8, stream changes as they happen. Newer devices
+list the properties every second and report the differences, so a property that changes and
+changes back between listings isn't reported.
+
+E.g. to wait for the device to finish booting:
+
+	watcher, err := device.WatchProps(ctx)
+	for event := range watcher.C() {
+		if event.Name == "sys.boot_completed" && event.Value == "1" {
+			break
+		}
+	}
+
+Corresponds to the commands:
+
+	adb shell watchprops
+	adb shell getprop
+*/
+func (c *Device) WatchProps(ctx context.Context) (*PropertyWatcher, error) {
+	props, err := c.Properties()
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.runShellCommand("command -v watchprops")
+	if err != nil {
+		return nil, wrapClientError(err, c, "WatchProps")
+	}
+
+	watcher := &PropertyWatcher{eventChan: make(chan PropertyEvent)}
+	if strings.TrimSpace(output) == "" {
+		go c.pollProps(ctx, watcher, props)
+		return watcher, nil
+	}
+
+	conn, err := c.openService("shell:watchprops")
+	if err != nil {
+		return nil, wrapClientError(err, c, "WatchProps")
+	}
+	go func() {
+		defer close(watcher.eventChan)
+		output := newContextReader(ctx, conn)
+		defer output.Close()
+
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			match := watchpropsLinePattern.FindStringSubmatch(strings.TrimRight(scanner.Text(), "\r"))
+			if match == nil {
+				continue
+			}
+			event := PropertyEvent{Name: match[1], Value: match[2], OldValue: props[match[1]]}
+			props[event.Name] = event.Value
+			select {
+			case watcher.eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			watcher.err.Store(wrapClientError(err, c, "WatchProps"))
+		}
+	}()
+	return watcher, nil
+}
+
+// pollProps sends the differences between consecutive listings of the properties, starting
+// from props, until ctx is done.
+func (c *Device) pollProps(ctx context.Context, watcher *PropertyWatcher, props map[string]string) {
+	defer close(watcher.eventChan)
+	ticker := time.NewTicker(propertyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := c.Properties()
+		if err != nil {
+			watcher.err.Store(err)
+			return
+		}
+		for _, event := range diffProperties(props, current) {
+			select {
+			case watcher.eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		props = current
+	}
+}
+
+// diffProperties returns an event for each property that's set or changed in current, sorted
+// by name. Properties can't be unset, so ones missing from current are ignored.
+func diffProperties(old, current map[string]string) []PropertyEvent {
+	var events []PropertyEvent
+	for name, value := range current {
+		if oldValue, ok := old[name]; !ok || oldValue != value {
+			events = append(events, PropertyEvent{Name: name, Value: value, OldValue: oldValue})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events
+}
+
+// parseGetprop parses the "[name]: [value]" lines of getprop.
+func parseGetprop(output string) map[string]string {
+	props := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if match := getpropLinePattern.FindStringSubmatch(strings.TrimRight(line, "\r")); match != nil {
+			props[match[1]] = match[2]
+		}
+	}
+	return props
+}
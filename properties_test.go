@@ -0,0 +1,54 @@
+package adb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseGetprop(t *testing.T) {
+	props := parseGetprop("[ro.build.version.sdk]: [30]\r\n[sys.boot_completed]: [1]\n[empty]: []\nnot a property\n")
+	assert.Equal(t, map[string]string{
+		"ro.build.version.sdk": "30",
+		"sys.boot_completed":   "1",
+		"empty":                "",
+	}, props)
+}
+
+func TestDiffProperties(t *testing.T) {
+	events := diffProperties(
+		map[string]string{"a": "1", "b": "2", "c": "3"},
+		map[string]string{"a": "1", "b": "20", "d": "4"},
+	)
+	assert.Equal(t, []PropertyEvent{
+		{Name: "b", Value: "20", OldValue: "2"},
+		{Name: "d", Value: "4"},
+	}, events)
+}
+
+func TestWatchPropsWithWatchprops(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop":               "[sys.boot_completed]: [0]\n",
+			"command -v watchprops": "/system/bin/watchprops\n",
+			"watchprops":            "1500000000 sys.boot_completed = '1'\n1500000001 debug.foo = 'bar baz'\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	watcher, err := device.WatchProps(context.Background())
+	require.NoError(t, err)
+	var events []PropertyEvent
+	for event := range watcher.C() {
+		events = append(events, event)
+	}
+	assert.NoError(t, watcher.Err())
+	assert.Equal(t, []PropertyEvent{
+		{Name: "sys.boot_completed", Value: "1", OldValue: "0"},
+		{Name: "debug.foo", Value: "bar baz"},
+	}, events)
+}
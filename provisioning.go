@@ -0,0 +1,139 @@
+package adb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Matches the identifier in the "DeviceState{identifier=0, name='CLOSED', ...}" printed by newer
+// versions of cmd device_state print-state, which older versions print on its own.
+var deviceStateIdentifierPattern = regexp.MustCompile(`^(?:DeviceState\{identifier=)?(\d+)\b`)
+
+/*
+SetDeviceOwner makes admin, the component of a device admin receiver, e.g.
+com.example.dpc/.AdminReceiver, the device owner. It fails unless the device has no accounts
+and no other users, e.g. right after a factory reset.
+
+Corresponds to the command:
+
+	adb shell dpm set-device-owner <component>
+*/
+func (c *Device) SetDeviceOwner(admin string) error {
+	err := c.runDevicePolicy("set-device-owner", admin)
+	return wrapClientError(err, c, "SetDeviceOwner(%s)", admin)
+}
+
+/*
+RemoveActiveAdmin removes the device admin admin, including a device owner set by
+SetDeviceOwner. Only admins of apps with android:testOnly can be removed.
+
+Corresponds to the command:
+
+	adb shell dpm remove-active-admin <component>
+*/
+func (c *Device) RemoveActiveAdmin(admin string) error {
+	err := c.runDevicePolicy("remove-active-admin", admin)
+	return wrapClientError(err, c, "RemoveActiveAdmin(%s)", admin)
+}
+
+// runDevicePolicy runs dpm verb for admin and the device's user. dpm prints "Success: ..." if
+// it succeeded, and an exception otherwise.
+func (c *Device) runDevicePolicy(verb, admin string) error {
+	cmdArgs := append([]string{"dpm", verb}, c.userArgs()...)
+	output, err := c.runShellCommand(quoteShellArgs(append(cmdArgs, admin)...))
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(strings.TrimSpace(output), "Success") {
+		return errors.Errorf(errors.AdbError, "dpm %s failed: %s", verb, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+/*
+DeviceStateID returns the identifier of the device state, e.g. folded or unfolded on a
+foldable, from the device's device_state_config.xml. Needs Android 12L.
+
+Corresponds to the command:
+
+	adb shell cmd device_state print-state
+*/
+func (c *Device) DeviceStateID() (int, error) {
+	output, err := c.runDeviceStateCommand("print-state")
+	if err != nil {
+		return 0, wrapClientError(err, c, "DeviceStateID")
+	}
+	match := deviceStateIdentifierPattern.FindStringSubmatch(strings.TrimSpace(output))
+	if match == nil {
+		return 0, wrapClientError(errors.Errorf(errors.ParseError, "invalid device state: %q", strings.TrimSpace(output)),
+			c, "DeviceStateID")
+	}
+	id, _ := strconv.Atoi(match[1])
+	return id, nil
+}
+
+/*
+OverrideDeviceState puts the device in the state with identifier id, e.g. to test an app
+folded on a foldable, regardless of its physical state, until ClearDeviceStateOverride.
+
+Corresponds to the command:
+
+	adb shell cmd device_state state <id>
+*/
+func (c *Device) OverrideDeviceState(id int) error {
+	_, err := c.runDeviceStateCommand("state", strconv.Itoa(id))
+	return wrapClientError(err, c, "OverrideDeviceState(%d)", id)
+}
+
+/*
+ClearDeviceStateOverride returns the device to its physical state after OverrideDeviceState.
+
+Corresponds to the command:
+
+	adb shell cmd device_state state reset
+*/
+func (c *Device) ClearDeviceStateOverride() error {
+	_, err := c.runDeviceStateCommand("state", "reset")
+	return wrapClientError(err, c, "ClearDeviceStateOverride")
+}
+
+func (c *Device) runDeviceStateCommand(args ...string) (string, error) {
+	output, err := c.runShellCommand(quoteShellArgs(append([]string{"cmd", "device_state"}, args...)...))
+	if err != nil {
+		return "", err
+	}
+	if err := cmdServiceError("device_state", output); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+/*
+EnableTestHarness enables Test Harness Mode, which factory resets the device, keeping only this
+host's adb key, and skips setup, so it comes back ready for automation: adb authorized, setup
+wizard done, and screen lock disabled. The device reboots, so wait for it with
+WaitForBootComplete. Needs Android 10.
+
+Corresponds to the command:
+
+	adb shell cmd testharness enable
+*/
+func (c *Device) EnableTestHarness() error {
+	output, err := c.runShellCommand("cmd testharness enable")
+	if err == nil {
+		err = cmdServiceError("testharness", output)
+	}
+	return wrapClientError(err, c, "EnableTestHarness")
+}
+
+// cmdServiceError returns an error if output is an error from cmd, e.g. because the device is
+// too old to have service, or from the service's shell command.
+func cmdServiceError(service, output string) error {
+	if strings.HasPrefix(output, "Can't find service") {
+		return errors.Errorf(errors.AdbError, "cmd %s failed: %s", service, strings.TrimSpace(output))
+	}
+	return commandOutputError("cmd "+service, output)
+}
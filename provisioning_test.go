@@ -0,0 +1,69 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestSetDeviceOwner(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dpm set-device-owner com.example.dpc/.AdminReceiver": "Success: Device owner set to package ComponentInfo{com.example.dpc/com.example.dpc.AdminReceiver}\n" +
+				"Active admin set to component {com.example.dpc/com.example.dpc.AdminReceiver}\n",
+			"dpm set-device-owner com.example.other/.AdminReceiver": "Exception occurred while executing 'set-device-owner':\n" +
+				"java.lang.IllegalStateException: Not allowed to set the device owner because there are already some accounts on the device.\n",
+			"dpm remove-active-admin --user 0 com.example.dpc/.AdminReceiver": "Success: Admin removed ComponentInfo{com.example.dpc/com.example.dpc.AdminReceiver}\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	assert.NoError(t, device.SetDeviceOwner("com.example.dpc/.AdminReceiver"))
+	err := device.SetDeviceOwner("com.example.other/.AdminReceiver")
+	assert.True(t, HasErrCode(err, AdbError))
+	assert.Contains(t, ErrorWithCauseChain(err), "already some accounts")
+	assert.NoError(t, device.ForUser(UserSystem).RemoveActiveAdmin("com.example.dpc/.AdminReceiver"))
+}
+
+func TestDeviceState(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"cmd device_state print-state": "DeviceState{identifier=1, name='HALF_OPENED', app_properties=[], system_properties=[]}\n",
+			"cmd device_state state 0":     "",
+			"cmd device_state state reset": "",
+			"cmd device_state state 9":     "Error: Requested state: 9 is not supported.\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	id, err := device.DeviceStateID()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+	s.ShellOutputs["cmd device_state print-state"] = "2\n"
+	id, err = device.DeviceStateID()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, id)
+
+	assert.NoError(t, device.OverrideDeviceState(0))
+	assert.NoError(t, device.ClearDeviceStateOverride())
+	assert.True(t, HasErrCode(device.OverrideDeviceState(9), AdbError))
+
+	s.ShellOutputs["cmd device_state print-state"] = "Can't find service: device_state\n"
+	_, err = device.DeviceStateID()
+	assert.True(t, HasErrCode(err, AdbError))
+}
+
+func TestEnableTestHarness(t *testing.T) {
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{"cmd testharness enable": ""},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	assert.NoError(t, device.EnableTestHarness())
+
+	s.ShellOutputs["cmd testharness enable"] = "Can't find service: testharness\n"
+	assert.True(t, HasErrCode(device.EnableTestHarness(), AdbError))
+}
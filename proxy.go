@@ -0,0 +1,121 @@
+package adb
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Global settings that hold the device-wide HTTP proxy.
+const (
+	settingHttpProxy                = "http_proxy"
+	settingGlobalProxyHost          = "global_http_proxy_host"
+	settingGlobalProxyPort          = "global_http_proxy_port"
+	settingGlobalProxyExclusionList = "global_http_proxy_exclusion_list"
+
+	// Writing this to http_proxy clears the proxy. Deleting the setting doesn't
+	// notify the connectivity service on all versions.
+	clearedHttpProxy = ":0"
+)
+
+// ProxyConfig is a device-wide HTTP proxy.
+type ProxyConfig struct {
+	Host string
+	Port int
+	// Hosts that bypass the proxy, e.g. localhost or *.example.com.
+	Exclusions []string
+}
+
+/*
+SetGlobalProxy routes HTTP traffic from all apps through the proxy at host:port, except for
+requests to hosts in exclusions.
+
+Corresponds to the command:
+
+	adb shell settings put global http_proxy <host>:<port>
+*/
+func (c *Device) SetGlobalProxy(host string, port int, exclusions ...string) error {
+	if host == "" || strings.ContainsAny(host, " ,") || port <= 0 || port > 65535 {
+		return wrapClientError(errors.AssertionErrorf("invalid proxy address: %s:%d", host, port), c, "SetGlobalProxy")
+	}
+	for _, exclusion := range exclusions {
+		if exclusion == "" || strings.ContainsAny(exclusion, " ,") {
+			return wrapClientError(errors.AssertionErrorf("invalid proxy exclusion: %q", exclusion), c, "SetGlobalProxy")
+		}
+	}
+
+	settings := c.Settings()
+	if err := settings.Put(SettingsGlobal, settingGlobalProxyHost, host); err != nil {
+		return err
+	}
+	if err := settings.PutInt(SettingsGlobal, settingGlobalProxyPort, port); err != nil {
+		return err
+	}
+	if len(exclusions) > 0 {
+		err := settings.Put(SettingsGlobal, settingGlobalProxyExclusionList, strings.Join(exclusions, ","))
+		if err != nil {
+			return err
+		}
+	} else if err := settings.Delete(SettingsGlobal, settingGlobalProxyExclusionList); err != nil {
+		return err
+	}
+	return settings.Put(SettingsGlobal, settingHttpProxy, net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+// ClearGlobalProxy removes any device-wide HTTP proxy set by SetGlobalProxy.
+func (c *Device) ClearGlobalProxy() error {
+	settings := c.Settings()
+	if err := settings.Put(SettingsGlobal, settingHttpProxy, clearedHttpProxy); err != nil {
+		return err
+	}
+	for _, key := range []string{settingGlobalProxyHost, settingGlobalProxyPort, settingGlobalProxyExclusionList} {
+		if err := settings.Delete(SettingsGlobal, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GlobalProxy returns the device-wide HTTP proxy, or nil if none is set.
+func (c *Device) GlobalProxy() (*ProxyConfig, error) {
+	settings := c.Settings()
+	value, err := settings.Get(SettingsGlobal, settingHttpProxy)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := parseHttpProxy(value)
+	if err != nil || proxy == nil {
+		return nil, wrapClientError(err, c, "GlobalProxy")
+	}
+
+	exclusions, err := settings.Get(SettingsGlobal, settingGlobalProxyExclusionList)
+	if err != nil {
+		return nil, err
+	}
+	if exclusions != "" {
+		proxy.Exclusions = strings.Split(exclusions, ",")
+	}
+	return proxy, nil
+}
+
+// parseHttpProxy parses the host:port value of the http_proxy setting.
+// Returns nil if the value is empty or the cleared value.
+func parseHttpProxy(value string) (*ProxyConfig, error) {
+	if value == "" || value == clearedHttpProxy {
+		return nil, nil
+	}
+	host, portStr, err := net.SplitHostPort(value)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "invalid http_proxy setting: %q", value)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "invalid http_proxy port: %q", value)
+	}
+	if host == "" && port == 0 {
+		return nil, nil
+	}
+	return &ProxyConfig{Host: host, Port: port}, nil
+}
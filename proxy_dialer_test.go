@@ -0,0 +1,243 @@
+package adb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyFromEnv(t *testing.T) {
+	env := map[string]string{
+		"HTTPS_PROXY": "proxy.corp:3128",
+		"NO_PROXY":    "internal.lab, .corp,10.0.0.5:5555",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	for _, test := range []struct {
+		Address string
+		Want    string
+	}{
+		{"build-7.example.com:5037", "http://proxy.corp:3128"},
+		{"127.0.0.1:5037", ""},
+		{"localhost:5037", ""},
+		{"internal.lab:5037", ""},
+		{"dev.internal.lab:5037", ""},
+		{"build.corp:5037", ""},
+		{"10.0.0.5:5037", ""},
+	} {
+		proxyURL, err := proxyFromEnv(getenv, test.Address)
+		assert.NoError(t, err, test.Address)
+		if test.Want == "" {
+			assert.Nil(t, proxyURL, test.Address)
+		} else if assert.NotNil(t, proxyURL, test.Address) {
+			assert.Equal(t, test.Want, proxyURL.String(), test.Address)
+		}
+	}
+
+	env["ALL_PROXY"] = "socks5://jump:1080"
+	proxyURL, err := proxyFromEnv(getenv, "build-7.example.com:5037")
+	assert.NoError(t, err)
+	assert.Equal(t, "socks5://jump:1080", proxyURL.String())
+
+	env["NO_PROXY"] = "*"
+	proxyURL, err = proxyFromEnv(getenv, "build-7.example.com:5037")
+	assert.NoError(t, err)
+	assert.Nil(t, proxyURL)
+}
+
+func TestTcpDialerSOCKS5(t *testing.T) {
+	target := listenEcho(t)
+	defer target.Close()
+	proxy := listenProxy(t, serveSOCKS5)
+	defer proxy.Close()
+
+	proxyURL := &url.URL{Scheme: "socks5", Host: proxy.Addr().String(), User: url.UserPassword("lab", "secret")}
+	assertEchoes(t, tcpDialer{proxy: ProxyURL(proxyURL)}, target.Addr().String())
+
+	proxyURL.User = url.UserPassword("lab", "wrong")
+	_, err := tcpDialer{proxy: ProxyURL(proxyURL)}.Dial(target.Addr().String())
+	assert.True(t, HasErrCode(err, ServerNotAvailable))
+	assert.Contains(t, ErrorWithCauseChain(err), "rejected username or password")
+}
+
+func TestTcpDialerSOCKS5Resolve(t *testing.T) {
+	target := listenEcho(t)
+	defer target.Close()
+	hosts := make(chan string, 1)
+	proxy := listenProxy(t, func(conn net.Conn) { serveSOCKS5Recording(conn, hosts) })
+	defer proxy.Close()
+	_, port, err := net.SplitHostPort(target.Addr().String())
+	require.NoError(t, err)
+	address := net.JoinHostPort("localhost", port)
+
+	// socks5 resolves names locally, socks5h sends them to the proxy.
+	proxyURL := &url.URL{Scheme: "socks5", Host: proxy.Addr().String(), User: url.UserPassword("lab", "secret")}
+	assertEchoes(t, tcpDialer{proxy: ProxyURL(proxyURL)}, address)
+	assert.Equal(t, "127.0.0.1", <-hosts)
+
+	proxyURL.Scheme = "socks5h"
+	assertEchoes(t, tcpDialer{proxy: ProxyURL(proxyURL)}, address)
+	assert.Equal(t, "localhost", <-hosts)
+}
+
+func TestTcpDialerHTTPConnect(t *testing.T) {
+	target := listenEcho(t)
+	defer target.Close()
+	proxy := listenProxy(t, serveConnect)
+	defer proxy.Close()
+
+	proxyURL := &url.URL{Scheme: "http", Host: proxy.Addr().String(), User: url.UserPassword("lab", "secret")}
+	assertEchoes(t, tcpDialer{proxy: ProxyURL(proxyURL)}, target.Addr().String())
+
+	proxyURL.User = nil
+	_, err := tcpDialer{proxy: ProxyURL(proxyURL)}.Dial(target.Addr().String())
+	assert.True(t, HasErrCode(err, ServerNotAvailable))
+	assert.Contains(t, ErrorWithCauseChain(err), "407")
+}
+
+func TestForwardProxy(t *testing.T) {
+	target := listenEcho(t)
+	defer target.Close()
+	proxy := listenProxy(t, serveSOCKS5)
+	defer proxy.Close()
+
+	listener, err := ForwardProxy(&url.URL{Scheme: "socks5h", Host: proxy.Addr().String(), User: url.UserPassword("lab", "secret")},
+		target.Addr().String())
+	require.NoError(t, err)
+	defer listener.Close()
+	assertEchoes(t, tcpDialer{}, listener.Addr().String())
+}
+
+func assertEchoes(t *testing.T, dialer tcpDialer, address string) {
+	conn, err := dialer.Dial(address)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SendMessage([]byte("host:version")))
+	msg, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "host:version", string(msg))
+}
+
+// listenEcho returns a listener that echoes back whatever is written to its connections.
+func listenEcho(t *testing.T) net.Listener {
+	return listenProxy(t, func(conn net.Conn) {
+		io.Copy(conn, conn)
+	})
+}
+
+func listenProxy(t *testing.T, serve func(net.Conn)) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				serve(conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+// serveSOCKS5 is a SOCKS5 proxy that requires the username lab and password secret.
+func serveSOCKS5(conn net.Conn) {
+	serveSOCKS5Recording(conn, nil)
+}
+
+// serveSOCKS5Recording is serveSOCKS5, and sends the requested hosts to hosts if it's not nil.
+func serveSOCKS5Recording(conn net.Conn, hosts chan<- string) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	methods := make([]byte, header[1])
+	io.ReadFull(conn, methods)
+	conn.Write([]byte{socks5Version, socks5PasswordAuth})
+
+	// Username and password.
+	io.ReadFull(conn, header)
+	username := make([]byte, header[1])
+	io.ReadFull(conn, username)
+	io.ReadFull(conn, header[:1])
+	password := make([]byte, header[0])
+	io.ReadFull(conn, password)
+	if string(username) != "lab" || string(password) != "secret" {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+
+	req := make([]byte, 4)
+	io.ReadFull(conn, req)
+	var host string
+	switch req[3] {
+	case socks5AddrIPv4:
+		ip := make([]byte, net.IPv4len)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case socks5AddrDomain:
+		io.ReadFull(conn, header[:1])
+		name := make([]byte, header[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	}
+	if hosts != nil {
+		hosts <- host
+	}
+	io.ReadFull(conn, header)
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(header)))))
+	if err != nil {
+		conn.Write([]byte{socks5Version, 5, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{socks5Version, 0, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+	pipe(conn, target)
+}
+
+// serveConnect is an HTTP proxy that requires the username lab and password secret.
+func serveConnect(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	req, err := http.ReadRequest(reader)
+	if err != nil || req.Method != http.MethodConnect {
+		return
+	}
+	req.Header.Set("Authorization", req.Header.Get("Proxy-Authorization"))
+	if username, password, ok := req.BasicAuth(); !ok || username != "lab" || password != "secret" {
+		io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+		return
+	}
+	target, err := net.Dial("tcp", req.Host)
+	if err != nil {
+		io.WriteString(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+		return
+	}
+	defer target.Close()
+	io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+	pipe(conn, target)
+}
+
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+	<-done
+}
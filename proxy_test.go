@@ -0,0 +1,36 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseHttpProxy(t *testing.T) {
+	for _, test := range []struct {
+		Value   string
+		Want    *ProxyConfig
+		WantErr bool
+	}{
+		{"", nil, false},
+		{":0", nil, false},
+		{"192.168.1.10:8080", &ProxyConfig{Host: "192.168.1.10", Port: 8080}, false},
+		{"proxy.lab:3128", &ProxyConfig{Host: "proxy.lab", Port: 3128}, false},
+		{"proxy.lab", nil, true},
+	} {
+		proxy, err := parseHttpProxy(test.Value)
+		assert.Equal(t, test.Want, proxy, test.Value)
+		assert.Equal(t, test.WantErr, err != nil, test.Value)
+	}
+}
+
+func TestSetGlobalProxyValidates(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	assert.True(t, HasErrCode(device.SetGlobalProxy("", 8080), AssertionError))
+	assert.True(t, HasErrCode(device.SetGlobalProxy("host", 0), AssertionError))
+	assert.True(t, HasErrCode(device.SetGlobalProxy("host", 8080, "a,b"), AssertionError))
+	assert.Empty(t, s.Requests)
+}
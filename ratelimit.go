@@ -0,0 +1,120 @@
+package adb
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+/*
+RateLimiter caps the bandwidth of file transfers, so background syncs don't starve
+latency-sensitive operations, like input injection and screenshots, on the same device.
+
+A RateLimiter is safe for concurrent use, and the transfers of every Device it's passed to
+share its rate. E.g. to sync artifacts to all devices at up to 10 MB/s in total:
+
+	limiter, err := adb.NewRateLimiter(10 * 1000 * 1000)
+	device.WithRateLimit(limiter).PushDir("artifacts", "/sdcard/artifacts", adb.TransferOptions{})
+*/
+type RateLimiter struct {
+	bytesPerSecond float64
+
+	lock sync.Mutex
+	// Bytes that may be transferred without waiting. It's negative when transfers have reserved
+	// more than the rate allows, and they're waiting for it to come back up.
+	tokens float64
+	last   time.Time
+
+	// Overridden by tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// Bytes that may be transferred at once after the limiter has been idle, one sync chunk, so a
+// whole chunk can be sent without waiting.
+const rateLimitBurst = wire.SyncMaxChunkSize
+
+// NewRateLimiter returns a RateLimiter allowing bytesPerSecond bytes per second, which must be
+// positive.
+func NewRateLimiter(bytesPerSecond int64) (*RateLimiter, error) {
+	if bytesPerSecond <= 0 {
+		return nil, errors.AssertionErrorf("rate limit must be positive: %d bytes per second", bytesPerSecond)
+	}
+	return &RateLimiter{
+		bytesPerSecond: float64(bytesPerSecond),
+		tokens:         rateLimitBurst,
+		now:            time.Now,
+		sleep:          time.Sleep,
+	}, nil
+}
+
+// wait blocks until n more bytes may be transferred.
+func (l *RateLimiter) wait(n int) {
+	l.lock.Lock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.bytesPerSecond
+		if l.tokens > rateLimitBurst {
+			l.tokens = rateLimitBurst
+		}
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	delay := time.Duration(-l.tokens / l.bytesPerSecond * float64(time.Second))
+	l.lock.Unlock()
+
+	if delay > 0 {
+		l.sleep(delay)
+	}
+}
+
+/*
+WithRateLimit returns a Device whose file transfers, OpenRead and OpenWrite and everything
+built on them like PushDir and PullDir, are limited by limiter. Other operations, and other
+Devices, aren't affected.
+*/
+func (c *Device) WithRateLimit(limiter *RateLimiter) *Device {
+	limited := *c
+	limited.rateLimit = limiter
+	return &limited
+}
+
+// rateLimitedReader waits for its limiter after each read.
+type rateLimitedReader struct {
+	io.ReadCloser
+	limiter *RateLimiter
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.limiter.wait(n)
+	return n, err
+}
+
+// rateLimitedWriter waits for its limiter before each write.
+type rateLimitedWriter struct {
+	io.WriteCloser
+	limiter *RateLimiter
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (int, error) {
+	w.limiter.wait(len(p))
+	return w.WriteCloser.Write(p)
+}
+
+func (w *rateLimitedWriter) abort() error {
+	return abortWrite(w.WriteCloser)
+}
+
+// ReadFrom limits reads from r instead, so the wrapped writer's ReadFrom can still be used.
+func (w *rateLimitedWriter) ReadFrom(r io.Reader) (int64, error) {
+	limited := &rateLimitedReader{ReadCloser: ioutil.NopCloser(r), limiter: w.limiter}
+	if readerFrom, ok := w.WriteCloser.(io.ReaderFrom); ok {
+		return readerFrom.ReadFrom(limited)
+	}
+	return io.Copy(w.WriteCloser, limited)
+}
@@ -0,0 +1,85 @@
+package adb
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// newFakeRateLimiter returns a limiter whose clock only advances while it sleeps, and the
+// sleeps it's made.
+func newFakeRateLimiter(t *testing.T, bytesPerSecond int64) (*RateLimiter, *[]time.Duration) {
+	limiter, err := NewRateLimiter(bytesPerSecond)
+	require.NoError(t, err)
+	now := time.Unix(1000, 0)
+	var sleeps []time.Duration
+	limiter.now = func() time.Time { return now }
+	limiter.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	return limiter, &sleeps
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter, sleeps := newFakeRateLimiter(t, 1000)
+
+	// The burst is free, then each byte takes a millisecond.
+	limiter.wait(rateLimitBurst)
+	assert.Empty(t, *sleeps)
+	limiter.wait(1000)
+	limiter.wait(500)
+	assert.Equal(t, []time.Duration{time.Second, 500 * time.Millisecond}, *sleeps)
+}
+
+func TestRateLimiterRefillsWhileIdle(t *testing.T) {
+	limiter, sleeps := newFakeRateLimiter(t, 1000)
+	limiter.wait(rateLimitBurst)
+
+	limiter.sleep(time.Hour)
+	// The idle time only refills one burst.
+	limiter.wait(rateLimitBurst + 1000)
+	assert.Equal(t, []time.Duration{time.Hour, time.Second}, *sleeps)
+}
+
+func TestNewRateLimiterRejectsNonPositiveRate(t *testing.T) {
+	for _, rate := range []int64{0, -1} {
+		_, err := NewRateLimiter(rate)
+		assert.True(t, errors.HasErrCode(err, errors.AssertionError), "%d: %v", rate, err)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/a": {Data: make([]byte, rateLimitBurst+2000)}},
+	}
+	limiter, sleeps := newFakeRateLimiter(t, 1000)
+	device := (&Adb{s}).Device(AnyDevice())
+
+	reader, err := device.WithRateLimit(limiter).OpenRead("/sdcard/a")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	assert.Len(t, data, rateLimitBurst+2000)
+	var slept time.Duration
+	for _, d := range *sleeps {
+		slept += d
+	}
+	assert.Equal(t, 2*time.Second, slept)
+
+	// The original device isn't limited.
+	limitedSleeps := len(*sleeps)
+	reader, err = device.OpenRead("/sdcard/a")
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	assert.Len(t, *sleeps, limitedSleeps)
+}
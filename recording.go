@@ -0,0 +1,341 @@
+package adb
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+/*
+Transcript is the raw traffic of a sequence of connections to an adb server, captured by
+a RecordingDialer and served back by a ReplayDialer.
+
+Transcripts are saved as text, one chunk per line, so they can be reviewed and edited by hand.
+Each connection starts with a "conn" line, followed by the quoted bytes sent to the server
+(prefixed with ">") and received from it (prefixed with "<"):
+
+	conn
+	> "000chost:version"
+	< "OKAY0004001f"
+*/
+type Transcript struct {
+	Conns []*TranscriptConn
+}
+
+// TranscriptConn is the traffic of a single connection, in the order it happened.
+type TranscriptConn struct {
+	Chunks []TranscriptChunk
+}
+
+// TranscriptChunk is a single read from or write to the server.
+type TranscriptChunk struct {
+	// True if the data was sent to the server, false if it was received from it.
+	Sent bool
+	Data []byte
+}
+
+// Prefixes of chunk lines in transcript files.
+const (
+	transcriptConnLine   = "conn"
+	transcriptSentPrefix = "> "
+	transcriptRecvPrefix = "< "
+)
+
+// ReadTranscriptFile reads a transcript saved by Transcript.WriteFile.
+func ReadTranscriptFile(path string) (*Transcript, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.FileNoExistError, "error opening transcript %s", path)
+	}
+	defer file.Close()
+	return ReadTranscript(file)
+}
+
+// ReadTranscript parses a transcript in the format written by Transcript.WriteTo.
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	transcript := &Transcript{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 4*wire.SyncMaxChunkSize)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			continue
+		case line == transcriptConnLine:
+			transcript.Conns = append(transcript.Conns, &TranscriptConn{})
+			continue
+		}
+
+		sent := strings.HasPrefix(line, transcriptSentPrefix)
+		if !sent && !strings.HasPrefix(line, transcriptRecvPrefix) {
+			return nil, errors.Errorf(errors.ParseError, "invalid transcript line %d: %s", lineNum, line)
+		}
+		if len(transcript.Conns) == 0 {
+			return nil, errors.Errorf(errors.ParseError, "transcript line %d is not part of a connection", lineNum)
+		}
+		data, err := strconv.Unquote(line[len(transcriptSentPrefix):])
+		if err != nil {
+			return nil, errors.WrapErrorf(err, errors.ParseError, "invalid transcript line %d: %s", lineNum, line)
+		}
+		conn := transcript.Conns[len(transcript.Conns)-1]
+		conn.Chunks = append(conn.Chunks, TranscriptChunk{Sent: sent, Data: []byte(data)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "error reading transcript")
+	}
+	return transcript, nil
+}
+
+// WriteFile saves the transcript to path, creating or truncating it.
+func (t *Transcript) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if _, err := t.WriteTo(&buf); err != nil {
+		return err
+	}
+	return errors.WrapErrorf(ioutil.WriteFile(path, buf.Bytes(), 0644), errors.AssertionError,
+		"error writing transcript %s", path)
+}
+
+// WriteTo writes the transcript to w in text form.
+func (t *Transcript) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	for _, conn := range t.Conns {
+		buf.WriteString(transcriptConnLine + "\n")
+		for _, chunk := range conn.Chunks {
+			if chunk.Sent {
+				buf.WriteString(transcriptSentPrefix)
+			} else {
+				buf.WriteString(transcriptRecvPrefix)
+			}
+			buf.WriteString(strconv.Quote(string(chunk.Data)))
+			buf.WriteString("\n")
+		}
+	}
+	n, err := w.Write(buf.Bytes())
+	return int64(n), errors.WrapErrorf(err, errors.NetworkError, "error writing transcript")
+}
+
+// sentData returns all the data sent on the connection.
+func (c *TranscriptConn) sentData() []byte {
+	var data []byte
+	for _, chunk := range c.Chunks {
+		if chunk.Sent {
+			data = append(data, chunk.Data...)
+		}
+	}
+	return data
+}
+
+/*
+RecordingDialer is a Dialer that connects to a real adb server over TCP and records all
+traffic, so the session can be replayed later with a ReplayDialer.
+
+E.g.
+
+	recorder := adb.NewRecordingDialer()
+	client, _ := adb.NewWithConfig(adb.ServerConfig{Dialer: recorder})
+	...
+	recorder.Transcript().WriteFile("testdata/session.txt")
+*/
+type RecordingDialer struct {
+	lock  sync.Mutex
+	conns []*TranscriptConn
+}
+
+var _ Dialer = &RecordingDialer{}
+
+func NewRecordingDialer() *RecordingDialer {
+	return &RecordingDialer{}
+}
+
+func (d *RecordingDialer) Dial(address string) (*wire.Conn, error) {
+	netConn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error dialing %s", address)
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	transcript := &TranscriptConn{}
+	d.conns = append(d.conns, transcript)
+	return newStreamConn(&recordingConn{ReadWriteCloser: netConn, dialer: d, transcript: transcript}), nil
+}
+
+// Transcript returns a copy of the traffic recorded so far. Connections are in the order they
+// were dialed.
+func (d *RecordingDialer) Transcript() *Transcript {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	transcript := &Transcript{}
+	for _, conn := range d.conns {
+		transcript.Conns = append(transcript.Conns, &TranscriptConn{
+			Chunks: append([]TranscriptChunk{}, conn.Chunks...),
+		})
+	}
+	return transcript
+}
+
+// recordingConn appends every read and write to a TranscriptConn.
+type recordingConn struct {
+	io.ReadWriteCloser
+	dialer     *RecordingDialer
+	transcript *TranscriptConn
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	c.record(false, p[:n])
+	return n, err
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Write(p)
+	c.record(true, p[:n])
+	return n, err
+}
+
+func (c *recordingConn) record(sent bool, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	c.dialer.lock.Lock()
+	defer c.dialer.lock.Unlock()
+	c.transcript.Chunks = append(c.transcript.Chunks, TranscriptChunk{
+		Sent: sent,
+		Data: append([]byte{}, data...),
+	})
+}
+
+/*
+ReplayDialer is a Dialer that serves connections from a Transcript instead of a real server,
+so tests can re-run a recorded session deterministically. Since no server is started, use
+it with ServerConfig.NoServer.
+
+Each new connection is matched to the first unused recorded connection whose traffic starts
+with the first data written to it, so concurrent connections, e.g. from a DeviceWatcher, don't
+need to be dialed in the recorded order. Writes that differ from the recording fail with an
+AssertionError.
+*/
+type ReplayDialer struct {
+	lock   sync.Mutex
+	conns  []*TranscriptConn
+	unused []bool
+}
+
+var _ Dialer = &ReplayDialer{}
+
+func NewReplayDialer(transcript *Transcript) *ReplayDialer {
+	unused := make([]bool, len(transcript.Conns))
+	for i := range unused {
+		unused[i] = true
+	}
+	return &ReplayDialer{conns: transcript.Conns, unused: unused}
+}
+
+func (d *ReplayDialer) Dial(address string) (*wire.Conn, error) {
+	return newStreamConn(&replayConn{dialer: d}), nil
+}
+
+// Unused returns the number of recorded connections that haven't been replayed.
+func (d *ReplayDialer) Unused() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	count := 0
+	for _, unused := range d.unused {
+		if unused {
+			count++
+		}
+	}
+	return count
+}
+
+// claim returns the first unused connection whose sent data starts with data.
+func (d *ReplayDialer) claim(data []byte) *TranscriptConn {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for i, conn := range d.conns {
+		if d.unused[i] && bytes.HasPrefix(conn.sentData(), data) {
+			d.unused[i] = false
+			return conn
+		}
+	}
+	return nil
+}
+
+// replayConn checks writes against, and serves reads from, a recorded connection.
+type replayConn struct {
+	dialer *ReplayDialer
+	conn   *TranscriptConn
+	// Index of the current chunk, and the offset of the next byte in it.
+	chunk  int
+	offset int
+}
+
+func (c *replayConn) Write(p []byte) (int, error) {
+	if c.conn == nil {
+		if c.conn = c.dialer.claim(p); c.conn == nil {
+			return 0, errors.Errorf(errors.AssertionError, "replay: no recorded connection sends %q", p)
+		}
+	}
+
+	for written := 0; written < len(p); {
+		if c.chunk >= len(c.conn.Chunks) || !c.conn.Chunks[c.chunk].Sent {
+			return written, errors.Errorf(errors.AssertionError,
+				"replay: unexpected write %q, recorded connection expects a read", p[written:])
+		}
+		expected := c.conn.Chunks[c.chunk].Data[c.offset:]
+		n := len(p) - written
+		if n > len(expected) {
+			n = len(expected)
+		}
+		if !bytes.Equal(p[written:written+n], expected[:n]) {
+			return written, errors.Errorf(errors.AssertionError, "replay: wrote %q, recorded %q",
+				p[written:written+n], expected[:n])
+		}
+		written += n
+		c.advance(n)
+	}
+	return len(p), nil
+}
+
+func (c *replayConn) Read(p []byte) (int, error) {
+	if c.conn == nil {
+		return 0, errors.Errorf(errors.AssertionError, "replay: read before any write")
+	}
+
+	if c.chunk >= len(c.conn.Chunks) {
+		return 0, io.EOF
+	}
+	chunk := c.conn.Chunks[c.chunk]
+	if chunk.Sent {
+		return 0, errors.Errorf(errors.AssertionError,
+			"replay: unexpected read, recorded connection expects a write of %q", chunk.Data[c.offset:])
+	}
+	n := copy(p, chunk.Data[c.offset:])
+	c.advance(n)
+	return n, nil
+}
+
+func (c *replayConn) advance(n int) {
+	c.offset += n
+	if c.offset == len(c.conn.Chunks[c.chunk].Data) {
+		c.chunk++
+		c.offset = 0
+	}
+}
+
+func (c *replayConn) Close() error {
+	return nil
+}
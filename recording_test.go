@@ -0,0 +1,114 @@
+package adb
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const versionTranscript = `conn
+> "000chost:version"
+< "OKAY"
+< "0004001f"
+`
+
+func TestRecordingDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.ReadFull(conn, make([]byte, len("000chost:version")))
+		conn.Write([]byte("OKAY"))
+		conn.Write([]byte("0004001f"))
+	}()
+
+	recorder := NewRecordingDialer()
+	addr := listener.Addr().(*net.TCPAddr)
+	client, err := NewWithConfig(ServerConfig{Host: "127.0.0.1", Port: addr.Port, Dialer: recorder, NoServer: true})
+	require.NoError(t, err)
+
+	version, err := client.ServerVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 31, version)
+
+	transcript := recorder.Transcript()
+	require.Len(t, transcript.Conns, 1)
+	assert.Equal(t, []byte("000chost:version"), transcript.Conns[0].sentData())
+	var received []byte
+	for _, chunk := range transcript.Conns[0].Chunks {
+		if !chunk.Sent {
+			received = append(received, chunk.Data...)
+		}
+	}
+	assert.Equal(t, "OKAY0004001f", string(received))
+}
+
+func TestTranscriptRoundTrip(t *testing.T) {
+	transcript := &Transcript{Conns: []*TranscriptConn{{Chunks: []TranscriptChunk{
+		{Sent: true, Data: []byte("0005sync:")},
+		{Sent: false, Data: []byte("OKAY\x00\x01\xff\n\"")},
+	}}}}
+
+	var buf bytes.Buffer
+	_, err := transcript.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "conn\n> \"0005sync:\"\n< \"OKAY\\x00\\x01\\xff\\n\\\"\"\n", buf.String())
+
+	parsed, err := ReadTranscript(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, transcript, parsed)
+}
+
+func TestReadTranscriptInvalid(t *testing.T) {
+	_, err := ReadTranscript(strings.NewReader("> \"0004host\"\n"))
+	assert.True(t, HasErrCode(err, ParseError))
+
+	_, err = ReadTranscript(strings.NewReader("conn\n? \"data\"\n"))
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestReplayDialer(t *testing.T) {
+	transcript, err := ReadTranscript(strings.NewReader(versionTranscript + `conn
+> "0012host:transport-any"
+< "OKAY"
+> "0008shell:ls"
+< "OKAY"
+< "sdcard\n"
+`))
+	require.NoError(t, err)
+	replayer := NewReplayDialer(transcript)
+	client, err := NewWithConfig(ServerConfig{Dialer: replayer, NoServer: true})
+	require.NoError(t, err)
+
+	// Connections are matched by content, not dial order.
+	output, err := client.Device(AnyDevice()).RunCommand("ls")
+	assert.NoError(t, err)
+	assert.Equal(t, "sdcard\n", output)
+	assert.Equal(t, 1, replayer.Unused())
+
+	version, err := client.ServerVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 31, version)
+	assert.Equal(t, 0, replayer.Unused())
+}
+
+func TestReplayDialerMismatch(t *testing.T) {
+	transcript, err := ReadTranscript(strings.NewReader(versionTranscript))
+	require.NoError(t, err)
+	client, err := NewWithConfig(ServerConfig{Dialer: NewReplayDialer(transcript), NoServer: true})
+	require.NoError(t, err)
+
+	_, err = client.ListDeviceSerials()
+	assert.Error(t, err)
+	assert.Contains(t, ErrorWithCauseChain(err), `replay: no recorded connection sends "000chost:devices"`)
+}
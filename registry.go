@@ -0,0 +1,215 @@
+package adb
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DeviceLabel is what a DeviceRegistry knows about a device.
+type DeviceLabel struct {
+	// Human-friendly name, e.g. "Pixel-7-slot-3".
+	Name string `json:"name"`
+	// Anything else worth knowing about the device, e.g. its rack slot or owner.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// RegistryStore is where a DeviceRegistry persists its labels, e.g. a file or a database.
+type RegistryStore interface {
+	// Load returns the saved labels, keyed by serial, or none if nothing was saved yet.
+	Load() (map[string]DeviceLabel, error)
+	// Save replaces the saved labels.
+	Save(labels map[string]DeviceLabel) error
+}
+
+/*
+DeviceRegistry maps device serials to labels, so reports can name devices instead of listing
+serials. Set ServerConfig.Registry to add the labels to DeviceInfo, DeviceResult, and
+PooledDevice.
+
+E.g.
+
+	registry, err := adb.NewDeviceRegistry(adb.NewFileRegistryStore("devices.json"))
+	registry.Set("0123abcd", adb.DeviceLabel{Name: "Pixel-7-slot-3", Metadata: map[string]string{"owner": "camera"}})
+	client, err := adb.NewWithConfig(adb.ServerConfig{Registry: registry})
+
+It's safe to use from multiple goroutines, and to share between clients.
+*/
+type DeviceRegistry struct {
+	store RegistryStore
+
+	mu     sync.Mutex
+	labels map[string]DeviceLabel
+}
+
+// NewDeviceRegistry returns a registry persisted in store, with the labels saved in it. If
+// store is nil, the labels are only kept in memory.
+func NewDeviceRegistry(store RegistryStore) (*DeviceRegistry, error) {
+	labels := map[string]DeviceLabel{}
+	if store != nil {
+		saved, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		for serial, label := range saved {
+			labels[serial] = label
+		}
+	}
+	return &DeviceRegistry{store: store, labels: labels}, nil
+}
+
+// Get returns the label of the device with serial, and false if it doesn't have one.
+func (r *DeviceRegistry) Get(serial string) (DeviceLabel, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	label, ok := r.labels[serial]
+	return label.copy(), ok
+}
+
+// Set labels the device with serial, replacing its previous label, and saves the registry.
+func (r *DeviceRegistry) Set(serial string, label DeviceLabel) error {
+	if serial == "" {
+		return errors.AssertionErrorf("device serial cannot be blank")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.labels[serial] = label.copy()
+	return r.saveLocked()
+}
+
+// Remove removes the label of the device with serial, if any, and saves the registry.
+func (r *DeviceRegistry) Remove(serial string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.labels[serial]; !ok {
+		return nil
+	}
+	delete(r.labels, serial)
+	return r.saveLocked()
+}
+
+// Serials returns the serials of the labeled devices, sorted.
+func (r *DeviceRegistry) Serials() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	serials := make([]string, 0, len(r.labels))
+	for serial := range r.labels {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+	return serials
+}
+
+func (r *DeviceRegistry) saveLocked() error {
+	if r.store == nil {
+		return nil
+	}
+	// The store may keep the map, which is modified by later calls.
+	labels := make(map[string]DeviceLabel, len(r.labels))
+	for serial, label := range r.labels {
+		labels[serial] = label.copy()
+	}
+	return r.store.Save(labels)
+}
+
+// copy returns a copy of l that doesn't share its Metadata, so callers can't modify the
+// registry's labels.
+func (l DeviceLabel) copy() DeviceLabel {
+	if l.Metadata == nil {
+		return l
+	}
+	metadata := make(map[string]string, len(l.Metadata))
+	for key, value := range l.Metadata {
+		metadata[key] = value
+	}
+	l.Metadata = metadata
+	return l
+}
+
+// label sets the label of device, if the registry has one. It's a no-op on a nil registry.
+func (r *DeviceRegistry) label(device *DeviceInfo) {
+	if r == nil {
+		return
+	}
+	if label, ok := r.Get(device.Serial); ok {
+		device.Label, device.Metadata = label.Name, label.Metadata
+	}
+}
+
+// name returns the label of the device with serial, or "" if it doesn't have one or r is nil.
+func (r *DeviceRegistry) name(serial string) string {
+	if r == nil {
+		return ""
+	}
+	label, _ := r.Get(serial)
+	return label.Name
+}
+
+// NewFileRegistryStore returns a RegistryStore that saves the labels as JSON in the file at path.
+// The file doesn't need to exist until the first save.
+func NewFileRegistryStore(path string) RegistryStore {
+	return fileRegistryStore(path)
+}
+
+type fileRegistryStore string
+
+func (path fileRegistryStore) Load() (map[string]DeviceLabel, error) {
+	data, err := ioutil.ReadFile(string(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WrapErrorf(err, fileErrCode(err), "error reading device registry %s", path)
+	}
+	var labels map[string]DeviceLabel
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "invalid device registry %s", path)
+	}
+	return labels, nil
+}
+
+func (path fileRegistryStore) Save(labels map[string]DeviceLabel) error {
+	data, err := json.MarshalIndent(labels, "", "  ")
+	if err != nil {
+		return errors.WrapErrorf(err, errors.AssertionError, "error encoding device registry")
+	}
+	// Written to a temporary file first, so a crash doesn't leave a truncated registry.
+	tmp, err := ioutil.TempFile(filepath.Dir(string(path)), filepath.Base(string(path))+".tmp")
+	if err != nil {
+		return errors.WrapErrorf(err, fileErrCode(err), "error writing device registry %s", path)
+	}
+	if _, err = tmp.Write(append(data, '\n')); err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), string(path))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return errors.WrapErrorf(err, fileErrCode(err), "error writing device registry %s", path)
+	}
+	return nil
+}
+
+// fileErrCode returns the code of an error reading or writing a file on the host:
+// FileNoExistError if the file or its directory doesn't exist, PermissionDenied if access to it
+// was denied, and NetworkError for other I/O errors.
+func fileErrCode(err error) errors.ErrCode {
+	switch {
+	case stderrors.Is(err, os.ErrNotExist):
+		return errors.FileNoExistError
+	case stderrors.Is(err, os.ErrPermission):
+		return errors.PermissionDenied
+	default:
+		return errors.NetworkError
+	}
+}
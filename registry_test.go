@@ -0,0 +1,103 @@
+package adb
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestFileRegistryStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "devices.json")
+
+	registry, err := NewDeviceRegistry(NewFileRegistryStore(path))
+	require.NoError(t, err)
+	assert.Empty(t, registry.Serials())
+
+	label := DeviceLabel{Name: "Pixel-7-slot-3", Metadata: map[string]string{"owner": "camera"}}
+	require.NoError(t, registry.Set("0123abcd", label))
+	require.NoError(t, registry.Set("emulator-5554", DeviceLabel{Name: "emulator"}))
+	require.NoError(t, registry.Remove("emulator-5554"))
+
+	registry, err = NewDeviceRegistry(NewFileRegistryStore(path))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0123abcd"}, registry.Serials())
+	saved, ok := registry.Get("0123abcd")
+	assert.True(t, ok)
+	assert.Equal(t, label, saved)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0644))
+	_, err = NewDeviceRegistry(NewFileRegistryStore(path))
+	assert.True(t, HasErrCode(err, ParseError))
+
+	err = NewFileRegistryStore(filepath.Join(dir, "missing", "devices.json")).Save(nil)
+	assert.True(t, HasErrCode(err, FileNoExistError))
+}
+
+func TestListDevicesLabelsDevices(t *testing.T) {
+	registry, err := NewDeviceRegistry(nil)
+	require.NoError(t, err)
+	require.NoError(t, registry.Set("b", DeviceLabel{Name: "Pixel-7-slot-3", Metadata: map[string]string{"slot": "3"}}))
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"a device\nb device\n"},
+		Registry: registry,
+	}
+
+	devices, err := (&Adb{s}).ListDevices()
+	assert.NoError(t, err)
+	assert.Equal(t, []*DeviceInfo{
+		{Serial: "a", State: StateOnline},
+		{Serial: "b", State: StateOnline, Label: "Pixel-7-slot-3", Metadata: map[string]string{"slot": "3"}},
+	}, devices)
+	assert.Equal(t, "a", devices[0].Name())
+	assert.Equal(t, "Pixel-7-slot-3", devices[1].Name())
+
+	// Modifying the returned metadata doesn't modify the registry.
+	devices[1].Metadata["slot"] = "4"
+	label, _ := registry.Get("b")
+	assert.Equal(t, map[string]string{"slot": "3"}, label.Metadata)
+}
+
+func TestForEachDeviceLabelsResults(t *testing.T) {
+	registry, err := NewDeviceRegistry(nil)
+	require.NoError(t, err)
+	require.NoError(t, registry.Set("b", DeviceLabel{Name: "Pixel-7-slot-3"}))
+	client := newFanOutClient()
+	client.server.(*MockServer).Registry = registry
+
+	results, err := client.ForEachDevice(context.Background(), nil, func(ctx context.Context, device *Device) error {
+		if device.descriptor.serial == "b" {
+			return assert.AnError
+		}
+		return nil
+	})
+	assert.Equal(t, "Pixel-7-slot-3", results[1].Label)
+	assert.Equal(t, "", results[0].Label)
+	assert.Contains(t, ErrorWithCauseChain(err), "Pixel-7-slot-3 (b)")
+}
+
+func TestDevicePoolLabelsDevices(t *testing.T) {
+	registry, err := NewDeviceRegistry(nil)
+	require.NoError(t, err)
+	require.NoError(t, registry.Set("serial", DeviceLabel{Name: "Pixel-6-slot-1"}))
+	pool := newTestPool(nil)
+	pool.client.server.(*MockServer).Registry = registry
+
+	pool.handleEvent(DeviceStateChangedEvent{"serial", StateDisconnected, StateOnline})
+	pool.checks.Wait()
+	assert.Equal(t, "Pixel-6-slot-1", pool.Devices()[0].Label)
+
+	require.NoError(t, registry.Set("serial", DeviceLabel{Name: "Pixel-6-slot-2"}))
+	pool.checkHealth()
+	pool.checks.Wait()
+	assert.Equal(t, "Pixel-6-slot-2", pool.Devices()[0].Label)
+}
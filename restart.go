@@ -0,0 +1,137 @@
+package adb
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// restartPollInterval is the time between device list checks of RestartServer.
+const restartPollInterval = 500 * time.Millisecond
+
+// ServerRestart is the result of RestartServer.
+type ServerRestart struct {
+	// Devices listed before the restart that were listed again in the same state, as listed
+	// after it. Their transport ids are new.
+	Reattached []*DeviceInfo
+	// Devices listed before the restart that didn't come back in time, as listed before it.
+	Missing []*DeviceInfo
+}
+
+/*
+RestartServer kills the server, starts it again, and waits for the devices it listed before to
+be listed again in the same state, e.g. for online devices to come back online. The devices are
+waited for together, up to Timeouts.Reattach in total from when the server is started, or until
+ctx is done if that's 0. Devices take a few seconds to reattach, USB devices usually longer than
+emulators.
+
+Devices that haven't come back by then are reported in Missing, they're not an error. If ctx is
+done first, the result so far is returned along with an error that wraps ctx's.
+
+Corresponds to the commands:
+
+	adb kill-server
+	adb start-server
+*/
+func (c *Adb) RestartServer(ctx context.Context) (*ServerRestart, error) {
+	restart, err := c.restartServer(ctx)
+	return restart, wrapClientError(err, c, "RestartServer")
+}
+
+func (c *Adb) restartServer(ctx context.Context) (*ServerRestart, error) {
+	before, err := c.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.killServerAndWait(ctx); err != nil {
+		return nil, err
+	}
+	// The server may be a new version, if adb was upgraded.
+	setCachedServerVersion(c.server, 0)
+	if err := c.server.Start(); err != nil {
+		return nil, err
+	}
+
+	var deadline <-chan time.Time
+	if timeout := c.server.timeouts().Reattach; timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(restartPollInterval)
+	defer ticker.Stop()
+
+	reattached := map[string]*DeviceInfo{}
+	for {
+		// The server may not be listening yet, or may be slow to answer while it's starting.
+		if after, err := c.ListDevices(); err == nil {
+			reattached = map[string]*DeviceInfo{}
+			for _, device := range after {
+				reattached[device.Serial] = device
+			}
+		}
+		restart := newServerRestart(before, reattached)
+		if len(restart.Missing) == 0 {
+			return restart, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return restart, errors.WrapErrf(ctx.Err(), "devices didn't reattach: %s",
+				strings.Join(deviceSerials(restart.Missing), ", "))
+		case <-deadline:
+			return restart, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// killServerAndWait tells the server to quit, and waits for it to close the connection as it
+// exits, so it's not still listening when the new one starts.
+func (c *Adb) killServerAndWait(ctx context.Context) error {
+	conn, err := c.server.Dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if err := wire.SendMessageString(conn, "host:kill"); err != nil {
+		return err
+	}
+	if _, err := conn.ReadStatus("host:kill"); err != nil {
+		return err
+	}
+	// A reset also means the server is gone.
+	conn.ReadUntilEof()
+	return nil
+}
+
+// newServerRestart sorts before into the devices that are in after in the same state, and the
+// rest.
+func newServerRestart(before []*DeviceInfo, after map[string]*DeviceInfo) *ServerRestart {
+	restart := &ServerRestart{}
+	for _, device := range before {
+		if back, ok := after[device.Serial]; ok && back.State == device.State {
+			restart.Reattached = append(restart.Reattached, back)
+		} else {
+			restart.Missing = append(restart.Missing, device)
+		}
+	}
+	return restart
+}
+
+func deviceSerials(devices []*DeviceInfo) []string {
+	serials := make([]string, len(devices))
+	for i, device := range devices {
+		serials[i] = device.Serial
+	}
+	return serials
+}
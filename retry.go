@@ -0,0 +1,106 @@
+package adb
+
+import (
+	stderrors "errors"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// Defaults for unset RetryPolicy fields.
+const (
+	defaultRetryInitialBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff     = 2 * time.Second
+	defaultRetryMultiplier     = 2
+)
+
+/*
+RetryPolicy retries idempotent operations that fail with transient errors, waiting with
+exponential backoff between attempts. It's applied to host queries like ServerVersion and
+ListDevices, device attributes like State and Serial, and Stat, including dialing the server for
+them.
+
+The zero value never retries.
+*/
+type RetryPolicy struct {
+	// Maximum number of attempts, including the first. 0 and 1 disable retries.
+	MaxAttempts int
+	// Wait before the first retry. Defaults to 100ms.
+	InitialBackoff time.Duration
+	// Maximum wait between attempts. Defaults to 2s.
+	MaxBackoff time.Duration
+	// Factor the wait is multiplied by after each retry. Defaults to 2.
+	Multiplier float64
+	// Returns true if an operation that failed with err should be retried. Defaults to IsTransient.
+	Retryable func(err error) bool
+}
+
+/*
+IsTransient returns true if err is likely to go away on its own, so the operation that
+returned it can be retried: the device is offline, e.g. while it's booting or reconnecting,
+the connection was reset or refused, or the server or device asked to try again (EAGAIN).
+*/
+func IsTransient(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case stderrors.Is(err, ErrDeviceOffline), stderrors.Is(err, ErrConnectionReset),
+		stderrors.Is(err, ErrServerNotAvailable), stderrors.Is(err, syscall.EAGAIN):
+		return true
+	}
+	return wire.IsAdbServerErrorMatching(err, func(msg string) bool {
+		msg = strings.ToLower(msg)
+		return strings.Contains(msg, "try again") || strings.Contains(msg, "temporarily unavailable")
+	})
+}
+
+// do calls op until it succeeds, returns an error that isn't retryable, or MaxAttempts is reached.
+// Returns the last error.
+func (p RetryPolicy) do(op func() error) error {
+	err := op()
+	backoff := p.InitialBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryInitialBackoff
+	}
+	for attempt := 1; attempt < p.MaxAttempts && err != nil && p.retryable(err); attempt++ {
+		time.Sleep(backoff)
+		backoff = p.nextBackoff(backoff)
+		err = op()
+	}
+	return err
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsTransient(err)
+}
+
+func (p RetryPolicy) nextBackoff(backoff time.Duration) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultRetryMultiplier
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+
+	next := time.Duration(float64(backoff) * multiplier)
+	if next > maxBackoff {
+		return maxBackoff
+	}
+	return next
+}
+
+// retryRoundTrip sends req, and reads its single response, with the server's retry policy.
+func retryRoundTrip(s server, req string) (resp []byte, err error) {
+	err = s.retryPolicy().do(func() error {
+		resp, err = roundTripSingleResponse(s, req)
+		return err
+	})
+	return resp, err
+}
@@ -0,0 +1,105 @@
+package adb
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestRetryPolicyRetriesTransientErrors(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"device"},
+		Errs: []error{
+			errors.Errorf(errors.ConnectionResetError, "reset"),
+			errors.Errorf(errors.DeviceOffline, "device offline"),
+		},
+		Retry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Nanosecond},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("abc"))
+
+	state, err := device.State()
+	assert.NoError(t, err)
+	assert.Equal(t, StateOnline, state)
+	assert.Equal(t, []string{"Dial", "Dial", "Dial", "SendMessage", "ReadStatus", "ReadMessage", "Close", "Close"}, s.Trace)
+}
+
+func TestRetryPolicyStopsAtMaxAttempts(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Errs: []error{
+			errors.Errorf(errors.ConnectionResetError, "reset"),
+			errors.Errorf(errors.ConnectionResetError, "reset"),
+		},
+		Retry: RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Nanosecond},
+	}
+
+	_, err := (&Adb{s}).ServerVersion()
+	assert.True(t, HasErrCode(err, ConnectionResetError))
+	assert.Equal(t, []string{"Dial", "Dial"}, s.Trace)
+}
+
+func TestRetryPolicyDoesNotRetryPermanentErrors(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Errs:   []error{errors.Errorf(errors.DeviceNotFound, "device not found")},
+		Retry:  RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Nanosecond},
+	}
+
+	_, err := (&Adb{s}).Device(AnyDevice()).Serial()
+	assert.True(t, HasErrCode(err, DeviceNotFound))
+	assert.Equal(t, []string{"Dial"}, s.Trace)
+}
+
+func TestRetryPolicyZeroValueDoesNotRetry(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Errs:   []error{errors.Errorf(errors.ConnectionResetError, "reset")},
+	}
+
+	_, err := (&Adb{s}).ListDevices()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"Dial"}, s.Trace)
+}
+
+func TestRetryPolicyCustomRetryable(t *testing.T) {
+	attempts := 0
+	policy := RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Nanosecond,
+		Retryable:      func(err error) bool { return HasErrCode(err, ParseError) },
+	}
+	err := policy.do(func() error {
+		attempts++
+		return errors.Errorf(errors.ParseError, "bad")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 5, attempts)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxBackoff: 300 * time.Millisecond}
+	assert.Equal(t, 200*time.Millisecond, policy.nextBackoff(100*time.Millisecond))
+	assert.Equal(t, 300*time.Millisecond, policy.nextBackoff(200*time.Millisecond))
+
+	policy = RetryPolicy{Multiplier: 1.5}
+	assert.Equal(t, 150*time.Millisecond, policy.nextBackoff(100*time.Millisecond))
+	assert.Equal(t, 2*time.Second, policy.nextBackoff(10*time.Second))
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.False(t, IsTransient(nil))
+	assert.True(t, IsTransient(errors.Errorf(errors.DeviceOffline, "device offline")))
+	assert.True(t, IsTransient(errors.Errorf(errors.ConnectionResetError, "reset")))
+	assert.True(t, IsTransient(errors.WrapErrorf(syscall.EAGAIN, errors.NetworkError, "write")))
+	assert.True(t, IsTransient(&errors.Err{
+		Code:    errors.AdbError,
+		Details: wire.ErrorResponseDetails{ServerMsg: "Resource temporarily unavailable"},
+	}))
+	assert.False(t, IsTransient(errors.Errorf(errors.DeviceNotFound, "device not found")))
+	assert.False(t, IsTransient(errors.Errorf(errors.FileNoExistError, "no such file")))
+}
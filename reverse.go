@@ -0,0 +1,137 @@
+package adb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// ReverseEntry is a reverse forward from a socket spec on a device to one on the host.
+type ReverseEntry struct {
+	// Socket spec on the device, e.g. tcp:8081.
+	Remote string
+	// Socket spec on the host, e.g. tcp:8081.
+	Local string
+}
+
+/*
+Reverse forwards connections to remote on the device to local on the host.
+
+If remote is tcp:0, the device picks a free port, which is returned. Otherwise the returned
+string is empty.
+
+Corresponds to the command:
+
+	adb reverse <remote> <local>
+*/
+func (c *Device) Reverse(remote, local string) (string, error) {
+	port, err := c.reverse(remote, local)
+	return port, wrapClientError(err, c, "Reverse(%s, %s)", remote, local)
+}
+
+func (c *Device) reverse(remote, local string) (string, error) {
+	if isBlank(local) || isBlank(remote) || strings.Contains(remote, ";") {
+		return "", errors.AssertionErrorf("invalid reverse spec: %q -> %q", remote, local)
+	}
+
+	conn, err := c.reverseRequest(fmt.Sprintf("forward:%s;%s", remote, local))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if remote != "tcp:0" {
+		return "", nil
+	}
+	port, err := conn.ReadMessage()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(port)), nil
+}
+
+/*
+ListReverses returns the reverse forwards of this device.
+
+Corresponds to the command:
+
+	adb reverse --list
+*/
+func (c *Device) ListReverses() ([]ReverseEntry, error) {
+	conn, err := c.openService("reverse:list-forward")
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListReverses")
+	}
+	defer conn.Close()
+
+	resp, err := conn.ReadMessage()
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListReverses")
+	}
+	reverses, err := parseReverseList(string(resp))
+	return reverses, wrapClientError(err, c, "ListReverses")
+}
+
+/*
+RemoveReverse removes the reverse forward listening on remote.
+
+Corresponds to the command:
+
+	adb reverse --remove <remote>
+*/
+func (c *Device) RemoveReverse(remote string) error {
+	conn, err := c.reverseRequest("killforward:" + remote)
+	if err != nil {
+		return wrapClientError(err, c, "RemoveReverse(%s)", remote)
+	}
+	return wrapClientError(conn.Close(), c, "RemoveReverse(%s)", remote)
+}
+
+/*
+RemoveAllReverses removes all the reverse forwards of this device.
+
+Corresponds to the command:
+
+	adb reverse --remove-all
+*/
+func (c *Device) RemoveAllReverses() error {
+	conn, err := c.reverseRequest("killforward-all")
+	if err != nil {
+		return wrapClientError(err, c, "RemoveAllReverses")
+	}
+	return wrapClientError(conn.Close(), c, "RemoveAllReverses")
+}
+
+// reverseRequest sends reverse:<service> to the device, and returns the connection once
+// adbd confirms the listeners were updated.
+func (c *Device) reverseRequest(service string) (*wire.Conn, error) {
+	req := "reverse:" + service
+	conn, err := c.openService(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.ReadStatus(req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// parseReverseList parses "<transport> <remote> <local>" lines. The transport is the
+// device's name for its connection to the host, e.g. UsbFfs, and isn't useful to callers.
+func parseReverseList(list string) ([]ReverseEntry, error) {
+	var reverses []ReverseEntry
+	for _, line := range strings.Split(list, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, errors.Errorf(errors.ParseError, "invalid reverse forward: %q", line)
+		}
+		reverses = append(reverses, ReverseEntry{Remote: fields[1], Local: fields[2]})
+	}
+	return reverses, nil
+}
@@ -0,0 +1,49 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestReverse(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"38011"},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	port, err := device.Reverse("tcp:0", "tcp:8081")
+	assert.NoError(t, err)
+	assert.Equal(t, "38011", port)
+	assert.Equal(t, []string{"host:transport:serial", "reverse:forward:tcp:0;tcp:8081"}, s.Requests)
+}
+
+func TestListReverses(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"UsbFfs tcp:8081 tcp:8081\nUsbFfs localabstract:metro tcp:9000\n"},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	reverses, err := device.ListReverses()
+	assert.NoError(t, err)
+	assert.Equal(t, []ReverseEntry{
+		{Remote: "tcp:8081", Local: "tcp:8081"},
+		{Remote: "localabstract:metro", Local: "tcp:9000"},
+	}, reverses)
+	assert.Equal(t, "reverse:list-forward", s.Requests[1])
+}
+
+func TestRemoveReverses(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	assert.NoError(t, device.RemoveReverse("tcp:8081"))
+	assert.NoError(t, device.RemoveAllReverses())
+	assert.Equal(t, []string{
+		"host:transport:serial", "reverse:killforward:tcp:8081",
+		"host:transport:serial", "reverse:killforward-all",
+	}, s.Requests)
+}
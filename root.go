@@ -0,0 +1,38 @@
+package adb
+
+import (
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// IsRoot returns true if adbd is running as root, i.e. shell commands run with uid 0.
+func (c *Device) IsRoot() (bool, error) {
+	output, err := c.runShellCommand("id -u")
+	if err != nil {
+		return false, wrapClientError(err, c, "IsRoot")
+	}
+	return strings.TrimSpace(output) == "0", nil
+}
+
+// runRootShellCommand runs cmdLine as root. If adbd isn't running as root, the command is
+// run through su, which only works on rooted builds.
+func (c *Device) runRootShellCommand(cmdLine string) (string, error) {
+	root, err := c.IsRoot()
+	if err != nil {
+		return "", err
+	}
+	if root {
+		return c.runShellCommand(cmdLine)
+	}
+
+	output, err := c.runShellCommand(quoteShellArgs("su", "0", "sh", "-c", cmdLine))
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(output, "su: not found") || strings.Contains(output, "su: inaccessible or not found") ||
+		strings.Contains(output, "Permission denied") {
+		return "", errors.Errorf(errors.AdbError, "root is required: %s", strings.TrimSpace(output))
+	}
+	return output, nil
+}
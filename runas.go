@@ -0,0 +1,234 @@
+package adb
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Number of bytes of a run-as stream to inspect for error messages before passing it through.
+const runAsErrorPeekSize = 128
+
+/*
+AppExecutor runs commands and accesses files as a debuggable app, via run-as.
+Commands run with the app's uid and SELinux context, so they can read and write
+its private data directory, e.g. /data/data/<package>/databases.
+To get an instance, call RunAs() on a Device.
+*/
+type AppExecutor struct {
+	device *Device
+	pkg    string
+}
+
+// RunAs returns an executor for commands run as pkg, which must be a debuggable app.
+func (c *Device) RunAs(pkg string) *AppExecutor {
+	return &AppExecutor{device: c, pkg: pkg}
+}
+
+// Package returns the package commands are run as.
+func (e *AppExecutor) Package() string {
+	return e.pkg
+}
+
+// RunCommand runs cmd with args as the app, and returns its combined output.
+// Arguments are quoted, so they can contain spaces and shell metacharacters.
+func (e *AppExecutor) RunCommand(cmd string, args ...string) (string, error) {
+	output, err := e.device.runShellCommand(quoteShellArgs(e.commandLine(cmd, args...)...))
+	if err == nil {
+		err = runAsOutputError(output)
+	}
+	return output, wrapClientError(err, e.device, "RunAs(%s).RunCommand(%s)", e.pkg, cmd)
+}
+
+// OpenRead opens the file at path, relative to the app's data directory if not absolute,
+// and returns a reader for its contents. Data is streamed through exec:, so binary files
+// are read unmodified.
+func (e *AppExecutor) OpenRead(path string) (io.ReadCloser, error) {
+	conn, err := e.device.openService("exec:" + quoteShellArgs(e.commandLine("cat", path)...))
+	if err != nil {
+		return nil, wrapClientError(err, e.device, "RunAs(%s).OpenRead(%s)", e.pkg, path)
+	}
+
+	reader, err := newRunAsReader(conn)
+	if err != nil {
+		conn.Close()
+		return nil, wrapClientError(err, e.device, "RunAs(%s).OpenRead(%s)", e.pkg, path)
+	}
+	return reader, nil
+}
+
+// OpenWrite creates or truncates the file at path, relative to the app's data directory if
+// not absolute, and returns a writer for its contents. The file is complete once the writer
+// is closed.
+func (e *AppExecutor) OpenWrite(path string) (io.WriteCloser, error) {
+	conn, err := e.device.openService("exec:" + quoteShellArgs(
+		e.commandLine("sh", "-c", "cat > "+quoteShellArg(path))...))
+	if err != nil {
+		return nil, wrapClientError(err, e.device, "RunAs(%s).OpenWrite(%s)", e.pkg, path)
+	}
+	return conn, nil
+}
+
+// PullAppFile copies the file at remotePath, relative to the app's data directory if not
+// absolute, to localPath on the host.
+func (e *AppExecutor) PullAppFile(remotePath, localPath string) error {
+	reader, err := e.OpenRead(remotePath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return wrapClientError(errors.WrapErrorf(err, errors.AssertionError, "error creating %s", localPath),
+			e.device, "RunAs(%s).PullAppFile(%s)", e.pkg, remotePath)
+	}
+	if _, err = io.Copy(file, reader); err != nil {
+		file.Close()
+		return wrapClientError(errors.WrapErrorf(err, errors.NetworkError, "error pulling %s", remotePath),
+			e.device, "RunAs(%s).PullAppFile(%s)", e.pkg, remotePath)
+	}
+	return wrapClientError(errors.WrapErrorf(file.Close(), errors.AssertionError, "error closing %s", localPath),
+		e.device, "RunAs(%s).PullAppFile(%s)", e.pkg, remotePath)
+}
+
+// Entries of the data directory left out of backups: lib is a link to the installed APK's
+// native libraries, and the caches are rebuilt by the app.
+var appDataExcludes = []string{"lib", "cache", "code_cache"}
+
+/*
+BackupData writes the app's data directory, e.g. its databases, shared_prefs, and files, to w
+as a tar archive, so it can be restored with RestoreData to get the app back to the same
+state, e.g. logged in, without repeating onboarding. Stop the app first, e.g. with ForceStop,
+so files aren't archived while being written.
+
+Corresponds to the command:
+
+	adb exec-out run-as <package> tar -cf - .
+*/
+func (e *AppExecutor) BackupData(w io.Writer) error {
+	err := e.backupData(w)
+	return wrapClientError(err, e.device, "RunAs(%s).BackupData", e.pkg)
+}
+
+func (e *AppExecutor) backupData(w io.Writer) error {
+	exclude := ""
+	for _, name := range appDataExcludes {
+		exclude += " -e " + name
+	}
+	// tar's errors are printed after the archive, since exec: doesn't separate stderr.
+	// An empty directory is archived as just ".", since tar refuses to create an empty archive.
+	script := `set -- $(ls -A | grep -vx` + exclude + `); [ $# -gt 0 ] || set -- --no-recursion .; ` +
+		`exec 3>&1; e=$(tar -cf - "$@" 2>&1 >&3); printf %s "$e"`
+	conn, err := e.device.openService("exec:" + quoteShellArgs(e.commandLine("sh", "-c", script)...))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	reader, err := newRunAsReader(conn)
+	if err != nil {
+		return err
+	}
+
+	// Read the archive as it's copied, to find where it ends.
+	archive := tar.NewReader(io.TeeReader(reader, w))
+	for {
+		_, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.WrapErrorf(err, errors.ParseError, "invalid archive from tar")
+		}
+	}
+	output, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.WrapErrorf(err, errors.NetworkError, "error reading backup")
+	}
+	// The end of the archive may be padded with zeros.
+	return fileCommandError("tar", string(bytes.Trim(output, "\x00")))
+}
+
+/*
+RestoreData replaces the contents of the app's data directory with the tar archive read
+from r, e.g. one written by BackupData. Files not in the archive are deleted, except for the
+entries BackupData leaves out. Stop the app first, e.g. with ForceStop, so it doesn't see a
+partly restored state.
+
+Corresponds to the command:
+
+	adb exec-in run-as <package> tar -xf -
+*/
+func (e *AppExecutor) RestoreData(r io.Reader) error {
+	err := e.restoreData(r)
+	return wrapClientError(err, e.device, "RunAs(%s).RestoreData", e.pkg)
+}
+
+func (e *AppExecutor) restoreData(r io.Reader) error {
+	keep := ""
+	for _, name := range appDataExcludes {
+		keep += " ! -name " + name
+	}
+	script := `{ find . -mindepth 1 -maxdepth 1` + keep + ` -exec rm -rf {} + && tar -xf -; } 2>&1`
+	conn, err := e.device.openService("exec:" + quoteShellArgs(e.commandLine("sh", "-c", script)...))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := io.Copy(conn, r); err != nil {
+		return errors.WrapErrorf(err, errors.NetworkError, "error streaming backup")
+	}
+	// tar stops at the end of the archive, and only prints errors.
+	output, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return err
+	}
+	if err := runAsOutputError(string(output)); err != nil {
+		return err
+	}
+	return fileCommandError("tar", string(output))
+}
+
+func (e *AppExecutor) commandLine(cmd string, args ...string) []string {
+	cmdLine := append([]string{"run-as"}, e.device.userArgs()...)
+	cmdLine = append(cmdLine, e.pkg, cmd)
+	return append(cmdLine, args...)
+}
+
+// runAsReader passes through the output of a run-as stream once it has been checked for
+// error messages.
+type runAsReader struct {
+	*bufio.Reader
+	io.Closer
+}
+
+func newRunAsReader(stream io.ReadCloser) (io.ReadCloser, error) {
+	reader := bufio.NewReaderSize(stream, runAsErrorPeekSize)
+	head, _ := reader.Peek(runAsErrorPeekSize)
+	if err := runAsOutputError(string(head)); err != nil {
+		return nil, err
+	}
+	return &runAsReader{Reader: reader, Closer: stream}, nil
+}
+
+// runAsOutputError returns an error if output starts with an error message from run-as
+// or cat.
+func runAsOutputError(output string) error {
+	if !strings.HasPrefix(output, "run-as:") && !strings.HasPrefix(output, "cat:") &&
+		!strings.HasPrefix(output, "sh:") {
+		return nil
+	}
+
+	msg := strings.TrimSpace(strings.SplitN(output, "\n", 2)[0])
+	if strings.Contains(msg, "No such file or directory") {
+		return errors.Errorf(errors.FileNoExistError, "%s", msg)
+	}
+	return errors.Errorf(errors.AdbError, "%s", msg)
+}
@@ -0,0 +1,116 @@
+package adb
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestRunAsOpenRead(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"SQLite format 3\x00", "rest of file"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	reader, err := device.RunAs("com.example").OpenRead("databases/app.db")
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "SQLite format 3\x00rest of file", string(data))
+	assert.Equal(t, "exec:run-as com.example cat databases/app.db", s.Requests[1])
+}
+
+func TestRunAsOpenReadErrors(t *testing.T) {
+	for _, test := range []struct {
+		Output   string
+		WantCode ErrCode
+	}{
+		{"run-as: package not debuggable: com.example\n", AdbError},
+		{"run-as: unknown package: com.example\n", AdbError},
+		{"cat: databases/app.db: No such file or directory\n", FileNoExistError},
+	} {
+		s := &MockServer{
+			Status:   wire.StatusSuccess,
+			Messages: []string{test.Output},
+		}
+		device := (&Adb{s}).Device(AnyDevice())
+
+		_, err := device.RunAs("com.example").OpenRead("databases/app.db")
+		assert.True(t, HasErrCode(err, test.WantCode), test.Output)
+	}
+}
+
+func TestRunAsCommandLineForUser(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice()).ForUser(10)
+
+	_, err := device.RunAs("com.example").RunCommand("ls", "-l", "shared_prefs/my prefs.xml")
+	assert.NoError(t, err)
+	assert.Equal(t, "shell:run-as --user 10 com.example ls -l 'shared_prefs/my prefs.xml'", s.Requests[1])
+}
+
+// appDataArchive returns an archive of a data directory with one shared preferences file.
+func appDataArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	require.NoError(t, archive.WriteHeader(&tar.Header{Name: "shared_prefs/", Typeflag: tar.TypeDir, Mode: 0771}))
+	prefs := `<map><boolean name="logged_in" value="true" /></map>`
+	require.NoError(t, archive.WriteHeader(&tar.Header{Name: "shared_prefs/session.xml", Typeflag: tar.TypeReg,
+		Mode: 0660, Size: int64(len(prefs))}))
+	archive.Write([]byte(prefs))
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+func TestRunAsBackupData(t *testing.T) {
+	archive := appDataArchive(t)
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{string(archive), string(make([]byte, 1024))},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, (&Adb{s}).Device(AnyDevice()).RunAs("com.example").BackupData(&buf))
+	assert.Equal(t, archive, buf.Bytes())
+	assert.True(t, strings.HasPrefix(s.Requests[1], "exec:run-as com.example sh -c "), s.Requests[1])
+	assert.Contains(t, s.Requests[1], "grep -vx -e lib -e cache -e code_cache")
+
+	// Errors are printed after the archive.
+	s = &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{string(archive), "tar: files/locked.db: Permission denied"},
+	}
+	err := (&Adb{s}).Device(AnyDevice()).RunAs("com.example").BackupData(ioutil.Discard)
+	assert.True(t, HasErrCode(err, PermissionDenied), "%v", err)
+
+	s = &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"run-as: package not debuggable: com.example\n"},
+	}
+	err = (&Adb{s}).Device(AnyDevice()).RunAs("com.example").BackupData(ioutil.Discard)
+	assert.True(t, HasErrCode(err, AdbError), "%v", err)
+}
+
+func TestRunAsRestoreData(t *testing.T) {
+	archive := appDataArchive(t)
+	s := &MockServer{Status: wire.StatusSuccess}
+	require.NoError(t, (&Adb{s}).Device(AnyDevice()).RunAs("com.example").RestoreData(bytes.NewReader(archive)))
+	assert.Equal(t, "exec:run-as com.example sh -c "+
+		quoteShellArg("{ find . -mindepth 1 -maxdepth 1 ! -name lib ! -name cache ! -name code_cache -exec rm -rf {} + && tar -xf -; } 2>&1"),
+		s.Requests[1])
+	assert.Equal(t, string(archive), s.Requests[2])
+
+	s = &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"tar: shared_prefs/session.xml: No space left on device\n"},
+	}
+	err := (&Adb{s}).Device(AnyDevice()).RunAs("com.example").RestoreData(bytes.NewReader(archive))
+	assert.True(t, HasErrCode(err, AdbError), "%v", err)
+	assert.Contains(t, ErrorWithCauseChain(err), "No space left on device")
+}
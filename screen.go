@@ -0,0 +1,159 @@
+package adb
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// How long to wait for the keyguard to go away after asking it to.
+const keyguardDismissDelay = 500 * time.Millisecond
+
+var (
+	// Lines from dumpsys window (policy) that indicate the keyguard is showing. The
+	// field has moved and been renamed several times across Android versions.
+	keyguardShowingMarkers = []string{
+		"mShowingLockscreen=true",
+		"mDreamingLockscreen=true",
+		"isStatusBarKeyguard=true",
+		"mIsShowing=true",
+		"showing=true",
+	}
+)
+
+/*
+IsScreenOn returns true if the display is on (the device is awake).
+
+Corresponds to the command:
+
+	adb shell dumpsys power
+*/
+func (c *Device) IsScreenOn() (bool, error) {
+	output, err := c.RunCommand("dumpsys", "power")
+	if err != nil {
+		return false, wrapClientError(err, c, "IsScreenOn")
+	}
+	on, err := parseScreenOn(output)
+	return on, wrapClientError(err, c, "IsScreenOn")
+}
+
+/*
+IsLocked returns true if the keyguard (lock screen) is showing.
+
+Corresponds to the command:
+
+	adb shell dumpsys window policy
+*/
+func (c *Device) IsLocked() (bool, error) {
+	output, err := c.RunCommand("dumpsys", "window", "policy")
+	if err != nil {
+		return false, wrapClientError(err, c, "IsLocked")
+	}
+	return parseKeyguardShowing(output), nil
+}
+
+// Wake turns the screen on if it is off. Does nothing if the screen is already on.
+func (c *Device) Wake() error {
+	on, err := c.IsScreenOn()
+	if err != nil || on {
+		return err
+	}
+
+	// KEYCODE_WAKEUP only exists on API 20+, and is a no-op if the screen is on. Older devices
+	// ignore it, so fall back to toggling the power button.
+	if _, err := c.RunCommand("input", "keyevent", "KEYCODE_WAKEUP"); err != nil {
+		return wrapClientError(err, c, "Wake")
+	}
+	if on, err = c.IsScreenOn(); err != nil || on {
+		return err
+	}
+	_, err = c.RunCommand("input", "keyevent", "KEYCODE_POWER")
+	return wrapClientError(err, c, "Wake")
+}
+
+/*
+Unlock wakes the device and dismisses the keyguard.
+Only insecure keyguards (swipe or none) can be dismissed, if a PIN, pattern, or password
+is set, the keyguard will still be showing and an error is returned.
+
+Uses wm dismiss-keyguard where available (API 26+), falling back to swiping up
+from the bottom of the screen.
+*/
+func (c *Device) Unlock() error {
+	if err := c.Wake(); err != nil {
+		return err
+	}
+
+	locked, err := c.IsLocked()
+	if err != nil || !locked {
+		return err
+	}
+
+	if _, err := c.RunCommand("wm", "dismiss-keyguard"); err != nil {
+		return wrapClientError(err, c, "Unlock")
+	}
+	time.Sleep(keyguardDismissDelay)
+	if locked, err = c.IsLocked(); err != nil || !locked {
+		return err
+	}
+
+	display, err := c.DisplayInfo()
+	if err != nil {
+		return err
+	}
+	width, height := display.Width, display.Height
+	if _, err := c.Drag(width/2, height*4/5, width/2, height/5); err != nil {
+		return wrapClientError(err, c, "Unlock")
+	}
+	time.Sleep(keyguardDismissDelay)
+
+	if locked, err = c.IsLocked(); err != nil {
+		return err
+	}
+	if locked {
+		return wrapClientError(errors.Errorf(errors.AdbError, "keyguard is still showing, device may have a secure lock"),
+			c, "Unlock")
+	}
+	return nil
+}
+
+/*
+SetStayAwake keeps the screen on while the device is plugged in to any power source.
+
+Corresponds to the command:
+
+	adb shell svc power stayon true|false
+*/
+func (c *Device) SetStayAwake(enabled bool) error {
+	_, err := c.RunCommand("svc", "power", "stayon", strconv.FormatBool(enabled))
+	return wrapClientError(err, c, "SetStayAwake")
+}
+
+// parseScreenOn parses dumpsys power output. API 21+ reports mWakefulness, older versions
+// report mScreenOn.
+func parseScreenOn(output string) (bool, error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "mWakefulness="):
+			return strings.TrimPrefix(line, "mWakefulness=") == "Awake", nil
+		case strings.HasPrefix(line, "mScreenOn="):
+			return strings.TrimPrefix(line, "mScreenOn=") == "true", nil
+		}
+	}
+	return false, errors.Errorf(errors.ParseError, "no screen state found in dumpsys power output")
+}
+
+func parseKeyguardShowing(output string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		for _, marker := range keyguardShowingMarkers {
+			if strings.HasPrefix(line, marker) || strings.Contains(line, " "+marker) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScreenOn(t *testing.T) {
+	for _, test := range []struct {
+		Output  string
+		WantOn  bool
+		WantErr bool
+	}{
+		{"POWER MANAGER (dumpsys power)\n  mWakefulness=Awake\n", true, false},
+		{"  mWakefulness=Asleep\n", false, false},
+		{"  mWakefulness=Dozing\n", false, false},
+		{"  mScreenOn=true\n", true, false},
+		{"  mScreenOn=false\n", false, false},
+		{"nothing useful", false, true},
+	} {
+		on, err := parseScreenOn(test.Output)
+		assert.Equal(t, test.WantOn, on, test.Output)
+		assert.Equal(t, test.WantErr, err != nil, test.Output)
+	}
+}
+
+func TestParseKeyguardShowing(t *testing.T) {
+	assert.True(t, parseKeyguardShowing("    mShowingLockscreen=true mShowingDream=false"))
+	assert.True(t, parseKeyguardShowing("  KeyguardServiceDelegate\n    showing=true\n    showingAndNotOccluded=true"))
+	assert.True(t, parseKeyguardShowing("    mDreamingLockscreen=true"))
+	assert.False(t, parseKeyguardShowing("  KeyguardServiceDelegate\n    showing=false\n    mShowingLockscreen=false"))
+}
@@ -0,0 +1,340 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// ScreenRecordOptions configures ScreenRecord. The zero value records with screenrecord's
+// defaults.
+type ScreenRecordOptions struct {
+	// Length of the recording, in whole seconds. screenrecord defaults to, and caps it at, 3
+	// minutes.
+	TimeLimit time.Duration
+	// Bits per second of the video. Defaults to screenrecord's default, 20 Mbps.
+	BitRate int
+	// Size of the video in pixels, if both are set. Defaults to the size of the display.
+	Width, Height int
+}
+
+// How long ScreenRecord waits for screenrecord to finish writing the video after ctx is done,
+// before giving up on it.
+const screenRecordStopTimeout = 5 * time.Second
+
+func (opts ScreenRecordOptions) args() ([]string, error) {
+	var args []string
+	if opts.TimeLimit != 0 {
+		seconds := int(opts.TimeLimit / time.Second)
+		if seconds < 1 {
+			return nil, errors.AssertionErrorf("invalid time limit: %s", opts.TimeLimit)
+		}
+		args = append(args, "--time-limit", strconv.Itoa(seconds))
+	}
+	if opts.BitRate < 0 {
+		return nil, errors.AssertionErrorf("invalid bit rate: %d", opts.BitRate)
+	} else if opts.BitRate > 0 {
+		args = append(args, "--bit-rate", strconv.Itoa(opts.BitRate))
+	}
+	if opts.Width != 0 || opts.Height != 0 {
+		if opts.Width <= 0 || opts.Height <= 0 {
+			return nil, errors.AssertionErrorf("invalid video size: %dx%d", opts.Width, opts.Height)
+		}
+		args = append(args, "--size", strconv.Itoa(opts.Width)+"x"+strconv.Itoa(opts.Height))
+	}
+	return args, nil
+}
+
+/*
+ScreenRecord records the display to an MP4 video at remotePath on the device, and blocks until
+the recording ends. Pull it with PullWithProgress or PullTo.
+
+The recording ends when opts.TimeLimit is reached, or when ctx is done. In that case
+screenrecord is interrupted so it still finishes writing a playable video, and ctx.Err() is
+returned.
+
+Corresponds to the command:
+
+	adb shell screenrecord [--time-limit <seconds>] [--bit-rate <bps>] [--size <width>x<height>] [--display-id <id>] <path>
+*/
+func (c *Device) ScreenRecord(ctx context.Context, remotePath string, opts ScreenRecordOptions) error {
+	args, err := opts.args()
+	if err == nil {
+		var displayArgs []string
+		displayArgs, err = c.captureDisplayArgs("--display-id")
+		args = append(args, displayArgs...)
+	}
+	if err != nil {
+		return wrapClientError(err, c, "ScreenRecord(%s)", remotePath)
+	}
+	args = append(append([]string{"screenrecord"}, args...), remotePath)
+	// The shell prints its pid, which screenrecord takes over, so it can be interrupted.
+	conn, err := c.openService("shell:echo $$; exec " + quoteShellArgs(args...))
+	if err != nil {
+		return wrapClientError(err, c, "ScreenRecord(%s)", remotePath)
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	first, _ := reader.ReadString('\n')
+	pid, pidErr := strconv.Atoi(strings.TrimSpace(first))
+	if pidErr == nil {
+		first = ""
+	}
+
+	type result struct {
+		output []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := ioutil.ReadAll(reader)
+		done <- result{append([]byte(first), output...), err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		// Closing the connection would kill screenrecord before it writes the index of the
+		// video, so interrupt it instead and wait for it to exit.
+		if pidErr != nil {
+			return wrapClientError(errors.WrapErrorf(pidErr, errors.ParseError,
+				"invalid screenrecord pid: %q", first), c, "ScreenRecord(%s)", remotePath)
+		}
+		if _, err := c.runShellCommand("kill -INT " + strconv.Itoa(pid)); err != nil {
+			return wrapClientError(err, c, "ScreenRecord(%s)", remotePath)
+		}
+		select {
+		case <-done:
+		case <-time.After(screenRecordStopTimeout):
+		}
+		return ctx.Err()
+	}
+
+	if res.err == nil {
+		res.err = screenRecordError(string(res.output))
+	}
+	return wrapClientError(res.err, c, "ScreenRecord(%s)", remotePath)
+}
+
+// screenRecordError returns an AdbError if the output of screenrecord reports an error.
+// It prints nothing on success unless --verbose is passed.
+func screenRecordError(output string) error {
+	output = strings.TrimSpace(output)
+	if strings.Contains(output, "ERROR") || strings.Contains(output, "Usage:") {
+		return errors.Errorf(errors.AdbError, "screenrecord failed: %s", output)
+	}
+	return commandOutputError("screenrecord", output)
+}
+
+// Longest recording screenrecord makes, and the default length of RecordingSession segments.
+const maxScreenRecordTimeLimit = 3 * time.Minute
+
+// RecordingOptions configures a RecordingSession.
+type RecordingOptions struct {
+	// Options of each segment. TimeLimit is the length of each segment, and defaults to, and is
+	// capped at, screenrecord's limit of 3 minutes.
+	ScreenRecordOptions
+	// Total length of the recording. If 0, it records until Stop is called or the ctx passed to
+	// StartRecording is done.
+	Duration time.Duration
+
+	// Local directory segments are pulled into, as segment-000.mp4, segment-001.mp4, and so on.
+	// Required.
+	LocalDir string
+	// Directory on the device segments are recorded into, and deleted from once they're
+	// pulled. Defaults to /data/local/tmp.
+	RemoteDir string
+	// If set, the segments are joined into a single video at this local path with ffmpeg, which
+	// must be on the PATH, and deleted. Otherwise they're left in LocalDir.
+	ConcatPath string
+}
+
+/*
+RecordingSession is a screen recording longer than screenrecord allows. It records consecutive
+segments, pulling and deleting each one from the device while the next is recorded. Videos
+lose a fraction of a second between segments while screenrecord restarts.
+
+E.g. to record a 10 minute test run:
+
+	session, err := device.StartRecording(ctx, adb.RecordingOptions{
+		Duration:   10 * time.Minute,
+		LocalDir:   "recordings",
+		ConcatPath: "recordings/run.mp4",
+	})
+	...
+	files, err := session.Wait()
+*/
+type RecordingSession struct {
+	device    *Device
+	opts      RecordingOptions
+	remoteDir string
+	startedAt time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	files  []string
+	err    error
+}
+
+/*
+StartRecording starts recording the display in the background. The recording ends after
+opts.Duration, when ctx is done, or when Stop is called; each ends the current segment cleanly
+and keeps what was recorded.
+*/
+func (c *Device) StartRecording(ctx context.Context, opts RecordingOptions) (*RecordingSession, error) {
+	if opts.LocalDir == "" {
+		return nil, wrapClientError(errors.AssertionErrorf("must specify local directory"), c, "StartRecording")
+	}
+	if opts.TimeLimit <= 0 || opts.TimeLimit > maxScreenRecordTimeLimit {
+		opts.TimeLimit = maxScreenRecordTimeLimit
+	}
+	if _, err := opts.ScreenRecordOptions.args(); err != nil {
+		return nil, wrapClientError(err, c, "StartRecording")
+	}
+	if err := os.MkdirAll(opts.LocalDir, 0755); err != nil {
+		return nil, wrapClientError(err, c, "StartRecording")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &RecordingSession{
+		device:    c,
+		opts:      opts,
+		remoteDir: opts.RemoteDir,
+		startedAt: time.Now(),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	if s.remoteDir == "" {
+		s.remoteDir = "/data/local/tmp"
+	}
+	go s.run(ctx)
+	return s, nil
+}
+
+// Stop ends the recording, and returns what Wait returns.
+func (s *RecordingSession) Stop() ([]string, error) {
+	s.cancel()
+	return s.Wait()
+}
+
+// Wait blocks until the recording ends and its segments are pulled, and returns the local
+// paths of the segments, in order, or just ConcatPath if it was set. They're returned even if
+// the recording failed part way through.
+func (s *RecordingSession) Wait() ([]string, error) {
+	<-s.done
+	return s.files, s.err
+}
+
+func (s *RecordingSession) run(ctx context.Context) {
+	defer close(s.done)
+	defer s.cancel()
+
+	// Segments are pulled one at a time while the next one records.
+	segments := make(chan int)
+	pulled := make(chan error, 1)
+	go func() {
+		var err error
+		for i := range segments {
+			if pullErr := s.pullSegment(i); err == nil {
+				err = pullErr
+			}
+		}
+		pulled <- err
+	}()
+
+	err := s.record(ctx, segments)
+	close(segments)
+	err = errors.CombineErrs("errors recording screen", errors.AdbError, err, <-pulled)
+
+	if err == nil && s.opts.ConcatPath != "" && len(s.files) > 0 {
+		if err = concatVideos(s.opts.ConcatPath, s.files); err == nil {
+			for _, file := range s.files {
+				os.Remove(file)
+			}
+			s.files = []string{s.opts.ConcatPath}
+		}
+	}
+	s.err = wrapClientError(err, s.device, "RecordingSession")
+}
+
+// record records segments until the session ends, and sends the index of each one to segments
+// once it's complete.
+func (s *RecordingSession) record(ctx context.Context, segments chan<- int) error {
+	for i := 0; ctx.Err() == nil; i++ {
+		opts := s.opts.ScreenRecordOptions
+		if s.opts.Duration > 0 {
+			remaining := s.opts.Duration - time.Since(s.startedAt)
+			if remaining < time.Second {
+				return nil
+			}
+			if remaining < opts.TimeLimit {
+				opts.TimeLimit = remaining
+			}
+		}
+
+		err := s.device.ScreenRecord(ctx, s.remoteSegment(i), opts)
+		segments <- i
+		if err != nil && err != ctx.Err() {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullSegment pulls segment i into LocalDir, and deletes it from the device.
+func (s *RecordingSession) pullSegment(i int) error {
+	remote := s.remoteSegment(i)
+	local := filepath.Join(s.opts.LocalDir, fmt.Sprintf("segment-%03d.mp4", i))
+	_, err := s.device.PullWithProgress(context.Background(), false, remote, local, nil)
+	if HasErrCode(err, FileNoExistError) {
+		// The session ended before screenrecord created the segment.
+		return nil
+	}
+	if err == nil {
+		s.files = append(s.files, local)
+	}
+	return errors.CombineErrs("error pulling segment", errors.AdbError, err, s.device.RemoveAll(remote))
+}
+
+func (s *RecordingSession) remoteSegment(i int) string {
+	return path.Join(s.remoteDir, fmt.Sprintf("goadb-recording-%d-%03d.mp4", s.startedAt.UnixNano(), i))
+}
+
+// concatVideos joins the videos at paths into one at dest, without re-encoding them.
+func concatVideos(dest string, paths []string) error {
+	list, err := ioutil.TempFile("", "goadb-concat-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(list.Name())
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			list.Close()
+			return err
+		}
+		fmt.Fprintf(list, "file '%s'\n", strings.Replace(abs, "'", `'\''`, -1))
+	}
+	if err := list.Close(); err != nil {
+		return err
+	}
+
+	output, err := exec.Command("ffmpeg", "-y", "-loglevel", "error",
+		"-f", "concat", "-safe", "0", "-i", list.Name(), "-c", "copy", dest).CombinedOutput()
+	if err != nil {
+		return errors.WrapErrorf(err, errors.AdbError, "ffmpeg failed: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
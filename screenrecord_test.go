@@ -0,0 +1,65 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestScreenRecordOptionsArgs(t *testing.T) {
+	args, err := ScreenRecordOptions{}.args()
+	assert.NoError(t, err)
+	assert.Empty(t, args)
+
+	args, err = ScreenRecordOptions{TimeLimit: 90 * time.Second, BitRate: 4000000, Width: 1280, Height: 720}.args()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--time-limit", "90", "--bit-rate", "4000000", "--size", "1280x720"}, args)
+
+	for _, opts := range []ScreenRecordOptions{
+		{TimeLimit: time.Millisecond},
+		{BitRate: -1},
+		{Width: 1280},
+	} {
+		_, err := opts.args()
+		assert.True(t, HasErrCode(err, AssertionError), "%+v", opts)
+	}
+}
+
+func TestScreenRecord(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice()).OnDisplay(&Display{ID: 2, PhysicalID: 42})
+
+	err := device.ScreenRecord(context.Background(), "/sdcard/demo.mp4", ScreenRecordOptions{TimeLimit: 10 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, "shell:echo $$; exec screenrecord --time-limit 10 --display-id 42 /sdcard/demo.mp4", s.Requests[len(s.Requests)-1])
+}
+
+func TestScreenRecordError(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"echo $$; exec screenrecord /sdcard/demo.mp4": "1234\nERROR: unable to get output buffers (err=-38)\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	err := device.ScreenRecord(context.Background(), "/sdcard/demo.mp4", ScreenRecordOptions{})
+	assert.True(t, HasErrCode(err, AdbError))
+}
+
+func TestStartRecordingValidates(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.StartRecording(context.Background(), RecordingOptions{})
+	assert.True(t, HasErrCode(err, AssertionError))
+	_, err = device.StartRecording(context.Background(), RecordingOptions{
+		ScreenRecordOptions: ScreenRecordOptions{BitRate: -1},
+		LocalDir:            "recordings",
+	})
+	assert.True(t, HasErrCode(err, AssertionError))
+	assert.Empty(t, s.Requests)
+}
@@ -0,0 +1,234 @@
+package adb
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DecodeScreenshot decodes the PNG returned by ScreenShot.
+func DecodeScreenshot(data []byte) (image.Image, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "error decoding screenshot")
+	}
+	return img, nil
+}
+
+// ScreenShotImage captures the display, like ScreenShot, and decodes it.
+func (c *Device) ScreenShotImage() (image.Image, error) {
+	data, err := c.ScreenShot()
+	if err != nil {
+		return nil, err
+	}
+	img, err := DecodeScreenshot(data)
+	return img, wrapClientError(err, c, "ScreenShotImage")
+}
+
+// ScreenshotOptions configures ScreenShotWithOptions. Screenshots are processed on the host,
+// after screencap's PNG is transferred.
+type ScreenshotOptions struct {
+	// If set, the screenshot is scaled down, keeping its aspect ratio, to fit within them.
+	// Screenshots smaller than the limits aren't scaled up.
+	MaxWidth, MaxHeight int
+	// If set, the screenshot is encoded as a JPEG with this quality, from 1 to 100, instead of
+	// a PNG.
+	JPEGQuality int
+}
+
+/*
+ScreenShotWithOptions captures the display like ScreenShot, and scales and re-encodes it as
+opts asks, e.g. to keep frames for a dashboard small:
+
+	jpg, err := device.ScreenShotWithOptions(adb.ScreenshotOptions{MaxWidth: 540, JPEGQuality: 70})
+*/
+func (c *Device) ScreenShotWithOptions(opts ScreenshotOptions) ([]byte, error) {
+	if opts.MaxWidth < 0 || opts.MaxHeight < 0 || opts.JPEGQuality < 0 || opts.JPEGQuality > 100 {
+		return nil, wrapClientError(errors.AssertionErrorf("invalid screenshot options: %+v", opts),
+			c, "ScreenShotWithOptions")
+	}
+	if opts == (ScreenshotOptions{}) {
+		return c.ScreenShot()
+	}
+
+	img, err := c.ScreenShotImage()
+	if err != nil {
+		return nil, err
+	}
+	data, err := encodeScreenshot(img, opts)
+	return data, wrapClientError(err, c, "ScreenShotWithOptions")
+}
+
+func encodeScreenshot(img image.Image, opts ScreenshotOptions) ([]byte, error) {
+	size := img.Bounds().Size()
+	width, height := size.X, size.Y
+	if opts.MaxWidth > 0 && width > opts.MaxWidth {
+		width, height = opts.MaxWidth, height*opts.MaxWidth/width
+	}
+	if opts.MaxHeight > 0 && height > opts.MaxHeight {
+		width, height = width*opts.MaxHeight/height, opts.MaxHeight
+	}
+	if width != size.X || height != size.Y {
+		img = scaleDown(img, maxInt(width, 1), maxInt(height, 1))
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if opts.JPEGQuality > 0 {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: opts.JPEGQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.AssertionError, "error encoding screenshot")
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown scales img to width by height by averaging the pixels each destination pixel
+// covers, which doesn't alias text and thin lines like nearest-neighbor sampling does.
+func scaleDown(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*srcHeight/height
+		y1 := maxInt(bounds.Min.Y+(y+1)*srcHeight/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*srcWidth/width
+			x1 := maxInt(bounds.Min.X+(x+1)*srcWidth/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// PixelAt returns the color of the pixel at x, y in img, relative to the top left of its
+// bounds. It returns an AssertionError if the point is outside the image.
+func PixelAt(img image.Image, x, y int) (color.NRGBA, error) {
+	bounds := img.Bounds()
+	p := image.Pt(bounds.Min.X+x, bounds.Min.Y+y)
+	if !p.In(bounds) {
+		return color.NRGBA{}, errors.AssertionErrorf("point %d,%d is outside the %dx%d image",
+			x, y, bounds.Dx(), bounds.Dy())
+	}
+	return color.NRGBAModel.Convert(img.At(p.X, p.Y)).(color.NRGBA), nil
+}
+
+// DiffOptions configures DiffScreenshots.
+type DiffOptions struct {
+	// Pixels whose channels all differ by at most Tolerance are treated as unchanged, to ignore
+	// compression and dithering noise.
+	Tolerance uint8
+	// Regions left out of the comparison, relative to the top left of the images, e.g. the
+	// status bar clock or an animated spinner.
+	Masks []image.Rectangle
+}
+
+// ScreenshotDiff describes how two screenshots differ.
+type ScreenshotDiff struct {
+	// Pixels that differ, and the pixels that were compared, which exclude masked pixels.
+	ChangedPixels, ComparedPixels int
+	// Smallest rectangle containing every changed pixel, relative to the top left of the
+	// images. It's empty if nothing changed.
+	Bounds image.Rectangle
+}
+
+// Percent returns the percentage of compared pixels that changed.
+func (d *ScreenshotDiff) Percent() float64 {
+	if d.ComparedPixels == 0 {
+		return 0
+	}
+	return float64(d.ChangedPixels) * 100 / float64(d.ComparedPixels)
+}
+
+/*
+DiffScreenshots compares two screenshots of the same size pixel by pixel, e.g. to assert that
+tapping a button changed the screen:
+
+	diff, err := adb.DiffScreenshots(before, after, adb.DiffOptions{
+		Tolerance: 8,
+		Masks:     []image.Rectangle{image.Rect(0, 0, 1080, 80)},
+	})
+	if diff.Percent() < 1 {
+		t.Error("screen didn't change")
+	}
+
+It returns an AssertionError if the images aren't the same size.
+*/
+func DiffScreenshots(a, b image.Image, opts DiffOptions) (*ScreenshotDiff, error) {
+	boundsA, boundsB := a.Bounds(), b.Bounds()
+	if boundsA.Size() != boundsB.Size() {
+		return nil, errors.AssertionErrorf("can't compare a %dx%d image to a %dx%d image",
+			boundsA.Dx(), boundsA.Dy(), boundsB.Dx(), boundsB.Dy())
+	}
+
+	diff := &ScreenshotDiff{}
+	for y := 0; y < boundsA.Dy(); y++ {
+		for x := 0; x < boundsA.Dx(); x++ {
+			if isMasked(opts.Masks, x, y) {
+				continue
+			}
+			diff.ComparedPixels++
+			ca := a.At(boundsA.Min.X+x, boundsA.Min.Y+y)
+			cb := b.At(boundsB.Min.X+x, boundsB.Min.Y+y)
+			if colorsEqual(ca, cb, opts.Tolerance) {
+				continue
+			}
+			diff.ChangedPixels++
+			diff.Bounds = diff.Bounds.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	return diff, nil
+}
+
+func isMasked(masks []image.Rectangle, x, y int) bool {
+	p := image.Pt(x, y)
+	for _, mask := range masks {
+		if p.In(mask) {
+			return true
+		}
+	}
+	return false
+}
+
+// colorsEqual returns true if no 8-bit channel of a and b differs by more than tolerance.
+func colorsEqual(a, b color.Color, tolerance uint8) bool {
+	ca := color.NRGBAModel.Convert(a).(color.NRGBA)
+	cb := color.NRGBAModel.Convert(b).(color.NRGBA)
+	return channelDelta(ca.R, cb.R) <= tolerance && channelDelta(ca.G, cb.G) <= tolerance &&
+		channelDelta(ca.B, cb.B) <= tolerance && channelDelta(ca.A, cb.A) <= tolerance
+}
+
+func channelDelta(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
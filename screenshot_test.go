@@ -0,0 +1,141 @@
+package adb
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func filledImage(width, height int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestPixelAt(t *testing.T) {
+	img := filledImage(4, 4, color.White)
+	img.Set(1, 2, color.NRGBA{R: 255, A: 255})
+
+	c, err := PixelAt(img, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, c)
+
+	// Coordinates are relative to the bounds of sub-images.
+	c, err = PixelAt(img.SubImage(image.Rect(1, 1, 4, 4)), 0, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 255, A: 255}, c)
+
+	_, err = PixelAt(img, 4, 0)
+	assert.True(t, HasErrCode(err, AssertionError))
+}
+
+func TestDiffScreenshots(t *testing.T) {
+	before := filledImage(10, 10, color.White)
+	after := filledImage(10, 10, color.White)
+	after.Set(2, 3, color.Black)
+	after.Set(5, 7, color.Black)
+	// Within the tolerance.
+	after.Set(9, 9, color.NRGBA{R: 250, G: 250, B: 250, A: 255})
+
+	diff, err := DiffScreenshots(before, after, DiffOptions{Tolerance: 8})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, diff.ChangedPixels)
+	assert.Equal(t, 100, diff.ComparedPixels)
+	assert.Equal(t, image.Rect(2, 3, 6, 8), diff.Bounds)
+	assert.Equal(t, 2.0, diff.Percent())
+
+	diff, err = DiffScreenshots(before, after, DiffOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, diff.ChangedPixels)
+
+	diff, err = DiffScreenshots(before, after, DiffOptions{
+		Tolerance: 8,
+		Masks:     []image.Rectangle{image.Rect(0, 0, 10, 5)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, diff.ChangedPixels)
+	assert.Equal(t, 50, diff.ComparedPixels)
+	assert.Equal(t, image.Rect(5, 7, 6, 8), diff.Bounds)
+
+	diff, err = DiffScreenshots(before, before, DiffOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, diff.Percent())
+	assert.True(t, diff.Bounds.Empty())
+
+	_, err = DiffScreenshots(before, filledImage(10, 11, color.White), DiffOptions{})
+	assert.True(t, HasErrCode(err, AssertionError))
+}
+
+func TestScreenShotImage(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, filledImage(3, 2, color.Black)))
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{"screencap -p": buf.String()},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	img, err := device.ScreenShotImage()
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 3, 2), img.Bounds())
+
+	_, err = DecodeScreenshot([]byte("not a png"))
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestScaleDown(t *testing.T) {
+	img := filledImage(4, 2, color.White)
+	img.Set(0, 0, color.Black)
+	img.Set(1, 0, color.Black)
+	img.Set(0, 1, color.Black)
+	img.Set(1, 1, color.Black)
+
+	scaled := scaleDown(img, 2, 1)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), scaled.Bounds())
+	assert.Equal(t, color.RGBA{A: 255}, scaled.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, scaled.RGBAAt(1, 0))
+
+	// Half black, half white averages to grey.
+	scaled = scaleDown(img, 1, 1)
+	assert.Equal(t, color.RGBA{R: 127, G: 127, B: 127, A: 255}, scaled.RGBAAt(0, 0))
+}
+
+func TestScreenShotWithOptions(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, filledImage(1080, 2400, color.White)))
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{"screencap -p": buf.String()},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	data, err := device.ScreenShotWithOptions(ScreenshotOptions{MaxWidth: 540, JPEGQuality: 70})
+	assert.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 540, 1200), img.Bounds())
+
+	data, err = device.ScreenShotWithOptions(ScreenshotOptions{MaxWidth: 540, MaxHeight: 600})
+	assert.NoError(t, err)
+	img, err = png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 270, 600), img.Bounds())
+
+	// No options returns screencap's PNG untouched.
+	data, err = device.ScreenShotWithOptions(ScreenshotOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), data)
+
+	_, err = device.ScreenShotWithOptions(ScreenshotOptions{JPEGQuality: 101})
+	assert.True(t, HasErrCode(err, AssertionError))
+}
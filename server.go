@@ -4,9 +4,15 @@ import (
 	stderrors "errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
 	"github.com/zach-klippenstein/goadb/wire"
@@ -20,19 +26,49 @@ const (
 )
 
 type ServerConfig struct {
-	// Path to the adb executable. If empty, the PATH environment variable will be searched.
+	// Path to the adb executable. If empty, like build tools that call the adb CLI, uses the
+	// ADB environment variable if set, then searches PATH, then the platform-tools directory of
+	// the SDK in ANDROID_HOME or ANDROID_SDK_ROOT.
 	PathToAdb string
 
 	// Host and port the adb server is listening on.
-	// If not specified, will use the default port on localhost.
+	// If not specified, will use localhost, and the port in ANDROID_ADB_SERVER_PORT like the adb
+	// CLI, or the default port.
 	Host string
 	Port int
 
 	// Dialer used to connect to the adb server.
 	Dialer
 
+	// If set, and Dialer isn't, returns the SOCKS5 or HTTP proxy to connect to an address
+	// through, or nil to connect directly, e.g. ProxyFromEnvironment or ProxyURL.
+	Proxy func(address string) (*url.URL, error)
+
 	fs *filesystem
 
+	// If set, every request to the server is logged to Logger. See Logger for details.
+	Logger Logger
+	// If set, timings and traffic stats of every request are reported to Instrumentation.
+	Instrumentation Instrumentation
+
+	// Timeouts for dialing, reads and writes, and whole operations. By default, operations
+	// never time out.
+	Timeouts Timeouts
+
+	// Retries idempotent operations that fail with transient errors. By default, nothing
+	// is retried.
+	Retry RetryPolicy
+
+	// Binaries pushed to devices that lack them.
+	Tools HostTools
+
+	// If set, labels the devices returned by ListDevices, ForEachDevice, and DevicePool.
+	Registry *DeviceRegistry
+
+	// If true, the server is never started, and PathToAdb isn't needed. Use this when the
+	// server is managed elsewhere, e.g. by adbtest.Server. Otherwise, when Host is local and
+	// nothing is listening on the port, the server is started with PathToAdb and the dial is
+	// retried, like the adb CLI does.
 	NoServer bool
 }
 
@@ -41,6 +77,24 @@ type server interface {
 	Start() error
 	Dial() (*wire.Conn, error)
 	NoServer() bool
+	retryPolicy() RetryPolicy
+	timeouts() Timeouts
+	tools() HostTools
+	capabilities() *serverCapabilities
+	registry() *DeviceRegistry
+}
+
+/*
+HostTools are the paths on the host of static binaries for the devices' ABI, pushed to devices
+that don't have the tools some methods need. They're set per client, so clients for different
+hosts can push binaries for different ABIs.
+*/
+type HostTools struct {
+	// Busybox is used by WatchPath when the device doesn't have inotifyd.
+	Busybox string
+	// Strace is used by Strace when the device doesn't have strace, which only eng and
+	// userdebug builds do.
+	Strace string
 }
 
 func roundTripSingleResponse(s server, req string) ([]byte, error) {
@@ -50,6 +104,9 @@ func roundTripSingleResponse(s server, req string) ([]byte, error) {
 	}
 	defer conn.Close()
 
+	if err = conn.SetDeadline(deadlineFor(s.timeouts().Query)); err != nil {
+		return nil, err
+	}
 	return conn.RoundTripSingleResponse([]byte(req))
 }
 
@@ -58,33 +115,45 @@ type realServer struct {
 
 	// Caches Host:Port so they don't have to be concatenated for every dial.
 	address string
+
+	caps serverCapabilities
 }
 
 func newServer(config ServerConfig) (server, error) {
 	if config.Dialer == nil {
-		config.Dialer = tcpDialer{}
+		config.Dialer = tcpDialer{timeouts: config.Timeouts, proxy: config.Proxy}
 	}
 
 	if config.Host == "" {
 		config.Host = "127.0.0.1"
 	}
-	if config.Port == 0 {
-		config.Port = AdbPort
-	}
 
 	if config.fs == nil {
 		config.fs = localFilesystem
 	}
 
-	if config.PathToAdb == "" {
-		path, err := config.fs.LookPath(AdbExecutableName)
-		if err != nil {
-			return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "could not find %s in PATH", AdbExecutableName)
+	if config.Port == 0 {
+		config.Port = AdbPort
+		if port := config.fs.Getenv("ANDROID_ADB_SERVER_PORT"); port != "" {
+			var err error
+			if config.Port, err = strconv.Atoi(port); err != nil {
+				return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "invalid ANDROID_ADB_SERVER_PORT: %q", port)
+			}
 		}
-		config.PathToAdb = path
 	}
-	if err := config.fs.IsExecutableFile(config.PathToAdb); err != nil {
-		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "invalid adb executable: %s", config.PathToAdb)
+
+	// The adb executable is only needed to start the server.
+	if !config.NoServer {
+		if config.PathToAdb == "" {
+			path, err := config.fs.findAdb()
+			if err != nil {
+				return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "could not find %s in PATH", AdbExecutableName)
+			}
+			config.PathToAdb = path
+		}
+		if err := config.fs.IsExecutableFile(config.PathToAdb); err != nil {
+			return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "invalid adb executable: %s", config.PathToAdb)
+		}
 	}
 
 	return &realServer{
@@ -93,11 +162,23 @@ func newServer(config ServerConfig) (server, error) {
 	}, nil
 }
 
-// Dial tries to connect to the server. If the first attempt fails, tries starting the server before
-// retrying. If the second attempt fails, returns the error.
+// Dial tries to connect to the server. It doesn't retry, since the idempotent operations that
+// dial do so with the configured RetryPolicy, dialing included.
 func (s *realServer) Dial() (*wire.Conn, error) {
+	return s.dialOrStart()
+}
+
+// dialOrStart tries to connect to the server. If the first attempt is refused, tries starting the server before
+// retrying. If the second attempt fails, returns the error.
+func (s *realServer) dialOrStart() (*wire.Conn, error) {
 	conn, err := s.config.Dial(s.address)
 	if err != nil {
+		// A server that's running but not answering, or on another host, can't be fixed by
+		// starting one here.
+		if s.config.NoServer || !isConnectionRefused(err) || !isLocalHost(s.config.Host) {
+			return nil, err
+		}
+
 		// Attempt to start the server and try again.
 		if err = s.Start(); err != nil {
 			return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error starting server for dial")
@@ -122,10 +203,44 @@ func (s *realServer) Start() error {
 	return errors.WrapErrorf(err, errors.ServerNotAvailable, "error starting server: %s\noutput:\n%s", err, outputStr)
 }
 
+// isConnectionRefused returns true if err is from dialing a port nothing is listening on.
+func isConnectionRefused(err error) bool {
+	return stderrors.Is(err, syscall.ECONNREFUSED) || isPlatformConnectionRefused(err)
+}
+
+// isLocalHost returns true if host is this machine, so a server started here would listen on it.
+func isLocalHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func (s *realServer) NoServer() bool {
 	return s.config.NoServer
 }
 
+func (s *realServer) retryPolicy() RetryPolicy {
+	return s.config.Retry
+}
+
+func (s *realServer) timeouts() Timeouts {
+	return s.config.Timeouts
+}
+
+func (s *realServer) tools() HostTools {
+	return s.config.Tools
+}
+
+func (s *realServer) capabilities() *serverCapabilities {
+	return &s.caps
+}
+
+func (s *realServer) registry() *DeviceRegistry {
+	return s.config.Registry
+}
+
 // filesystem abstracts interactions with the local filesystem for testability.
 type filesystem struct {
 	// Wraps exec.LookPath.
@@ -136,6 +251,34 @@ type filesystem struct {
 
 	// Wraps exec.Command().CombinedOutput()
 	CmdCombinedOutput func(name string, arg ...string) ([]byte, error)
+
+	// Wraps os.Getenv.
+	Getenv func(key string) string
+}
+
+// findAdb returns the path of the adb executable, see ServerConfig.PathToAdb.
+func (fs *filesystem) findAdb() (string, error) {
+	if path := fs.Getenv("ADB"); path != "" {
+		return path, nil
+	}
+	path, err := fs.LookPath(AdbExecutableName)
+	if err == nil {
+		return path, nil
+	}
+	name := AdbExecutableName
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	for _, sdk := range []string{fs.Getenv("ANDROID_HOME"), fs.Getenv("ANDROID_SDK_ROOT")} {
+		if sdk == "" {
+			continue
+		}
+		sdkPath := filepath.Join(sdk, "platform-tools", name)
+		if fs.IsExecutableFile(sdkPath) == nil {
+			return sdkPath, nil
+		}
+	}
+	return "", err
 }
 
 var localFilesystem = &filesystem{
@@ -153,4 +296,5 @@ var localFilesystem = &filesystem{
 	CmdCombinedOutput: func(name string, arg ...string) ([]byte, error) {
 		return exec.Command(name, arg...).CombinedOutput()
 	},
+	Getenv: os.Getenv,
 }
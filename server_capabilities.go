@@ -0,0 +1,78 @@
+package adb
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// Server versions, as reported by host:version, at which host services used by this package
+// were added.
+const (
+	// The features service was added, along with shell_v2, in adb 1.0.33.
+	serverVersionFeatures = 33
+)
+
+// serverCapabilities caches what the server supports, so requests it doesn't know can fail with
+// ErrUnsupportedServer instead of a protocol error. It's shared by a client and its Devices.
+type serverCapabilities struct {
+	mu      sync.Mutex
+	version int
+}
+
+// cachedServerVersion returns the server's version, querying it only the first time.
+func cachedServerVersion(s server) (int, error) {
+	caps := s.capabilities()
+	caps.mu.Lock()
+	defer caps.mu.Unlock()
+
+	if caps.version == 0 {
+		version, err := queryServerVersion(s)
+		if err != nil {
+			return 0, err
+		}
+		caps.version = version
+	}
+	return caps.version, nil
+}
+
+// setCachedServerVersion replaces the cached version, e.g. after querying it again.
+func setCachedServerVersion(s server, version int) {
+	caps := s.capabilities()
+	caps.mu.Lock()
+	defer caps.mu.Unlock()
+	caps.version = version
+}
+
+func queryServerVersion(s server) (int, error) {
+	resp, err := retryRoundTrip(s, "host:version")
+	if err != nil {
+		return 0, err
+	}
+	return parseServerVersion(resp)
+}
+
+func parseServerVersion(versionRaw []byte) (int, error) {
+	versionStr := string(versionRaw)
+	version, err := strconv.ParseInt(versionStr, 16, 32)
+	if err != nil {
+		return 0, errors.WrapErrorf(err, errors.ParseError,
+			"error parsing server version: %s", versionStr)
+	}
+	return int(version), nil
+}
+
+// requireServerVersion returns an UnsupportedServer error if the server is older than version,
+// which added feature.
+func requireServerVersion(s server, version int, feature string) error {
+	actual, err := cachedServerVersion(s)
+	if err != nil {
+		return err
+	}
+	if actual < version {
+		return errors.Errorf(errors.UnsupportedServer,
+			"%s requires adb server version %d, but the server is version %d", feature, version, actual)
+	}
+	return nil
+}
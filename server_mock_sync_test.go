@@ -0,0 +1,237 @@
+package adb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// MockFile is a file on the device simulated by MockServer in sync mode.
+type MockFile struct {
+	Data []byte
+	// Defaults to a regular file with permissions 0644. For os.ModeSymlink, Data is the target.
+	Mode    os.FileMode
+	ModTime time.Time
+}
+
+// mockSyncDevice is the device end of a sync connection. It parses the requests written by
+// a wire.SyncSender, and queues responses for a wire.SyncScanner to read, using the files of
+// its MockServer.
+type mockSyncDevice struct {
+	server *MockServer
+
+	// Bytes written by the client that don't make up a complete packet yet.
+	in bytes.Buffer
+	// Responses not read by the client yet.
+	out bytes.Buffer
+
+	// The file being received for a SEND request, or nil.
+	sending     *MockFile
+	sendingPath string
+}
+
+func newMockSyncDevice(server *MockServer) *mockSyncDevice {
+	return &mockSyncDevice{server: server}
+}
+
+func (d *mockSyncDevice) Read(p []byte) (int, error) {
+	if d.out.Len() == 0 {
+		return 0, io.EOF
+	}
+	return d.out.Read(p)
+}
+
+func (d *mockSyncDevice) Write(p []byte) (int, error) {
+	d.in.Write(p)
+	for d.handleNextPacket() {
+	}
+	return len(p), nil
+}
+
+func (d *mockSyncDevice) Close() error {
+	return nil
+}
+
+// handleNextPacket handles the next complete packet written by the client, and returns false
+// if there isn't one yet.
+func (d *mockSyncDevice) handleNextPacket() bool {
+	buf := d.in.Bytes()
+	if len(buf) < 8 {
+		return false
+	}
+	id := string(buf[:4])
+	arg := binary.LittleEndian.Uint32(buf[4:8])
+
+	// DONE carries an mtime instead of a length.
+	if id == wire.StatusSyncDone {
+		d.in.Next(8)
+		d.finishSend(time.Unix(int64(arg), 0).UTC())
+		return true
+	}
+	if len(buf) < 8+int(arg) {
+		return false
+	}
+	d.in.Next(8)
+	data := append([]byte{}, d.in.Next(int(arg))...)
+
+	switch id {
+	case "STAT":
+		d.record(id, string(data))
+		d.writeStat(d.server.lookupFile(string(data)))
+	case "LIST":
+		d.record(id, string(data))
+		d.writeList(string(data))
+	case "RECV":
+		d.record(id, string(data))
+		d.writeFile(string(data))
+	case "SEND":
+		d.record(id, string(data))
+		d.startSend(string(data))
+	case wire.StatusSyncData:
+		if d.sending != nil {
+			d.sending.Data = append(d.sending.Data, data...)
+		}
+	default:
+		d.writeFail(fmt.Sprintf("unknown sync request %q", id))
+	}
+	return true
+}
+
+func (d *mockSyncDevice) record(id, arg string) {
+	d.server.SyncRequests = append(d.server.SyncRequests, id+" "+arg)
+}
+
+func (d *mockSyncDevice) writeStat(file *MockFile) {
+	d.out.WriteString("STAT")
+	d.writeFileInfo(file)
+}
+
+func (d *mockSyncDevice) writeList(dir string) {
+	dir = path.Clean(dir)
+	entries := map[string]*MockFile{}
+	for name := range d.server.Files {
+		rel := strings.TrimPrefix(name, dir+"/")
+		if rel == name || rel == "" {
+			continue
+		}
+		// Files in subdirectories imply the subdirectory.
+		child := strings.SplitN(rel, "/", 2)[0]
+		entries[child] = d.server.lookupFile(path.Join(dir, child))
+	}
+
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d.out.WriteString("DENT")
+		d.writeFileInfo(entries[name])
+		d.writeInt32(uint32(len(name)))
+		d.out.WriteString(name)
+	}
+	d.out.WriteString(wire.StatusSyncDone)
+	d.out.Write(make([]byte, 16))
+}
+
+func (d *mockSyncDevice) writeFile(name string) {
+	file := d.server.lookupFile(name)
+	if file == nil || file.Mode.IsDir() {
+		d.writeFail("No such file or directory")
+		return
+	}
+	for data := file.Data; len(data) > 0; {
+		chunk := data
+		if len(chunk) > wire.SyncMaxChunkSize {
+			chunk = chunk[:wire.SyncMaxChunkSize]
+		}
+		d.out.WriteString(wire.StatusSyncData)
+		d.writeInt32(uint32(len(chunk)))
+		d.out.Write(chunk)
+		data = data[len(chunk):]
+	}
+	d.out.WriteString(wire.StatusSyncDone)
+	d.writeInt32(0)
+}
+
+// startSend parses the "<path>,<mode>" argument of a SEND request.
+func (d *mockSyncDevice) startSend(pathAndMode string) {
+	i := strings.LastIndexByte(pathAndMode, ',')
+	if i < 0 {
+		d.writeFail("invalid SEND request")
+		return
+	}
+	var perm uint32
+	fmt.Sscanf(pathAndMode[i+1:], "%d", &perm)
+	d.sendingPath = pathAndMode[:i]
+	d.sending = &MockFile{Mode: os.FileMode(perm).Perm()}
+}
+
+func (d *mockSyncDevice) finishSend(mtime time.Time) {
+	if d.sending == nil {
+		d.writeFail("DONE without SEND")
+		return
+	}
+	d.sending.ModTime = mtime
+	if d.server.Files == nil {
+		d.server.Files = map[string]*MockFile{}
+	}
+	d.server.Files[d.sendingPath] = d.sending
+	d.sending = nil
+
+	d.out.WriteString(wire.StatusSuccess)
+	d.writeInt32(0)
+}
+
+func (d *mockSyncDevice) writeFail(msg string) {
+	d.out.WriteString(wire.StatusFailure)
+	d.writeInt32(uint32(len(msg)))
+	d.out.WriteString(msg)
+}
+
+// writeFileInfo writes the mode, size and mtime of file, or zeros if it's nil, which is how
+// adbd reports missing files.
+func (d *mockSyncDevice) writeFileInfo(file *MockFile) {
+	if file == nil {
+		d.out.Write(make([]byte, 12))
+		return
+	}
+
+	mode := wire.ModeRegular | uint32(file.Mode.Perm())
+	if file.Mode.IsDir() {
+		mode = wire.ModeDir | uint32(file.Mode.Perm())
+	} else if file.Mode&os.ModeSymlink != 0 {
+		mode = wire.ModeSymlink | 0777
+	} else if file.Mode == 0 {
+		mode = wire.ModeRegular | 0644
+	}
+	d.writeInt32(mode)
+	d.writeInt32(uint32(len(file.Data)))
+	d.writeInt32(uint32(file.ModTime.Unix()))
+}
+
+func (d *mockSyncDevice) writeInt32(v uint32) {
+	binary.Write(&d.out, binary.LittleEndian, v)
+}
+
+// lookupFile returns the file at name, a directory if name is the parent of any file, or nil.
+func (s *MockServer) lookupFile(name string) *MockFile {
+	name = path.Clean(name)
+	if file, ok := s.Files[name]; ok {
+		return file
+	}
+	for other := range s.Files {
+		if strings.HasPrefix(other, name+"/") || name == "/" {
+			return &MockFile{Mode: os.ModeDir | 0755}
+		}
+	}
+	return nil
+}
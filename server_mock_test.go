@@ -2,6 +2,7 @@ package adb
 
 import (
 	"io"
+	"io/ioutil"
 	"strings"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
@@ -26,12 +27,68 @@ type MockServer struct {
 
 	// Each time an operation is performed, its name is appended to this slice.
 	Trace []string
+
+	// Output of shell: and exec: commands, keyed by command line. Commands not in this map
+	// read from Messages instead.
+	ShellOutputs map[string]string
+	// Output of the current shell: or exec: command, if it's in ShellOutputs.
+	stream io.Reader
+
+	// Files on the simulated device, keyed by absolute path, read and written by sync: requests.
+	// Directories are implied by the files they contain.
+	Files map[string]*MockFile
+	// Each sync request is appended to this slice as "<ID> <argument>", e.g. "STAT /sdcard".
+	SyncRequests []string
+	// Device end of the current sync: connection.
+	sync *mockSyncDevice
+
+	// Returned by retryPolicy, timeouts, and tools.
+	Retry    RetryPolicy
+	Timeouts Timeouts
+	Tools    HostTools
+
+	// Version checked by requests that need a newer server, the latest if 0. ServerVersion
+	// reads Messages instead.
+	Version int
+	caps    *serverCapabilities
+
+	// Returned by registry.
+	Registry *DeviceRegistry
 }
 
 func (s *MockServer) NoServer() bool {
-	panic("implement me")
+	return false
+}
+
+func (s *MockServer) retryPolicy() RetryPolicy {
+	return s.Retry
+}
+
+func (s *MockServer) timeouts() Timeouts {
+	return s.Timeouts
+}
+
+func (s *MockServer) tools() HostTools {
+	return s.Tools
+}
+
+func (s *MockServer) capabilities() *serverCapabilities {
+	if s.caps == nil {
+		s.caps = &serverCapabilities{version: s.Version}
+		if s.caps.version == 0 {
+			s.caps.version = mockServerVersion
+		}
+	}
+	return s.caps
+}
+
+func (s *MockServer) registry() *DeviceRegistry {
+	return s.Registry
 }
 
+// mockServerVersion is the version of adb 1.0.41, which supports every request.
+const mockServerVersion = 41
+
 var _ server = &MockServer{}
 
 func (s *MockServer) Dial() (*wire.Conn, error) {
@@ -74,6 +131,11 @@ func (s *MockServer) ReadUntilEof() ([]byte, error) {
 		return nil, err
 	}
 
+	if s.stream != nil {
+		data, _ := ioutil.ReadAll(s.stream)
+		return data, nil
+	}
+
 	var data []string
 	for ; s.nextMsgIndex < len(s.Messages); s.nextMsgIndex++ {
 		data = append(data, s.Messages[s.nextMsgIndex])
@@ -81,23 +143,72 @@ func (s *MockServer) ReadUntilEof() ([]byte, error) {
 	return []byte(strings.Join(data, "")), nil
 }
 
+// Read returns the remaining messages concatenated, without length headers.
+func (s *MockServer) Read(p []byte) (int, error) {
+	s.logMethod("Read")
+	if err := s.getNextErrToReturn(); err != nil {
+		return 0, err
+	}
+	if s.stream != nil {
+		return s.stream.Read(p)
+	}
+	if s.nextMsgIndex >= len(s.Messages) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, s.Messages[s.nextMsgIndex])
+	if n < len(s.Messages[s.nextMsgIndex]) {
+		s.Messages[s.nextMsgIndex] = s.Messages[s.nextMsgIndex][n:]
+	} else {
+		s.nextMsgIndex++
+	}
+	return n, nil
+}
+
+// Write appends p to Requests.
+func (s *MockServer) Write(p []byte) (int, error) {
+	s.logMethod("Write")
+	if err := s.getNextErrToReturn(); err != nil {
+		return 0, err
+	}
+	s.Requests = append(s.Requests, string(p))
+	return len(p), nil
+}
+
 func (s *MockServer) SendMessage(msg []byte) error {
 	s.logMethod("SendMessage")
 	if err := s.getNextErrToReturn(); err != nil {
 		return err
 	}
 	s.Requests = append(s.Requests, string(msg))
+
+	req := string(msg)
+	s.stream = nil
+	for _, prefix := range []string{"shell:", "exec:"} {
+		if output, ok := s.ShellOutputs[strings.TrimPrefix(req, prefix)]; ok && strings.HasPrefix(req, prefix) {
+			s.stream = strings.NewReader(output)
+		}
+	}
+	if req == "sync:" {
+		s.sync = newMockSyncDevice(s)
+	}
 	return nil
 }
 
 func (s *MockServer) NewSyncScanner() wire.SyncScanner {
 	s.logMethod("NewSyncScanner")
-	return nil
+	if s.sync == nil {
+		return nil
+	}
+	return wire.NewSyncScanner(s.sync)
 }
 
 func (s *MockServer) NewSyncSender() wire.SyncSender {
 	s.logMethod("NewSyncSender")
-	return nil
+	if s.sync == nil {
+		return nil
+	}
+	return wire.NewSyncSender(s.sync)
 }
 
 func (s *MockServer) Close() error {
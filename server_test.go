@@ -2,9 +2,12 @@ package adb
 
 import (
 	"fmt"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/internal/errors"
 	"github.com/zach-klippenstein/goadb/wire"
 )
 
@@ -22,18 +25,58 @@ func TestNewServer_ZeroConfig(t *testing.T) {
 			}
 			return fmt.Errorf("wrong path: %s", path)
 		},
+		Getenv: noEnv,
 	}}
 
 	serverIf, err := newServer(config)
 	server := serverIf.(*realServer)
 	assert.NoError(t, err)
 	assert.IsType(t, tcpDialer{}, server.config.Dialer)
-	assert.Equal(t, "localhost", server.config.Host)
+	assert.Equal(t, "127.0.0.1", server.config.Host)
 	assert.Equal(t, AdbPort, server.config.Port)
-	assert.Equal(t, fmt.Sprintf("localhost:%d", AdbPort), server.address)
+	assert.Equal(t, fmt.Sprintf("127.0.0.1:%d", AdbPort), server.address)
 	assert.Equal(t, "/bin/adb", server.config.PathToAdb)
 }
 
+func noEnv(key string) string {
+	return ""
+}
+
+func TestNewServer_Env(t *testing.T) {
+	env := map[string]string{
+		"ANDROID_HOME":            "/sdk",
+		"ANDROID_ADB_SERVER_PORT": "5038",
+	}
+	config := ServerConfig{fs: &filesystem{
+		LookPath: func(name string) (string, error) {
+			return "", fmt.Errorf("executable not found: %s", name)
+		},
+		IsExecutableFile: func(path string) error {
+			if path == "/sdk/platform-tools/adb" || path == "/opt/adb" {
+				return nil
+			}
+			return fmt.Errorf("wrong path: %s", path)
+		},
+		Getenv: func(key string) string {
+			return env[key]
+		},
+	}}
+
+	serverIf, err := newServer(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "/sdk/platform-tools/adb", serverIf.(*realServer).config.PathToAdb)
+	assert.Equal(t, "127.0.0.1:5038", serverIf.(*realServer).address)
+
+	env["ADB"] = "/opt/adb"
+	serverIf, err = newServer(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "/opt/adb", serverIf.(*realServer).config.PathToAdb)
+
+	env["ANDROID_ADB_SERVER_PORT"] = "adb"
+	_, err = newServer(config)
+	assert.True(t, HasErrCode(err, ServerNotAvailable))
+}
+
 type MockDialer struct{}
 
 func (d MockDialer) Dial(address string) (*wire.Conn, error) {
@@ -71,8 +114,100 @@ func TestNewServer_AdbNotFound(t *testing.T) {
 		LookPath: func(name string) (string, error) {
 			return "", fmt.Errorf("executable not found: %s", name)
 		},
+		Getenv: noEnv,
 	}}
 
 	_, err := newServer(config)
 	assert.EqualError(t, err, "ServerNotAvailable: could not find adb in PATH")
 }
+
+func TestNewServer_NoServerDoesNotNeedAdb(t *testing.T) {
+	config := ServerConfig{NoServer: true, fs: &filesystem{
+		LookPath: func(name string) (string, error) {
+			return "", fmt.Errorf("not found: %s", name)
+		},
+		Getenv: noEnv,
+	}}
+
+	serverIf, err := newServer(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "", serverIf.(*realServer).config.PathToAdb)
+}
+
+type flakyDialer struct {
+	failures int
+	dials    int
+	// Returned by failed dials, connection refused if nil.
+	err error
+}
+
+func (d *flakyDialer) Dial(address string) (*wire.Conn, error) {
+	d.dials++
+	if d.dials <= d.failures {
+		err := d.err
+		if err == nil {
+			err = syscall.ECONNREFUSED
+		}
+		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error dialing %s", address)
+	}
+	return &wire.Conn{}, nil
+}
+
+func TestRealServerDialRetries(t *testing.T) {
+	dialer := &flakyDialer{failures: 4}
+	server, err := newServer(ServerConfig{
+		Dialer:   dialer,
+		NoServer: true,
+		Retry:    RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Nanosecond},
+	})
+	assert.NoError(t, err)
+
+	// Dial doesn't retry on its own, so queries retrying it don't multiply the attempts.
+	_, err = server.Dial()
+	assert.True(t, HasErrCode(err, ServerNotAvailable))
+	assert.Equal(t, 1, dialer.dials)
+	_, err = retryRoundTrip(server, "host:version")
+	assert.True(t, HasErrCode(err, ServerNotAvailable))
+	assert.Equal(t, 3, dialer.dials)
+}
+
+func TestRealServerDialStartsServer(t *testing.T) {
+	dialer := &flakyDialer{failures: 1}
+	var starts [][]string
+	server, err := newServer(ServerConfig{
+		Dialer:    dialer,
+		PathToAdb: "/bin/adb",
+		fs: &filesystem{
+			IsExecutableFile: func(path string) error {
+				return nil
+			},
+			CmdCombinedOutput: func(name string, arg ...string) ([]byte, error) {
+				starts = append(starts, append([]string{name}, arg...))
+				return nil, nil
+			},
+			Getenv: noEnv,
+		},
+	})
+	assert.NoError(t, err)
+
+	conn, err := server.Dial()
+	assert.NoError(t, err)
+	assert.NotNil(t, conn)
+	assert.Equal(t, 2, dialer.dials)
+	assert.Equal(t, [][]string{{"/bin/adb", "-L", "tcp:127.0.0.1:5037", "start-server"}}, starts)
+
+	// Starting another server won't help one that's running but not answering.
+	*dialer = flakyDialer{failures: 1, err: syscall.ETIMEDOUT}
+	_, err = server.Dial()
+	assert.True(t, HasErrCode(err, ServerNotAvailable))
+	assert.Equal(t, 1, dialer.dials)
+	assert.Len(t, starts, 1)
+}
+
+func TestIsLocalHost(t *testing.T) {
+	assert.True(t, isLocalHost("127.0.0.1"))
+	assert.True(t, isLocalHost("localhost"))
+	assert.True(t, isLocalHost("::1"))
+	assert.False(t, isLocalHost("192.168.1.2"))
+	assert.False(t, isLocalHost("example.com"))
+}
@@ -0,0 +1,226 @@
+package adb
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// SettingsNamespace is one of the tables of the Android settings provider.
+type SettingsNamespace string
+
+const (
+	SettingsSystem SettingsNamespace = "system"
+	SettingsSecure SettingsNamespace = "secure"
+	SettingsGlobal SettingsNamespace = "global"
+)
+
+// settingNotSet is printed by settings get when a key has no value.
+const settingNotSet = "null"
+
+/*
+Settings reads and writes values in the Android settings provider.
+To get an instance, call Settings() on a Device.
+
+Corresponds to the command:
+
+	adb shell settings
+*/
+type Settings struct {
+	device *Device
+}
+
+// Settings returns a client for the device's settings provider.
+func (c *Device) Settings() *Settings {
+	return &Settings{device: c}
+}
+
+// Get returns the value of key in namespace, or "" if the key is not set.
+func (s *Settings) Get(namespace SettingsNamespace, key string) (string, error) {
+	value, _, err := s.Lookup(namespace, key)
+	return value, err
+}
+
+// Lookup returns the value of key in namespace, and false if the key is not set.
+func (s *Settings) Lookup(namespace SettingsNamespace, key string) (string, bool, error) {
+	output, err := s.run("get", namespace, key)
+	if err != nil {
+		return "", false, wrapClientError(err, s.device, "Settings.Get(%s, %s)", namespace, key)
+	}
+
+	value := strings.TrimRight(output, "\r\n")
+	if value == settingNotSet {
+		return "", false, nil
+	}
+	return value, true, nil
+}
+
+// GetInt returns the value of key parsed as an integer.
+// Returns a ParseError if the key is not set or isn't an integer.
+func (s *Settings) GetInt(namespace SettingsNamespace, key string) (int, error) {
+	value, err := s.getSet(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, wrapClientError(errors.WrapErrorf(err, errors.ParseError, "invalid int setting %s: %q", key, value),
+			s.device, "Settings.GetInt(%s, %s)", namespace, key)
+	}
+	return n, nil
+}
+
+// GetFloat returns the value of key parsed as a float.
+// Returns a ParseError if the key is not set or isn't a number.
+func (s *Settings) GetFloat(namespace SettingsNamespace, key string) (float64, error) {
+	value, err := s.getSet(namespace, key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, wrapClientError(errors.WrapErrorf(err, errors.ParseError, "invalid float setting %s: %q", key, value),
+			s.device, "Settings.GetFloat(%s, %s)", namespace, key)
+	}
+	return f, nil
+}
+
+// GetBool returns the value of key parsed as a boolean. Android stores booleans as 1 and 0,
+// but true and false are also accepted.
+// Returns a ParseError if the key is not set or isn't a boolean.
+func (s *Settings) GetBool(namespace SettingsNamespace, key string) (bool, error) {
+	value, err := s.getSet(namespace, key)
+	if err != nil {
+		return false, err
+	}
+	switch value {
+	case "1", "true":
+		return true, nil
+	case "0", "false":
+		return false, nil
+	}
+	return false, wrapClientError(errors.Errorf(errors.ParseError, "invalid bool setting %s: %q", key, value),
+		s.device, "Settings.GetBool(%s, %s)", namespace, key)
+}
+
+// Put sets key in namespace to value.
+func (s *Settings) Put(namespace SettingsNamespace, key, value string) error {
+	output, err := s.run("put", namespace, key, value)
+	if err == nil {
+		err = commandOutputError("settings", output)
+	}
+	return wrapClientError(err, s.device, "Settings.Put(%s, %s)", namespace, key)
+}
+
+// PutInt sets key in namespace to the decimal representation of value.
+func (s *Settings) PutInt(namespace SettingsNamespace, key string, value int) error {
+	return s.Put(namespace, key, strconv.Itoa(value))
+}
+
+// PutFloat sets key in namespace to the decimal representation of value.
+func (s *Settings) PutFloat(namespace SettingsNamespace, key string, value float64) error {
+	return s.Put(namespace, key, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// PutBool sets key in namespace to 1 or 0.
+func (s *Settings) PutBool(namespace SettingsNamespace, key string, value bool) error {
+	if value {
+		return s.Put(namespace, key, "1")
+	}
+	return s.Put(namespace, key, "0")
+}
+
+// Delete removes key from namespace. Deleting a key that isn't set is not an error.
+// Before Android 5.0, which has no settings delete, the row is deleted through the
+// settings content provider.
+func (s *Settings) Delete(namespace SettingsNamespace, key string) error {
+	supported, err := s.device.supportsSDK(sdkSettingsDelete)
+	if err != nil {
+		return wrapClientError(err, s.device, "Settings.Delete(%s, %s)", namespace, key)
+	}
+	if !supported {
+		where, err := ContentWhere("name = ?", key)
+		if err != nil {
+			return wrapClientError(err, s.device, "Settings.Delete(%s, %s)", namespace, key)
+		}
+		return s.device.Content().Delete(settingsURI(namespace), where)
+	}
+
+	output, err := s.run("delete", namespace, key)
+	if err == nil {
+		err = commandOutputError("settings", output)
+	}
+	return wrapClientError(err, s.device, "Settings.Delete(%s, %s)", namespace, key)
+}
+
+// List returns all the keys and values set in namespace.
+// Before Android 6.0, which has no settings list, rows are read from the settings
+// content provider.
+func (s *Settings) List(namespace SettingsNamespace) (map[string]string, error) {
+	supported, err := s.device.supportsSDK(sdkSettingsList)
+	if err != nil {
+		return nil, wrapClientError(err, s.device, "Settings.List(%s)", namespace)
+	}
+	if !supported {
+		rows, err := s.device.Content().Query(settingsURI(namespace), ContentQuery{Projection: []string{"name", "value"}})
+		if err != nil {
+			return nil, err
+		}
+		settings := map[string]string{}
+		for _, row := range rows {
+			settings[row["name"]] = row["value"]
+		}
+		return settings, nil
+	}
+
+	output, err := s.run("list", namespace)
+	if err == nil {
+		err = commandOutputError("settings", output)
+	}
+	if err != nil {
+		return nil, wrapClientError(err, s.device, "Settings.List(%s)", namespace)
+	}
+	return parseSettingsList(output), nil
+}
+
+func (s *Settings) getSet(namespace SettingsNamespace, key string) (string, error) {
+	value, ok, err := s.Lookup(namespace, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", wrapClientError(errors.Errorf(errors.ParseError, "setting %s is not set", key),
+			s.device, "Settings.Get(%s, %s)", namespace, key)
+	}
+	return value, nil
+}
+
+func (s *Settings) run(verb string, namespace SettingsNamespace, args ...string) (string, error) {
+	cmdArgs := append([]string{"settings"}, s.device.userArgs()...)
+	cmdArgs = append(cmdArgs, verb, string(namespace))
+	cmdArgs = append(cmdArgs, args...)
+	return s.device.runShellCommand(quoteShellArgs(cmdArgs...))
+}
+
+// settingsURI returns the content provider URI of namespace.
+func settingsURI(namespace SettingsNamespace) string {
+	return "content://settings/" + string(namespace)
+}
+
+// parseSettingsList parses key=value lines printed by settings list.
+// Values may themselves contain '='.
+func parseSettingsList(output string) map[string]string {
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		i := strings.IndexByte(line, '=')
+		if i <= 0 {
+			continue
+		}
+		settings[line[:i]] = line[i+1:]
+	}
+	return settings
+}
@@ -0,0 +1,62 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestSettingsGet(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"1\n"},
+	}
+	settings := (&Adb{s}).Device(DeviceWithSerial("serial")).Settings()
+
+	v, err := settings.GetBool(SettingsGlobal, "adb_enabled")
+	assert.NoError(t, err)
+	assert.True(t, v)
+	assert.Equal(t, []string{"host:transport:serial", "shell:settings get global adb_enabled"}, s.Requests)
+}
+
+func TestSettingsLookupNotSet(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"null\n"},
+	}
+	settings := (&Adb{s}).Device(AnyDevice()).Settings()
+
+	v, ok, err := settings.Lookup(SettingsSecure, "missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "", v)
+}
+
+func TestSettingsPutQuotesValue(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	settings := (&Adb{s}).Device(AnyDevice()).Settings()
+
+	assert.NoError(t, settings.Put(SettingsSystem, "name", "it's mine"))
+	assert.Equal(t, `shell:settings put system name 'it'\''s mine'`, s.Requests[1])
+}
+
+func TestSettingsPutError(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"Invalid namespace 'bogus'\n"},
+	}
+	settings := (&Adb{s}).Device(AnyDevice()).Settings()
+
+	err := settings.Put("bogus", "k", "v")
+	assert.True(t, HasErrCode(err, AdbError))
+}
+
+func TestParseSettingsList(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"adb_enabled":  "1",
+		"device_name":  "Pixel 7",
+		"http_proxy":   "",
+		"bluetooth_on": "a=b",
+	}, parseSettingsList("adb_enabled=1\r\ndevice_name=Pixel 7\nhttp_proxy=\nbluetooth_on=a=b\n\n"))
+}
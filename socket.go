@@ -0,0 +1,106 @@
+package adb
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+/*
+DialLocalAbstract connects to the abstract unix socket name on the device, e.g. an on-device
+agent's socket or chrome_devtools_remote, through the device's transport. Unlike Forward, no
+host port is allocated, and nothing is left behind when the returned connection is closed.
+
+Corresponds to the service localabstract:<name>, which adb forward opens for each connection.
+*/
+func (c *Device) DialLocalAbstract(name string) (net.Conn, error) {
+	if isBlank(name) {
+		return nil, wrapClientError(errors.AssertionErrorf("socket name cannot be empty"), c, "DialLocalAbstract")
+	}
+	conn, err := c.dialSocket("localabstract:" + name)
+	return conn, wrapClientError(err, c, "DialLocalAbstract(%s)", name)
+}
+
+/*
+DialTCPOnDevice connects to port on the device's loopback interface through the device's
+transport, e.g. to reach a server the device only listens for locally. Unlike Forward, no host
+port is allocated, and nothing is left behind when the returned connection is closed.
+
+Corresponds to the service tcp:<port>, which adb forward opens for each connection.
+*/
+func (c *Device) DialTCPOnDevice(port int) (net.Conn, error) {
+	if port <= 0 || port > 65535 {
+		return nil, wrapClientError(errors.AssertionErrorf("invalid port: %d", port), c, "DialTCPOnDevice")
+	}
+	conn, err := c.dialSocket(fmt.Sprintf("tcp:%d", port))
+	return conn, wrapClientError(err, c, "DialTCPOnDevice(%d)", port)
+}
+
+func (c *Device) dialSocket(spec string) (net.Conn, error) {
+	conn, err := c.openService(spec)
+	if err != nil {
+		return nil, err
+	}
+	device := c.descriptor.String()
+	if c.descriptor.descriptorType == DeviceSerial {
+		device = c.descriptor.serial
+	}
+	return &socketConn{
+		conn:   conn,
+		remote: socketAddr(device + "/" + spec),
+	}, nil
+}
+
+// socketConn is a net.Conn to a socket on a device, over a connection to the server.
+type socketConn struct {
+	conn   *wire.Conn
+	remote socketAddr
+}
+
+func (c *socketConn) Read(p []byte) (int, error) {
+	return c.conn.Read(p)
+}
+
+func (c *socketConn) Write(p []byte) (int, error) {
+	return c.conn.Write(p)
+}
+
+func (c *socketConn) Close() error {
+	return c.conn.Close()
+}
+
+// LocalAddr returns "host", since the local end is the adb server's connection to the device.
+func (c *socketConn) LocalAddr() net.Addr {
+	return socketAddr("host")
+}
+
+// RemoteAddr returns the device and socket spec, e.g. "emulator-5554/localabstract:agent".
+func (c *socketConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *socketConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *socketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *socketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// socketAddr is the address of either end of a socketConn.
+type socketAddr string
+
+func (a socketAddr) Network() string {
+	return "adb"
+}
+
+func (a socketAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,66 @@
+package adb
+
+import (
+	stderrors "errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestDialLocalAbstract(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"pong"},
+	}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	conn, err := device.DialLocalAbstract("agent")
+	require.NoError(t, err)
+	assert.Equal(t, "serial/localabstract:agent", conn.RemoteAddr().String())
+	_, err = conn.Write([]byte("ping"))
+	assert.NoError(t, err)
+	data, err := ioutil.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong", string(data))
+	assert.NoError(t, conn.Close())
+	assert.Equal(t, []string{"host:transport:serial", "localabstract:agent", "ping"}, s.Requests)
+
+	_, err = device.DialLocalAbstract(" ")
+	assert.True(t, HasErrCode(err, AssertionError))
+}
+
+func TestDialTCPOnDevice(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(DeviceWithSerial("serial"))
+
+	conn, err := device.DialTCPOnDevice(8080)
+	require.NoError(t, err)
+	conn.Close()
+	assert.Equal(t, []string{"host:transport:serial", "tcp:8080"}, s.Requests)
+
+	_, err = device.DialTCPOnDevice(0)
+	assert.True(t, HasErrCode(err, AssertionError))
+}
+
+func TestSocketConnSeparateDeadlines(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &socketConn{conn: newStreamConn(&timeoutConn{Conn: client})}
+	defer conn.Close()
+	go io.Copy(ioutil.Discard, server)
+
+	// An expired read deadline doesn't stop writes.
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(-time.Second)))
+	_, err := conn.Read(make([]byte, 1))
+	var netErr net.Error
+	require.True(t, stderrors.As(err, &netErr), "%v", err)
+	assert.True(t, netErr.Timeout())
+	_, err = conn.Write([]byte("hello"))
+	assert.NoError(t, err)
+}
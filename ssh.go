@@ -0,0 +1,157 @@
+package adb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+/*
+SSHDialer is a Dialer that reaches an adb server on a remote machine through ssh, so one
+controller can manage the devices plugged into several build machines. The address dialed,
+ServerConfig.Host and Port, is connected to from the remote machine, so the default of
+127.0.0.1:5037 is the remote machine's own server.
+
+E.g.
+
+	client, err := adb.NewWithConfig(adb.ServerConfig{
+		Dialer:   &adb.SSHDialer{Destination: "builder@build-7"},
+		NoServer: true,
+	})
+
+Each connection runs ssh -W, using the ssh binary so keys, agents, and ~/.ssh/config work as
+they do on the command line. Since every request to the server is a new connection, set up
+connection sharing for the destination in ~/.ssh/config, e.g. "ControlMaster auto",
+"ControlPath ~/.ssh/%C", and "ControlPersist 10m", so requests don't each log in.
+*/
+type SSHDialer struct {
+	// Machine to connect to, as passed to ssh, e.g. "user@host" or a Host from ~/.ssh/config.
+	Destination string
+	// Options passed to ssh before the destination, e.g. "-p", "2222", "-i", "lab_key".
+	Args []string
+	// Time to wait for the ssh connection, ssh's default if 0.
+	ConnectTimeout time.Duration
+	// Path to the ssh executable. If empty, PATH is searched.
+	SSHPath string
+}
+
+// Dial connects to address, as seen from Destination.
+func (d *SSHDialer) Dial(address string) (*wire.Conn, error) {
+	stream, err := d.dialStream(address)
+	if err != nil {
+		return nil, err
+	}
+	return newStreamConn(stream), nil
+}
+
+func (d *SSHDialer) dialStream(address string) (*sshStream, error) {
+	if d.Destination == "" {
+		return nil, errors.AssertionErrorf("SSHDialer.Destination must be set")
+	}
+	sshPath := d.SSHPath
+	if sshPath == "" {
+		sshPath = "ssh"
+	}
+	args := []string{"-W", address, "-o", "BatchMode=yes"}
+	if d.ConnectTimeout > 0 {
+		args = append(args, "-o", fmt.Sprintf("ConnectTimeout=%d", int((d.ConnectTimeout+time.Second-1)/time.Second)))
+	}
+	// Ends the options, so ssh doesn't take a Destination starting with - for one.
+	args = append(append(args, d.Args...), "--", d.Destination)
+
+	stream := &sshStream{destination: d.Destination, address: address, cmd: exec.Command(sshPath, args...)}
+	stream.cmd.Stderr = &stream.stderr
+	stdin, err := stream.cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error running ssh")
+	}
+	stdout, err := stream.cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error running ssh")
+	}
+	stream.stdin, stream.stdout = stdin, stdout
+	if err := stream.cmd.Start(); err != nil {
+		return nil, errors.WrapErrorf(err, errors.ServerNotAvailable, "error running %s", sshPath)
+	}
+	return stream, nil
+}
+
+// sshStream is a connection forwarded by an ssh -W process, over its stdin and stdout.
+type sshStream struct {
+	destination string
+	address     string
+	cmd         *exec.Cmd
+	stdin       io.WriteCloser
+	stdout      io.Reader
+	stderr      bytes.Buffer
+
+	// Set once something was read, after which EOF is the connection being closed rather than ssh
+	// failing to connect.
+	connected bool
+	waitOnce  sync.Once
+}
+
+func (s *sshStream) Read(p []byte) (int, error) {
+	n, err := s.stdout.Read(p)
+	if n > 0 {
+		s.connected = true
+	}
+	if err == io.EOF && !s.connected {
+		// ssh prints why it failed, e.g. "connect failed: Connection refused", and exits.
+		s.wait()
+		if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+			return n, errors.Errorf(errors.ServerNotAvailable, "error dialing %s via ssh to %s: %s",
+				s.address, s.destination, msg)
+		}
+	}
+	return n, err
+}
+
+func (s *sshStream) Write(p []byte) (int, error) {
+	n, err := s.stdin.Write(p)
+	if err != nil {
+		return n, errors.WrapErrorf(err, errors.ConnectionResetError, "ssh to %s exited", s.destination)
+	}
+	return n, nil
+}
+
+// Close ends the ssh process. It's safe to call more than once.
+func (s *sshStream) Close() error {
+	s.stdin.Close()
+	// Fails if ssh already exited, which is fine.
+	s.cmd.Process.Kill()
+	s.wait()
+	return nil
+}
+
+// wait waits for ssh to exit, after which stderr is complete.
+func (s *sshStream) wait() {
+	s.waitOnce.Do(func() {
+		s.cmd.Wait()
+	})
+}
+
+/*
+ForwardSSH listens on a local port, and forwards each connection to it to address, as seen from
+the SSHDialer's Destination, until the returned listener is closed. Use it to reach a device's
+adbd directly, e.g. a device on the remote machine's network, or plugged into it and switched to
+adb tcpip, and connect the local server to it:
+
+	listener, err := adb.ForwardSSH(&adb.SSHDialer{Destination: "build-7"}, "192.168.1.20:5555")
+	defer listener.Close()
+	addr := listener.Addr().(*net.TCPAddr)
+	err = client.Connect(addr.IP.String(), addr.Port)
+*/
+func ForwardSSH(dialer *SSHDialer, address string) (net.Listener, error) {
+	return listenAndForward(func() (io.ReadWriteCloser, error) {
+		return dialer.dialStream(address)
+	})
+}
@@ -0,0 +1,62 @@
+package adb
+
+import (
+	"strconv"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// StatusBarAction is an action on the status bar panels.
+type StatusBarAction int
+
+const (
+	StatusBarExpandNotifications StatusBarAction = iota
+	StatusBarExpandSettings
+	StatusBarCollapse
+)
+
+/*
+SetStatusBar expands the notification or quick settings panel, or collapses both.
+
+On API 26+ this uses cmd statusbar, older versions call the statusbar service directly.
+
+Corresponds to the command:
+
+	adb shell cmd statusbar expand-notifications|expand-settings|collapse
+*/
+func (c *Device) SetStatusBar(action StatusBarAction) error {
+	cmdLine, err := c.statusBarCommand(action)
+	if err == nil {
+		var output string
+		output, err = c.runShellCommand(quoteShellArgs(cmdLine...))
+		if err == nil {
+			err = commandOutputError("statusbar", output)
+		}
+	}
+	return wrapClientError(err, c, "SetStatusBar(%d)", action)
+}
+
+func (c *Device) statusBarCommand(action StatusBarAction) ([]string, error) {
+	var verb string
+	var code int
+	switch action {
+	case StatusBarExpandNotifications:
+		verb, code = "expand-notifications", 1
+	case StatusBarExpandSettings:
+		verb, code = "expand-settings", 3
+	case StatusBarCollapse:
+		verb, code = "collapse", 2
+	default:
+		return nil, errors.AssertionErrorf("invalid status bar action: %d", action)
+	}
+
+	useCmd, err := c.supportsSDK(sdkCmdStatusBar)
+	if err != nil {
+		return nil, err
+	}
+	if useCmd {
+		return []string{"cmd", "statusbar", verb}, nil
+	}
+	// Transaction codes of IStatusBarService before Android 8.0.
+	return []string{"service", "call", "statusbar", strconv.Itoa(code)}, nil
+}
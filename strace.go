@@ -0,0 +1,273 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// devicePushedStracePath is where the strace binary is pushed to.
+const devicePushedStracePath = "/data/local/tmp/goadb-strace"
+
+// Matches a line of strace -f -ttt -T, e.g.
+// "[pid  1234] 1600000000.123456 openat(AT_FDCWD, "/x", O_RDONLY) = 3 <0.000021>", or the
+// "<... read resumed>" half of a call another thread interrupted.
+var straceLinePattern = regexp.MustCompile(`^(?:\[pid\s+(\d+)\] )?(\d+)\.(\d{6}) (?:<\.\.\. (\w+) resumed>|(\w+)\()?`)
+
+// Matches the result and duration at the end of a finished call, e.g. " = -1 ENOENT (No such
+// file or directory) <0.000021>". The last " = " is the result's, arguments may contain others.
+var straceResultPattern = regexp.MustCompile(`^.* = (.*?)(?: <(\d+)\.(\d{6})>)?$`)
+
+// StraceOptions configures Strace. The zero value traces every syscall.
+type StraceOptions struct {
+	// Syscalls to trace, as a strace -e trace= expression, e.g. "openat,close" or "%file".
+	Filter string
+	// Maximum length of the strings printed in arguments, strace's default of 32 if 0.
+	StringLimit int
+}
+
+// SyscallTrace is a line of strace output: a syscall, a signal, or an exit.
+type SyscallTrace struct {
+	Time time.Time
+	// Thread that made the call.
+	TID int
+	// Name of the syscall, empty for signals and exits.
+	Syscall string
+	// Return value, e.g. "3" or "-1 ENOENT (No such file or directory)". Empty if the call
+	// hasn't returned yet, i.e. Unfinished.
+	Result string
+	// Time spent in the call.
+	Duration time.Duration
+	// True if another thread's call was printed before the call returned. The rest of it is
+	// sent later, as a trace with the same Syscall and TID.
+	Unfinished bool
+	// The whole line.
+	Text string
+}
+
+// StraceWatcher publishes the syscalls of a process traced with strace.
+type StraceWatcher struct {
+	traceChan chan SyscallTrace
+	// If an error occurs, it is stored here and traceChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get traces. It's closed when the context
+// passed to Strace is done, when the process exits, or if an error occurs.
+func (w *StraceWatcher) C() <-chan SyscallTrace {
+	return w.traceChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (w *StraceWatcher) Err() error {
+	if err, ok := w.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+Strace attaches strace to every thread of the process pid, and sends the syscalls it makes
+until ctx is done, at which point strace is interrupted so it detaches and the process carries on
+untraced. Requires root, through adbd or su; see HostTools for devices without strace.
+
+E.g. to find the files an app fails to open:
+
+	watcher, err := device.Strace(ctx, pid, adb.StraceOptions{Filter: "openat"})
+	for trace := range watcher.C() {
+		if strings.HasPrefix(trace.Result, "-1") {
+			fmt.Println(trace.Text)
+		}
+	}
+
+Corresponds to the command:
+
+	adb shell su 0 strace -f -ttt -T [-e trace=<filter>] [-s <limit>] -p <pid>
+*/
+func (c *Device) Strace(ctx context.Context, pid int, opts StraceOptions) (*StraceWatcher, error) {
+	watcher, err := c.strace(ctx, pid, opts)
+	return watcher, wrapClientError(err, c, "Strace(%d)", pid)
+}
+
+/*
+StracePackage is Strace for the main process of the app pkg. It returns a FileNoExistError if
+the app isn't running.
+*/
+func (c *Device) StracePackage(ctx context.Context, pkg string, opts StraceOptions) (*StraceWatcher, error) {
+	pids, err := c.PidOf(pkg)
+	if err != nil {
+		return nil, wrapClientError(err, c, "StracePackage(%s)", pkg)
+	}
+	if len(pids) == 0 {
+		return nil, wrapClientError(errors.Errorf(errors.FileNoExistError, "%s isn't running", pkg),
+			c, "StracePackage(%s)", pkg)
+	}
+	watcher, err := c.strace(ctx, pids[0], opts)
+	return watcher, wrapClientError(err, c, "StracePackage(%s)", pkg)
+}
+
+func (c *Device) strace(ctx context.Context, pid int, opts StraceOptions) (*StraceWatcher, error) {
+	strace, err := c.straceCommand()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{strace, "-f", "-ttt", "-T"}
+	if opts.Filter != "" {
+		args = append(args, "-e", "trace="+opts.Filter)
+	}
+	if opts.StringLimit > 0 {
+		args = append(args, "-s", strconv.Itoa(opts.StringLimit))
+	}
+	args = append(args, "-p", strconv.Itoa(pid))
+	// The shell prints its pid, which strace takes over, so it can be interrupted to detach.
+	script := "echo $$; exec " + quoteShellArgs(args...) + " 2>&1"
+
+	root, err := c.IsRoot()
+	if err != nil {
+		return nil, err
+	}
+	cmdLine := script
+	if !root {
+		cmdLine = quoteShellArgs("su", "0", "sh", "-c", script)
+	}
+	conn, err := c.openService("shell:" + cmdLine)
+	if err != nil {
+		return nil, err
+	}
+	// The connection is only closed once strace has been interrupted, see below.
+	detachCtx, detach := context.WithCancel(context.Background())
+	stream := newContextReader(detachCtx, conn)
+	reader := bufio.NewReader(stream)
+	first, _ := reader.ReadString('\n')
+	stracePid, err := strconv.Atoi(strings.TrimSpace(first))
+	if err != nil {
+		detach()
+		stream.Close()
+		return nil, straceOutputError(first)
+	}
+
+	watcher := &StraceWatcher{traceChan: make(chan SyscallTrace)}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Closing the connection first may kill strace before it detaches, which leaves
+			// threads that were in a syscall stopped on some kernels.
+			c.runRootShellCommand("kill -INT " + strconv.Itoa(stracePid))
+			detach()
+		case <-done:
+		}
+	}()
+	go func() {
+		defer close(watcher.traceChan)
+		defer close(done)
+		defer detach()
+		defer stream.Close()
+
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := strings.TrimRight(scanner.Text(), "\r")
+			if strings.HasPrefix(line, "strace: ") {
+				if err := straceOutputError(line); err != nil {
+					watcher.err.Store(wrapClientError(err, c, "Strace(%d)", pid))
+					return
+				}
+				continue
+			}
+			trace, ok := parseStraceLine(line, pid)
+			if !ok || ctx.Err() != nil {
+				// Once ctx is done, the output is drained until strace detaches.
+				continue
+			}
+			select {
+			case watcher.traceChan <- trace:
+			case <-ctx.Done():
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			watcher.err.Store(wrapClientError(err, c, "Strace(%d)", pid))
+		}
+	}()
+	return watcher, nil
+}
+
+// straceCommand returns the path of strace on the device, pushing it if needed.
+func (c *Device) straceCommand() (string, error) {
+	for _, cmd := range []string{"strace", devicePushedStracePath} {
+		output, err := c.runShellCommand("command -v " + quoteShellArg(cmd))
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(output) != "" {
+			return cmd, nil
+		}
+	}
+
+	strace := c.server.tools().Strace
+	if strace == "" {
+		return "", errors.Errorf(errors.AdbError, "strace not found on device, and ServerConfig.Tools.Strace not set")
+	}
+	if err := c.pushTool("strace", strace, devicePushedStracePath); err != nil {
+		return "", err
+	}
+	return devicePushedStracePath, nil
+}
+
+// straceOutputError returns an error for a line strace or su printed instead of a trace. Notes
+// like "strace: Process 1234 attached" aren't errors.
+func straceOutputError(line string) error {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "strace: Process "):
+		return nil
+	case strings.Contains(line, "not permitted") || strings.Contains(line, "Permission denied") ||
+		strings.Contains(line, "su: "):
+		return errors.Errorf(errors.PermissionDenied, "root is required: %s", line)
+	case strings.Contains(line, "No such process"):
+		return errors.Errorf(errors.FileNoExistError, "%s", line)
+	case line == "":
+		return errors.Errorf(errors.AdbError, "strace exited without output")
+	}
+	return errors.Errorf(errors.AdbError, "%s", line)
+}
+
+// parseStraceLine parses a line of strace -f -ttt -T. Lines without a "[pid N]" prefix are from
+// pid, since strace only prints it once it traces more than one thread.
+func parseStraceLine(line string, pid int) (SyscallTrace, bool) {
+	match := straceLinePattern.FindStringSubmatch(line)
+	if match == nil {
+		return SyscallTrace{}, false
+	}
+	trace := SyscallTrace{TID: pid, Text: line, Syscall: match[4] + match[5]}
+	if match[1] != "" {
+		trace.TID, _ = strconv.Atoi(match[1])
+	}
+	seconds, _ := strconv.ParseInt(match[2], 10, 64)
+	micros, _ := strconv.ParseInt(match[3], 10, 64)
+	trace.Time = time.Unix(seconds, micros*int64(time.Microsecond))
+
+	if trace.Syscall == "" {
+		// A "--- SIGCHLD {...} ---" signal or "+++ exited with 0 +++" exit.
+		return trace, true
+	}
+	if strings.HasSuffix(line, "<unfinished ...>") {
+		trace.Unfinished = true
+		return trace, true
+	}
+	if result := straceResultPattern.FindStringSubmatch(line[len(match[0]):]); result != nil {
+		trace.Result = result[1]
+		if result[2] != "" {
+			seconds, _ := strconv.ParseInt(result[2], 10, 64)
+			micros, _ := strconv.ParseInt(result[3], 10, 64)
+			trace.Duration = time.Duration(seconds)*time.Second + time.Duration(micros)*time.Microsecond
+		}
+	}
+	return trace, true
+}
@@ -0,0 +1,97 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseStraceLine(t *testing.T) {
+	line := `[pid  1240] 1600000000.000250 openat(AT_FDCWD, "/data/a = b", O_RDONLY) = -1 ENOENT (No such file or directory) <0.000021>`
+	trace, ok := parseStraceLine(line, 1234)
+	require.True(t, ok)
+	assert.Equal(t, SyscallTrace{
+		Time:     time.Unix(1600000000, 250000),
+		TID:      1240,
+		Syscall:  "openat",
+		Result:   "-1 ENOENT (No such file or directory)",
+		Duration: 21 * time.Microsecond,
+		Text:     line,
+	}, trace)
+
+	trace, ok = parseStraceLine(`1600000000.500000 read(3,  <unfinished ...>`, 1234)
+	require.True(t, ok)
+	assert.Equal(t, 1234, trace.TID)
+	assert.Equal(t, "read", trace.Syscall)
+	assert.True(t, trace.Unfinished)
+
+	trace, ok = parseStraceLine(`[pid  1234] 1600000001.000000 <... read resumed>"ok", 10) = 2 <0.500000>`, 1234)
+	require.True(t, ok)
+	assert.Equal(t, "read", trace.Syscall)
+	assert.Equal(t, "2", trace.Result)
+	assert.Equal(t, 500*time.Millisecond, trace.Duration)
+
+	trace, ok = parseStraceLine(`1600000002.000000 --- SIGCHLD {si_signo=SIGCHLD} ---`, 1234)
+	require.True(t, ok)
+	assert.Equal(t, "", trace.Syscall)
+
+	_, ok = parseStraceLine("strace: Process 1234 attached", 1234)
+	assert.False(t, ok)
+}
+
+func TestStraceOutputError(t *testing.T) {
+	assert.NoError(t, straceOutputError("strace: Process 1234 attached with 3 threads"))
+	assert.True(t, HasErrCode(straceOutputError(
+		"strace: attach: ptrace(PTRACE_SEIZE, 1234): Operation not permitted"), PermissionDenied))
+	assert.True(t, HasErrCode(straceOutputError(
+		"strace: attach: ptrace(PTRACE_SEIZE, 1234): No such process"), FileNoExistError))
+	assert.True(t, HasErrCode(straceOutputError("/system/bin/sh: su: not found\n"), PermissionDenied))
+	assert.True(t, HasErrCode(straceOutputError("strace: invalid system call 'foo'"), AdbError))
+}
+
+func TestStrace(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"command -v strace": "/system/bin/strace\n",
+			"id -u":             "0\n",
+			"echo $$; exec strace -f -ttt -T -e trace=openat -p 1234 2>&1": "5678\n" +
+				"strace: Process 1234 attached\n" +
+				"1600000000.000000 openat(AT_FDCWD, \"/x\", O_RDONLY) = 3 <0.000010>\n" +
+				"1600000000.100000 +++ exited with 0 +++\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	watcher, err := device.Strace(context.Background(), 1234, StraceOptions{Filter: "openat"})
+	require.NoError(t, err)
+	var syscalls []string
+	for trace := range watcher.C() {
+		syscalls = append(syscalls, trace.Syscall)
+	}
+	assert.NoError(t, watcher.Err())
+	assert.Equal(t, []string{"openat", ""}, syscalls)
+}
+
+func TestStraceWithoutRoot(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"command -v strace": "/system/bin/strace\n",
+			"id -u":             "2000\n",
+			"su 0 sh -c 'echo $$; exec strace -f -ttt -T -p 1234 2>&1'": "/system/bin/sh: su: not found\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.Strace(context.Background(), 1234, StraceOptions{})
+	assert.True(t, HasErrCode(err, PermissionDenied))
+
+	s.ShellOutputs["command -v strace"] = ""
+	_, err = device.Strace(context.Background(), 1234, StraceOptions{})
+	assert.Contains(t, ErrorWithCauseChain(err), "ServerConfig.Tools.Strace not set")
+}
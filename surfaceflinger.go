@@ -0,0 +1,147 @@
+package adb
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// FrameTiming is the timing of a frame of a SurfaceFlinger layer. Times are on the device's
+// monotonic clock, since it booted.
+type FrameTiming struct {
+	// When the app wanted the frame shown.
+	DesiredPresent time.Duration
+	// When the frame was shown.
+	ActualPresent time.Duration
+	// When the GPU finished rendering the frame.
+	FrameReady time.Duration
+}
+
+// FrameLatency is the timing of the last frames of a SurfaceFlinger layer, up to 127.
+type FrameLatency struct {
+	// Time between refreshes of the display, e.g. 16.6ms at 60Hz.
+	RefreshPeriod time.Duration
+	// Oldest first. Frames that haven't been shown yet are left out.
+	Frames []FrameTiming
+}
+
+// FPS returns the rate the frames were shown at, or 0 if there are fewer than two.
+func (l *FrameLatency) FPS() float64 {
+	if len(l.Frames) < 2 {
+		return 0
+	}
+	elapsed := l.Frames[len(l.Frames)-1].ActualPresent - l.Frames[0].ActualPresent
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(len(l.Frames)-1) / elapsed.Seconds()
+}
+
+// JankyFrames returns the number of frames shown more than one and a half refresh periods
+// after the previous one, i.e. that missed at least one refresh.
+func (l *FrameLatency) JankyFrames() int {
+	janky := 0
+	for i := 1; i < len(l.Frames); i++ {
+		if l.Frames[i].ActualPresent-l.Frames[i-1].ActualPresent > l.RefreshPeriod*3/2 {
+			janky++
+		}
+	}
+	return janky
+}
+
+/*
+SurfaceFlingerLayers returns the names of the layers SurfaceFlinger is compositing. Apps have a
+layer per window, named after the activity, e.g.
+"com.example/com.example.MainActivity#0", and games and video players that draw to a
+SurfaceView have another, e.g. "SurfaceView[com.example/com.example.MainActivity]#0".
+
+Corresponds to the command:
+
+	adb shell dumpsys SurfaceFlinger --list
+*/
+func (c *Device) SurfaceFlingerLayers() ([]string, error) {
+	output, err := c.runShellCommand("dumpsys SurfaceFlinger --list")
+	if err != nil {
+		return nil, wrapClientError(err, c, "SurfaceFlingerLayers")
+	}
+	var layers []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			layers = append(layers, line)
+		}
+	}
+	return layers, nil
+}
+
+/*
+FrameLatency returns the timing of the last frames of layer, one of the SurfaceFlingerLayers.
+It measures frame rate and jank from what was composited, so it works for apps that render to
+a SurfaceView, e.g. with OpenGL or Vulkan, which gfxinfo doesn't see. Call ClearFrameLatency
+before the interaction to measure, and FrameLatency within about two seconds after, since only
+127 frames are kept.
+
+Corresponds to the command:
+
+	adb shell dumpsys SurfaceFlinger --latency <layer>
+*/
+func (c *Device) FrameLatency(layer string) (*FrameLatency, error) {
+	output, err := c.runShellCommand(quoteShellArgs("dumpsys", "SurfaceFlinger", "--latency", layer))
+	if err != nil {
+		return nil, wrapClientError(err, c, "FrameLatency(%s)", layer)
+	}
+	latency, err := parseFrameLatency(output)
+	return latency, wrapClientError(err, c, "FrameLatency(%s)", layer)
+}
+
+/*
+ClearFrameLatency discards the frame timings SurfaceFlinger has kept for layer, so the next
+FrameLatency only reports frames shown after this call.
+
+Corresponds to the command:
+
+	adb shell dumpsys SurfaceFlinger --latency-clear <layer>
+*/
+func (c *Device) ClearFrameLatency(layer string) error {
+	output, err := c.runShellCommand(quoteShellArgs("dumpsys", "SurfaceFlinger", "--latency-clear", layer))
+	if err == nil {
+		err = commandOutputError("dumpsys SurfaceFlinger --latency-clear", output)
+	}
+	return wrapClientError(err, c, "ClearFrameLatency(%s)", layer)
+}
+
+// parseFrameLatency parses dumpsys SurfaceFlinger --latency: the refresh period, followed by a
+// "desired actual ready" line of nanosecond times per frame. Frames not shown yet have an actual
+// time of INT64_MAX, and unused slots are all zeroes.
+func parseFrameLatency(output string) (*FrameLatency, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	period, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "invalid refresh period: %q", lines[0])
+	}
+
+	latency := &FrameLatency{RefreshPeriod: time.Duration(period)}
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		var times [3]int64
+		for i, field := range fields {
+			if times[i], err = strconv.ParseInt(field, 10, 64); err != nil {
+				return nil, errors.WrapErrorf(err, errors.ParseError, "invalid frame timing: %q", line)
+			}
+		}
+		if times[1] == 0 || times[1] == math.MaxInt64 {
+			continue
+		}
+		latency.Frames = append(latency.Frames, FrameTiming{
+			DesiredPresent: time.Duration(times[0]),
+			ActualPresent:  time.Duration(times[1]),
+			FrameReady:     time.Duration(times[2]),
+		})
+	}
+	return latency, nil
+}
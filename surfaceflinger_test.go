@@ -0,0 +1,60 @@
+package adb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseFrameLatency(t *testing.T) {
+	latency, err := parseFrameLatency(`16666666
+0	0	0
+1000000000	1000000000	990000000
+1016666666	1016666666	1010000000
+1033333333	1050000000	1045000000
+1066666666	9223372036854775807	1060000000
+`)
+	require.NoError(t, err)
+	assert.Equal(t, 16666666*time.Nanosecond, latency.RefreshPeriod)
+	assert.Equal(t, []FrameTiming{
+		{DesiredPresent: time.Second, ActualPresent: time.Second, FrameReady: 990 * time.Millisecond},
+		{DesiredPresent: 1016666666, ActualPresent: 1016666666, FrameReady: 1010 * time.Millisecond},
+		{DesiredPresent: 1033333333, ActualPresent: 1050 * time.Millisecond, FrameReady: 1045 * time.Millisecond},
+	}, latency.Frames)
+	assert.InDelta(t, 40.0, latency.FPS(), 0.001)
+	assert.Equal(t, 1, latency.JankyFrames())
+
+	latency, err = parseFrameLatency("16666666\n")
+	require.NoError(t, err)
+	assert.Empty(t, latency.Frames)
+	assert.Equal(t, 0.0, latency.FPS())
+
+	_, err = parseFrameLatency("Can't find service: SurfaceFlinger\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestSurfaceFlingerLayers(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dumpsys SurfaceFlinger --list": "com.example/com.example.MainActivity#0\r\n" +
+				"SurfaceView[com.example/com.example.MainActivity]#0\r\n\r\n",
+			"dumpsys SurfaceFlinger --latency 'SurfaceView[com.example/com.example.MainActivity]#0'": "16666666\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	layers, err := device.SurfaceFlingerLayers()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"com.example/com.example.MainActivity#0",
+		"SurfaceView[com.example/com.example.MainActivity]#0",
+	}, layers)
+
+	latency, err := device.FrameLatency(layers[1])
+	require.NoError(t, err)
+	assert.Equal(t, 16666666*time.Nanosecond, latency.RefreshPeriod)
+}
@@ -0,0 +1,44 @@
+package adb
+
+import (
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+/*
+ReadLink returns the target of the symbolic link at path on the device. Stat and
+ListDirEntries report links themselves, with os.ModeSymlink set in their Mode, rather than
+what they point to.
+
+Corresponds to the command:
+
+	adb shell readlink <path>
+*/
+func (c *Device) ReadLink(path string) (string, error) {
+	output, err := c.runShellCommand(quoteShellArgs("readlink", path))
+	if err == nil {
+		output = strings.TrimRight(output, "\r\n")
+		if strings.HasPrefix(output, "readlink:") {
+			err = fileCommandError("readlink", output)
+		} else if output == "" {
+			// readlink prints nothing for files that aren't links, or don't exist.
+			err = errors.Errorf(errors.AdbError, "not a symbolic link: %s", path)
+		}
+	}
+	if err != nil {
+		return "", wrapClientError(err, c, "ReadLink(%s)", path)
+	}
+	return output, nil
+}
+
+/*
+CreateSymlink creates a symbolic link at path on the device that points to target.
+
+Corresponds to the command:
+
+	adb shell ln -s <target> <path>
+*/
+func (c *Device) CreateSymlink(target, path string) error {
+	return wrapClientError(c.runFileCommand("ln", "-s", target, path), c, "CreateSymlink(%s, %s)", target, path)
+}
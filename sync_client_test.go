@@ -17,7 +17,7 @@ var someTime = time.Date(2015, 5, 3, 8, 8, 8, 0, time.UTC)
 
 func TestStatValid(t *testing.T) {
 	var buf bytes.Buffer
-	conn := &wire.SyncConn{wire.NewSyncScanner(&buf), wire.NewSyncSender(&buf)}
+	conn := &wire.SyncConn{SyncScanner: wire.NewSyncScanner(&buf), SyncSender: wire.NewSyncSender(&buf)}
 
 	var mode os.FileMode = 0777
 
@@ -37,7 +37,7 @@ func TestStatValid(t *testing.T) {
 
 func TestStatBadResponse(t *testing.T) {
 	var buf bytes.Buffer
-	conn := &wire.SyncConn{wire.NewSyncScanner(&buf), wire.NewSyncSender(&buf)}
+	conn := &wire.SyncConn{SyncScanner: wire.NewSyncScanner(&buf), SyncSender: wire.NewSyncSender(&buf)}
 
 	conn.SendOctetString("SPAT")
 
@@ -48,7 +48,7 @@ func TestStatBadResponse(t *testing.T) {
 
 func TestStatNoExist(t *testing.T) {
 	var buf bytes.Buffer
-	conn := &wire.SyncConn{wire.NewSyncScanner(&buf), wire.NewSyncSender(&buf)}
+	conn := &wire.SyncConn{SyncScanner: wire.NewSyncScanner(&buf), SyncSender: wire.NewSyncSender(&buf)}
 
 	conn.SendOctetString("STAT")
 	conn.SendFileMode(0)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
@@ -21,7 +22,10 @@ type syncFileWriter struct {
 	scanner wire.SyncScanner
 }
 
-var _ io.WriteCloser = &syncFileWriter{}
+var (
+	_ io.WriteCloser = &syncFileWriter{}
+	_ io.ReaderFrom  = &syncFileWriter{}
+)
 
 func newSyncFileWriter(s *wire.SyncConn, mtime time.Time) io.WriteCloser {
 	return &syncFileWriter{
@@ -58,10 +62,7 @@ func (w *syncFileWriter) Write(buf []byte) (n int, err error) {
 			partialBuf = partialBuf[:wire.SyncMaxChunkSize]
 		}
 
-		if err := w.sender.SendOctetString(wire.StatusSyncData); err != nil {
-			return written, err
-		}
-		if err := w.sender.SendBytes(partialBuf); err != nil {
+		if err := w.sender.SendData(partialBuf); err != nil {
 			return written, err
 		}
 
@@ -72,6 +73,58 @@ func (w *syncFileWriter) Write(buf []byte) (n int, err error) {
 	return written, nil
 }
 
+/*
+ReadFrom sends everything read from r, and is used by io.Copy. It reads the next chunk while the
+previous one is being sent, so the connection stays busy instead of waiting on r between chunks.
+adb only reports the status of a SEND once the file is done, so nothing waits on the device
+until Close.
+*/
+func (w *syncFileWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	// Filled chunks are sent through chunks, and returned through free to be filled again.
+	chunks := make(chan []byte)
+	free := make(chan []byte, 2)
+	free <- make([]byte, wire.SyncMaxChunkSize)
+	free <- make([]byte, wire.SyncMaxChunkSize)
+	sendErr := make(chan error, 1)
+	var failed int32
+
+	go func() {
+		var err error
+		for chunk := range chunks {
+			if err == nil {
+				if err = w.sender.SendData(chunk); err != nil {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+			free <- chunk[:cap(chunk)]
+		}
+		sendErr <- err
+	}()
+
+	var readErr error
+	for atomic.LoadInt32(&failed) == 0 {
+		chunk := <-free
+		read, err := io.ReadFull(r, chunk)
+		if read > 0 {
+			chunks <- chunk[:read]
+			n += int64(read)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			readErr = err
+			break
+		}
+	}
+	close(chunks)
+
+	if err := <-sendErr; err != nil {
+		// The last chunk counted wasn't necessarily sent, but the file is incomplete either way.
+		return n, err
+	}
+	return n, readErr
+}
+
 func (w *syncFileWriter) Close() error {
 	if w.mtime.IsZero() {
 		w.mtime = time.Now()
@@ -90,3 +143,17 @@ func (w *syncFileWriter) Close() error {
 
 	return errors.WrapErrf(w.sender.Close(), "error closing FileWriter")
 }
+
+// abort closes the connection without sending DONE, so the device discards the file instead of
+// committing what it has received so far.
+func (w *syncFileWriter) abort() error {
+	return errors.WrapErrf(w.sender.Close(), "error aborting FileWriter")
+}
+
+// abortWrite closes w, a writer returned by OpenWrite, without committing the file if it can.
+func abortWrite(w io.WriteCloser) error {
+	if aborter, ok := w.(interface{ abort() error }); ok {
+		return aborter.abort()
+	}
+	return w.Close()
+}
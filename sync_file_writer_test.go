@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"encoding/binary"
+	"io"
 	"strings"
 
 	"github.com/stretchr/testify/assert"
@@ -14,7 +15,7 @@ import (
 
 func TestFileWriterWriteSingleChunk(t *testing.T) {
 	var buf bytes.Buffer
-	writer := newSyncFileWriter(wire.NewSyncSender(&buf), MtimeOfClose)
+	writer := newSyncFileWriter(newTestSyncConn(&buf), MtimeOfClose)
 
 	n, err := writer.Write([]byte("hello"))
 	assert.NoError(t, err)
@@ -25,7 +26,7 @@ func TestFileWriterWriteSingleChunk(t *testing.T) {
 
 func TestFileWriterWriteMultiChunk(t *testing.T) {
 	var buf bytes.Buffer
-	writer := newSyncFileWriter(wire.NewSyncSender(&buf), MtimeOfClose)
+	writer := newSyncFileWriter(newTestSyncConn(&buf), MtimeOfClose)
 
 	n, err := writer.Write([]byte("hello"))
 	assert.NoError(t, err)
@@ -40,7 +41,7 @@ func TestFileWriterWriteMultiChunk(t *testing.T) {
 
 func TestFileWriterWriteLargeChunk(t *testing.T) {
 	var buf bytes.Buffer
-	writer := newSyncFileWriter(wire.NewSyncSender(&buf), MtimeOfClose)
+	writer := newSyncFileWriter(newTestSyncConn(&buf), MtimeOfClose)
 
 	// Send just enough data to get 2 chunks.
 	data := make([]byte, wire.SyncMaxChunkSize+1)
@@ -64,10 +65,42 @@ func TestFileWriterWriteLargeChunk(t *testing.T) {
 	assert.Equal(t, expectedHeader, chunk[:8])
 }
 
+func TestFileWriterReadFrom(t *testing.T) {
+	var buf bytes.Buffer
+	writer := newSyncFileWriter(newTestSyncConn(&buf), MtimeOfClose)
+
+	// Hide bytes.Reader's WriteTo, so io.Copy uses ReadFrom.
+	data := bytes.Repeat([]byte("a"), 2*wire.SyncMaxChunkSize+1)
+	n, err := io.Copy(writer, struct{ io.Reader }{bytes.NewReader(data)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+
+	// Full chunks, then the rest.
+	assert.Equal(t, 3*8+len(data), buf.Len())
+	for i, size := range []int{wire.SyncMaxChunkSize, wire.SyncMaxChunkSize, 1} {
+		header := buf.Next(8)
+		assert.Equal(t, "DATA", string(header[:4]), "chunk %d", i)
+		assert.Equal(t, uint32(size), binary.LittleEndian.Uint32(header[4:]), "chunk %d", i)
+		assert.Equal(t, data[:size], buf.Next(size), "chunk %d", i)
+	}
+}
+
+func TestFileWriterReadFromSendError(t *testing.T) {
+	writer := newSyncFileWriter(newTestSyncConn(failingWriter{}), MtimeOfClose)
+
+	// The reader never ends, so ReadFrom only returns if it stops after the send fails.
+	_, err := writer.(io.ReaderFrom).ReadFrom(zeroReader{})
+	assert.True(t, HasErrCode(err, NetworkError))
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+
 func TestFileWriterCloseEmpty(t *testing.T) {
 	var buf bytes.Buffer
 	mtime := time.Unix(1, 0)
-	writer := newSyncFileWriter(wire.NewSyncSender(&buf), mtime)
+	writer := newSyncFileWriter(newTestSyncConn(&buf), mtime)
 
 	assert.NoError(t, writer.Close())
 
@@ -77,7 +110,7 @@ func TestFileWriterCloseEmpty(t *testing.T) {
 func TestFileWriterWriteClose(t *testing.T) {
 	var buf bytes.Buffer
 	mtime := time.Unix(1, 0)
-	writer := newSyncFileWriter(wire.NewSyncSender(&buf), mtime)
+	writer := newSyncFileWriter(newTestSyncConn(&buf), mtime)
 
 	writer.Write([]byte("hello"))
 	assert.NoError(t, writer.Close())
@@ -87,7 +120,7 @@ func TestFileWriterWriteClose(t *testing.T) {
 
 func TestFileWriterCloseAutoMtime(t *testing.T) {
 	var buf bytes.Buffer
-	writer := newSyncFileWriter(wire.NewSyncSender(&buf), MtimeOfClose)
+	writer := newSyncFileWriter(newTestSyncConn(&buf), MtimeOfClose)
 
 	assert.NoError(t, writer.Close())
 	assert.Len(t, buf.String(), 8)
@@ -99,3 +132,12 @@ func TestFileWriterCloseAutoMtime(t *testing.T) {
 	// Delta has to be a whole second since adb only supports second granularity for mtimes.
 	assert.WithinDuration(t, time.Now(), mtimeActual, 1*time.Second)
 }
+
+// newTestSyncConn returns a SyncConn that sends to w and reads an OKAY status when the
+// writer is closed.
+func newTestSyncConn(w io.Writer) *wire.SyncConn {
+	return &wire.SyncConn{
+		SyncScanner: wire.NewSyncScanner(strings.NewReader(wire.StatusSuccess)),
+		SyncSender:  wire.NewSyncSender(w),
+	}
+}
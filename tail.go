@@ -0,0 +1,138 @@
+package adb
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+)
+
+// tailPollInterval is the time between stats of a file followed by polling.
+const tailPollInterval = time.Second
+
+/*
+TailFile returns a reader that streams data appended to the file at path, like tail -f, until
+ctx is done or the reader is closed. If fromEnd is true, only data appended after the call is
+streamed, else the whole file is.
+
+Devices with tail -f run it. Older devices poll the file with the sync protocol instead, and
+restart from the beginning if it's truncated.
+
+Corresponds to the command:
+
+	adb shell tail -f <path>
+*/
+func (c *Device) TailFile(ctx context.Context, path string, fromEnd bool) (io.ReadCloser, error) {
+	if ok, err := c.supportsSDK(sdkTailFollow); err == nil && ok {
+		lines := "+1"
+		if fromEnd {
+			lines = "0"
+		}
+		conn, err := c.openService("shell:" + quoteShellArgs("tail", "-n", lines, "-f", path))
+		if err != nil {
+			return nil, wrapClientError(err, c, "TailFile(%s)", path)
+		}
+		return newContextReader(ctx, conn), nil
+	}
+
+	offset := int64(0)
+	if fromEnd {
+		entry, err := c.Stat(path)
+		if err != nil {
+			return nil, wrapClientError(err, c, "TailFile(%s)", path)
+		}
+		offset = int64(entry.Size)
+	}
+	reader, writer := io.Pipe()
+	tail := newContextReader(ctx, reader)
+	go c.pollFile(tail.ctx, path, offset, writer)
+	return tail, nil
+}
+
+// pollFile writes the data appended to path after offset to w, until ctx is done.
+func (c *Device) pollFile(ctx context.Context, path string, offset int64, w *io.PipeWriter) {
+	ticker := time.NewTicker(tailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		entry, err := c.Stat(path)
+		if err != nil {
+			w.CloseWithError(wrapClientError(err, c, "TailFile(%s)", path))
+			return
+		}
+		size := int64(entry.Size)
+		if size < offset {
+			// Truncated or rotated.
+			offset = 0
+		}
+		if size > offset {
+			n, err := c.copyFileRange(w, path, offset, size-offset)
+			offset += n
+			if err != nil {
+				w.CloseWithError(err)
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			w.Close()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// copyFileRange copies length bytes starting at offset of the file at path to w. The sync
+// protocol can't seek, so the bytes before offset are read and discarded.
+func (c *Device) copyFileRange(w io.Writer, path string, offset, length int64) (int64, error) {
+	reader, err := c.OpenRead(path)
+	if err != nil {
+		return 0, wrapClientError(err, c, "TailFile(%s)", path)
+	}
+	defer reader.Close()
+
+	if _, err := io.CopyN(ioutil.Discard, reader, offset); err != nil {
+		return 0, wrapClientError(err, c, "TailFile(%s)", path)
+	}
+	return io.CopyN(w, reader, length)
+}
+
+// contextReader closes its underlying reader when ctx is done, to unblock reads. Reads then
+// return io.EOF. Closing it cancels its ctx.
+type contextReader struct {
+	io.ReadCloser
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newContextReader(ctx context.Context, r io.ReadCloser) *contextReader {
+	ctx, cancel := context.WithCancel(ctx)
+	cr := &contextReader{ReadCloser: r, ctx: ctx, cancel: cancel}
+	go func() {
+		<-ctx.Done()
+		cr.closeOnce.Do(func() {
+			cr.closeErr = cr.ReadCloser.Close()
+		})
+	}()
+	return cr
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil && r.ctx.Err() != nil {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *contextReader) Close() error {
+	r.cancel()
+	r.closeOnce.Do(func() {
+		r.closeErr = r.ReadCloser.Close()
+	})
+	return r.closeErr
+}
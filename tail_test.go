@@ -0,0 +1,67 @@
+package adb
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestTailFileFollowsWithTail(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"line 1\n", "line 2\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 30
+
+	reader, err := device.TailFile(context.Background(), "/sdcard/app log.txt", true)
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	assert.Equal(t, "line 1\nline 2\n", string(data))
+	assert.Equal(t, []string{"host:transport-any", "shell:tail -n 0 -f '/sdcard/app log.txt'"}, s.Requests)
+}
+
+func TestTailFilePollsOnOldDevices(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/log.txt": {Data: []byte("line 1\n")}},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 19
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, err := device.TailFile(ctx, "/sdcard/log.txt", false)
+	require.NoError(t, err)
+	buf := make([]byte, 100)
+	n, err := reader.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1\n", string(buf[:n]))
+
+	cancel()
+	rest, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.NoError(t, reader.Close())
+}
+
+func TestTailFileFromEndClose(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/log.txt": {Data: []byte("old\n")}},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 19
+
+	reader, err := device.TailFile(context.Background(), "/sdcard/log.txt", true)
+	require.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	rest, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+}
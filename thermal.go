@@ -0,0 +1,265 @@
+package adb
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DefaultThermalSampleInterval is the time between ThermalSampler samples, unless another
+// interval is passed to SampleThermal.
+const DefaultThermalSampleInterval = 5 * time.Second
+
+// Prints the type and temperature of every thermal zone, for devices without thermalservice.
+const thermalZonesCommand = `cd /sys/class/thermal && for z in thermal_zone*; do ` +
+	`echo "$(cat $z/type 2>/dev/null) $(cat $z/temp 2>/dev/null)"; done`
+
+// ThermalStatus is the throttling status of a device, from PowerManager.
+//
+//go:generate stringer -type=ThermalStatus
+type ThermalStatus int8
+
+const (
+	// Not throttling, or the status isn't known.
+	ThermalNone ThermalStatus = iota
+	// Light throttling that doesn't affect the user experience.
+	ThermalLight
+	// Throttling that doesn't have a large impact on the user experience.
+	ThermalModerate
+	// Throttling that has a large impact on the user experience.
+	ThermalSevere
+	// The platform is reducing power as much as it can.
+	ThermalCritical
+	// Key components are shutting down.
+	ThermalEmergency
+	// The device is shutting down.
+	ThermalShutdown
+)
+
+var (
+	// Matches the "Temperature{mValue=36.0, mType=2, mName=battery, mStatus=0}" lines of dumpsys
+	// thermalservice.
+	thermalTemperaturePattern = regexp.MustCompile(
+		`Temperature\{mValue=([-\d.]+), mType=(-?\d+), mName=([^,]*), mStatus=(\d+)\}`)
+
+	// Names of the sensor types of the HAL, indexed by type.
+	thermalSensorTypes = []string{"CPU", "GPU", "BATTERY", "SKIN", "USB_PORT", "POWER_AMPLIFIER",
+		"BCL_VOLTAGE", "BCL_CURRENT", "BCL_PERCENTAGE", "NPU"}
+)
+
+// Temperature is the reading of a temperature sensor.
+type Temperature struct {
+	// Name of the sensor, e.g. "cpu0" or "battery", or the type of the thermal zone on devices
+	// without thermalservice.
+	Name string
+	// Kind of sensor, e.g. CPU, GPU, BATTERY, or SKIN. Empty if it isn't known.
+	Type    string
+	Celsius float64
+	// The throttling the sensor's temperature calls for.
+	Status ThermalStatus
+}
+
+// ThermalState is a sample of the thermal state of a device.
+type ThermalState struct {
+	Time time.Time
+	// Throttling status of the device as a whole.
+	Status       ThermalStatus
+	Temperatures []Temperature
+}
+
+/*
+Thermal returns the current throttling status and sensor temperatures of the device.
+
+Devices since Android 10 report them through thermalservice. Older devices only report the
+temperatures of their thermal zones, and Status is always ThermalNone.
+
+Corresponds to the commands:
+
+	adb shell dumpsys thermalservice
+	adb shell cat /sys/class/thermal/thermal_zone<N>/temp
+*/
+func (c *Device) Thermal() (*ThermalState, error) {
+	state, err := c.thermalState()
+	return state, wrapClientError(err, c, "Thermal")
+}
+
+func (c *Device) thermalState() (*ThermalState, error) {
+	output, err := c.runShellCommand("dumpsys thermalservice")
+	if err != nil {
+		return nil, err
+	}
+	if state, ok := parseThermalService(output); ok {
+		state.Time = time.Now()
+		return state, nil
+	}
+
+	output, err = c.runShellCommand(thermalZonesCommand)
+	if err != nil {
+		return nil, err
+	}
+	state, err := parseThermalZones(output)
+	if err != nil {
+		return nil, err
+	}
+	state.Time = time.Now()
+	return state, nil
+}
+
+// ThermalSampler publishes the thermal state of a device over time.
+type ThermalSampler struct {
+	stateChan chan ThermalState
+	// If an error occurs, it is stored here and stateChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get samples. It's closed when the context
+// passed to SampleThermal is done, or if an error occurs.
+func (s *ThermalSampler) C() <-chan ThermalState {
+	return s.stateChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (s *ThermalSampler) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+SampleThermal sends the thermal state of the device once per interval, the first immediately,
+until ctx is done. If interval is 0, DefaultThermalSampleInterval is used.
+
+E.g. to annotate benchmark results with throttling:
+
+	sampler, err := device.SampleThermal(ctx, time.Second)
+	for state := range sampler.C() {
+		if state.Status >= adb.ThermalModerate {
+			fmt.Println(state.Time, "throttled:", state.Status)
+		}
+	}
+*/
+func (c *Device) SampleThermal(ctx context.Context, interval time.Duration) (*ThermalSampler, error) {
+	if interval <= 0 {
+		interval = DefaultThermalSampleInterval
+	}
+	state, err := c.thermalState()
+	if err != nil {
+		return nil, wrapClientError(err, c, "SampleThermal")
+	}
+
+	sampler := &ThermalSampler{stateChan: make(chan ThermalState)}
+	go func() {
+		defer close(sampler.stateChan)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case sampler.stateChan <- *state:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if state, err = c.thermalState(); err != nil {
+				sampler.err.Store(wrapClientError(err, c, "SampleThermal"))
+				return
+			}
+		}
+	}()
+	return sampler, nil
+}
+
+// parseThermalService parses dumpsys thermalservice. It returns false if the device doesn't
+// have thermalservice. Temperatures are read from the "Current temperatures from HAL" section,
+// or the cached ones if the HAL isn't connected.
+func parseThermalService(output string) (*ThermalState, bool) {
+	state := &ThermalState{}
+	found := false
+	var current, cached []Temperature
+	var section *[]Temperature
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Thermal Status:"):
+			status, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Thermal Status:")))
+			if err == nil {
+				state.Status, found = ThermalStatus(status), true
+			}
+			section = nil
+		case line == "Current temperatures from HAL:":
+			section = &current
+		case line == "Cached temperatures:":
+			section = &cached
+		case strings.HasSuffix(line, ":"):
+			section = nil
+		case section != nil:
+			if temperature, ok := parseThermalTemperature(line); ok {
+				*section = append(*section, temperature)
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	state.Temperatures = current
+	if len(current) == 0 {
+		state.Temperatures = cached
+	}
+	return state, true
+}
+
+func parseThermalTemperature(line string) (Temperature, bool) {
+	match := thermalTemperaturePattern.FindStringSubmatch(line)
+	if match == nil {
+		return Temperature{}, false
+	}
+	celsius, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return Temperature{}, false
+	}
+	temperature := Temperature{Name: match[3], Celsius: celsius}
+	if sensorType, _ := strconv.Atoi(match[2]); sensorType >= 0 && sensorType < len(thermalSensorTypes) {
+		temperature.Type = thermalSensorTypes[sensorType]
+	}
+	status, _ := strconv.Atoi(match[4])
+	temperature.Status = ThermalStatus(status)
+	return temperature, true
+}
+
+// parseThermalZones parses the output of thermalZonesCommand. Zones report millidegrees, except
+// on some old kernels which report degrees. Zones that can't be read, e.g. because the sensor
+// is off, are skipped.
+func parseThermalZones(output string) (*ThermalState, error) {
+	state := &ThermalState{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		if math.Abs(value) >= 1000 {
+			value /= 1000
+		}
+		state.Temperatures = append(state.Temperatures, Temperature{Name: fields[0], Celsius: value})
+	}
+	if len(state.Temperatures) == 0 {
+		return nil, errors.Errorf(errors.ParseError, "no thermal zones: %q", output)
+	}
+	return state, nil
+}
@@ -0,0 +1,84 @@
+package adb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testThermalService = `IsStatusOverride: false
+ThermalEventListeners:
+	callbacks: 1
+	killed: false
+	broadcasts count: -1
+Thermal Status: 2
+Cached temperatures:
+	Temperature{mValue=35.0, mType=2, mName=battery, mStatus=0}
+HAL Ready: true
+HAL connection:
+	ThermalHAL 2.0 connected: yes
+Current temperatures from HAL:
+	Temperature{mValue=36.5, mType=2, mName=battery, mStatus=0}
+	Temperature{mValue=78.25, mType=0, mName=cpu0, mStatus=2}
+	Temperature{mValue=40.0, mType=-1, mName=modem, mStatus=0}
+Current cooling devices from HAL:
+	CoolingDevice{mValue=0, mType=2, mName=cpu0}
+`
+
+func TestParseThermalService(t *testing.T) {
+	state, ok := parseThermalService(testThermalService)
+	require.True(t, ok)
+	assert.Equal(t, &ThermalState{
+		Status: ThermalModerate,
+		Temperatures: []Temperature{
+			{Name: "battery", Type: "BATTERY", Celsius: 36.5},
+			{Name: "cpu0", Type: "CPU", Celsius: 78.25, Status: ThermalModerate},
+			{Name: "modem", Celsius: 40},
+		},
+	}, state)
+
+	state, ok = parseThermalService("Thermal Status: 0\nCached temperatures:\n\tTemperature{mValue=35.0, mType=3, mName=skin, mStatus=0}\nHAL Ready: false\n")
+	require.True(t, ok)
+	assert.Equal(t, []Temperature{{Name: "skin", Type: "SKIN", Celsius: 35}}, state.Temperatures)
+
+	_, ok = parseThermalService("Can't find service: thermalservice\n")
+	assert.False(t, ok)
+	assert.Equal(t, "ThermalModerate", ThermalModerate.String())
+}
+
+func TestParseThermalZones(t *testing.T) {
+	state, err := parseThermalZones("battery 36500\ncpu-0-0-usr 45\ntsens_tz_sensor3 \n")
+	require.NoError(t, err)
+	assert.Equal(t, []Temperature{{Name: "battery", Celsius: 36.5}, {Name: "cpu-0-0-usr", Celsius: 45}}, state.Temperatures)
+
+	_, err = parseThermalZones("")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestSampleThermal(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"dumpsys thermalservice": "Can't find service: thermalservice\n",
+			thermalZonesCommand:      "battery 36500\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sampler, err := device.SampleThermal(ctx, 0)
+	require.NoError(t, err)
+	state := <-sampler.C()
+	assert.Equal(t, ThermalNone, state.Status)
+	assert.Equal(t, []Temperature{{Name: "battery", Celsius: 36.5}}, state.Temperatures)
+	assert.False(t, state.Time.IsZero())
+
+	cancel()
+	for range sampler.C() {
+	}
+	assert.NoError(t, sampler.Err())
+}
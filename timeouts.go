@@ -0,0 +1,105 @@
+package adb
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+/*
+Timeouts bound how long operations on the server may take. Zero values disable the
+corresponding timeout, so the zero value relies on OS defaults, like earlier versions.
+
+Read and Write bound each individual read or write, so a stalled server or device is detected
+even in the middle of long transfers. Query and Transfer bound whole operations: Query applies
+to requests with a single short response, e.g. ServerVersion and State, and Transfer to file
+transfers in sync mode, e.g. Stat, OpenRead, and OpenWrite.
+
+Reattach bounds how long RestartServer waits, in total, for the devices to come back after the
+restart.
+
+Dial, Read, and Write are only enforced by the default Dialer. Query and Transfer are enforced
+by any Dialer whose connections implement wire.Deadliner.
+*/
+type Timeouts struct {
+	Dial  time.Duration
+	Read  time.Duration
+	Write time.Duration
+
+	Query    time.Duration
+	Transfer time.Duration
+
+	Reattach time.Duration
+}
+
+// deadlineFor returns the deadline for an operation started now that may take timeout, or the
+// zero time if timeout is zero.
+func deadlineFor(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// timeoutConn sets a deadline on conn before each read and write: the earliest of the read or
+// write timeout from now, and the overall read or write deadline set with SetDeadline,
+// SetReadDeadline, or SetWriteDeadline.
+type timeoutConn struct {
+	net.Conn
+	timeouts Timeouts
+
+	lock          sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func (c *timeoutConn) Read(p []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(c.nextDeadline(c.timeouts.Read, &c.readDeadline)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(p)
+}
+
+func (c *timeoutConn) Write(p []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(c.nextDeadline(c.timeouts.Write, &c.writeDeadline)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(p)
+}
+
+// SetDeadline sets the overall deadline for all future reads and writes.
+func (c *timeoutConn) SetDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.readDeadline, c.writeDeadline = t, t
+	return nil
+}
+
+// SetReadDeadline sets the overall deadline for all future reads.
+func (c *timeoutConn) SetReadDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline sets the overall deadline for all future writes.
+func (c *timeoutConn) SetWriteDeadline(t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// nextDeadline returns the earliest of timeout from now and *deadline, which is read under
+// the lock.
+func (c *timeoutConn) nextDeadline(timeout time.Duration, deadline *time.Time) time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	next := deadlineFor(timeout)
+	if next.IsZero() || (!deadline.IsZero() && deadline.Before(next)) {
+		return *deadline
+	}
+	return next
+}
@@ -0,0 +1,85 @@
+package adb
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeoutConnReadTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &timeoutConn{Conn: client, timeouts: Timeouts{Read: 10 * time.Millisecond}}
+
+	_, err := conn.Read(make([]byte, 1))
+	netErr, ok := err.(net.Error)
+	require.True(t, ok, "%v", err)
+	assert.True(t, netErr.Timeout())
+}
+
+func TestTimeoutConnOverallDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &timeoutConn{Conn: client, timeouts: Timeouts{Write: time.Hour}}
+	conn.SetDeadline(time.Now().Add(10 * time.Millisecond))
+
+	_, err := conn.Write([]byte("hello"))
+	netErr, ok := err.(net.Error)
+	require.True(t, ok, "%v", err)
+	assert.True(t, netErr.Timeout())
+}
+
+func TestTimeoutConnNextDeadline(t *testing.T) {
+	conn := &timeoutConn{}
+	assert.True(t, conn.nextDeadline(0, &conn.readDeadline).IsZero())
+
+	before := time.Now()
+	next := conn.nextDeadline(time.Minute, &conn.readDeadline)
+	assert.False(t, next.Before(before.Add(time.Minute)))
+
+	deadline := time.Now().Add(time.Second)
+	conn.SetDeadline(deadline)
+	assert.Equal(t, deadline, conn.nextDeadline(time.Minute, &conn.readDeadline))
+	assert.Equal(t, deadline, conn.nextDeadline(0, &conn.writeDeadline))
+
+	// Read and write deadlines are separate.
+	conn.SetReadDeadline(time.Time{})
+	assert.True(t, conn.nextDeadline(0, &conn.readDeadline).IsZero())
+	assert.Equal(t, deadline, conn.nextDeadline(0, &conn.writeDeadline))
+}
+
+func TestQueryTimeout(t *testing.T) {
+	// A server that accepts connections but never responds.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				break
+			}
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	client, err := NewWithConfig(ServerConfig{
+		Host:     "127.0.0.1",
+		Port:     listener.Addr().(*net.TCPAddr).Port,
+		NoServer: true,
+		Timeouts: Timeouts{Query: 50 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.ServerVersion()
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
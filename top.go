@@ -0,0 +1,250 @@
+package adb
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DefaultProcessSampleInterval is the time between ProcessSampler snapshots, unless
+// ProcessSamplerConfig.Interval is set.
+const DefaultProcessSampleInterval = 2 * time.Second
+
+// Lists the device's uptime, followed by the stat line of every process. Processes can exit
+// between the glob and cat reading them, so errors are dropped.
+const procStatCommand = "cat /proc/uptime; cd /proc && cat [0-9]*/stat 2>/dev/null"
+
+// Page size and clock tick rate, for devices whose getconf doesn't know them.
+const (
+	defaultPageSize  = 4096
+	defaultClockTick = 100
+)
+
+// ProcessStats is the resource usage of a process, as reported by a ProcessSampler.
+type ProcessStats struct {
+	PID  int
+	Name string
+	// Percentage of one CPU core used since the previous snapshot. It's over 100 for processes
+	// that keep more than one core busy.
+	CPU float64
+	// Resident set size, in bytes.
+	RSS int64
+}
+
+// ProcessSnapshot is one sample of a ProcessSampler.
+type ProcessSnapshot struct {
+	Time time.Time
+	// Busiest processes first, by CPU and then by RSS.
+	Processes []ProcessStats
+}
+
+// ProcessSamplerConfig configures a ProcessSampler. The zero value uses the defaults.
+type ProcessSamplerConfig struct {
+	// Time between snapshots. Defaults to DefaultProcessSampleInterval.
+	Interval time.Duration
+	// If set, snapshots only include this many of the busiest processes.
+	Top int
+}
+
+// ProcessSampler publishes snapshots of the CPU and memory usage of the processes on a device,
+// like top.
+type ProcessSampler struct {
+	snapshotChan chan ProcessSnapshot
+	// If an error occurs, it is stored here and snapshotChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get snapshots. It's closed when the context
+// passed to SampleProcesses is done, or if an error occurs.
+func (s *ProcessSampler) C() <-chan ProcessSnapshot {
+	return s.snapshotChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (s *ProcessSampler) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+SampleProcesses reads the CPU time and memory of every process from /proc once per interval,
+until ctx is done, and sends a snapshot of each interval's usage. The first snapshot is sent
+after the first interval.
+
+Each sample is a single shell command, so sampling often doesn't add much load to the device.
+E.g. to show the five busiest processes:
+
+	sampler, err := device.SampleProcesses(ctx, adb.ProcessSamplerConfig{Top: 5})
+	for snapshot := range sampler.C() {
+		for _, p := range snapshot.Processes {
+			fmt.Printf("%6d %5.1f%% %8d %s\n", p.PID, p.CPU, p.RSS, p.Name)
+		}
+	}
+*/
+func (c *Device) SampleProcesses(ctx context.Context, config ProcessSamplerConfig) (*ProcessSampler, error) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultProcessSampleInterval
+	}
+
+	output, err := c.runShellCommand("getconf PAGESIZE; getconf CLK_TCK")
+	if err != nil {
+		return nil, wrapClientError(err, c, "SampleProcesses")
+	}
+	pageSize, clockTick := parseGetconf(output)
+
+	previous, err := c.sampleProcStat()
+	if err != nil {
+		return nil, wrapClientError(err, c, "SampleProcesses")
+	}
+
+	sampler := &ProcessSampler{snapshotChan: make(chan ProcessSnapshot)}
+	go func() {
+		defer close(sampler.snapshotChan)
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			current, err := c.sampleProcStat()
+			if err != nil {
+				sampler.err.Store(wrapClientError(err, c, "SampleProcesses"))
+				return
+			}
+			snapshot := ProcessSnapshot{
+				Time:      time.Now(),
+				Processes: processStats(previous, current, pageSize, clockTick, config.Top),
+			}
+			previous = current
+
+			select {
+			case sampler.snapshotChan <- snapshot:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sampler, nil
+}
+
+// procStatSample is the CPU time and memory of every process at an uptime of the device.
+type procStatSample struct {
+	uptime    float64
+	processes map[int]procStat
+}
+
+type procStat struct {
+	name string
+	// User and system CPU time, in clock ticks.
+	ticks int64
+	// Resident set size, in pages.
+	rssPages int64
+}
+
+func (c *Device) sampleProcStat() (*procStatSample, error) {
+	output, err := c.runShellCommand(procStatCommand)
+	if err != nil {
+		return nil, err
+	}
+	return parseProcStat(output)
+}
+
+// parseProcStat parses the output of procStatCommand.
+func parseProcStat(output string) (*procStatSample, error) {
+	lines := strings.Split(output, "\n")
+	uptime := strings.Fields(lines[0])
+	if len(uptime) == 0 {
+		return nil, errors.Errorf(errors.ParseError, "invalid /proc/uptime: %q", lines[0])
+	}
+	sample := &procStatSample{processes: make(map[int]procStat)}
+	var err error
+	if sample.uptime, err = strconv.ParseFloat(uptime[0], 64); err != nil {
+		return nil, errors.WrapErrorf(err, errors.ParseError, "invalid /proc/uptime: %q", lines[0])
+	}
+
+	for _, line := range lines[1:] {
+		// The name is in parentheses, and may contain spaces and parentheses itself.
+		start, end := strings.IndexByte(line, '('), strings.LastIndexByte(line, ')')
+		if start < 0 || end < start {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(line[:start]))
+		// Fields after the name, starting with the state, the third field of the line.
+		fields := strings.Fields(line[end+1:])
+		if err != nil || len(fields) < 22 {
+			return nil, errors.Errorf(errors.ParseError, "invalid /proc/<pid>/stat line: %q", line)
+		}
+		var ints psIntParser
+		stat := procStat{
+			name:     line[start+1 : end],
+			ticks:    ints.parse(fields[11]) + ints.parse(fields[12]),
+			rssPages: ints.parse(fields[21]),
+		}
+		if ints.err != nil {
+			return nil, errors.WrapErrorf(ints.err, errors.ParseError, "invalid /proc/<pid>/stat line: %q", line)
+		}
+		sample.processes[pid] = stat
+	}
+	return sample, nil
+}
+
+// processStats returns the usage of the processes in current since previous, busiest first.
+// Processes that started since previous are counted from when they started.
+func processStats(previous, current *procStatSample, pageSize, clockTick int64, top int) []ProcessStats {
+	elapsed := current.uptime - previous.uptime
+	stats := make([]ProcessStats, 0, len(current.processes))
+	for pid, stat := range current.processes {
+		process := ProcessStats{PID: pid, Name: stat.name, RSS: stat.rssPages * pageSize}
+		if elapsed > 0 {
+			ticks := stat.ticks - previous.processes[pid].ticks
+			if ticks < 0 {
+				// The pid was reused.
+				ticks = stat.ticks
+			}
+			process.CPU = float64(ticks) / float64(clockTick) / elapsed * 100
+		}
+		stats = append(stats, process)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].CPU != stats[j].CPU {
+			return stats[i].CPU > stats[j].CPU
+		}
+		if stats[i].RSS != stats[j].RSS {
+			return stats[i].RSS > stats[j].RSS
+		}
+		return stats[i].PID < stats[j].PID
+	})
+	if top > 0 && len(stats) > top {
+		stats = stats[:top]
+	}
+	return stats
+}
+
+// parseGetconf parses the page size and clock tick rate printed by getconf, falling back to the
+// defaults for values it doesn't print.
+func parseGetconf(output string) (pageSize, clockTick int64) {
+	pageSize, clockTick = defaultPageSize, defaultClockTick
+	lines := strings.Split(output, "\n")
+	if value, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64); err == nil && value > 0 {
+		pageSize = value
+	}
+	if len(lines) > 1 {
+		if value, err := strconv.ParseInt(strings.TrimSpace(lines[1]), 10, 64); err == nil && value > 0 {
+			clockTick = value
+		}
+	}
+	return pageSize, clockTick
+}
@@ -0,0 +1,87 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const procStatOutput = `12345.67 23456.78
+1 (init) S 0 1 0 0 -1 4194560 7564 37198 401 2505 100 200 30 40 20 0 1 0 2 12345678 500 18446744073709551615 1 1 0 0 0 0 0 1 1 0 0 0 17 2 0 0 0 0 0
+4321 (Binder:4321 (x)) S 612 612 0 0 -1 1077952832 52826 0 25 0 1000 500 0 0 10 -10 85 0 1234 4800000000 25600 18446744073709551615 1 1 0 0 0 0 4612 1 1073775864 0 0 0 17 5 0 0 0 0 0
+`
+
+func TestParseProcStat(t *testing.T) {
+	sample, err := parseProcStat(procStatOutput)
+	require.NoError(t, err)
+	assert.Equal(t, 12345.67, sample.uptime)
+	assert.Equal(t, map[int]procStat{
+		1:    {name: "init", ticks: 300, rssPages: 500},
+		4321: {name: "Binder:4321 (x)", ticks: 1500, rssPages: 25600},
+	}, sample.processes)
+
+	_, err = parseProcStat("")
+	assert.True(t, HasErrCode(err, ParseError))
+	_, err = parseProcStat("1.0 2.0\n1 (init) S 0 1\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestProcessStats(t *testing.T) {
+	previous := &procStatSample{uptime: 100, processes: map[int]procStat{
+		1: {name: "init", ticks: 300, rssPages: 500},
+		2: {name: "idle", ticks: 50, rssPages: 10},
+	}}
+	current := &procStatSample{uptime: 102, processes: map[int]procStat{
+		1: {name: "init", ticks: 400, rssPages: 500},
+		2: {name: "idle", ticks: 50, rssPages: 10},
+		// Started since the previous sample.
+		3: {name: "app", ticks: 150, rssPages: 1000},
+	}}
+
+	stats := processStats(previous, current, 4096, 100, 0)
+	assert.Equal(t, []ProcessStats{
+		{PID: 3, Name: "app", CPU: 75, RSS: 1000 * 4096},
+		{PID: 1, Name: "init", CPU: 50, RSS: 500 * 4096},
+		{PID: 2, Name: "idle", CPU: 0, RSS: 10 * 4096},
+	}, stats)
+
+	assert.Len(t, processStats(previous, current, 4096, 100, 2), 2)
+}
+
+func TestParseGetconf(t *testing.T) {
+	pageSize, clockTick := parseGetconf("16384\n100\n")
+	assert.Equal(t, int64(16384), pageSize)
+	assert.Equal(t, int64(100), clockTick)
+
+	pageSize, clockTick = parseGetconf("/system/bin/sh: getconf: not found\n")
+	assert.Equal(t, int64(defaultPageSize), pageSize)
+	assert.Equal(t, int64(defaultClockTick), clockTick)
+}
+
+func TestSampleProcesses(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getconf PAGESIZE; getconf CLK_TCK": "4096\n100\n",
+			procStatCommand:                     procStatOutput,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sampler, err := device.SampleProcesses(ctx, ProcessSamplerConfig{Interval: 10 * time.Millisecond, Top: 1})
+	require.NoError(t, err)
+	snapshot, ok := <-sampler.C()
+	require.True(t, ok, "%v", sampler.Err())
+	assert.Equal(t, []ProcessStats{{PID: 4321, Name: "Binder:4321 (x)", RSS: 25600 * 4096}}, snapshot.Processes)
+
+	cancel()
+	for range sampler.C() {
+	}
+	assert.NoError(t, sampler.Err())
+}
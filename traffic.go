@@ -0,0 +1,169 @@
+package adb
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+var (
+	// Matches the "ident=[{type=WIFI, ...}] uid=10123 set=DEFAULT tag=0x0" line that starts the
+	// history of a uid in dumpsys netstats.
+	netstatsUIDLinePattern = regexp.MustCompile(`^ident=(\[.*\]) uid=(-?\d+) set=\S+ tag=0x0$`)
+
+	// Matches the "iface=wlan0 ident=[{type=WIFI, ...}]" lines of the active interfaces.
+	netstatsIfaceLinePattern = regexp.MustCompile(`^iface=(\S+) ident=(\[.*\])$`)
+
+	// Matches the "st=1500000000 rb=1234 rp=12 tb=567 tp=8 op=0" lines of a history bucket.
+	netstatsBucketLinePattern = regexp.MustCompile(`^st=\d+ rb=(\d+) rp=\d+ tb=(\d+)`)
+
+	// Matches the network type in an ident, a name on older releases and a number on newer ones.
+	netstatsTypePattern = regexp.MustCompile(`type=(\w+)`)
+
+	// Names of the ConnectivityManager network types newer releases print as numbers.
+	networkTypeNames = map[string]string{
+		"0": "MOBILE", "1": "WIFI", "6": "WIMAX", "7": "BLUETOOTH", "9": "ETHERNET", "17": "VPN",
+	}
+)
+
+// PackageTraffic is the data a uid sent and received over a network since the device booted.
+type PackageTraffic struct {
+	UID int
+	// Packages that run as UID, usually one. Empty for uids without packages, like the kernel
+	// (0) or tethering (-5).
+	Packages []string
+	// Kind of network, e.g. WIFI or MOBILE.
+	Network string
+	// Interface of the network, e.g. wlan0. Empty if the network isn't connected anymore.
+	Interface string
+	RxBytes   int64
+	TxBytes   int64
+}
+
+/*
+TrafficByPackage returns the data each app sent and received since the device booted, per
+network, most first. Apps that share a uid are counted together.
+
+Corresponds to the commands:
+
+	adb shell pm list packages -U
+	adb shell dumpsys netstats detail
+*/
+func (c *Device) TrafficByPackage() ([]PackageTraffic, error) {
+	packages, err := c.packagesByUID()
+	if err != nil {
+		return nil, wrapClientError(err, c, "TrafficByPackage")
+	}
+	output, err := c.runShellCommand("dumpsys netstats detail")
+	if err != nil {
+		return nil, wrapClientError(err, c, "TrafficByPackage")
+	}
+	traffic, err := parseNetstatsUIDs(output)
+	if err != nil {
+		return nil, wrapClientError(err, c, "TrafficByPackage")
+	}
+
+	for i := range traffic {
+		traffic[i].Packages = packages[traffic[i].UID]
+	}
+	return traffic, nil
+}
+
+// parseNetstatsUIDs parses the "UID stats" section of dumpsys netstats detail, which holds the
+// history since boot, summing the buckets of each uid and network.
+func parseNetstatsUIDs(output string) ([]PackageTraffic, error) {
+	type key struct {
+		uid   int
+		ident string
+	}
+	ifaces := make(map[string]string)
+	totals := make(map[key]*PackageTraffic)
+	var current *PackageTraffic
+	inUIDStats, found := false, false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, "stats:") {
+			// The dev, xt, uid, and uid tag sections, "Uid stats:" on older releases.
+			inUIDStats = strings.EqualFold(line, "UID stats:")
+			found = found || inUIDStats
+			current = nil
+			continue
+		}
+		if match := netstatsIfaceLinePattern.FindStringSubmatch(line); match != nil {
+			ifaces[match[2]] = match[1]
+			continue
+		}
+		if !inUIDStats {
+			continue
+		}
+		if match := netstatsUIDLinePattern.FindStringSubmatch(line); match != nil {
+			uid, _ := strconv.Atoi(match[2])
+			k := key{uid, match[1]}
+			if totals[k] == nil {
+				totals[k] = &PackageTraffic{UID: uid, Network: networkType(match[1])}
+			}
+			current = totals[k]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if match := netstatsBucketLinePattern.FindStringSubmatch(line); match != nil {
+			rx, _ := strconv.ParseInt(match[1], 10, 64)
+			tx, _ := strconv.ParseInt(match[2], 10, 64)
+			current.RxBytes += rx
+			current.TxBytes += tx
+		}
+	}
+	if !found {
+		return nil, errors.Errorf(errors.ParseError, "no uid stats in dumpsys netstats output")
+	}
+
+	// Merge the idents of the same network, e.g. metered and not, and find their interfaces.
+	type mergeKey struct {
+		uid            int
+		network, iface string
+	}
+	merged := make(map[mergeKey]*PackageTraffic)
+	var traffic []PackageTraffic
+	for k, total := range totals {
+		total.Interface = ifaces[k.ident]
+		mk := mergeKey{total.UID, total.Network, total.Interface}
+		if existing := merged[mk]; existing != nil {
+			existing.RxBytes += total.RxBytes
+			existing.TxBytes += total.TxBytes
+			continue
+		}
+		merged[mk] = total
+	}
+	for _, total := range merged {
+		traffic = append(traffic, *total)
+	}
+	sort.Slice(traffic, func(i, j int) bool {
+		a, b := traffic[i], traffic[j]
+		if a.RxBytes+a.TxBytes != b.RxBytes+b.TxBytes {
+			return a.RxBytes+a.TxBytes > b.RxBytes+b.TxBytes
+		}
+		if a.UID != b.UID {
+			return a.UID < b.UID
+		}
+		return a.Network+a.Interface < b.Network+b.Interface
+	})
+	return traffic, nil
+}
+
+// networkType returns the type of the network an ident describes.
+func networkType(ident string) string {
+	match := netstatsTypePattern.FindStringSubmatch(ident)
+	if match == nil {
+		return ""
+	}
+	if name, ok := networkTypeNames[match[1]]; ok {
+		return name
+	}
+	return match[1]
+}
@@ -0,0 +1,99 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testNetstatsDetail = `Active interfaces:
+  iface=wlan0 ident=[{type=1, ratType=COMBINED, wifiNetworkKey="home", metered=false}]
+Active UID interfaces:
+  iface=wlan0 ident=[{type=1, ratType=COMBINED, wifiNetworkKey="home", metered=false}]
+Dev stats:
+  Pending bytes: 0
+  History since boot:
+  ident=[{type=1, ratType=COMBINED, wifiNetworkKey="home", metered=false}] uid=-1 set=ALL tag=0x0
+    NetworkStatsHistory: bucketDuration=3600
+      st=1600000000 rb=999999 rp=1 tb=999999 tp=1 op=0
+UID stats:
+  Pending bytes: 0
+  History since boot:
+  ident=[{type=1, ratType=COMBINED, wifiNetworkKey="home", metered=false}] uid=10123 set=DEFAULT tag=0x0
+    NetworkStatsHistory: bucketDuration=7200
+      st=1600000000 rb=1000 rp=10 tb=200 tp=2 op=0
+      st=1600007200 rb=500 rp=5 tb=100 tp=1 op=0
+  ident=[{type=1, ratType=COMBINED, wifiNetworkKey="home", metered=false}] uid=10123 set=FOREGROUND tag=0x0
+    NetworkStatsHistory: bucketDuration=7200
+      st=1600000000 rb=300 rp=3 tb=0 tp=0 op=0
+  ident=[{type=0, ratType=13, subscriberId=310260..., metered=true}] uid=10123 set=DEFAULT tag=0x0
+    NetworkStatsHistory: bucketDuration=7200
+      st=1600000000 rb=50 rp=1 tb=50 tp=1 op=0
+  ident=[{type=1, ratType=COMBINED, wifiNetworkKey="home", metered=false}] uid=0 set=DEFAULT tag=0x0
+    NetworkStatsHistory: bucketDuration=7200
+      st=1600000000 rb=10000 rp=100 tb=10000 tp=100 op=0
+UID tag stats:
+  Pending bytes: 0
+  History since boot:
+  ident=[{type=1, ratType=COMBINED, wifiNetworkKey="home", metered=false}] uid=10123 set=DEFAULT tag=0xff
+    NetworkStatsHistory: bucketDuration=7200
+      st=1600000000 rb=77777 rp=1 tb=77777 tp=1 op=0
+`
+
+func TestParseNetstatsUIDs(t *testing.T) {
+	traffic, err := parseNetstatsUIDs(testNetstatsDetail)
+	require.NoError(t, err)
+	assert.Equal(t, []PackageTraffic{
+		{UID: 0, Network: "WIFI", Interface: "wlan0", RxBytes: 10000, TxBytes: 10000},
+		{UID: 10123, Network: "WIFI", Interface: "wlan0", RxBytes: 1800, TxBytes: 300},
+		{UID: 10123, Network: "MOBILE", RxBytes: 50, TxBytes: 50},
+	}, traffic)
+
+	_, err = parseNetstatsUIDs("Can't find service: netstats\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestParsePackageList(t *testing.T) {
+	assert.Equal(t, []Package{
+		{Name: "com.android.shell", UID: 2000},
+		{Name: "com.example", UID: 10123},
+		{Name: "com.example.old", UID: -1},
+	}, parsePackageList("package:com.example uid:10123,1010123\npackage:com.android.shell uid:2000\npackage:com.example.old\n"))
+}
+
+func TestListPackagesWithoutUIDs(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk": "23\n",
+			"pm list packages -U":          "Error: Unknown option: -U\n",
+			"pm list packages":             "package:com.example\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	packages, err := device.ListPackages()
+	require.NoError(t, err)
+	assert.Equal(t, []Package{{Name: "com.example", UID: -1}}, packages)
+}
+
+func TestTrafficByPackage(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk": "30\n",
+			"cmd package list packages -U": "package:com.example uid:10123\npackage:com.example.shared uid:10123\n",
+			"dumpsys netstats detail":      testNetstatsDetail,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	traffic, err := device.TrafficByPackage()
+	require.NoError(t, err)
+	require.Len(t, traffic, 3)
+	assert.Empty(t, traffic[0].Packages)
+	assert.Equal(t, []string{"com.example", "com.example.shared"}, traffic[1].Packages)
+	assert.Equal(t, []string{"com.example", "com.example.shared"}, traffic[2].Packages)
+}
@@ -0,0 +1,392 @@
+package adb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// tarEntry is a local file planned to be added to a pushed archive.
+type tarEntry struct {
+	local string
+	// Slash-separated path in the archive.
+	name string
+	info os.FileInfo
+	// Target of a preserved link, empty otherwise.
+	link string
+}
+
+// hasTar returns true if the device has a tar command, which toybox provides since Android 6.
+func (c *Device) hasTar() (bool, error) {
+	output, err := c.runShellCommand("command -v tar")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(output) != "", nil
+}
+
+// pushTar pushes the directory tree at local to remote as a single archive extracted by tar
+// on the device. Returns false, without pushing anything, if local isn't a directory or the
+// device has no tar.
+func (c *Device) pushTar(t *transfer, local, remote string) (bool, error) {
+	if info, err := os.Stat(local); err != nil || !info.IsDir() {
+		// Pushing a single file gains nothing, and sync reports the error.
+		return false, nil
+	}
+	if ok, err := c.hasTar(); !ok || err != nil {
+		return false, err
+	}
+
+	var entries []tarEntry
+	if err := planTar(t, &entries, local, ".", 0); err != nil {
+		return true, err
+	}
+
+	script := "mkdir -p " + quoteShellArg(remote) + " && tar -xf - -C " + quoteShellArg(remote) + " 2>&1"
+	conn, err := c.openService("exec:" + quoteShellArgs("sh", "-c", script))
+	if err != nil {
+		return true, err
+	}
+	defer conn.Close()
+
+	archive := tar.NewWriter(conn)
+	for _, entry := range entries {
+		if err := writeTarEntry(t, archive, entry); err != nil {
+			return true, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return true, errors.WrapErrorf(err, errors.NetworkError, "error streaming archive")
+	}
+
+	// tar stops at the end of the archive, and only prints errors.
+	output, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return true, err
+	}
+	return true, fileCommandError("tar", string(output))
+}
+
+// planTar adds the tree at local to entries, as name in the archive, following or skipping
+// links like planPush.
+func planTar(t *transfer, entries *[]tarEntry, local, name string, links int) error {
+	info, err := os.Lstat(local)
+	if err != nil {
+		return err
+	}
+	entry := tarEntry{local: local, name: name, info: info}
+	if info.Mode()&os.ModeSymlink != 0 {
+		switch t.opts.Symlinks {
+		case SymlinkSkip:
+			return nil
+		case SymlinkPreserve:
+			if entry.link, err = os.Readlink(local); err != nil {
+				return err
+			}
+			entry.link = filepath.ToSlash(entry.link)
+			t.progress.TotalFiles++
+			*entries = append(*entries, entry)
+			return nil
+		}
+		if links++; links > maxSymlinkDepth {
+			return &os.PathError{Op: "push", Path: local, Err: syscall.ELOOP}
+		}
+		if entry.info, err = os.Stat(local); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case entry.info.IsDir():
+		*entries = append(*entries, entry)
+		children, err := ioutil.ReadDir(local)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if err := planTar(t, entries, filepath.Join(local, child.Name()), path.Join(name, child.Name()), links); err != nil {
+				return err
+			}
+		}
+	case entry.info.Mode().IsRegular():
+		t.progress.TotalFiles++
+		t.progress.TotalBytes += entry.info.Size()
+		*entries = append(*entries, entry)
+	}
+	return nil
+}
+
+func writeTarEntry(t *transfer, archive *tar.Writer, entry tarEntry) error {
+	header, err := tar.FileInfoHeader(entry.info, entry.link)
+	if err != nil {
+		return err
+	}
+	if entry.link != "" {
+		// FileInfoHeader describes what the link points to if the info was from Stat.
+		header.Typeflag, header.Size = tar.TypeSymlink, 0
+	}
+	header.Name = entry.name
+	if entry.info.IsDir() {
+		header.Name += "/"
+	}
+	// Files pushed with sync are owned by the shell user, so don't restore the host's owner.
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	if err := archive.WriteHeader(header); err != nil {
+		return errors.WrapErrorf(err, errors.NetworkError, "error streaming archive")
+	}
+
+	if header.Typeflag == tar.TypeReg {
+		file, err := os.Open(entry.local)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(archive, io.TeeReader(file, t))
+		file.Close()
+		if err != nil {
+			return errors.WrapErrorf(err, errors.NetworkError, "error streaming %s", entry.local)
+		}
+	}
+	if !entry.info.IsDir() {
+		t.fileDone()
+	}
+	return nil
+}
+
+// pullTar pulls the directory tree at remote, which Stat returned entry for, to local as a
+// single archive created by tar on the device. Returns false, without pulling anything, if
+// remote isn't a directory or the device has no tar.
+func (c *Device) pullTar(t *transfer, remote, local string, entry *DirEntry) (bool, error) {
+	if !entry.Mode.IsDir() {
+		return false, nil
+	}
+	if ok, err := c.hasTar(); !ok || err != nil {
+		return false, err
+	}
+
+	// tar's errors are printed after the archive, since anything else on stdout would corrupt
+	// it, and exec: doesn't separate stderr.
+	create := "-cf"
+	if t.opts.Symlinks == SymlinkFollow {
+		create = "-chf"
+	}
+	script := `f=/data/local/tmp/goadb-tar-$$.err; tar ` + create + ` - -C ` + quoteShellArg(remote) +
+		` . 2>"$f"; cat "$f"; rm -f "$f"`
+	conn, err := c.openService("exec:" + quoteShellArgs("sh", "-c", script))
+	if err != nil {
+		return true, err
+	}
+	defer conn.Close()
+
+	if err := os.MkdirAll(local, entry.Mode.Perm()); err != nil {
+		return true, err
+	}
+	archive := tar.NewReader(conn)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return true, errors.WrapErrorf(err, errors.ParseError, "invalid archive from tar")
+		}
+		if err := extractTarEntry(t, archive, header, local, t); err != nil {
+			return true, err
+		}
+	}
+
+	output, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return true, err
+	}
+	// The end of the archive may be padded with zeros.
+	return true, fileCommandError("tar", string(bytes.Trim(output, "\x00")))
+}
+
+// extractTarEntry extracts the entry for header to the tree at local, writing the data of files
+// to counter as well. Sockets, devices, and pipes are skipped, like PullDir does.
+func extractTarEntry(t *transfer, archive *tar.Reader, header *tar.Header, local string, counter io.Writer) error {
+	target, err := tarEntryPath(local, header.Name)
+	if err != nil || target == local {
+		return err
+	}
+	mode := header.FileInfo().Mode()
+	if header.Typeflag == tar.TypeDir {
+		return os.MkdirAll(target, mode.Perm())
+	}
+	// Archives don't have to list directories before their files.
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	switch header.Typeflag {
+	case tar.TypeReg:
+		// Replace a symlink instead of writing through it.
+		if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			os.Remove(target)
+		}
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.MultiWriter(file, counter), archive); err != nil {
+			file.Close()
+			return errors.WrapErrorf(err, errors.NetworkError, "error pulling %s", header.Name)
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		if err := os.Chtimes(target, header.ModTime, header.ModTime); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if t.opts.Symlinks == SymlinkSkip {
+			return nil
+		}
+		// Replace any existing file, like pulled files replace existing files.
+		os.Remove(target)
+		if err := os.Symlink(filepath.FromSlash(header.Linkname), target); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		source, err := tarEntryPath(local, header.Linkname)
+		if err != nil {
+			return err
+		}
+		os.Remove(target)
+		if err := os.Link(source, target); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	t.fileDone()
+	return nil
+}
+
+// tarEntryPath returns where name from an archive is extracted to in local, refusing names
+// that would be outside it, including through a symlink an earlier entry created, e.g. ./x
+// pointing to $HOME followed by ./x/.bashrc.
+func tarEntryPath(local, name string) (string, error) {
+	name = path.Clean(name)
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", errors.Errorf(errors.ParseError, "invalid path in archive: %s", name)
+	}
+
+	dir := local
+	for _, component := range strings.Split(path.Dir(name), "/") {
+		if component == "." {
+			continue
+		}
+		dir = filepath.Join(dir, component)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			// Neither is anything below it.
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", errors.Errorf(errors.ParseError, "invalid path in archive: %s is under a symlink", name)
+		}
+	}
+	return filepath.Join(local, filepath.FromSlash(name)), nil
+}
+
+/*
+PullDirCompressed copies the directory tree at remoteDir on the device to localDir like PullDir,
+but has the device compress the tree into a single tar.gz in /data/local/tmp first, then pulls
+and extracts it, and removes it from the device, even if the pull fails. Trees of many small or
+compressible files, e.g. sharded test output, pull much faster. Needs room for the archive on
+the device.
+
+Progress reports the bytes of the archive, and the files extracted so far; TotalFiles stays 0.
+Workers and Tar are ignored. Falls back to PullDir with Tar if the device has no tar or gzip.
+*/
+func (c *Device) PullDirCompressed(remoteDir, localDir string, opts TransferOptions) error {
+	t := &transfer{opts: opts}
+	ok, err := c.pullCompressed(t, remoteDir, localDir)
+	if err == nil && !ok {
+		opts.Tar = true
+		return c.PullDir(remoteDir, localDir, opts)
+	}
+	return wrapClientError(err, c, "PullDirCompressed(%s, %s)", remoteDir, localDir)
+}
+
+// pullCompressed returns false, without pulling anything, if remote isn't a directory or the
+// device can't compress it.
+func (c *Device) pullCompressed(t *transfer, remote, local string) (bool, error) {
+	entry, err := c.Stat(remote)
+	if err != nil {
+		return true, err
+	}
+	if !entry.Mode.IsDir() {
+		return false, nil
+	}
+	for _, cmd := range []string{"tar", "gzip"} {
+		output, err := c.runShellCommand("command -v " + cmd)
+		if err != nil {
+			return true, err
+		}
+		if strings.TrimSpace(output) == "" {
+			return false, nil
+		}
+	}
+
+	archivePath := fmt.Sprintf("/data/local/tmp/goadb-%d.tar.gz", time.Now().UnixNano())
+	// Removed even if tar or the pull fails, which may leave a partial archive.
+	defer c.runShellCommand(quoteShellArgs("rm", "-f", archivePath))
+	create := "-czf"
+	if t.opts.Symlinks == SymlinkFollow {
+		create = "-chzf"
+	}
+	output, err := c.runShellCommand(quoteShellArgs("tar", create, archivePath, "-C", remote, ".") + " 2>&1")
+	if err != nil {
+		return true, err
+	}
+	if err := fileCommandError("tar", output); err != nil {
+		return true, err
+	}
+
+	archiveEntry, err := c.Stat(archivePath)
+	if err != nil {
+		return true, err
+	}
+	t.progress.TotalBytes = int64(archiveEntry.Size)
+	reader, err := c.OpenRead(archivePath)
+	if err != nil {
+		return true, err
+	}
+	defer reader.Close()
+
+	if err := os.MkdirAll(local, entry.Mode.Perm()); err != nil {
+		return true, err
+	}
+	decompressed, err := gzip.NewReader(io.TeeReader(reader, t))
+	if err != nil {
+		return true, errors.WrapErrorf(err, errors.ParseError, "invalid archive from tar")
+	}
+	archive := tar.NewReader(decompressed)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return true, errors.WrapErrorf(err, errors.ParseError, "invalid archive from tar")
+		}
+		if err := extractTarEntry(t, archive, header, local, ioutil.Discard); err != nil {
+			return true, err
+		}
+	}
+}
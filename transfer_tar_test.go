@@ -0,0 +1,253 @@
+package adb
+
+import (
+	"archive/tar"
+	"bytes"
+	stderrors "errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestPushDirTar(t *testing.T) {
+	local := newLocalTree(t)
+	defer os.RemoveAll(local)
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{"command -v tar": "/system/bin/tar\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	var progress TransferProgress
+	err := device.PushDir(local, "/sdcard/dst", TransferOptions{
+		Tar:      true,
+		Symlinks: SymlinkPreserve,
+		Progress: func(p TransferProgress) { progress = p },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TransferProgress{Files: 3, TotalFiles: 3, Bytes: 2, TotalBytes: 2}, progress)
+	assert.Empty(t, s.Files)
+
+	// The archive is written to the exec: service after its request.
+	var i int
+	for i = range s.Requests {
+		if strings.HasPrefix(s.Requests[i], "exec:sh -c") {
+			break
+		}
+	}
+	assert.Equal(t, `exec:sh -c 'mkdir -p /sdcard/dst && tar -xf - -C /sdcard/dst 2>&1'`, s.Requests[i])
+	archive := tar.NewReader(strings.NewReader(strings.Join(s.Requests[i+1:], "")))
+	files := map[string]*tar.Header{}
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		files[header.Name] = header
+	}
+	assert.Equal(t, byte(tar.TypeDir), files["sub/"].Typeflag)
+	assert.Equal(t, int64(0600), files["sub/b.txt"].Mode&0777)
+	assert.Equal(t, byte(tar.TypeSymlink), files["link"].Typeflag)
+	assert.Equal(t, "a.txt", files["link"].Linkname)
+}
+
+func TestPushDirTarFallsBackToSync(t *testing.T) {
+	local := newLocalTree(t)
+	defer os.RemoveAll(local)
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	require.NoError(t, device.PushDir(local, "/sdcard/dst", TransferOptions{Tar: true}))
+	assert.Equal(t, []byte("a"), s.Files["/sdcard/dst/a.txt"].Data)
+}
+
+func newTarTree(t *testing.T, trailer string) string {
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, header := range []*tar.Header{
+		{Name: "./", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "./sub/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "./sub/b.txt", Typeflag: tar.TypeReg, Mode: 0640, Size: 1, ModTime: mtime},
+		{Name: "./link", Typeflag: tar.TypeSymlink, Linkname: "sub/b.txt"},
+	} {
+		require.NoError(t, archive.WriteHeader(header))
+		if header.Size > 0 {
+			archive.Write([]byte("b"))
+		}
+	}
+	require.NoError(t, archive.Close())
+	return buf.String() + trailer
+}
+
+const pullTarCommand = `sh -c 'f=/data/local/tmp/goadb-tar-$$.err; tar -cf - -C /sdcard/src . 2>"$f"; cat "$f"; rm -f "$f"'`
+
+func TestPullDirTar(t *testing.T) {
+	local, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/src/sub/b.txt": {Data: []byte("b")}},
+		ShellOutputs: map[string]string{
+			"command -v tar": "/system/bin/tar\n",
+			pullTarCommand:   newTarTree(t, ""),
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	var progress TransferProgress
+	err = device.PullDir("/sdcard/src", local, TransferOptions{
+		Tar:      true,
+		Symlinks: SymlinkPreserve,
+		Progress: func(p TransferProgress) { progress = p },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TransferProgress{Files: 2, Bytes: 1}, progress)
+	data, err := ioutil.ReadFile(filepath.Join(local, "sub", "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+	info, err := os.Stat(filepath.Join(local, "sub", "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode())
+	assert.Equal(t, 2024, info.ModTime().Year())
+	target, err := os.Readlink(filepath.Join(local, "link"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sub/b.txt", target)
+}
+
+func TestPullDirTarReportsErrors(t *testing.T) {
+	local, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/src/sub/b.txt": {Data: []byte("b")}},
+		ShellOutputs: map[string]string{
+			"command -v tar": "/system/bin/tar\n",
+			pullTarCommand:   newTarTree(t, "tar: ./private: Permission denied\n"),
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	err = device.PullDir("/sdcard/src", local, TransferOptions{Tar: true, Symlinks: SymlinkPreserve})
+	assert.True(t, stderrors.Is(err, ErrPermissionDenied), "%v", err)
+}
+
+func TestTarEntryPath(t *testing.T) {
+	path, err := tarEntryPath("/tmp/dst", "./sub/b.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("/tmp/dst", "sub", "b.txt"), path)
+	_, err = tarEntryPath("/tmp/dst", "../../etc/passwd")
+	assert.True(t, HasErrCode(err, ParseError))
+	_, err = tarEntryPath("/tmp/dst", "/etc/passwd")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestPullDirTarRefusesPathsThroughSymlinks(t *testing.T) {
+	outside, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(outside)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0644))
+
+	for _, headers := range [][]*tar.Header{
+		{
+			{Name: "./x", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "./x/.bashrc", Typeflag: tar.TypeReg, Mode: 0644, Size: 1},
+		},
+		{
+			{Name: "./x", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "./x/sub/", Typeflag: tar.TypeDir, Mode: 0755},
+		},
+		{
+			{Name: "./x", Typeflag: tar.TypeSymlink, Linkname: outside},
+			{Name: "./secret", Typeflag: tar.TypeLink, Linkname: "./x/secret"},
+		},
+	} {
+		local, err := ioutil.TempDir("", "goadb")
+		require.NoError(t, err)
+		defer os.RemoveAll(local)
+		var buf bytes.Buffer
+		archive := tar.NewWriter(&buf)
+		for _, header := range headers {
+			require.NoError(t, archive.WriteHeader(header))
+			if header.Size > 0 {
+				archive.Write([]byte("b"))
+			}
+		}
+		require.NoError(t, archive.Close())
+		s := &MockServer{
+			Status: wire.StatusSuccess,
+			Files:  map[string]*MockFile{"/sdcard/src/a.txt": {Data: []byte("a")}},
+			ShellOutputs: map[string]string{
+				"command -v tar": "/system/bin/tar\n",
+				pullTarCommand:   buf.String(),
+			},
+		}
+		device := (&Adb{s}).Device(AnyDevice())
+
+		err = device.PullDir("/sdcard/src", local, TransferOptions{Tar: true, Symlinks: SymlinkPreserve})
+		assert.True(t, HasErrCode(err, ParseError), "%s: %v", headers[1].Name, err)
+		_, err = os.Lstat(filepath.Join(local, "secret"))
+		assert.True(t, os.IsNotExist(err), "%v", err)
+		entries, err := ioutil.ReadDir(outside)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	}
+}
+
+func TestPullDirTarReplacesSymlinkedFile(t *testing.T) {
+	outside, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(outside)
+	secret := filepath.Join(outside, "secret")
+	require.NoError(t, ioutil.WriteFile(secret, []byte("secret"), 0644))
+	local, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	require.NoError(t, archive.WriteHeader(&tar.Header{Name: "./a.txt", Typeflag: tar.TypeSymlink, Linkname: secret}))
+	require.NoError(t, archive.WriteHeader(&tar.Header{Name: "./a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 1}))
+	archive.Write([]byte("a"))
+	require.NoError(t, archive.Close())
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		Files:  map[string]*MockFile{"/sdcard/src/a.txt": {Data: []byte("a")}},
+		ShellOutputs: map[string]string{
+			"command -v tar": "/system/bin/tar\n",
+			pullTarCommand:   buf.String(),
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	require.NoError(t, device.PullDir("/sdcard/src", local, TransferOptions{Tar: true, Symlinks: SymlinkPreserve}))
+	data, err := ioutil.ReadFile(filepath.Join(local, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+	data, err = ioutil.ReadFile(secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(data))
+}
+
+func TestPullDirCompressedFallsBackToSync(t *testing.T) {
+	local, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	device := (&Adb{newRemoteTree()}).Device(AnyDevice())
+
+	require.NoError(t, device.PullDirCompressed("/sdcard/src", local, TransferOptions{}))
+	data, err := ioutil.ReadFile(filepath.Join(local, "sub", "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+}
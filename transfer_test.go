@@ -0,0 +1,163 @@
+package adb
+
+import (
+	stderrors "errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+// newLocalTree creates a directory with a file, a file in a subdirectory, and a link to the
+// first file.
+func newLocalTree(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("b"), 0600))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(dir, "link")))
+	return dir
+}
+
+func TestPushDirSymlinkPolicies(t *testing.T) {
+	local := newLocalTree(t)
+	defer os.RemoveAll(local)
+
+	for _, test := range []struct {
+		Policy     SymlinkPolicy
+		WantLink   bool
+		WantLnCmds int
+	}{
+		{SymlinkFollow, true, 0},
+		{SymlinkPreserve, false, 1},
+		{SymlinkSkip, false, 0},
+	} {
+		s := &MockServer{Status: wire.StatusSuccess}
+		device := (&Adb{s}).Device(AnyDevice())
+
+		assert.NoError(t, device.PushDir(local, "/sdcard/dst", TransferOptions{Symlinks: test.Policy}), test.Policy.String())
+		assert.Equal(t, []byte("a"), s.Files["/sdcard/dst/a.txt"].Data, test.Policy.String())
+		assert.Equal(t, os.FileMode(0600), s.Files["/sdcard/dst/sub/b.txt"].Mode, test.Policy.String())
+		_, pushed := s.Files["/sdcard/dst/link"]
+		assert.Equal(t, test.WantLink, pushed, test.Policy.String())
+
+		var lnCmds int
+		for _, req := range s.Requests {
+			if req == "shell:ln -sfn a.txt /sdcard/dst/link" {
+				lnCmds++
+			}
+		}
+		assert.Equal(t, test.WantLnCmds, lnCmds, test.Policy.String())
+		assert.Contains(t, s.Requests, "shell:mkdir -p -m 755 /sdcard/dst/sub", test.Policy.String())
+	}
+}
+
+func TestPushDirProgress(t *testing.T) {
+	local := newLocalTree(t)
+	defer os.RemoveAll(local)
+	s := &MockServer{Status: wire.StatusSuccess}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	var progress []TransferProgress
+	err := device.PushDir(local, "/sdcard/dst", TransferOptions{
+		Progress: func(p TransferProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+	require.NotEmpty(t, progress)
+	assert.Equal(t, TransferProgress{Files: 3, TotalFiles: 3, Bytes: 3, TotalBytes: 3}, progress[len(progress)-1])
+	for i := 1; i < len(progress); i++ {
+		assert.True(t, progress[i].Bytes >= progress[i-1].Bytes && progress[i].Files >= progress[i-1].Files)
+	}
+}
+
+func TestPushDirStopsAfterError(t *testing.T) {
+	local := newLocalTree(t)
+	defer os.RemoveAll(local)
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{"mkdir -p -m 700 /system/dst": "mkdir: '/system/dst': Read-only file system\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	err := device.PushDir(local, "/system/dst", TransferOptions{Workers: 1})
+	assert.True(t, stderrors.Is(err, ErrReadOnlyFileSystem), "%v", err)
+	assert.Empty(t, s.Files)
+}
+
+func newRemoteTree() *MockServer {
+	return &MockServer{
+		Status: wire.StatusSuccess,
+		Files: map[string]*MockFile{
+			"/sdcard/src/a.txt":     {Data: []byte("a"), Mode: 0640},
+			"/sdcard/src/sub/b.txt": {Data: []byte("b")},
+			"/sdcard/src/link":      {Data: []byte("a.txt"), Mode: os.ModeSymlink},
+		},
+		ShellOutputs: map[string]string{"readlink /sdcard/src/link": "a.txt\n"},
+	}
+}
+
+func TestPullDirFollowsSymlinks(t *testing.T) {
+	local, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	device := (&Adb{newRemoteTree()}).Device(AnyDevice())
+
+	require.NoError(t, device.PullDir("/sdcard/src", local, TransferOptions{}))
+	data, err := ioutil.ReadFile(filepath.Join(local, "sub", "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "b", string(data))
+	data, err = ioutil.ReadFile(filepath.Join(local, "link"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(data))
+	info, err := os.Stat(filepath.Join(local, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode())
+}
+
+func TestPullDirPreservesSymlinks(t *testing.T) {
+	local, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	device := (&Adb{newRemoteTree()}).Device(AnyDevice())
+
+	require.NoError(t, device.PullDir("/sdcard/src", local, TransferOptions{Symlinks: SymlinkPreserve}))
+	target, err := os.Readlink(filepath.Join(local, "link"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a.txt", target)
+}
+
+func TestPullDirSymlinkLoop(t *testing.T) {
+	local, err := ioutil.TempDir("", "goadb")
+	require.NoError(t, err)
+	defer os.RemoveAll(local)
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		Files:        map[string]*MockFile{"/sdcard/src/loop": {Data: []byte("."), Mode: os.ModeSymlink}},
+		ShellOutputs: map[string]string{"readlink /sdcard/src/loop": ".\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	err = device.PullDir("/sdcard/src", local, TransferOptions{})
+	assert.True(t, stderrors.Is(err, syscall.ELOOP), "%v", err)
+}
+
+func TestReadLink(t *testing.T) {
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{"readlink /sdcard/link": "/data/target\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	target, err := device.ReadLink("/sdcard/link")
+	assert.NoError(t, err)
+	assert.Equal(t, "/data/target", target)
+
+	_, err = device.ReadLink("/sdcard/file")
+	assert.True(t, HasErrCode(err, AdbError))
+}
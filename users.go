@@ -0,0 +1,108 @@
+package adb
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// UserID identifies an Android user (or profile). Values match android.os.UserHandle.
+type UserID int
+
+const (
+	// UserSystem is the system user, the only user on most devices.
+	UserSystem UserID = 0
+	// UserAll targets all users, where supported by the command.
+	UserAll UserID = -1
+	// UserCurrent targets the user in the foreground.
+	UserCurrent UserID = -2
+)
+
+// String returns the user as accepted by the --user option of pm, am and settings.
+func (u UserID) String() string {
+	switch u {
+	case UserAll:
+		return "all"
+	case UserCurrent:
+		return "current"
+	default:
+		return strconv.Itoa(int(u))
+	}
+}
+
+// UserInfo describes a user or profile on the device.
+type UserInfo struct {
+	ID      UserID
+	Name    string
+	Flags   int
+	Running bool
+}
+
+// Matches lines of pm list users:
+//
+//	UserInfo{0:Owner:c13} running
+//	UserInfo{10:Work profile:1030}
+var userInfoPattern = regexp.MustCompile(`UserInfo\{(\d+):(.*):([0-9a-fA-F]+)\}(\s+running)?`)
+
+/*
+ForUser returns a Device whose package, activity, settings, and permission operations all
+target user instead of the shell's default user.
+
+E.g. to install an app into a work profile:
+
+	users, _ := device.ListUsers()
+	device.ForUser(users[1].ID).InstallAppByPm(ctx, "/data/local/tmp/app.apk", true, false)
+*/
+func (c *Device) ForUser(user UserID) *Device {
+	scoped := *c
+	scoped.user = &user
+	return &scoped
+}
+
+/*
+ListUsers returns all the users and profiles on the device.
+
+Corresponds to the command:
+
+	adb shell pm list users
+*/
+func (c *Device) ListUsers() ([]*UserInfo, error) {
+	output, err := c.RunCommand("pm", "list", "users")
+	if err != nil {
+		return nil, wrapClientError(err, c, "ListUsers")
+	}
+	users, err := parseUserList(output)
+	return users, wrapClientError(err, c, "ListUsers")
+}
+
+// userArgs returns the --user option for the user the device is scoped to, if any.
+func (c *Device) userArgs() []string {
+	if c.user == nil {
+		return nil
+	}
+	return []string{"--user", c.user.String()}
+}
+
+func parseUserList(output string) ([]*UserInfo, error) {
+	var users []*UserInfo
+	for _, line := range strings.Split(output, "\n") {
+		match := userInfoPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(match[1])
+		flags, _ := strconv.ParseInt(match[3], 16, 32)
+		users = append(users, &UserInfo{
+			ID:      UserID(id),
+			Name:    match[2],
+			Flags:   int(flags),
+			Running: match[4] != "",
+		})
+	}
+	if len(users) == 0 {
+		return nil, errors.Errorf(errors.ParseError, "no users found in: %q", output)
+	}
+	return users, nil
+}
@@ -0,0 +1,42 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseUserList(t *testing.T) {
+	users, err := parseUserList(`Users:
+	UserInfo{0:Owner:c13} running
+	UserInfo{10:Work profile:1030}
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, []*UserInfo{
+		{ID: 0, Name: "Owner", Flags: 0xc13, Running: true},
+		{ID: 10, Name: "Work profile", Flags: 0x1030},
+	}, users)
+
+	_, err = parseUserList("Error: couldn't get users\n")
+	assert.Error(t, err)
+}
+
+func TestForUserScopesCommands(t *testing.T) {
+	s := &MockServer{
+		Status:   wire.StatusSuccess,
+		Messages: []string{"Success\n"},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+	device.capabilities.sdk = 23
+
+	assert.NoError(t, device.ForUser(10).ClearData("com.example"))
+	assert.Equal(t, "shell:pm clear --user 10 com.example", s.Requests[1])
+
+	assert.NoError(t, device.ForUser(UserCurrent).Settings().Put(SettingsSecure, "k", "v"))
+	assert.Equal(t, "shell:settings --user current put secure k v", s.Requests[3])
+
+	// The original device isn't scoped.
+	assert.NoError(t, device.GrantPermission("com.example", "android.permission.CAMERA"))
+	assert.Equal(t, "shell:pm grant com.example android.permission.CAMERA", s.Requests[5])
+}
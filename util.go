@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
+	"github.com/zach-klippenstein/goadb/internal/shellquote"
 )
 
 var (
@@ -21,18 +22,52 @@ func isBlank(str string) bool {
 	return whitespaceRegex.MatchString(str)
 }
 
-func wrapClientError(err error, client interface{}, operation string, args ...interface{}) error {
-	if err == nil {
+// quoteShellArgs joins args into a single command line, quoting each argument so the
+// device shell passes it through unchanged.
+func quoteShellArgs(args ...string) string {
+	return shellquote.Join(args...)
+}
+
+// quoteShellArg wraps arg in single quotes if it contains anything other than
+// characters that are always safe in a POSIX shell.
+func quoteShellArg(arg string) string {
+	return shellquote.Quote(arg)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// commandOutputError returns an AdbError if output from a device command that prints
+// nothing (or a short status) on success contains an error report instead.
+func commandOutputError(command, output string) error {
+	output = strings.TrimSpace(output)
+	if output == "" {
 		return nil
 	}
-	if _, ok := err.(*errors.Err); !ok {
-		panic("err is not a *Err: " + err.Error())
+	if strings.HasPrefix(output, "Error") || strings.Contains(output, "Exception") ||
+		strings.Contains(output, "usage:") || strings.Contains(output, "Usage:") ||
+		strings.HasPrefix(output, "Invalid") || strings.HasPrefix(output, "Unknown") {
+		return errors.Errorf(errors.AdbError, "%s failed: %s", command, output)
 	}
+	return nil
+}
 
+// wrapClientError wraps err, which may be any error, with the operation that failed on client.
+// The code of err is preserved, or chosen by errors.CodeOf if it isn't an *errors.Err.
+func wrapClientError(err error, client interface{}, operation string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
 	clientType := reflect.TypeOf(client)
 
 	return &errors.Err{
-		Code:    err.(*errors.Err).Code,
+		Code:    errors.CodeOf(err),
 		Cause:   err,
 		Message: fmt.Sprintf("error performing %s on %s", fmt.Sprintf(operation, args...), clientType),
 		Details: client,
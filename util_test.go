@@ -1,9 +1,13 @@
 package adb
 
 import (
+	stderrors "errors"
+	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zach-klippenstein/goadb/internal/errors"
 )
 
 func TestContainsWhitespaceYes(t *testing.T) {
@@ -25,3 +29,20 @@ func TestIsBlankWhenJustWhitespace(t *testing.T) {
 func TestIsBlankNo(t *testing.T) {
 	assert.False(t, isBlank("     h   "))
 }
+
+func TestQuoteShellArgs(t *testing.T) {
+	assert.Equal(t, "settings put global k 1", quoteShellArgs("settings", "put", "global", "k", "1"))
+	assert.Equal(t, "echo '' 'a b' 'it'\\''s' '$HOME'", quoteShellArgs("echo", "", "a b", "it's", "$HOME"))
+}
+
+func TestWrapClientErrorForeignError(t *testing.T) {
+	device := &Device{descriptor: AnyDevice()}
+
+	err := wrapClientError(&os.PathError{Op: "open", Path: "/tmp/foo", Err: os.ErrNotExist}, device, "Pull(%s)", "foo")
+	assert.True(t, HasErrCode(err, FileNoExistError))
+	assert.True(t, stderrors.Is(err, os.ErrNotExist))
+
+	err = wrapClientError(fmt.Errorf("something broke"), device, "RunCommand")
+	assert.True(t, HasErrCode(err, AssertionError))
+	assert.EqualError(t, err.(*errors.Err).Cause, "something broke")
+}
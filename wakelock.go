@@ -0,0 +1,311 @@
+package adb
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// DefaultWakeLockSampleInterval is the time between WakeLockSampler snapshots, unless another
+// interval is passed to SampleWakeLocks. Checking in battery stats takes a while, so it's long.
+const DefaultWakeLockSampleInterval = 30 * time.Second
+
+var (
+	// Matches the "PARTIAL_WAKE_LOCK  'AudioMix' ACQ=-1m2s30ms (uid=1041 pid=1234 ws=...)" lines of
+	// the "Wake Locks" section of dumpsys power. Flags like ON_AFTER_RELEASE come before ACQ.
+	wakeLockLinePattern = regexp.MustCompile(`^\s*(\w+)\s+'(.*)'.*?\bACQ=(\S+).*?\(uid=(\d+)(?:\s+pid=(\d+))?`)
+
+	// Matches the "1d2h3m4s5ms" durations dumpsys prints.
+	androidDurationPattern = regexp.MustCompile(`^[-+]?(?:(\d+)d)?(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s)?(?:(\d+)ms)?$`)
+)
+
+// WakeLock is a wake lock an app holds.
+type WakeLock struct {
+	// Type of the lock, e.g. PARTIAL_WAKE_LOCK, which keeps the CPU on with the screen off.
+	Type string
+	// Name the app gave the lock.
+	Tag string
+	// Uid of the process that acquired the lock, which may have done it on behalf of another
+	// app, e.g. the system for alarms.
+	UID int
+	// Packages that run as UID.
+	Packages []string
+	// Pid of the process that acquired the lock, 0 if unknown.
+	PID int
+	// Time since the lock was acquired.
+	Held time.Duration
+}
+
+// WakeLockStats is the time an app held a partial wake lock since the battery stats were
+// reset, or the device was last unplugged.
+type WakeLockStats struct {
+	Tag      string
+	UID      int
+	Packages []string
+	// Total time the lock was held, and the number of times it was acquired.
+	Time  time.Duration
+	Count int
+}
+
+// WakeLockSnapshot is one sample of a WakeLockSampler.
+type WakeLockSnapshot struct {
+	Time time.Time
+	// Wake locks held when the snapshot was taken, longest held first.
+	Held []WakeLock
+	// Partial wake locks held since the stats were reset, longest first.
+	Cumulative []WakeLockStats
+}
+
+/*
+WakeLocks returns the wake locks apps currently hold, longest held first.
+
+Corresponds to the commands:
+
+	adb shell dumpsys power
+	adb shell pm list packages -U
+*/
+func (c *Device) WakeLocks() ([]WakeLock, error) {
+	packages, err := c.wakeLockPackages()
+	if err != nil {
+		return nil, wrapClientError(err, c, "WakeLocks")
+	}
+	locks, err := c.wakeLocks(packages)
+	return locks, wrapClientError(err, c, "WakeLocks")
+}
+
+func (c *Device) wakeLocks(packages map[int][]string) ([]WakeLock, error) {
+	output, err := c.runShellCommand("dumpsys power")
+	if err != nil {
+		return nil, err
+	}
+	locks, err := parseWakeLocks(output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range locks {
+		locks[i].Packages = packages[locks[i].UID]
+	}
+	return locks, nil
+}
+
+/*
+WakeLockStats returns the time apps held partial wake locks since the battery stats were reset,
+see ResetBatteryStats, longest first. Stats are only collected while the device is on battery.
+
+Corresponds to the commands:
+
+	adb shell dumpsys batterystats -c
+	adb shell pm list packages -U
+*/
+func (c *Device) WakeLockStats() ([]WakeLockStats, error) {
+	packages, err := c.wakeLockPackages()
+	if err != nil {
+		return nil, wrapClientError(err, c, "WakeLockStats")
+	}
+	stats, err := c.wakeLockStats(packages)
+	return stats, wrapClientError(err, c, "WakeLockStats")
+}
+
+func (c *Device) wakeLockStats(packages map[int][]string) ([]WakeLockStats, error) {
+	output, err := c.runShellCommand("dumpsys batterystats -c")
+	if err != nil {
+		return nil, err
+	}
+	stats := parseWakeLockCheckin(output)
+	for i := range stats {
+		stats[i].Packages = packages[stats[i].UID]
+	}
+	return stats, nil
+}
+
+// wakeLockPackages returns the names of the installed packages by uid, to attribute wake locks
+// to. pm can't list uids before Android 8.0, so there the locks have no Packages.
+func (c *Device) wakeLockPackages() (map[int][]string, error) {
+	ok, err := c.supportsSDK(sdkListPackagesUID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return c.packagesByUID()
+}
+
+// WakeLockSampler publishes the wake locks apps hold over time.
+type WakeLockSampler struct {
+	snapshotChan chan WakeLockSnapshot
+	// If an error occurs, it is stored here and snapshotChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get snapshots. It's closed when the context
+// passed to SampleWakeLocks is done, or if an error occurs.
+func (s *WakeLockSampler) C() <-chan WakeLockSnapshot {
+	return s.snapshotChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (s *WakeLockSampler) Err() error {
+	if err, ok := s.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+SampleWakeLocks reads the held and cumulative wake locks once per interval, until ctx is done.
+The first snapshot is sent immediately. If interval is 0, DefaultWakeLockSampleInterval is used.
+
+Packages are looked up once, when sampling starts, so the locks of apps installed later have no
+Packages.
+
+Soak tests can use it to find which apps keep the device awake in the background:
+
+	device.ResetBatteryStats()
+	sampler, err := device.SampleWakeLocks(ctx, time.Minute)
+	for snapshot := range sampler.C() {
+		for _, stats := range snapshot.Cumulative {
+			fmt.Println(stats.Packages, stats.Tag, stats.Time)
+		}
+	}
+*/
+func (c *Device) SampleWakeLocks(ctx context.Context, interval time.Duration) (*WakeLockSampler, error) {
+	if interval <= 0 {
+		interval = DefaultWakeLockSampleInterval
+	}
+	packages, err := c.wakeLockPackages()
+	if err != nil {
+		return nil, wrapClientError(err, c, "SampleWakeLocks")
+	}
+
+	sampler := &WakeLockSampler{snapshotChan: make(chan WakeLockSnapshot)}
+	go func() {
+		defer close(sampler.snapshotChan)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			held, err := c.wakeLocks(packages)
+			var cumulative []WakeLockStats
+			if err == nil {
+				cumulative, err = c.wakeLockStats(packages)
+			}
+			if err != nil {
+				sampler.err.Store(wrapClientError(err, c, "SampleWakeLocks"))
+				return
+			}
+
+			select {
+			case sampler.snapshotChan <- WakeLockSnapshot{Time: time.Now(), Held: held, Cumulative: cumulative}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return sampler, nil
+}
+
+// parseWakeLocks parses the "Wake Locks: size=N" section of dumpsys power.
+func parseWakeLocks(output string) ([]WakeLock, error) {
+	var locks []WakeLock
+	inLocks, found := false, false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "Wake Locks:") {
+			inLocks, found = true, true
+			continue
+		}
+		if !inLocks {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		match := wakeLockLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		lock := WakeLock{Type: match[1], Tag: match[2]}
+		held, ok := parseAndroidDuration(match[3])
+		if !ok {
+			return nil, errors.Errorf(errors.ParseError, "invalid wake lock acquire time: %q", line)
+		}
+		lock.Held = held
+		lock.UID, _ = strconv.Atoi(match[4])
+		lock.PID, _ = strconv.Atoi(match[5])
+		locks = append(locks, lock)
+	}
+	if !found {
+		return nil, errors.Errorf(errors.ParseError, "no wake locks in dumpsys power output")
+	}
+	sort.SliceStable(locks, func(i, j int) bool { return locks[i].Held > locks[j].Held })
+	return locks, nil
+}
+
+// parseWakeLockCheckin parses the partial wake locks of the "9,<uid>,l,wl,<tag>,<full ms>,f,...
+// <partial ms>,p,<count>,..." lines of dumpsys batterystats -c. Each wake lock type has
+// a time, a letter, and a count, followed by more durations on newer releases.
+func parseWakeLockCheckin(output string) []WakeLockStats {
+	var stats []WakeLockStats
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(strings.TrimRight(line, "\r"), ",")
+		if len(fields) < 8 || fields[2] != "l" || fields[3] != "wl" {
+			continue
+		}
+		// Tags may contain commas, so find the full lock's letter to know where the tag ends.
+		full := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "f" {
+				full = i
+				break
+			}
+		}
+		if full < 0 {
+			continue
+		}
+		for i := full + 1; i < len(fields)-1; i++ {
+			if fields[i] != "p" {
+				continue
+			}
+			uid, err := strconv.Atoi(fields[1])
+			ms, err2 := strconv.ParseInt(fields[i-1], 10, 64)
+			count, err3 := strconv.Atoi(fields[i+1])
+			if err != nil || err2 != nil || err3 != nil || ms == 0 {
+				break
+			}
+			stats = append(stats, WakeLockStats{
+				Tag:   strings.Join(fields[4:full-1], ","),
+				UID:   uid,
+				Time:  time.Duration(ms) * time.Millisecond,
+				Count: count,
+			})
+			break
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Time > stats[j].Time })
+	return stats
+}
+
+// parseAndroidDuration parses a duration formatted by dumpsys, e.g. "-1m2s30ms", ignoring the
+// sign.
+func parseAndroidDuration(s string) (time.Duration, bool) {
+	match := androidDurationPattern.FindStringSubmatch(s)
+	if match == nil || strings.Trim(s, "-+") == "" {
+		return 0, false
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second, time.Millisecond} {
+		value, _ := strconv.Atoi(match[i+1])
+		d += time.Duration(value) * unit
+	}
+	return d, true
+}
@@ -0,0 +1,103 @@
+package adb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+const testDumpsysPower = `POWER MANAGER (dumpsys power)
+
+Wake Locks: size=3
+  PARTIAL_WAKE_LOCK              'AudioMix' ACQ=-5s (uid=1041 pid=1234)
+  PARTIAL_WAKE_LOCK              'sync' ON_AFTER_RELEASE ACQ=-1h2m3s4ms (uid=10123 pid=4567 ws=WorkSource{10123})
+  SCREEN_BRIGHT_WAKE_LOCK        'WindowManager' ACQ=-250ms (uid=1000)
+
+Suspend Blockers: size=1
+  PowerManagerService.WakeLocks: ref count=1
+`
+
+const testBatteryStatsCheckin = `9,0,i,vers,36,214,SP1A,SP1A
+9,0,i,uid,10123,com.example
+9,10123,l,wl,sync,0,f,0,0,0,0,62000,p,4,0,30000,62000,0,w,0,0,0,0
+9,10123,l,wl,with,comma,0,f,0,1500,p,2,0,w,0
+9,1000,l,wl,never,0,f,0,0,p,0,0,w,0
+9,1000,c,wl,current,0,f,0,900,p,1,0,w,0
+`
+
+func TestParseWakeLocks(t *testing.T) {
+	locks, err := parseWakeLocks(testDumpsysPower)
+	require.NoError(t, err)
+	assert.Equal(t, []WakeLock{
+		{Type: "PARTIAL_WAKE_LOCK", Tag: "sync", UID: 10123, PID: 4567, Held: time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond},
+		{Type: "PARTIAL_WAKE_LOCK", Tag: "AudioMix", UID: 1041, PID: 1234, Held: 5 * time.Second},
+		{Type: "SCREEN_BRIGHT_WAKE_LOCK", Tag: "WindowManager", UID: 1000, Held: 250 * time.Millisecond},
+	}, locks)
+
+	_, err = parseWakeLocks("Permission Denial: can't dump PowerManager\n")
+	assert.True(t, HasErrCode(err, ParseError))
+}
+
+func TestParseWakeLockCheckin(t *testing.T) {
+	assert.Equal(t, []WakeLockStats{
+		{Tag: "sync", UID: 10123, Time: 62 * time.Second, Count: 4},
+		{Tag: "with,comma", UID: 10123, Time: 1500 * time.Millisecond, Count: 2},
+	}, parseWakeLockCheckin(testBatteryStatsCheckin))
+}
+
+func TestParseAndroidDuration(t *testing.T) {
+	d, ok := parseAndroidDuration("+1d0h2m30ms")
+	assert.True(t, ok)
+	assert.Equal(t, 24*time.Hour+2*time.Minute+30*time.Millisecond, d)
+
+	_, ok = parseAndroidDuration("-")
+	assert.False(t, ok)
+	_, ok = parseAndroidDuration("5 seconds")
+	assert.False(t, ok)
+}
+
+func TestSampleWakeLocks(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk": "30\n",
+			"cmd package list packages -U": "package:com.example uid:10123\n",
+			"dumpsys power":                testDumpsysPower,
+			"dumpsys batterystats -c":      testBatteryStatsCheckin,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sampler, err := device.SampleWakeLocks(ctx, time.Hour)
+	require.NoError(t, err)
+	snapshot := <-sampler.C()
+	require.Len(t, snapshot.Held, 3)
+	assert.Equal(t, []string{"com.example"}, snapshot.Held[0].Packages)
+	assert.Empty(t, snapshot.Held[1].Packages)
+	require.Len(t, snapshot.Cumulative, 2)
+	assert.Equal(t, []string{"com.example"}, snapshot.Cumulative[0].Packages)
+}
+
+func TestWakeLocksWithoutUIDs(t *testing.T) {
+	// pm can't list uids before Android 8.0, so it isn't run.
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"getprop ro.build.version.sdk": "25\n",
+			"dumpsys power":                testDumpsysPower,
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	locks, err := device.WakeLocks()
+	require.NoError(t, err)
+	require.Len(t, locks, 3)
+	assert.Empty(t, locks[0].Packages)
+	assert.NotContains(t, s.Requests, "shell:pm list packages -U")
+}
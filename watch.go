@@ -0,0 +1,177 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// PathEventType is the kind of change reported by a PathWatcher.
+//
+//go:generate stringer -type=PathEventType
+type PathEventType int8
+
+const (
+	PathCreated PathEventType = iota
+	// A file was closed after being written.
+	PathModified
+	PathDeleted
+	// A file was moved into or out of the watched directory.
+	PathMovedIn
+	PathMovedOut
+)
+
+// inotifyd event characters for each PathEventType.
+const inotifydEvents = "nwdym"
+
+// PathEvent is a change to a file in a directory watched by a PathWatcher.
+type PathEvent struct {
+	Type PathEventType
+	// Path of the file that changed, on the device.
+	Path string
+}
+
+// devicePushedBusyboxPath is where the busybox binary is pushed to.
+const devicePushedBusyboxPath = "/data/local/tmp/goadb-busybox"
+
+// PathWatcher publishes changes to the files in a directory on the device.
+type PathWatcher struct {
+	eventChan chan PathEvent
+	// If an error occurs, it is stored here and eventChan is closed immediately after.
+	err atomic.Value
+}
+
+// C returns a channel that can be received on to get events. It's closed when the context
+// passed to WatchPath is done, or if an error occurs.
+func (w *PathWatcher) C() <-chan PathEvent {
+	return w.eventChan
+}
+
+// Err returns the error that caused the channel returned by C to be closed, if C is closed.
+// If C is not closed, its return value is undefined.
+func (w *PathWatcher) Err() error {
+	if err, ok := w.err.Load().(error); ok {
+		return err
+	}
+	return nil
+}
+
+/*
+WatchPath watches the directory at path for files being created, written, deleted, or moved,
+until ctx is done. It uses inotifyd, from toybox or busybox; see HostTools for devices that
+have neither.
+
+E.g. to wait for an app to write its output:
+
+	watcher, err := device.WatchPath(ctx, "/sdcard/Android/data/com.example/files")
+	for event := range watcher.C() {
+		if event.Type == adb.PathModified && strings.HasSuffix(event.Path, ".json") {
+			...
+		}
+	}
+
+Corresponds to the command:
+
+	adb shell inotifyd - <path>:nwdym
+*/
+func (c *Device) WatchPath(ctx context.Context, path string) (*PathWatcher, error) {
+	inotifyd, err := c.inotifydCommand()
+	if err != nil {
+		return nil, wrapClientError(err, c, "WatchPath(%s)", path)
+	}
+
+	args := append(inotifyd, "-", path+":"+inotifydEvents)
+	conn, err := c.openService("shell:" + quoteShellArgs(args...))
+	if err != nil {
+		return nil, wrapClientError(err, c, "WatchPath(%s)", path)
+	}
+
+	watcher := &PathWatcher{eventChan: make(chan PathEvent)}
+	go func() {
+		defer close(watcher.eventChan)
+		output := newContextReader(ctx, conn)
+		defer output.Close()
+
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			event, err := parseInotifydEvent(scanner.Text())
+			if err != nil {
+				watcher.err.Store(wrapClientError(err, c, "WatchPath(%s)", path))
+				return
+			}
+			select {
+			case watcher.eventChan <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			watcher.err.Store(wrapClientError(err, c, "WatchPath(%s)", path))
+		}
+	}()
+	return watcher, nil
+}
+
+// inotifydCommand returns the command line that runs inotifyd on the device, pushing
+// busybox if needed.
+func (c *Device) inotifydCommand() ([]string, error) {
+	for _, cmd := range [][]string{{"inotifyd"}, {"busybox", "inotifyd"}, {devicePushedBusyboxPath, "inotifyd"}} {
+		output, err := c.runShellCommand("command -v " + quoteShellArg(cmd[0]))
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(output) != "" {
+			return cmd, nil
+		}
+	}
+
+	busybox := c.server.tools().Busybox
+	if busybox == "" {
+		return nil, errors.Errorf(errors.AdbError, "inotifyd not found on device, and ServerConfig.Tools.Busybox not set")
+	}
+	if err := c.pushTool("busybox", busybox, devicePushedBusyboxPath); err != nil {
+		return nil, err
+	}
+	return []string{devicePushedBusyboxPath, "inotifyd"}, nil
+}
+
+// pushTool pushes the executable at localPath, on the host, to remotePath.
+func (c *Device) pushTool(name, localPath, remotePath string) error {
+	local, err := os.Open(localPath)
+	if err != nil {
+		return errors.WrapErrorf(err, errors.FileNoExistError, "error opening %s: %s", name, localPath)
+	}
+	defer local.Close()
+
+	writer, err := c.OpenWrite(remotePath, 0755, MtimeOfClose)
+	if err != nil {
+		return err
+	}
+	if _, err := bufio.NewReader(local).WriteTo(writer); err != nil {
+		writer.Close()
+		return errors.WrapErrorf(err, errors.NetworkError, "error pushing %s", name)
+	}
+	return writer.Close()
+}
+
+// parseInotifydEvent parses a line printed by inotifyd -, e.g. "n\t/sdcard/dir\tfile".
+func parseInotifydEvent(line string) (PathEvent, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return PathEvent{}, errors.Errorf(errors.ParseError, "invalid inotifyd output: %q", line)
+	}
+	i := strings.Index(inotifydEvents, fields[0])
+	if i < 0 {
+		return PathEvent{}, errors.Errorf(errors.ParseError, "unexpected inotifyd event: %q", line)
+	}
+	event := PathEvent{Type: PathEventType(i), Path: fields[1]}
+	if len(fields) > 2 {
+		event.Path = path.Join(fields[1], fields[2])
+	}
+	return event, nil
+}
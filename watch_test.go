@@ -0,0 +1,77 @@
+package adb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zach-klippenstein/goadb/wire"
+)
+
+func TestParseInotifydEvent(t *testing.T) {
+	event, err := parseInotifydEvent("n\t/sdcard/out\tresult.json")
+	assert.NoError(t, err)
+	assert.Equal(t, PathEvent{Type: PathCreated, Path: "/sdcard/out/result.json"}, event)
+
+	event, err = parseInotifydEvent("w\t/sdcard/out/log.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, PathEvent{Type: PathModified, Path: "/sdcard/out/log.txt"}, event)
+
+	_, err = parseInotifydEvent("x\t/sdcard/out")
+	assert.Error(t, err)
+	_, err = parseInotifydEvent("inotifyd: /sdcard/missing: No such file or directory")
+	assert.Error(t, err)
+}
+
+func TestWatchPath(t *testing.T) {
+	s := &MockServer{
+		Status: wire.StatusSuccess,
+		ShellOutputs: map[string]string{
+			"command -v inotifyd":                  "",
+			"command -v busybox":                   "/system/xbin/busybox\n",
+			"busybox inotifyd - /sdcard/out:nwdym": "n\t/sdcard/out\ta.json\nw\t/sdcard/out\ta.json\nm\t/sdcard/out\ta.json\n",
+		},
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	watcher, err := device.WatchPath(context.Background(), "/sdcard/out")
+	require.NoError(t, err)
+	var events []PathEvent
+	for event := range watcher.C() {
+		events = append(events, event)
+	}
+	assert.NoError(t, watcher.Err())
+	assert.Equal(t, []PathEvent{
+		{PathCreated, "/sdcard/out/a.json"},
+		{PathModified, "/sdcard/out/a.json"},
+		{PathMovedOut, "/sdcard/out/a.json"},
+	}, events)
+}
+
+func TestWatchPathWithoutInotifyd(t *testing.T) {
+	s := &MockServer{Status: wire.StatusSuccess, ShellOutputs: map[string]string{}}
+	for _, cmd := range []string{"inotifyd", "busybox", devicePushedBusyboxPath} {
+		s.ShellOutputs["command -v "+cmd] = ""
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.WatchPath(context.Background(), "/sdcard/out")
+	assert.Contains(t, ErrorWithCauseChain(err), "ServerConfig.Tools.Busybox not set")
+}
+
+func TestWatchPathPushesClientBusybox(t *testing.T) {
+	s := &MockServer{
+		Status:       wire.StatusSuccess,
+		ShellOutputs: map[string]string{},
+		Tools:        HostTools{Busybox: "/nonexistent/busybox"},
+	}
+	for _, cmd := range []string{"inotifyd", "busybox", devicePushedBusyboxPath} {
+		s.ShellOutputs["command -v "+cmd] = ""
+	}
+	device := (&Adb{s}).Device(AnyDevice())
+
+	_, err := device.WatchPath(context.Background(), "/sdcard/out")
+	assert.True(t, HasErrCode(err, FileNoExistError))
+	assert.Contains(t, ErrorWithCauseChain(err), "error opening busybox: /nonexistent/busybox")
+}
@@ -0,0 +1,158 @@
+package adb
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
+
+// WifiSecurity is the security type of a Wi-Fi network, as accepted by cmd wifi connect-network.
+type WifiSecurity string
+
+const (
+	WifiOpen WifiSecurity = "open"
+	WifiOWE  WifiSecurity = "owe"
+	WifiWPA2 WifiSecurity = "wpa2"
+	WifiWPA3 WifiSecurity = "wpa3"
+)
+
+// WifiNetwork describes a network to join with ConnectWifi.
+type WifiNetwork struct {
+	SSID string
+	// Ignored for open and OWE networks.
+	Passphrase string
+	// Defaults to WifiWPA2 if Passphrase is set, else WifiOpen.
+	Security WifiSecurity
+}
+
+const (
+	// Helper app used to join networks on builds without cmd wifi connect-network.
+	// See https://github.com/steinwurf/adb-join-wifi.
+	wifiJoinHelperComponent = "com.steinwurf.adbjoinwifi/.MainActivity"
+
+	// How often ConnectWifi checks whether the device has joined the network.
+	wifiPollInterval = time.Second
+)
+
+var (
+	// Matches the connected SSID from cmd wifi status (API 30+):
+	//	Wifi is connected to "MyNetwork"
+	wifiStatusSSIDPattern = regexp.MustCompile(`Wifi is connected to "(.*)"`)
+
+	// Matches the connected SSID from dumpsys wifi on older builds:
+	//	mWifiInfo SSID: "MyNetwork", BSSID: ..., Supplicant state: COMPLETED, ...
+	wifiInfoSSIDPattern = regexp.MustCompile(`mWifiInfo SSID: "?([^",]*)"?,.*Supplicant state: COMPLETED`)
+)
+
+/*
+ConnectWifi joins network and waits until the device is connected to it, or ctx is done.
+Wi-Fi is turned on first if necessary.
+
+On API 30+ this uses cmd wifi connect-network. Older builds need the adb-join-wifi
+helper app (com.steinwurf.adbjoinwifi) to be installed.
+*/
+func (c *Device) ConnectWifi(ctx context.Context, network WifiNetwork) error {
+	if network.SSID == "" {
+		return wrapClientError(errors.AssertionErrorf("SSID cannot be empty"), c, "ConnectWifi")
+	}
+	if network.Security == "" {
+		network.Security = WifiOpen
+		if network.Passphrase != "" {
+			network.Security = WifiWPA2
+		}
+	}
+
+	if err := c.SetWifiEnabled(true); err != nil {
+		return err
+	}
+
+	sdk, err := c.sdkVersion()
+	if err != nil {
+		return err
+	}
+
+	var output string
+	if sdk >= sdkCmdWifi {
+		args := []string{"cmd", "wifi", "connect-network", network.SSID, string(network.Security)}
+		if network.Security != WifiOpen && network.Security != WifiOWE {
+			args = append(args, network.Passphrase)
+		}
+		output, err = c.runShellCommand(quoteShellArgs(args...))
+	} else {
+		output, err = c.runShellCommand(quoteShellArgs(wifiJoinHelperArgs(network)...))
+		if err == nil && strings.Contains(output, "does not exist") {
+			err = errors.Errorf(errors.AdbError, "%s is not installed", wifiJoinHelperComponent)
+		}
+	}
+	if err == nil {
+		err = commandOutputError("connect-network", output)
+	}
+	if err != nil {
+		return wrapClientError(err, c, "ConnectWifi(%s)", network.SSID)
+	}
+
+	ticker := time.NewTicker(wifiPollInterval)
+	defer ticker.Stop()
+	for {
+		ssid, err := c.WifiSSID()
+		if err != nil {
+			return err
+		}
+		if ssid == network.SSID {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return wrapClientError(errors.WrapErrorf(ctx.Err(), errors.NetworkError,
+				"timed out waiting to connect to %s", network.SSID), c, "ConnectWifi(%s)", network.SSID)
+		case <-ticker.C:
+		}
+	}
+}
+
+// WifiSSID returns the SSID of the Wi-Fi network the device is connected to,
+// or "" if it isn't connected.
+func (c *Device) WifiSSID() (string, error) {
+	sdk, err := c.sdkVersion()
+	if err != nil {
+		return "", err
+	}
+
+	if sdk >= sdkCmdWifi {
+		output, err := c.RunCommand("cmd", "wifi", "status")
+		if err != nil {
+			return "", wrapClientError(err, c, "WifiSSID")
+		}
+		return parseWifiSSID(output, wifiStatusSSIDPattern), nil
+	}
+
+	output, err := c.RunCommand("dumpsys", "wifi")
+	if err != nil {
+		return "", wrapClientError(err, c, "WifiSSID")
+	}
+	return parseWifiSSID(output, wifiInfoSSIDPattern), nil
+}
+
+func wifiJoinHelperArgs(network WifiNetwork) []string {
+	passwordType := "WPA"
+	if network.Security == WifiOpen || network.Security == WifiOWE {
+		passwordType = "NONE"
+	}
+	args := []string{"am", "start", "-n", wifiJoinHelperComponent,
+		"-e", "ssid", network.SSID, "-e", "password_type", passwordType}
+	if passwordType != "NONE" {
+		args = append(args, "-e", "password", network.Passphrase)
+	}
+	return args
+}
+
+func parseWifiSSID(output string, pattern *regexp.Regexp) string {
+	if match := pattern.FindStringSubmatch(output); match != nil && match[1] != "<unknown ssid>" {
+		return match[1]
+	}
+	return ""
+}
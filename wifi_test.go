@@ -0,0 +1,32 @@
+package adb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWifiSSID(t *testing.T) {
+	assert.Equal(t, "Lab Net", parseWifiSSID(`Wifi is enabled
+Wifi scanning is only available when wifi is enabled
+==== Primary ClientModeManager instance ====
+Wifi is connected to "Lab Net"
+WifiInfo: SSID: "Lab Net", BSSID: 02:00:00:00:00:00`, wifiStatusSSIDPattern))
+	assert.Equal(t, "", parseWifiSSID("Wifi is enabled\nWifi is not connected\n", wifiStatusSSIDPattern))
+
+	assert.Equal(t, "Lab", parseWifiSSID(
+		`mWifiInfo SSID: "Lab", BSSID: 00:11:22:33:44:55, MAC: 02:00:00:00:00:00, Supplicant state: COMPLETED, RSSI: -50`,
+		wifiInfoSSIDPattern))
+	assert.Equal(t, "", parseWifiSSID(
+		`mWifiInfo SSID: <unknown ssid>, BSSID: <none>, MAC: 02:00:00:00:00:00, Supplicant state: DISCONNECTED`,
+		wifiInfoSSIDPattern))
+}
+
+func TestWifiJoinHelperArgs(t *testing.T) {
+	assert.Equal(t,
+		"am start -n com.steinwurf.adbjoinwifi/.MainActivity -e ssid 'Lab Net' -e password_type WPA -e password 's3cr3t!'",
+		quoteShellArgs(wifiJoinHelperArgs(WifiNetwork{SSID: "Lab Net", Passphrase: "s3cr3t!", Security: WifiWPA2})...))
+	assert.Equal(t,
+		"am start -n com.steinwurf.adbjoinwifi/.MainActivity -e ssid Guest -e password_type NONE",
+		quoteShellArgs(wifiJoinHelperArgs(WifiNetwork{SSID: "Guest", Security: WifiOpen})...))
+}
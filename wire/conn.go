@@ -1,6 +1,10 @@
 package wire
 
-import "github.com/zach-klippenstein/goadb/internal/errors"
+import (
+	"time"
+
+	"github.com/zach-klippenstein/goadb/internal/errors"
+)
 
 const (
 	// The official implementation of adb imposes an undocumented 255-byte limit
@@ -38,6 +42,49 @@ func NewConn(scanner Scanner, sender Sender) *Conn {
 	return &Conn{scanner, sender}
 }
 
+// Deadliner is implemented by connections whose reads and writes can time out, e.g. net.Conn.
+type Deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// ReadDeadliner and WriteDeadliner are implemented by connections whose reads and writes can
+// time out separately, e.g. net.Conn.
+type ReadDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type WriteDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// SetDeadline sets the time after which reads and writes on the connection, including in sync
+// mode, fail with a timeout error. A zero t disables the deadline.
+// If the underlying connection doesn't implement Deadliner, does nothing.
+func (conn *Conn) SetDeadline(t time.Time) error {
+	if d, ok := conn.Scanner.(Deadliner); ok {
+		return d.SetDeadline(t)
+	}
+	return nil
+}
+
+// SetReadDeadline is like SetDeadline, but only for reads. If the Scanner doesn't implement
+// ReadDeadliner, does nothing.
+func (conn *Conn) SetReadDeadline(t time.Time) error {
+	if d, ok := conn.Scanner.(ReadDeadliner); ok {
+		return d.SetReadDeadline(t)
+	}
+	return nil
+}
+
+// SetWriteDeadline is like SetDeadline, but only for writes. If the Sender doesn't implement
+// WriteDeadliner, does nothing.
+func (conn *Conn) SetWriteDeadline(t time.Time) error {
+	if d, ok := conn.Sender.(WriteDeadliner); ok {
+		return d.SetWriteDeadline(t)
+	}
+	return nil
+}
+
 // NewSyncConn returns connection that can operate in sync mode.
 // The connection must already have been switched (by sending the sync command
 // to a specific device), or the return connection will return an error.
@@ -5,27 +5,34 @@ import "os"
 // ADB file modes seem to only be 16 bits.
 // Values are taken from http://linux.die.net/include/bits/stat.h.
 const (
-	ModeDir        uint32 = 0040000
-	ModeSymlink           = 0120000
-	ModeSocket            = 0140000
-	ModeFifo              = 0010000
-	ModeCharDevice        = 0020000
+	// Mask of the bits that hold the file type, S_IFMT.
+	ModeTypeMask    uint32 = 0170000
+	ModeRegular     uint32 = 0100000
+	ModeDir         uint32 = 0040000
+	ModeSymlink     uint32 = 0120000
+	ModeSocket      uint32 = 0140000
+	ModeFifo        uint32 = 0010000
+	ModeCharDevice  uint32 = 0020000
+	ModeBlockDevice uint32 = 0060000
 )
 
 func ParseFileModeFromAdb(modeFromSync uint32) (filemode os.FileMode) {
 	// The ADB filemode uses the permission bits defined in Go's os package, but
-	// we need to parse the other bits manually.
-	switch {
-	case modeFromSync&ModeSymlink == ModeSymlink:
+	// we need to parse the other bits manually. The type is a value, not a set of flags, e.g.
+	// sockets have the directory bit set.
+	switch modeFromSync & ModeTypeMask {
+	case ModeSymlink:
 		filemode = os.ModeSymlink
-	case modeFromSync&ModeDir == ModeDir:
+	case ModeDir:
 		filemode = os.ModeDir
-	case modeFromSync&ModeSocket == ModeSocket:
+	case ModeSocket:
 		filemode = os.ModeSocket
-	case modeFromSync&ModeFifo == ModeFifo:
+	case ModeFifo:
 		filemode = os.ModeNamedPipe
-	case modeFromSync&ModeCharDevice == ModeCharDevice:
-		filemode = os.ModeCharDevice
+	case ModeCharDevice:
+		filemode = os.ModeDevice | os.ModeCharDevice
+	case ModeBlockDevice:
+		filemode = os.ModeDevice
 	}
 
 	filemode |= os.FileMode(modeFromSync).Perm()
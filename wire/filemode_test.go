@@ -0,0 +1,26 @@
+package wire
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileModeFromAdb(t *testing.T) {
+	for _, test := range []struct {
+		Mode uint32
+		Want os.FileMode
+	}{
+		{ModeRegular | 0644, 0644},
+		{ModeDir | 0755, os.ModeDir | 0755},
+		{ModeSymlink | 0777, os.ModeSymlink | 0777},
+		// The socket and block device types overlap the directory bit.
+		{ModeSocket | 0660, os.ModeSocket | 0660},
+		{ModeBlockDevice | 0600, os.ModeDevice | 0600},
+		{ModeCharDevice | 0666, os.ModeDevice | os.ModeCharDevice | 0666},
+		{ModeFifo | 0600, os.ModeNamedPipe | 0600},
+	} {
+		assert.Equal(t, test.Want, ParseFileModeFromAdb(test.Mode), "%o", test.Mode)
+	}
+}
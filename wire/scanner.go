@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/ioutil"
 	"strconv"
+	"time"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
 )
@@ -42,6 +43,9 @@ type Scanner interface {
 	ReadMessage() ([]byte, error)
 	ReadUntilEof() ([]byte, error)
 
+	// Read reads raw, unframed bytes, e.g. the output of a shell: or exec: service.
+	io.Reader
+
 	NewSyncScanner() SyncScanner
 }
 
@@ -77,10 +81,35 @@ func (s *realScanner) ReadUntilEof() ([]byte, error) {
 	return data, nil
 }
 
+func (s *realScanner) Read(p []byte) (int, error) {
+	n, err := s.reader.Read(p)
+	if err != nil && err != io.EOF {
+		return n, errors.WrapErrorf(err, errors.NetworkError, "error reading from scanner")
+	}
+	return n, err
+}
+
 func (s *realScanner) NewSyncScanner() SyncScanner {
 	return NewSyncScanner(s.reader)
 }
 
+// SetDeadline sets the deadline of the underlying reader, if it implements Deadliner.
+func (s *realScanner) SetDeadline(t time.Time) error {
+	if d, ok := s.reader.(Deadliner); ok {
+		return errors.WrapErrorf(d.SetDeadline(t), errors.NetworkError, "error setting deadline")
+	}
+	return nil
+}
+
+// SetReadDeadline sets the read deadline of the underlying reader, if it implements
+// ReadDeadliner.
+func (s *realScanner) SetReadDeadline(t time.Time) error {
+	if d, ok := s.reader.(ReadDeadliner); ok {
+		return errors.WrapErrorf(d.SetReadDeadline(t), errors.NetworkError, "error setting read deadline")
+	}
+	return nil
+}
+
 func (s *realScanner) Close() error {
 	return errors.WrapErrorf(s.reader.Close(), errors.NetworkError, "error closing scanner")
 }
@@ -3,6 +3,7 @@ package wire
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
 )
@@ -11,6 +12,9 @@ import (
 type Sender interface {
 	SendMessage(msg []byte) error
 
+	// Write writes raw, unframed bytes, e.g. the input of an exec: service.
+	io.Writer
+
 	NewSyncSender() SyncSender
 
 	Close() error
@@ -37,10 +41,26 @@ func (s *realSender) SendMessage(msg []byte) error {
 	return writeFully(s.writer, []byte(lengthAndMsg))
 }
 
+func (s *realSender) Write(p []byte) (int, error) {
+	if err := writeFully(s.writer, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 func (s *realSender) NewSyncSender() SyncSender {
 	return NewSyncSender(s.writer)
 }
 
+// SetWriteDeadline sets the write deadline of the underlying writer, if it implements
+// WriteDeadliner.
+func (s *realSender) SetWriteDeadline(t time.Time) error {
+	if d, ok := s.writer.(WriteDeadliner); ok {
+		return errors.WrapErrorf(d.SetWriteDeadline(t), errors.NetworkError, "error setting write deadline")
+	}
+	return nil
+}
+
 func (s *realSender) Close() error {
 	return errors.WrapErrorf(s.writer.Close(), errors.NetworkError, "error closing sender")
 }
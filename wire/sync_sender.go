@@ -21,14 +21,25 @@ type SyncSender interface {
 	// Sends len(data) as an octet, followed by the bytes.
 	// If data is bigger than SyncMaxChunkSize, it returns an assertion error.
 	SendBytes(data []byte) error
+
+	// SendData sends data as a DATA packet with a single write, so consecutive packets don't
+	// wait on each other's headers. If data is bigger than SyncMaxChunkSize, it returns an
+	// assertion error.
+	SendData(data []byte) error
 }
 
+// Size of the ID and length that precede the data of a DATA packet.
+const syncDataHeaderSize = 8
+
 type realSyncSender struct {
 	io.Writer
+
+	// Buffer SendData assembles packets in, allocated on first use.
+	packet []byte
 }
 
 func NewSyncSender(w io.Writer) SyncSender {
-	return &realSyncSender{w}
+	return &realSyncSender{Writer: w}
 }
 
 func (s *realSyncSender) SendOctetString(str string) error {
@@ -71,6 +82,22 @@ func (s *realSyncSender) SendBytes(data []byte) error {
 	return writeFully(s.Writer, data)
 }
 
+func (s *realSyncSender) SendData(data []byte) error {
+	if len(data) > SyncMaxChunkSize {
+		return errors.AssertionErrorf("data must be <= %d in length", SyncMaxChunkSize)
+	}
+	if s.packet == nil {
+		s.packet = make([]byte, syncDataHeaderSize+SyncMaxChunkSize)
+	}
+
+	packet := s.packet[:syncDataHeaderSize+len(data)]
+	copy(packet, StatusSyncData)
+	binary.LittleEndian.PutUint32(packet[4:], uint32(len(data)))
+	copy(packet[syncDataHeaderSize:], data)
+	return errors.WrapErrorf(writeFully(s.Writer, packet),
+		errors.NetworkError, "error sending data on sync sender")
+}
+
 func (s *realSyncSender) Close() error {
 	if closer, ok := s.Writer.(io.Closer); ok {
 		return errors.WrapErrorf(closer.Close(), errors.NetworkError, "error closing sync sender")
@@ -79,3 +79,14 @@ func TestSyncReadBytes(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "hello", string(str))
 }
+
+func TestSyncSendData(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSyncSender(&buf)
+	assert.NoError(t, s.SendData([]byte("hello")))
+	assert.NoError(t, s.SendData([]byte("hi")))
+	assert.Equal(t, "DATA\005\000\000\000helloDATA\002\000\000\000hi", buf.String())
+
+	err := s.SendData(make([]byte, SyncMaxChunkSize+1))
+	assert.Equal(t, errors.AssertionError, errors.CodeOf(err))
+}
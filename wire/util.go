@@ -5,6 +5,7 @@ import (
 	"io"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/zach-klippenstein/goadb/internal/errors"
 )
@@ -22,6 +23,14 @@ type ErrorResponseDetails struct {
 // Old servers send "device not found", and newer ones "device 'serial' not found".
 var deviceNotFoundMessagePattern = regexp.MustCompile(`device( '.*')? not found`)
 
+// deviceOfflineMessagePattern and deviceUnauthorizedMessagePattern match the messages returned
+// for devices that are attached, but can't be used, e.g. "device offline" and
+// "device unauthorized.\nThis adb server's $ADB_VENDOR_KEYS is not set".
+var (
+	deviceOfflineMessagePattern      = regexp.MustCompile(`^device( '.*')? offline`)
+	deviceUnauthorizedMessagePattern = regexp.MustCompile(`^device( '.*')? unauthorized`)
+)
+
 func adbServerError(request string, serverMsg string) error {
 	var msg string
 	if request == "" {
@@ -31,8 +40,13 @@ func adbServerError(request string, serverMsg string) error {
 	}
 
 	errCode := errors.AdbError
-	if deviceNotFoundMessagePattern.MatchString(serverMsg) {
+	switch {
+	case deviceNotFoundMessagePattern.MatchString(serverMsg):
 		errCode = errors.DeviceNotFound
+	case deviceOfflineMessagePattern.MatchString(serverMsg):
+		errCode = errors.DeviceOffline
+	case deviceUnauthorizedMessagePattern.MatchString(serverMsg):
+		errCode = errors.DeviceUnauthorized
 	}
 
 	return &errors.Err{
@@ -49,7 +63,9 @@ func adbServerError(request string, serverMsg string) error {
 // predicate returns true when passed Details.ServerMsg.
 func IsAdbServerErrorMatching(err error, predicate func(string) bool) bool {
 	if err, ok := err.(*errors.Err); ok && err.Code == errors.AdbError {
-		return predicate(err.Details.(ErrorResponseDetails).ServerMsg)
+		if details, ok := err.Details.(ErrorResponseDetails); ok {
+			return predicate(details.ServerMsg)
+		}
 	}
 	return false
 }
@@ -93,6 +109,30 @@ type multiCloseable struct {
 	err       error
 }
 
+// SetDeadline sets the deadline of c, if it implements Deadliner.
+func (c *multiCloseable) SetDeadline(t time.Time) error {
+	if d, ok := c.ReadWriteCloser.(Deadliner); ok {
+		return d.SetDeadline(t)
+	}
+	return nil
+}
+
+// SetReadDeadline sets the read deadline of c, if it implements ReadDeadliner.
+func (c *multiCloseable) SetReadDeadline(t time.Time) error {
+	if d, ok := c.ReadWriteCloser.(ReadDeadliner); ok {
+		return d.SetReadDeadline(t)
+	}
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline of c, if it implements WriteDeadliner.
+func (c *multiCloseable) SetWriteDeadline(t time.Time) error {
+	if d, ok := c.ReadWriteCloser.(WriteDeadliner); ok {
+		return d.SetWriteDeadline(t)
+	}
+	return nil
+}
+
 func (c *multiCloseable) Close() error {
 	c.closeOnce.Do(func() {
 		c.err = c.ReadWriteCloser.Close()
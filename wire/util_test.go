@@ -54,3 +54,17 @@ func TestAdbServerError_DeviceSerialNotFound(t *testing.T) {
 		},
 	}, *(err.(*errors.Err)))
 }
+
+func TestAdbServerError_DeviceOffline(t *testing.T) {
+	err := adbServerError("host:transport:emulator-5554", "device offline")
+	assert.True(t, errors.HasErrCode(err, errors.DeviceOffline))
+
+	err = adbServerError("host:transport:emulator-5554", "device 'emulator-5554' offline")
+	assert.True(t, errors.HasErrCode(err, errors.DeviceOffline))
+}
+
+func TestAdbServerError_DeviceUnauthorized(t *testing.T) {
+	err := adbServerError("host:transport-any",
+		"device unauthorized.\nThis adb server's $ADB_VENDOR_KEYS is not set\n")
+	assert.True(t, errors.HasErrCode(err, errors.DeviceUnauthorized))
+}